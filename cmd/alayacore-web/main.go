@@ -4,47 +4,73 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/alayacore/alayacore/internal/adaptors/stdio"
 	"github.com/alayacore/alayacore/internal/adaptors/websocket"
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/app"
 	"github.com/alayacore/alayacore/internal/config"
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/llm/factory"
+	"github.com/alayacore/alayacore/internal/logging"
 )
 
 func main() {
-	cfg := config.Parse()
+	cfg, err := config.Parse()
+	if err != nil {
+		exit(domainerrors.ConfigError(err))
+	}
 
 	if cfg.ShowVersion {
 		fmt.Printf("alayacore-web version %s\n", config.Version)
 		os.Exit(0)
 	}
 
+	if cfg.ListProviders {
+		fmt.Print(factory.FormatTypes())
+		os.Exit(0)
+	}
+
 	if cfg.ShowHelp {
 		printHelp()
 		os.Exit(1)
 	}
 
+	// Log collectors of servers read JSON
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = logging.FormatJSON
+	}
 	appCfg, err := app.Setup(cfg)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		exit(domainerrors.ConfigError(err))
 	}
 
 	// Load model from config file
 	modelManager := agentpkg.NewModelManager(cfg.ModelConfig)
 	if !modelManager.HasModels() {
 		modelPath := modelManager.GetFilePath()
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Error: No models configured.")
-		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "Please edit the model config file:")
 		fmt.Fprintf(os.Stderr, "  %s\n", modelPath)
-		fmt.Fprintln(os.Stderr, "")
-		os.Exit(1)
+		exit(domainerrors.ConfigError(fmt.Errorf("no models configured in %s", modelPath)))
 	}
 
 	// The session will load the model from config when it starts
 	// No need to set appCfg.Provider here
 
+	if cfg.Stdio {
+		// stdout carries only frames; failures end up on stderr and in the
+		// exit code
+		if err := stdio.NewAdaptor(appCfg, cfg.Handshake, os.Stdin, os.Stdout).Run(); err != nil {
+			exit(err)
+		}
+		os.Exit(domainerrors.ExitOK)
+	}
+
+	if cfg.WebRoot != "" {
+		if info, err := os.Stat(cfg.WebRoot); err != nil || !info.IsDir() {
+			exit(domainerrors.ConfigError(fmt.Errorf("--web-root %s is not a directory", cfg.WebRoot)))
+		}
+	}
+
 	port := cfg.Addr
 	if port == "" {
 		port = ":8080"
@@ -58,22 +84,104 @@ func main() {
 	select {}
 }
 
+// exit prints a one-line summary of err to stderr and exits with its code.
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, domainerrors.Summary(err))
+	os.Exit(domainerrors.ExitCode(err))
+}
+
 func printHelp() {
 	fmt.Print(`AlayaCore Web - A WebSocket server for AlayaCore
 
 Usage:
   alayacore-web [flags]
+  alayacore-web --stdio [flags]
 
 Flags:
   --model-config string   Model config file path (default: ~/.alayacore/model.conf)
   --runtime-config string Runtime config file path (default: ~/.alayacore/runtime.conf)
   --system string         Extra system prompt (can be specified multiple times)
   --skill strings         Skills directory path (can be specified multiple times)
+  --no-default-skills     Do not scan ~/.alayacore/skills and ./.alayacore/skills
+  --watch-skills          Reload the skills when a SKILL.md changes (see :skills reload)
   --addr string           Server address to listen on (default: ":8080")
+  --ping-interval duration
+                          Keepalive ping interval; clients missing two pongs are dropped (default: 30s)
+  --max-connections int   Maximum concurrent connections, 0 for no limit (default: 32)
+  --max-connections-per-ip int
+                          Maximum concurrent connections from one IP, 0 for no limit (default: 4)
+  --prompt-rate int       Maximum prompts per minute per connection, 0 for no limit (default: 10)
+  --session-ttl duration  Close sessions idle for this long, after a warning a minute before;
+                          0 never closes them (default: 0)
+  --transcript-dir string Where --session-ttl saves closed sessions, off to not save them
+                          (default: ~/.alayacore/transcripts)
+  --metrics               Serve Prometheus metrics at /metrics
+  --web-root string       Directory to serve at / instead of the built-in chat UI
+  --stdio                 Speak the TLV protocol on stdin and stdout instead of serving WebSocket,
+                          for editor integrations; exits when stdin ends and queued tasks finish
+  --handshake             With --stdio, send the protocol hello (HI) frame first
   --session string        Session file path to load/save conversations
   --proxy string          HTTP proxy URL (e.g., http://127.0.0.1:7890 or socks5://127.0.0.1:1080)
+  --header string         Add "Key: Value" to every provider request (can be specified multiple times)
+  --extra-body string     JSON object whose fields are added to every provider request body
+  --azure-endpoint string
+                          Azure OpenAI resource endpoint for azure models without a base_url
+  --azure-deployment string
+                          Azure OpenAI deployment for azure models without model_name or deployment
+  --azure-api-version string
+                          Azure OpenAI api-version for azure models without api_version (default: 2024-10-21)
   --max-steps int         Maximum agent loop steps (default: 100)
+  --temperature float     Sampling temperature, >= 0 (default: provider default)
+  --top-p float           Nucleus sampling top_p, in (0, 1] (default: provider default)
+  --max-output-tokens int Maximum tokens per response (default: provider default)
+  --reasoning-effort string
+                          Reasoning effort: low, medium, or high (OpenAI-compatible models only)
+  --thinking-budget-tokens int
+                          Extended thinking budget, >= 1024 (Anthropic models only)
+  --context-warning float Warn when a prompt's estimated size exceeds this fraction of the context window (default: 0.8, 0 disables)
+  --stall-warning duration
+                          Warn when the provider sends nothing for this long during a prompt (default: 30s, 0 disables)
+  --stream-stall-timeout duration
+                          Cancel a request whose stream sends nothing for this long, and continue or
+                          report it (default: 2m0s, 0 disables)
+  --auto-continue int     Continue a reply cut off at the output token limit up to this many times
+                          per prompt (default: 3, 0 disables)
+  --notify string         Notify when a long prompt finishes in a hidden tab: off, or bell/desktop
+                          for a browser notification (default: desktop)
+  --notify-after duration Only notify for prompts that run at least this long (default: 30s, 0 disables)
+  --no-context-recovery   Report context-length errors instead of summarizing and retrying once
+  --pricing-file string   JSON file of model prices in USD per million tokens, added to the built-in table
+  --read-max-lines int    Maximum lines one read_file call returns (default: 2000)
+  --read-max-bytes int    Maximum bytes one read_file call returns (default: 262144)
+  --parallel-tools        Run consecutive read_file calls of one step concurrently
+  --review-edits          Ask before the file tools write a change, showing its diff
+  --review-timeout duration
+                          Reject a change nobody reviewed within this long (default: 10m)
+  --secret-scan string    Prompts that look like they hold credentials: asked about unless off;
+                          batch mode blocks, warns, or sends them (block, warn, off; default: block)
+  --no-exec               Remove the tools that run programs (posix_shell, git), for untrusted deployments
+  --dry-run               Describe commands and file changes instead of making them (see :dryrun)
+  --timestamps            Show the time of each prompt and tool call (see :time)
+  --memory                Give the model a persistent memory shared by all sessions (see :memory)
+  --shell string          Shell for posix_shell: sh, bash, or a path (default: /bin/sh; sh on Windows)
+  --enable-tools string   Comma-separated tools to enable (default: all)
+  --disable-tools string  Comma-separated tools to disable
+  --allow-path string     Restrict file tools to this path or glob (can be specified multiple times)
+  --deny-path string      Deny file tools this path or glob; overrides --allow-path (can be specified multiple times)
+  --safe-mode             Deny file tools access to SSH keys, cloud credentials, and shell history
+  --env-inherit string    Environment for posix_shell: filtered (default) drops *KEY, *TOKEN,
+                          *SECRET, *PASSWORD, ... variables; all passes everything
+  --env-allow string      Pass this variable or pattern to posix_shell anyway (repeatable)
+  --env-deny string       Never pass this variable or pattern to posix_shell (repeatable)
+  --hooks string          Hook file: shell commands for pre_tool, post_tool, on_prompt_start, on_turn_end
+  --formatter string      Format written files by extension, e.g. ".go=gofmt -w" (repeatable)
+  --command-rule string   Allow, deny, or ask about posix_shell commands, e.g. "deny sudo" (repeatable)
   --debug-api             Write raw API requests and responses to log file
+  --verbose               Show agent lifecycle events: steps, tool invocations, and usage
+  --debug-log-dir string  Directory for debug log files (default: $XDG_STATE_HOME/alayacore or ~/.alayacore/logs)
+  --log-level string      Log level on stderr: debug, info, warn, or error (default: warn)
+  --log-format string     Log format: text or json (default: json)
+  --list-providers        List the protocol_type values model.conf accepts
   --version               Show version information
   --help                  Show help information
 `)
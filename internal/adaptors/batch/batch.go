@@ -0,0 +1,85 @@
+// Package batch runs the prompts of a file in one session without the
+// terminal UI, for alayacore --batch.
+//
+// The prompts of the file are separated by lines holding only "---" (see
+// agent.SplitBatch). They run one after another, each seeing the answers to
+// those before it. Their output is streamed as plain text: the echoed
+// prompts, the assistant's answers, the tools called, and the notices and
+// errors of the session. A summary table of the status, duration, and tokens
+// of each prompt follows. With --json the output goes to stderr and stdout
+// gets only a JSON array of the results (see agent.PromptResult).
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/app"
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// Adaptor runs one batch file.
+type Adaptor struct {
+	Config          *app.Config
+	Path            string // the batch file
+	ContinueOnError bool   // run the prompts after one that failed
+	JSON            bool   // print the results as JSON instead of a table
+
+	out    io.Writer // the summary, or the JSON results
+	stream io.Writer // the output of the prompts
+}
+
+// NewAdaptor creates an Adaptor for the batch file at path, writing to out
+// and, with jsonOut, the output of the prompts to errOut.
+func NewAdaptor(cfg *app.Config, path string, continueOnError, jsonOut bool, out, errOut io.Writer) *Adaptor {
+	a := &Adaptor{Config: cfg, Path: path, ContinueOnError: continueOnError, JSON: jsonOut, out: out, stream: out}
+	if jsonOut {
+		a.stream = errOut
+	}
+	return a
+}
+
+// Run runs the prompts of the batch file and prints their results. It
+// returns the first prompt failure, for alayacore to exit with its code, or
+// a config error when the file cannot be read or holds no prompt.
+func (a *Adaptor) Run() error {
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return domainerrors.ConfigError(fmt.Errorf("failed to read batch file: %w", err))
+	}
+	prompts := agentpkg.SplitBatch(string(data))
+	if len(prompts) == 0 {
+		return domainerrors.ConfigError(fmt.Errorf("batch file %s holds no prompt", a.Path))
+	}
+
+	cfg := a.Config
+	input := stream.NewChanInput(10)
+	output := newTextOutput(a.stream)
+	// Nobody is there to answer a question or to see a notification
+	opts := cfg.SessionOptions()
+	opts.SkillReloader = nil
+	opts.NotifyAfter = 0
+	opts.AskSecrets = false
+	session, _ := agentpkg.LoadOrNewSession(input, output, opts)
+
+	// The input stays open until the last prompt has run, or the session
+	// would stop once its queue is empty
+	results := session.RunBatch(prompts, a.ContinueOnError)
+	input.Close()
+	session.Wait()
+
+	if a.JSON {
+		encoded, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(a.out, string(encoded))
+	} else {
+		fmt.Fprintf(a.out, "\n%s\n", agentpkg.FormatBatchSummary(results))
+	}
+	return session.Err()
+}
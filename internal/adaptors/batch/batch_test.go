@@ -0,0 +1,56 @@
+package batch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/app"
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestRunRejectsEmptyBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(path, []byte("\n---\n  \n---\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := NewAdaptor(&app.Config{}, path, false, false, &out, &out).Run()
+	if got := domainerrors.ExitCode(err); got != domainerrors.ExitConfig {
+		t.Errorf("exit code = %d (%v), want %d", got, err, domainerrors.ExitConfig)
+	}
+}
+
+func TestTextOutput(t *testing.T) {
+	var out bytes.Buffer
+	o := newTextOutput(&out)
+	frames := [][2]string{
+		{stream.TagTextUser, "#1 ▸ list the files"},
+		{stream.TagSystemData, `{"context":10}`},
+		{stream.TagTextAssistant, "[:a1:]Let me "},
+		{stream.TagTextAssistant, "[:a1:]look."},
+		{stream.TagFunctionCall, `{"id":"c1","name":"posix_shell","input":"{}"}`},
+		{stream.TagFunctionResult, `{"id":"c1","output":"main.go"}`},
+		{stream.TagTextAssistant, "[:a2:]There is main.go."},
+		{stream.TagSystemNotify, "#1 done, 12 tokens, 0.5s"},
+		{stream.TagSystemError, "rate limited"},
+	}
+	var encoded []byte
+	for _, f := range frames {
+		encoded = append(encoded, stream.EncodeTLV(f[0], f[1])...)
+	}
+	// Frames split across writes are joined
+	if _, err := o.Write(encoded[:9]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Write(encoded[9:]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#1 ▸ list the files\nLet me look.\n→ posix_shell\nThere is main.go.\n#1 done, 12 tokens, 0.5s\nerror: rate limited\n"
+	if got := out.String(); got != want {
+		t.Errorf("output =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/adaptors/terminal"
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// textOutput decodes the TLV output of the session and writes it as plain
+// text. Frames the text has no place for, such as system data and tool
+// results, are dropped.
+type textOutput struct {
+	mu       sync.Mutex
+	w        io.Writer
+	buffer   []byte
+	inAnswer bool   // an answer is being written
+	lastID   string // its stream ID
+}
+
+func newTextOutput(w io.Writer) *textOutput {
+	return &textOutput{w: w}
+}
+
+func (o *textOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buffer = append(o.buffer, p...)
+	for len(o.buffer) >= 6 {
+		tag, length, err := stream.ParseHeader(o.buffer)
+		if err != nil {
+			o.buffer = stream.SkipToHeader(o.buffer)
+			continue
+		}
+		if len(o.buffer) < 6+length {
+			break
+		}
+		o.record(tag, string(o.buffer[6:6+length]))
+		o.buffer = o.buffer[6+length:]
+	}
+	return len(p), nil
+}
+
+func (o *textOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+func (o *textOutput) Flush() error {
+	return nil
+}
+
+// record writes one frame. The deltas of an answer continue its text; any
+// other frame starts a new line.
+func (o *textOutput) record(tag, value string) {
+	if tag == stream.TagTextAssistant {
+		id, content, _ := terminal.ParseStreamID(value)
+		if !o.inAnswer || id != o.lastID {
+			o.endAnswer()
+			o.inAnswer, o.lastID = true, id
+		}
+		fmt.Fprint(o.w, tools.EscapeControls(content))
+		return
+	}
+
+	var line string
+	switch tag {
+	case stream.TagTextUser, stream.TagSystemNotify:
+		line = value
+	case stream.TagSystemError:
+		line = "error: " + value
+	case stream.TagFunctionCall:
+		var call terminal.ToolCallData
+		if json.Unmarshal([]byte(value), &call) != nil {
+			return
+		}
+		line = "→ " + call.Name
+	default:
+		return
+	}
+	o.endAnswer()
+	// Answers, tool names, and notices quoting them come from the model
+	fmt.Fprintln(o.w, tools.EscapeControls(line))
+}
+
+// endAnswer ends the line of the answer being written, if any.
+func (o *textOutput) endAnswer() {
+	if o.inAnswer {
+		fmt.Fprintln(o.w)
+		o.inAnswer = false
+	}
+}
@@ -0,0 +1,147 @@
+// Package stdio runs a session over standard input and output, for editor
+// integrations that start alayacore-web --stdio as a subprocess.
+//
+// The protocol is the TLV protocol of /ws (see /protocol.json) without the
+// WebSocket: frames are read from stdin and written to stdout, one write per
+// frame, flushed at once. Nothing else is written to stdout. With
+// --handshake the first frame is the server's HI hello, and HI frames from
+// the client are negotiated as over /ws; a failed negotiation is reported as
+// an SE error and ends the run.
+//
+// When stdin ends the session finishes the tasks already queued and Run
+// returns, so piping a prompt in and closing stdin gets its full answer.
+// Send :cancel_all before closing stdin to stop early. Run then returns the
+// first prompt failure, for alayacore-web to exit with its code (see
+// domainerrors.ExitCode).
+package stdio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/adaptors/websocket"
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// Adaptor runs one session over a pair of streams.
+type Adaptor struct {
+	Config    *app.Config
+	Handshake bool // send the HI hello first and negotiate client hellos
+
+	in  io.Reader
+	out io.Writer
+}
+
+// NewAdaptor creates an Adaptor reading frames from in and writing them to
+// out, usually os.Stdin and os.Stdout.
+func NewAdaptor(cfg *app.Config, handshake bool, in io.Reader, out io.Writer) *Adaptor {
+	return &Adaptor{Config: cfg, Handshake: handshake, in: in, out: out}
+}
+
+// Run runs the session until the input ends and its queued tasks finish. It
+// returns an error when reading fails, the handshake does, or a prompt
+// failed; a failed prompt is a *domainerrors.RunError.
+func (a *Adaptor) Run() error {
+	cfg := a.Config
+	input := stream.NewChanInput(100)
+	output := newFrameOutput(a.out)
+	if a.Handshake {
+		_ = stream.WriteTLV(output, stream.TagHello, string(websocket.ServerHello())) //nolint:errcheck // a closed stdout ends the run anyway
+	}
+
+	session, _ := agentpkg.LoadOrNewSession(input, output, cfg.SessionOptions())
+
+	var protocolType, modelName, baseURL string
+	if model := session.ModelManager.GetActive(); model != nil {
+		protocolType, modelName, baseURL = model.ProtocolType, model.ModelName, model.BaseURL
+	}
+	_ = stream.WriteTLV(output, stream.TagSystemNotify, cfg.RuntimeSummary(protocolType, modelName, baseURL)) //nolint:errcheck // best-effort welcome message
+
+	err := a.readFrames(input, output)
+	if err != nil {
+		// Nothing more will arrive, so do not keep calling the model
+		_ = input.EmitTLV(stream.TagTextUser, ":cancel_all") //nolint:errcheck // best-effort cleanup
+	}
+	input.Close()
+	session.Wait()
+	if err != nil {
+		return err
+	}
+	return session.Err()
+}
+
+// readFrames forwards frames from the input to the session until EOF.
+// Malformed frames are reported and skipped.
+func (a *Adaptor) readFrames(input *stream.ChanInput, output stream.Output) error {
+	frames := stream.NewFrameReader(a.in, stream.WithMaxFrameSize(a.Config.Cfg.MaxFrameSize))
+	for {
+		tag, value, err := frames.Next()
+		if errors.Is(err, stream.ErrMalformedFrame) {
+			a.logger().Warn("dropped malformed frame", "err", err)
+			_ = stream.WriteTLV(output, stream.TagSystemError, "dropped input: "+err.Error()) //nolint:errcheck // best-effort notice
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			a.logger().Error("reading input failed", "err", err)
+			return err
+		}
+
+		if tag == stream.TagHello && a.Handshake {
+			version, err := websocket.Negotiate(value)
+			if err != nil {
+				a.logger().Warn("protocol negotiation failed", "err", err)
+				_ = stream.WriteTLV(output, stream.TagSystemError, err.Error()) //nolint:errcheck // ending anyway
+				return err
+			}
+			_ = stream.WriteTLV(output, stream.TagHello, fmt.Sprintf(`{"protocol":%d}`, version)) //nolint:errcheck // a closed stdout ends the run anyway
+			continue
+		}
+		_ = input.EmitTLV(tag, value) //nolint:errcheck // the session reads until the input closes
+	}
+}
+
+// logger returns the logger of the app config, or one that drops records.
+func (a *Adaptor) logger() *slog.Logger {
+	return logging.OrDiscard(a.Config.Logger).With("component", "stdio")
+}
+
+// frameOutput writes each frame to the underlying writer in one piece and
+// flushes it at once, so a reader never waits on a buffered frame.
+type frameOutput struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func newFrameOutput(w io.Writer) *frameOutput {
+	return &frameOutput{w: bufio.NewWriter(w)}
+}
+
+func (o *frameOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n, err := o.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, o.w.Flush()
+}
+
+func (o *frameOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+func (o *frameOutput) Flush() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Flush()
+}
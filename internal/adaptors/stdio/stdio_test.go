@@ -0,0 +1,112 @@
+package stdio
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/config"
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/skills"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newTestConfig(t *testing.T) *app.Config {
+	t.Helper()
+	dir := t.TempDir()
+	modelConfig := filepath.Join(dir, "model.conf")
+	if err := os.WriteFile(modelConfig, []byte(agentpkg.DefaultModelConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	skillsMgr, err := skills.NewManager(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &app.Config{
+		Cfg: &config.Settings{
+			ModelConfig:   modelConfig,
+			RuntimeConfig: filepath.Join(dir, "runtime.conf"),
+		},
+		SkillsMgr: skillsMgr,
+		MaxSteps:  10,
+	}
+}
+
+// frames decodes everything written to out.
+func frames(t *testing.T, out []byte) map[string][]string {
+	t.Helper()
+	got := make(map[string][]string)
+	r := stream.NewFrameReader(bytes.NewReader(out))
+	for {
+		tag, value, err := r.Next()
+		if err != nil {
+			return got
+		}
+		got[tag] = append(got[tag], value)
+	}
+}
+
+func TestRunEndsOnEOF(t *testing.T) {
+	in := bytes.NewBuffer(nil)
+	in.Write([]byte("\x00\x01junk"))
+	in.Write(stream.EncodeTLV(stream.TagTextUser, ":stats"))
+	var out, log bytes.Buffer
+	cfg := newTestConfig(t)
+	cfg.Logger = logging.New(&log, logging.FormatText, slog.LevelWarn)
+	if err := NewAdaptor(cfg, false, in, &out).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(log.String(), `level=WARN msg="dropped malformed frame" component=stdio`) {
+		t.Errorf("log = %q, want the dropped junk", log.String())
+	}
+
+	got := frames(t, out.Bytes())
+	if len(got[stream.TagHello]) != 0 {
+		t.Error("hello sent without --handshake")
+	}
+	if len(got[stream.TagSystemError]) == 0 || !strings.HasPrefix(got[stream.TagSystemError][0], "dropped input: ") {
+		t.Errorf("errors = %q, want the dropped junk", got[stream.TagSystemError])
+	}
+	if len(got[stream.TagTurnEnd]) == 0 {
+		t.Error("the queued command did not finish before Run returned")
+	}
+}
+
+func TestRunHandshake(t *testing.T) {
+	in := bytes.NewReader(stream.EncodeTLV(stream.TagHello, `{"protocol":9,"min_protocol":8}`))
+	var out bytes.Buffer
+	err := NewAdaptor(newTestConfig(t), true, in, &out).Run()
+	if err == nil || !strings.Contains(err.Error(), "no common protocol version") {
+		t.Errorf("Run() error = %v, want a failed negotiation", err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte(stream.TagHello)) {
+		t.Error("the hello is not the first frame")
+	}
+}
+
+func TestRunReturnsPromptFailure(t *testing.T) {
+	cfg := newTestConfig(t)
+	broken := strings.Replace(agentpkg.DefaultModelConfig, `protocol_type: "anthropic"`, `protocol_type: "carrier-pigeon"`, 1)
+	if err := os.WriteFile(cfg.Cfg.ModelConfig, []byte(broken), 0600); err != nil {
+		t.Fatal(err)
+	}
+	in := bytes.NewReader(stream.EncodeTLV(stream.TagTextUser, "hello"))
+	var out, log bytes.Buffer
+	cfg.Logger = logging.New(&log, logging.FormatJSON, slog.LevelInfo)
+	err := NewAdaptor(cfg, false, in, &out).Run()
+	if got := domainerrors.ExitCode(err); got != domainerrors.ExitConfig {
+		t.Errorf("exit code = %d (%v), want %d", got, err, domainerrors.ExitConfig)
+	}
+	if got := frames(t, out.Bytes())[stream.TagSystemError]; len(got) == 0 {
+		t.Error("the failure is not reported on the output")
+	}
+	if got := log.String(); !strings.Contains(got, `"level":"ERROR","msg":"prompt failed","component":"session","class":"config"`) {
+		t.Errorf("log = %q, want the failed prompt", got)
+	}
+}
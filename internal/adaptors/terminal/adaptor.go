@@ -6,12 +6,14 @@ package terminal
 import (
 	"fmt"
 	"os"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"golang.org/x/term"
 
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/logging"
 	"github.com/alayacore/alayacore/internal/stream"
 )
 
@@ -38,30 +40,50 @@ func NewAdaptorWithThemes(cfg *app.Config, themesFolder string) *Adaptor {
 
 // Start runs the Terminal program.
 func (a *Adaptor) Start() {
+	logger := logging.OrDiscard(a.Config.Logger)
+
 	// Create theme manager
 	themeManager := NewThemeManager(a.ThemesFolder)
 
 	inputStream := stream.NewChanInput(10)
-	terminalOutput := NewTerminalOutput(NewStyles(DefaultTheme()))
 
 	// Get terminal size before loading session (so session loads with correct dimensions)
 	initialWidth, initialHeight := getTerminalSize()
-	terminalOutput.SetWindowWidth(initialWidth)
+	terminalOutput := a.newOutput(initialWidth)
+	profile := colorProfile(a.Config.Cfg.Color, os.Stdout, os.Environ())
+
+	// Offer the setup wizard before the model config is loaded, which would
+	// otherwise fill a missing file with the default config
+	var setupModel agentpkg.ModelConfig
+	var setupSaved, setupDone bool
+	if path, err := agentpkg.ResolveModelConfigPath(a.Config.Cfg.ModelConfig); err == nil {
+		setupModel, setupSaved, setupDone = runSetupWizard(path, a.Config.Cfg.Proxy, profile)
+	}
+
+	// Open the transcript before the session restores its history into it
+	var tr *transcript
+	if dir := a.Config.Cfg.TranscriptDirectory(); dir != "" {
+		var err error
+		if tr, err = openTranscript(dir, time.Now); err != nil {
+			logger.Warn("transcript disabled", "err", err)
+		} else {
+			terminalOutput.SetTranscript(tr)
+		}
+	}
 
 	// Load session synchronously before starting the UI
-	session, _ := agentpkg.LoadOrNewSession(
-		a.Config.AgentTools,
-		a.Config.SystemPrompt,
-		a.Config.ExtraSystemPrompt,
-		a.Config.MaxSteps,
-		inputStream,
-		terminalOutput,
-		a.Config.Cfg.Session,
-		a.Config.Cfg.ModelConfig,
-		a.Config.Cfg.RuntimeConfig,
-		a.Config.Cfg.DebugAPI,
-		a.Config.Cfg.Proxy,
-	)
+	session := a.loadSession(inputStream, terminalOutput, a.Config.Cfg.Session)
+
+	// A model the user chose not to save is used for this run only
+	useModel := func(session *agentpkg.Session) {
+		if setupDone && !setupSaved {
+			id := session.ModelManager.AddModel(setupModel)
+			if err := session.ModelManager.SetActive(id); err == nil {
+				_ = session.SwitchModel(session.ModelManager.GetModel(id)) //nolint:errcheck // errors surface on the first prompt
+			}
+		}
+	}
+	useModel(session)
 
 	// Load active theme from runtime.conf (default to "theme-dark" if not set)
 	activeThemeName := session.GetRuntimeManager().GetActiveTheme()
@@ -105,10 +127,55 @@ context_limit: 128000`)
 
 	// Create terminal with loaded session, initial window size, theme, and theme manager
 	t := NewTerminalWithTheme(session, terminalOutput, inputStream, a.Config, initialWidth, initialHeight, theme, themeManager)
+	t.SetMouse(!a.Config.Cfg.NoMouse)
+	t.SetNotify(a.Config.Cfg.Notify, notifyTitle(a.Config.Cfg.Session))
+	t.SetNewTab(func(width int, styles *Styles) (*agentpkg.Session, OutputWriter, *stream.ChanInput) {
+		input := stream.NewChanInput(10)
+		output := a.newOutput(width)
+		output.SetStyles(styles)
+		// Only the first session is saved to the session file
+		session := a.loadSession(input, output, "")
+		useModel(session)
+		return session, output, input
+	})
+
+	// Create and run the program. Log records would garble the screen, so
+	// they are held until it exits.
+	p := tea.NewProgram(t, tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout), tea.WithColorProfile(profile))
+	a.Config.LogOutput.Hold()
+	_, err := p.Run()
+	_ = a.Config.LogOutput.Release() //nolint:errcheck // stderr is all there is to report it on
+	if err != nil {
+		logger.Error("terminal UI failed", "err", err)
+	}
+
+	if tr != nil {
+		tr.Footer(session.StatsSummary())
+		if err := tr.Close(); err != nil {
+			logger.Error("failed to save transcript", "path", tr.Path(), "err", err)
+		} else {
+			fmt.Printf("Transcript saved to %s\n", tr.Path())
+		}
+	}
+}
+
+// newOutput creates the output writer of a session, rendering at width.
+func (a *Adaptor) newOutput(width int) *outputWriter {
+	output := NewTerminalOutput(NewStyles(DefaultTheme()))
+	output.SetWindowWidth(width)
+	output.WindowBuffer().SetHighlight(!a.Config.Cfg.NoHighlight)
+	output.WindowBuffer().SetRender(a.Config.Cfg.Render)
+	output.WindowBuffer().SetShowReasoning(a.Config.Cfg.ShowReasoning)
+	return output
+}
 
-	// Create and run the program
-	p := tea.NewProgram(t, tea.WithInput(os.Stdin), tea.WithOutput(os.Stdout))
-	_, _ = p.Run() //nolint:errcheck // terminal program run, error not critical
+// loadSession loads the session in sessionFile, or starts a new one when it
+// is empty or missing, configured from the app config.
+func (a *Adaptor) loadSession(input *stream.ChanInput, output *outputWriter, sessionFile string) *agentpkg.Session {
+	opts := a.Config.SessionOptions()
+	opts.SessionFile = sessionFile
+	session, _ := agentpkg.LoadOrNewSession(input, output, opts)
+	return session
 }
 
 // getTerminalSize returns the current terminal size, or defaults if not a TTY.
@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func ctrlG() tea.KeyPressMsg {
+	return tea.KeyPressMsg(tea.Key{Code: 'g', Mod: tea.ModCtrl})
+}
+
+func TestDoubleCtrlGCancelsAll(t *testing.T) {
+	input := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), input, nil, 80, 24)
+
+	terminal.Update(ctrlG())
+	if !terminal.cancelConfirmDialog {
+		t.Fatal("first Ctrl+G should open the cancel confirmation")
+	}
+	terminal.Update(ctrlG())
+	if terminal.cancelConfirmDialog {
+		t.Error("second Ctrl+G should close the confirmation")
+	}
+
+	tag, value, err := stream.ReadTLV(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != stream.TagTextUser || value != ":cancel_all" {
+		t.Errorf("got %s %q, want %s \":cancel_all\"", tag, value, stream.TagTextUser)
+	}
+}
+
+func TestSlowSecondCtrlGKeepsConfirmation(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	terminal.Update(ctrlG())
+	terminal.lastCancelKey = time.Now().Add(-2 * DoubleCancelWindow)
+	terminal.Update(ctrlG())
+	if !terminal.cancelConfirmDialog {
+		t.Error("a slow second Ctrl+G should leave the confirmation open")
+	}
+}
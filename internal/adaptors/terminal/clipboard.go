@@ -0,0 +1,95 @@
+package terminal
+
+// Copying the last response.
+// :copy and Ctrl+Y on the display put the latest assistant response on the
+// clipboard as plain text. The text goes out as an OSC 52 escape sequence,
+// which reaches the local clipboard even over SSH, and also through
+// pbcopy, wl-copy, xclip, or xsel when one is installed, for terminals that
+// ignore OSC 52.
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// responseTracker keeps the plain text of the latest assistant response:
+// every text step of the most recent prompt, without ANSI codes.
+type responseTracker struct {
+	prompt string // prompt ID of the tracked response
+	stream string // stream ID of the step being appended to
+	text   strings.Builder
+}
+
+// add appends a text delta. Stream IDs have the form <prompt>-<step>-t; a
+// delta for a new prompt starts a new response.
+func (r *responseTracker) add(streamID, delta string) {
+	prompt, _, _ := strings.Cut(streamID, "-")
+	switch {
+	case prompt != r.prompt:
+		r.prompt = prompt
+		r.text.Reset()
+	case streamID != r.stream && r.text.Len() > 0:
+		r.text.WriteString("\n\n")
+	}
+	r.stream = streamID
+	r.text.WriteString(stripANSI(delta))
+}
+
+// String returns the tracked response.
+func (r *responseTracker) String() string {
+	return strings.TrimSpace(r.text.String())
+}
+
+// clipboardCommands are tried in order; the first one installed is used.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+// nativeClipboardCommand returns the installed clipboard command, or nil.
+func nativeClipboardCommand() []string {
+	for _, cmd := range clipboardCommands {
+		if _, err := exec.LookPath(cmd[0]); err == nil {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// copyLastResponse copies the latest assistant response to the clipboard.
+func (m *Terminal) copyLastResponse() tea.Cmd {
+	text := m.out.LastResponse()
+	if text == "" {
+		m.out.WriteNotify("No response to copy yet")
+		return nil
+	}
+	m.out.WriteNotify("Copied " + formatByteSize(len(text)) + " to the clipboard")
+	cmds := []tea.Cmd{tea.SetClipboard(text)}
+	if native := nativeClipboardCommand(); native != nil {
+		cmds = append(cmds, func() tea.Msg {
+			c := exec.Command(native[0], native[1:]...)
+			c.Stdin = strings.NewReader(text)
+			// OSC 52 still applies when this fails, e.g. without a display
+			_ = c.Run() //nolint:errcheck // best-effort fallback
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// formatByteSize formats a byte count as e.g. "512 B" or "1.4 KB".
+func formatByteSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
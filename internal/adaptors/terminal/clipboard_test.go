@@ -0,0 +1,76 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func writeTLV(out *outputWriter, tag, value string) {
+	_, _ = out.Write(stream.EncodeTLV(tag, value)) //nolint:errcheck // test helper
+}
+
+func TestLastResponseTracksLatestPrompt(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	if got := out.LastResponse(); got != "" {
+		t.Fatalf("LastResponse before any response = %q", got)
+	}
+
+	writeTLV(out, stream.TagTextAssistant, "[:1-1-t:]First ")
+	writeTLV(out, stream.TagTextAssistant, "[:1-1-t:]answer")
+	if got := out.LastResponse(); got != "First answer" {
+		t.Errorf("LastResponse = %q, want the streamed deltas joined", got)
+	}
+
+	// A new prompt replaces the response; its steps are separated by a blank line
+	writeTLV(out, stream.TagTextAssistant, "[:2-1-t:]Let me look.")
+	writeTLV(out, stream.TagTextReasoning, "[:2-2-r:]thinking")
+	writeTLV(out, stream.TagFunctionCall, `{"id":"c1","name":"read_file","input":"{}"}`)
+	writeTLV(out, stream.TagTextAssistant, "[:2-2-t:]It is \x1b[1mfine\x1b[0m.")
+	if got, want := out.LastResponse(), "Let me look.\n\nIt is fine."; got != want {
+		t.Errorf("LastResponse = %q, want %q", got, want)
+	}
+}
+
+func TestCopyWithoutResponse(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	terminal.input.SetValue(":copy")
+
+	if cmd := terminal.handleSubmit(); cmd != nil {
+		t.Error("nothing should be copied before the first response")
+	}
+	if !containsSubstring(out.WindowBuffer().GetAll(-1), "No response to copy yet") {
+		t.Error("expected a notice that there is nothing to copy")
+	}
+	if terminal.input.Value() != "" {
+		t.Errorf("input should be cleared, got %q", terminal.input.Value())
+	}
+}
+
+func TestCtrlYCopiesFromDisplay(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	writeTLV(out, stream.TagTextAssistant, "[:1-1-t:]"+strings.Repeat("x", 1434))
+	terminal.focusDisplay()
+
+	_, cmd := terminal.Update(tea.KeyPressMsg(tea.Key{Code: 'y', Mod: tea.ModCtrl}))
+	if cmd == nil {
+		t.Fatal("Ctrl+Y on the display should return a clipboard command")
+	}
+	if !containsSubstring(out.WindowBuffer().GetAll(-1), "Copied 1.4 KB to the clipboard") {
+		t.Error("expected a copy confirmation")
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int]string{0: "0 B", 512: "512 B", 1434: "1.4 KB", 3 << 20: "3.0 MB"}
+	for n, want := range tests {
+		if got := formatByteSize(n); got != want {
+			t.Errorf("formatByteSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package terminal
+
+// Color policy.
+// Styles are always built in full color; the renderer downsamples them to
+// the color profile chosen here. --color=auto detects it: NO_COLOR, TERM=dumb,
+// or a stdout that is not a terminal turn styling off, and COLORTERM decides
+// between truecolor and fewer colors. always keeps colors when piped, in
+// truecolor only if COLORTERM advertises it and in the 16 ANSI colors
+// otherwise. never strips every escape sequence from the styled text.
+
+import (
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+
+	"github.com/alayacore/alayacore/internal/config"
+)
+
+// colorProfile resolves a --color mode into the profile styles are rendered
+// with.
+func colorProfile(mode string, output io.Writer, environ []string) colorprofile.Profile {
+	switch mode {
+	case config.ColorNever:
+		return colorprofile.NoTTY
+	case config.ColorAlways:
+		if advertisesTrueColor(environ) {
+			return colorprofile.TrueColor
+		}
+		return colorprofile.ANSI
+	default:
+		return colorprofile.Detect(output, environ)
+	}
+}
+
+// advertisesTrueColor reports whether COLORTERM announces 24-bit color.
+func advertisesTrueColor(environ []string) bool {
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && name == "COLORTERM" {
+			value = strings.ToLower(value)
+			return value == "truecolor" || value == "24bit"
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/colorprofile"
+
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestColorProfile(t *testing.T) {
+	tests := []struct {
+		mode    string
+		environ []string
+		want    colorprofile.Profile
+	}{
+		{config.ColorNever, []string{"TERM=xterm-256color", "COLORTERM=truecolor"}, colorprofile.NoTTY},
+		{config.ColorAlways, []string{"TERM=xterm-256color", "COLORTERM=truecolor"}, colorprofile.TrueColor},
+		{config.ColorAlways, []string{"TERM=xterm-256color"}, colorprofile.ANSI},
+		{config.ColorAlways, []string{"NO_COLOR=1", "COLORTERM=24bit"}, colorprofile.TrueColor},
+		// A buffer is not a terminal
+		{config.ColorAuto, []string{"TERM=xterm-256color", "COLORTERM=truecolor"}, colorprofile.NoTTY},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.mode, tt.environ), func(t *testing.T) {
+			if got := colorProfile(tt.mode, &bytes.Buffer{}, tt.environ); got != tt.want {
+				t.Errorf("colorProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorNeverEmitsNoEscapes(t *testing.T) {
+	output := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, output, stream.NewChanInput(10), nil, 80, 24)
+	writeTLV(output, stream.TagTextUser, "hello")
+	writeTLV(output, stream.TagTextAssistant, "[:1-1-t:]Some **bold** text\n\n```go\nfunc main() {}\n```")
+	writeTLV(output, stream.TagSystemError, "something failed")
+	terminal.display.updateContent()
+
+	view := terminal.View().Content
+	if !strings.Contains(view, "\x1b") {
+		t.Fatal("expected the full-color view to contain escape sequences")
+	}
+
+	var buf bytes.Buffer
+	w := &colorprofile.Writer{Forward: &buf, Profile: colorProfile(config.ColorNever, &buf, nil)}
+	if _, err := w.WriteString(view); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("--color=never output contains escape sequences: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "something failed") {
+		t.Error("--color=never dropped the text")
+	}
+}
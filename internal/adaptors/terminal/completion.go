@@ -0,0 +1,75 @@
+package terminal
+
+// Command completion.
+// Tab in the input, when it holds a ":" command, completes the command name,
+// or a saved prompt name after ":prompt ". One match is completed with a
+// trailing space; several are completed to their common prefix and listed.
+// Otherwise Tab toggles focus as usual.
+
+import (
+	"sort"
+	"strings"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+)
+
+// localCommands are handled by the terminal rather than the session.
+var localCommands = []string{"quit", "q", "copy", "render", "review"}
+
+// commandCandidates returns what can follow ":", sorted: command names and
+// "prompt <name>" for each saved prompt.
+func (m *Terminal) commandCandidates() []string {
+	candidates := append([]string(nil), localCommands...)
+	for _, cmd := range agentpkg.GetCommandRegistry().List() {
+		candidates = append(candidates, cmd.Name)
+	}
+	if m.session != nil {
+		for _, name := range m.session.PromptNames() {
+			candidates = append(candidates, "prompt "+name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// completeCommand completes the ":" command in the input. It reports false
+// when the input holds no command, so Tab keeps its other meaning.
+func (m *Terminal) completeCommand() bool {
+	value := m.input.Value()
+	typed, ok := strings.CutPrefix(value, ":")
+	if !ok {
+		return false
+	}
+
+	var matches []string
+	for _, c := range m.commandCandidates() {
+		rest, ok := strings.CutPrefix(c, typed)
+		// One word at a time: "pro" offers prompt and prompts, not every
+		// "prompt <name>"
+		if ok && !strings.Contains(rest, " ") {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return true
+	case 1:
+		m.input.SetValue(":" + matches[0] + " ")
+	default:
+		m.input.SetValue(":" + commonPrefix(matches))
+		m.out.WriteNotify(strings.Join(matches, "  "))
+	}
+	m.input.CursorEnd()
+	return true
+}
+
+// commonPrefix returns the longest prefix shared by all of ss.
+func commonPrefix(ss []string) string {
+	prefix := ss[0]
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
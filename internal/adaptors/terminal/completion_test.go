@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func pressTab(terminal *Terminal) {
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyTab}))
+}
+
+func TestTabCompletesCommand(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	terminal.input.SetValue(":clear_p")
+	pressTab(terminal)
+	if got := terminal.input.Value(); got != ":clear_plan " {
+		t.Errorf("completed %q, want %q", got, ":clear_plan ")
+	}
+	if terminal.focusedWindow != focusInput {
+		t.Error("Tab completing a command should keep the input focused")
+	}
+
+	// prompt and prompts: complete the shared prefix and keep both
+	terminal.input.SetValue(":promp")
+	pressTab(terminal)
+	if got := terminal.input.Value(); got != ":prompt" {
+		t.Errorf("completed %q, want %q", got, ":prompt")
+	}
+}
+
+func TestTabWithoutCommandTogglesFocus(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	terminal.input.SetValue("hello")
+	pressTab(terminal)
+	if terminal.focusedWindow != focusDisplay {
+		t.Error("Tab outside a command should move focus to the display")
+	}
+	if got := terminal.input.Value(); got != "hello" {
+		t.Errorf("input changed to %q", got)
+	}
+}
@@ -8,6 +8,7 @@
 //   - Model selection and switching
 //   - Task queue management
 //   - Focus management between input and display windows
+//   - Session tabs, several sessions in one terminal
 //
 // Architecture Overview:
 //
@@ -33,6 +34,7 @@
 //   - input_component.go: Input handling and external editor support
 //   - model_selector.go: Model switching UI
 //   - queue_manager.go: Task queue UI
+//   - tabs.go: Session tabs
 //   - theme_test.go: Theme configuration
 //   - tool.go, tool_handler.go: Tool execution display
 //
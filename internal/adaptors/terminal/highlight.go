@@ -0,0 +1,217 @@
+package terminal
+
+// Markdown fenced code blocks in assistant text.
+//
+// Assistant text arrives as deltas and is wrapped incrementally (see
+// Window.AppendContent). Prose keeps the plain Text style on that fast path, and
+// so do code blocks while they are still open. fenceTracker watches the deltas
+// and asks for a full re-render only when a block closes, at which point
+// renderMarkdown highlights the finished block in place.
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// ============================================================================
+// Fences
+// ============================================================================
+
+// parseFence reports whether line opens a fenced code block. It returns the
+// fence marker (``` or ~~~, possibly longer) and the info string's first word,
+// which names the language.
+func parseFence(line string) (marker, lang string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return "", "", false
+	}
+	ch := trimmed[0]
+	if ch != '`' && ch != '~' {
+		return "", "", false
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return "", "", false
+	}
+	info := strings.TrimSpace(trimmed[n:])
+	if ch == '`' && strings.Contains(info, "`") {
+		return "", "", false
+	}
+	if fields := strings.Fields(info); len(fields) > 0 {
+		lang = strings.ToLower(fields[0])
+	}
+	return trimmed[:n], lang, true
+}
+
+// isClosingFence reports whether line closes a block opened with marker: the
+// same character, at least as many of it, and nothing else.
+func isClosingFence(line, marker string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	trimmed = strings.TrimRight(trimmed, " ")
+	return len(trimmed) >= len(marker) && strings.Trim(trimmed, marker[:1]) == ""
+}
+
+// fenceTracker follows fence state across streamed deltas so the window knows
+// when a full re-render is needed.
+type fenceTracker struct {
+	partial   string // trailing line without its newline yet
+	open      string // marker of the open block, "" outside a block
+	tentative bool   // partial line currently reads as the closing fence
+}
+
+// feed consumes a delta and reports whether it changed which code blocks are
+// closed. A closing fence still waiting for its newline (usually the end of
+// the reply) already renders as closed, so it counts too.
+func (t *fenceTracker) feed(delta string) bool {
+	changed := false
+	t.partial += delta
+	for {
+		i := strings.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := t.partial[:i]
+		t.partial = t.partial[i+1:]
+		if t.open == "" {
+			if marker, _, ok := parseFence(line); ok {
+				t.open = marker
+			}
+		} else if isClosingFence(line, t.open) {
+			t.open = ""
+			changed = true
+		}
+	}
+
+	tentative := t.open != "" && isClosingFence(t.partial, t.open)
+	if tentative != t.tentative {
+		t.tentative = tentative
+		changed = true
+	}
+	return changed
+}
+
+// ============================================================================
+// Rendering
+// ============================================================================
+
+// renderMarkdown styles assistant text: prose and unterminated code blocks use
+// the Text style line by line, closed code blocks are syntax highlighted.
+func renderMarkdown(content string, styles *Styles) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, len(lines))
+	for i := 0; i < len(lines); {
+		marker, lang, ok := parseFence(lines[i])
+		end := -1
+		if ok {
+			end = findClosingFence(lines, i+1, marker)
+		}
+		if end < 0 {
+			out[i] = styles.Text.Render(lines[i])
+			i++
+			continue
+		}
+		out[i] = styles.CodeFence.Render(lines[i])
+		copy(out[i+1:end], highlightCode(lines[i+1:end], lang, styles))
+		out[end] = styles.CodeFence.Render(lines[end])
+		i = end + 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// findClosingFence returns the index of the line closing a block opened with
+// marker, searching from start, or -1 when the block is still open.
+func findClosingFence(lines []string, start int, marker string) int {
+	for i := start; i < len(lines); i++ {
+		if isClosingFence(lines[i], marker) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ============================================================================
+// Highlighting
+// ============================================================================
+
+// highlightCode colors the lines of one code block with the chroma lexer for
+// lang. Blocks without a known language are guessed from their content, and
+// left plain when that fails. Lexing the whole block keeps multi-line
+// strings and comments intact.
+func highlightCode(lines []string, lang string, styles *Styles) []string {
+	code := strings.Join(lines, "\n")
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	tokens, err := chroma.Tokenise(chroma.Coalesce(lexer), nil, code)
+	if err != nil {
+		tokens = []chroma.Token{{Type: chroma.Text, Value: code}}
+	}
+
+	// Runs of one style are rendered together, one line at a time, because
+	// ANSI styles do not nest (see output.go)
+	out := make([]string, len(lines))
+	var sb strings.Builder
+	line := 0
+	var run strings.Builder
+	runStyle := &styles.CodeText
+	flush := func() {
+		if run.Len() > 0 {
+			sb.WriteString(runStyle.Render(run.String()))
+			run.Reset()
+		}
+	}
+	for _, token := range tokens {
+		style := tokenStyle(token.Type, styles)
+		for i, part := range strings.Split(token.Value, "\n") {
+			if i > 0 {
+				flush()
+				if line < len(out) {
+					out[line] = sb.String()
+				}
+				sb.Reset()
+				line++
+			}
+			if part == "" {
+				continue
+			}
+			if style != runStyle {
+				flush()
+				runStyle = style
+			}
+			run.WriteString(part)
+		}
+	}
+	flush()
+	if line < len(out) {
+		out[line] = sb.String()
+	}
+	return out
+}
+
+// tokenStyle returns the style of a chroma token type.
+func tokenStyle(t chroma.TokenType, styles *Styles) *lipgloss.Style {
+	switch {
+	case t.InCategory(chroma.Comment):
+		return &styles.CodeComment
+	case t.InCategory(chroma.Keyword):
+		return &styles.CodeKeyword
+	case t.InSubCategory(chroma.LiteralString):
+		return &styles.CodeString
+	case t.InSubCategory(chroma.LiteralNumber):
+		return &styles.CodeNumber
+	}
+	return &styles.CodeText
+}
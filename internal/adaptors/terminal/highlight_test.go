@@ -0,0 +1,166 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestParseFence(t *testing.T) {
+	tests := []struct {
+		line   string
+		marker string
+		lang   string
+		ok     bool
+	}{
+		{"```go", "```", "go", true},
+		{"````", "````", "", true},
+		{"  ~~~ Python extra", "~~~", "python", true},
+		{"    ```go", "", "", false}, // indented code, not a fence
+		{"``", "", "", false},
+		{"```a`b", "", "", false},
+		{"text ```", "", "", false},
+	}
+	for _, tt := range tests {
+		marker, lang, ok := parseFence(tt.line)
+		if marker != tt.marker || lang != tt.lang || ok != tt.ok {
+			t.Errorf("parseFence(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.line, marker, lang, ok, tt.marker, tt.lang, tt.ok)
+		}
+	}
+
+	if !isClosingFence("`````", "```") {
+		t.Error("a longer fence should close a shorter one")
+	}
+	if isClosingFence("```", "````") || isClosingFence("~~~", "```") || isClosingFence("```go", "```") {
+		t.Error("shorter, mismatched, or info-bearing fences must not close")
+	}
+}
+
+func TestFenceTrackerReportsClosedBlocks(t *testing.T) {
+	var tr fenceTracker
+	steps := []struct {
+		delta string
+		want  bool
+	}{
+		{"Here:\n``", false},
+		{"`go\nfunc main() {}\n", false},
+		{"``", false},
+		{"`", true},  // closing fence without newline already renders as closed
+		{"\n", true}, // the line completes: the block is closed for good
+		{"More prose\n", false},
+		{"```\nopen block", false},
+	}
+	for i, step := range steps {
+		if got := tr.feed(step.delta); got != step.want {
+			t.Errorf("step %d: feed(%q) = %v, want %v", i, step.delta, got, step.want)
+		}
+	}
+	if tr.open != "```" {
+		t.Errorf("open = %q, want the last block to still be open", tr.open)
+	}
+}
+
+func TestRenderMarkdownHighlightsClosedBlocksOnly(t *testing.T) {
+	styles := DefaultStyles()
+	prose := styles.Text.Render("Run this:")
+
+	closed := renderMarkdown("Run this:\n```go\nreturn 42\n```", styles)
+	if !strings.HasPrefix(closed, prose+"\n") {
+		t.Errorf("prose should keep the Text style, got %q", closed)
+	}
+	if !strings.Contains(closed, styles.CodeKeyword.Render("return")) ||
+		!strings.Contains(closed, styles.CodeNumber.Render("42")) {
+		t.Errorf("closed block should be highlighted, got %q", closed)
+	}
+
+	open := "Run this:\n```go\nreturn 42"
+	if got, want := renderMarkdown(open, styles), styleMultiline(open, styles.Text); got != want {
+		t.Errorf("an open block should render like prose\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	styles := DefaultStyles()
+
+	got := highlightCode([]string{`x := "a//b" // note`}, "go", styles)[0]
+	want := styles.CodeText.Render("x := ") + styles.CodeString.Render(`"a//b"`) +
+		styles.CodeText.Render(" ") + styles.CodeComment.Render("// note")
+	if got != want {
+		t.Errorf("highlightCode = %q, want %q", got, want)
+	}
+
+	lines := highlightCode([]string{"a /* start", "still comment", "end */ b"}, "c", styles)
+	if lines[1] != styles.CodeComment.Render("still comment") {
+		t.Errorf("block comment should carry across lines, got %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], styles.CodeText.Render(" b")) {
+		t.Errorf("text after the comment should be plain, got %q", lines[2])
+	}
+}
+
+func TestHighlightCodeMultilineStrings(t *testing.T) {
+	styles := DefaultStyles()
+	tests := []struct {
+		lang  string
+		lines []string
+		want  string // the second line, which lies inside the string
+	}{
+		{"go", []string{"s := `first", "if return", "last`"}, "if return"},
+		{"python", []string{`s = """first`, "if return", `last"""`}, "if return"},
+	}
+	for _, tt := range tests {
+		lines := highlightCode(tt.lines, tt.lang, styles)
+		if len(lines) != len(tt.lines) || lines[1] != styles.CodeString.Render(tt.want) {
+			t.Errorf("%s: the inside of a multi-line string = %q", tt.lang, lines)
+		}
+	}
+
+	// An apostrophe in a comment starts no string
+	lines := highlightCode([]string{"// it's", "return 0;"}, "c", styles)
+	if lines[0] != styles.CodeComment.Render("// it's") || !strings.HasPrefix(lines[1], styles.CodeKeyword.Render("return")) {
+		t.Errorf("an apostrophe leaked out of a comment: %q", lines)
+	}
+}
+
+func TestStreamedCodeBlockMatchesFullRender(t *testing.T) {
+	deltas := []string{"Example", ":\n```py", "thon\ndef f():\n", "    return 'x'  # done\n", "``", "`\nThat is ", "all."}
+
+	streamed := NewWindowBuffer(60, DefaultStyles())
+	for _, d := range deltas {
+		streamed.AppendOrUpdate("t1", stream.TagTextAssistant, d)
+		streamed.GetAll(-1) // render between deltas to exercise the incremental path
+	}
+
+	full := NewWindowBuffer(60, DefaultStyles())
+	full.AppendOrUpdate("t1", stream.TagTextAssistant, strings.Join(deltas, ""))
+
+	// Prose deltas are styled one by one, so compare the visible text and
+	// check the closed block separately.
+	got, want := streamed.GetAll(-1), full.GetAll(-1)
+	if stripANSI(got) != stripANSI(want) {
+		t.Errorf("streamed render differs from full render\ngot:\n%s\nwant:\n%s", stripANSI(got), stripANSI(want))
+	}
+	styles := DefaultStyles()
+	for _, token := range []string{styles.CodeKeyword.Render("def"), styles.CodeComment.Render("# done")} {
+		if !strings.Contains(got, token) {
+			t.Errorf("streamed render should highlight the closed block, missing %q", token)
+		}
+	}
+}
+
+func TestSetHighlightDisablesCodeStyling(t *testing.T) {
+	wb := NewWindowBuffer(60, DefaultStyles())
+	wb.SetHighlight(false)
+	wb.AppendOrUpdate("t1", stream.TagTextAssistant, "```go\nreturn\n```")
+
+	if strings.Contains(wb.GetAll(-1), DefaultStyles().CodeKeyword.Render("return")) {
+		t.Error("--no-highlight should render code blocks as plain text")
+	}
+	if lipgloss.Width(wb.GetAll(-1)) != 60 {
+		t.Errorf("rendered width = %d, want 60", lipgloss.Width(wb.GetAll(-1)))
+	}
+}
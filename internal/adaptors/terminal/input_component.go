@@ -2,12 +2,19 @@ package terminal
 
 import (
 	"strings"
+	"unicode"
 
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 )
 
+// Default input prompt and placeholder
+const (
+	defaultPrompt      = "> "
+	defaultPlaceholder = "Enter your prompt..."
+)
+
 // InputModel handles text input and editor integration.
 type InputModel struct {
 	input         textinput.Model
@@ -16,14 +23,15 @@ type InputModel struct {
 	editor        *Editor
 	styles        *Styles
 	width         int
+	killBuffer    string // last text removed by a readline kill, restored by Ctrl+Y
 }
 
 // NewInputModel creates a new input model
 func NewInputModel(styles *Styles) InputModel {
 	input := textinput.New()
-	input.Placeholder = "Enter your prompt..."
+	input.Placeholder = defaultPlaceholder
 	input.Focus()
-	input.Prompt = "> "
+	input.Prompt = defaultPrompt
 	input.SetWidth(76)
 
 	return InputModel{
@@ -128,6 +136,19 @@ func (m InputModel) GetPrompt() string {
 	return m.input.Value()
 }
 
+// SetText replaces the input with text, ready to edit and submit. Text of
+// several lines becomes editor content, as after Ctrl+O.
+func (m *InputModel) SetText(text string) {
+	if strings.Contains(text, "\n") {
+		m.editorContent = text
+		m.input.SetValue(FormatEditorContent(text))
+	} else {
+		m.editorContent = ""
+		m.input.SetValue(text)
+	}
+	m.input.CursorEnd()
+}
+
 // GetEditorContent returns the editor content
 func (m InputModel) GetEditorContent() string {
 	return m.editorContent
@@ -164,7 +185,9 @@ func (m InputModel) RenderWithBorder(confirmDialog bool, confirmText string) str
 	m.input.SetStyles(styles)
 
 	if confirmDialog {
-		return m.styles.RenderBorderedBox(m.styles.Confirm.Render(confirmText), m.width, borderColor)
+		// Kept to one line, which is all the layout gives the input
+		clip := lipgloss.NewStyle().MaxWidth(max(0, m.width-4))
+		return m.styles.RenderBorderedBox(clip.Render(m.styles.Confirm.Render(confirmText)), m.width, borderColor)
 	}
 
 	return m.styles.RenderBorderedBox(m.input.View(), m.width, borderColor)
@@ -182,6 +205,12 @@ func (m *InputModel) SetStyles(styles *Styles) {
 	m.updateInputStyles()
 }
 
+// SetPrompt sets the prompt and placeholder shown in the input box
+func (m *InputModel) SetPrompt(prompt, placeholder string) {
+	m.input.Prompt = prompt
+	m.input.Placeholder = placeholder
+}
+
 // CursorEnd moves cursor to end
 func (m *InputModel) CursorEnd() {
 	m.input.CursorEnd()
@@ -198,4 +227,86 @@ func (m *InputModel) updateFromMsg(msg tea.Msg) {
 	}
 }
 
+// ============================================================================
+// Readline-style Editing
+// ============================================================================
+
+// handleReadlineKey applies a readline-style editing binding to the input.
+// Returns true if the key was consumed. Positions are in runes, matching the
+// textinput cursor.
+func (m *InputModel) handleReadlineKey(key string) bool {
+	value := []rune(m.input.Value())
+	pos := min(m.input.Position(), len(value))
+
+	switch key {
+	case KeyCtrlA:
+		m.input.CursorStart()
+	case KeyCtrlE:
+		m.input.CursorEnd()
+	case KeyCtrlU:
+		m.kill(value, 0, pos)
+	case KeyCtrlK:
+		m.kill(value, pos, len(value))
+	case KeyCtrlW:
+		m.kill(value, wordStartBefore(value, pos), pos)
+	case KeyCtrlY:
+		if m.killBuffer == "" {
+			return true
+		}
+		yank := []rune(m.killBuffer)
+		newValue := append(append(append([]rune{}, value[:pos]...), yank...), value[pos:]...)
+		m.setValueAndCursor(string(newValue), pos+len(yank))
+	case KeyAltB:
+		m.input.SetCursor(wordStartBefore(value, pos))
+	case KeyAltF:
+		m.input.SetCursor(wordEndAfter(value, pos))
+	default:
+		return false
+	}
+	return true
+}
+
+// kill removes value[start:end], saving it for a later yank.
+// An empty range leaves the kill buffer untouched, like readline.
+func (m *InputModel) kill(value []rune, start, end int) {
+	if start >= end {
+		return
+	}
+	m.killBuffer = string(value[start:end])
+	newValue := append(append([]rune{}, value[:start]...), value[end:]...)
+	m.setValueAndCursor(string(newValue), start)
+}
+
+// setValueAndCursor replaces the input value and places the cursor at pos.
+func (m *InputModel) setValueAndCursor(value string, pos int) {
+	m.input.SetValue(value)
+	m.input.SetCursor(pos)
+}
+
+// wordStartBefore returns the start of the word before pos, skipping any
+// whitespace immediately to the left of the cursor.
+func wordStartBefore(value []rune, pos int) int {
+	i := pos
+	for i > 0 && unicode.IsSpace(value[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(value[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEndAfter returns the end of the word after pos, skipping any
+// whitespace immediately to the right of the cursor.
+func wordEndAfter(value []rune, pos int) int {
+	i := pos
+	for i < len(value) && unicode.IsSpace(value[i]) {
+		i++
+	}
+	for i < len(value) && !unicode.IsSpace(value[i]) {
+		i++
+	}
+	return i
+}
+
 var _ tea.Model = (*InputModel)(nil)
@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newReadlineTerminal(value string, cursor int) *Terminal {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+	terminal.input.SetValue(value)
+	terminal.input.input.SetCursor(cursor)
+	return terminal
+}
+
+func pressKey(t *testing.T, terminal *Terminal, code rune, mod tea.KeyMod) {
+	t.Helper()
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: code, Mod: mod}))
+}
+
+func assertInput(t *testing.T, terminal *Terminal, wantValue string, wantCursor int) {
+	t.Helper()
+	if got := terminal.input.Value(); got != wantValue {
+		t.Errorf("value = %q, want %q", got, wantValue)
+	}
+	if got := terminal.input.input.Position(); got != wantCursor {
+		t.Errorf("cursor = %d, want %d", got, wantCursor)
+	}
+}
+
+// sample: "git commit  -m msg" with the cursor after "commit  " (index 12)
+const readlineSample = "git commit  -m msg"
+
+func TestReadlineKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       rune
+		mod        tea.KeyMod
+		wantValue  string
+		wantCursor int
+	}{
+		{"ctrl+a moves to start", 'a', tea.ModCtrl, readlineSample, 0},
+		{"ctrl+e moves to end", 'e', tea.ModCtrl, readlineSample, len(readlineSample)},
+		{"alt+b moves back one word", 'b', tea.ModAlt, readlineSample, 4},
+		{"alt+f moves forward one word", 'f', tea.ModAlt, readlineSample, 14},
+		{"ctrl+w deletes word backward", 'w', tea.ModCtrl, "git -m msg", 4},
+		{"ctrl+u kills to start", 'u', tea.ModCtrl, "-m msg", 0},
+		{"ctrl+k kills to end", 'k', tea.ModCtrl, "git commit  ", 12},
+		{"ctrl+y with empty kill buffer is a no-op", 'y', tea.ModCtrl, readlineSample, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminal := newReadlineTerminal(readlineSample, 12)
+			pressKey(t, terminal, tt.code, tt.mod)
+			assertInput(t, terminal, tt.wantValue, tt.wantCursor)
+		})
+	}
+}
+
+func TestReadlineYankRestoresKilledText(t *testing.T) {
+	terminal := newReadlineTerminal(readlineSample, 12)
+
+	pressKey(t, terminal, 'w', tea.ModCtrl)
+	assertInput(t, terminal, "git -m msg", 4)
+
+	pressKey(t, terminal, 'e', tea.ModCtrl)
+	pressKey(t, terminal, 'y', tea.ModCtrl)
+	assertInput(t, terminal, "git -m msgcommit  ", 18)
+}
+
+func TestReadlineMultibyteRunes(t *testing.T) {
+	terminal := newReadlineTerminal("héllo wörld", 11)
+
+	pressKey(t, terminal, 'w', tea.ModCtrl)
+	assertInput(t, terminal, "héllo ", 6)
+
+	pressKey(t, terminal, 'a', tea.ModCtrl)
+	pressKey(t, terminal, 'y', tea.ModCtrl)
+	assertInput(t, terminal, "wörldhéllo ", 5)
+}
+
+func TestReadlineKeysDoNotShadowGlobalBindings(t *testing.T) {
+	terminal := newReadlineTerminal(readlineSample, 12)
+
+	pressKey(t, terminal, 'g', tea.ModCtrl)
+	if !terminal.cancelConfirmDialog {
+		t.Error("Ctrl+G should still open the cancel confirmation")
+	}
+	terminal.cancelConfirmDialog = false
+
+	pressKey(t, terminal, 'c', tea.ModCtrl)
+	assertInput(t, terminal, "", 0)
+}
@@ -29,6 +29,10 @@ type OutputWriter interface {
 	GetCurrentStep() int
 	GetMaxSteps() int
 	GetLastStepInfo() (currentStep, maxSteps int)
+	LastResponse() string
+	TakeAlerts() []string
+	PendingReviews() []agentpkg.ReviewFrame
+	SessionState() agentpkg.SessionState
 
 	// Model management
 	GetModels() []agentpkg.ModelInfo
@@ -4,12 +4,15 @@ package terminal
 // This file provides key constants, bindings, and the key handler.
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/stream"
 )
 
@@ -63,9 +66,11 @@ const (
 	KeyShiftK = "K"
 	KeyShiftL = "L"
 	KeyShiftM = "M"
+	KeyShiftN = "N"
 
 	// Special keys
 	KeyColon = ":"
+	KeySlash = "/"
 	Keyg     = "g"
 
 	// Control keys
@@ -95,6 +100,11 @@ const (
 	KeyCtrlX = "ctrl+x"
 	KeyCtrlY = "ctrl+y"
 	KeyCtrlZ = "ctrl+z"
+
+	// Alt keys
+	KeyAltB     = "alt+b"
+	KeyAltF     = "alt+f"
+	KeyAltEnter = "alt+enter"
 )
 
 // ============================================================================
@@ -110,15 +120,19 @@ type KeyBinding struct {
 
 // Global key bindings - work from any context
 var globalKeyBindings = []KeyBinding{
-	{KeyTab, "Toggle focus between display and input", "global"},
-	{KeyCtrlG, "Cancel current request (with confirmation)", "global"},
+	{KeyTab, "Complete a :command, or toggle focus between display and input", "global"},
+	{KeyCtrlG, "Cancel current request (with confirmation); twice quickly cancels all", "global"},
 	{KeyCtrlC, "Clear input field", "global"},
 	{KeyCtrlS, "Save session", "global"},
 	{KeyCtrlO, "Open external editor", "global"},
 	{KeyCtrlL, "Open model selector", "global"},
 	{KeyCtrlP, "Open theme selector", "global"},
 	{KeyCtrlQ, "Open queue manager", "global"},
+	{KeyCtrlR, "Pick a past prompt to edit and send again", "global"},
 	{KeyEnter, "Submit prompt/command", "global"},
+	{KeyAltEnter, "Steer the running prompt with the input (:steer)", "global"},
+	{KeyCtrlT, "Open a new session tab", "global"},
+	{"ctrl+1..9", "Switch to session tab 1-9 (also alt+1..9)", "global"},
 }
 
 // Display key bindings - only active when display is focused
@@ -136,7 +150,24 @@ var displayKeyBindings = []KeyBinding{
 	{KeyShiftL, "Move cursor to bottom window", "display"},
 	{KeyShiftM, "Move cursor to middle window", "display"},
 	{KeyColon, "Switch to input with command prefix", "display"},
+	{KeySlash, "Search the transcript", "display"},
+	{KeyN, "Jump to next search match", "display"},
+	{KeyShiftN, "Jump to previous search match", "display"},
 	{KeySpace, "Toggle window fold (expand/collapse)", "display"},
+	{KeyR, "Expand or collapse the latest reasoning", "display"},
+	{KeyCtrlY, "Copy the last response to the clipboard", "display"},
+}
+
+// Input key bindings - readline-style editing when input is focused
+var inputKeyBindings = []KeyBinding{
+	{KeyCtrlA, "Move cursor to start of line", "input"},
+	{KeyCtrlE, "Move cursor to end of line", "input"},
+	{KeyAltB, "Move cursor back one word", "input"},
+	{KeyAltF, "Move cursor forward one word", "input"},
+	{KeyCtrlW, "Delete word before cursor", "input"},
+	{KeyCtrlU, "Delete from cursor to start of line", "input"},
+	{KeyCtrlK, "Delete from cursor to end of line", "input"},
+	{KeyCtrlY, "Yank (paste) last deleted text", "input"},
 }
 
 // Model selector key bindings
@@ -158,6 +189,26 @@ var queueManagerKeyBindings = []KeyBinding{
 	{"d", "Delete selected queue item", "queue-manager"},
 }
 
+// Prompt picker key bindings
+var promptPickerKeyBindings = []KeyBinding{
+	{KeyUp, "Move selection up (newer)", "prompt-picker"},
+	{KeyDown, "Move selection down (older)", "prompt-picker"},
+	{KeyCtrlR, "Move selection down (older)", "prompt-picker"},
+	{KeyEnter, "Put the prompt in the input box", "prompt-picker"},
+	{KeyEsc, "Close prompt picker", "prompt-picker"},
+	{KeyCtrlC, "Clear the filter", "prompt-picker"},
+}
+
+// Review viewer key bindings
+var reviewViewerKeyBindings = []KeyBinding{
+	{"a", "Accept the change", "review-viewer"},
+	{"r", "Reject the change", "review-viewer"},
+	{"e", "Reject the change with a reason", "review-viewer"},
+	{"j", "Scroll down", "review-viewer"},
+	{"k", "Scroll up", "review-viewer"},
+	{KeyEsc, "Decide later (:review shows it again)", "review-viewer"},
+}
+
 // Theme selector key bindings
 var themeSelectorKeyBindings = []KeyBinding{
 	{KeyUp, "Move selection up", "theme-selector"},
@@ -176,6 +227,7 @@ var confirmDialogKeyBindings = []KeyBinding{
 	{"n", "Cancel action", "confirm-dialog"},
 	{KeyEsc, "Cancel action", "confirm-dialog"},
 	{KeyCtrlC, "Cancel action", "confirm-dialog"},
+	{KeyEsc, "Quit without waiting for the running task to stop", "quitting"},
 }
 
 // GetAllKeyBindings returns all key bindings for help display
@@ -183,8 +235,11 @@ func GetAllKeyBindings() []KeyBinding {
 	var all []KeyBinding
 	all = append(all, globalKeyBindings...)
 	all = append(all, displayKeyBindings...)
+	all = append(all, inputKeyBindings...)
 	all = append(all, modelSelectorKeyBindings...)
 	all = append(all, queueManagerKeyBindings...)
+	all = append(all, promptPickerKeyBindings...)
+	all = append(all, reviewViewerKeyBindings...)
 	all = append(all, themeSelectorKeyBindings...)
 	all = append(all, confirmDialogKeyBindings...)
 	return all
@@ -196,6 +251,14 @@ func GetAllKeyBindings() []KeyBinding {
 
 // handleKeyMsg routes keyboard input to the appropriate handler.
 func (m *Terminal) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// 0. While quitting waits for the session, only Esc does anything
+	if m.stopping {
+		if msg.String() == KeyEsc {
+			return m, m.quit()
+		}
+		return m, nil
+	}
+
 	// 1. Theme selector takes precedence when open
 	if m.themeSelector.IsOpen() {
 		return m.handleThemeSelectorKeys(msg)
@@ -211,30 +274,51 @@ func (m *Terminal) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleQueueManagerKeys(msg)
 	}
 
-	// 4. Confirmation dialogs block normal input
+	// 4. Prompt picker takes precedence when open
+	if m.promptPicker.IsOpen() {
+		return m.handlePromptPickerKeys(msg)
+	}
+
+	// 5. Review viewer takes precedence when open
+	if m.reviewViewer.IsOpen() {
+		return m.handleReviewViewerKeys(msg)
+	}
+
+	// 6. Confirmation dialogs block normal input
 	if cmd, handled := m.handleConfirmDialog(msg); handled {
 		return m, cmd
 	}
 
-	// 5. Tab toggles focus between display and input
+	// 7. Search prompt handles Enter/Esc itself; other keys edit the query
+	if m.search.prompting {
+		if cmd, handled := m.handleSearchPromptKeys(msg); handled {
+			return m, cmd
+		}
+	}
+
+	// 8. Tab completes a command in the input, or toggles focus between
+	// display and input
 	if msg.String() == KeyTab {
+		if m.focusedWindow == focusInput && m.completeCommand() {
+			return m, nil
+		}
 		m.toggleFocus()
 		return m, nil
 	}
 
-	// 6. Display-specific keys when display is focused
+	// 9. Display-specific keys when display is focused
 	if m.focusedWindow == "display" {
 		if cmd, handled := m.handleDisplayKeys(msg); handled {
 			return m, cmd
 		}
 	}
 
-	// 7. Global shortcuts (work from any context)
+	// 10. Global shortcuts (work from any context)
 	if cmd, handled := m.handleGlobalKeys(msg); handled {
 		return m, cmd
 	}
 
-	// 8. Default: pass to input
+	// 11. Default: pass to input
 	return m.handleInputKeys(msg)
 }
 
@@ -367,6 +451,39 @@ func (m *Terminal) handleQueueManagerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handlePromptPickerKeys handles input when the prompt picker is open.
+func (m *Terminal) handlePromptPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt, chosen, cmd := m.promptPicker.HandleKeyMsg(msg)
+	if chosen {
+		m.input.SetText(prompt)
+		m.focusInput()
+		m.display.updateContent()
+		return m, cmd
+	}
+	if !m.promptPicker.IsOpen() {
+		m.restoreFocusAfterQueueManager()
+	}
+	return m, cmd
+}
+
+// handleReviewViewerKeys handles input when the review viewer is open.
+func (m *Terminal) handleReviewViewerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	id := m.reviewViewer.ID()
+	action, reason, cmd := m.reviewViewer.HandleKeyMsg(msg)
+	switch action {
+	case reviewNone:
+		return m, cmd
+	case reviewAccept:
+		_ = m.streamInput.EmitTLV(stream.TagTextUser, ":review_accept "+id) //nolint:errcheck // best-effort input
+	case reviewReject:
+		_ = m.streamInput.EmitTLV(stream.TagTextUser, strings.TrimSpace(":review_reject "+id+" "+reason)) //nolint:errcheck // best-effort input
+	case reviewMask:
+		_ = m.streamInput.EmitTLV(stream.TagTextUser, ":review_mask "+id) //nolint:errcheck // best-effort input
+	}
+	m.closeReviewViewer(action != reviewDismiss)
+	return m, cmd
+}
+
 // handleConfirmDialog handles quit and cancel confirmation dialogs.
 func (m *Terminal) handleConfirmDialog(msg tea.KeyMsg) (tea.Cmd, bool) {
 	if m.confirmDialog {
@@ -388,10 +505,8 @@ func (m *Terminal) handleConfirmDialog(msg tea.KeyMsg) (tea.Cmd, bool) {
 func (m *Terminal) handleQuitConfirm(msg tea.KeyMsg) (tea.Cmd, bool) {
 	switch msg.String() {
 	case KeyY, "Y":
-		m.quitting = true
-		m.streamInput.Close()
-		m.out.Close()
-		return tea.Quit, true
+		m.confirmDialog = false
+		return m.shutdown(), true
 	case KeyN, "N", KeyEsc, KeyCtrlC:
 		m.confirmDialog = false
 		m.input.SetValue("")
@@ -400,6 +515,85 @@ func (m *Terminal) handleQuitConfirm(msg tea.KeyMsg) (tea.Cmd, bool) {
 	return nil, true
 }
 
+// shutdownDoneMsg reports that the session has stopped, or that
+// ShutdownTimeout passed first.
+type shutdownDoneMsg struct{}
+
+// shutdown stops the sessions before quitting. Their running tasks are
+// canceled and given ShutdownTimeout to return, so a tool is not killed
+// midway, and queued tasks are dropped. Esc quits without waiting.
+func (m *Terminal) shutdown() tea.Cmd {
+	m.stopping = true
+	var sessions []*agentpkg.Session
+	for _, tab := range m.syncTabs() {
+		tab.streamInput.Close()
+		if tab.session != nil {
+			sessions = append(sessions, tab.session)
+		}
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for _, session := range sessions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = session.Shutdown(ctx) //nolint:errcheck // quitting either way
+			}()
+		}
+		wg.Wait()
+		return shutdownDoneMsg{}
+	}
+}
+
+// quit ends the program. It is called once the sessions have stopped, or
+// early by Esc.
+func (m *Terminal) quit() tea.Cmd {
+	if m.quitting {
+		return nil
+	}
+	m.quitting = true
+	for _, tab := range m.syncTabs() {
+		tab.out.Close()
+	}
+	return tea.Quit
+}
+
+// quitConfirmText asks to confirm quitting, naming the tasks of all sessions
+// that would be stopped, e.g. "1 task running, 2 queued — quit anyway? Press
+// y/n".
+func (m *Terminal) quitConfirmText() string {
+	running, queued := 0, 0
+	for _, tab := range m.syncTabs() {
+		if tab.out.IsInProgress() {
+			running++
+		}
+		queued += tab.out.GetQueueCount()
+	}
+
+	var parts []string
+	switch {
+	case running == 1:
+		parts = append(parts, "1 task running")
+	case running > 1:
+		parts = append(parts, fmt.Sprintf("%d tasks running", running))
+	}
+	if queued > 0 {
+		if len(parts) > 0 {
+			parts = append(parts, fmt.Sprintf("%d queued", queued))
+		} else if queued == 1 {
+			parts = append(parts, "1 task queued")
+		} else {
+			parts = append(parts, fmt.Sprintf("%d tasks queued", queued))
+		}
+	}
+	if len(parts) == 0 {
+		return "Confirm exit? Press y/n"
+	}
+	return strings.Join(parts, ", ") + " — quit anyway? Press y/n"
+}
+
 // handleCancelConfirm handles the cancel confirmation dialog.
 func (m *Terminal) handleCancelConfirm(msg tea.KeyMsg) (tea.Cmd, bool) {
 	switch msg.String() {
@@ -415,6 +609,13 @@ func (m *Terminal) handleCancelConfirm(msg tea.KeyMsg) (tea.Cmd, bool) {
 			m.input.SetValue("")
 		}
 		return nil, true
+	case KeyCtrlG:
+		// A quick second Ctrl+G also drops the queued prompts
+		if !m.cancelFromCommand && time.Since(m.lastCancelKey) <= DoubleCancelWindow {
+			m.cancelConfirmDialog = false
+			return m.submitCommand("cancel_all", false), true
+		}
+		m.lastCancelKey = time.Now()
 	}
 	return nil, true
 }
@@ -467,11 +668,13 @@ func (m *Terminal) handleDisplayKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 	case KeyShiftJ:
 		m.display.MarkUserScrolled()
 		m.display.ScrollDown(1)
+		m.display.updateContent()
 		return nil, true
 
 	case KeyShiftK:
 		m.display.MarkUserScrolled()
 		m.display.ScrollUp(1)
+		m.display.updateContent()
 		return nil, true
 
 	case KeyShiftH:
@@ -514,12 +717,34 @@ func (m *Terminal) handleDisplayKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 		m.input.CursorEnd()
 		return nil, true
 
+	case KeySlash:
+		m.startSearch()
+		return nil, true
+
+	case KeyN:
+		m.nextMatch(1)
+		return nil, true
+
+	case KeyShiftN:
+		m.nextMatch(-1)
+		return nil, true
+
 	case KeySpace:
 		if m.display.ToggleWindowFold() {
 			m.display.updateContent()
 		}
 		return nil, true
 
+	case KeyR:
+		if m.display.ToggleLatestReasoning() {
+			m.display.EnsureCursorVisible()
+			m.display.updateContent()
+		}
+		return nil, true
+
+	case KeyCtrlY:
+		return m.copyLastResponse(), true
+
 	case KeyE:
 		// Open current window content in external editor (view only, don't populate input)
 		content := m.display.GetCursorWindowContent()
@@ -538,6 +763,7 @@ func (m *Terminal) handleGlobalKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 	case KeyCtrlG:
 		m.cancelConfirmDialog = true
 		m.cancelFromCommand = false
+		m.lastCancelKey = time.Now()
 		return nil, true
 
 	case KeyCtrlC:
@@ -547,10 +773,6 @@ func (m *Terminal) handleGlobalKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 		}
 		return nil, true
 
-	case KeyCtrlU:
-		// Reserved for future use
-		return nil, true
-
 	case KeyCtrlS:
 		return m.submitCommand("save", false), true
 
@@ -569,8 +791,21 @@ func (m *Terminal) handleGlobalKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 		m.openQueueManager()
 		return nil, true
 
+	case KeyCtrlR:
+		return m.openPromptPicker(), true
+
 	case KeyEnter:
 		return m.handleSubmit(), true
+
+	case KeyAltEnter:
+		return m.handleSteerSubmit(), true
+
+	case KeyCtrlT:
+		return m.openTab(), true
+	}
+
+	if i, ok := tabKey(msg.String()); ok {
+		return m.switchTab(i), true
 	}
 
 	return nil, false
@@ -579,7 +814,9 @@ func (m *Terminal) handleGlobalKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
 // handleInputKeys handles keys when input is focused (default behavior).
 func (m *Terminal) handleInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	oldValue := m.input.Value()
-	m.input.updateFromMsg(msg)
+	if m.focusedWindow != focusInput || !m.input.handleReadlineKey(msg.String()) {
+		m.input.updateFromMsg(msg)
+	}
 	newValue := m.input.Value()
 
 	// Clear editor content if user manually edits the input
@@ -612,7 +849,18 @@ func (m *Terminal) handleSubmit() tea.Cmd {
 	_ = m.streamInput.EmitTLV(stream.TagTextUser, prompt) //nolint:errcheck // best-effort input
 	m.input.SetValue("")
 
-	return scheduleTick()
+	return nil
+}
+
+// handleSteerSubmit sends the input as guidance for the running prompt.
+// Commands are submitted as with Enter.
+func (m *Terminal) handleSteerSubmit() tea.Cmd {
+	prompt := m.input.GetPrompt()
+	if prompt == "" || strings.HasPrefix(prompt, ":") {
+		return m.handleSubmit()
+	}
+	m.input.editorContent = ""
+	return m.submitCommand("steer "+prompt, true)
 }
 
 // handleCommand processes a command string (without the ":" prefix).
@@ -637,24 +885,59 @@ func (m *Terminal) handleCommand(command string) tea.Cmd {
 		return nil
 	}
 
+	// Copy command, handled here as only the terminal has a clipboard
+	if command == "copy" {
+		m.input.SetValue("")
+		return m.copyLastResponse()
+	}
+
+	// Render command, handled here as only the terminal renders markdown
+	if command == "render" || strings.HasPrefix(command, "render ") {
+		m.input.SetValue("")
+		m.setRender(strings.TrimSpace(strings.TrimPrefix(command, "render")))
+		return nil
+	}
+
+	// Review command, handled here as the viewer belongs to the terminal
+	if command == "review" {
+		m.input.SetValue("")
+		m.showReviews()
+		return nil
+	}
+
 	// All other commands - pass through to session
 	return m.submitCommand(command, true)
 }
 
+// setRender handles :render [on|off]; without an argument it toggles.
+func (m *Terminal) setRender(arg string) {
+	wb := m.out.WindowBuffer()
+	enabled := !wb.Render()
+	switch arg {
+	case "":
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		m.out.AppendError("Usage: :render [on|off]")
+		return
+	}
+	wb.SetRender(enabled)
+	if enabled {
+		m.out.WriteNotify("Markdown rendering on")
+	} else {
+		m.out.WriteNotify("Markdown rendering off: assistant text is shown as written")
+	}
+}
+
 // submitCommand sends a command to the session and optionally clears input.
 func (m *Terminal) submitCommand(command string, clearInput bool) tea.Cmd {
 	_ = m.streamInput.EmitTLV(stream.TagTextUser, ":"+command) //nolint:errcheck // best-effort input
 	if clearInput {
 		m.input.SetValue("")
 	}
-	return scheduleTick()
-}
-
-// scheduleTick schedules a tick message for UI updates.
-func scheduleTick() tea.Cmd {
-	return tea.Tick(SubmitTickDelay, func(_ time.Time) tea.Msg {
-		return tickMsg{}
-	})
+	return nil
 }
 
 // switchToSelectedModel sends a model_set command to switch to the selected model.
@@ -0,0 +1,405 @@
+package terminal
+
+// Markdown rendering of assistant text (:render, --render).
+//
+// With rendering off, assistant text is shown as the model wrote it, with only
+// its fenced code blocks highlighted (see highlight.go). With rendering on,
+// headings, emphasis, inline code, lists, quotes, rules, and tables are
+// formatted too. Tool output and reasoning are never rendered.
+//
+// Markdown cannot be styled delta by delta: "**bo" only becomes bold once
+// "ld**" arrives. paragraphTracker splits the streamed text at its last
+// finished paragraph, a blank line or a closing fence outside any code block.
+// The text before the split is rendered and wrapped once and cached by the
+// window; only the unfinished paragraph after it is rendered again on each
+// delta, so long replies stream without re-rendering everything they hold.
+
+import (
+	"strings"
+	"unicode"
+
+	"charm.land/lipgloss/v2"
+)
+
+// paragraphTracker follows streamed assistant text to find where its last
+// finished paragraph ends.
+type paragraphTracker struct {
+	offset  int    // bytes of content consumed as whole lines
+	partial string // trailing line without its newline yet
+	open    string // marker of the open code block, "" outside a block
+	end     int    // content offset just past the last finished paragraph
+}
+
+// feed consumes a delta.
+func (t *paragraphTracker) feed(delta string) {
+	t.partial += delta
+	for {
+		i := strings.IndexByte(t.partial, '\n')
+		if i < 0 {
+			return
+		}
+		line := t.partial[:i]
+		t.partial = t.partial[i+1:]
+		t.offset += i + 1
+		switch {
+		case t.open != "":
+			if isClosingFence(line, t.open) {
+				t.open = ""
+				t.end = t.offset
+			}
+		case strings.TrimSpace(line) == "":
+			t.end = t.offset
+		default:
+			if marker, _, ok := parseFence(line); ok {
+				t.open = marker
+			}
+		}
+	}
+}
+
+// renderFormatted renders assistant markdown for a viewport of width
+// columns. Closed code blocks are highlighted when highlight is set and
+// otherwise shown in the code style; everything else is prose.
+func renderFormatted(content string, width int, highlight bool, styles *Styles) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	prose := 0 // start of the current run of prose lines
+	for i := 0; i < len(lines); {
+		marker, lang, ok := parseFence(lines[i])
+		end := -1
+		if ok {
+			end = findClosingFence(lines, i+1, marker)
+		}
+		if end < 0 {
+			i++
+			continue
+		}
+		out = append(out, renderProse(lines[prose:i], width, styles)...)
+		out = append(out, styles.CodeFence.Render(lines[i]))
+		if highlight {
+			out = append(out, highlightCode(lines[i+1:end], lang, styles)...)
+		} else {
+			for _, line := range lines[i+1 : end] {
+				out = append(out, styles.CodeText.Render(line))
+			}
+		}
+		out = append(out, styles.CodeFence.Render(lines[end]))
+		i = end + 1
+		prose = i
+	}
+	out = append(out, renderProse(lines[prose:], width, styles)...)
+	return strings.Join(out, "\n")
+}
+
+// renderProse renders lines outside code blocks, one output line per input
+// line.
+func renderProse(lines []string, width int, styles *Styles) []string {
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if n := tableRows(lines[i:]); n > 0 {
+			out = append(out, renderTable(lines[i:i+n], width, styles)...)
+			i += n
+			continue
+		}
+		out = append(out, renderProseLine(lines[i], width, styles))
+		i++
+	}
+	return out
+}
+
+// renderProseLine renders a single line of prose: a heading, rule, quote,
+// list item, or plain text.
+func renderProseLine(line string, width int, styles *Styles) string {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+
+	if level, text, ok := parseHeading(trimmed); ok {
+		return styles.MarkdownMarker.Render(strings.Repeat("#", level)+" ") + renderInline(text, styles.MarkdownHeading, styles)
+	}
+	if isRule(trimmed) {
+		return styles.MarkdownMarker.Render(strings.Repeat("─", max(3, width)))
+	}
+	if text, ok := strings.CutPrefix(trimmed, ">"); ok {
+		return indent + styles.MarkdownMarker.Render("│ ") + renderInline(strings.TrimPrefix(text, " "), styles.MarkdownQuote, styles)
+	}
+	if marker, text, ok := parseListItem(trimmed); ok {
+		return indent + styles.MarkdownMarker.Render(marker) + renderInline(text, styles.Text, styles)
+	}
+	return indent + renderInline(trimmed, styles.Text, styles)
+}
+
+// parseHeading parses an ATX heading: one to six #, a space, then the text.
+func parseHeading(line string) (level int, text string, ok bool) {
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' {
+		return 0, "", false
+	}
+	return level, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#")), true
+}
+
+// isRule reports whether line is a thematic break: three or more of -, *, or
+// _, optionally separated by spaces.
+func isRule(line string) bool {
+	compact := strings.ReplaceAll(line, " ", "")
+	return len(compact) >= 3 && strings.Trim(compact, compact[:1]) == "" && strings.ContainsAny(compact[:1], "-*_")
+}
+
+// parseListItem parses a bullet ("- ", "* ", "+ ") or numbered ("1. ",
+// "1) ") list item. Bullets become "• "; numbers are kept.
+func parseListItem(line string) (marker, text string, ok bool) {
+	if len(line) >= 2 && strings.IndexByte("-*+", line[0]) >= 0 && line[1] == ' ' {
+		return "• ", line[2:], true
+	}
+	n := 0
+	for n < len(line) && n < 9 && isDigit(line[n]) {
+		n++
+	}
+	if n > 0 && n+1 < len(line) && (line[n] == '.' || line[n] == ')') && line[n+1] == ' ' {
+		return line[:n+2], line[n+2:], true
+	}
+	return "", "", false
+}
+
+// ============================================================================
+// Tables
+// ============================================================================
+
+// tableRows returns how many lines at the start of lines form a table: a
+// header row, a delimiter row such as |---|:--:|, and any body rows. It
+// returns 0 when lines do not start with a table.
+func tableRows(lines []string) int {
+	if len(lines) < 2 || !isTableRow(lines[0]) || !isTableDelimiter(lines[1]) {
+		return 0
+	}
+	n := 2
+	for n < len(lines) && isTableRow(lines[n]) {
+		n++
+	}
+	return n
+}
+
+func isTableRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") && len(trimmed) > 1
+}
+
+func isTableDelimiter(line string) bool {
+	if !isTableRow(line) {
+		return false
+	}
+	for _, cell := range tableCells(line) {
+		if strings.Trim(cell, ":-") != "" || !strings.Contains(cell, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// tableCells splits a table row into its trimmed cells.
+func tableCells(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimPrefix(trimmed, "|")
+	trimmed = strings.TrimSuffix(trimmed, "|")
+	cells := strings.Split(trimmed, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// renderTable aligns the columns of a table. A table too wide for the
+// viewport is left as written, one styled line per row, since wrapping
+// would scramble its columns anyway.
+func renderTable(lines []string, width int, styles *Styles) []string {
+	rows := make([][]string, 0, len(lines)-1)
+	for i, line := range lines {
+		if i != 1 {
+			rows = append(rows, tableCells(line))
+		}
+	}
+	var widths []int
+	for _, row := range rows {
+		for c, cell := range row {
+			if c == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[c] = max(widths[c], lipgloss.Width(plainInline(cell)))
+		}
+	}
+	total := 1
+	for _, w := range widths {
+		total += w + 3
+	}
+	if total > width {
+		out := make([]string, len(lines))
+		for i, line := range lines {
+			out[i] = styles.Text.Render(line)
+		}
+		return out
+	}
+
+	bar := styles.MarkdownMarker.Render("│")
+	out := make([]string, 0, len(lines))
+	for r, row := range rows {
+		base := styles.Text
+		if r == 0 {
+			base = styles.MarkdownHeading
+		}
+		var sb strings.Builder
+		sb.WriteString(bar)
+		for c, w := range widths {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			pad := w - lipgloss.Width(plainInline(cell))
+			sb.WriteString(" " + renderInline(cell, base, styles) + strings.Repeat(" ", pad) + " " + bar)
+		}
+		out = append(out, sb.String())
+		if r == 0 {
+			parts := make([]string, len(widths))
+			for c, w := range widths {
+				parts[c] = strings.Repeat("─", w+2)
+			}
+			out = append(out, styles.MarkdownMarker.Render("├"+strings.Join(parts, "┼")+"┤"))
+		}
+	}
+	return out
+}
+
+// ============================================================================
+// Inline spans
+// ============================================================================
+
+// inlineSpan is a run of text in one style.
+type inlineSpan struct {
+	text string
+	kind int
+}
+
+const (
+	spanPlain = iota
+	spanStrong
+	spanEmphasis
+	spanCode
+	spanLink
+	spanURL
+)
+
+// parseInline splits text into code spans, strong and emphasized runs, and
+// links. Unclosed markers are kept as text, so a delta that has not closed
+// its ** yet renders literally until it does.
+func parseInline(text string) []inlineSpan {
+	var spans []inlineSpan
+	plainStart := 0
+	emit := func(start, end int, span inlineSpan) {
+		if plainStart < start {
+			spans = append(spans, inlineSpan{text: text[plainStart:start]})
+		}
+		spans = append(spans, span)
+		plainStart = end
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				emit(i, i+end+2, inlineSpan{text: rest[1 : end+1], kind: spanCode})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 && isEmphasisEdge(text, i, i+end+4) {
+				emit(i, i+end+4, inlineSpan{text: rest[2 : end+2], kind: spanStrong})
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_':
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 && rest[1] != ' ' && isEmphasisEdge(text, i, i+end+2) {
+				emit(i, i+end+2, inlineSpan{text: rest[1 : end+1], kind: spanEmphasis})
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if label, url, n, ok := parseLink(rest); ok {
+				emit(i, i+n, inlineSpan{text: label, kind: spanLink})
+				if url != label {
+					spans = append(spans, inlineSpan{text: " (" + url + ")", kind: spanURL})
+				}
+				i += n
+				continue
+			}
+		}
+		i++
+	}
+	if plainStart < len(text) {
+		spans = append(spans, inlineSpan{text: text[plainStart:]})
+	}
+	return spans
+}
+
+// isEmphasisEdge reports whether an emphasis run text[start:end] stands on
+// word boundaries, so snake_case names and 2*3*4 stay as written.
+func isEmphasisEdge(text string, start, end int) bool {
+	return (start == 0 || !isIdentByte(text[start-1])) && (end >= len(text) || !isIdentByte(text[end]))
+}
+
+// parseLink parses [label](url) at the start of s and returns its length.
+func parseLink(s string) (label, url string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 1 || strings.ContainsAny(s[1:closeLabel], "[]") {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeURL < 1 {
+		return "", "", 0, false
+	}
+	return s[1:closeLabel], s[closeLabel+2 : closeLabel+2+closeURL], closeLabel + 3 + closeURL, true
+}
+
+// renderInline styles text's inline spans on top of base. Each span is
+// rendered on its own because ANSI styles do not nest.
+func renderInline(text string, base lipgloss.Style, styles *Styles) string {
+	var sb strings.Builder
+	for _, span := range parseInline(text) {
+		style := base
+		switch span.kind {
+		case spanStrong:
+			style = styles.MarkdownStrong
+		case spanEmphasis:
+			style = base.Italic(true)
+		case spanCode:
+			style = styles.CodeText
+		case spanLink:
+			style = styles.MarkdownLink
+		case spanURL:
+			style = styles.MarkdownMarker
+		}
+		sb.WriteString(style.Render(span.text))
+	}
+	return sb.String()
+}
+
+// plainInline returns text as renderInline shows it, without styling.
+func plainInline(text string) string {
+	var sb strings.Builder
+	for _, span := range parseInline(text) {
+		sb.WriteString(span.text)
+	}
+	return sb.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isIdentByte reports whether c can be part of a word, for emphasis markers
+// inside words such as snake_case.
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || isDigit(c) || unicode.IsLetter(rune(c))
+}
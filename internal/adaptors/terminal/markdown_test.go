@@ -0,0 +1,132 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestRenderFormatted(t *testing.T) {
+	styles := DefaultStyles()
+	content := "# Title\n\nSome **bold** and `code`, snake_case_name.\n- one\n2. two\n> quoted\n\n" +
+		"| Name | Size |\n|------|-----:|\n| a | 1 |\n| longer | 22 |\n\n```go\nx := 1\n```"
+	got := stripANSI(renderFormatted(content, 40, true, styles))
+	want := strings.Join([]string{
+		"# Title",
+		"",
+		"Some bold and code, snake_case_name.",
+		"• one",
+		"2. two",
+		"│ quoted",
+		"",
+		"│ Name   │ Size │",
+		"├────────┼──────┤",
+		"│ a      │ 1    │",
+		"│ longer │ 22   │",
+		"",
+		"```go",
+		"x := 1",
+		"```",
+	}, "\n")
+	if got != want {
+		t.Errorf("renderFormatted() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderFormattedKeepsWideTables(t *testing.T) {
+	table := "| a very long header | another long header |\n|---|---|\n| x | y |"
+	if got := stripANSI(renderFormatted(table, 20, true, DefaultStyles())); got != table {
+		t.Errorf("table wider than the viewport changed:\n%s", got)
+	}
+}
+
+func TestParseInlineLeavesUnclosedMarkers(t *testing.T) {
+	for _, text := range []string{"**bo", "2 * 3 * 4", "a_b_c", "[link](", "`open"} {
+		if got := plainInline(text); got != text {
+			t.Errorf("plainInline(%q) = %q", text, got)
+		}
+	}
+	if got := plainInline("see [docs](https://example.com)"); got != "see docs (https://example.com)" {
+		t.Errorf("link = %q", got)
+	}
+}
+
+func TestParagraphTracker(t *testing.T) {
+	var tr paragraphTracker
+	tr.feed("First para\ncontinues\n")
+	if tr.end != 0 {
+		t.Errorf("end = %d before any blank line", tr.end)
+	}
+	tr.feed("\nSecond")
+	if want := len("First para\ncontinues\n\n"); tr.end != want {
+		t.Errorf("end = %d, want %d", tr.end, want)
+	}
+	// Blank lines inside a code block do not end a paragraph
+	before := tr.end
+	tr.feed("\n```\ncode\n\nmore\n")
+	if tr.end != before {
+		t.Errorf("blank line in a code block moved end to %d", tr.end)
+	}
+	tr.feed("```\n")
+	if !strings.HasSuffix("First para\ncontinues\n\nSecond\n```\ncode\n\nmore\n```\n"[:tr.end], "```\n") {
+		t.Errorf("closing fence did not end a paragraph: end = %d", tr.end)
+	}
+}
+
+// renderAssistant renders the assistant window of a buffer without ANSI codes.
+func renderAssistant(wb *WindowBuffer) string {
+	w := wb.GetWindow(0)
+	return stripANSI(w.Render(wb.Width(), false, wb.styles, wb.borderStyle, wb.cursorStyle))
+}
+
+func TestStreamedMarkdownMatchesWhole(t *testing.T) {
+	content := "# Plan\n\nThis is **important** text that is long enough to wrap in a narrow window.\n\n" +
+		"| k | v |\n|---|---|\n| a | 1 |\n\n```go\nfunc main() {}\n```\nDone *now*."
+
+	whole := NewWindowBuffer(40, DefaultStyles())
+	whole.SetRender(true)
+	whole.AppendOrUpdate("s", stream.TagTextAssistant, content)
+
+	streamed := NewWindowBuffer(40, DefaultStyles())
+	streamed.SetRender(true)
+	for i, r := range content {
+		streamed.AppendOrUpdate("s", stream.TagTextAssistant, string(r))
+		if i%7 == 0 {
+			renderAssistant(streamed) // populate the caches mid-stream
+		}
+	}
+
+	if got, want := renderAssistant(streamed), renderAssistant(whole); got != want {
+		t.Errorf("streamed render differs:\n%s\nwhole:\n%s", got, want)
+	}
+}
+
+func TestToggleRenderKeepsContent(t *testing.T) {
+	wb := NewWindowBuffer(60, DefaultStyles())
+	wb.AppendOrUpdate("s", stream.TagTextAssistant, "Use **bold** here.\n\n- item")
+	wb.AppendOrUpdate("r", stream.TagTextReasoning, "thinking **hard**")
+	raw := renderAssistant(wb)
+	if !strings.Contains(raw, "**bold**") {
+		t.Fatalf("raw render lost the markup:\n%s", raw)
+	}
+
+	wb.SetRender(true)
+	rendered := renderAssistant(wb)
+	if strings.Contains(rendered, "**") || !strings.Contains(rendered, "• item") {
+		t.Errorf("rendered:\n%s", rendered)
+	}
+	// Text streamed while rendering is kept raw underneath
+	wb.AppendOrUpdate("s", stream.TagTextAssistant, "\n- **more**")
+
+	wb.SetRender(false)
+	if got := renderAssistant(wb); !strings.Contains(got, "│ - item ") || !strings.Contains(got, "- **more**") {
+		t.Errorf("after toggling off:\n%s", got)
+	}
+	if w := wb.GetWindow(1); w.Content != "thinking **hard**" {
+		t.Errorf("reasoning content = %q", w.Content)
+	}
+	if got := stripANSI(wb.GetWindow(0).Content); got != "Use **bold** here.\n\n- item\n- **more**" {
+		t.Errorf("content = %q", got)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"image/color"
 	"os"
-	"os/exec"
 	"strings"
 
 	"charm.land/bubbles/v2/textinput"
@@ -571,12 +570,12 @@ func OpenModelConfigFile(path string) error {
 		}
 	}
 
-	editor := os.Getenv("EDITOR")
+	editor := getEditorCommand(os.Getenv("EDITOR"))
 	if editor == "" {
-		editor = "vi"
+		return errNoEditor()
 	}
 
-	cmd := exec.Command(editor, path)
+	cmd := editorExec(editor, path)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
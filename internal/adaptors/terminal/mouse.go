@@ -0,0 +1,70 @@
+package terminal
+
+// Mouse support.
+// The wheel scrolls the display window whichever window has focus, and a
+// click focuses the window under the pointer. --no-mouse turns mouse
+// reporting off, leaving text selection to the terminal.
+
+import (
+	tea "charm.land/bubbletea/v2"
+)
+
+// MouseWheelLines is how far one wheel step scrolls the display.
+const MouseWheelLines = 3
+
+// SetMouse enables or disables mouse reporting. It is on by default.
+func (m *Terminal) SetMouse(enabled bool) {
+	m.mouse = enabled
+}
+
+// mouseBlocked reports whether an overlay, dialog, or the search prompt owns
+// the screen, in which case mouse events are ignored.
+func (m *Terminal) mouseBlocked() bool {
+	return m.modelSelector.IsOpen() || m.themeSelector.IsOpen() || m.queueManager.IsOpen() || m.promptPicker.IsOpen() ||
+		m.stopping || m.confirmDialog || m.cancelConfirmDialog || m.cancelAllConfirmDialog ||
+		m.search.prompting
+}
+
+// handleMouseWheel scrolls the display. Scrolling up stops it following new
+// output; scrolling back to the bottom resumes it.
+func (m *Terminal) handleMouseWheel(msg tea.MouseWheelMsg) (tea.Model, tea.Cmd) {
+	if m.mouseBlocked() {
+		return m, nil
+	}
+	switch msg.Button {
+	case tea.MouseWheelUp:
+		m.display.MarkUserScrolled()
+		m.display.ScrollUp(MouseWheelLines)
+	case tea.MouseWheelDown:
+		m.display.ScrollDown(MouseWheelLines)
+		if m.display.AtBottom() {
+			m.display.ResumeFollow()
+		}
+	default:
+		return m, nil
+	}
+	m.display.updateContent()
+	return m, nil
+}
+
+// handleMouseClick focuses the display or the input box, whichever was
+// clicked. Clicks on the status bar are ignored.
+func (m *Terminal) handleMouseClick(msg tea.MouseClickMsg) (tea.Model, tea.Cmd) {
+	if m.mouseBlocked() || msg.Button != tea.MouseLeft {
+		return m, nil
+	}
+	displayRows := m.display.GetHeight()
+	switch {
+	case msg.Y < displayRows:
+		if m.focusedWindow != focusDisplay {
+			m.focusDisplay()
+			m.display.updateContent()
+		}
+	case msg.Y < displayRows+InputRows:
+		if m.focusedWindow != focusInput {
+			m.focusInput()
+			m.display.updateContent()
+		}
+	}
+	return m, nil
+}
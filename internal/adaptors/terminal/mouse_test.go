@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// newMouseTestTerminal returns a terminal whose display holds more lines than
+// fit on screen.
+func newMouseTestTerminal(t *testing.T) *Terminal {
+	t.Helper()
+	output := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, output, stream.NewChanInput(10), nil, 80, 24)
+	for i := range 10 {
+		content := strings.Repeat(fmt.Sprintf("window %d line\n", i), 8)
+		output.windowBuffer.AppendOrUpdate(fmt.Sprintf("w%d", i), stream.TagTextAssistant, content)
+	}
+	terminal.display.updateContent()
+	if !terminal.display.AtBottom() {
+		t.Fatal("expected the display to start at the bottom")
+	}
+	return terminal
+}
+
+func TestMouseWheelScrollsDisplayWhileInputFocused(t *testing.T) {
+	terminal := newMouseTestTerminal(t)
+	bottom := terminal.display.YOffset()
+
+	terminal.Update(tea.MouseWheelMsg{Button: tea.MouseWheelUp, Y: 5})
+	if got := terminal.display.YOffset(); got != bottom-MouseWheelLines {
+		t.Errorf("YOffset after wheel up = %d, want %d", got, bottom-MouseWheelLines)
+	}
+	if terminal.display.shouldFollow() {
+		t.Error("wheel up should stop following new output")
+	}
+	if terminal.focusedWindow != focusInput {
+		t.Errorf("wheel changed focus to %q", terminal.focusedWindow)
+	}
+
+	// New output must not pull the view back down
+	terminal.out.WindowBuffer().AppendOrUpdate("w10", stream.TagTextAssistant, "more")
+	terminal.display.updateContent()
+	if got := terminal.display.YOffset(); got != bottom-MouseWheelLines {
+		t.Errorf("YOffset after new output = %d, want %d", got, bottom-MouseWheelLines)
+	}
+
+	terminal.Update(tea.MouseWheelMsg{Button: tea.MouseWheelDown, Y: 5})
+	terminal.Update(tea.MouseWheelMsg{Button: tea.MouseWheelDown, Y: 5})
+	if !terminal.display.AtBottom() {
+		t.Error("wheel down should reach the bottom")
+	}
+	if !terminal.display.shouldFollow() {
+		t.Error("reaching the bottom should follow new output again")
+	}
+}
+
+func TestMouseClickFocusesWindow(t *testing.T) {
+	terminal := newMouseTestTerminal(t)
+
+	terminal.Update(tea.MouseClickMsg{Button: tea.MouseLeft, Y: 2})
+	if terminal.focusedWindow != focusDisplay {
+		t.Fatalf("click on display: focus = %q, want %q", terminal.focusedWindow, focusDisplay)
+	}
+	if terminal.display.GetWindowCursor() < 0 {
+		t.Error("focusing the display should select a window")
+	}
+
+	inputRow := terminal.display.GetHeight() + 1
+	terminal.Update(tea.MouseClickMsg{Button: tea.MouseLeft, Y: inputRow})
+	if terminal.focusedWindow != focusInput {
+		t.Fatalf("click on input: focus = %q, want %q", terminal.focusedWindow, focusInput)
+	}
+
+	// The status bar is the last row
+	terminal.Update(tea.MouseClickMsg{Button: tea.MouseLeft, Y: 23})
+	if terminal.focusedWindow != focusInput {
+		t.Errorf("click on status bar changed focus to %q", terminal.focusedWindow)
+	}
+}
+
+func TestMouseIgnoredWhileOverlayOpen(t *testing.T) {
+	terminal := newMouseTestTerminal(t)
+	bottom := terminal.display.YOffset()
+	terminal.queueManager.Open()
+
+	terminal.Update(tea.MouseWheelMsg{Button: tea.MouseWheelUp, Y: 5})
+	terminal.Update(tea.MouseClickMsg{Button: tea.MouseLeft, Y: 2})
+	if terminal.display.YOffset() != bottom || terminal.focusedWindow != focusInput {
+		t.Error("mouse events should be ignored while an overlay is open")
+	}
+}
+
+func TestViewMouseMode(t *testing.T) {
+	terminal := newMouseTestTerminal(t)
+	if got := terminal.View().MouseMode; got != tea.MouseModeCellMotion {
+		t.Errorf("MouseMode = %v, want cell motion", got)
+	}
+	terminal.SetMouse(false)
+	if got := terminal.View().MouseMode; got != tea.MouseModeNone {
+		t.Errorf("MouseMode with mouse off = %v, want none", got)
+	}
+}
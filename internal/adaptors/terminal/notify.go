@@ -0,0 +1,75 @@
+package terminal
+
+// Turn notifications.
+// When a prompt that ran for at least --notify-after finishes, the session
+// sends a TagTurnAlert. If the terminal is not focused, or never reported
+// focus so it may not be, the user is alerted with a BEL and, with --notify
+// desktop, an OSC 9 and an OSC 777 desktop notification (kitty, WezTerm,
+// iTerm2, foot). Terminals skip the sequences they do not understand.
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/config"
+)
+
+// SetNotify sets the --notify mode and the title of desktop notifications.
+func (m *Terminal) SetNotify(mode, title string) {
+	m.notifyMode = mode
+	m.notifyTitle = title
+}
+
+// notifyTitle names notifications after the session file, if there is one,
+// to tell several running sessions apart.
+func notifyTitle(sessionFile string) string {
+	if sessionFile == "" {
+		return "AlayaCore"
+	}
+	base := filepath.Base(sessionFile)
+	return "AlayaCore: " + strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// alertCmd returns the notification for the alerts received since the last
+// update, or nil when there are none or the user is looking.
+func (m *Terminal) alertCmd() tea.Cmd {
+	return m.notifyAlerts(m.out.TakeAlerts())
+}
+
+// notifyAlerts returns the notification for alerts of any session.
+func (m *Terminal) notifyAlerts(alerts []string) tea.Cmd {
+	if len(alerts) == 0 || m.notifyMode == "" || m.notifyMode == config.NotifyOff {
+		return nil
+	}
+	if m.hasFocus && m.focusReported {
+		return nil
+	}
+	// One notification for a burst: the latest summary
+	return tea.Raw(notifySequence(m.notifyMode, m.notifyTitle, alerts[len(alerts)-1]))
+}
+
+// notifySequence returns the escape sequences for an alert: BEL, then for
+// desktop mode OSC 9 (body only) and OSC 777 (title and body).
+func notifySequence(mode, title, body string) string {
+	if mode != config.NotifyDesktop {
+		return "\a"
+	}
+	title, body = oscText(title), oscText(body)
+	return "\a\x1b]9;" + body + "\a" + "\x1b]777;notify;" + title + ";" + body + "\a"
+}
+
+// oscText removes what would end an OSC string early or split an OSC 777
+// field: control characters and semicolons.
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			return ' '
+		}
+		return r
+	}, s)
+}
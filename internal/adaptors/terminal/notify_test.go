@@ -0,0 +1,76 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// alertOutput returns the escape sequence of cmd, or "" for none.
+func alertOutput(t *testing.T, cmd tea.Cmd) string {
+	t.Helper()
+	if cmd == nil {
+		return ""
+	}
+	raw, ok := cmd().(tea.RawMsg)
+	if !ok {
+		t.Fatalf("expected a raw message, got %T", cmd())
+	}
+	return raw.Msg.(string)
+}
+
+func TestAlertOnlyWhenNotFocused(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	terminal.SetNotify(config.NotifyBell, "AlayaCore")
+
+	// No focus event yet: the terminal may not report focus, so alert
+	writeTLV(out, stream.TagTurnAlert, "#1 done, 1.2k tokens, 45.0s: Refactor")
+	if got := alertOutput(t, terminal.alertCmd()); got != "\a" {
+		t.Errorf("alert = %q, want BEL", got)
+	}
+	if terminal.alertCmd() != nil {
+		t.Error("an alert should be taken once")
+	}
+
+	terminal.handleFocus()
+	writeTLV(out, stream.TagTurnAlert, "#2 done")
+	if cmd := terminal.alertCmd(); cmd != nil {
+		t.Error("no alert while focused")
+	}
+
+	terminal.handleBlur()
+	writeTLV(out, stream.TagTurnAlert, "#3 done")
+	if got := alertOutput(t, terminal.alertCmd()); got != "\a" {
+		t.Errorf("alert after blur = %q, want BEL", got)
+	}
+
+	terminal.SetNotify(config.NotifyOff, "AlayaCore")
+	writeTLV(out, stream.TagTurnAlert, "#4 done")
+	if cmd := terminal.alertCmd(); cmd != nil {
+		t.Error("no alert with --notify off")
+	}
+}
+
+func TestNotifySequence(t *testing.T) {
+	got := notifySequence(config.NotifyDesktop, "AlayaCore: work", "#1 done; ok\x1b\a")
+	want := "\a\x1b]9;#1 done, ok  \a\x1b]777;notify;AlayaCore: work;#1 done, ok  \a"
+	if got != want {
+		t.Errorf("desktop = %q, want %q", got, want)
+	}
+	if got := notifySequence(config.NotifyBell, "t", "b"); got != "\a" {
+		t.Errorf("bell = %q", got)
+	}
+}
+
+func TestNotifyTitle(t *testing.T) {
+	if got := notifyTitle(""); got != "AlayaCore" {
+		t.Errorf("no session: %q", got)
+	}
+	if got := notifyTitle("/tmp/refactor.session"); got != "AlayaCore: refactor" {
+		t.Errorf("session: %q", got)
+	}
+}
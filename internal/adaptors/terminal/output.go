@@ -8,14 +8,17 @@ package terminal
 // Always render segments separately, then join them.
 
 import (
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
 )
 
 // outputWriter parses TLV from the session and writes styled content to the WindowBuffer.
@@ -25,37 +28,41 @@ type outputWriter struct {
 	buffer            []byte
 	mu                sync.Mutex
 	updateChan        chan struct{}
-	done              chan struct{}        // Signal goroutine to stop
-	status            string               // Status bar content from TagSystem
-	inProgress        bool                 // Whether session has task in progress
-	styles            *Styles              // UI styles
-	nextWindowID      int                  // Monotonic counter for generating window IDs
-	pendingUpdate     bool                 // Whether there's a pending update to flush
-	lastUpdate        time.Time            // Last time an update was sent
-	updateMu          sync.Mutex           // Mutex for update throttling
-	models            []agentpkg.ModelInfo // Current model list
-	activeModelID     int                  // Current active model ID
-	hasModels         bool                 // Whether models are configured
-	modelConfigPath   string               // Path to model.conf
-	activeModelName   string               // Name of active model
-	pendingQueueItems []QueueItem          // Queue items from taskqueue_get_all
-	queueCount        int                  // Number of items in the queue
-	currentStep       int                  // Current step in agent loop (1-indexed)
-	maxSteps          int                  // Maximum steps allowed
-	lastCurrentStep   int                  // Last step reached in completed task
-	lastMaxSteps      int                  // Last max steps from completed task
+	flushTimer        *time.Timer            // Sends the pending update once the throttle interval passes
+	status            string                 // Status bar content from TagSystem
+	inProgress        bool                   // Whether session has task in progress
+	styles            *Styles                // UI styles
+	nextWindowID      int                    // Monotonic counter for generating window IDs
+	pendingUpdate     bool                   // Whether there's a pending update to flush
+	lastUpdate        time.Time              // Last time an update was sent
+	updateMu          sync.Mutex             // Mutex for update throttling
+	models            []agentpkg.ModelInfo   // Current model list
+	activeModelID     int                    // Current active model ID
+	hasModels         bool                   // Whether models are configured
+	modelConfigPath   string                 // Path to model.conf
+	activeModelName   string                 // Name of active model
+	pendingQueueItems []QueueItem            // Queue items from taskqueue_get_all
+	queueCount        int                    // Number of items in the queue
+	currentStep       int                    // Current step in agent loop (1-indexed)
+	maxSteps          int                    // Maximum steps allowed
+	lastCurrentStep   int                    // Last step reached in completed task
+	lastMaxSteps      int                    // Last max steps from completed task
+	transcript        *transcript            // Plain-text copy of the output, if enabled
+	lastResponse      responseTracker        // Plain text of the latest assistant response, for :copy
+	alerts            []string               // TagTurnAlert summaries not yet taken by the UI
+	reviews           []agentpkg.ReviewFrame // Changes awaiting the user's review, oldest first
+	typedResults      map[string]bool        // Tool call IDs shown from a TagToolResult, whose FR frames are skipped
+	sessionState      agentpkg.SessionState  // Modes shown above the input box
+	eventTime         time.Time              // From the TagTime frame, for the frame after it
 }
 
 func NewTerminalOutput(styles *Styles) *outputWriter { //nolint:revive // tests need access to internal methods
 	to := &outputWriter{
 		windowBuffer: NewWindowBuffer(DefaultWidth, styles),
 		updateChan:   make(chan struct{}, 1),
-		done:         make(chan struct{}),
 		styles:       styles,
 		lastUpdate:   time.Now(),
 	}
-	// Start background update flusher
-	go to.updateFlusher()
 	return to
 }
 
@@ -67,33 +74,42 @@ func (to *outputWriter) SetStyles(styles *Styles) {
 	to.windowBuffer.SetStyles(styles)
 }
 
-// Close stops the background goroutine and cleans up resources
+// SetTranscript tees all further output into t. Call it before the session
+// starts writing.
+func (w *outputWriter) SetTranscript(t *transcript) {
+	w.mu.Lock()
+	w.transcript = t
+	w.mu.Unlock()
+}
+
+// Close stops a pending update from being sent
 func (w *outputWriter) Close() error {
-	close(w.done)
+	w.updateMu.Lock()
+	defer w.updateMu.Unlock()
+	if w.flushTimer != nil {
+		w.flushTimer.Stop()
+	}
+	w.pendingUpdate = false
 	return nil
 }
 
-// updateFlusher periodically flushes pending updates
-func (w *outputWriter) updateFlusher() {
-	ticker := time.NewTicker(FlusherInterval)
-	defer ticker.Stop()
+// signalUpdate wakes the Terminal to refresh the display. The channel holds
+// one signal, so signals sent before the Terminal catches up coalesce.
+func (w *outputWriter) signalUpdate() {
+	select {
+	case w.updateChan <- struct{}{}:
+	default:
+	}
+}
 
-	for {
-		select {
-		case <-w.done:
-			return
-		case <-ticker.C:
-			w.updateMu.Lock()
-			if w.pendingUpdate && time.Since(w.lastUpdate) >= UpdateThrottleInterval {
-				w.pendingUpdate = false
-				w.lastUpdate = time.Now()
-				select {
-				case w.updateChan <- struct{}{}:
-				default:
-				}
-			}
-			w.updateMu.Unlock()
-		}
+// flushPendingUpdate sends the update held back by the throttle.
+func (w *outputWriter) flushPendingUpdate() {
+	w.updateMu.Lock()
+	defer w.updateMu.Unlock()
+	if w.pendingUpdate {
+		w.pendingUpdate = false
+		w.lastUpdate = time.Now()
+		w.signalUpdate()
 	}
 }
 
@@ -116,22 +132,34 @@ func (w *outputWriter) Flush() error {
 // AppendError adds an error message to the display buffer with error styling
 func (w *outputWriter) AppendError(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
+	if w.transcript != nil {
+		w.transcript.Record(stream.TagSystemError, msg)
+	}
 	id := w.generateWindowID()
 	w.windowBuffer.AppendOrUpdate(id, stream.TagSystemError, w.styles.Error.Render(msg))
+	w.triggerUpdateForTag(stream.TagSystemError)
 }
 
 // WriteNotify writes a notification message to the display
 func (w *outputWriter) WriteNotify(msg string) {
+	if w.transcript != nil {
+		w.transcript.Record(stream.TagSystemNotify, msg)
+	}
 	id := w.generateWindowID()
 	w.windowBuffer.AppendOrUpdate(id, stream.TagSystemNotify, w.styles.System.Render(msg))
 	w.triggerUpdateForTag(stream.TagSystemNotify)
 }
 
-// processBuffer parses TLV-encoded data from the buffer
+// processBuffer parses TLV-encoded data from the buffer. A malformed header
+// is reported once and skipped up to the next plausible header.
 func (w *outputWriter) processBuffer() {
 	for len(w.buffer) >= 6 {
-		tag := string(w.buffer[0:2])
-		length := int(binary.BigEndian.Uint32(w.buffer[2:6]))
+		tag, length, err := stream.ParseHeader(w.buffer)
+		if err != nil {
+			w.AppendError("Dropped malformed output: %v", err)
+			w.buffer = stream.SkipToHeader(w.buffer)
+			continue
+		}
 
 		if len(w.buffer) < 6+length {
 			break
@@ -146,6 +174,14 @@ func (w *outputWriter) processBuffer() {
 // writeColored writes styled content based on the TLV tag
 func (w *outputWriter) writeColored(tag string, value string) {
 	w.triggerUpdateForTag(tag)
+	if w.transcript != nil {
+		w.transcript.Record(tag, value)
+	}
+	if tag == stream.TagTime {
+		w.setEventTime(value)
+		return
+	}
+	at := w.takeEventTime()
 
 	switch tag {
 	// Text content tags (delta messages with stream ID prefix)
@@ -158,6 +194,9 @@ func (w *outputWriter) writeColored(tag string, value string) {
 		}
 		// Pass raw content - styling is applied during render
 		w.windowBuffer.AppendOrUpdate(id, tag, content)
+		if tag == stream.TagTextAssistant {
+			w.lastResponse.add(id, content)
+		}
 
 	// Function call (JSON: id, name, input)
 	case stream.TagFunctionCall:
@@ -166,10 +205,29 @@ func (w *outputWriter) writeColored(tag string, value string) {
 			return
 		}
 		handler := GetHandler(tc.Name)
-		formatted := handler.FormatCall(json.RawMessage(tc.Input), w.styles)
+		// The model wrote the input: show escapes in it instead of obeying them
+		formatted := tools.EscapeControls(handler.FormatCall(json.RawMessage(tc.Input), w.styles))
 
 		// Pass formatted but unstyled content - styling is applied during render
 		w.windowBuffer.AppendToolCall(tc.ID, tc.Name, formatted)
+		w.windowBuffer.SetTime(tc.ID, at)
+
+	// Structured result (JSON: id, kind, data), shown rendered instead of
+	// the FR frames that follow
+	case stream.TagToolResult:
+		var tr TypedResultData
+		if err := json.Unmarshal([]byte(value), &tr); err != nil {
+			return
+		}
+		if w.typedResults == nil {
+			w.typedResults = make(map[string]bool)
+		}
+		w.typedResults[tr.ID] = true
+		handler := w.windowBuffer.GetHandler(tr.ID)
+		if handler != nil && !handler.ShouldShowOutput() {
+			return
+		}
+		w.windowBuffer.AppendOrUpdate(tr.ID, stream.TagFunctionResult, tools.FormatResult(tr.Kind, tr.Data))
 
 	// Function result (JSON: id, output)
 	case stream.TagFunctionResult:
@@ -177,13 +235,23 @@ func (w *outputWriter) writeColored(tag string, value string) {
 		if err := json.Unmarshal([]byte(value), &tr); err != nil {
 			return
 		}
+		if w.typedResults[tr.ID] {
+			return
+		}
+		output := tr.Output
 		handler := w.windowBuffer.GetHandler(tr.ID)
 		if handler != nil && !handler.ShouldShowOutput() {
-			// Skip output for tools that don't show it
-			return
+			// Skip output for tools that don't show it, unless they failed
+			if !tr.Error {
+				return
+			}
+			// Their calls don't end in a newline, as nothing normally follows
+			if !tr.Continued {
+				output = "\n" + output
+			}
 		}
 		// Pass raw output - styling is applied during render
-		w.windowBuffer.AppendOrUpdate(tr.ID, tag, tr.Output)
+		w.windowBuffer.AppendOrUpdate(tr.ID, tag, output)
 
 	// Function output status indicator
 	case stream.TagFunctionState:
@@ -200,7 +268,7 @@ func (w *outputWriter) writeColored(tag string, value string) {
 		// Pass raw value - styling is applied during render
 		w.windowBuffer.AppendOrUpdate(id, tag, value)
 
-	case stream.TagSystemNotify:
+	case stream.TagSystemNotify, stream.TagSystemLog:
 		id := w.generateWindowID()
 		// Pass raw value - styling is applied during render
 		w.windowBuffer.AppendOrUpdate(id, tag, value)
@@ -209,11 +277,43 @@ func (w *outputWriter) writeColored(tag string, value string) {
 		w.handleSystemTag(value)
 		return
 
+	case stream.TagPlan:
+		// A fresh window per change keeps the latest plan below the output
+		// that changed it
+		content, ok := formatPlan(value)
+		if !ok {
+			return
+		}
+		w.windowBuffer.AppendOrUpdate(w.generateWindowID(), tag, content)
+
+	case stream.TagClear:
+		// :clear started the conversation over; show only what follows
+		w.windowBuffer.Clear()
+		w.typedResults = nil
+
+	case stream.TagTurnEnd:
+		// The status bar follows InProgress in the system data instead
+		w.windowBuffer.EndThinking()
+		return
+
+	case stream.TagTurnAlert:
+		// The "#N done" notice already shows it; the UI decides on a notification
+		w.alerts = append(w.alerts, value)
+		return
+
+	case stream.TagReview:
+		var frame agentpkg.ReviewFrame
+		if err := json.Unmarshal([]byte(value), &frame); err != nil {
+			return
+		}
+		w.handleReview(frame)
+
 	// User text tag
 	case stream.TagTextUser:
 		id := w.generateWindowID()
 		// Pass raw value - styling is applied during render
 		w.windowBuffer.AppendOrUpdate(id, tag, value)
+		w.windowBuffer.SetTime(id, at)
 
 	default:
 		id := w.generateWindowID()
@@ -221,29 +321,88 @@ func (w *outputWriter) writeColored(tag string, value string) {
 	}
 }
 
+// formatPlan renders a TagPlan value as a titled checkbox list.
+func formatPlan(value string) (string, bool) {
+	var items []tools.TodoItem
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return "", false
+	}
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return fmt.Sprintf("Plan (%d/%d done)\n%s", done, len(items), tools.FormatTodoList(items)), true
+}
+
+// handleReview shows a change awaiting review with its diff and keeps it
+// pending until its decision arrives.
+func (w *outputWriter) handleReview(frame agentpkg.ReviewFrame) {
+	if frame.Decision == "" {
+		w.reviews = append(w.reviews, frame)
+		content := fmt.Sprintf("Review %s: %s %s\n%s", frame.ID, frame.Tool, frame.Subject(), strings.TrimRight(frame.Diff, "\n"))
+		w.windowBuffer.AppendOrUpdate(w.generateWindowID(), stream.TagReview, content)
+		return
+	}
+
+	for i, r := range w.reviews {
+		if r.ID == frame.ID {
+			w.reviews = append(w.reviews[:i], w.reviews[i+1:]...)
+			break
+		}
+	}
+	w.windowBuffer.AppendOrUpdate(w.generateWindowID(), stream.TagSystemNotify, reviewOutcome(frame))
+}
+
+// reviewOutcome describes the decision on a reviewed change, e.g.
+// "R2 rejected: main.go (use a constant)".
+func reviewOutcome(frame agentpkg.ReviewFrame) string {
+	msg := fmt.Sprintf("%s %s: %s", frame.ID, frame.Decision, frame.Subject())
+	if frame.Reason != "" {
+		msg += " (" + frame.Reason + ")"
+	}
+	return msg
+}
+
+// PendingReviews returns the changes awaiting the user's review, oldest
+// first.
+func (w *outputWriter) PendingReviews() []agentpkg.ReviewFrame {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]agentpkg.ReviewFrame(nil), w.reviews...)
+}
+
+// SessionState returns the session's modes from the latest TagSystemData.
+func (w *outputWriter) SessionState() agentpkg.SessionState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sessionState
+}
+
 // triggerUpdateForTag sends an update signal for tags that modify the display
 // Uses throttling to batch rapid updates together
 func (w *outputWriter) triggerUpdateForTag(tag string) {
 	switch tag {
 	// Text content tags
 	case stream.TagTextAssistant, stream.TagTextReasoning, stream.TagTextUser,
-		stream.TagFunctionCall,
+		stream.TagFunctionCall, stream.TagPlan, stream.TagClear, stream.TagTurnAlert, stream.TagReview,
 		// System tags
-		stream.TagSystemError, stream.TagSystemNotify, stream.TagSystemData:
+		stream.TagSystemError, stream.TagSystemNotify, stream.TagSystemData, stream.TagSystemLog:
 		w.updateMu.Lock()
 		defer w.updateMu.Unlock()
 
-		// If enough time has passed since last update, send immediately
-		if time.Since(w.lastUpdate) >= UpdateThrottleInterval {
+		// If enough time has passed since last update, send immediately;
+		// otherwise send one update when the interval is over
+		since := time.Since(w.lastUpdate)
+		switch {
+		case since >= UpdateThrottleInterval:
 			w.lastUpdate = time.Now()
 			w.pendingUpdate = false
-			select {
-			case w.updateChan <- struct{}{}:
-			default:
-			}
-		} else {
-			// Mark that we have a pending update
+			w.signalUpdate()
+		case !w.pendingUpdate:
 			w.pendingUpdate = true
+			w.flushTimer = time.AfterFunc(UpdateThrottleInterval-since, w.flushPendingUpdate)
 		}
 	}
 }
@@ -265,6 +424,8 @@ func (w *outputWriter) handleSystemTag(value string) {
 		}
 
 		w.inProgress = info.InProgress
+		w.sessionState = info.SessionState
+		w.windowBuffer.SetShowTimes(info.Timestamps)
 		w.queueCount = len(info.QueueItems)
 		if info.ContextLimit > 0 {
 			pct := float64(info.ContextTokens) * 100.0 / float64(info.ContextLimit)
@@ -272,6 +433,40 @@ func (w *outputWriter) handleSystemTag(value string) {
 		} else {
 			w.status = fmt.Sprintf("Context: %d", info.ContextTokens)
 		}
+		if info.CachedTokens > 0 {
+			w.status += fmt.Sprintf(" | Cached: %d", info.CachedTokens)
+		}
+		if info.EstimatedTokens > 0 {
+			w.status += " | est. " + formatCompactTokens(info.EstimatedTokens)
+			if info.ContextWindow > 0 {
+				w.status += "/" + formatCompactTokens(info.ContextWindow)
+			}
+		}
+		if info.TotalTokens > 0 {
+			if info.Cost != nil {
+				w.status += " | " + agentpkg.FormatCost(*info.Cost, true)
+			} else {
+				w.status += " | " + agentpkg.FormatCost(0, false)
+			}
+		}
+		if info.Checkpoint != "" {
+			w.status += " | checkpoint: " + info.Checkpoint
+			if info.CheckpointDiverged {
+				w.status += " (diverged)"
+			}
+		}
+		if info.ChangedFiles == 1 {
+			w.status += " | 1 file changed"
+		} else if info.ChangedFiles > 1 {
+			w.status += fmt.Sprintf(" | %d files changed", info.ChangedFiles)
+		}
+		if info.Workdir != "" {
+			w.status += " | " + shortPath(info.Workdir)
+		}
+		if info.DryRun {
+			// First, so it is never cut off
+			w.status = "DRY RUN | " + w.status
+		}
 		// Store model info
 		w.models = info.Models
 		w.activeModelID = info.ActiveModelID
@@ -299,11 +494,8 @@ func (w *outputWriter) handleSystemTag(value string) {
 		w.currentStep = info.CurrentStep
 		w.maxSteps = info.MaxSteps
 
-		// Signal update so tick handler picks up changes
-		select {
-		case w.updateChan <- struct{}{}:
-		default:
-		}
+		// Signal update so the Terminal picks up changes
+		w.signalUpdate()
 	}
 }
 
@@ -365,6 +557,24 @@ func (w *outputWriter) GetStatus() string {
 	return w.status
 }
 
+// LastResponse returns the plain text of the latest assistant response, or
+// "" before the first one.
+func (w *outputWriter) LastResponse() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastResponse.String()
+}
+
+// TakeAlerts returns and clears the TagTurnAlert summaries received since the
+// last call.
+func (w *outputWriter) TakeAlerts() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	alerts := w.alerts
+	w.alerts = nil
+	return alerts
+}
+
 // IsInProgress returns whether the session has a task in progress
 func (w *outputWriter) IsInProgress() bool {
 	w.mu.Lock()
@@ -403,3 +613,30 @@ func (w *outputWriter) generateWindowID() string {
 func (w *outputWriter) SetWindowWidth(width int) {
 	w.windowBuffer.SetWidth(width)
 }
+
+// shortPath writes a directory under the home directory as "~/...".
+func shortPath(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return dir
+}
+
+// formatCompactTokens renders a token count for the status bar: 38k, 1.2M.
+func formatCompactTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%dk", (n+500)/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
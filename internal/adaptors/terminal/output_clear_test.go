@@ -0,0 +1,34 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestClearFrameEmptiesDisplay(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	writeTLV(out, stream.TagTextUser, "#1 ▸ explain the parser")
+	writeTLV(out, stream.TagTextAssistant, "[:1-1-t:]The parser reads tokens.")
+	writeTLV(out, stream.TagSystemNotify, "#1 done, 1.2k tokens, 2.0s")
+	terminal.focusDisplay()
+	terminal.display.SetWindowCursor(1)
+
+	writeTLV(out, stream.TagTextUser, "#2 ▸ :clear")
+	writeTLV(out, stream.TagClear, "")
+	writeTLV(out, stream.TagSystemNotify, "Conversation cleared, dropped 2 messages")
+	terminal.handleUpdate()
+
+	rendered := stripANSI(out.WindowBuffer().GetAll(-1))
+	if strings.Contains(rendered, "parser") || strings.Contains(rendered, ":clear") {
+		t.Errorf("output before the clear is still shown:\n%s", rendered)
+	}
+	if !strings.Contains(rendered, "Conversation cleared") {
+		t.Errorf("the notice after the clear is missing:\n%s", rendered)
+	}
+	if got := terminal.display.GetWindowCursor(); got != 0 {
+		t.Errorf("cursor = %d, want the only window left", got)
+	}
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestMalformedOutputIsDroppedAndResynced(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	// A length with the high bit set, then a good frame
+	_, _ = out.Write([]byte{'T', 'A', 0xff, 0xff, 0xff, 0xf0, 'x', 'y'})
+	_ = stream.WriteTLV(out, stream.TagSystemNotify, "still here")
+
+	var sawError, sawNotify bool
+	for _, w := range out.windowBuffer.Windows {
+		switch w.Tag {
+		case stream.TagSystemError:
+			sawError = strings.Contains(w.Content, "Dropped malformed output")
+		case stream.TagSystemNotify:
+			sawNotify = strings.Contains(w.Content, "still here")
+		}
+	}
+	if !sawError {
+		t.Error("no error for the malformed frame")
+	}
+	if !sawNotify {
+		t.Error("the frame after the malformed one was lost")
+	}
+}
+
+func FuzzOutputWriter(f *testing.F) {
+	f.Add(stream.EncodeTLV(stream.TagTextAssistant, "[:1-1-t:]hello"))
+	f.Add([]byte{'T', 'A', 0x80, 0, 0, 0})
+	f.Add([]byte("\x00\x01garbage"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out := NewTerminalOutput(DefaultStyles())
+		defer out.Close()
+		// Split writes exercise frames spanning several Write calls
+		half := len(data) / 2
+		_, _ = out.Write(data[:half])
+		_, _ = out.Write(data[half:])
+	})
+}
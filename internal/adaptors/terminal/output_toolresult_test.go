@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestFailedToolResultIsShown(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	write := func(tag, value string) {
+		if err := stream.WriteTLV(out, tag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// write_file hides its output when it succeeds
+	write(stream.TagFunctionCall, `{"id":"ok","name":"write_file","input":"{\"path\":\"a.txt\",\"content\":\"x\"}"}`)
+	write(stream.TagFunctionResult, `{"id":"ok","output":"wrote 1 byte"}`)
+	write(stream.TagFunctionCall, `{"id":"bad","name":"write_file","input":"{\"path\":\"/etc/x\",\"content\":\"x\"}"}`)
+	write(stream.TagFunctionResult, `{"id":"bad","output":"permission denied","error":true}`)
+
+	var okContent, badContent string
+	for _, w := range out.windowBuffer.Windows {
+		switch w.ID {
+		case "ok":
+			okContent = w.Content
+		case "bad":
+			badContent = w.Content
+		}
+	}
+	if strings.Contains(okContent, "wrote 1 byte") {
+		t.Errorf("successful write_file output is shown: %q", okContent)
+	}
+	if !strings.Contains(badContent, "\npermission denied") {
+		t.Errorf("failed write_file output is hidden: %q", badContent)
+	}
+}
+
+func TestChunkedToolResultIsJoined(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	// 5 MB over frames of ChunkSize bytes, the way the session sends them
+	writeResult := func(id, output string, failed bool) {
+		for i, chunk := range stream.SplitChunks(output, stream.ChunkSize) {
+			data, err := json.Marshal(ToolResultData{ID: id, Output: chunk, Error: failed, Continued: i > 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := stream.WriteTLV(out, stream.TagFunctionResult, string(data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	big := strings.Repeat("line of output ✓\n", 5<<20/19)
+	if err := stream.WriteTLV(out, stream.TagFunctionCall, `{"id":"sh","name":"posix_shell","input":"{\"command\":\"cat big\"}"}`); err != nil {
+		t.Fatal(err)
+	}
+	writeResult("sh", big, false)
+	// A hidden output gets one leading newline, not one per frame
+	if err := stream.WriteTLV(out, stream.TagFunctionCall, `{"id":"bad","name":"write_file","input":"{\"path\":\"a.txt\",\"content\":\"x\"}"}`); err != nil {
+		t.Fatal(err)
+	}
+	writeResult("bad", big, true)
+
+	for _, w := range out.windowBuffer.Windows {
+		switch w.ID {
+		case "sh":
+			if !strings.HasSuffix(w.Content, big) || strings.Count(w.Content, "line of output") != strings.Count(big, "line of output") {
+				t.Errorf("posix_shell output was not joined: %d bytes", len(w.Content))
+			}
+		case "bad":
+			if !strings.HasSuffix(w.Content, "\n"+big) || strings.Count(w.Content, "\n\n") != 0 {
+				t.Errorf("write_file error was not joined: %d bytes", len(w.Content))
+			}
+		}
+	}
+}
+
+func TestTypedToolResultIsRendered(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	write := func(tag, value string) {
+		if err := stream.WriteTLV(out, tag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	table := `{"columns":["name","size"],"rows":[["main.go","1024"],["go.mod","88"]]}`
+	write(stream.TagFunctionCall, `{"id":"ls","name":"list","input":"{}"}`)
+	write(stream.TagToolResult, `{"id":"ls","kind":"table","data":`+table+`}`)
+	data, _ := json.Marshal(ToolResultData{ID: "ls", Output: table})
+	write(stream.TagFunctionResult, string(data))
+
+	var content string
+	for _, w := range out.windowBuffer.Windows {
+		if w.ID == "ls" {
+			content = w.Content
+		}
+	}
+	if !strings.Contains(content, "name     size\nmain.go  1024\ngo.mod   88") {
+		t.Errorf("table is not shown as aligned columns: %q", content)
+	}
+	if strings.Contains(content, `"columns"`) {
+		t.Errorf("the FR text of a rendered result is shown too: %q", content)
+	}
+}
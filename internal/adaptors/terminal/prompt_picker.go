@@ -0,0 +1,218 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// pickerRows is the number of prompts the picker shows at once.
+const pickerRows = 8
+
+// PromptPicker lists the prompts sent in this session, newest first, for
+// Ctrl+R. Typing filters them fuzzily; Enter hands the selected prompt to
+// the parent, which puts it in the input box for editing.
+type PromptPicker struct {
+	open        bool
+	prompts     []string // newest first
+	lower       []string // prompts in lowercase, for matching
+	filtered    []int    // indexes into prompts
+	selectedIdx int
+	scrollIdx   int
+	query       textinput.Model
+	width       int
+	height      int
+	styles      *Styles
+
+	// App focus state (when app loses focus, dim all UI elements)
+	hasFocus bool
+}
+
+// NewPromptPicker creates a closed prompt picker.
+func NewPromptPicker(styles *Styles) *PromptPicker {
+	query := textinput.New()
+	query.Placeholder = "Filter prompts..."
+	query.Prompt = "/ "
+	return &PromptPicker{
+		query:    query,
+		styles:   styles,
+		width:    60,
+		height:   20,
+		hasFocus: true,
+	}
+}
+
+// --- State Management ---
+
+func (pp *PromptPicker) IsOpen() bool { return pp.open }
+
+// Open shows prompts, newest first, with an empty filter.
+func (pp *PromptPicker) Open(prompts []string) tea.Cmd {
+	pp.open = true
+	pp.prompts = prompts
+	pp.lower = make([]string, len(prompts))
+	for i, p := range prompts {
+		pp.lower[i] = strings.ToLower(p)
+	}
+	pp.query.SetValue("")
+	pp.filter()
+	return pp.query.Focus()
+}
+
+func (pp *PromptPicker) Close() {
+	pp.open = false
+	pp.prompts = nil
+	pp.lower = nil
+	pp.filtered = nil
+	pp.query.Blur()
+}
+
+// --- Size Management ---
+
+func (pp *PromptPicker) SetSize(width, height int) {
+	pp.width = width
+	pp.height = height
+	pp.query.SetWidth(max(0, width-InputPaddingH-len(pp.query.Prompt)))
+}
+
+func (pp *PromptPicker) SetStyles(styles *Styles) {
+	pp.styles = styles
+}
+
+// SetHasFocus sets the application focus state.
+// When the app loses focus, all UI elements should be dimmed.
+func (pp *PromptPicker) SetHasFocus(hasFocus bool) {
+	pp.hasFocus = hasFocus
+}
+
+// filter keeps the prompts matching the query and selects the newest.
+func (pp *PromptPicker) filter() {
+	term := strings.ToLower(pp.query.Value())
+	pp.filtered = pp.filtered[:0]
+	for i, p := range pp.lower {
+		if fuzzyMatch(term, p) {
+			pp.filtered = append(pp.filtered, i)
+		}
+	}
+	pp.selectedIdx = 0
+	pp.scrollIdx = 0
+}
+
+func (pp *PromptPicker) moveSelection(delta int) {
+	pp.selectedIdx = max(0, min(pp.selectedIdx+delta, len(pp.filtered)-1))
+	if pp.selectedIdx < pp.scrollIdx {
+		pp.scrollIdx = pp.selectedIdx
+	} else if pp.selectedIdx >= pp.scrollIdx+pickerRows {
+		pp.scrollIdx = pp.selectedIdx - pickerRows + 1
+	}
+}
+
+// --- Input Handling ---
+
+// HandleKeyMsg processes keyboard input. It returns the prompt chosen with
+// Enter and true, which closes the picker; any other key returns false.
+func (pp *PromptPicker) HandleKeyMsg(msg tea.KeyMsg) (string, bool, tea.Cmd) {
+	switch msg.String() {
+	case KeyEsc:
+		pp.Close()
+		return "", false, nil
+	case KeyEnter:
+		if len(pp.filtered) == 0 {
+			return "", false, nil
+		}
+		prompt := pp.prompts[pp.filtered[pp.selectedIdx]]
+		pp.Close()
+		return prompt, true, nil
+	case KeyDown, KeyCtrlR:
+		// Ctrl+R again goes further back, like a shell's history search
+		pp.moveSelection(1)
+		return "", false, nil
+	case KeyUp:
+		pp.moveSelection(-1)
+		return "", false, nil
+	case KeyCtrlC:
+		pp.query.SetValue("")
+		pp.filter()
+		return "", false, nil
+	}
+
+	old := pp.query.Value()
+	var cmd tea.Cmd
+	pp.query, cmd = pp.query.Update(msg)
+	if pp.query.Value() != old {
+		pp.filter()
+	}
+	return "", false, cmd
+}
+
+// --- Rendering ---
+
+func (pp *PromptPicker) View() string {
+	if !pp.open {
+		return ""
+	}
+
+	innerWidth := max(1, pp.width-4)
+	clip := lipgloss.NewStyle().MaxWidth(innerWidth)
+	lines := []string{pp.query.View()}
+	switch {
+	case len(pp.prompts) == 0:
+		lines = append(lines, pp.styles.System.Render("  No prompts sent yet"))
+	case len(pp.filtered) == 0:
+		lines = append(lines, pp.styles.System.Render("  No matching prompts"))
+	}
+	end := min(len(pp.filtered), pp.scrollIdx+pickerRows)
+	for i := pp.scrollIdx; i < end; i++ {
+		line := oneLine(pp.prompts[pp.filtered[i]])
+		if i == pp.selectedIdx {
+			lines = append(lines, clip.Render(pp.styles.Prompt.Render("> "+line)))
+		} else {
+			lines = append(lines, clip.Render("  "+pp.styles.System.Render(line)))
+		}
+	}
+	for len(lines) < pickerRows+1 {
+		lines = append(lines, "")
+	}
+
+	borderColor := pp.styles.BorderFocused
+	if !pp.hasFocus {
+		borderColor = pp.styles.BorderBlurred
+	}
+	box := pp.styles.RenderBorderedBox(strings.Join(lines, "\n"), pp.width, borderColor)
+
+	help := "type to filter │ ↑/↓: navigate │ enter: edit │ esc: close"
+	if len(pp.filtered) > pickerRows {
+		help += fmt.Sprintf(" │ %d of %d", pp.selectedIdx+1, len(pp.filtered))
+	}
+	return box + "\n" + pp.styles.System.Render(help)
+}
+
+// oneLine shows a prompt on a single line, with its line breaks and tabs
+// escaped.
+func oneLine(s string) string {
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return strings.ReplaceAll(s, "\t", "\\t")
+}
+
+// RenderOverlay renders the picker as an overlay on top of base content
+func (pp *PromptPicker) RenderOverlay(baseContent string, screenWidth, screenHeight int) string {
+	if !pp.open {
+		return baseContent
+	}
+
+	box := pp.View()
+	boxWidth := lipgloss.Width(box)
+	boxHeight := lipgloss.Height(box)
+
+	x := max(0, (screenWidth-boxWidth)/2)
+	y := max(0, screenHeight-boxHeight-LayoutGap)
+
+	c := lipgloss.NewCompositor(
+		lipgloss.NewLayer(baseContent),
+		lipgloss.NewLayer(box).X(x).Y(y).Z(1),
+	)
+	return c.Render()
+}
@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newPickerTerminal() *Terminal {
+	out := NewTerminalOutput(DefaultStyles())
+	return NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+}
+
+func TestCtrlROpensPickerAndEscRestoresFocus(t *testing.T) {
+	terminal := newPickerTerminal()
+	terminal.focusDisplay()
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Mod: tea.ModCtrl}))
+	if !terminal.promptPicker.IsOpen() {
+		t.Fatal("Ctrl+R should open the prompt picker")
+	}
+	if view := stripANSI(terminal.promptPicker.View()); !strings.Contains(view, "No prompts sent yet") {
+		t.Errorf("empty picker view:\n%s", view)
+	}
+
+	// Display keys must not reach the display while the picker is open
+	pressRune(terminal, 'j')
+	if terminal.promptPicker.query.Value() != "j" {
+		t.Errorf("query = %q, want the typed key", terminal.promptPicker.query.Value())
+	}
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if terminal.promptPicker.IsOpen() {
+		t.Fatal("Esc should close the picker")
+	}
+	if terminal.focusedWindow != focusDisplay || !terminal.display.displayFocused {
+		t.Errorf("focus = %q, want the display back", terminal.focusedWindow)
+	}
+}
+
+func TestPromptPickerFiltersAndInsertsSelection(t *testing.T) {
+	terminal := newPickerTerminal()
+	terminal.promptPicker.Open([]string{"thanks", "add a flag", "fix the lint errors", "read main.go"})
+
+	for _, r := range "fl" {
+		pressRune(terminal, r)
+	}
+	if got := len(terminal.promptPicker.filtered); got != 2 {
+		t.Fatalf("%d prompts match \"fl\", want add a flag and fix the lint errors", got)
+	}
+	view := stripANSI(terminal.promptPicker.View())
+	if !strings.Contains(view, "> add a flag") || strings.Contains(view, "read main.go") {
+		t.Errorf("filtered view:\n%s", view)
+	}
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+
+	if terminal.promptPicker.IsOpen() {
+		t.Fatal("Enter should close the picker")
+	}
+	if got := terminal.input.GetPrompt(); got != "fix the lint errors" {
+		t.Errorf("input = %q, want the selected prompt", got)
+	}
+	if terminal.focusedWindow != focusInput {
+		t.Errorf("focus = %q, want the input", terminal.focusedWindow)
+	}
+}
+
+func TestPromptPickerKeepsMultilinePrompts(t *testing.T) {
+	terminal := newPickerTerminal()
+	terminal.input.SetValue("draft")
+	terminal.promptPicker.Open([]string{"first line\nsecond line"})
+
+	if view := stripANSI(terminal.promptPicker.View()); !strings.Contains(view, `first line\nsecond line`) {
+		t.Errorf("a prompt should show on one line:\n%s", view)
+	}
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+
+	if got := terminal.input.GetPrompt(); got != "first line\nsecond line" {
+		t.Errorf("input = %q, want the whole prompt", got)
+	}
+}
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestQuitConfirmNamesTasksAtRisk(t *testing.T) {
+	tests := []struct {
+		name       string
+		inProgress bool
+		queued     int
+		want       string
+	}{
+		{"idle", false, 0, "Confirm exit? Press y/n"},
+		{"running", true, 0, "1 task running — quit anyway? Press y/n"},
+		{"running and queued", true, 2, "1 task running, 2 queued — quit anyway? Press y/n"},
+		{"queued", false, 1, "1 task queued — quit anyway? Press y/n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := NewTerminalOutput(DefaultStyles())
+			out.inProgress = tt.inProgress
+			out.queueCount = tt.queued
+			terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+			if got := terminal.quitConfirmText(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscQuitsWithoutWaitingForShutdown(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+	terminal.confirmDialog = true
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 'y', Text: "y"}))
+	if !terminal.stopping || terminal.quitting {
+		t.Fatalf("y should start stopping the session, stopping=%v quitting=%v", terminal.stopping, terminal.quitting)
+	}
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if !terminal.quitting {
+		t.Error("Esc while stopping should quit at once")
+	}
+}
@@ -0,0 +1,102 @@
+package terminal
+
+// Collapsed reasoning.
+// Reasoning models can stream pages of thinking before a short answer. A
+// folded reasoning window therefore shows one line, "· thinking (1.2k chars)
+// — press r to expand", with a spinner while the reasoning still streams;
+// space toggles the window under the cursor and r the latest one. With
+// --show-reasoning, reasoning folds like tool output instead: its first line
+// and last three lines.
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// spinnerFrames turn once per reasoning delta.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// collapsesReasoning reports whether the window shows the one-line summary.
+func (w *Window) collapsesReasoning() bool {
+	return w.collapse && w.Folded
+}
+
+// reasoningSummary renders the line a collapsed reasoning window shows.
+func (w *Window) reasoningSummary(styles *Styles) string {
+	size := formatChars(utf8.RuneCountInString(w.Content))
+	if w.thinking {
+		return styles.Reasoning.Render(spinnerFrames[w.deltas%len(spinnerFrames)] + " thinking (" + size + ")")
+	}
+	return styles.Reasoning.Render("· thinking (" + size + ") — press r to expand")
+}
+
+// formatChars renders a character count, e.g. "640 chars" or "1.2k chars".
+func formatChars(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM chars", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk chars", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%d chars", n)
+	}
+}
+
+// SetShowReasoning folds reasoning windows created from now on like tool
+// output instead of summarizing them (--show-reasoning).
+func (wb *WindowBuffer) SetShowReasoning(enabled bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.showReasoning = enabled
+}
+
+// endThinking marks the reasoning streaming into the last window as
+// finished, as no delta follows once another window starts. wb.mu must be
+// held.
+func (wb *WindowBuffer) endThinking() {
+	if len(wb.Windows) == 0 {
+		return
+	}
+	idx := len(wb.Windows) - 1
+	if w := wb.Windows[idx]; w.thinking {
+		w.thinking = false
+		w.Invalidate()
+		wb.markDirty(idx)
+	}
+}
+
+// EndThinking stops the spinner of the last reasoning window, when the turn
+// ends on its reasoning.
+func (wb *WindowBuffer) EndThinking() {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.endThinking()
+}
+
+// ToggleLatestReasoning expands or collapses the last reasoning window. It
+// returns the window's index, or -1 when there is none.
+func (wb *WindowBuffer) ToggleLatestReasoning() int {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	for i := len(wb.Windows) - 1; i >= 0; i-- {
+		if w := wb.Windows[i]; w.Tag == stream.TagTextReasoning && w.Visible {
+			w.Folded = !w.Folded
+			wb.markDirty(i)
+			return i
+		}
+	}
+	return -1
+}
+
+// ToggleLatestReasoning expands or collapses the last reasoning window and
+// moves the cursor to it. It returns false when there is none.
+func (m *DisplayModel) ToggleLatestReasoning() bool {
+	idx := m.windowBuffer.ToggleLatestReasoning()
+	if idx < 0 {
+		return false
+	}
+	m.SetWindowCursor(idx)
+	return true
+}
@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestReasoningCollapsesToSummary(t *testing.T) {
+	wb := NewWindowBuffer(80, DefaultStyles())
+	wb.AppendOrUpdate("1-1-r", stream.TagTextReasoning, "Let me think about the parser.\n")
+	wb.AppendOrUpdate("1-1-r", stream.TagTextReasoning, strings.Repeat("Still thinking.\n", 80))
+
+	rendered := stripANSI(wb.GetAll(-1))
+	if !strings.Contains(rendered, "⠙ thinking (1.3k chars)") {
+		t.Errorf("streaming reasoning should show a spinner and its size, got\n%s", rendered)
+	}
+	if strings.Contains(rendered, "Still thinking.") {
+		t.Errorf("collapsed reasoning should hide its text, got\n%s", rendered)
+	}
+
+	wb.AppendOrUpdate("1-1-t", stream.TagTextAssistant, "Done.")
+	rendered = stripANSI(wb.GetAll(-1))
+	if !strings.Contains(rendered, "· thinking (1.3k chars) — press r to expand") {
+		t.Errorf("finished reasoning should offer to expand, got\n%s", rendered)
+	}
+
+	if wb.ToggleLatestReasoning() != 0 {
+		t.Fatal("the reasoning window should be toggled")
+	}
+	if rendered = stripANSI(wb.GetAll(-1)); !strings.Contains(rendered, "Let me think about the parser.") || !strings.Contains(rendered, "Still thinking.") {
+		t.Errorf("expanded reasoning should show its text, got\n%s", rendered)
+	}
+}
+
+func TestShowReasoningFoldsLikeOtherWindows(t *testing.T) {
+	wb := NewWindowBuffer(80, DefaultStyles())
+	wb.SetShowReasoning(true)
+	wb.AppendOrUpdate("1-1-r", stream.TagTextReasoning, "First thought.\n"+strings.Repeat("More.\n", 10)+"Last thought.")
+
+	rendered := stripANSI(wb.GetAll(-1))
+	if !strings.Contains(rendered, "First thought.") || !strings.Contains(rendered, "⁝") || !strings.Contains(rendered, "Last thought.") {
+		t.Errorf("reasoning should fold to its first and last lines, got\n%s", rendered)
+	}
+	if strings.Contains(rendered, "thinking (") {
+		t.Errorf("no summary with --show-reasoning, got\n%s", rendered)
+	}
+}
+
+func TestRKeyExpandsLatestReasoning(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	writeTLV(out, stream.TagTextReasoning, "[:1-1-r:]old reasoning")
+	writeTLV(out, stream.TagTextAssistant, "[:1-1-t:]First answer.")
+	writeTLV(out, stream.TagTextReasoning, "[:2-1-r:]new reasoning")
+	writeTLV(out, stream.TagTextAssistant, "[:2-1-t:]Second answer.")
+	writeTLV(out, stream.TagTurnEnd, "2")
+	terminal.focusDisplay()
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 'r', Text: "r"}))
+
+	rendered := stripANSI(out.WindowBuffer().GetAll(-1))
+	if !strings.Contains(rendered, "new reasoning") || strings.Contains(rendered, "old reasoning") {
+		t.Errorf("r should expand only the latest reasoning, got\n%s", rendered)
+	}
+	if terminal.display.GetWindowCursor() != 2 {
+		t.Errorf("cursor = %d, want the expanded reasoning", terminal.display.GetWindowCursor())
+	}
+}
+
+func TestFormatChars(t *testing.T) {
+	tests := map[int]string{0: "0 chars", 640: "640 chars", 1234: "1.2k chars", 2_500_000: "2.5M chars"}
+	for n, want := range tests {
+		if got := formatChars(n); got != want {
+			t.Errorf("formatChars(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
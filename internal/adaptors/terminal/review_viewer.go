@@ -0,0 +1,243 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+)
+
+// reviewAction is what the user decided in the review viewer.
+type reviewAction int
+
+const (
+	reviewNone    reviewAction = iota
+	reviewAccept               // accept the change
+	reviewReject               // reject it, with the reason if one was typed
+	reviewDismiss              // close the viewer and decide later
+	reviewMask                 // send a prompt with its secrets masked
+)
+
+// ReviewViewer shows a file change awaiting review (--review-edits) as a
+// colored unified diff, a command to approve, or the secrets found in a
+// prompt. The parent turns its decisions into :review_accept,
+// :review_reject, and :review_mask commands.
+type ReviewViewer struct {
+	frame   *agentpkg.ReviewFrame
+	lines   []string // diff lines, prepared for display
+	scroll  int
+	reason  textinput.Model
+	editing bool // typing a rejection reason
+	width   int
+	height  int
+	styles  *Styles
+
+	// App focus state (when app loses focus, dim all UI elements)
+	hasFocus bool
+}
+
+// NewReviewViewer creates a closed review viewer.
+func NewReviewViewer(styles *Styles) *ReviewViewer {
+	reason := textinput.New()
+	reason.Placeholder = "Why? (optional)"
+	reason.Prompt = "reason: "
+	return &ReviewViewer{
+		reason:   reason,
+		styles:   styles,
+		width:    60,
+		height:   20,
+		hasFocus: true,
+	}
+}
+
+// --- State Management ---
+
+func (rv *ReviewViewer) IsOpen() bool { return rv.frame != nil }
+
+// ID returns the ID of the change shown, or "" when closed.
+func (rv *ReviewViewer) ID() string {
+	if rv.frame == nil {
+		return ""
+	}
+	return rv.frame.ID
+}
+
+// Open shows frame, a pending change.
+func (rv *ReviewViewer) Open(frame agentpkg.ReviewFrame) {
+	rv.frame = &frame
+	rv.lines = strings.Split(strings.TrimRight(prepareContent(frame.Diff), "\n"), "\n")
+	rv.scroll = 0
+	rv.editing = false
+	rv.reason.SetValue("")
+	rv.reason.Blur()
+}
+
+func (rv *ReviewViewer) Close() {
+	rv.frame = nil
+	rv.lines = nil
+	rv.editing = false
+	rv.reason.Blur()
+}
+
+// --- Size Management ---
+
+func (rv *ReviewViewer) SetSize(width, height int) {
+	rv.width = width
+	rv.height = height
+	rv.reason.SetWidth(max(0, width-InputPaddingH-len(rv.reason.Prompt)))
+}
+
+func (rv *ReviewViewer) SetStyles(styles *Styles) {
+	rv.styles = styles
+}
+
+// SetHasFocus sets the application focus state.
+// When the app loses focus, all UI elements should be dimmed.
+func (rv *ReviewViewer) SetHasFocus(hasFocus bool) {
+	rv.hasFocus = hasFocus
+}
+
+// diffHeight is the number of diff lines shown at once.
+func (rv *ReviewViewer) diffHeight() int {
+	// Leave room for the title, the reason, the border, the help text and
+	// the input area below
+	return max(3, min(len(rv.lines), rv.height-LayoutGap-6))
+}
+
+func (rv *ReviewViewer) scrollBy(delta int) {
+	rv.scroll = max(0, min(rv.scroll+delta, len(rv.lines)-rv.diffHeight()))
+}
+
+// --- Input Handling ---
+
+// HandleKeyMsg processes keyboard input. A rejection reports the reason the
+// user typed, if any.
+func (rv *ReviewViewer) HandleKeyMsg(msg tea.KeyMsg) (reviewAction, string, tea.Cmd) {
+	if rv.editing {
+		switch msg.String() {
+		case KeyEnter:
+			return reviewReject, strings.TrimSpace(rv.reason.Value()), nil
+		case KeyEsc:
+			rv.editing = false
+			rv.reason.Blur()
+			return reviewNone, "", nil
+		}
+		var cmd tea.Cmd
+		rv.reason, cmd = rv.reason.Update(msg)
+		return reviewNone, "", cmd
+	}
+
+	switch msg.String() {
+	case KeyA, KeyY:
+		return reviewAccept, "", nil
+	case KeyR, KeyN:
+		return reviewReject, "", nil
+	case KeyM:
+		if rv.isPrompt() {
+			return reviewMask, "", nil
+		}
+	case KeyE:
+		rv.editing = true
+		return reviewNone, "", rv.reason.Focus()
+	case KeyJ, KeyDown:
+		rv.scrollBy(1)
+	case KeyK, KeyUp:
+		rv.scrollBy(-1)
+	case KeySpace, KeyCtrlF:
+		rv.scrollBy(rv.diffHeight())
+	case KeyCtrlB:
+		rv.scrollBy(-rv.diffHeight())
+	case KeyQ, KeyEsc:
+		return reviewDismiss, "", nil
+	}
+	return reviewNone, "", nil
+}
+
+// --- Rendering ---
+
+func (rv *ReviewViewer) View() string {
+	if rv.frame == nil {
+		return ""
+	}
+
+	innerWidth := max(1, rv.width-4)
+	clip := lipgloss.NewStyle().MaxWidth(innerWidth)
+	lines := []string{
+		clip.Render(rv.styles.Tool.Render("Review "+rv.frame.ID+": "+rv.frame.Tool+" ") + rv.styles.ToolContent.Render(rv.frame.Subject())),
+	}
+
+	height := rv.diffHeight()
+	rv.scrollBy(0)
+	end := min(len(rv.lines), rv.scroll+height)
+	for i := rv.scroll; i < end; i++ {
+		// The first two lines of a file's diff are the --- and +++ headers
+		lines = append(lines, clip.Render(styleDiffLine(rv.lines[i], i < 2 && rv.frame.Path != "", rv.styles)))
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	if rv.editing {
+		lines = append(lines, rv.reason.View())
+	}
+
+	borderColor := rv.styles.BorderFocused
+	if !rv.hasFocus {
+		borderColor = rv.styles.BorderBlurred
+	}
+	box := rv.styles.RenderBorderedBox(strings.Join(lines, "\n"), rv.width, borderColor)
+
+	help := "a: accept │ r: reject │ e: reject with reason │ j/k: scroll │ esc: later"
+	if rv.isPrompt() {
+		help = "a: send │ m: send masked │ r: don't send │ j/k: scroll │ esc: later"
+	}
+	if rv.editing {
+		help = "enter: reject │ esc: back"
+	} else if len(rv.lines) > height {
+		help += fmt.Sprintf(" │ lines %d-%d of %d", rv.scroll+1, end, len(rv.lines))
+	}
+	return box + "\n" + rv.styles.System.Render(help)
+}
+
+// isPrompt reports whether the viewer shows a prompt holding secrets rather
+// than a change.
+func (rv *ReviewViewer) isPrompt() bool {
+	return len(rv.frame.Secrets) > 0
+}
+
+// styleDiffLine colors one line of a unified diff.
+func styleDiffLine(line string, header bool, styles *Styles) string {
+	switch {
+	case header, strings.HasPrefix(line, "@@"), strings.HasPrefix(line, `\`):
+		return styles.System.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return styles.DiffAdd.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return styles.DiffRemove.Render(line)
+	default:
+		return styles.Text.Render(line)
+	}
+}
+
+// RenderOverlay renders the viewer as an overlay on top of base content
+func (rv *ReviewViewer) RenderOverlay(baseContent string, screenWidth, screenHeight int) string {
+	if rv.frame == nil {
+		return baseContent
+	}
+
+	box := rv.View()
+	boxWidth := lipgloss.Width(box)
+	boxHeight := lipgloss.Height(box)
+
+	x := max(0, (screenWidth-boxWidth)/2)
+	y := max(0, screenHeight-boxHeight-LayoutGap)
+
+	c := lipgloss.NewCompositor(
+		lipgloss.NewLayer(baseContent),
+		lipgloss.NewLayer(box).X(x).Y(y).Z(1),
+	)
+	return c.Render()
+}
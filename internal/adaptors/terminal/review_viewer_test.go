@@ -0,0 +1,82 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func pressRune(terminal *Terminal, r rune) {
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: r, Text: string(r)}))
+}
+
+func newReviewTerminal(t *testing.T) (*Terminal, *outputWriter, *stream.ChanInput) {
+	t.Helper()
+	out := NewTerminalOutput(DefaultStyles())
+	input := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, out, input, nil, 80, 24)
+	_ = stream.WriteTLV(out, stream.TagReview, `{"id":"R1","tool":"edit_file","path":"main.go","diff":"--- a/main.go\n+++ b/main.go\n@@ -1,1 +1,1 @@\n-old\n+new\n"}`)
+	terminal.syncReviewViewer()
+	if terminal.reviewViewer.ID() != "R1" {
+		t.Fatal("a pending change should open the review viewer")
+	}
+	return terminal, out, input
+}
+
+func TestReviewViewerRejectsWithReason(t *testing.T) {
+	terminal, out, input := newReviewTerminal(t)
+	if view := stripANSI(terminal.reviewViewer.View()); !strings.Contains(view, "Review R1: edit_file main.go") || !strings.Contains(view, "+new") {
+		t.Errorf("viewer lacks the change:\n%s", view)
+	}
+
+	pressRune(terminal, 'e')
+	for _, r := range "too risky" {
+		pressRune(terminal, r)
+	}
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if terminal.reviewViewer.IsOpen() {
+		t.Error("the viewer should close once the change is answered")
+	}
+	tag, value, err := stream.ReadTLV(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != stream.TagTextUser || value != ":review_reject R1 too risky" {
+		t.Errorf("got %s %q", tag, value)
+	}
+
+	// Until the decision arrives the change is still pending, but answered
+	terminal.syncReviewViewer()
+	if terminal.reviewViewer.IsOpen() {
+		t.Error("an answered change was shown again")
+	}
+	_ = stream.WriteTLV(out, stream.TagReview, `{"id":"R1","tool":"edit_file","path":"main.go","decision":"rejected","reason":"too risky"}`)
+	if len(out.PendingReviews()) != 0 {
+		t.Error("the decision should end the review")
+	}
+	if got := out.WindowBuffer().Search("R1 rejected: main.go (too risky)"); len(got) != 1 {
+		t.Error("the decision should be shown")
+	}
+}
+
+func TestReviewViewerEscDefersUntilReviewCommand(t *testing.T) {
+	terminal, _, input := newReviewTerminal(t)
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	terminal.syncReviewViewer()
+	if terminal.reviewViewer.IsOpen() {
+		t.Fatal("esc should put the change off")
+	}
+
+	terminal.handleCommand("review")
+	if !terminal.reviewViewer.IsOpen() {
+		t.Fatal(":review should show the change again")
+	}
+	pressRune(terminal, 'a')
+	if _, value, _ := stream.ReadTLV(input); value != ":review_accept R1" {
+		t.Errorf("got %q, want :review_accept R1", value)
+	}
+}
@@ -0,0 +1,110 @@
+package terminal
+
+// Transcript search for the display window.
+// "/" opens a search prompt in the input box, Enter jumps to the first
+// matching window, and "n"/"N" cycle through the matches.
+
+import (
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+const (
+	searchPrompt        = "/ "
+	searchPlaceholder   = "Search transcript (Enter to search, Esc to cancel)"
+	statusFlashDuration = 2 * time.Second
+)
+
+// searchState holds the active transcript search.
+type searchState struct {
+	prompting bool   // the input box is collecting a query
+	query     string // last submitted query
+	matches   []int  // indices of matching windows
+	current   int    // index into matches
+}
+
+// startSearch switches the input box to the search prompt.
+func (m *Terminal) startSearch() {
+	m.search.prompting = true
+	m.input.SetPrompt(searchPrompt, searchPlaceholder)
+	m.input.SetValue("")
+	m.focusInput()
+	m.display.updateContent()
+}
+
+// endSearchPrompt restores the normal input prompt and returns focus to the display.
+func (m *Terminal) endSearchPrompt() {
+	m.search.prompting = false
+	m.input.SetPrompt(defaultPrompt, defaultPlaceholder)
+	m.input.SetValue("")
+	m.focusDisplay()
+	m.display.updateContent()
+}
+
+// handleSearchPromptKeys handles keys while the search prompt is open.
+// Keys it does not consume are edited into the query as usual.
+func (m *Terminal) handleSearchPromptKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case KeyEnter:
+		query := m.input.Value()
+		m.endSearchPrompt()
+		if query != "" {
+			m.runSearch(query)
+		}
+		return nil, true
+	case KeyEsc, KeyTab, KeyCtrlC:
+		m.endSearchPrompt()
+		return nil, true
+	}
+	return nil, false
+}
+
+// runSearch finds all windows matching query and jumps to the first one.
+func (m *Terminal) runSearch(query string) {
+	m.search.query = query
+	m.search.matches = m.out.WindowBuffer().Search(query)
+	m.search.current = 0
+	if len(m.search.matches) == 0 {
+		m.flashStatus(fmt.Sprintf("No matches for %q", query))
+		return
+	}
+	m.jumpToMatch()
+}
+
+// nextMatch moves to the next (delta=1) or previous (delta=-1) match, wrapping around.
+func (m *Terminal) nextMatch(delta int) {
+	if m.search.query == "" {
+		m.flashStatus("No active search (press / to search)")
+		return
+	}
+	// Re-run the query so windows streamed in since the last search are included
+	m.search.matches = m.out.WindowBuffer().Search(m.search.query)
+	count := len(m.search.matches)
+	if count == 0 {
+		m.flashStatus(fmt.Sprintf("No matches for %q", m.search.query))
+		return
+	}
+	m.search.current = ((m.search.current+delta)%count + count) % count
+	m.jumpToMatch()
+}
+
+// jumpToMatch moves the window cursor to the current match, unfolds it, and
+// scrolls it into view. The viewport stops following new output so streamed
+// content does not scroll the match away.
+func (m *Terminal) jumpToMatch() {
+	index := m.search.matches[m.search.current]
+	m.out.WindowBuffer().Unfold(index)
+	m.display.SetWindowCursor(index)
+	m.display.MarkUserScrolled()
+	m.display.EnsureCursorVisible()
+	m.display.updateContent()
+	m.flashStatus(fmt.Sprintf("Match %d/%d for %q", m.search.current+1, len(m.search.matches), m.search.query))
+}
+
+// flashStatus shows msg in the status bar for a short time.
+func (m *Terminal) flashStatus(msg string) {
+	m.statusFlash = msg
+	m.statusFlashUntil = time.Now().Add(statusFlashDuration)
+}
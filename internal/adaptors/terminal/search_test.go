@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newSearchTestTerminal() *Terminal {
+	out := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, out, stream.NewChanInput(10), nil, 80, 24)
+	wb := out.WindowBuffer()
+	wb.AppendOrUpdate("w0", stream.TagTextUser, "run the tests")
+	wb.AppendOrUpdate("w1", stream.TagTextAssistant, "FAIL: TestNeedle")
+	wb.AppendOrUpdate("w2", stream.TagSystemNotify, "\x1b[31mcolored\x1b[0m output")
+	wb.AppendOrUpdate("w3", stream.TagTextAssistant, "fixed the needle, all pass")
+	return terminal
+}
+
+func typeKey(terminal *Terminal, code rune, text string) {
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: code, Text: text}))
+}
+
+func searchFor(terminal *Terminal, query string) {
+	terminal.focusDisplay()
+	typeKey(terminal, '/', "/")
+	terminal.input.SetValue(query)
+	typeKey(terminal, tea.KeyEnter, "")
+}
+
+func TestSearchJumpsToFirstMatch(t *testing.T) {
+	terminal := newSearchTestTerminal()
+
+	searchFor(terminal, "needle")
+
+	if terminal.search.prompting {
+		t.Error("search prompt should close after Enter")
+	}
+	if terminal.focusedWindow != focusDisplay {
+		t.Errorf("focus = %q, want display", terminal.focusedWindow)
+	}
+	if got := terminal.display.GetWindowCursor(); got != 1 {
+		t.Errorf("cursor = %d, want first match 1", got)
+	}
+	if terminal.display.shouldFollow() {
+		t.Error("viewport should not follow new output after jumping to a match")
+	}
+	if terminal.input.Value() != "" {
+		t.Errorf("query should not remain in the input, got %q", terminal.input.Value())
+	}
+}
+
+func TestSearchCyclesMatches(t *testing.T) {
+	terminal := newSearchTestTerminal()
+	searchFor(terminal, "NEEDLE")
+
+	typeKey(terminal, 'n', "n")
+	if got := terminal.display.GetWindowCursor(); got != 3 {
+		t.Errorf("after n cursor = %d, want 3", got)
+	}
+	typeKey(terminal, 'n', "n")
+	if got := terminal.display.GetWindowCursor(); got != 1 {
+		t.Errorf("n should wrap to first match, got %d", got)
+	}
+	typeKey(terminal, 'N', "N")
+	if got := terminal.display.GetWindowCursor(); got != 3 {
+		t.Errorf("N should wrap to last match, got %d", got)
+	}
+}
+
+func TestSearchIgnoresANSI(t *testing.T) {
+	terminal := newSearchTestTerminal()
+	if matches := terminal.out.WindowBuffer().Search("colored output"); len(matches) != 1 || matches[0] != 2 {
+		t.Errorf("Search on plain text = %v, want [2]", matches)
+	}
+	if matches := terminal.out.WindowBuffer().Search("[31m"); len(matches) != 0 {
+		t.Errorf("escape sequences should not match, got %v", matches)
+	}
+}
+
+func TestSearchNoMatchesFlashesStatus(t *testing.T) {
+	terminal := newSearchTestTerminal()
+	terminal.focusDisplay()
+	cursor := terminal.display.GetWindowCursor()
+
+	searchFor(terminal, "missing")
+
+	if !strings.Contains(terminal.renderStatusBar(), `No matches for "missing"`) {
+		t.Errorf("status bar = %q, want no-match message", terminal.renderStatusBar())
+	}
+	if got := terminal.display.GetWindowCursor(); got != cursor {
+		t.Errorf("cursor moved to %d on no match", got)
+	}
+}
+
+func TestSearchEscCancelsPrompt(t *testing.T) {
+	terminal := newSearchTestTerminal()
+	terminal.focusDisplay()
+	typeKey(terminal, '/', "/")
+	if !terminal.search.prompting || terminal.focusedWindow != focusInput {
+		t.Fatal("/ should open the search prompt in the input")
+	}
+
+	typeKey(terminal, tea.KeyEscape, "")
+
+	if terminal.search.prompting {
+		t.Error("Esc should close the search prompt")
+	}
+	if terminal.search.query != "" {
+		t.Errorf("Esc should not run a search, query = %q", terminal.search.query)
+	}
+}
@@ -0,0 +1,335 @@
+package terminal
+
+// First-run setup wizard.
+// When no model is configured and the terminal is interactive, a small
+// bubbletea program asks for a provider, API key, and model, checks the key
+// by listing the provider's models, and optionally saves the result to the
+// model config file.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/colorprofile"
+	"golang.org/x/term"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	debugpkg "github.com/alayacore/alayacore/internal/debug"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+)
+
+// setupValidateTimeout bounds the model list request that checks the key.
+const setupValidateTimeout = 15 * time.Second
+
+// setupProvider is a provider choice offered by the wizard.
+type setupProvider struct {
+	Label        string
+	ProtocolType string
+	BaseURL      string // empty asks for one
+	DefaultModel string
+}
+
+var setupProviders = []setupProvider{
+	{"OpenAI", "openai", "https://api.openai.com/v1", "gpt-4.1"},
+	{"DeepSeek", "openai", "https://api.deepseek.com/v1", "deepseek-chat"},
+	{"Z.ai", "openai", "https://api.z.ai/api/paas/v4", "glm-4.6"},
+	{"Anthropic", "anthropic", "https://api.anthropic.com", "claude-sonnet-4-5"},
+	{"OpenRouter", "openrouter", providers.OpenRouterBaseURL, "anthropic/claude-sonnet-4.5"},
+	{"Custom (OpenAI-compatible base URL)", "openai", "", ""},
+}
+
+type setupStep int
+
+const (
+	setupStepProvider setupStep = iota
+	setupStepBaseURL
+	setupStepKey
+	setupStepValidating
+	setupStepModel
+	setupStepSave
+	setupStepDone
+)
+
+// setupValidatedMsg carries the result of the key check.
+type setupValidatedMsg struct {
+	models []string
+	err    error
+}
+
+// setupWizard is the bubbletea model of the setup wizard.
+type setupWizard struct {
+	step       setupStep
+	selected   int
+	provider   setupProvider
+	input      textinput.Model
+	baseURL    string
+	apiKey     string
+	model      string
+	save       bool
+	err        error
+	configPath string
+	client     *http.Client
+	styles     *Styles
+}
+
+func newSetupWizard(configPath string, client *http.Client) *setupWizard {
+	input := textinput.New()
+	input.SetWidth(60)
+	return &setupWizard{
+		input:      input,
+		configPath: configPath,
+		client:     client,
+		styles:     DefaultStyles(),
+	}
+}
+
+func (w *setupWizard) Init() tea.Cmd {
+	return nil
+}
+
+func (w *setupWizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case setupValidatedMsg:
+		return w, w.handleValidated(msg)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case KeyCtrlC, KeyEsc:
+			return w, tea.Quit
+		}
+		return w, w.handleKey(msg)
+	}
+	return w, nil
+}
+
+func (w *setupWizard) handleKey(msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+	switch w.step {
+	case setupStepProvider:
+		return w.handleProviderKey(key)
+	case setupStepBaseURL, setupStepKey, setupStepModel:
+		if key == KeyEnter {
+			return w.submit()
+		}
+		var cmd tea.Cmd
+		w.input, cmd = w.input.Update(msg)
+		return cmd
+	case setupStepSave:
+		switch strings.ToLower(key) {
+		case "y", KeyEnter:
+			w.save = true
+		case "n":
+			w.save = false
+		default:
+			return nil
+		}
+		w.step = setupStepDone
+		return tea.Quit
+	}
+	return nil
+}
+
+func (w *setupWizard) handleProviderKey(key string) tea.Cmd {
+	switch key {
+	case KeyUp:
+		w.selected = (w.selected + len(setupProviders) - 1) % len(setupProviders)
+	case KeyDown:
+		w.selected = (w.selected + 1) % len(setupProviders)
+	case KeyEnter:
+		w.provider = setupProviders[w.selected]
+		w.baseURL = w.provider.BaseURL
+		if w.baseURL == "" {
+			return w.prompt(setupStepBaseURL, "https://", textinput.EchoNormal)
+		}
+		return w.prompt(setupStepKey, "", textinput.EchoPassword)
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(setupProviders) {
+			w.selected = int(key[0] - '1')
+		}
+	}
+	return nil
+}
+
+// submit accepts the line entered at a text step.
+func (w *setupWizard) submit() tea.Cmd {
+	value := strings.TrimSpace(w.input.Value())
+	switch w.step {
+	case setupStepBaseURL:
+		if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			w.err = errors.New("base URL must start with http:// or https://")
+			return nil
+		}
+		w.baseURL = strings.TrimSuffix(value, "/")
+		return w.prompt(setupStepKey, "", textinput.EchoPassword)
+	case setupStepKey:
+		if value == "" {
+			w.err = errors.New("the API key is empty")
+			return nil
+		}
+		w.apiKey = value
+		w.step = setupStepValidating
+		w.err = nil
+		return w.validate()
+	case setupStepModel:
+		if value == "" {
+			w.err = errors.New("the model name is empty")
+			return nil
+		}
+		w.model = value
+		w.step = setupStepSave
+		w.err = nil
+		w.input.Blur()
+	}
+	return nil
+}
+
+// prompt moves to a step that reads a line of text.
+func (w *setupWizard) prompt(step setupStep, value string, echo textinput.EchoMode) tea.Cmd {
+	w.step = step
+	w.err = nil
+	w.input.Reset()
+	w.input.SetValue(value)
+	w.input.EchoMode = echo
+	w.input.ShowSuggestions = false
+	return w.input.Focus()
+}
+
+// validate checks the key by listing the provider's models.
+func (w *setupWizard) validate() tea.Cmd {
+	protocol, baseURL, apiKey, client := w.provider.ProtocolType, w.baseURL, w.apiKey, w.client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), setupValidateTimeout)
+		defer cancel()
+		models, err := providers.ListModels(ctx, client, protocol, baseURL, apiKey)
+		return setupValidatedMsg{models: models, err: err}
+	}
+}
+
+func (w *setupWizard) handleValidated(msg setupValidatedMsg) tea.Cmd {
+	if msg.err != nil {
+		cmd := w.prompt(setupStepKey, w.apiKey, textinput.EchoPassword)
+		w.err = msg.err
+		return cmd
+	}
+	cmd := w.prompt(setupStepModel, w.provider.DefaultModel, textinput.EchoNormal)
+	w.input.SetSuggestions(msg.models)
+	w.input.ShowSuggestions = len(msg.models) > 0
+	if w.provider.DefaultModel == "" && len(msg.models) > 0 {
+		w.input.SetValue(msg.models[0])
+	}
+	w.input.CursorEnd()
+	return cmd
+}
+
+func (w *setupWizard) View() tea.View {
+	var sb strings.Builder
+	sb.WriteString(w.styles.Prompt.Render("AlayaCore setup") + "\n")
+	sb.WriteString(w.styles.System.Render("No model is configured yet. Esc quits.") + "\n\n")
+
+	switch w.step {
+	case setupStepProvider:
+		sb.WriteString("Choose a provider:\n")
+		for i, p := range setupProviders {
+			line := fmt.Sprintf("  %d. %s", i+1, p.Label)
+			if i == w.selected {
+				line = w.styles.Prompt.Render(fmt.Sprintf("> %d. %s", i+1, p.Label))
+			}
+			sb.WriteString(line + "\n")
+		}
+	case setupStepBaseURL:
+		sb.WriteString("Base URL of the OpenAI-compatible API:\n" + w.input.View() + "\n")
+	case setupStepKey:
+		sb.WriteString(fmt.Sprintf("API key for %s:\n", w.provider.Label) + w.input.View() + "\n")
+	case setupStepValidating:
+		sb.WriteString("Checking the API key...\n")
+	case setupStepModel:
+		sb.WriteString("Model name (Tab completes from the provider's list):\n" + w.input.View() + "\n")
+	case setupStepSave, setupStepDone:
+		sb.WriteString(fmt.Sprintf("Save %s / %s to %s? [Y/n]\n", w.provider.Label, w.model, w.configPath))
+	}
+	if w.err != nil {
+		sb.WriteString("\n" + w.styles.Error.Render("Error: "+w.err.Error()) + "\n")
+	}
+	return tea.NewView(sb.String())
+}
+
+// result returns the configured model.
+func (w *setupWizard) result() agentpkg.ModelConfig {
+	name := w.provider.Label
+	if w.provider.BaseURL == "" {
+		name = "Custom"
+	}
+	return agentpkg.ModelConfig{
+		Name:         name + " / " + w.model,
+		ProtocolType: w.provider.ProtocolType,
+		BaseURL:      w.baseURL,
+		APIKey:       w.apiKey,
+		ModelName:    w.model,
+		ContextLimit: providers.ContextWindow(w.model),
+	}
+}
+
+// runSetupWizard runs the wizard when the model config at configPath has no
+// model and both stdin and stdout are terminals. It returns the configured
+// model, whether it was saved to configPath, and false when the wizard did
+// not run or was canceled.
+func runSetupWizard(configPath, proxyURL string, profile colorprofile.Profile) (agentpkg.ModelConfig, bool, bool) {
+	if !agentpkg.NeedsModelSetup(configPath) ||
+		!term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return agentpkg.ModelConfig{}, false, false
+	}
+
+	var client *http.Client
+	if proxyURL != "" {
+		c, err := debugpkg.NewHTTPClientWithProxy(proxyURL)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return agentpkg.ModelConfig{}, false, false
+		}
+		client = c
+	}
+
+	w := newSetupWizard(configPath, client)
+	if _, err := tea.NewProgram(w, tea.WithColorProfile(profile)).Run(); err != nil || w.step != setupStepDone {
+		return agentpkg.ModelConfig{}, false, false
+	}
+
+	model := w.result()
+	if !w.save {
+		return model, false, true
+	}
+	if err := saveSetupModel(configPath, model); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save %s: %v\nUsing the model for this run only.\n", configPath, err)
+		return model, false, true
+	}
+	fmt.Printf("Saved to %s\n", configPath)
+	return model, true, true
+}
+
+// saveSetupModel appends the model to the model config file, creating it
+// when missing.
+func saveSetupModel(path string, model agentpkg.ModelConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(existing)
+	if strings.TrimSpace(content) != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if !strings.HasSuffix(strings.TrimSpace(content), "---") {
+		content += "---\n"
+	}
+	content += agentpkg.FormatModelConfig(model) + "---\n"
+	return os.WriteFile(path, []byte(content), 0600)
+}
@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+)
+
+func keyPress(key string) tea.KeyPressMsg {
+	switch key {
+	case KeyEnter:
+		return tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter})
+	case KeyDown:
+		return tea.KeyPressMsg(tea.Key{Code: tea.KeyDown})
+	}
+	return tea.KeyPressMsg(tea.Key{Code: []rune(key)[0], Text: key})
+}
+
+// typeText feeds text to the wizard one key at a time.
+func typeText(w *setupWizard, text string) {
+	for _, r := range text {
+		w.Update(keyPress(string(r)))
+	}
+}
+
+// runCmd runs cmd and feeds its message back, as the program would.
+func runCmd(w *setupWizard, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if msg, ok := cmd().(setupValidatedMsg); ok {
+		w.Update(msg)
+	}
+}
+
+func TestSetupWizardCustomProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"id": "local-large"}, {"id": "local-small"}]}`))
+	}))
+	defer server.Close()
+
+	w := newSetupWizard("model.conf", server.Client())
+	w.Update(keyPress("6"))
+	w.Update(keyPress(KeyEnter))
+	if w.step != setupStepBaseURL {
+		t.Fatalf("custom provider should ask for a base URL, step = %d", w.step)
+	}
+	w.input.SetValue(server.URL + "/")
+	w.Update(keyPress(KeyEnter))
+
+	typeText(w, "sk-bad")
+	_, cmd := w.Update(keyPress(KeyEnter))
+	runCmd(w, cmd)
+	if w.step != setupStepKey || w.err == nil || !strings.Contains(w.err.Error(), "API key rejected") {
+		t.Fatalf("a rejected key should be asked again, step = %d, err = %v", w.step, w.err)
+	}
+	if view := w.View().Content; strings.Contains(view, "sk-bad") {
+		t.Error("the API key must be masked")
+	}
+
+	w.input.SetValue("sk-good")
+	_, cmd = w.Update(keyPress(KeyEnter))
+	runCmd(w, cmd)
+	if w.step != setupStepModel || w.input.Value() != "local-large" {
+		t.Fatalf("step = %d, model = %q; want the first listed model", w.step, w.input.Value())
+	}
+	w.Update(keyPress(KeyEnter))
+	w.Update(keyPress("n"))
+
+	if w.step != setupStepDone || w.save {
+		t.Fatalf("step = %d, save = %v", w.step, w.save)
+	}
+	got := w.result()
+	if got.BaseURL != server.URL || got.APIKey != "sk-good" || got.ModelName != "local-large" || got.ProtocolType != "openai" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestSaveSetupModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "model.conf")
+	model := agentpkg.ModelConfig{Name: "OpenAI / gpt-4.1", ProtocolType: "openai", BaseURL: "https://api.openai.com/v1", APIKey: "k", ModelName: "gpt-4.1"}
+
+	if err := saveSetupModel(path, model); err != nil {
+		t.Fatal(err)
+	}
+	if agentpkg.NeedsModelSetup(path) {
+		t.Fatal("the saved config should have a model")
+	}
+
+	mm := agentpkg.NewModelManager(path)
+	models := mm.GetModels()
+	if len(models) != 1 || models[0].ModelName != "gpt-4.1" {
+		t.Errorf("models = %+v", models)
+	}
+	if info, err := os.Stat(path); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0600) {
+		t.Errorf("config should be private: %v %v", info.Mode(), err)
+	}
+}
@@ -0,0 +1,28 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestAltEnterSendsSteer(t *testing.T) {
+	in := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), in, nil, 80, 24)
+	terminal.input.SetValue("use the other file")
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter, Mod: tea.ModAlt}))
+
+	tag, value, err := stream.ReadTLV(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != stream.TagTextUser || value != ":steer use the other file" {
+		t.Errorf("sent %s %q, want the input as :steer", tag, value)
+	}
+	if terminal.input.Value() != "" {
+		t.Errorf("input should be cleared, got %q", terminal.input.Value())
+	}
+}
@@ -45,7 +45,7 @@ func TestStreamingProfile(t *testing.T) {
 		// Simulate delta
 		wb.AppendOrUpdate(streamID, "TA", fmt.Sprintf(" word%d", i))
 
-		// This is what handleTick does
+		// This is what handleUpdate does
 		dm.updateContent()
 
 		elapsed := time.Since(start)
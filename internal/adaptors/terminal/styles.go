@@ -114,6 +114,21 @@ type Styles struct {
 	DiffRemove  lipgloss.Style
 	DiffAdd     lipgloss.Style
 
+	// Fenced code block styles (assistant markdown)
+	CodeText    lipgloss.Style
+	CodeKeyword lipgloss.Style
+	CodeString  lipgloss.Style
+	CodeComment lipgloss.Style
+	CodeNumber  lipgloss.Style
+	CodeFence   lipgloss.Style
+
+	// Rendered markdown styles (assistant text with :render on)
+	MarkdownHeading lipgloss.Style
+	MarkdownStrong  lipgloss.Style
+	MarkdownQuote   lipgloss.Style
+	MarkdownLink    lipgloss.Style
+	MarkdownMarker  lipgloss.Style
+
 	// Display styles
 	Input       lipgloss.Style
 	Status      lipgloss.Style
@@ -154,6 +169,7 @@ func (s *Styles) RenderBorderedBox(content string, width int, borderColor color.
 // NewStyles creates a Styles instance from a Theme
 func NewStyles(theme *Theme) *Styles {
 	baseStyle := lipgloss.NewStyle()
+	codeStyle := baseStyle.Background(lipgloss.Color(theme.Dim))
 	return &Styles{
 		// Output text styles
 		Text:        baseStyle.Foreground(lipgloss.Color(theme.Text)).Bold(true),
@@ -167,6 +183,21 @@ func NewStyles(theme *Theme) *Styles {
 		DiffRemove:  baseStyle.Foreground(lipgloss.Color(theme.Removed)),
 		DiffAdd:     baseStyle.Foreground(lipgloss.Color(theme.Added)),
 
+		// Code blocks sit on the dim color so they stand out from prose
+		CodeText:    codeStyle.Foreground(lipgloss.Color(theme.Text)),
+		CodeKeyword: codeStyle.Foreground(lipgloss.Color(theme.Primary)).Bold(true),
+		CodeString:  codeStyle.Foreground(lipgloss.Color(theme.Success)),
+		CodeComment: codeStyle.Foreground(lipgloss.Color(theme.Muted)).Italic(true),
+		CodeNumber:  codeStyle.Foreground(lipgloss.Color(theme.Warning)),
+		CodeFence:   baseStyle.Foreground(lipgloss.Color(theme.Muted)),
+
+		// Prose is already bold, so strong text stands out by color
+		MarkdownHeading: baseStyle.Foreground(lipgloss.Color(theme.Primary)).Bold(true),
+		MarkdownStrong:  baseStyle.Foreground(lipgloss.Color(theme.Selection)).Bold(true),
+		MarkdownQuote:   baseStyle.Foreground(lipgloss.Color(theme.Muted)).Italic(true),
+		MarkdownLink:    baseStyle.Foreground(lipgloss.Color(theme.Primary)).Underline(true),
+		MarkdownMarker:  baseStyle.Foreground(lipgloss.Color(theme.Muted)),
+
 		// Display styles
 		Input:       baseStyle,
 		Status:      baseStyle.Foreground(lipgloss.Color(theme.Dim)),
@@ -0,0 +1,210 @@
+package terminal
+
+// Session tabs.
+// Ctrl+T opens another session in the same terminal, with its own history,
+// output writer, and display; Ctrl+1..9 switch between them (Alt+1..9 in
+// terminals that do not report Ctrl with a digit). The session shown lives
+// in the Terminal's session, out, streamInput, and display fields as before;
+// the others wait in tabs. Output for a hidden session only updates its
+// badge in the status bar, e.g. "1 [2] 3• 4✓".
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// MaxTabs is the number of sessions one terminal holds, one per digit key.
+const MaxTabs = 9
+
+// NewTabFunc creates the session of a new tab. Its output writer renders at
+// width with styles.
+type NewTabFunc func(width int, styles *Styles) (*agentpkg.Session, OutputWriter, *stream.ChanInput)
+
+// sessionTab is a session with its own output and display.
+type sessionTab struct {
+	session       *agentpkg.Session
+	out           OutputWriter
+	streamInput   *stream.ChanInput
+	display       DisplayModel
+	reviewsClosed map[string]bool
+
+	// What the output was last rendered with, to catch up on resizes and
+	// theme changes made while the tab was hidden
+	width  int
+	styles *Styles
+
+	busy     bool // a prompt runs or is queued
+	finished bool // went idle while hidden
+}
+
+// SetNewTab sets how Ctrl+T creates sessions; without it Ctrl+T does nothing.
+func (m *Terminal) SetNewTab(newTab NewTabFunc) {
+	m.newTab = newTab
+}
+
+// syncTabs stores the session shown in its tab and returns all tabs. A
+// terminal that never opened a tab has one.
+func (m *Terminal) syncTabs() []*sessionTab {
+	if len(m.tabs) == 0 {
+		m.tabs = []*sessionTab{{}}
+		m.activeTab = 0
+	}
+	tab := m.tabs[m.activeTab]
+	tab.session, tab.out, tab.streamInput = m.session, m.out, m.streamInput
+	tab.display, tab.reviewsClosed = m.display, m.reviewsClosed
+	tab.width, tab.styles = m.windowWidth, m.styles
+	tab.busy = isBusy(m.out)
+	tab.finished = false
+	return m.tabs
+}
+
+// openTab creates a session in a new tab and shows it.
+func (m *Terminal) openTab() tea.Cmd {
+	if m.newTab == nil {
+		return nil
+	}
+	if len(m.syncTabs()) >= MaxTabs {
+		m.flashStatus(fmt.Sprintf("At most %d sessions", MaxTabs))
+		return nil
+	}
+	session, out, input := m.newTab(m.layoutWidth(), m.styles)
+	m.tabs = append(m.tabs, &sessionTab{
+		session:     session,
+		out:         out,
+		streamInput: input,
+		display:     NewDisplayModel(out.WindowBuffer(), m.styles),
+		styles:      m.styles,
+	})
+	cmd := m.switchTab(len(m.tabs) - 1)
+	m.out.WriteNotify(m.welcomeMessage())
+	return tea.Batch(cmd, waitForOutput(out))
+}
+
+// switchTab shows the session of tab i, counted from 0.
+func (m *Terminal) switchTab(i int) tea.Cmd {
+	tabs := m.syncTabs()
+	if i >= len(tabs) {
+		m.flashStatus(fmt.Sprintf("No session %d (Ctrl+T opens one)", i+1))
+		return nil
+	}
+	if i == m.activeTab {
+		return nil
+	}
+	tab := tabs[i]
+	m.activeTab = i
+	m.session, m.out, m.streamInput = tab.session, tab.out, tab.streamInput
+	m.display, m.reviewsClosed = tab.display, tab.reviewsClosed
+	tab.finished = false
+
+	if tab.width != m.windowWidth {
+		m.out.SetWindowWidth(m.layoutWidth())
+		m.display.SetWidth(m.layoutWidth())
+	}
+	if tab.styles != m.styles {
+		m.out.SetStyles(m.styles)
+		m.display.SetStyles(m.styles)
+	}
+	// Search matches index the windows of the other session
+	m.search.matches = nil
+
+	m.updateDisplayHeight()
+	m.restoreFocusAfterSelector()
+	return tea.Batch(m.refreshOutput(), m.alertCmd())
+}
+
+// handleTabUpdate takes output of a hidden session: it updates the badge of
+// its tab, and flashes when the session finished.
+func (m *Terminal) handleTabUpdate(out OutputWriter) (tea.Model, tea.Cmd) {
+	i := m.tabIndex(out)
+	if i < 0 {
+		return m, nil
+	}
+	tab := m.tabs[i]
+	busy := isBusy(out)
+	if tab.busy && !busy {
+		tab.finished = true
+		m.flashStatus(fmt.Sprintf("Session %d finished", i+1))
+	}
+	tab.busy = busy
+	m.updateStatus()
+	return m, tea.Batch(waitForOutput(out), m.notifyAlerts(out.TakeAlerts()), m.startTick())
+}
+
+// tabIndex returns the tab of a hidden session's output, or -1.
+func (m *Terminal) tabIndex(out OutputWriter) int {
+	for i, tab := range m.tabs {
+		if i != m.activeTab && tab.out == out {
+			return i
+		}
+	}
+	return -1
+}
+
+// tabsStatus renders the tabs for the status bar, or "" with one session.
+func (m *Terminal) tabsStatus() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	states := make([]tabState, len(m.tabs))
+	for i, tab := range m.tabs {
+		if i == m.activeTab {
+			states[i] = tabState{busy: isBusy(m.out)}
+			continue
+		}
+		states[i] = tabState{busy: tab.busy, finished: tab.finished, review: len(tab.out.PendingReviews()) > 0}
+	}
+	return formatTabs(states, m.activeTab)
+}
+
+// tabState is what the badge of a tab shows.
+type tabState struct {
+	busy     bool
+	finished bool
+	review   bool // a change awaits review
+}
+
+// formatTabs renders tabs by number, the shown one in brackets, marked "!"
+// when a change awaits review, "•" while busy, and "✓" when it finished
+// while hidden.
+func formatTabs(tabs []tabState, active int) string {
+	labels := make([]string, len(tabs))
+	for i, tab := range tabs {
+		label := fmt.Sprint(i + 1)
+		switch {
+		case tab.review:
+			label += "!"
+		case tab.busy:
+			label += "•"
+		case tab.finished:
+			label += "✓"
+		}
+		if i == active {
+			label = "[" + label + "]"
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, " ")
+}
+
+// isBusy reports whether a session has a prompt running or queued.
+func isBusy(out OutputWriter) bool {
+	return out.IsInProgress() || out.GetQueueCount() > 0
+}
+
+// tabKey returns the tab a key switches to, counted from 0: ctrl+1..9, and
+// alt+1..9 for terminals that send Ctrl with a digit as the digit alone.
+func tabKey(key string) (int, bool) {
+	digit, ok := strings.CutPrefix(key, "ctrl+")
+	if !ok {
+		digit, ok = strings.CutPrefix(key, "alt+")
+	}
+	if !ok || len(digit) != 1 || digit[0] < '1' || digit[0] > '9' {
+		return 0, false
+	}
+	return int(digit[0] - '1'), true
+}
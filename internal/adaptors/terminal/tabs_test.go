@@ -0,0 +1,108 @@
+package terminal
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// newTabbedTerminal returns a terminal whose Ctrl+T opens sessions writing
+// to the returned outputs, the first being the session it starts with.
+func newTabbedTerminal() (*Terminal, *[]*outputWriter) {
+	first := NewTerminalOutput(DefaultStyles())
+	outs := []*outputWriter{first}
+	terminal := NewTerminal(nil, first, stream.NewChanInput(10), &app.Config{Cfg: &config.Settings{}}, 80, 24)
+	terminal.SetNewTab(func(width int, styles *Styles) (*agentpkg.Session, OutputWriter, *stream.ChanInput) {
+		out := NewTerminalOutput(styles)
+		out.SetWindowWidth(width)
+		outs = append(outs, out)
+		return nil, out, stream.NewChanInput(10)
+	})
+	return terminal, &outs
+}
+
+func TestTabsSwitchAndShowBackgroundProgress(t *testing.T) {
+	terminal, outs := newTabbedTerminal()
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 't', Mod: tea.ModCtrl}))
+	if len(*outs) != 2 || terminal.activeTab != 1 || terminal.out != (*outs)[1] {
+		t.Fatalf("Ctrl+T should open and show a second session, active tab %d", terminal.activeTab)
+	}
+	if got := terminal.tabsStatus(); got != "1 [2]" {
+		t.Errorf("tabs = %q, want %q", got, "1 [2]")
+	}
+	first, second := (*outs)[0], (*outs)[1]
+	shown := second.WindowBuffer().GetWindowCount()
+
+	// Output of the hidden session updates its badge, not the display
+	first.inProgress = true
+	first.AppendError("hidden output")
+	terminal.Update(updateMsg{out: first})
+	if got := terminal.tabsStatus(); got != "1• [2]" {
+		t.Errorf("tabs while the hidden session works = %q, want %q", got, "1• [2]")
+	}
+	first.inProgress = false
+	terminal.Update(updateMsg{out: first})
+	if got := terminal.tabsStatus(); got != "1✓ [2]" {
+		t.Errorf("tabs after the hidden session finished = %q, want %q", got, "1✓ [2]")
+	}
+	if terminal.statusFlash != "Session 1 finished" {
+		t.Errorf("flash = %q, want %q", terminal.statusFlash, "Session 1 finished")
+	}
+	if terminal.out != second || second.WindowBuffer().GetWindowCount() != shown {
+		t.Error("output of the hidden session should not change the display")
+	}
+
+	// Alt+digit switches back and clears the badge
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: '1', Mod: tea.ModAlt}))
+	if terminal.activeTab != 0 || terminal.out != first {
+		t.Fatalf("Alt+1 should show the first session, active tab %d", terminal.activeTab)
+	}
+	if got := terminal.tabsStatus(); got != "[1] 2" {
+		t.Errorf("tabs after switching = %q, want %q", got, "[1] 2")
+	}
+
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: '3', Mod: tea.ModCtrl}))
+	if terminal.activeTab != 0 || terminal.statusFlash != "No session 3 (Ctrl+T opens one)" {
+		t.Errorf("Ctrl+3 without a third session: active tab %d, flash %q", terminal.activeTab, terminal.statusFlash)
+	}
+}
+
+func TestQuitWarnsAboutHiddenSessions(t *testing.T) {
+	terminal, outs := newTabbedTerminal()
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 't', Mod: tea.ModCtrl}))
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: 't', Mod: tea.ModCtrl}))
+	terminal.Update(tea.KeyPressMsg(tea.Key{Code: '1', Mod: tea.ModCtrl}))
+
+	(*outs)[1].inProgress = true
+	(*outs)[2].inProgress = true
+	(*outs)[2].queueCount = 1
+	want := "2 tasks running, 1 queued — quit anyway? Press y/n"
+	if got := terminal.quitConfirmText(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTabKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+		ok   bool
+	}{
+		{"ctrl+1", 0, true},
+		{"alt+9", 8, true},
+		{"ctrl+0", 0, false},
+		{"1", 0, false},
+		{"ctrl+t", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := tabKey(tt.key); got != tt.want || ok != tt.ok {
+			t.Errorf("tabKey(%q) = %d, %v, want %d, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -11,14 +11,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
 
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/app"
 	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
 )
 
 // ============================================================================
@@ -29,6 +32,10 @@ const (
 	DefaultWidth  = 80
 	DefaultHeight = 20
 
+	// Narrower terminals show a placeholder instead of the layout, whose
+	// borders and paddings need room
+	MinWidth = 20
+
 	// Row allocation: input box, status bar, newlines
 	InputRows  = 3
 	StatusRows = 1
@@ -37,14 +44,17 @@ const (
 	// Component sizing
 	InputPaddingH     = 8  // horizontal padding for input fields (border + padding both sides)
 	SelectorMaxHeight = 30 // maximum height for model selector and similar overlays
+
+	// Pastes longer than this are saved to a temp file and referenced by path
+	LargePasteThreshold = 10000
 )
 
 // Timing constants
 const (
-	UpdateThrottleInterval = 100 * time.Millisecond // batch rapid display updates
-	TickInterval           = 250 * time.Millisecond // polling during streaming
-	FlusherInterval        = 50 * time.Millisecond  // update flusher tick
-	SubmitTickDelay        = 50 * time.Millisecond  // delay before first tick after submit
+	UpdateThrottleInterval = time.Second / 30       // batch rapid display updates, at most ~30 refreshes a second
+	TickInterval           = 250 * time.Millisecond // status bar refresh while a task runs
+	DoubleCancelWindow     = time.Second            // second Ctrl+G within this cancels all
+	ShutdownTimeout        = 3 * time.Second        // how long quitting waits for the running task to stop
 )
 
 // Focus constants
@@ -76,24 +86,48 @@ type Terminal struct {
 	input         InputModel
 	modelSelector *ModelSelector
 	queueManager  *QueueManager
+	promptPicker  *PromptPicker
+	reviewViewer  *ReviewViewer
 	themeSelector *ThemeSelector
 	themeManager  *ThemeManager
 
 	// Status bar state (simplified - no separate struct)
-	statusText string
-	inProgress bool
+	statusText       string
+	inProgress       bool
+	statusFlash      string    // transient message shown instead of statusText
+	statusFlashUntil time.Time // when statusFlash expires
+	modeLine         string    // badges of the session's modes, shown above the input box
+	ticking          bool      // a status tick is scheduled
+
+	// Session tabs; empty until Ctrl+T opens a second session
+	tabs      []*sessionTab
+	activeTab int
+	newTab    NewTabFunc
+
+	// Transcript search
+	search searchState
+
+	// Changes awaiting review whose viewer the user closed, by ID: true once
+	// answered, false when put off with esc (:review shows those again)
+	reviewsClosed map[string]bool
 
 	// State
 	quitting               bool
+	stopping               bool // quit was confirmed; waiting for the session to stop
 	confirmDialog          bool
 	cancelConfirmDialog    bool
 	cancelAllConfirmDialog bool
-	cancelFromCommand      bool   // tracks if cancel came from :cancel command (vs Ctrl+G)
-	focusedWindow          string // "input" or "display"
+	cancelFromCommand      bool      // tracks if cancel came from :cancel command (vs Ctrl+G)
+	lastCancelKey          time.Time // last Ctrl+G, for the double-press cancel all
+	focusedWindow          string    // "input" or "display"
 	windowWidth            int
 	windowHeight           int
 	styles                 *Styles
-	hasFocus               bool // tracks whether the terminal has application focus
+	hasFocus               bool   // tracks whether the terminal has application focus
+	focusReported          bool   // the terminal has sent a focus event, so hasFocus can be trusted
+	notifyMode             string // --notify; empty is off
+	notifyTitle            string // title of desktop notifications
+	mouse                  bool   // report mouse events; off with --no-mouse
 
 	// Theme preview debouncing
 	themePreviewID int // ID of the current pending theme preview
@@ -131,6 +165,8 @@ func NewTerminalWithTheme(
 		input:         NewInputModel(styles),
 		modelSelector: NewModelSelector(styles),
 		queueManager:  NewQueueManager(styles),
+		promptPicker:  NewPromptPicker(styles),
+		reviewViewer:  NewReviewViewer(styles),
 		themeSelector: NewThemeSelector(styles),
 		themeManager:  themeManager,
 		windowWidth:   initialWidth,
@@ -138,21 +174,37 @@ func NewTerminalWithTheme(
 		styles:        styles,
 		focusedWindow: "input",
 		hasFocus:      true,
+		mouse:         true,
 	}
 
-	// Initialize component widths
-	m.display.SetWidth(initialWidth)
-	m.input.SetWidth(initialWidth)
-	m.modelSelector.SetSize(initialWidth, initialHeight)
-	m.queueManager.SetSize(initialWidth, initialHeight)
-	m.themeSelector.SetSize(initialWidth, initialHeight)
-	m.updateDisplayHeight()
+	m.sizeComponents()
 
 	return m
 }
 
-// Init starts the periodic tick loop for processing session updates.
+// layoutWidth returns the width the layout is computed for: the terminal's,
+// or MinWidth when it is narrower and the placeholder is shown.
+func (m *Terminal) layoutWidth() int {
+	return max(MinWidth, m.windowWidth)
+}
+
+// sizeComponents sizes the display, input, and overlays to the terminal.
+func (m *Terminal) sizeComponents() {
+	width, height := m.layoutWidth(), max(0, m.windowHeight)
+	m.display.SetWidth(width)
+	m.input.SetWidth(width)
+	m.modelSelector.SetSize(width, height)
+	m.queueManager.SetSize(width, height)
+	m.promptPicker.SetSize(width, height)
+	m.reviewViewer.SetSize(width, height)
+	m.themeSelector.SetSize(width, height)
+	m.updateDisplayHeight()
+}
+
+// Init shows the welcome message and starts waiting for session output.
 func (m *Terminal) Init() tea.Cmd {
+	m.out.WriteNotify(m.welcomeMessage())
+
 	// Display any buffered warnings from initialization
 	if warnings := GetWarnings(); len(warnings) > 0 {
 		for _, w := range warnings {
@@ -160,33 +212,42 @@ func (m *Terminal) Init() tea.Cmd {
 		}
 	}
 
-	return tea.Tick(TickInterval, func(_ time.Time) tea.Msg {
-		return tickMsg{}
-	})
+	return m.waitForUpdate()
 }
 
 // Update handles all incoming messages and routes them to appropriate handlers.
 // Messages are processed in order of priority:
 //  1. KeyMsg - keyboard input (highest priority for responsiveness)
 //  2. WindowSizeMsg - terminal resize
-//  3. tickMsg - periodic updates for display and model switching
+//  3. updateMsg - session output to show; tickMsg - status bar refresh
 //  4. Editor messages - external editor completion
 //  5. Focus/Blur - application focus changes
 //  6. Paste - clipboard paste
+//  7. Mouse - wheel scrolling and click-to-focus
 func (m *Terminal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKeyMsg(msg)
+		model, cmd := m.handleKeyMsg(msg)
+		return model, tea.Batch(cmd, m.startTick())
 
 	case tea.WindowSizeMsg:
 		return m.handleWindowSize(msg)
 
+	case updateMsg:
+		if msg.out != nil && msg.out != m.out {
+			return m.handleTabUpdate(msg.out)
+		}
+		return m.handleUpdate()
+
 	case tickMsg:
 		return m.handleTick()
 
 	case themePreviewMsg:
 		return m.handleThemePreview(msg)
 
+	case shutdownDoneMsg:
+		return m, m.quit()
+
 	case editorStartMsg:
 		return m.handleEditorStart(msg)
 
@@ -206,8 +267,13 @@ func (m *Terminal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleFocus()
 
 	case tea.PasteMsg:
-		m.input.updateFromMsg(msg)
-		return m, nil
+		return m.handlePaste(msg)
+
+	case tea.MouseWheelMsg:
+		return m.handleMouseWheel(msg)
+
+	case tea.MouseClickMsg:
+		return m.handleMouseClick(msg)
 	}
 
 	// Default: pass to input component
@@ -215,22 +281,52 @@ func (m *Terminal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// tickMsg is sent periodically to update the display.
+// updateMsg reports that a session wrote output to show.
+type updateMsg struct {
+	out OutputWriter // the output written to
+}
+
+// tickMsg refreshes the status bar while a task runs.
 type tickMsg struct{}
 
+// waitForUpdate waits until the output writer signals new output, which it
+// does at most every UpdateThrottleInterval. Handling the updateMsg starts
+// the next wait, so the display is refreshed when there is output rather
+// than polled.
+func (m *Terminal) waitForUpdate() tea.Cmd {
+	return waitForOutput(m.out)
+}
+
+// waitForOutput waits for new output of any session, shown or not.
+func waitForOutput(out OutputWriter) tea.Cmd {
+	updates := out.UpdateChan()
+	return func() tea.Msg {
+		<-updates
+		return updateMsg{out: out}
+	}
+}
+
+// startTick schedules the next status tick while a task runs, for its
+// elapsed time, or a status flash shows. It returns nil when a tick is
+// already scheduled or none is needed.
+func (m *Terminal) startTick() tea.Cmd {
+	if m.ticking || (!m.inProgress && !time.Now().Before(m.statusFlashUntil)) {
+		return nil
+	}
+	m.ticking = true
+	return tea.Tick(TickInterval, func(_ time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
 // handleWindowSize handles terminal resize events.
 func (m *Terminal) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	m.windowWidth = msg.Width
 	m.windowHeight = msg.Height
 
-	// Update all components
-	m.out.SetWindowWidth(max(0, msg.Width))
-	m.display.SetWidth(max(0, msg.Width))
-	m.input.SetWidth(max(0, msg.Width))
-	m.modelSelector.SetSize(msg.Width, msg.Height)
-	m.queueManager.SetSize(msg.Width, msg.Height)
-	m.themeSelector.SetSize(msg.Width, msg.Height)
-	m.updateDisplayHeight()
+	// Every window is wrapped again for the new width
+	m.out.SetWindowWidth(m.layoutWidth())
+	m.sizeComponents()
 
 	// Validate cursor position after resize (window heights may have changed)
 	m.display.ValidateCursor()
@@ -241,43 +337,46 @@ func (m *Terminal) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd)
 	return m, nil
 }
 
-// handleTick processes periodic updates for display and model switching.
-func (m *Terminal) handleTick() (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
-	// Drain pending display updates (non-blocking)
-	select {
-	case <-m.out.UpdateChan():
-		if m.out.WindowBuffer().GetWindowCount() > 0 {
-			m.updateStatus()
-			m.updateDisplayHeight()
-			if m.display.shouldFollow() {
-				m.display.SetCursorToLastWindow()
-			}
-			m.display.updateContent()
-		}
-
-		// Update model selector if models changed
-		cmd = m.modelSelector.LoadModels(m.out.GetModels(), m.out.GetActiveModelID())
+// handleUpdate shows new session output, then waits for the next.
+func (m *Terminal) handleUpdate() (tea.Model, tea.Cmd) {
+	cmd := m.refreshOutput()
+	return m, tea.Batch(m.waitForUpdate(), cmd, m.alertCmd(), m.startTick())
+}
 
-		// Check for queue items update
-		if queueItems := m.out.GetQueueItems(); queueItems != nil {
-			m.queueManager.SetItems(queueItems)
-			// Update display to show new items
-			m.display.updateContent()
+// refreshOutput brings the status bar, display, model selector, queue, and
+// review viewer up to date with the output of the session shown.
+func (m *Terminal) refreshOutput() tea.Cmd {
+	m.updateStatus()
+	if m.out.WindowBuffer().GetWindowCount() > 0 {
+		m.updateDisplayHeight()
+		// After :clear the cursor may point past the last window
+		if m.display.shouldFollow() || m.display.GetWindowCursor() >= m.out.WindowBuffer().GetWindowCount() {
+			m.display.SetCursorToLastWindow()
 		}
+		m.display.updateContent()
+	}
 
-	default:
-		m.updateStatus()
+	// Update model selector if models changed
+	cmd := m.modelSelector.LoadModels(m.out.GetModels(), m.out.GetActiveModelID())
+
+	// Check for queue items update
+	if queueItems := m.out.GetQueueItems(); queueItems != nil {
+		m.queueManager.SetItems(queueItems)
+		// Update display to show new items
+		m.display.updateContent()
 	}
 
-	// Continue ticking
-	return m, tea.Batch(
-		tea.Tick(TickInterval, func(_ time.Time) tea.Msg {
-			return tickMsg{}
-		}),
-		cmd,
-	)
+	m.syncReviewViewer()
+
+	return cmd
+}
+
+// handleTick refreshes the status bar, and keeps ticking while it changes
+// on its own.
+func (m *Terminal) handleTick() (tea.Model, tea.Cmd) {
+	m.ticking = false
+	m.updateStatus()
+	return m, m.startTick()
 }
 
 // handleEditorFinished handles completion of the external editor.
@@ -295,6 +394,67 @@ func (m *Terminal) handleEditorFinished(msg editorFinishedMsg) (tea.Model, tea.C
 	return m, nil
 }
 
+// handlePaste handles a bracketed paste as a single edit.
+// Multi-line text goes into editorContent (shown as the "[N lines]" summary,
+// like the Ctrl+O flow) so embedded newlines never submit a partial prompt.
+// Very large pastes are saved to a temp file and referenced by path instead.
+func (m *Terminal) handlePaste(msg tea.PasteMsg) (tea.Model, tea.Cmd) {
+	content := strings.ReplaceAll(msg.Content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	if content == "" {
+		return m, nil
+	}
+	m.focusInput()
+
+	if len(content) > LargePasteThreshold {
+		path, err := savePasteToTempFile(content)
+		if err != nil {
+			m.out.AppendError("Failed to save large paste: %v", err)
+		} else {
+			m.out.WriteNotify(fmt.Sprintf("Pasted %d characters (%d lines) saved to %s",
+				len(content), strings.Count(content, "\n")+1, path))
+			content = "(pasted text saved to " + path + ")"
+		}
+	}
+
+	if !strings.Contains(content, "\n") && m.input.editorContent == "" {
+		m.input.updateFromMsg(tea.PasteMsg{Content: content})
+		return m, nil
+	}
+
+	// Merge with what is already typed: append to editor content, or insert
+	// at the cursor of the single-line value
+	var combined string
+	if m.input.editorContent != "" {
+		combined = m.input.editorContent + content
+	} else {
+		value := []rune(m.input.Value())
+		pos := min(m.input.input.Position(), len(value))
+		combined = string(value[:pos]) + content + string(value[pos:])
+	}
+
+	m.input.editorContent = combined
+	m.input.SetValue(FormatEditorContent(combined))
+	m.input.CursorEnd()
+	return m, nil
+}
+
+// savePasteToTempFile writes pasted text to a new temp file and returns its path.
+func savePasteToTempFile(content string) (string, error) {
+	f, err := os.CreateTemp("", "alayacore-paste-*.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close() //nolint:errcheck // already returning the write error
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
 // handleDisplayEditorFinished handles completion of the external editor for display viewing.
 // This is a no-op - we just opened the editor to view content, nothing to do when it closes.
 func (m *Terminal) handleDisplayEditorFinished(msg displayEditorFinishedMsg) (tea.Model, tea.Cmd) {
@@ -314,8 +474,7 @@ func (m *Terminal) handleEditorStart(msg editorStartMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	//nolint:gosec // G204: Editor command from user config is intentional
-	cmd := exec.Command(msg.editorCmd, tmpFileName)
+	cmd := editorExec(msg.editorCmd, tmpFileName)
 
 	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
 		defer os.Remove(tmpFileName)
@@ -357,7 +516,11 @@ func (m *Terminal) handleFileEditorFinished(msg FileEditorFinishedMsg) (tea.Mode
 
 // updateDisplayHeight updates the display viewport height based on window size.
 func (m *Terminal) updateDisplayHeight() {
-	m.display.UpdateHeight(m.windowHeight)
+	height := m.windowHeight
+	if m.modeLine != "" {
+		height--
+	}
+	m.display.UpdateHeight(height)
 }
 
 // updateStatus updates the status bar state from the output writer.
@@ -374,6 +537,11 @@ func (m *Terminal) updateStatus() {
 	// Build status segments - each rendered separately with appropriate colors
 	var segments []string
 
+	// Tabs segment when there are several sessions
+	if tabs := m.tabsStatus(); tabs != "" {
+		segments = append(segments, m.styles.Status.Foreground(m.styles.ColorAccent).Render(tabs))
+	}
+
 	// Queue segment - prefix dimmed, count highlighted
 	if queueCount > 0 {
 		prefix := m.styles.Status.Render("Queued(Ctrl-Q):")
@@ -390,6 +558,13 @@ func (m *Terminal) updateStatus() {
 	}
 	segments = append(segments, m.styles.Status.Render(stepsPart))
 
+	// Progress segment while a prompt streams
+	if inProgress && m.session != nil {
+		if progress := formatProgress(m.session.Progress()); progress != "" {
+			segments = append(segments, m.styles.Status.Render(progress))
+		}
+	}
+
 	// Context segment (dimmed)
 	if contextStatus != "" {
 		segments = append(segments, m.styles.Status.Render(contextStatus))
@@ -407,26 +582,77 @@ func (m *Terminal) updateStatus() {
 
 	m.statusText = status
 	m.inProgress = inProgress
+
+	// The mode line takes a line from the display while it is shown
+	if modeLine := formatBadges(m.out.SessionState()); modeLine != m.modeLine {
+		m.modeLine = modeLine
+		m.updateDisplayHeight()
+	}
+}
+
+// formatBadges renders the modes that change what the next prompt does,
+// e.g. "[dry-run] [approve] [skill: pdf-processing] [📎 2]", or "" when
+// none is on.
+func formatBadges(state agentpkg.SessionState) string {
+	var badges []string
+	if state.DryRun {
+		badges = append(badges, "[dry-run]")
+	}
+	if state.ReviewEdits {
+		badges = append(badges, "[approve]")
+	}
+	if state.ActiveSkill != "" {
+		badges = append(badges, "[skill: "+tools.EscapeControls(state.ActiveSkill)+"]")
+	}
+	if state.Attachments > 0 {
+		badges = append(badges, fmt.Sprintf("[📎 %d]", state.Attachments))
+	}
+	return strings.Join(badges, " ")
+}
+
+// formatProgress renders streaming progress for the status bar, e.g.
+// "Processing… 12s · 34 tok/s". The rate appears once it can be measured.
+func formatProgress(p agentpkg.ProgressSnapshot) string {
+	if !p.Active {
+		return ""
+	}
+	text := fmt.Sprintf("Processing… %ds", int(p.Elapsed.Seconds()))
+	if p.TokensPerSecond > 0 {
+		text += fmt.Sprintf(" · %.0f tok/s", p.TokensPerSecond)
+	}
+	return text
 }
 
 // View renders the complete terminal UI.
 func (m *Terminal) View() tea.View {
+	if m.windowWidth < MinWidth {
+		return m.newView(m.tooNarrowView())
+	}
+
 	var sb strings.Builder
 
 	// Display area
 	sb.WriteString(m.display.View().Content)
 	sb.WriteString("\n")
 
+	// Mode line
+	if m.modeLine != "" {
+		sb.WriteString(m.styles.Status.Foreground(m.styles.ColorAccent).Padding(0, 1).Render(m.modeLine))
+		sb.WriteString("\n")
+	}
+
 	// Input area with optional confirmation dialog
 	confirmText := ""
-	if m.confirmDialog {
-		confirmText = "Confirm exit? Press y/n"
+	if m.stopping {
+		confirmText = "Stopping the running task… Esc quits now"
+	} else if m.confirmDialog {
+		confirmText = m.quitConfirmText()
 	} else if m.cancelConfirmDialog {
-		confirmText = "Confirm cancel? Press y/n"
+		confirmText = "Confirm cancel? Press y/n (Ctrl+G again: cancel all)"
 	} else if m.cancelAllConfirmDialog {
 		confirmText = "Confirm cancel all? Press y/n"
 	}
-	sb.WriteString(m.input.RenderWithBorder(m.confirmDialog || m.cancelConfirmDialog || m.cancelAllConfirmDialog, confirmText))
+	sb.WriteString(m.input.RenderWithBorder(m.stopping || m.confirmDialog || m.cancelConfirmDialog || m.cancelAllConfirmDialog, confirmText))
 
 	// Status bar (simplified - just render directly)
 	sb.WriteString("\n")
@@ -436,34 +662,53 @@ func (m *Terminal) View() tea.View {
 
 	// Render model selector overlay if open
 	if m.modelSelector.IsOpen() {
-		fullContent := m.modelSelector.RenderOverlay(baseContent, m.windowWidth, m.windowHeight)
-		v := tea.NewView(fullContent)
-		v.AltScreen = true
-		v.ReportFocus = true
-		return v
+		return m.newView(m.modelSelector.RenderOverlay(baseContent, m.windowWidth, m.windowHeight))
 	}
 
 	// Render theme selector overlay if open
 	if m.themeSelector.IsOpen() {
-		fullContent := m.themeSelector.RenderOverlay(baseContent, m.windowWidth, m.windowHeight)
-		v := tea.NewView(fullContent)
-		v.AltScreen = true
-		v.ReportFocus = true
-		return v
+		return m.newView(m.themeSelector.RenderOverlay(baseContent, m.windowWidth, m.windowHeight))
 	}
 
 	// Render queue manager overlay if open
 	if m.queueManager.IsOpen() {
-		fullContent := m.queueManager.RenderOverlay(baseContent, m.windowWidth, m.windowHeight)
-		v := tea.NewView(fullContent)
-		v.AltScreen = true
-		v.ReportFocus = true
-		return v
+		return m.newView(m.queueManager.RenderOverlay(baseContent, m.windowWidth, m.windowHeight))
 	}
 
-	v := tea.NewView(baseContent)
+	// Render prompt picker overlay if open
+	if m.promptPicker.IsOpen() {
+		return m.newView(m.promptPicker.RenderOverlay(baseContent, m.windowWidth, m.windowHeight))
+	}
+
+	// Render review viewer overlay if open
+	if m.reviewViewer.IsOpen() {
+		return m.newView(m.reviewViewer.RenderOverlay(baseContent, m.windowWidth, m.windowHeight))
+	}
+
+	return m.newView(baseContent)
+}
+
+// tooNarrowView asks for a wider terminal, in the lines the terminal has.
+func (m *Terminal) tooNarrowView() string {
+	text := fmt.Sprintf("Terminal too narrow: widen it to %d columns", MinWidth)
+	lines := strings.Split(wrapText(text, max(1, m.windowWidth)), "\n")
+	lines = lines[:min(len(lines), max(1, m.windowHeight))]
+	return m.styles.System.Render(strings.Join(lines, "\n"))
+}
+
+// newView wraps rendered content in a full-screen view, cutting the lines
+// wider than the terminal, such as a long status bar or overlay help, which
+// it would otherwise wrap onto extra rows.
+func (m *Terminal) newView(content string) tea.View {
+	if m.windowWidth > 0 {
+		content = lipgloss.NewStyle().MaxWidth(m.windowWidth).Render(content)
+	}
+	v := tea.NewView(content)
 	v.AltScreen = true
 	v.ReportFocus = true
+	if m.mouse {
+		v.MouseMode = tea.MouseModeCellMotion
+	}
 	return v
 }
 
@@ -476,6 +721,11 @@ func (m *Terminal) renderStatusBar() string {
 		indicator = m.styles.Status.Foreground(m.styles.ColorDim).Render("·")
 	}
 
+	if m.statusFlash != "" && time.Now().Before(m.statusFlashUntil) {
+		padding := m.styles.Status.Padding(0, 2)
+		return padding.Render(indicator + " " + m.styles.Status.Foreground(m.styles.ColorAccent).Render(m.statusFlash))
+	}
+
 	if m.statusText != "" {
 		padding := m.styles.Status.Padding(0, 2)
 		return padding.Render(indicator + " " + m.statusText)
@@ -545,7 +795,21 @@ func (m *Terminal) openQueueManager() {
 	m.display.updateContent()
 }
 
-// restoreFocusAfterQueueManager restores focus after queue manager closes.
+// openPromptPicker opens the picker on the prompts sent so far.
+func (m *Terminal) openPromptPicker() tea.Cmd {
+	var prompts []string
+	if m.session != nil {
+		prompts = m.session.PromptHistory()
+	}
+	cmd := m.promptPicker.Open(prompts)
+	m.input.Blur()
+	m.display.SetDisplayFocused(false)
+	m.display.updateContent()
+	return cmd
+}
+
+// restoreFocusAfterQueueManager restores focus after queue manager or
+// prompt picker closes.
 func (m *Terminal) restoreFocusAfterQueueManager() {
 	if m.focusedWindow == focusDisplay {
 		m.focusDisplay()
@@ -555,6 +819,78 @@ func (m *Terminal) restoreFocusAfterQueueManager() {
 	m.display.updateContent()
 }
 
+// syncReviewViewer opens the viewer on the oldest change awaiting review,
+// unless the user put it off or another overlay is open, and closes it once
+// the change shown has been decided.
+func (m *Terminal) syncReviewViewer() {
+	pending := m.out.PendingReviews()
+	isPending := make(map[string]bool, len(pending))
+	for _, r := range pending {
+		isPending[r.ID] = true
+	}
+	for id := range m.reviewsClosed {
+		if !isPending[id] {
+			delete(m.reviewsClosed, id)
+		}
+	}
+
+	if m.reviewViewer.IsOpen() {
+		if isPending[m.reviewViewer.ID()] {
+			return
+		}
+		// Decided elsewhere, e.g. expired
+		m.reviewViewer.Close()
+		m.restoreFocusAfterReviewViewer()
+	}
+	if m.modelSelector.IsOpen() || m.themeSelector.IsOpen() || m.queueManager.IsOpen() || m.promptPicker.IsOpen() {
+		return
+	}
+	for _, r := range pending {
+		if _, closed := m.reviewsClosed[r.ID]; !closed {
+			m.reviewViewer.Open(r)
+			m.input.Blur()
+			m.display.SetDisplayFocused(false)
+			m.display.updateContent()
+			return
+		}
+	}
+}
+
+// showReviews handles :review, which shows the changes put off with esc again.
+func (m *Terminal) showReviews() {
+	for id, answered := range m.reviewsClosed {
+		if !answered {
+			delete(m.reviewsClosed, id)
+		}
+	}
+	if len(m.out.PendingReviews()) == 0 {
+		m.flashStatus("No changes awaiting review")
+		return
+	}
+	m.syncReviewViewer()
+}
+
+// closeReviewViewer closes the viewer after the user answered the change
+// shown or put it off.
+func (m *Terminal) closeReviewViewer(answered bool) {
+	if m.reviewsClosed == nil {
+		m.reviewsClosed = make(map[string]bool)
+	}
+	m.reviewsClosed[m.reviewViewer.ID()] = answered
+	m.reviewViewer.Close()
+	m.restoreFocusAfterReviewViewer()
+}
+
+// restoreFocusAfterReviewViewer restores focus after the review viewer closes.
+func (m *Terminal) restoreFocusAfterReviewViewer() {
+	if m.focusedWindow == focusDisplay {
+		m.focusDisplay()
+	} else {
+		m.focusInput()
+	}
+	m.display.updateContent()
+}
+
 // openThemeSelector opens the theme selector UI.
 func (m *Terminal) openThemeSelector() {
 	if m.themeManager == nil {
@@ -586,6 +922,8 @@ func (m *Terminal) applyTheme(theme *Theme) {
 	m.input.SetStyles(m.styles)
 	m.modelSelector.SetStyles(m.styles)
 	m.queueManager.SetStyles(m.styles)
+	m.promptPicker.SetStyles(m.styles)
+	m.reviewViewer.SetStyles(m.styles)
 	m.themeSelector.SetStyles(m.styles)
 	m.display.updateContent()
 }
@@ -593,10 +931,13 @@ func (m *Terminal) applyTheme(theme *Theme) {
 // handleBlur handles loss of application focus.
 func (m *Terminal) handleBlur() (tea.Model, tea.Cmd) {
 	m.hasFocus = false
+	m.focusReported = true
 	m.display.SetDisplayFocused(false)
 	m.input.Blur()
 	m.modelSelector.SetHasFocus(false)
 	m.queueManager.SetHasFocus(false)
+	m.promptPicker.SetHasFocus(false)
+	m.reviewViewer.SetHasFocus(false)
 	m.themeSelector.SetHasFocus(false)
 	m.display.updateContent()
 	return m, nil
@@ -605,9 +946,12 @@ func (m *Terminal) handleBlur() (tea.Model, tea.Cmd) {
 // handleFocus handles gain of application focus.
 func (m *Terminal) handleFocus() (tea.Model, tea.Cmd) {
 	m.hasFocus = true
+	m.focusReported = true
 
 	m.modelSelector.SetHasFocus(true)
 	m.queueManager.SetHasFocus(true)
+	m.promptPicker.SetHasFocus(true)
+	m.reviewViewer.SetHasFocus(true)
 	m.themeSelector.SetHasFocus(true)
 
 	if m.modelSelector.IsOpen() {
@@ -615,7 +959,7 @@ func (m *Terminal) handleFocus() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	if m.queueManager.IsOpen() {
+	if m.queueManager.IsOpen() || m.promptPicker.IsOpen() {
 		m.display.updateContent()
 		return m, nil
 	}
@@ -625,6 +969,11 @@ func (m *Terminal) handleFocus() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.reviewViewer.IsOpen() {
+		m.display.updateContent()
+		return m, nil
+	}
+
 	if m.focusedWindow == focusDisplay {
 		m.focusDisplay()
 	} else {
@@ -683,7 +1032,7 @@ func (e *Editor) Open(currentContent string) tea.Cmd {
 
 	if editorCmd == "" {
 		return func() tea.Msg {
-			return editorFinishedMsg{content: "", err: fmt.Errorf("no editor found (tried: vim, vi, nano)")}
+			return editorFinishedMsg{content: "", err: errNoEditor()}
 		}
 	}
 
@@ -707,7 +1056,7 @@ func (e *Editor) OpenForDisplay(content string) tea.Cmd {
 
 	if editorCmd == "" {
 		return func() tea.Msg {
-			return displayEditorFinishedMsg{err: fmt.Errorf("no editor found (tried: vim, vi, nano)")}
+			return displayEditorFinishedMsg{err: errNoEditor()}
 		}
 	}
 
@@ -751,11 +1100,11 @@ func (e *Editor) OpenFile(path string) tea.Cmd {
 
 	if editorCmd == "" {
 		return func() tea.Msg {
-			return FileEditorFinishedMsg{Path: path, Err: fmt.Errorf("no editor found (tried: vim, vi, nano)")}
+			return FileEditorFinishedMsg{Path: path, Err: errNoEditor()}
 		}
 	}
 
-	cmd := exec.Command(editorCmd, path)
+	cmd := editorExec(editorCmd, path)
 
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		return FileEditorFinishedMsg{Path: path, Err: err}
@@ -785,22 +1134,48 @@ func FormatEditorContent(content string) string {
 	return fmt.Sprintf("[%d lines] %s (press Enter to send)", lineCount, previewText)
 }
 
+// editorCandidates returns the editors tried in order when $EDITOR is unset.
+// Entries may carry arguments; VS Code needs --wait to block until the file
+// is closed.
+func editorCandidates() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"notepad", "code --wait"}
+	}
+	return []string{"vim", "vi", "nano"}
+}
+
 // getEditorCommand returns the editor command to use
 func getEditorCommand(editorCmd string) string {
 	if editorCmd != "" {
 		return editorCmd
 	}
 
-	for _, editor := range []string{"vim", "vi", "nano"} {
-		path, err := exec.LookPath(editor)
-		if err == nil {
-			return path
+	for _, editor := range editorCandidates() {
+		if _, err := exec.LookPath(strings.Fields(editor)[0]); err == nil {
+			return editor
 		}
 	}
 
 	return ""
 }
 
+// errNoEditor reports that neither $EDITOR nor any fallback editor exists.
+func errNoEditor() error {
+	return fmt.Errorf("no editor found (set $EDITOR or install one of: %s)", strings.Join(editorCandidates(), ", "))
+}
+
+// editorExec builds the command that opens path in editorCmd. The command is
+// split on spaces for arguments like "code --wait", unless it names an
+// existing file, as in "C:\Program Files\Notepad++\notepad++.exe".
+func editorExec(editorCmd, path string) *exec.Cmd {
+	args := []string{editorCmd}
+	if _, err := os.Stat(editorCmd); err != nil {
+		args = strings.Fields(editorCmd)
+	}
+	//nolint:gosec // G204: Editor command from user config is intentional
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
 // hasEditorPrefix checks if the value has an editor content prefix.
 func hasEditorPrefix(value string) bool {
 	return len(value) > 0 && value[0] == '['
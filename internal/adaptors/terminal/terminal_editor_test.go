@@ -2,6 +2,8 @@ package terminal
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -399,7 +401,8 @@ func TestCtrlCInDisplayWindow(t *testing.T) {
 }
 
 func TestCtrlGTriggersCancel(t *testing.T) {
-	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+	input := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), input, nil, 80, 24)
 	terminal.input.SetValue("test input text")
 
 	// Press Ctrl+G (should work regardless of focus)
@@ -429,11 +432,11 @@ func TestCtrlGTriggersCancel(t *testing.T) {
 
 	// Test confirming the dialog by pressing 'y'
 	msg = tea.KeyPressMsg(tea.Key{Code: 'y'})
-	_, cmd = terminal.Update(msg)
+	terminal.Update(msg)
 
 	// Now should emit cancel command
-	if cmd == nil {
-		t.Fatal("Pressing 'y' should emit cancel command")
+	if _, value, err := stream.ReadTLV(input); err != nil || value != ":cancel" {
+		t.Fatalf("Pressing 'y' should emit cancel command, got %q (%v)", value, err)
 	}
 
 	// Cancel dialog should be closed
@@ -443,7 +446,8 @@ func TestCtrlGTriggersCancel(t *testing.T) {
 }
 
 func TestCancelAllCommandRequiresConfirm(t *testing.T) {
-	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+	input := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), input, nil, 80, 24)
 	terminal.input.SetValue(":cancel_all")
 
 	// Press Enter to submit the command
@@ -468,11 +472,11 @@ func TestCancelAllCommandRequiresConfirm(t *testing.T) {
 
 	// Test confirming the dialog by pressing 'y'
 	msg = tea.KeyPressMsg(tea.Key{Code: 'y'})
-	_, cmd = terminal.Update(msg)
+	terminal.Update(msg)
 
 	// Now should emit cancel_all command
-	if cmd == nil {
-		t.Fatal("Pressing 'y' should emit cancel_all command")
+	if _, value, err := stream.ReadTLV(input); err != nil || value != ":cancel_all" {
+		t.Fatalf("Pressing 'y' should emit cancel_all command, got %q (%v)", value, err)
 	}
 
 	// Cancel dialog should be closed
@@ -481,9 +485,10 @@ func TestCancelAllCommandRequiresConfirm(t *testing.T) {
 	}
 }
 
-func TestCtrlUDoesNothingInInput(t *testing.T) {
+func TestCtrlUKillsToStartInInput(t *testing.T) {
 	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
 	terminal.input.SetValue("test input text")
+	terminal.input.input.SetCursor(5)
 
 	// Press Ctrl+U while in input window
 	terminal.focusInput()
@@ -496,9 +501,9 @@ func TestCtrlUDoesNothingInInput(t *testing.T) {
 		t.Fatal("Update returned nil model")
 	}
 
-	// Input should remain unchanged
-	if terminal.input.Value() != "test input text" {
-		t.Errorf("Input should remain unchanged after Ctrl+U in input window, got %q", terminal.input.Value())
+	// Text before the cursor should be killed
+	if terminal.input.Value() != "input text" {
+		t.Errorf("Ctrl+U should delete text before the cursor, got %q", terminal.input.Value())
 	}
 
 	// Should not emit any command
@@ -560,6 +565,7 @@ func TestWindowBufferDeltaRouting(t *testing.T) {
 
 func TestWindowBufferRendering(t *testing.T) {
 	wb := NewWindowBuffer(30, DefaultStyles())
+	wb.SetShowReasoning(true) // fold the reasoning window instead of summarizing it
 	// Add a window with some content
 	wb.AppendOrUpdate("test1", stream.TagTextAssistant, "Hello world")
 	// Get rendered output
@@ -885,3 +891,21 @@ func TestGetWindowContentRegular(t *testing.T) {
 		t.Errorf("Expected regular content, got: %q", content)
 	}
 }
+
+func TestEditorExecSplitsArguments(t *testing.T) {
+	cmd := editorExec("code --wait", "notes.md")
+	if got := strings.Join(cmd.Args, " "); !strings.HasSuffix(got, "--wait notes.md") || !strings.Contains(cmd.Args[0], "code") {
+		t.Errorf("editorExec args = %q", cmd.Args)
+	}
+
+	// A path to an existing editor binary is kept whole even with spaces
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "my editor")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cmd = editorExec(editor, "notes.md")
+	if len(cmd.Args) != 2 || cmd.Args[1] != "notes.md" {
+		t.Errorf("editorExec args = %q, want [%q notes.md]", cmd.Args, editor)
+	}
+}
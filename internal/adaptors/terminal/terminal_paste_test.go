@@ -0,0 +1,94 @@
+package terminal
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestPasteMultiLineGoesToEditorContent(t *testing.T) {
+	input := stream.NewChanInput(10)
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), input, nil, 80, 24)
+
+	trace := "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/tmp/main.go:5 +0x1d"
+	terminal.Update(tea.PasteMsg{Content: trace})
+
+	if terminal.input.editorContent != trace {
+		t.Errorf("editorContent = %q, want pasted text", terminal.input.editorContent)
+	}
+	if want := FormatEditorContent(trace); terminal.input.Value() != want {
+		t.Errorf("input value = %q, want summary %q", terminal.input.Value(), want)
+	}
+	if !strings.HasPrefix(terminal.input.Value(), "[5 lines]") {
+		t.Errorf("summary should show line count, got %q", terminal.input.Value())
+	}
+
+	// Nothing should have been submitted by the embedded newlines
+	input.Close()
+	if n, _ := input.Read(make([]byte, 64)); n > 0 {
+		t.Fatal("paste should not submit anything to the session")
+	}
+}
+
+func TestPasteMultiLineInsertsAtCursor(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+	terminal.input.SetValue("explain  please")
+	terminal.input.input.SetCursor(8)
+
+	terminal.Update(tea.PasteMsg{Content: "line one\r\nline two"})
+
+	want := "explain line one\nline two please"
+	if terminal.input.GetPrompt() != want {
+		t.Errorf("prompt = %q, want %q", terminal.input.GetPrompt(), want)
+	}
+}
+
+func TestPasteAppendsToExistingEditorContent(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	terminal.Update(tea.PasteMsg{Content: "a\nb\n"})
+	terminal.Update(tea.PasteMsg{Content: "c"})
+
+	if terminal.input.GetPrompt() != "a\nb\nc" {
+		t.Errorf("prompt = %q, want %q", terminal.input.GetPrompt(), "a\nb\nc")
+	}
+}
+
+func TestPasteSingleLineStaysInline(t *testing.T) {
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	terminal.Update(tea.PasteMsg{Content: "hello"})
+
+	if terminal.input.Value() != "hello" {
+		t.Errorf("input value = %q, want %q", terminal.input.Value(), "hello")
+	}
+	if terminal.input.editorContent != "" {
+		t.Errorf("single-line paste should not set editorContent, got %q", terminal.input.editorContent)
+	}
+}
+
+func TestPasteLargeSavedToTempFile(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+
+	large := strings.Repeat("0123456789\n", LargePasteThreshold/10)
+	terminal.Update(tea.PasteMsg{Content: large})
+
+	prompt := terminal.input.GetPrompt()
+	path, ok := strings.CutPrefix(prompt, "(pasted text saved to ")
+	if !ok {
+		t.Fatalf("prompt should reference the temp file, got %q", prompt)
+	}
+	path = strings.TrimSuffix(path, ")")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("temp file not readable: %v", err)
+	}
+	if string(data) != large {
+		t.Error("temp file should hold the full pasted text")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/stream"
 )
 
@@ -134,3 +136,106 @@ func TestTerminalResizeUpdatesDisplayContent(t *testing.T) {
 		t.Errorf("Expected window buffer width to be 40, got %d", output.windowBuffer.Width())
 	}
 }
+
+// checkViewFits fails the test when a line of the terminal's view is wider
+// than width.
+func checkViewFits(t *testing.T, terminal *Terminal, width int) string {
+	t.Helper()
+	content := terminal.View().Content
+	for i, line := range strings.Split(content, "\n") {
+		if w := lipgloss.Width(line); w > max(1, width) {
+			t.Errorf("width %d: line %d is %d wide: %q", width, i, w, line)
+		}
+	}
+	return content
+}
+
+// TestTerminalResizeSequence drives the terminal through widths down to
+// none and back, with a status bar, mode line, and confirmation wider than
+// the narrow ones.
+func TestTerminalResizeSequence(t *testing.T) {
+	output := NewTerminalOutput(DefaultStyles())
+	terminal := NewTerminal(nil, output, stream.NewChanInput(10), nil, 80, 24)
+	output.windowBuffer.AppendOrUpdate("window-1", stream.TagTextAssistant, "Some **markdown** with `code` that wraps\n\n```go\nfunc main() {}\n```")
+	output.windowBuffer.AppendOrUpdate("window-2", stream.TagFunctionCall, "posix_shell: ls -la /a/rather/long/path")
+	terminal.statusText = "model: gpt-4o · est. 38k/128k · ~/src/project · $0.02"
+	terminal.modeLine = "[dry-run] [approve] [skill: review]"
+	terminal.cancelConfirmDialog = true
+
+	for _, width := range []int{80, 40, MinWidth, MinWidth - 1, 10, 3, 1, 0, 120, 10, 60} {
+		terminal.Update(tea.WindowSizeMsg{Width: width, Height: 24})
+		content := checkViewFits(t, terminal, width)
+		if narrow := !strings.Contains(content, "╭"); narrow != (width < MinWidth) {
+			t.Errorf("width %d: placeholder shown = %v", width, narrow)
+		}
+		if got := output.windowBuffer.Width(); got != max(MinWidth, width) {
+			t.Errorf("width %d: window buffer width = %d", width, got)
+		}
+	}
+
+	terminal.Update(tea.WindowSizeMsg{Width: 10, Height: 24})
+	if got := stripANSI(terminal.View().Content); !strings.HasPrefix(got, "Terminal\ntoo") {
+		t.Errorf("placeholder = %q", got)
+	}
+
+	// The placeholder keeps to the rows there are
+	terminal.Update(tea.WindowSizeMsg{Width: 1, Height: 2})
+	if lines := strings.Count(terminal.View().Content, "\n") + 1; lines > 2 {
+		t.Errorf("placeholder has %d lines in 2 rows", lines)
+	}
+}
+
+// TestTerminalResizeNarrowOverlays checks the overlays at the narrowest
+// width that shows them.
+func TestTerminalResizeNarrowOverlays(t *testing.T) {
+	open := map[string]func(*Terminal){
+		"models":  func(m *Terminal) { m.modelSelector.Open() },
+		"queue":   func(m *Terminal) { m.queueManager.Open() },
+		"prompts": func(m *Terminal) { m.promptPicker.Open([]string{"first prompt", "second prompt"}) },
+		"review": func(m *Terminal) {
+			m.reviewViewer.Open(agentpkg.ReviewFrame{ID: "R1", Tool: "write_file", Path: "/a/long/path/to/main.go", Diff: "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old line\n+new line"})
+		},
+		"themes": func(m *Terminal) { m.themeSelector.Open([]ThemeInfo{{Name: "dark"}, {Name: "light"}}, "dark") },
+	}
+	for name, openOverlay := range open {
+		t.Run(name, func(t *testing.T) {
+			terminal := NewTerminal(nil, NewTerminalOutput(DefaultStyles()), stream.NewChanInput(10), nil, 80, 24)
+			openOverlay(terminal)
+			for _, height := range []int{24, 3} {
+				terminal.Update(tea.WindowSizeMsg{Width: MinWidth, Height: height})
+				checkViewFits(t, terminal, MinWidth)
+			}
+		})
+	}
+}
+
+// TestTerminalResizeWhileStreaming resizes between deltas of a streaming
+// window: the lines wrapped before the resize are wrapped again, and the
+// later deltas wrap at the new width.
+func TestTerminalResizeWhileStreaming(t *testing.T) {
+	for _, tag := range []string{stream.TagTextAssistant, stream.TagTextReasoning, stream.TagFunctionResult} {
+		t.Run(tag, func(t *testing.T) {
+			output := NewTerminalOutput(DefaultStyles())
+			terminal := NewTerminal(nil, output, stream.NewChanInput(10), nil, 80, 24)
+			terminal.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+			sentence := "a sentence that goes on for a while and keeps going. "
+			for range 6 {
+				output.windowBuffer.AppendOrUpdate("window-1", tag, sentence)
+			}
+			output.windowBuffer.AppendOrUpdate("window-1", tag, "\n\n")
+			terminal.View()
+
+			terminal.Update(tea.WindowSizeMsg{Width: 40, Height: 24})
+			for range 4 {
+				output.windowBuffer.AppendOrUpdate("window-1", tag, sentence)
+				terminal.refreshOutput()
+				checkViewFits(t, terminal, 40)
+			}
+			for i, line := range strings.Split(output.windowBuffer.GetAll(-1), "\n") {
+				if w := lipgloss.Width(line); w > 40 {
+					t.Errorf("window line %d is %d wide: %q", i, w, line)
+				}
+			}
+		})
+	}
+}
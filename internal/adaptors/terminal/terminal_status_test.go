@@ -2,7 +2,10 @@ package terminal
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 )
@@ -107,3 +110,102 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestStatusBarShowsEstimatedTokens(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	data := marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{
+		ContextTokens:   1200,
+		EstimatedTokens: 38210,
+		ContextWindow:   128000,
+	})
+	out.handleSystemTag(string(data))
+
+	if !containsSubstring(out.status, "est. 38k/128k") {
+		t.Errorf("status = %q, want it to contain %q", out.status, "est. 38k/128k")
+	}
+}
+
+func TestStatusBarShowsCost(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	cost := 0.42
+	out.handleSystemTag(string(marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{TotalTokens: 5000, Cost: &cost})))
+	if !containsSubstring(out.status, "| $0.42") {
+		t.Errorf("status = %q, want it to contain the cost", out.status)
+	}
+
+	out.handleSystemTag(string(marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{TotalTokens: 5000})))
+	if !containsSubstring(out.status, "| cost: n/a") {
+		t.Errorf("status = %q, want an unknown cost to read n/a", out.status)
+	}
+}
+
+func TestStatusBarShowsCheckpoint(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	out.handleSystemTag(string(marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{Checkpoint: "plan-a"})))
+	if !containsSubstring(out.status, "| checkpoint: plan-a") || containsSubstring(out.status, "diverged") {
+		t.Errorf("status = %q, want the checkpoint without divergence", out.status)
+	}
+
+	out.handleSystemTag(string(marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{Checkpoint: "plan-a", CheckpointDiverged: true})))
+	if !containsSubstring(out.status, "| checkpoint: plan-a (diverged)") {
+		t.Errorf("status = %q, want the checkpoint marked diverged", out.status)
+	}
+}
+
+func TestFormatProgress(t *testing.T) {
+	if got := formatProgress(agentpkg.ProgressSnapshot{}); got != "" {
+		t.Errorf("idle progress should be empty, got %q", got)
+	}
+	got := formatProgress(agentpkg.ProgressSnapshot{Active: true, Elapsed: 12400 * time.Millisecond})
+	if got != "Processing… 12s" {
+		t.Errorf("got %q", got)
+	}
+	got = formatProgress(agentpkg.ProgressSnapshot{Active: true, Elapsed: 12 * time.Second, TokensPerSecond: 33.6})
+	if got != "Processing… 12s · 34 tok/s" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStatusBarShowsWorkdir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	out := NewTerminalOutput(DefaultStyles())
+	data := marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{
+		Workdir: filepath.Join(home, "src", "app"),
+	})
+	out.handleSystemTag(string(data))
+
+	want := "~" + string(filepath.Separator) + filepath.Join("src", "app")
+	if !strings.HasSuffix(out.status, " | "+want) {
+		t.Errorf("status = %q, want it to end with %q", out.status, want)
+	}
+}
+
+func TestStatusBarShowsDryRun(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	data := marshalSystemInfoForTerminalTest(t, agentpkg.SystemInfo{ContextTokens: 10, SessionState: agentpkg.SessionState{DryRun: true}})
+	out.handleSystemTag(string(data))
+
+	if !strings.HasPrefix(out.status, "DRY RUN | Context: 10") {
+		t.Errorf("status = %q, want it to start with DRY RUN", out.status)
+	}
+}
+
+func TestFormatBadges(t *testing.T) {
+	tests := []struct {
+		state agentpkg.SessionState
+		want  string
+	}{
+		{agentpkg.SessionState{}, ""},
+		{agentpkg.SessionState{DryRun: true}, "[dry-run]"},
+		{agentpkg.SessionState{Attachments: 2}, "[📎 2]"},
+		{agentpkg.SessionState{ReviewEdits: true, ActiveSkill: "pdf-processing"}, "[approve] [skill: pdf-processing]"},
+		{agentpkg.SessionState{DryRun: true, ReviewEdits: true, ActiveSkill: "pdf-processing", Attachments: 2}, "[dry-run] [approve] [skill: pdf-processing] [📎 2]"},
+	}
+	for _, tt := range tests {
+		if got := formatBadges(tt.state); got != tt.want {
+			t.Errorf("formatBadges(%+v) = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package terminal
+
+// Event times: the session sends a TagTime frame before each prompt, tool
+// call, and turn end. The windows keep the time, and show it as a dim
+// "15:04:05" at their start while :time is on.
+
+import (
+	"time"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// timeMarkerFormat is how windows show their time.
+const timeMarkerFormat = "15:04:05"
+
+// takeEventTime returns the time of the frame being handled, sent in the
+// TagTime frame before it, and forgets it; the zero time if none came.
+func (w *outputWriter) takeEventTime() time.Time {
+	at := w.eventTime
+	w.eventTime = time.Time{}
+	return at
+}
+
+// setEventTime records a TagTime value for the frame that follows.
+func (w *outputWriter) setEventTime(value string) {
+	at, err := time.Parse(stream.TimeFormat, value)
+	if err != nil {
+		return
+	}
+	w.eventTime = at
+}
+
+// SetTime records when the window's event happened.
+func (wb *WindowBuffer) SetTime(id string, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	idx, ok := wb.idIndex[id]
+	if !ok {
+		return
+	}
+	w := wb.Windows[idx]
+	w.Time = at
+	w.showTime = wb.showTimes
+	w.Invalidate()
+	wb.markDirty(idx)
+}
+
+// SetShowTimes shows or hides the times of the windows.
+func (wb *WindowBuffer) SetShowTimes(on bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	if wb.showTimes == on {
+		return
+	}
+	wb.showTimes = on
+	for _, w := range wb.Windows {
+		if w.Time.IsZero() {
+			continue
+		}
+		w.showTime = on
+		w.Invalidate()
+	}
+	wb.dirty = true
+	wb.dirtyIndex = dirtyFullRebuild
+}
+
+// timeMarker returns the dim time shown at the start of the window, or ""
+// when it has none or times are hidden.
+func (w *Window) timeMarker(styles *Styles) string {
+	if !w.showTime || w.Time.IsZero() {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(styles.ColorDim).Render(w.Time.Local().Format(timeMarkerFormat)) + " "
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestTimeMarkers(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	at := time.Date(2026, 10, 16, 9, 29, 58, 0, time.Local)
+	sent := at.Format(stream.TimeFormat)
+	out.Write(append(stream.EncodeTLV(stream.TagTime, sent), stream.EncodeTLV(stream.TagTextUser, "#1 ▸ list files")...))
+	out.Write(stream.EncodeTLV(stream.TagTextAssistant, "[:1-1:]Sure."))
+	out.Write(append(stream.EncodeTLV(stream.TagTime, sent), stream.EncodeTLV(stream.TagFunctionCall, `{"id":"call_1","name":"posix_shell","input":"{\"command\":\"ls\"}"}`)...))
+
+	wb := out.windowBuffer
+	if wb.GetWindowCount() != 3 {
+		t.Fatalf("windows = %d, want 3", wb.GetWindowCount())
+	}
+	render := func(i int) string {
+		return stripANSI(wb.RenderWindowContent(wb.GetWindow(i), 76))
+	}
+	for i := range 3 {
+		if got := wb.GetWindow(i).Time; i != 1 && !got.Equal(at) {
+			t.Errorf("window %d time = %v, want %v", i, got, at)
+		}
+		if strings.Contains(render(i), "09:29:58") {
+			t.Errorf("window %d shows its time with :time off:\n%s", i, render(i))
+		}
+	}
+
+	// :time on comes in the system data
+	out.Write(stream.EncodeTLV(stream.TagSystemData, `{"timestamps":true}`))
+	if got := render(0); !strings.Contains(got, "09:29:58 > #1 ▸ list files") {
+		t.Errorf("prompt with :time on:\n%s", got)
+	}
+	if got := render(2); !strings.Contains(got, "09:29:58 ") {
+		t.Errorf("tool call with :time on:\n%s", got)
+	}
+	if got := render(1); strings.Contains(got, "09:29:58") {
+		t.Errorf("assistant text has no time of its own, got:\n%s", got)
+	}
+
+	// Windows added while on show their time at once
+	out.Write(append(stream.EncodeTLV(stream.TagTime, sent), stream.EncodeTLV(stream.TagTextUser, "#2 ▸ again")...))
+	if got := render(3); !strings.Contains(got, "09:29:58 > #2 ▸ again") {
+		t.Errorf("new prompt with :time on:\n%s", got)
+	}
+
+	out.Write(stream.EncodeTLV(stream.TagSystemData, `{"timestamps":false}`))
+	for i := range 4 {
+		if strings.Contains(render(i), "09:29:58") {
+			t.Errorf("window %d shows its time after :time off:\n%s", i, render(i))
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"charm.land/lipgloss/v2"
+
+	"github.com/alayacore/alayacore/internal/tools"
 )
 
 // ============================================================================
@@ -101,8 +103,9 @@ func colorizeMultiLineTool(lines []string, styles *Styles) string {
 	firstLine := lines[0]
 	colonIdx := strings.Index(firstLine, ":")
 
+	var toolName string
 	if colonIdx > 0 {
-		toolName := firstLine[:colonIdx]
+		toolName = firstLine[:colonIdx]
 		restFirst := firstLine[colonIdx:]
 		result.WriteString(styles.Tool.Render(toolName))
 		result.WriteString(styles.ToolContent.Render(restFirst))
@@ -110,8 +113,30 @@ func colorizeMultiLineTool(lines []string, styles *Styles) string {
 		result.WriteString(styles.Tool.Render(firstLine))
 	}
 
+	// posix_shell output is split into stdout:/stderr:/exit: sections
+	shell := toolName == "posix_shell"
+	inStderr := false
 	for _, line := range lines[1:] {
 		result.WriteString("\n")
+		if shell {
+			switch {
+			case line == "stdout:" || line == "output:":
+				inStderr = false
+				result.WriteString(styles.ToolContent.Render(line))
+				continue
+			case line == "stderr:":
+				inStderr = true
+				result.WriteString(styles.ToolContent.Render(line))
+				continue
+			case strings.HasPrefix(line, "exit: "):
+				inStderr = false
+				result.WriteString(styles.ToolContent.Render(line))
+				continue
+			case inStderr:
+				result.WriteString(styles.Error.Render(line))
+				continue
+			}
+		}
 		// Content lines use Text style for readability
 		// Note: Diff coloring is handled by RenderDiffContent for edit_file windows
 		result.WriteString(styles.Text.Render(line))
@@ -159,9 +184,10 @@ func RenderDiffContent(content string, status ToolStatus, styles *Styles) string
 }
 
 // prepareContent normalizes content for rendering by stripping ANSI escape
-// sequences and expanding tabs to spaces (8-space width).
+// sequences, escaping the control characters left (see tools.EscapeControls),
+// and expanding tabs to spaces (8-space width).
 func prepareContent(s string) string {
-	s = stripANSI(s)
+	s = tools.EscapeControls(stripANSI(s))
 	s = expandTabs(s)
 	return s
 }
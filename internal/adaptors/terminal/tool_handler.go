@@ -6,6 +6,7 @@ package terminal
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -20,6 +21,17 @@ type ToolCallData struct {
 type ToolResultData struct {
 	ID     string `json:"id"`
 	Output string `json:"output"`
+	Error  bool   `json:"error,omitempty"`
+	// Continued marks more output for the same ID, after a large result
+	// was split over several frames
+	Continued bool `json:"continued,omitempty"`
+}
+
+// TypedResultData represents a structured tool result (FT tag payload).
+type TypedResultData struct {
+	ID   string          `json:"id"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
 }
 
 // ToolDisplayHandler handles display formatting for a specific tool.
@@ -69,6 +81,45 @@ func (h *PosixShellHandler) ShouldShowOutput() bool {
 	return true
 }
 
+// GitHandler handles git calls, showing the operation and its main argument.
+type GitHandler struct{}
+
+func (h *GitHandler) FormatCall(input json.RawMessage, _ *Styles) string {
+	var args struct {
+		Operation string `json:"operation"`
+		Path      string `json:"path"`
+		Staged    bool   `json:"staged"`
+		Message   string `json:"message"`
+		Branch    string `json:"branch"`
+		Ref       string `json:"ref"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return "git: <parse error>"
+	}
+
+	parts := []string{args.Operation}
+	switch {
+	case args.Operation == "commit":
+		parts = append(parts, strconv.Quote(args.Message))
+	case args.Branch != "":
+		parts = append(parts, args.Branch)
+	case args.Ref != "":
+		parts = append(parts, args.Ref)
+	}
+	if args.Staged {
+		parts = append(parts, "--staged")
+	}
+	if args.Path != "" {
+		parts = append(parts, args.Path)
+	}
+	// Add newline at end so output starts on new line
+	return fmt.Sprintf("git: %s\n", strings.Join(parts, " "))
+}
+
+func (h *GitHandler) ShouldShowOutput() bool {
+	return true
+}
+
 // ReadFileHandler handles read_file calls.
 type ReadFileHandler struct{}
 
@@ -164,6 +215,31 @@ func (h *EditFileHandler) ShouldShowOutput() bool {
 	return false
 }
 
+// ReplaceLinesHandler handles replace_lines calls, showing the range and the
+// new lines.
+type ReplaceLinesHandler struct{}
+
+func (h *ReplaceLinesHandler) FormatCall(input json.RawMessage, _ *Styles) string {
+	var args struct {
+		Path       string `json:"path"`
+		StartLine  string `json:"start_line"`
+		EndLine    string `json:"end_line"`
+		NewContent string `json:"new_content"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return "replace_lines: <parse error>"
+	}
+	header := fmt.Sprintf("replace_lines: %s:%s-%s", args.Path, args.StartLine, args.EndLine)
+	if args.NewContent == "" {
+		return header + " (delete)\n"
+	}
+	return header + "\n" + args.NewContent
+}
+
+func (h *ReplaceLinesHandler) ShouldShowOutput() bool {
+	return false
+}
+
 // ActivateSkillHandler handles activate_skill calls.
 type ActivateSkillHandler struct{}
 
@@ -189,9 +265,11 @@ func (h *ActivateSkillHandler) ShouldShowOutput() bool {
 // ToolHandlers maps tool names to their display handlers.
 var ToolHandlers = map[string]ToolDisplayHandler{
 	"posix_shell":    &PosixShellHandler{},
+	"git":            &GitHandler{},
 	"read_file":      &ReadFileHandler{},
 	"write_file":     &WriteFileHandler{},
 	"edit_file":      &EditFileHandler{},
+	"replace_lines":  &ReplaceLinesHandler{},
 	"activate_skill": &ActivateSkillHandler{},
 }
 
@@ -0,0 +1,35 @@
+package terminal
+
+import "testing"
+
+func TestGitHandlerFormatCall(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"operation":"commit","message":"Fix it\n\nBody"}`, "git: commit \"Fix it\\n\\nBody\"\n"},
+		{`{"operation":"diff","staged":true,"path":"main.go"}`, "git: diff --staged main.go\n"},
+		{`{"operation":"branch_switch","branch":"feature"}`, "git: branch_switch feature\n"},
+		{`{"operation":"status"}`, "git: status\n"},
+	}
+	for _, tt := range tests {
+		if got := GetHandler("git").FormatCall([]byte(tt.input), DefaultStyles()); got != tt.want {
+			t.Errorf("FormatCall(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceLinesHandlerFormatCall(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"path":"main.go","start_line":"3","end_line":"4","new_content":"a\nb\n"}`, "replace_lines: main.go:3-4\na\nb\n"},
+		{`{"path":"main.go","start_line":"3","end_line":"4"}`, "replace_lines: main.go:3-4 (delete)\n"},
+	}
+	for _, tt := range tests {
+		if got := GetHandler("replace_lines").FormatCall([]byte(tt.input), DefaultStyles()); got != tt.want {
+			t.Errorf("FormatCall(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -206,3 +206,24 @@ func TestColorizeTool_WithANSI(t *testing.T) {
 		})
 	}
 }
+
+func TestColorizeToolMarksShellStderr(t *testing.T) {
+	styles := DefaultStyles()
+	got := ColorizeTool("posix_shell: make\nstdout:\nok\nstderr:\nwarning: x\nexit: 0", styles)
+
+	if !strings.Contains(got, styles.Error.Render("warning: x")) {
+		t.Errorf("stderr line should use the error style: %q", got)
+	}
+	if !strings.Contains(got, styles.Text.Render("ok")) {
+		t.Errorf("stdout line should use the text style: %q", got)
+	}
+	if strings.Contains(got, styles.Error.Render("exit: 0")) {
+		t.Errorf("exit line should not use the error style: %q", got)
+	}
+
+	// Other tools are not parsed for sections
+	other := ColorizeTool("read_file: a\nstderr:\nplain", styles)
+	if strings.Contains(other, styles.Error.Render("plain")) {
+		t.Errorf("only posix_shell output has stderr sections: %q", other)
+	}
+}
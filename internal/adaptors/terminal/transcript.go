@@ -0,0 +1,197 @@
+package terminal
+
+// Session transcript.
+// The terminal runs on the alt screen, so the conversation disappears on exit.
+// The transcript tees the decoded TLV stream into a plain-text file as it
+// arrives: one record per prompt, response, tool call, tool result, review
+// decision, notice, and turn end, without ANSI codes. A summary of the session closes it.
+// Records carry ISO 8601 times whether or not :time shows them on screen: the
+// session's own from TagTime where it sends one, the time of arrival otherwise.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// transcript writes session output to a file. Records go straight to the
+// file without buffering, so a crash loses nothing that was displayed.
+type transcript struct {
+	mu      sync.Mutex
+	file    *os.File
+	now     func() time.Time
+	lastTag string    // tag of the delta run being written
+	lastID  string    // stream ID of the delta run being written
+	held    string    // newlines trimmed from a tool result that may continue
+	at      time.Time // from the TagTime frame, for the record after it
+	failed  bool      // a write failed; later records are dropped
+}
+
+// openTranscript creates a new transcript file in dir, named after the start
+// time, e.g. 20261016-093012.txt.
+func openTranscript(dir string, now func() time.Time) (*transcript, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	start := now()
+	base := start.Format("20060102-150405")
+	name := base + ".txt"
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			t := &transcript{file: f, now: now}
+			t.write(fmt.Sprintf("AlayaCore transcript, started %s\n", start.Format(stream.TimeFormat)))
+			return t, nil
+		}
+		// Two sessions started within the same second
+		if !errors.Is(err, os.ErrExist) || i > 100 {
+			return nil, fmt.Errorf("failed to create transcript: %w", err)
+		}
+		name = fmt.Sprintf("%s-%d.txt", base, i)
+	}
+}
+
+// Path returns the transcript file path.
+func (t *transcript) Path() string {
+	return t.file.Name()
+}
+
+// Record appends a decoded TLV message. Streamed text deltas with the same
+// stream ID continue the current record instead of starting a new one.
+func (t *transcript) Record(tag, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch tag {
+	case stream.TagTime:
+		if at, err := time.Parse(stream.TimeFormat, value); err == nil {
+			t.at = at
+		}
+		return
+	case stream.TagTextAssistant, stream.TagTextReasoning:
+		id, content, _ := ParseStreamID(value)
+		if tag != t.lastTag || id != t.lastID {
+			label := "assistant"
+			if tag == stream.TagTextReasoning {
+				label = "reasoning"
+			}
+			t.header(label)
+			t.lastTag, t.lastID = tag, id
+		}
+		t.write(stripANSI(content))
+		return
+	}
+
+	var label, body string
+	switch tag {
+	case stream.TagTextUser:
+		label, body = "user", value
+	case stream.TagFunctionCall:
+		var tc ToolCallData
+		if err := json.Unmarshal([]byte(value), &tc); err != nil {
+			return
+		}
+		label, body = "tool call: "+tc.Name, tc.Input
+	case stream.TagFunctionResult:
+		var tr ToolResultData
+		if err := json.Unmarshal([]byte(value), &tr); err != nil {
+			return
+		}
+		if tr.Continued && t.lastTag == tag && t.lastID == tr.ID {
+			t.writeResult(tr.Output)
+			return
+		}
+		t.header("tool result")
+		t.lastTag, t.lastID = tag, tr.ID
+		t.writeResult(tr.Output)
+		return
+	case stream.TagTurnEnd:
+		label, body = "turn end", "#"+value
+	case stream.TagSystemError:
+		label, body = "error", value
+	case stream.TagSystemNotify, stream.TagSystemLog:
+		label, body = "notice", value
+	case stream.TagPlan:
+		content, ok := formatPlan(value)
+		if !ok {
+			return
+		}
+		label, body = "plan", content
+	case stream.TagReview:
+		var frame agentpkg.ReviewFrame
+		if err := json.Unmarshal([]byte(value), &frame); err != nil {
+			return
+		}
+		if frame.Decision == "" {
+			label, body = fmt.Sprintf("review %s: %s %s", frame.ID, frame.Tool, frame.Subject()), frame.Diff
+		} else {
+			label, body = "review decision", reviewOutcome(frame)
+		}
+	default:
+		// Status updates and tool state changes are not part of the conversation
+		return
+	}
+	t.header(label)
+	t.lastTag, t.lastID = "", ""
+	t.write(strings.TrimRight(stripANSI(body), "\n"))
+}
+
+// writeResult writes a frame of tool output. Its trailing newlines are held
+// back, and written only if another frame of the same result follows.
+func (t *transcript) writeResult(output string) {
+	body := stripANSI(output)
+	trimmed := strings.TrimRight(body, "\n")
+	t.write(t.held + trimmed)
+	t.held = body[len(trimmed):]
+}
+
+// Footer ends the transcript with the session totals, e.g. turns and tool
+// calls.
+func (t *transcript) Footer(summary string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.header("session summary")
+	t.write(summary)
+}
+
+// header starts a new record, stamped with the time of the last TagTime
+// frame if one came since the previous record.
+func (t *transcript) header(label string) {
+	at := t.at
+	if at.IsZero() {
+		at = t.now()
+	}
+	t.at = time.Time{}
+	t.held = ""
+	t.write(fmt.Sprintf("\n\n[%s] %s\n", at.Format(stream.TimeFormat), label))
+}
+
+func (t *transcript) write(s string) {
+	if t.failed || s == "" {
+		return
+	}
+	if _, err := t.file.WriteString(s); err != nil {
+		// Keep the session going; the transcript is best-effort
+		t.failed = true
+	}
+}
+
+// Close syncs and closes the transcript file.
+func (t *transcript) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.write("\n")
+	syncErr := t.file.Sync()
+	if err := t.file.Close(); err != nil {
+		return err
+	}
+	return syncErr
+}
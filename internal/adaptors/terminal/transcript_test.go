@@ -0,0 +1,129 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestTranscriptRecordsConversation(t *testing.T) {
+	dir := t.TempDir()
+	now := func() time.Time { return time.Date(2026, 10, 16, 9, 30, 12, 0, time.UTC) }
+	tr, err := openTranscript(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(tr.Path()); got != "20261016-093012.txt" {
+		t.Errorf("file name = %q", got)
+	}
+
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+	out.SetTranscript(tr)
+
+	write := func(tag, value string) {
+		if err := stream.WriteTLV(out, tag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(stream.TagTextUser, "#1 ▸ list files")
+	write(stream.TagTextAssistant, "[:1-1:]Let me ")
+	write(stream.TagTextAssistant, "[:1-1:]check.")
+	write(stream.TagFunctionCall, `{"id":"call_1","name":"posix_shell","input":"{\"command\":\"ls\"}"}`)
+	write(stream.TagFunctionResult, `{"id":"call_1","output":"\u001b[32mmain.go\u001b[0m\n"}`)
+	write(stream.TagSystemData, `{"in_progress":false}`)
+	write(stream.TagTextAssistant, "[:1-2:]Done.")
+
+	// The file is written as output arrives, before Close
+	data, err := os.ReadFile(tr.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"[2026-10-16T09:30:12.000Z] user\n#1 ▸ list files",
+		"[2026-10-16T09:30:12.000Z] assistant\nLet me check.",
+		"[2026-10-16T09:30:12.000Z] tool call: posix_shell\n{\"command\":\"ls\"}",
+		"[2026-10-16T09:30:12.000Z] tool result\nmain.go",
+		"[2026-10-16T09:30:12.000Z] assistant\nDone.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("transcript is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b") || strings.Contains(got, "in_progress") {
+		t.Errorf("transcript has ANSI codes or status data:\n%s", got)
+	}
+
+	tr.Footer("1 turn, 1 tool call, 2s running prompts, 1m0s wall clock")
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(tr.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[2026-10-16T09:30:12.000Z] session summary\n1 turn, 1 tool call, 2s running prompts, 1m0s wall clock\n"; !strings.HasSuffix(string(data), want) {
+		t.Errorf("transcript does not end with the summary:\n%s", data)
+	}
+}
+
+func TestTranscriptNamesDoNotCollide(t *testing.T) {
+	dir := t.TempDir()
+	now := func() time.Time { return time.Date(2026, 10, 16, 9, 30, 12, 0, time.UTC) }
+	first, err := openTranscript(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := openTranscript(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if first.Path() == second.Path() {
+		t.Errorf("both transcripts use %s", first.Path())
+	}
+}
+
+func TestTranscriptUsesEventTimes(t *testing.T) {
+	now := func() time.Time { return time.Date(2026, 10, 16, 9, 30, 12, 0, time.UTC) }
+	tr, err := openTranscript(t.TempDir(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+	out.SetTranscript(tr)
+
+	// The session sends the time of prompts, tool calls, and turn ends in
+	// a TagTime frame before them; other records get the time of arrival
+	sent := time.Date(2026, 10, 16, 9, 29, 58, 250e6, time.UTC).Format(stream.TimeFormat)
+	out.Write(stream.EncodeTLV(stream.TagTime, sent))
+	out.Write(stream.EncodeTLV(stream.TagTextUser, "#1 ▸ hi"))
+	out.Write(stream.EncodeTLV(stream.TagTextAssistant, "[:1-1:]Hello."))
+	out.Write(append(stream.EncodeTLV(stream.TagTime, sent), stream.EncodeTLV(stream.TagTurnEnd, "1")...))
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(tr.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"AlayaCore transcript, started 2026-10-16T09:30:12.000Z\n",
+		"[2026-10-16T09:29:58.250Z] user\n#1 ▸ hi",
+		"[2026-10-16T09:30:12.000Z] assistant\nHello.",
+		"[2026-10-16T09:29:58.250Z] turn end\n#1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("transcript is missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package terminal
+
+// Startup welcome message showing the runtime configuration and key hints.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// welcomeMessage builds the startup notice from the active model, the app
+// config, and the global key bindings. The display window wraps it to the
+// viewport width.
+func (m *Terminal) welcomeMessage() string {
+	var protocolType, modelName, baseURL string
+	if m.session != nil && m.session.ModelManager != nil {
+		if model := m.session.ModelManager.GetActive(); model != nil {
+			protocolType, modelName, baseURL = model.ProtocolType, model.ModelName, model.BaseURL
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Welcome to AlayaCore\n")
+	sb.WriteString(m.appConfig.RuntimeSummary(protocolType, modelName, baseURL))
+	sb.WriteString("\n\nKeys:")
+	for _, kb := range globalKeyBindings {
+		fmt.Fprintf(&sb, "\n  %-9s %s", kb.Key, kb.Description)
+	}
+	return sb.String()
+}
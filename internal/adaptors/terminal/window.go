@@ -21,6 +21,7 @@ package terminal
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
@@ -36,14 +37,23 @@ import (
 // Window represents a single display window with border and content.
 // Caching is handled internally - callers just call Render().
 type Window struct {
-	ID       string     // stream ID or generated unique ID
-	Tag      string     // TLV tag that created this window
-	ToolName string     // tool name (for FC/FR tags)
-	Content  string     // accumulated content (raw, unstyled)
-	Folded   bool       // true if window is in folded (collapsed) mode
-	Status   ToolStatus // status indicator for tool windows
-	Visible  bool       // true if window should be rendered (tool windows always true; delta windows only when has non-whitespace content)
-	styles   *Styles    // reference to styles for incremental updates
+	ID        string           // stream ID or generated unique ID
+	Tag       string           // TLV tag that created this window
+	ToolName  string           // tool name (for FC/FR tags)
+	Content   string           // accumulated content (raw, unstyled)
+	Folded    bool             // true if window is in folded (collapsed) mode
+	Status    ToolStatus       // status indicator for tool windows
+	Visible   bool             // true if window should be rendered (tool windows always true; delta windows only when has non-whitespace content)
+	styles    *Styles          // reference to styles for incremental updates
+	highlight bool             // highlight closed fenced code blocks (assistant text only)
+	render    bool             // render markdown (assistant text only)
+	fences    fenceTracker     // fence state of streamed assistant text
+	paras     paragraphTracker // end of the last finished paragraph of assistant text
+	collapse  bool             // show folded reasoning as a one-line summary (see reasoning.go)
+	thinking  bool             // reasoning still streaming
+	deltas    int              // reasoning deltas received, to turn the spinner
+	Time      time.Time        // when the prompt or tool call happened, from TagTime (see timestamps.go)
+	showTime  bool             // show Time at the start of the window (:time)
 
 	// Internal cache - updated on render, invalidated on content change
 	cache windowCache
@@ -59,6 +69,14 @@ type windowCache struct {
 	inner        string   // inner content (for cursor border swap)
 	lineCount    int      // number of lines in rendered output
 	wrappedLines []string // wrapped lines for incremental update
+	framed       []string // wrappedLines with their side borders, all but the last few
+	edges        []string // top and bottom border lines of framed
+
+	// Rendered markdown: the wrapped lines of the finished paragraphs,
+	// Content[:headLen], which only the text after them is rendered on top of
+	headValid bool
+	headLen   int
+	headLines []string
 }
 
 // IsDiffWindow returns true if the window is a diff window
@@ -119,19 +137,25 @@ func (w *Window) rebuildCache(width int, styles *Styles, borderStyle lipgloss.St
 	// Render content based on window type
 	var inner string
 	switch {
+	case w.collapsesReasoning():
+		inner = w.reasoningSummary(styles)
 	case w.IsDiffWindow():
-		inner = RenderDiffContent(w.Content, w.Status, styles)
+		inner = w.timeMarker(styles) + RenderDiffContent(w.Content, w.Status, styles)
 	default:
 		inner = w.renderGenericContent(innerWidth, styles)
 	}
 
 	// Apply folding if needed
-	if w.Folded {
+	if w.Folded && !w.collapsesReasoning() {
 		inner = w.applyFolding(inner, innerWidth, styles)
 	}
 
 	// Update cache
-	w.cache.rendered = borderStyle.Width(width).Render(inner)
+	if w.framesIncrementally() {
+		w.cache.rendered = w.frameLines(width, borderStyle)
+	} else {
+		w.cache.rendered = borderStyle.Width(width).Render(inner)
+	}
 	w.cache.inner = inner
 	w.cache.width = width
 	w.cache.folded = w.Folded
@@ -140,10 +164,48 @@ func (w *Window) rebuildCache(width int, styles *Styles, borderStyle lipgloss.St
 	w.cache.valid = true
 }
 
+// framesIncrementally reports whether the window's border can be drawn
+// around its cached wrapped lines, adding only the lines that changed.
+func (w *Window) framesIncrementally() bool {
+	return len(w.cache.wrappedLines) > 0 && !w.Folded && !w.collapsesReasoning() && !w.IsDiffWindow() && !w.rendersMarkdown()
+}
+
+// frameLines draws the border around the wrapped lines. Each line gets its
+// sides on its own, so the lines framed before are kept and only the new
+// ones are framed: a long streaming window costs the size of each delta,
+// not of its whole content.
+func (w *Window) frameLines(width int, borderStyle lipgloss.Style) string {
+	lines := w.cache.wrappedLines
+	if len(w.cache.framed) > len(lines) {
+		w.cache.framed = nil
+	}
+	if len(w.cache.framed) == 0 {
+		edges := strings.Split(borderStyle.Width(width).Render(""), "\n")
+		w.cache.edges = []string{edges[0], edges[len(edges)-1]}
+	}
+	if rest := lines[len(w.cache.framed):]; len(rest) > 0 {
+		sides := borderStyle.BorderTop(false).BorderBottom(false).Width(width)
+		w.cache.framed = append(w.cache.framed, strings.Split(sides.Render(strings.Join(rest, "\n")), "\n")...)
+	}
+	var sb strings.Builder
+	sb.WriteString(w.cache.edges[0])
+	for _, line := range w.cache.framed {
+		sb.WriteByte('\n')
+		sb.WriteString(line)
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(w.cache.edges[1])
+	return sb.String()
+}
+
 // renderGenericContent renders a generic tool window content
 func (w *Window) renderGenericContent(innerWidth int, styles *Styles) string {
 	innerWidth = max(0, innerWidth)
 
+	if w.rendersMarkdown() && innerWidth > 0 {
+		return w.renderMarkdownContent(innerWidth, styles)
+	}
+
 	// FAST PATH: Use cached wrapped lines if width matches
 	// This avoids re-styling and re-wrapping the entire content
 	if len(w.cache.wrappedLines) > 0 && w.cache.width-4 == innerWidth && innerWidth > 0 {
@@ -158,18 +220,43 @@ func (w *Window) renderGenericContent(innerWidth int, styles *Styles) string {
 	content = prepareContent(content)
 
 	// Apply styling based on tag
-	content = w.styleContent(content, styles)
+	content = w.timeMarker(styles) + w.styleContent(content, styles)
 
 	// Wrap content
 	if innerWidth <= 0 {
 		return content
 	}
 
-	wrapped := lipgloss.Wrap(content, innerWidth, " ")
+	wrapped := wrapText(content, innerWidth)
 	w.cache.wrappedLines = strings.Split(wrapped, "\n")
+	w.cache.framed = nil
 	return wrapped
 }
 
+// renderMarkdownContent renders assistant markdown, re-rendering only the
+// text after the last finished paragraph (see markdown.go).
+func (w *Window) renderMarkdownContent(innerWidth int, styles *Styles) string {
+	end := w.paras.end
+	if !w.cache.headValid || w.cache.headLen != end {
+		w.cache.headLines = nil
+		if end > 0 {
+			w.cache.headLines = w.wrapMarkdown(strings.TrimSuffix(w.Content[:end], "\n"), innerWidth, styles)
+		}
+		w.cache.headValid, w.cache.headLen = true, end
+	}
+	tail := w.wrapMarkdown(w.Content[end:], innerWidth, styles)
+	lines := make([]string, 0, len(w.cache.headLines)+len(tail))
+	lines = append(lines, w.cache.headLines...)
+	lines = append(lines, tail...)
+	return strings.Join(lines, "\n")
+}
+
+// wrapMarkdown renders and wraps a piece of assistant markdown.
+func (w *Window) wrapMarkdown(content string, innerWidth int, styles *Styles) []string {
+	rendered := renderFormatted(prepareContent(content), innerWidth, w.highlight, styles)
+	return strings.Split(wrapText(rendered, innerWidth), "\n")
+}
+
 // styleMultiline applies a style to each line of text
 func styleMultiline(content string, style lipgloss.Style) string {
 	lines := strings.Split(content, "\n")
@@ -197,18 +284,33 @@ func (w *Window) applyFolding(content string, innerWidth int, styles *Styles) st
 func (w *Window) Invalidate() {
 	w.cache.valid = false
 	w.cache.wrappedLines = nil
+	w.cache.framed = nil
+	w.cache.headValid = false
+	w.cache.headLines = nil
 }
 
 // AppendContent adds content incrementally, updating wrapped lines if possible
 func (w *Window) AppendContent(delta string, innerWidth int) {
 	w.Content += delta
+	if w.thinking {
+		w.deltas++
+	}
+
+	// A code block that just closed must be re-rendered to highlight it
+	blockClosed := w.highlightsCode() && w.fences.feed(delta)
+	if w.Tag == stream.TagTextAssistant {
+		w.paras.feed(delta)
+	}
 
 	// Try incremental update if we have cached wrapped lines and styles
-	// Skip incremental updates for diff windows as they need special rendering
-	if len(w.cache.wrappedLines) > 0 && innerWidth > 0 && w.styles != nil && !w.IsDiffWindow() {
+	// Skip incremental updates for diff windows as they need special rendering,
+	// and for rendered markdown, which caches its finished paragraphs instead
+	if len(w.cache.wrappedLines) > 0 && innerWidth > 0 && w.styles != nil && !w.IsDiffWindow() && !blockClosed && !w.rendersMarkdown() {
 		// Prepare delta before styling (strip input ANSI, expand tabs)
 		preparedDelta := prepareContent(delta)
 		styledDelta := w.styleContent(preparedDelta, w.styles)
+		// The last line may change; the lines before it stay framed
+		w.cache.framed = w.cache.framed[:min(len(w.cache.framed), len(w.cache.wrappedLines)-1)]
 		w.cache.wrappedLines = appendDeltaToLines(w.cache.wrappedLines, styledDelta, innerWidth)
 		// Mark cache as needing rebuild for rendered output, but wrappedLines is updated
 		// The rebuild will use cached wrappedLines instead of re-wrapping
@@ -217,6 +319,7 @@ func (w *Window) AppendContent(delta string, innerWidth int) {
 		// Can't do incremental - need full rebuild
 		w.cache.valid = false
 		w.cache.wrappedLines = nil
+		w.cache.framed = nil
 	}
 }
 
@@ -233,6 +336,9 @@ func (w *Window) styleContent(content string, styles *Styles) string {
 	case stream.TagFunctionResult:
 		return styleMultiline(content, styles.Text)
 	case stream.TagTextAssistant:
+		if w.highlightsCode() {
+			return renderMarkdown(content, styles)
+		}
 		return styleMultiline(content, styles.Text)
 	case stream.TagTextReasoning:
 		return styleMultiline(content, styles.Reasoning)
@@ -242,11 +348,51 @@ func (w *Window) styleContent(content string, styles *Styles) string {
 		return styleMultiline(content, styles.Error)
 	case stream.TagSystemNotify:
 		return styleMultiline(content, styles.System)
+	case stream.TagSystemLog:
+		return styleMultiline(content, styles.Reasoning)
+	case stream.TagPlan:
+		return stylePlan(content, styles)
+	case stream.TagReview:
+		return styleReview(content, styles)
 	default:
 		return content
 	}
 }
 
+// stylePlan dims the title and the finished items of a plan window.
+func stylePlan(content string, styles *Styles) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if i == 0 || strings.HasPrefix(line, "[x]") {
+			lines[i] = styles.System.Render(line)
+		} else {
+			lines[i] = styles.Text.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// styleReview colors the diff of a change awaiting review below its title.
+func styleReview(content string, styles *Styles) string {
+	lines := strings.Split(content, "\n")
+	lines[0] = styles.System.Render(lines[0])
+	for i := 1; i < len(lines); i++ {
+		lines[i] = styleDiffLine(lines[i], i <= 2, styles)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightsCode reports whether fenced code blocks in this window are
+// syntax highlighted.
+func (w *Window) highlightsCode() bool {
+	return w.highlight && w.Tag == stream.TagTextAssistant
+}
+
+// rendersMarkdown reports whether this window's markdown is rendered.
+func (w *Window) rendersMarkdown() bool {
+	return w.render && w.Tag == stream.TagTextAssistant
+}
+
 // LineCount returns the cached line count (valid after Render())
 func (w *Window) LineCount() int {
 	return w.cache.lineCount
@@ -258,13 +404,17 @@ func (w *Window) LineCount() int {
 
 // WindowBuffer holds a sequence of windows with virtual rendering support.
 type WindowBuffer struct {
-	mu          sync.Mutex
-	Windows     []*Window // public for tests
-	idIndex     map[string]int
-	width       int
-	styles      *Styles
-	borderStyle lipgloss.Style
-	cursorStyle lipgloss.Style
+	mu            sync.Mutex
+	Windows       []*Window // public for tests
+	idIndex       map[string]int
+	width         int
+	styles        *Styles
+	highlight     bool // highlight fenced code blocks in assistant text
+	render        bool // render markdown in assistant text
+	showReasoning bool // fold reasoning like tool output instead of summarizing it (--show-reasoning)
+	showTimes     bool // show the times of prompts and tool calls (:time)
+	borderStyle   lipgloss.Style
+	cursorStyle   lipgloss.Style
 
 	// Line height tracking (for cursor navigation)
 	lineHeights []int
@@ -290,6 +440,7 @@ func NewWindowBuffer(width int, styles *Styles) *WindowBuffer {
 		idIndex:     make(map[string]int),
 		width:       width,
 		styles:      styles,
+		highlight:   true,
 		borderStyle: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(styles.ColorDim).Padding(0, 1),
 		cursorStyle: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(styles.BorderCursor).Padding(0, 1),
 		lineHeights: []int{},
@@ -319,6 +470,48 @@ func (wb *WindowBuffer) Width() int {
 	return wb.width
 }
 
+// SetHighlight enables or disables syntax highlighting of fenced code blocks
+// in assistant text (disabled by --no-highlight).
+func (wb *WindowBuffer) SetHighlight(enabled bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if wb.highlight == enabled {
+		return
+	}
+	wb.highlight = enabled
+	for _, w := range wb.Windows {
+		w.highlight = enabled
+		w.Invalidate()
+	}
+	wb.dirty = true
+	wb.dirtyIndex = dirtyFullRebuild
+}
+
+// SetRender enables or disables markdown rendering of assistant text
+// (:render, --render). Windows already shown are re-rendered from their raw
+// content, so toggling never changes what was received.
+func (wb *WindowBuffer) SetRender(enabled bool) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if wb.render == enabled {
+		return
+	}
+	wb.render = enabled
+	for _, w := range wb.Windows {
+		w.render = enabled
+		w.Invalidate()
+	}
+	wb.dirty = true
+	wb.dirtyIndex = dirtyFullRebuild
+}
+
+// Render reports whether markdown rendering is enabled.
+func (wb *WindowBuffer) Render() bool {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	return wb.render
+}
+
 // SetStyles updates the styles for the window buffer.
 func (wb *WindowBuffer) SetStyles(styles *Styles) {
 	wb.mu.Lock()
@@ -356,14 +549,27 @@ func (wb *WindowBuffer) AppendOrUpdate(id string, tag string, content string) {
 	}
 
 	// Create new window
+	wb.endThinking()
 	folded := tag != stream.TagTextUser && tag != stream.TagTextAssistant
 	w := &Window{
-		ID:      id,
-		Tag:     tag,
-		Content: content,
-		Folded:  folded,
-		Visible: true, // Will be updated below for delta windows
-		styles:  wb.styles,
+		ID:        id,
+		Tag:       tag,
+		Content:   content,
+		Folded:    folded,
+		Visible:   true, // Will be updated below for delta windows
+		styles:    wb.styles,
+		highlight: wb.highlight,
+		render:    wb.render,
+	}
+	if tag == stream.TagTextReasoning {
+		w.collapse = !wb.showReasoning
+		w.thinking = true
+	}
+	if w.highlightsCode() {
+		w.fences.feed(content)
+	}
+	if tag == stream.TagTextAssistant {
+		w.paras.feed(content)
 	}
 	// Tool windows are always visible; delta windows only when has visible content
 	if !w.IsToolWindow() {
@@ -386,6 +592,7 @@ func (wb *WindowBuffer) AppendToolCall(id string, toolName string, content strin
 		return
 	}
 
+	wb.endThinking()
 	w := &Window{
 		ID:       id,
 		Tag:      stream.TagFunctionCall,
@@ -492,6 +699,38 @@ func (wb *WindowBuffer) ToggleFold(windowIndex int) bool {
 	return true
 }
 
+// Unfold expands a folded window. Returns true if the fold state changed.
+func (wb *WindowBuffer) Unfold(windowIndex int) bool {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	if windowIndex < 0 || windowIndex >= len(wb.Windows) || !wb.Windows[windowIndex].Folded {
+		return false
+	}
+	wb.Windows[windowIndex].Folded = false
+	wb.markDirty(windowIndex)
+	return true
+}
+
+// Search returns the indices of visible windows whose content contains query,
+// ignoring case. Matching is done on the uncolored text.
+func (wb *WindowBuffer) Search(query string) []int {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	query = strings.ToLower(query)
+	if query == "" {
+		return nil
+	}
+	var matches []int
+	for i, w := range wb.Windows {
+		if w.Visible && strings.Contains(strings.ToLower(stripANSI(w.Content)), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
 // GetWindowContent returns the raw content of a window by index.
 // Returns empty string if index is out of bounds.
 func (wb *WindowBuffer) GetWindowContent(windowIndex int) string {
@@ -686,6 +925,7 @@ func (wb *WindowBuffer) renderVirtual(cursorIndex int) string {
 
 	var sb strings.Builder
 	firstWritten := false
+	line := 0
 	for i := range wb.Windows {
 		// Skip non-visible windows entirely
 		if !wb.Windows[i].Visible {
@@ -696,23 +936,55 @@ func (wb *WindowBuffer) renderVirtual(cursorIndex int) string {
 			sb.WriteString("\n")
 		}
 
+		height := wb.lineHeights[i]
 		if i >= startWindow && i <= endWindow {
 			// Render actual content
-			sb.WriteString(wb.Windows[i].Render(wb.width, cursorIndex == i, wb.styles, wb.borderStyle, wb.cursorStyle))
-		} else {
-			// Render placeholder (blank lines)
-			for j := 0; j < wb.lineHeights[i]; j++ {
-				if j > 0 {
-					sb.WriteString("\n")
-				}
-				sb.WriteString(" ")
+			rendered := wb.Windows[i].Render(wb.width, cursorIndex == i, wb.styles, wb.borderStyle, wb.cursorStyle)
+			if line >= startLine && line+height <= endLine {
+				sb.WriteString(rendered)
+			} else {
+				writeClipped(&sb, rendered, startLine-line, endLine-line)
 			}
+		} else {
+			writePlaceholder(&sb, height)
 		}
+		line += height
 		firstWritten = true
 	}
 	return sb.String()
 }
 
+// writeClipped writes the lines from to to of a rendered window and a
+// placeholder for the others, so a long window streaming at the bottom
+// does not hand the viewport thousands of lines to measure.
+func writeClipped(sb *strings.Builder, rendered string, from, to int) {
+	for j := 0; rendered != ""; j++ {
+		line, rest, found := strings.Cut(rendered, "\n")
+		if j > 0 {
+			sb.WriteString("\n")
+		}
+		if j >= from && j < to {
+			sb.WriteString(line)
+		} else {
+			sb.WriteString(" ")
+		}
+		if !found {
+			break
+		}
+		rendered = rest
+	}
+}
+
+// writePlaceholder writes height blank lines for a window out of view.
+func writePlaceholder(sb *strings.Builder, height int) {
+	for j := range height {
+		if j > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(" ")
+	}
+}
+
 // renderAll renders all visible windows
 func (wb *WindowBuffer) renderAll(cursorIndex int) string {
 	var sb strings.Builder
@@ -749,60 +1021,6 @@ func (wb *WindowBuffer) RenderWindowContent(w *Window, innerWidth int) string {
 	return w.renderGenericContent(innerWidth, wb.styles)
 }
 
-// ============================================================================
-// Line Wrapping Utilities
-// ============================================================================
-
-// wrapLines wraps content into lines at the given width.
-func wrapLines(content string, width int) []string {
-	if width <= 0 {
-		return []string{content}
-	}
-	wrapped := lipgloss.Wrap(content, width, " ")
-	return strings.Split(wrapped, "\n")
-}
-
-// appendDeltaToLines incrementally wraps a delta onto existing lines.
-func appendDeltaToLines(lines []string, delta string, width int) []string {
-	if len(lines) == 0 {
-		return wrapLines(delta, width)
-	}
-	if width <= 0 {
-		lines[len(lines)-1] += delta
-		return lines
-	}
-
-	if strings.Contains(delta, "\n") {
-		return appendDeltaWithNewlines(lines, delta, width)
-	}
-
-	// Append to last line and rewrap
-	lastLine := lines[len(lines)-1]
-	combined := lastLine + delta
-	newLines := wrapLines(combined, width)
-	return append(lines[:len(lines)-1], newLines...)
-}
-
-// appendDeltaWithNewlines handles delta that contains newlines.
-func appendDeltaWithNewlines(lines []string, delta string, width int) []string {
-	parts := strings.Split(delta, "\n")
-	for i, part := range parts {
-		if i == 0 {
-			if len(lines) == 0 {
-				lines = wrapLines(part, width)
-			} else {
-				lastIdx := len(lines) - 1
-				combined := lines[lastIdx] + part
-				newLines := wrapLines(combined, width)
-				lines = append(lines[:lastIdx], newLines...)
-			}
-		} else {
-			lines = append(lines, wrapLines(part, width)...)
-		}
-	}
-	return lines
-}
-
 // ============================================================================
 // DisplayModel - Viewport over WindowBuffer
 // ============================================================================
@@ -1066,6 +1284,12 @@ func (m *DisplayModel) MarkUserScrolled() {
 	m.userMovedCursorAway = true
 }
 
+// ResumeFollow makes the viewport follow new content again after the user
+// scrolled back to the bottom.
+func (m *DisplayModel) ResumeFollow() {
+	m.userMovedCursorAway = false
+}
+
 // MoveWindowCursorToTop moves cursor to top visible window
 func (m *DisplayModel) MoveWindowCursorToTop() bool {
 	windowCount := m.windowBuffer.GetWindowCount()
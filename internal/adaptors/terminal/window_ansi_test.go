@@ -171,3 +171,48 @@ func TestWindow_DiffContentWithANSI(t *testing.T) {
 		t.Errorf("DiffContent:\n  got:  %q\n  want: %q", resultStripped, expected)
 	}
 }
+
+// TestToolCallEscapesAreShown verifies that escape sequences the model puts
+// in a tool call are shown, not obeyed, so it cannot retitle the terminal or
+// move the cursor to hide what it ran.
+func TestToolCallEscapesAreShown(t *testing.T) {
+	out := NewTerminalOutput(DefaultStyles())
+	defer out.Close()
+
+	write := func(tag, value string) {
+		if err := stream.WriteTLV(out, tag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(stream.TagFunctionCall, `{"id":"sh","name":"posix_shell","input":"{\"command\":\"rm -rf ~ \\u001b]0;ls\\u0007\\u001b[1A\\u001b[2Kls\"}"}`)
+	write(stream.TagFunctionCall, `{"id":"rd","name":"read_file","input":"{\"path\":\"notes\\u001b[2J\\u001b[H.txt\"}"}`)
+	write(stream.TagFunctionCall, `{"id":"sk","name":"activate_skill","input":"{\"name\":\"pdf\\u009b2J\"}"}`)
+	write(stream.TagTextAssistant, "[:a1:]Done.\x07\x1b]2;owned\x1b\\")
+
+	want := map[string]string{
+		"sh": "posix_shell: rm -rf ~ ␛]0;ls␇␛[1A␛[2Kls",
+		"rd": "read_file: notes␛[2J␛[H.txt",
+		"sk": `activate_skill: pdf\u009b2J`,
+		"a1": "Done.␇",
+	}
+	styles := DefaultStyles()
+	for _, w := range out.windowBuffer.Windows {
+		expected, ok := want[w.ID]
+		if !ok {
+			continue
+		}
+		delete(want, w.ID)
+		rendered := w.renderGenericContent(80, styles)
+		if !strings.Contains(stripANSI(rendered), expected) {
+			t.Errorf("window %s shows %q, want %q", w.ID, stripANSI(rendered), expected)
+		}
+		for _, seq := range []string{"\x1b]", "\x1b[1A", "\x1b[2K", "\x1b[2J", "\x1b[H", "\x07", "\u009b"} {
+			if strings.Contains(rendered, seq) {
+				t.Errorf("window %s renders %q", w.ID, seq)
+			}
+		}
+	}
+	for id := range want {
+		t.Errorf("no window %s", id)
+	}
+}
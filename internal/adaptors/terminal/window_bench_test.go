@@ -917,3 +917,31 @@ func BenchmarkFullWrappingPath(b *testing.B) {
 		_ = wb.GetTotalLinesVirtual()
 	}
 }
+
+// BenchmarkStreamingInto1MBTranscript measures one refresh while a long
+// answer streams below a 1 MB transcript: append a delta, then update the
+// viewport as the Terminal does for each updateMsg. Only the new lines of
+// the streaming window are framed, and lines out of view are not handed to
+// the viewport, so the cost follows the delta and the screen rather than
+// the transcript.
+func BenchmarkStreamingInto1MBTranscript(b *testing.B) {
+	styles := NewStyles(DefaultTheme())
+	wb := NewWindowBuffer(100, styles)
+	paragraph := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20) + "\n"
+	for i, size := 0, 0; size < 1<<20; i++ {
+		content := strings.Repeat(paragraph, 10)
+		wb.AppendOrUpdate(fmt.Sprintf("%d-1-t", i), "TA", content)
+		size += len(content)
+	}
+	dm := NewDisplayModel(wb, styles)
+	dm.SetHeight(40)
+	dm.SetWidth(100)
+	wb.AppendOrUpdate("stream", "TA", strings.Repeat(paragraph, 100))
+	dm.updateContent()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wb.AppendOrUpdate("stream", "TA", "word ")
+		dm.updateContent()
+	}
+}
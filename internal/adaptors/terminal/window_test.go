@@ -1,6 +1,8 @@
 package terminal
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -127,6 +129,46 @@ func TestWindowBufferViewport(t *testing.T) {
 	})
 }
 
+func TestIncrementalFrameMatchesFullFrame(t *testing.T) {
+	styles := DefaultStyles()
+	for _, tag := range []string{stream.TagTextAssistant, stream.TagTextUser, stream.TagSystemNotify} {
+		wb := NewWindowBuffer(60, styles)
+		wb.AppendOrUpdate("w", tag, "hello **world**\n\n  indented\tx\n"+strings.Repeat("long words here ", 20))
+		wb.GetTotalLinesVirtual()
+		wb.AppendOrUpdate("w", tag, " more\nand\n\n")
+		wb.GetTotalLinesVirtual()
+		wb.AppendOrUpdate("w", tag, "日本語のテキスト "+strings.Repeat("x", 70))
+
+		w := wb.Windows[0]
+		w.Folded = false
+		got := w.Render(60, false, styles, wb.borderStyle, wb.cursorStyle)
+		want := wb.borderStyle.Width(60).Render(strings.Join(w.cache.wrappedLines, "\n"))
+		if got != want {
+			t.Errorf("%s: incremental frame differs from framing all lines at once\ngot:\n%s\nwant:\n%s", tag, got, want)
+		}
+	}
+}
+
+func TestVirtualRenderClipsLinesOutOfView(t *testing.T) {
+	wb := NewWindowBuffer(40, DefaultStyles())
+	for i := range 100 {
+		wb.AppendOrUpdate("w", stream.TagTextAssistant, fmt.Sprintf("line %d\n", i))
+	}
+	total := wb.GetTotalLinesVirtual()
+	wb.SetViewportPosition(total-10, 10)
+
+	lines := strings.Split(stripANSI(wb.GetAll(-1)), "\n")
+	if len(lines) != total {
+		t.Fatalf("got %d lines, want %d to keep scroll positions", len(lines), total)
+	}
+	if strings.TrimSpace(lines[0]) != "" || strings.Contains(lines[0], "╭") {
+		t.Errorf("a line far above the view should be blank, got %q", lines[0])
+	}
+	if !strings.Contains(lines[total-3], "line 99") {
+		t.Errorf("the last line in view should be shown, got %q", lines[total-3])
+	}
+}
+
 func TestWindowBufferDiff(t *testing.T) {
 	t.Run("append diff content", func(t *testing.T) {
 		wb := NewWindowBuffer(80, DefaultStyles())
@@ -481,3 +523,32 @@ func TestWindowBufferVisibility(t *testing.T) {
 		}
 	})
 }
+
+// TestTrailingSpacesArePreserved guards the spaces that end a line of code or
+// make a markdown hard line break: styling and wrapping must not trim them,
+// whether the content arrives at once or in deltas.
+func TestTrailingSpacesArePreserved(t *testing.T) {
+	content := "```go\nx := 1  \n```\nline one  \nline two"
+	wantLines := []string{"x := 1  ", "line one  "}
+	for _, render := range []bool{false, true} {
+		for _, deltas := range [][]string{{content}, {"```go\nx := 1 ", " \n```\nline one ", " ", "\nline two"}} {
+			wb := NewWindowBuffer(60, DefaultStyles())
+			wb.SetRender(render)
+			for _, delta := range deltas {
+				wb.AppendOrUpdate("w", stream.TagTextAssistant, delta)
+				wb.GetTotalLinesVirtual()
+			}
+
+			w := wb.Windows[0]
+			if w.Content != content {
+				t.Errorf("render=%v, %d deltas: content = %q, want %q", render, len(deltas), w.Content, content)
+			}
+			lines := strings.Split(stripANSI(w.renderGenericContent(56, wb.styles)), "\n")
+			for _, want := range wantLines {
+				if !slices.Contains(lines, want) {
+					t.Errorf("render=%v, %d deltas: no line %q in %q", render, len(deltas), want, lines)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package terminal
+
+// Line wrapping.
+// Every window, and the too-narrow notice, wraps through wrapText. Text that
+// is all ASCII goes to lipgloss.Wrap. lipgloss.Wrap measures other text by
+// grapheme cluster, but still takes ASCII bytes one at a time, so it can cut
+// a long word between an ASCII letter and the combining accent or keycap
+// mark that belongs to it. breakLines, which wraps the rest, takes every
+// character with its marks as one cluster, so a cut always falls between
+// clusters and a line never grows past the width. lipgloss.WrapWriter then
+// carries the style and link open at each line break onto the next line.
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// wrapText wraps s at width columns without splitting a grapheme cluster.
+// A width of 0 or less leaves s as it is.
+func wrapText(s string, width int) string {
+	if width <= 0 || isASCII(s) {
+		return lipgloss.Wrap(s, width, " ")
+	}
+	var sb strings.Builder
+	w := lipgloss.NewWrapWriter(&sb)
+	_, _ = io.WriteString(w, breakLines(s, width)) //nolint:errcheck // a strings.Builder never fails
+	_ = w.Close()                                  //nolint:errcheck // it only writes the closing codes
+	return sb.String()
+}
+
+// wrapLines wraps content into lines at the given width.
+func wrapLines(content string, width int) []string {
+	if width <= 0 {
+		return []string{content}
+	}
+	return strings.Split(wrapText(content, width), "\n")
+}
+
+// appendDeltaToLines incrementally wraps a delta onto existing lines.
+func appendDeltaToLines(lines []string, delta string, width int) []string {
+	if len(lines) == 0 {
+		return wrapLines(delta, width)
+	}
+	if width <= 0 {
+		lines[len(lines)-1] += delta
+		return lines
+	}
+
+	if strings.Contains(delta, "\n") {
+		return appendDeltaWithNewlines(lines, delta, width)
+	}
+
+	// Append to last line and rewrap
+	lastLine := lines[len(lines)-1]
+	combined := lastLine + delta
+	newLines := wrapLines(combined, width)
+	return append(lines[:len(lines)-1], newLines...)
+}
+
+// appendDeltaWithNewlines handles delta that contains newlines.
+func appendDeltaWithNewlines(lines []string, delta string, width int) []string {
+	parts := strings.Split(delta, "\n")
+	for i, part := range parts {
+		if i == 0 {
+			if len(lines) == 0 {
+				lines = wrapLines(part, width)
+			} else {
+				lastIdx := len(lines) - 1
+				combined := lines[lastIdx] + part
+				newLines := wrapLines(combined, width)
+				lines = append(lines[:lastIdx], newLines...)
+			}
+		} else {
+			lines = append(lines, wrapLines(part, width)...)
+		}
+	}
+	return lines
+}
+
+// breakLines puts line breaks into s so that no line is wider than limit,
+// as ansi.Wrap does: words move whole to the next line at spaces and after
+// hyphens, and a word wider than limit is cut where the line is full. Unlike
+// ansi.Wrap, it measures ASCII characters as grapheme clusters too, so a cut
+// never separates a character from its marks. ANSI codes stay whole.
+func breakLines(s string, limit int) string {
+	var (
+		buf, word, space strings.Builder
+		spaceWidth       int // width of space
+		curWidth         int // width written on the line
+		wordWidth        int // width of word
+	)
+	addSpace := func() {
+		curWidth += spaceWidth
+		buf.WriteString(space.String())
+		space.Reset()
+		spaceWidth = 0
+	}
+	addWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		addSpace()
+		curWidth += wordWidth
+		buf.WriteString(word.String())
+		word.Reset()
+		wordWidth = 0
+	}
+	addNewline := func() {
+		buf.WriteByte('\n')
+		curWidth = 0
+		space.Reset()
+		spaceWidth = 0
+	}
+	// endLine keeps the spaces at the end of a line that fit on it
+	endLine := func() {
+		if wordWidth == 0 {
+			if curWidth+spaceWidth <= limit {
+				buf.WriteString(space.String())
+			}
+			space.Reset()
+			spaceWidth = 0
+		}
+		addWord()
+	}
+
+	for s != "" {
+		if c := s[0]; c < ' ' || c == 0x7f || (c >= 0x80 && c < 0xc0) {
+			// A control character, or the start of an ANSI code
+			seq, _, n, _ := ansi.DecodeSequence(s, ansi.NormalState, nil)
+			s = s[n:]
+			switch {
+			case seq == "\n":
+				endLine()
+				addNewline()
+			case len(seq) == 1 && unicode.IsSpace(rune(seq[0])):
+				addWord()
+				space.WriteString(seq)
+				spaceWidth++
+			default:
+				word.WriteString(seq)
+			}
+			continue
+		}
+
+		cluster, width := ansi.FirstGraphemeCluster(s, ansi.GraphemeWidth)
+		s = s[len(cluster):]
+		switch r, _ := utf8.DecodeRuneInString(cluster); {
+		case unicode.IsSpace(r) && r != '\u00a0':
+			addWord()
+			space.WriteString(cluster)
+			spaceWidth += width
+		case r == '-':
+			addSpace()
+			if curWidth+wordWidth+width > limit {
+				word.WriteString(cluster)
+				wordWidth += width
+			} else {
+				addWord()
+				buf.WriteString(cluster)
+				curWidth += width
+			}
+		default:
+			if wordWidth+width > limit {
+				addWord()
+			}
+			word.WriteString(cluster)
+			wordWidth += width
+			if curWidth+wordWidth+spaceWidth > limit {
+				addNewline()
+			}
+			if wordWidth == limit {
+				addWord()
+			}
+		}
+	}
+	endLine()
+	return buf.String()
+}
+
+// isASCII reports whether s holds only ASCII bytes, which lipgloss.Wrap
+// never splits wrongly.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
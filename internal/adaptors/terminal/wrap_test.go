@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestWrapLines(t *testing.T) {
@@ -82,6 +83,61 @@ func TestIncrementalWrapMatchesFullWrap(t *testing.T) {
 	}
 }
 
+func TestWrapTextKeepsClusters(t *testing.T) {
+	const (
+		accent = "e\u0301"         // e and a combining acute accent
+		family = "👨\u200d👩\u200d👧" // a ZWJ sequence
+		keycap = "1\ufe0f\u20e3"   // 1️⃣
+		red    = "\x1b[31m"
+		reset  = "\x1b[0m"
+	)
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string // lines without ANSI codes
+	}{
+		{"ascii", "hello world", 5, []string{"hello", "world"}},
+		{"CJK", "日本語のテキスト", 5, []string{"日本", "語の", "テキ", "スト"}},
+		{"CJK between words", "see 世界 now", 4, []string{"see", "世界", "now"}},
+		{"ZWJ sequence", "ab" + family + family + "c", 4, []string{"ab" + family, family + "c"}},
+		{"skin tone", "👍🏽👍🏽👍🏽", 4, []string{"👍🏽👍🏽", "👍🏽"}},
+		{"flags", "🇯🇵🇯🇵🇯🇵", 4, []string{"🇯🇵🇯🇵", "🇯🇵"}},
+		{"combining accents", strings.Repeat(accent, 7), 4, []string{strings.Repeat(accent, 4), strings.Repeat(accent, 3)}},
+		{"accents after ASCII", "ab" + strings.Repeat(accent, 4) + " xy", 4, []string{"ab" + accent + accent, accent + accent, "xy"}},
+		{"keycaps", "ab" + keycap + keycap, 3, []string{"ab", keycap, keycap}}, // a keycap takes 2 columns
+		{"keycap cut before a full line", "abc" + keycap + "defgh", 4, []string{"abc", keycap + "de", "fgh"}},
+		{"colored CJK", red + "红色的文字" + reset, 4, []string{"红色", "的文", "字"}},
+		{"colored accents", red + strings.Repeat(accent, 5) + reset + " ok", 4, []string{strings.Repeat(accent, 4), accent + " ok"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(wrapText(tt.text, tt.width), "\n")
+			var got []string
+			for _, line := range lines {
+				if w := lipgloss.Width(line); w > tt.width {
+					t.Errorf("line %q is %d columns wide, over %d", line, w, tt.width)
+				}
+				got = append(got, ansi.Strip(line))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapTextCarriesStyle(t *testing.T) {
+	lines := strings.Split(wrapText("\x1b[31m"+strings.Repeat("e\u0301", 5)+"\x1b[0m", 4), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	// Each line opens the color and closes it, with the accent before the close
+	if !strings.HasPrefix(lines[1], "\x1b[31m") || !strings.HasSuffix(lines[0], "e\u0301\x1b[m") {
+		t.Errorf("lines = %q", lines)
+	}
+}
+
 func TestWindowRenderCaching(t *testing.T) {
 	wb := NewWindowBuffer(80, DefaultStyles())
 
@@ -0,0 +1,75 @@
+package websocket
+
+// Protocol version negotiation.
+// The TLV protocol of clients that never send a hello is version 0. On
+// connect the server sends a TagHello:
+//
+//	{"protocol": 1, "min_protocol": 0, "server": "alayacore/0.1.0", "tags": {"TU": "both", ...}}
+//
+// protocol is the newest version the server speaks and min_protocol the
+// oldest. A client may answer with its own hello, {"protocol": N} and
+// optionally "min_protocol": M, at any time before or between prompts. The
+// server settles on the lower of the two newest versions and confirms it
+// with a hello carrying only "protocol"; when no version suits both, the
+// connection is closed with code 1002 and a reason saying which versions
+// each side speaks. A client that ignores the hello keeps version 0, so
+// older clients work unchanged.
+//
+// Versions 0 and 1 differ only in the handshake. Later changes to tags or
+// framing bump ProtocolVersion and are sent only to clients that agreed to
+// the new version.
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alayacore/alayacore/internal/config"
+)
+
+// ProtocolVersion is the newest TLV protocol version this server speaks.
+const ProtocolVersion = 1
+
+// minProtocolVersion is the oldest version this server still speaks.
+const minProtocolVersion = 0
+
+// hello is the payload of a TagHello frame, in either direction.
+type hello struct {
+	Protocol    int               `json:"protocol"`
+	MinProtocol int               `json:"min_protocol,omitempty"`
+	Server      string            `json:"server,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"` // tag to direction: client, server, or both
+}
+
+// ServerHello returns the hello sent on connect. The stdio adaptor sends the
+// same hello with --handshake.
+func ServerHello() []byte {
+	tags := make(map[string]string, len(protocolSpec.Tags))
+	for _, t := range protocolSpec.Tags {
+		tags[t.Tag] = t.Direction
+	}
+	data, _ := json.Marshal(hello{ //nolint:errcheck // plain struct, cannot fail
+		Protocol:    ProtocolVersion,
+		MinProtocol: minProtocolVersion,
+		Server:      "alayacore/" + config.Version,
+		Tags:        tags,
+	})
+	return data
+}
+
+// Negotiate returns the version to use with a client that sent value as its
+// hello, or an error, the close reason, when there is none.
+func Negotiate(value string) (int, error) {
+	var h hello
+	if err := json.Unmarshal([]byte(value), &h); err != nil {
+		return 0, fmt.Errorf("invalid hello: %v", err)
+	}
+	if h.Protocol < 0 || h.MinProtocol < 0 || h.MinProtocol > h.Protocol {
+		return 0, fmt.Errorf("invalid hello: protocol %d, min_protocol %d", h.Protocol, h.MinProtocol)
+	}
+	version := min(h.Protocol, ProtocolVersion)
+	if version < minProtocolVersion || version < h.MinProtocol {
+		return 0, fmt.Errorf("no common protocol version: client speaks %d to %d, server %d to %d",
+			h.MinProtocol, h.Protocol, minProtocolVersion, ProtocolVersion)
+	}
+	return version, nil
+}
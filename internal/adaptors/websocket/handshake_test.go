@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		hello   string
+		want    int
+		wantErr string
+	}{
+		{`{"protocol": 1}`, 1, ""},
+		{`{"protocol": 0}`, 0, ""},
+		{`{"protocol": 5, "min_protocol": 1}`, 1, ""},
+		{`{"protocol": 5, "min_protocol": 2}`, 0, "no common protocol version: client speaks 2 to 5, server 0 to 1"},
+		{`{"protocol": 1, "min_protocol": 2}`, 0, "invalid hello: protocol 1, min_protocol 2"},
+		{`{"protocol": -1}`, 0, "invalid hello: protocol -1, min_protocol 0"},
+		{`not json`, 0, "invalid hello: "},
+	}
+	for _, tt := range tests {
+		got, err := Negotiate(tt.hello)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Negotiate(%s): error %v, want %q", tt.hello, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Negotiate(%s) = %d, %v; want %d", tt.hello, got, err, tt.want)
+		}
+	}
+}
+
+// readHello reads frames until a TagHello and decodes it.
+func readHello(t *testing.T, conn *websocket.Conn) hello {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no hello: %v", err)
+		}
+		tag, value, err := parseTLV(message)
+		if err != nil || tag != stream.TagHello {
+			continue
+		}
+		var h hello
+		if err := json.Unmarshal([]byte(value), &h); err != nil {
+			t.Fatalf("bad hello %q: %v", value, err)
+		}
+		return h
+	}
+}
+
+func TestServerHelloFirst(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 0)
+	conn, _ := dial(t, url)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, value, _ := parseTLV(message)
+	if tag != stream.TagHello {
+		t.Fatalf("first frame %s, want %s", tag, stream.TagHello)
+	}
+	var h hello
+	if err := json.Unmarshal([]byte(value), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Protocol != ProtocolVersion || !strings.HasPrefix(h.Server, "alayacore/") || h.Tags[stream.TagTextUser] != "both" {
+		t.Errorf("hello = %+v", h)
+	}
+}
+
+func TestHandshakeDowngrade(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 0)
+	conn, _ := dial(t, url)
+	readHello(t, conn)
+	if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagHello, `{"protocol": 5}`)); err != nil {
+		t.Fatal(err)
+	}
+	h := readHello(t, conn)
+	if h.Protocol != ProtocolVersion || h.Server != "" {
+		t.Errorf("confirmation = %+v, want only protocol %d", h, ProtocolVersion)
+	}
+}
+
+func TestHandshakeMismatchCloses(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 0)
+	conn, _ := dial(t, url)
+	readHello(t, conn)
+	if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagHello, `{"protocol": 3, "min_protocol": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("read error %v, want a close frame", err)
+		}
+		if closeErr.Code != websocket.CloseProtocolError || !strings.Contains(closeErr.Text, "no common protocol version") {
+			t.Errorf("close %d %q", closeErr.Code, closeErr.Text)
+		}
+		return
+	}
+}
@@ -0,0 +1,143 @@
+package websocket
+
+// JSON mode.
+// Clients that connect to /ws?proto=json exchange JSON text messages instead
+// of binary TLV frames, so a browser needs no binary parser. Tags are named
+// as in protocolSpec. The server sends each frame as
+//
+//	{"tag": "text", "value": "[:1-1-t:]Hello", "time": "2026-10-16T09:30:12.000Z"}
+//
+// where "time" is the value of the TagTime frame written just before it,
+// which is not sent on its own. The client sends
+//
+//	{"type": "prompt", "value": "list files"}
+//	{"type": "image", "name": "shot.png", "value": "<base64 bytes>"}
+//
+// The session still reads and writes TLV: jsonOutput converts the frames it
+// writes, and parseJSONMessage turns client messages into frames, so nothing
+// past the adaptor knows about JSON. Binary TLV stays the default.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// Values of the proto query parameter of /ws.
+const (
+	protoTLV  = "tlv"
+	protoJSON = "json"
+)
+
+// jsonTags maps the JSON name of each tag to its description.
+var jsonTags = func() map[string]protocolTag {
+	tags := make(map[string]protocolTag, len(protocolSpec.Tags))
+	for _, t := range protocolSpec.Tags {
+		tags[t.Name] = t
+	}
+	return tags
+}()
+
+// jsonNames maps each tag to its JSON name.
+var jsonNames = func() map[string]string {
+	names := make(map[string]string, len(protocolSpec.Tags))
+	for _, t := range protocolSpec.Tags {
+		names[t.Tag] = t.Name
+	}
+	return names
+}()
+
+// jsonFrame is a frame sent to a JSON mode client.
+type jsonFrame struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+	Time  string `json:"time,omitempty"`
+}
+
+// jsonMessage is a message from a JSON mode client.
+type jsonMessage struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"` // file name of an image
+}
+
+// encodeJSONFrame returns the JSON message for a frame of tag, timed at
+// at unless it is empty. A tag without a name keeps its TLV tag.
+func encodeJSONFrame(tag, value, at string) []byte {
+	name, ok := jsonNames[tag]
+	if !ok {
+		name = tag
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(jsonFrame{Tag: name, Value: value, Time: at}) //nolint:errcheck // plain struct, cannot fail
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// parseJSONMessage returns the tag and value of the frame a JSON mode client
+// message stands for. Only tags a client may send are accepted.
+func parseJSONMessage(message []byte) (tag string, value string, err error) {
+	var m jsonMessage
+	if err := json.Unmarshal(message, &m); err != nil {
+		return "", "", fmt.Errorf("invalid JSON message: %v", err)
+	}
+	t, ok := jsonTags[m.Type]
+	if !ok || t.Direction == "server" {
+		return "", "", fmt.Errorf("unknown message type %q", m.Type)
+	}
+	if t.Tag == stream.TagUserImage {
+		data, err := base64.StdEncoding.DecodeString(m.Value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid image data: %v", err)
+		}
+		return t.Tag, stream.EncodeImage(m.Name, data), nil
+	}
+	return t.Tag, m.Value, nil
+}
+
+// jsonOutput implements stream.Output for a JSON mode client, sending each
+// TLV frame written to it as a JSON text message.
+type jsonOutput struct {
+	*clientOutput
+}
+
+func newJSONOutput(conn *websocket.Conn, logger *slog.Logger) jsonOutput {
+	return jsonOutput{newClientOutput(conn, logger)}
+}
+
+// Write sends each frame of p as a JSON message, a TagTime frame as the time
+// of the frame after it.
+func (o jsonOutput) Write(p []byte) (n int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var at string
+	for rest := p; len(rest) > 0; {
+		frame := nextFrame(rest)
+		tag, value, err := parseTLV(frame)
+		if err != nil {
+			return n, err
+		}
+		if tag == stream.TagTime && len(frame) < len(rest) {
+			at = value
+		} else {
+			if err := o.send(websocket.TextMessage, encodeJSONFrame(tag, value, at)); err != nil {
+				return n, err
+			}
+			at = ""
+		}
+		n += len(frame)
+		rest = rest[len(frame):]
+	}
+	return n, nil
+}
+
+func (o jsonOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
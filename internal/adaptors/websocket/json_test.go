@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestEncodeJSONFrame(t *testing.T) {
+	tests := []struct {
+		tag, value, at string
+		want           string
+	}{
+		{stream.TagTextAssistant, "[:1-1-t:]héllo, 世界 👋", "", `{"tag":"text","value":"[:1-1-t:]héllo, 世界 👋"}`},
+		{stream.TagTextUser, "#1 ▸ a <b> & \"c\"\n", "2026-10-16T09:30:12.000Z", `{"tag":"prompt","value":"#1 ▸ a <b> & \"c\"\n","time":"2026-10-16T09:30:12.000Z"}`},
+		{stream.TagClear, "", "", `{"tag":"clear","value":""}`},
+		{"ZZ", "x", "", `{"tag":"ZZ","value":"x"}`},
+	}
+	for _, tt := range tests {
+		if got := string(encodeJSONFrame(tt.tag, tt.value, tt.at)); got != tt.want {
+			t.Errorf("encodeJSONFrame(%s, %q) = %s, want %s", tt.tag, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONMessage(t *testing.T) {
+	tests := []struct {
+		message   string
+		tag       string
+		value     string
+		wantError string
+	}{
+		{`{"type":"prompt","value":"résumé ✓ 日本語 🚀"}`, stream.TagTextUser, "résumé ✓ 日本語 🚀", ""},
+		{`{"type":"prompt","value":"é🚀"}`, stream.TagTextUser, "é🚀", ""},
+		{`{"type":"prompt","value":":cancel"}`, stream.TagTextUser, ":cancel", ""},
+		{`{"type":"hello","value":"{\"protocol\":1}"}`, stream.TagHello, `{"protocol":1}`, ""},
+		{`{"type":"image","name":"café.png","value":"iVBORw=="}`, stream.TagUserImage, stream.EncodeImage("café.png", []byte{0x89, 'P', 'N', 'G'}), ""},
+		{`{"type":"image","name":"a.png","value":"not base64!"}`, "", "", "invalid image data"},
+		{`{"type":"text","value":"hi"}`, "", "", `unknown message type "text"`},
+		{`{"type":"TU","value":"hi"}`, "", "", `unknown message type "TU"`},
+		{`{"value":"hi"}`, "", "", `unknown message type ""`},
+		{`prompt: hi`, "", "", "invalid JSON message"},
+	}
+	for _, tt := range tests {
+		tag, value, err := parseJSONMessage([]byte(tt.message))
+		if tt.wantError != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantError) {
+				t.Errorf("parseJSONMessage(%s): error %v, want %q", tt.message, err, tt.wantError)
+			}
+			continue
+		}
+		if err != nil || tag != tt.tag || value != tt.value {
+			t.Errorf("parseJSONMessage(%s) = %s, %q, %v; want %s, %q", tt.message, tag, value, err, tt.tag, tt.value)
+		}
+	}
+}
+
+func TestJSONNamesAreUnique(t *testing.T) {
+	if len(jsonTags) != len(protocolSpec.Tags) || len(jsonNames) != len(protocolSpec.Tags) {
+		t.Errorf("%d names, %d tags, %d described", len(jsonTags), len(jsonNames), len(protocolSpec.Tags))
+	}
+}
+
+// readJSON reads JSON mode messages until one with tag whose value contains
+// want, and returns it.
+func readJSON(t *testing.T, conn *websocket.Conn, tag, want string) jsonFrame {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		kind, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no %s message containing %q: %v", tag, want, err)
+		}
+		if kind != websocket.TextMessage {
+			t.Fatalf("message type %d, want text", kind)
+		}
+		var f jsonFrame
+		if err := json.Unmarshal(message, &f); err != nil {
+			t.Fatalf("bad message %s: %v", message, err)
+		}
+		if f.Tag == tag && strings.Contains(f.Value, want) {
+			return f
+		}
+	}
+}
+
+func TestJSONMode(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 0)
+	conn, _ := dial(t, url+"?proto=json")
+
+	hello := readJSON(t, conn, "hello", "")
+	if !strings.Contains(hello.Value, `"server"`) {
+		t.Errorf("first hello = %q", hello.Value)
+	}
+	send := func(message string) {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(`{"type":"hello","value":"{\"protocol\":1}"}`)
+	if confirm := readJSON(t, conn, "hello", ""); confirm.Value != `{"protocol":1}` {
+		t.Errorf("confirmation = %q", confirm.Value)
+	}
+
+	// A command echoes as a prompt, timed, and its turn end follows
+	send(`{"type":"prompt","value":":time 日本語"}`)
+	start := readJSON(t, conn, "prompt", ":time 日本語")
+	if _, err := time.Parse(stream.TimeFormat, start.Time); err != nil {
+		t.Errorf("prompt time %q: %v", start.Time, err)
+	}
+	readJSON(t, conn, "turn_end", "")
+
+	send(`{"type":"text","value":"hi"}`)
+	readJSON(t, conn, "error", `dropped message: unknown message type "text"`)
+}
+
+func TestUnknownProtoIsRefused(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 0)
+	if _, status := dial(t, url+"?proto=xml"); status != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", status)
+	}
+	// The default and explicit TLV stay binary
+	for _, u := range []string{url, url + "?proto=tlv"} {
+		conn, _ := dial(t, u)
+		kind, message, err := conn.ReadMessage()
+		if err != nil || kind != websocket.BinaryMessage || !slices.Equal(message[:2], []byte(stream.TagHello)) {
+			t.Errorf("%s: first message type %d %q, %v", u, kind, message, err)
+		}
+	}
+}
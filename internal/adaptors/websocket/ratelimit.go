@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// promptLimiter is a token bucket that allows perMinute prompts a minute,
+// with bursts of up to perMinute. It is safe for concurrent use, as every
+// connection from one client IP shares it.
+type promptLimiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// newPromptLimiter returns a full bucket, or nil when perMinute is not
+// positive, which allows everything.
+func newPromptLimiter(perMinute int, now time.Time) *promptLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &promptLimiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(perMinute),
+		tokens:    float64(perMinute),
+		last:      now,
+	}
+}
+
+// allow takes a token. Without one, it reports how long until the next.
+func (l *promptLimiter) allow(now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - l.tokens) / l.perSecond * float64(time.Second))
+	return false, wait
+}
+
+// refilled reports whether the bucket would be full again at now, so a new
+// one would do the same.
+func (l *promptLimiter) refilled(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tokens+now.Sub(l.last).Seconds()*l.perSecond >= l.burst
+}
+
+// promptLimit returns the limit the sessions of client ip ask before each
+// task that calls the model (see agent.SessionOptions.PromptLimit), or nil when
+// --prompt-rate is 0. Reconnecting does not refill the bucket.
+func (a *Adaptor) promptLimit(ip string) func() error {
+	if a.promptRate <= 0 {
+		return nil
+	}
+	return func() error {
+		if allowed, wait := a.limiterFor(ip).allow(a.now()); !allowed {
+			return fmt.Errorf("rate limited, retry in %ds", int(math.Ceil(wait.Seconds())))
+		}
+		return nil
+	}
+}
+
+// limiterFor returns the bucket of ip, creating it. Buckets that have
+// refilled are dropped on the way, so the map does not grow with every
+// client ever seen.
+func (a *Adaptor) limiterFor(ip string) *promptLimiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if l, ok := a.limiters[ip]; ok {
+		return l
+	}
+	now := a.now()
+	for other, l := range a.limiters {
+		if l.refilled(now) {
+			delete(a.limiters, other)
+		}
+	}
+	l := newPromptLimiter(a.promptRate, now)
+	a.limiters[ip] = l
+	return l
+}
@@ -0,0 +1,215 @@
+package websocket
+
+// Idle sessions.
+// Every connection runs an agent session until the client goes away. With
+// --session-ttl, a reaper also closes sessions nobody has used for that long:
+// the client gets a warning a minute before, the conversation is saved to the
+// transcript directory, and the connection is closed, which stops the
+// session. Client messages and running tasks count as use.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// ttlWarning is how long before expiry an idle client is warned. TTLs of two
+// minutes or less are warned at half-time instead.
+const ttlWarning = time.Minute
+
+// webSession is the adaptor's record of one connection's session.
+type webSession struct {
+	id      int64
+	conn    *websocket.Conn
+	output  stream.Output
+	session *agentpkg.Session
+	created time.Time
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	warned   bool // the client was warned since it was last used
+}
+
+// touch records use of the session at now.
+func (ws *webSession) touch(now time.Time) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.lastUsed = now
+	ws.warned = false
+}
+
+// SessionInfo describes a live session, as listed by /api/sessions.
+type SessionInfo struct {
+	ID      int64   `json:"id"`
+	Age     float64 `json:"age_seconds"`
+	Idle    float64 `json:"idle_seconds"`
+	Running bool    `json:"running"`
+}
+
+// track registers the session of a new connection.
+func (a *Adaptor) track(conn *websocket.Conn, output stream.Output, session *agentpkg.Session) *webSession {
+	now := a.now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	ws := &webSession{
+		id:       a.nextID,
+		conn:     conn,
+		output:   output,
+		session:  session,
+		created:  now,
+		lastUsed: now,
+	}
+	a.sessions[ws.id] = ws
+	return ws
+}
+
+// untrack removes a session whose connection has ended.
+func (a *Adaptor) untrack(ws *webSession) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, ws.id)
+}
+
+// Sessions returns the live sessions, oldest first.
+func (a *Adaptor) Sessions() []SessionInfo {
+	now := a.now()
+	a.mu.Lock()
+	list := make([]*webSession, 0, len(a.sessions))
+	for _, ws := range a.sessions {
+		list = append(list, ws)
+	}
+	a.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+
+	infos := make([]SessionInfo, len(list))
+	for i, ws := range list {
+		ws.mu.Lock()
+		lastUsed := ws.lastUsed
+		ws.mu.Unlock()
+		infos[i] = SessionInfo{
+			ID:      ws.id,
+			Age:     now.Sub(ws.created).Seconds(),
+			Idle:    now.Sub(lastUsed).Seconds(),
+			Running: ws.session.IsInProgress(),
+		}
+	}
+	return infos
+}
+
+// serveSessions reports the number of live sessions and their ages as JSON.
+// It is served with --metrics only.
+func (a *Adaptor) serveSessions(w http.ResponseWriter, _ *http.Request) {
+	sessions := a.Sessions()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // client may have gone away
+		"count":       len(sessions),
+		"ttl_seconds": a.sessionTTL.Seconds(),
+		"sessions":    sessions,
+	})
+}
+
+// reapLoop runs reap until the process exits.
+func (a *Adaptor) reapLoop() {
+	interval := min(a.sessionTTL/10, 10*time.Second)
+	interval = max(interval, 100*time.Millisecond)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.reap()
+	}
+}
+
+// reap warns sessions about to expire and closes the expired ones.
+func (a *Adaptor) reap() {
+	if a.sessionTTL <= 0 {
+		return
+	}
+	now := a.now()
+	warnAt := a.sessionTTL - ttlWarning
+	if a.sessionTTL <= 2*ttlWarning {
+		warnAt = a.sessionTTL / 2
+	}
+
+	a.mu.Lock()
+	list := make([]*webSession, 0, len(a.sessions))
+	for _, ws := range a.sessions {
+		list = append(list, ws)
+	}
+	a.mu.Unlock()
+
+	for _, ws := range list {
+		if ws.session.IsInProgress() {
+			ws.touch(now)
+			continue
+		}
+		ws.mu.Lock()
+		idle := now.Sub(ws.lastUsed)
+		warn := idle >= warnAt && idle < a.sessionTTL && !ws.warned
+		if warn {
+			ws.warned = true
+		}
+		ws.mu.Unlock()
+
+		switch {
+		case idle >= a.sessionTTL && !ws.session.CloseIfIdle():
+			// A task started since the check above
+			ws.touch(now)
+		case idle >= a.sessionTTL:
+			a.expire(ws)
+		case warn:
+			msg := fmt.Sprintf("This session has been idle for %s and closes in %s unless you send something.", idle.Round(time.Second), (a.sessionTTL - idle).Round(time.Second))
+			_ = stream.WriteTLV(ws.output, stream.TagSystemNotify, msg) //nolint:errcheck // a dead connection is reaped anyway
+		}
+	}
+}
+
+// expire saves an idle session, closed to new tasks, and closes its
+// connection. The handler of the connection then cancels the session and
+// waits for it to stop.
+func (a *Adaptor) expire(ws *webSession) {
+	msg := fmt.Sprintf("Session closed after %s idle.", a.sessionTTL)
+	path, err := a.saveSession(ws)
+	if err != nil {
+		a.logger.Warn("failed to save expired session", "session", ws.id, "err", err)
+		msg += " It could not be saved: " + err.Error()
+	} else if path != "" {
+		msg += " Saved to " + path
+	}
+	a.logger.Info("session expired", "session", ws.id, "saved", path)
+	_ = stream.WriteTLV(ws.output, stream.TagSystemNotify, msg) //nolint:errcheck // closing anyway
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session expired")
+	_ = ws.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait)) //nolint:errcheck // closing anyway
+	ws.conn.Close()
+	a.untrack(ws)
+}
+
+// saveSession writes the conversation of ws to the transcript directory,
+// e.g. web-20261016-093012-3.md. It returns "" when transcripts are off or
+// the conversation is empty.
+func (a *Adaptor) saveSession(ws *webSession) (string, error) {
+	if a.transcriptDir == "" || ws.session.HistoryLen() == 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(a.transcriptDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	name := fmt.Sprintf("web-%s-%d.md", ws.created.Format("20060102-150405"), ws.id)
+	path := filepath.Join(a.transcriptDir, name)
+	if err := ws.session.Save(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
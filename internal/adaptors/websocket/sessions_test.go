@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// fakeClock is a settable clock for the session reaper.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTTLServer serves an Adaptor with the given TTL and a fake clock. Every
+// session starts from a saved conversation, so there is something to save.
+func newTTLServer(t *testing.T, ttl time.Duration) (*Adaptor, string, *fakeClock, string) {
+	t.Helper()
+	a, url := newTestServer(t, 0, 0, 0)
+	dir := t.TempDir()
+	history := "---\ncreated_at: 2026-10-16T09:00:00Z\nupdated_at: 2026-10-16T09:00:00Z\n---\n" +
+		"\n\n" + string(stream.EncodeTLV(stream.TagTextUser, "hello")) +
+		"\n\n" + string(stream.EncodeTLV(stream.TagTextAssistant, "hi there"))
+	a.Config.Cfg.Session = filepath.Join(dir, "session.md")
+	if err := os.WriteFile(a.Config.Cfg.Session, []byte(history), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &fakeClock{now: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)}
+	a.now = clock.Now
+	a.sessionTTL = ttl
+	a.transcriptDir = filepath.Join(dir, "transcripts")
+	return a, url, clock, a.transcriptDir
+}
+
+// waitSessions waits for the adaptor to track n sessions.
+func waitSessions(t *testing.T, a *Adaptor, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(a.Sessions()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("sessions = %d, want %d", len(a.Sessions()), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readNotice reads frames until a TagSystemNotify containing want arrives.
+func readNotice(t *testing.T, conn *websocket.Conn, want string) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no notice containing %q: %v", want, err)
+		}
+		if tag, value, err := parseTLV(message); err == nil && tag == stream.TagSystemNotify && strings.Contains(value, want) {
+			return
+		}
+	}
+}
+
+func TestSessionTTLWarnsThenExpires(t *testing.T) {
+	a, url, clock, dir := newTTLServer(t, 10*time.Minute)
+	conn, _ := dial(t, url)
+	waitSessions(t, a, 1)
+
+	clock.Advance(8 * time.Minute)
+	a.reap()
+	if len(a.Sessions()) != 1 {
+		t.Fatal("session closed before its TTL")
+	}
+
+	clock.Advance(time.Minute + 30*time.Second)
+	a.reap()
+	readNotice(t, conn, "closes in 30s unless you send something")
+
+	clock.Advance(30 * time.Second)
+	a.reap()
+	readNotice(t, conn, "Session closed after 10m0s idle. Saved to "+dir)
+
+	// The server closes the connection once the session is saved
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Errorf("close error = %v, want a normal closure", err)
+			}
+			break
+		}
+	}
+	waitSessions(t, a, 0)
+	waitConnections(t, a, 0)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "web-20261016-093000-*.md"))
+	if len(files) != 1 {
+		t.Fatalf("saved sessions = %v, want one", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "hi there") {
+		t.Errorf("saved session lacks the conversation:\n%s", data)
+	}
+}
+
+func TestSessionTTLActivityResetsClock(t *testing.T) {
+	a, url, clock, _ := newTTLServer(t, 10*time.Minute)
+	conn, _ := dial(t, url)
+	waitSessions(t, a, 1)
+
+	clock.Advance(9 * time.Minute)
+	a.reap()
+	readNotice(t, conn, "closes in 1m0s")
+
+	// Any message counts as use, even a command
+	if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagTextUser, ":help")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Sessions()[0].Idle != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the message did not reset the idle clock")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	clock.Advance(9*time.Minute + 59*time.Second)
+	a.reap()
+	if len(a.Sessions()) != 1 {
+		t.Error("session closed although it was used within its TTL")
+	}
+}
+
+func TestSessionTTLZeroNeverExpires(t *testing.T) {
+	a, url, clock, _ := newTTLServer(t, 0)
+	dial(t, url)
+	waitSessions(t, a, 1)
+	clock.Advance(1000 * time.Hour)
+	a.reap()
+	if len(a.Sessions()) != 1 {
+		t.Error("session closed without a TTL")
+	}
+}
+
+func TestServeSessions(t *testing.T) {
+	a, url, clock, _ := newTTLServer(t, time.Hour)
+	dial(t, url)
+	waitSessions(t, a, 1)
+	clock.Advance(90 * time.Second)
+	dial(t, url)
+	waitSessions(t, a, 2)
+	clock.Advance(30 * time.Second)
+
+	resp := get(t, http.HandlerFunc(a.serveSessions), "/api/sessions")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var got struct {
+		Count    int           `json:"count"`
+		TTL      float64       `json:"ttl_seconds"`
+		Sessions []SessionInfo `json:"sessions"`
+	}
+	if err := json.Unmarshal([]byte(body(t, resp)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != 2 || got.TTL != 3600 || len(got.Sessions) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+	if got.Sessions[0].Age != 120 || got.Sessions[1].Age != 30 {
+		t.Errorf("ages = %v, %v; want 120, 30 (oldest first)", got.Sessions[0].Age, got.Sessions[1].Age)
+	}
+}
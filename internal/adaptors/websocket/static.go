@@ -0,0 +1,117 @@
+package websocket
+
+// Static files and protocol description.
+// The chat UI is embedded from static/; --web-root serves a directory
+// instead, so a custom UI can be developed without rebuilding. Either way
+// /protocol.json describes the frames a UI exchanges over /ws.
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// staticHandler serves the files of root, or the embedded chat UI when root
+// is empty. Browsers revalidate on every load, so an upgraded server or an
+// edited file is picked up without a hard refresh.
+func staticHandler(root string) http.Handler {
+	var files http.FileSystem
+	if root != "" {
+		files = http.Dir(root)
+	} else {
+		sub, err := fs.Sub(staticFiles, "static")
+		if err != nil {
+			panic(err) // the directory is embedded at build time
+		}
+		files = http.FS(sub)
+	}
+	fileServer := http.FileServer(files)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// serveHealthz answers load balancer liveness probes.
+func serveHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte("ok\n")) //nolint:errcheck // client may have gone away
+}
+
+// protocolTag describes one TLV tag for /protocol.json.
+type protocolTag struct {
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`      // the tag in JSON mode
+	Direction   string `json:"direction"` // "client", "server", or "both"
+	Description string `json:"description"`
+}
+
+// protocolSpec is the TLV-over-WebSocket contract served at /protocol.json.
+var protocolSpec = struct {
+	Endpoint  string        `json:"endpoint"`
+	Framing   string        `json:"framing"`
+	JSON      string        `json:"json"`
+	StreamID  string        `json:"stream_id"`
+	Handshake string        `json:"handshake"`
+	Tags      []protocolTag `json:"tags"`
+}{
+	Endpoint: "/ws",
+	Framing:  "Binary WebSocket messages carrying TLV frames: a 2-byte ASCII tag, a 4-byte big-endian value length, then the value.",
+	JSON: "With /ws?proto=json, frames are text messages instead, one JSON object each, naming tags by their name. " +
+		`The server sends {"tag": name, "value"}, with "time" added from the TM frame before it rather than a TM message of its own; ` +
+		`the client sends {"type": name, "value"}, an image as {"type": "image", "name", "value"} with the bytes in base64. ` +
+		"Values are otherwise the same strings as in TLV frames.",
+	StreamID: "TA, TR, and FS values start with a stream ID in [:id:] form. TA and TR deltas with the same ID " +
+		"belong to one response or reasoning block; an FS ID is the tool call ID of an FC frame.",
+	Handshake: "The server's first frame is an HI hello: {\"protocol\", \"min_protocol\", \"server\", \"tags\"}. " +
+		"A client may reply with {\"protocol\": its newest version, \"min_protocol\": its oldest}; the server confirms " +
+		"the agreed version with {\"protocol\"}, or closes with code 1002 when there is none. Clients that never reply get version 0.",
+	Tags: []protocolTag{
+		{stream.TagTextUser, "prompt", "both", "From the client: a prompt, or a command starting with ':' such as :cancel, which cancels the running prompt at once " +
+			"(commands are read while a prompt streams). " +
+			"From the server: the prompt or command as its task starts, prefixed with \"#<task id> ▸ \"."},
+		{stream.TagUserImage, "image", "client", "An image for the next prompt: the file name, a NUL byte, then the raw image bytes."},
+		{stream.TagTextAssistant, "text", "server", "Assistant text delta, prefixed with its stream ID. Deltas over 32 KiB are split over several frames."},
+		{stream.TagTextReasoning, "reasoning", "server", "Reasoning delta, prefixed with its stream ID."},
+		{stream.TagFunctionCall, "tool_call", "server", `Tool call as JSON: {"id", "name", "input"}.`},
+		{stream.TagFunctionResult, "tool_result", "server", `Tool result as JSON: {"id", "output", "error", "continued"}; error is true when the tool failed. ` +
+			`An output over 32 KiB is split over several frames, all but the first with continued set to true; append their output.`},
+		{stream.TagToolResult, "structured_result", "server", `Structured tool result as JSON: {"id", "kind", "data"}, sent before the FR frames of the same result. ` +
+			`kind is "json", "file" (data {"path", "bytes"}), or "table" (data {"columns", "rows"}); a client that renders it may ignore those FR frames.`},
+		{stream.TagFunctionState, "tool_state", "server", "Tool state, prefixed with the tool call ID: pending, success, or error."},
+		{stream.TagSystemError, "error", "server", "Error message."},
+		{stream.TagSystemNotify, "notify", "server", "Notification, such as command output or a task finishing."},
+		{stream.TagSystemData, "data", "server", "Session state as JSON: token usage, queue, models, and progress."},
+		{stream.TagSystemLog, "log", "server", "Agent lifecycle event, sent only with --verbose."},
+		{stream.TagPlan, "plan", "server", `The plan kept by the manage_todo tool, after each change: a JSON array of {"id", "text", "done"}.`},
+		{stream.TagClear, "clear", "server", "The conversation was cleared with :clear: clear the display. No value."},
+		{stream.TagReview, "review", "server", `A write_file, edit_file, or replace_lines change awaiting review (--review-edits): JSON {"id", "tool", "path", "diff"} ` +
+			`with a unified diff, or a posix_shell command an ask rule of --command-rule matched: {"id", "tool", "command", "diff"}, the diff being "$ <command>", ` +
+			`or a prompt holding possible secrets: {"id", "tool": "prompt", "secrets", "diff"}, the secrets redacted. ` +
+			`Answer with TU ":review_accept <id>" or ":review_reject <id> [reason]", or ":review_mask <id>" to send a prompt masked. A second frame ` +
+			`with the same id and a "decision" of accepted, rejected, masked, expired, or canceled (and the "reason" of a rejection) ends it.`},
+		{stream.TagHello, "hello", "both", "Protocol version handshake, JSON; see handshake."},
+		{stream.TagTurnEnd, "turn_end", "server", "A task finished, whether it succeeded, failed, or was canceled: its task ID. " +
+			"Sent after all of the task's output."},
+		{stream.TagTime, "time", "server", "When the event in the next frame happened, in ISO 8601 with milliseconds: sent right before each prompt's TU, " +
+			"each FC, and each TE, with no frame between them. Clients that do not show times may ignore it."},
+		{stream.TagTurnAlert, "alert", "server", "A prompt that ran for at least --notify-after finished: a one-line summary " +
+			"for a notification, sent before its TE. Not sent for canceled prompts or with --notify off."},
+	},
+}
+
+// serveProtocol serves protocolSpec as JSON.
+func serveProtocol(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(protocolSpec) //nolint:errcheck // client may have gone away
+}
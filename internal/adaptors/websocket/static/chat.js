@@ -0,0 +1,750 @@
+const messages = document.getElementById('messages');
+const prompt = document.getElementById('prompt');
+const send = document.getElementById('send');
+const stop = document.getElementById('stop');
+const status = document.getElementById('status');
+const connection = document.getElementById('connection');
+const inputArea = document.getElementById('input-area');
+const attach = document.getElementById('attach');
+const badges = document.getElementById('badges');
+const attachFile = document.getElementById('attach-file');
+const thinking = document.getElementById('thinking');
+const plan = document.getElementById('plan');
+const planItems = document.getElementById('plan-items');
+
+const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
+const wsUrl = protocol + '//' + location.host + '/ws?proto=json';
+let ws = null;
+let reconnectTimeout = null;
+
+let currentStreams = {};  // Map of streamId -> {value, element, type}
+let streamOrder = [];     // Track order of streams for display
+let toolWindows = {};     // Map of tool call id -> {call, status, result, element}; not flushed
+let exchanges = {};       // Map of task id -> container element grouping one prompt and its output
+let currentExchange = null;
+let generating = false;   // a prompt was sent and its turn has not ended
+let running = false;      // the server started a task ("#N ▸") and has not sent turn_end for it
+let pending = [];         // prompts entered while generating: {text, element}, sent one per turn end
+let reviews = {};         // Map of review id -> element of a change awaiting review
+let eventTime = null;     // Date of the frame being handled, for the prompt or tool call it starts
+
+// Newest protocol version this client speaks; see /protocol.json
+const PROTOCOL_VERSION = 1;
+
+// Send a frame in JSON mode: {type, value}, the type being the tag's name
+function sendFrame(type, value) {
+    if (!ws || ws.readyState !== WebSocket.OPEN) return;
+    try {
+        ws.send(JSON.stringify({type, value}));
+    } catch (e) {
+        console.error('Failed to send:', e);
+    }
+}
+
+// Send an image attachment: its name and its bytes in base64
+function sendImage(file) {
+    if (!ws || ws.readyState !== WebSocket.OPEN) return;
+    const reader = new FileReader();
+    reader.onload = () => {
+        const data = reader.result.substring(reader.result.indexOf(',') + 1);
+        try {
+            ws.send(JSON.stringify({type: 'image', name: file.name, value: data}));
+        } catch (e) {
+            console.error('Failed to send:', e);
+        }
+    };
+    reader.readAsDataURL(file);
+}
+
+function setConnectionState(state) {
+    connection.className = state;
+    if (state === 'connected') {
+        connection.textContent = 'Connected';
+        inputArea.classList.remove('disabled');
+        prompt.disabled = false;
+        send.disabled = generating;
+        attach.disabled = false;
+    } else if (state === 'connecting') {
+        connection.textContent = 'Connecting...';
+        inputArea.classList.add('disabled');
+        prompt.disabled = true;
+        send.disabled = true;
+        attach.disabled = true;
+    } else {
+        connection.textContent = 'Disconnected - Reconnecting...';
+        inputArea.classList.add('disabled');
+        prompt.disabled = true;
+        send.disabled = true;
+        attach.disabled = true;
+    }
+}
+
+function connect() {
+    setConnectionState('connecting');
+
+    if (reconnectTimeout) {
+        clearTimeout(reconnectTimeout);
+        reconnectTimeout = null;
+    }
+
+    ws = new WebSocket(wsUrl);
+
+    ws.onopen = () => {
+        setConnectionState('connected');
+        prompt.focus();
+        sendPending();
+    };
+
+    ws.onclose = () => {
+        // The turn cannot end on a closed connection
+        running = false;
+        setGenerating(false);
+        setConnectionState('disconnected');
+        reconnectTimeout = setTimeout(connect, 3000);
+    };
+
+    ws.onerror = () => {
+        // Error handling is done via onclose
+    };
+
+    ws.onmessage = (event) => {
+        let frame;
+        try {
+            frame = JSON.parse(event.data);
+        } catch (e) {
+            addMessage('system', event.data);
+            return;
+        }
+        handleFrame(frame.tag, frame.value, frame.time);
+    };
+}
+
+// Parse stream ID prefix from value. Format: "[:id:]content"
+function parseStreamID(value) {
+    const prefixStart = "[:";
+    const prefixEnd = ":]";
+    if (!value.startsWith(prefixStart)) {
+        return {id: null, content: value};
+    }
+    const endIdx = value.indexOf(prefixEnd);
+    if (endIdx === -1) {
+        return {id: null, content: value};
+    }
+    const id = value.substring(prefixStart.length, endIdx);
+    const content = value.substring(endIdx + prefixEnd.length);
+    return {id, content};
+}
+
+// handleFrame handles a frame from the server; time is when its prompt,
+// tool call, or turn end happened, if the server said
+function handleFrame(tag, value, time) {
+    eventTime = time ? new Date(time) : null;
+    // Handshake: answer the server's hello, which names the server; its
+    // confirmation carries only the agreed version
+    if (tag === 'hello') {
+        try {
+            const hello = JSON.parse(value);
+            if (hello.server) {
+                sendFrame('hello', JSON.stringify({protocol: PROTOCOL_VERSION}));
+            }
+        } catch (e) {
+            console.error('Bad hello:', e);
+        }
+        return;
+    }
+    // Text content tags (delta messages with stream ID prefix)
+    if (tag === 'text' || tag === 'reasoning') {
+        const {id, content} = parseStreamID(value);
+        const streamId = id || ('unknown-' + Date.now());
+        const streamType = tag === 'text' ? 'assistant' : 'reasoning';
+        if (currentStreams[streamId]) {
+            // Append to existing stream
+            currentStreams[streamId].value += content;
+            updateMessageContent(currentStreams[streamId].element, streamType, currentStreams[streamId].value, currentStreams[streamId].status);
+        } else {
+            // New stream
+            currentStreams[streamId] = {
+                value: content,
+                element: addMessageElement(streamType, content),
+                type: streamType,
+                status: ''
+            };
+            streamOrder.push(streamId);
+        }
+    // Function call: JSON {id, name, input}
+    } else if (tag === 'tool_call') {
+        try {
+            const call = JSON.parse(value);
+            const text = escapeControls(call.name + ': ' + call.input);
+            const tool = toolWindows[call.id];
+            if (tool) {
+                tool.call = text;
+                renderToolWindow(tool);
+            } else {
+                toolWindows[call.id] = {
+                    call: text,
+                    status: '',
+                    result: null,
+                    element: stampTime(addMessageElement('tool', text))
+                };
+            }
+        } catch (e) {
+            addMessage('tool', value);
+        }
+    // Function result: JSON {id, output, error}, shown collapsed under
+    // its call unless the tool failed. A large output comes in several
+    // frames, the later ones marked continued
+    } else if (tag === 'tool_result') {
+        try {
+            const result = JSON.parse(value);
+            const tool = toolWindows[result.id];
+            if (tool) {
+                if (result.continued && tool.result !== null) {
+                    tool.result += result.output;
+                } else {
+                    tool.result = result.output;
+                }
+                tool.error = !!result.error;
+                renderToolWindow(tool);
+            }
+        } catch (e) {
+            addMessage('tool', value);
+        }
+    // Structured result: JSON {id, kind, data}, sent before the tool_result frames
+    // of the same result; shown rendered instead of their text
+    } else if (tag === 'structured_result') {
+        try {
+            const result = JSON.parse(value);
+            const tool = toolWindows[result.id];
+            if (tool) {
+                tool.typed = result;
+                renderToolWindow(tool);
+            }
+        } catch (e) {
+            console.error('Bad tool result:', e);
+        }
+    // Function output status indicator
+    } else if (tag === 'tool_state') {
+        const {id, content} = parseStreamID(value);
+        if (id && toolWindows[id]) {
+            toolWindows[id].status = content;
+            renderToolWindow(toolWindows[id]);
+        }
+    // System tags
+    } else if (tag === 'error') {
+        flushCurrentStreams();
+        addMessage('error', value);
+        // Outside a task, the error rejected the prompt, e.g. the rate
+        // limit, and no turn end follows
+        if (!running) {
+            setGenerating(false);
+            sendPending();
+        }
+    // Plan: the manage_todo list after a change, shown in the sidebar
+    } else if (tag === 'plan') {
+        try {
+            renderPlan(JSON.parse(value));
+        } catch (e) {
+            console.error('Bad plan:', e);
+        }
+    // Review: JSON {id, tool, path, diff} for a change awaiting the user's
+    // decision, then {id, tool, path, decision, reason} once decided
+    } else if (tag === 'review') {
+        flushCurrentStreams();
+        try {
+            renderReview(JSON.parse(value));
+        } catch (e) {
+            console.error('Bad review:', e);
+        }
+    // Clear: :clear started the conversation over. The exchange of the
+    // :clear itself stays, emptied, for its notice, and so do the prompts
+    // not sent yet
+    } else if (tag === 'clear') {
+        flushCurrentStreams();
+        toolWindows = {};
+        reviews = {};
+        exchanges = {};
+        for (const child of Array.from(messages.children)) {
+            if (child !== currentExchange && !child.classList.contains('pending')) {
+                child.remove();
+            }
+        }
+        if (currentExchange) {
+            currentExchange.replaceChildren();
+            exchanges[currentExchange.dataset.task] = currentExchange;
+        }
+    // Turn alert: a long prompt finished, notify if the user looked away
+    } else if (tag === 'alert') {
+        notifyTurn(value);
+    // Turn end: the task has finished, send the next prompt held back
+    } else if (tag === 'turn_end') {
+        running = false;
+        setGenerating(false);
+        sendPending();
+    } else if (tag === 'notify') {
+        flushCurrentStreams();
+        const queued = value.match(/^\[Queued #(\d+)\]$/);
+        const done = value.match(/^#(\d+) (done|canceled),/);
+        if (queued) {
+            // Belongs to the later task, not the one currently streaming
+            messages.appendChild(createMessage('system', value));
+            messages.scrollTop = messages.scrollHeight;
+        } else {
+            addMessage('system', value);
+        }
+        if (done && exchanges[done[1]]) {
+            exchanges[done[1]].classList.add('done');
+            if (currentExchange === exchanges[done[1]]) currentExchange = null;
+        }
+    // Verbose lifecycle events: one collapsed panel per exchange
+    } else if (tag === 'log') {
+        addDebugLine(value);
+    } else if (tag === 'data') {
+        flushCurrentStreams();
+        try {
+            const systemInfo = JSON.parse(value);
+            renderBadges(systemInfo);
+            document.body.classList.toggle('show-times', !!systemInfo.timestamps);
+            let statusText = '';
+            if (systemInfo.dry_run) {
+                statusText += '<span style="color: #f9e2af; font-weight: bold;">DRY RUN</span> | ';
+            }
+            if (systemInfo.queue !== undefined && systemInfo.queue > 0) {
+                statusText += 'Queue: <span style="color: #f38ba8; font-weight: bold;">' + systemInfo.queue + '</span> | ';
+            }
+            if (systemInfo.context !== undefined) {
+                statusText += 'Context: ' + systemInfo.context + ' | ';
+            }
+            if (systemInfo.total !== undefined) {
+                statusText += 'Total: ' + systemInfo.total + ' | ';
+            }
+            if (systemInfo.total > 0) {
+                statusText += systemInfo.cost === undefined ? 'cost: n/a' : formatCost(systemInfo.cost);
+            }
+            if (statusText) {
+                // Remove trailing " | " if present
+                statusText = statusText.replace(/ \s*\|\s*$/, '');
+                status.innerHTML = statusText;
+            }
+        } catch (e) {
+            // Not JSON, display as plain system message
+            addMessage('system', value);
+        }
+    // User text tag
+    } else if (tag === 'prompt') {
+        // "#N ▸ prompt" starts task N: group its output in a container
+        const start = value.match(/^#(\d+) ▸ /);
+        if (start) {
+            running = true;
+            setGenerating(true);
+            flushCurrentStreams();
+            currentExchange = document.createElement('div');
+            currentExchange.className = 'exchange';
+            currentExchange.dataset.task = start[1];
+            exchanges[start[1]] = currentExchange;
+            messages.appendChild(currentExchange);
+        }
+        stampTime(addMessage('user', value));
+    }
+    eventTime = null;
+}
+
+// stampTime marks a prompt or tool call with the time sent with its frame,
+// shown while :time is on. It returns the element.
+function stampTime(element) {
+    if (eventTime && !isNaN(eventTime)) {
+        element.dataset.time = eventTime.toLocaleTimeString([], {hour: '2-digit', minute: '2-digit', second: '2-digit', hour12: false});
+        element.title = eventTime.toISOString();
+    }
+    return element;
+}
+
+// renderPlan fills the sidebar with items [{id, text, done}]; an empty
+// plan hides it
+function renderPlan(items) {
+    planItems.innerHTML = '';
+    for (const item of items) {
+        const li = document.createElement('li');
+        li.textContent = item.id + '. ' + item.text;
+        if (item.done) li.className = 'done';
+        planItems.appendChild(li);
+    }
+    const done = items.filter(item => item.done).length;
+    plan.querySelector('h2').textContent = 'Plan (' + done + '/' + items.length + ' done)';
+    plan.hidden = items.length === 0;
+}
+
+// reviewSubject names what a review is about: a file, a command, or the
+// secrets of a prompt
+function reviewSubject(review) {
+    if (review.path || review.command) return review.path || review.command;
+    const n = (review.secrets || []).length;
+    return n === 1 ? 'holds a possible secret' : 'holds ' + n + ' possible secrets';
+}
+
+// renderReview shows a pending change as a colored diff with Accept and
+// Reject buttons, and replaces the buttons with the decision once it arrives.
+// A prompt holding secrets gets Send, Send masked, and Don't send instead
+function renderReview(review) {
+    if (!review.decision) {
+        const div = addMessageElement('review', '');
+        const prompt = (review.secrets || []).length > 0;
+        div.innerHTML = '<div class="review-title"></div><pre>' + (review.path ? formatDiff(review.diff) : escapeHtml(review.diff || '')) + '</pre>' +
+            (prompt
+                ? '<div class="review-actions"><button class="accept">Send</button><button class="mask">Send masked</button>' +
+                  '<button class="reject">Don\'t send</button></div>'
+                : '<div class="review-actions"><button class="accept">Accept</button>' +
+                  '<input class="reason" placeholder="Reason (optional)"><button class="reject">Reject</button></div>');
+        div.querySelector('.review-title').textContent = escapeControls('Review ' + review.id + ': ' + review.tool + ' ' + reviewSubject(review));
+        div.querySelector('.accept').addEventListener('click', () => sendFrame('prompt', ':review_accept ' + review.id));
+        if (prompt) {
+            div.querySelector('.mask').addEventListener('click', () => sendFrame('prompt', ':review_mask ' + review.id));
+        }
+        div.querySelector('.reject').addEventListener('click', () => {
+            const input = div.querySelector('.reason');
+            const reason = input ? input.value.trim() : '';
+            sendFrame('prompt', (':review_reject ' + review.id + ' ' + reason).trim());
+        });
+        reviews[review.id] = div;
+        return;
+    }
+    const div = reviews[review.id];
+    delete reviews[review.id];
+    let text = review.id + ' ' + review.decision + ': ' + reviewSubject(review);
+    if (review.reason) text += ' (' + review.reason + ')';
+    if (div) {
+        const actions = div.querySelector('.review-actions');
+        actions.className = 'review-decision ' + review.decision;
+        actions.textContent = text;
+    } else {
+        addMessage('system', text);
+    }
+}
+
+// formatDiff colors the lines of a unified diff
+function formatDiff(text) {
+    return text.replace(/\n$/, '').split('\n').map((line, i) => {
+        if (i < 2 || line.startsWith('@@') || line.startsWith('\\')) {
+            return '<span class="section">' + escapeHtml(line) + '</span>';
+        }
+        if (line.startsWith('+')) return '<span class="added">' + escapeHtml(line) + '</span>';
+        if (line.startsWith('-')) return '<span class="removed">' + escapeHtml(line) + '</span>';
+        return escapeHtml(line);
+    }).join('\n');
+}
+
+function addDebugLine(text) {
+    const parent = currentExchange || messages;
+    let panel = parent.debugPanel;
+    if (!panel) {
+        panel = document.createElement('details');
+        panel.className = 'debug';
+        panel.lines = [];
+        panel.innerHTML = '<summary></summary><pre></pre>';
+        parent.appendChild(panel);
+        parent.debugPanel = panel;
+    }
+    panel.lines.push(text);
+    panel.querySelector('summary').textContent = 'debug (' + panel.lines.length + (panel.lines.length === 1 ? ' event)' : ' events)');
+    panel.querySelector('pre').textContent = panel.lines.join('\n');
+    messages.scrollTop = messages.scrollHeight;
+}
+
+function flushCurrentStreams() {
+    // All streams are already updated in place, just clear the tracking
+    currentStreams = {};
+    streamOrder = [];
+}
+
+function addMessageElement(type, text) {
+    // Remove welcome message when first message is added
+    const welcome = document.getElementById('welcome');
+    if (welcome) {
+        welcome.remove();
+    }
+
+    const div = createMessage(type, text);
+    (currentExchange || messages).appendChild(div);
+    messages.scrollTop = messages.scrollHeight;
+    return div;
+}
+
+function createMessage(type, text) {
+    const div = document.createElement('div');
+    div.className = 'message ' + type;
+    if (type === 'tool') {
+        div.innerHTML = '<pre>' + escapeHtml(text) + '</pre>';
+    } else if (type === 'reasoning') {
+        renderReasoning(div, text);
+    } else if (type === 'assistant') {
+        div.innerHTML = marked.parse(text);
+    } else {
+        div.textContent = text;
+    }
+    return div;
+}
+
+function updateMessageContent(element, type, text, status) {
+    // Add status indicator for tool messages
+    let displayText = text;
+    if (type === 'tool' && status) {
+        if (status === 'success') {
+            displayText = '<span class="status-success">•</span> ' + escapeHtml(text);
+        } else if (status === 'error') {
+            displayText = '<span class="status-error">•</span> ' + escapeHtml(text);
+        } else if (status === 'pending') {
+            displayText = '<span class="status-pending">·</span> ' + escapeHtml(text);
+        }
+    }
+    
+    if (type === 'tool') {
+        element.innerHTML = '<pre>' + displayText + '</pre>';
+    } else if (type === 'reasoning') {
+        renderReasoning(element, text);
+    } else if (type === 'assistant') {
+        element.innerHTML = marked.parse(text);
+    } else {
+        element.textContent = text;
+    }
+    messages.scrollTop = messages.scrollHeight;
+}
+
+// Reasoning is collapsed under a summary of its size; an opened one stays
+// open while more streams in
+function renderReasoning(element, text) {
+    let details = element.querySelector('details');
+    if (!details) {
+        details = document.createElement('details');
+        details.innerHTML = '<summary></summary><div class="reasoning-body"></div>';
+        element.replaceChildren(details);
+    }
+    details.querySelector('summary').textContent = 'thinking (' + formatChars(text.length) + ')';
+    details.querySelector('.reasoning-body').innerHTML = marked.parse(text);
+}
+
+// Matches formatChars in the terminal: "640 chars" or "1.2k chars"
+function formatChars(n) {
+    if (n >= 1000000) return (n / 1000000).toFixed(1) + 'M chars';
+    if (n >= 1000) return (n / 1000).toFixed(1) + 'k chars';
+    return n + ' chars';
+}
+
+function renderToolWindow(tool) {
+    updateMessageContent(tool.element, 'tool', tool.call, tool.status);
+    if (tool.typed) {
+        const details = document.createElement('details');
+        details.innerHTML = '<summary>' + escapeHtml(tool.typed.kind) + '</summary>' + formatTypedResult(tool.typed);
+        tool.element.appendChild(details);
+        return;
+    }
+    if (tool.result !== null && tool.result !== '') {
+        const lines = tool.result.split('\n').length;
+        const details = document.createElement('details');
+        const body = tool.call.startsWith('posix_shell:') ? formatShellOutput(tool.result) : escapeHtml(tool.result);
+        details.open = !!tool.error;
+        details.innerHTML = '<summary>' + (tool.error ? 'error' : 'output') + ' (' + lines + (lines === 1 ? ' line' : ' lines') + ')</summary>' +
+            '<pre>' + body + '</pre>';
+        tool.element.appendChild(details);
+    }
+}
+
+// Shows the session's modes next to the send button, like the terminal's
+// mode line: [dry-run] [approve] [skill: name] [📎 N]
+function renderBadges(info) {
+    const labels = [];
+    if (info.dry_run) labels.push('dry-run');
+    if (info.review_edits) labels.push('approve');
+    if (info.active_skill) labels.push('skill: ' + info.active_skill);
+    if (info.attachments > 0) labels.push('📎 ' + info.attachments);
+    badges.replaceChildren(...labels.map(label => {
+        const badge = document.createElement('span');
+        badge.className = 'badge';
+        badge.textContent = label;
+        return badge;
+    }));
+}
+
+// Matches agent.FormatCost: cents, or four decimals below a cent
+function formatCost(cost) {
+    return '$' + (cost > 0 && cost < 0.01 ? cost.toFixed(4) : cost.toFixed(2));
+}
+
+// Matches tools.FormatResult: a table as <table>, a file reference as its
+// path and size, anything else as indented JSON
+function formatTypedResult(result) {
+    const data = result.data;
+    if (result.kind === 'table' && data && Array.isArray(data.columns)) {
+        const row = (cells, tag) => '<tr>' + cells.map(c => '<' + tag + '>' + escapeHtml(String(c)) + '</' + tag + '>').join('') + '</tr>';
+        return '<table>' + row(data.columns, 'th') + (data.rows || []).map(r => row(r, 'td')).join('') + '</table>';
+    }
+    if (result.kind === 'file' && data && data.path) {
+        return '<pre>' + escapeHtml(data.path + ' (' + data.bytes + ' bytes)') + '</pre>';
+    }
+    return '<pre>' + escapeHtml(JSON.stringify(data, null, 2)) + '</pre>';
+}
+
+// posix_shell results have stdout:/stderr:/output: sections and an exit: line
+function formatShellOutput(text) {
+    let inStderr = false;
+    return text.split('\n').map(line => {
+        if (line === 'stdout:' || line === 'output:' || line.startsWith('exit: ')) {
+            inStderr = false;
+            return '<span class="section">' + escapeHtml(line) + '</span>';
+        }
+        if (line === 'stderr:') {
+            inStderr = true;
+            return '<span class="section">' + escapeHtml(line) + '</span>';
+        }
+        return inStderr ? '<span class="stderr">' + escapeHtml(line) + '</span>' : escapeHtml(line);
+    }).join('\n');
+}
+
+function addMessage(type, text) {
+    flushCurrentStreams();
+    return addMessageElement(type, text);
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = escapeControls(text);
+    return div.innerHTML;
+}
+
+// escapeControls shows the control characters in text the model wrote, as
+// tools.EscapeControls does for the terminal: ESC as ␛, other C0 controls and
+// DEL as their control pictures, and C1 controls as \u009b. Newlines and
+// tabs are kept.
+function escapeControls(text) {
+    return text.replace(/[\x00-\x08\x0b-\x1f\x7f-\x9f]/g, (c) => {
+        const code = c.charCodeAt(0);
+        if (code < 0x20) return String.fromCharCode(0x2400 + code);
+        if (code === 0x7f) return '\u2421';
+        return '\\u' + code.toString(16).padStart(4, '0');
+    });
+}
+
+function sendMessage() {
+    const text = prompt.value.trim();
+    if (!text) return;
+    prompt.value = '';
+    requestNotifyPermission();
+    // Commands such as :cancel go out at once; the server queues the rest
+    if (text.startsWith(':')) {
+        sendFrame('prompt', text);
+        return;
+    }
+    if (generating || pending.length > 0) {
+        const element = createMessage('user pending', text);
+        messages.appendChild(element);
+        messages.scrollTop = messages.scrollHeight;
+        pending.push({text, element});
+        return;
+    }
+    sendPrompt(text);
+}
+
+function sendPrompt(text) {
+    setGenerating(true);
+    sendFrame('prompt', text);
+}
+
+// requestNotifyPermission asks once, while handling the user's own action as
+// browsers require, to notify about long prompts finishing (alert frames)
+function requestNotifyPermission() {
+    if ('Notification' in window && Notification.permission === 'default') {
+        Notification.requestPermission();
+    }
+}
+
+// notifyTurn shows an alert summary as a desktop notification when the tab is
+// hidden; a visible tab already shows the "#N done" line
+function notifyTurn(summary) {
+    if (!document.hidden || !('Notification' in window) || Notification.permission !== 'granted') return;
+    const notification = new Notification(document.title, {body: summary, tag: 'alayacore-turn'});
+    notification.onclick = () => {
+        window.focus();
+        notification.close();
+    };
+}
+
+// sendPending sends the oldest prompt held back while generating
+function sendPending() {
+    if (generating || pending.length === 0 || !ws || ws.readyState !== WebSocket.OPEN) return;
+    const next = pending.shift();
+    next.element.remove();
+    sendPrompt(next.text);
+}
+
+// setGenerating disables Send and shows the thinking indicator and Stop
+// while a prompt is being answered. Enter still works and holds the prompt
+// back.
+function setGenerating(value) {
+    generating = value;
+    thinking.hidden = !value;
+    stop.hidden = !value;
+    send.disabled = value || prompt.disabled;
+}
+
+function sendCancelCommand() {
+    sendFrame('prompt', ':cancel');
+}
+
+function sendSaveCommand() {
+    sendFrame('prompt', ':save');
+}
+
+send.addEventListener('click', sendMessage);
+// Cancels the running prompt only; prompts held back are sent after its turn_end
+stop.addEventListener('click', () => {
+    sendCancelCommand();
+    prompt.focus();
+});
+attach.addEventListener('click', () => attachFile.click());
+attachFile.addEventListener('change', () => {
+    for (const file of attachFile.files) sendImage(file);
+    attachFile.value = '';
+    prompt.focus();
+});
+messages.addEventListener('dragover', (e) => {
+    e.preventDefault();
+    messages.classList.add('dragover');
+});
+messages.addEventListener('dragleave', () => messages.classList.remove('dragover'));
+messages.addEventListener('drop', (e) => {
+    e.preventDefault();
+    messages.classList.remove('dragover');
+    for (const file of e.dataTransfer.files) sendImage(file);
+});
+prompt.addEventListener('keypress', (e) => {
+    if (e.key === 'Enter') sendMessage();
+});
+prompt.addEventListener('keydown', (e) => {
+    if (e.ctrlKey && e.key === 'c') {
+        // Ctrl+C clears input (when input is focused)
+        e.preventDefault();
+        prompt.value = '';
+    } else if (e.ctrlKey && e.key === 'g') {
+        // Ctrl+G sends :cancel, like Stop
+        e.preventDefault();
+        sendCancelCommand();
+    } else if (e.ctrlKey && e.key === 's') {
+        // Ctrl+S sends :save
+        e.preventDefault();
+        sendSaveCommand();
+    } else if (e.ctrlKey && e.key === 'u') {
+        // Ctrl+U is disabled (prevent textinput's clear line behavior)
+        e.preventDefault();
+    }
+});
+
+// Handle ":" key to focus input and insert it
+document.addEventListener('keydown', (e) => {
+    if (e.key === ':' && document.activeElement !== prompt) {
+        e.preventDefault();
+        prompt.focus();
+        prompt.value = ':';
+    }
+});
+
+prompt.focus();
+connect();
@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func get(t *testing.T, handler http.Handler, path string) *http.Response {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Result()
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestServesEmbeddedUI(t *testing.T) {
+	handler := NewAdaptor(":0", &app.Config{Cfg: &config.Settings{}}).Server.Handler
+
+	resp := get(t, handler, "/")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body(t, resp), `<script src="chat.js">`) {
+		t.Fatalf("GET / = %d, want the chat page", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", got)
+	}
+	for path, contentType := range map[string]string{"/chat.css": "text/css", "/chat.js": "javascript"} {
+		resp := get(t, handler, path)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), contentType) {
+			t.Errorf("GET %s = %d %q", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestServesWebRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<h1>custom</h1>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "app.js"), []byte("connect()"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewAdaptor(":0", &app.Config{Cfg: &config.Settings{WebRoot: root}}).Server.Handler
+
+	if got := body(t, get(t, handler, "/")); got != "<h1>custom</h1>" {
+		t.Errorf("GET / = %q, want the custom page", got)
+	}
+	if got := body(t, get(t, handler, "/app.js")); got != "connect()" {
+		t.Errorf("GET /app.js = %q", got)
+	}
+	if resp := get(t, handler, "/chat.js"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /chat.js = %d, the embedded UI should not be served", resp.StatusCode)
+	}
+	// The built-in endpoints stay in place
+	if resp := get(t, handler, "/healthz"); resp.StatusCode != http.StatusOK || body(t, resp) != "ok\n" {
+		t.Errorf("GET /healthz = %d", resp.StatusCode)
+	}
+	if resp := get(t, handler, "/ws"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /ws without an upgrade = %d, want 400 from the WebSocket handler", resp.StatusCode)
+	}
+}
+
+func TestServesProtocol(t *testing.T) {
+	handler := NewAdaptor(":0", &app.Config{Cfg: &config.Settings{}}).Server.Handler
+
+	resp := get(t, handler, "/protocol.json")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var spec struct {
+		Endpoint string `json:"endpoint"`
+		Tags     []struct {
+			Tag       string `json:"tag"`
+			Direction string `json:"direction"`
+		} `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Endpoint != "/ws" {
+		t.Errorf("endpoint = %q", spec.Endpoint)
+	}
+	directions := make(map[string]string)
+	for _, tag := range spec.Tags {
+		directions[tag.Tag] = tag.Direction
+	}
+	for _, tag := range []string{stream.TagTextUser, stream.TagUserImage, stream.TagTextAssistant, stream.TagFunctionResult, stream.TagSystemData} {
+		if directions[tag] == "" {
+			t.Errorf("tag %s is not described", tag)
+		}
+	}
+	if directions[stream.TagUserImage] != "client" || directions[stream.TagTextAssistant] != "server" {
+		t.Errorf("unexpected directions: %v", directions)
+	}
+}
+
+func TestServesMetricsOnlyWithFlag(t *testing.T) {
+	if resp := get(t, NewAdaptor(":0", &app.Config{Cfg: &config.Settings{Metrics: true}}).Server.Handler, "/api/sessions"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/sessions with --metrics = %d, want 200", resp.StatusCode)
+	}
+	// The metrics are per process, so adaptors created one after another
+	// share them without registering anything twice
+	for range 2 {
+		handler := NewAdaptor(":0", &app.Config{Cfg: &config.Settings{Metrics: true}}).Server.Handler
+		resp := get(t, handler, "/metrics")
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "# TYPE alayacore_active_sessions gauge") {
+			t.Fatalf("GET /metrics = %d:\n%s", resp.StatusCode, body)
+		}
+	}
+
+	handler := NewAdaptor(":0", &app.Config{Cfg: &config.Settings{}}).Server.Handler
+	if resp := get(t, handler, "/metrics"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /metrics without --metrics = %d, want 404", resp.StatusCode)
+	}
+	if resp := get(t, handler, "/api/sessions"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /api/sessions without --metrics = %d, want 404", resp.StatusCode)
+	}
+}
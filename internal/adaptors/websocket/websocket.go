@@ -4,20 +4,26 @@ package websocket
 // TLV-based session over WebSocket. Each connected client gets its
 // own agent session wired to a ChanInput/Output pair; the adaptor
 // is responsible only for upgrading HTTP, shuttling TLV bytes, and
-// serving the embedded HTML chat UI.
+// serving the chat UI.
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 
-	_ "embed"
-
 	agentpkg "github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/logging"
 	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/telemetry"
 )
 
 var upgrader = websocket.Upgrader{
@@ -26,113 +32,358 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// DefaultPingInterval is how often idle connections are pinged. Proxies such
+// as nginx drop connections after 60 seconds without traffic by default.
+const DefaultPingInterval = 30 * time.Second
+
+// writeWait bounds every write so a stalled client cannot block the session.
+const writeWait = 10 * time.Second
+
 // Adaptor connects WebSocket clients to agent sessions.
 type Adaptor struct {
 	Config *app.Config
 	Server *http.Server
+
+	pingInterval  time.Duration
+	webRoot       string           // directory served at /; empty serves the embedded UI
+	maxConns      int              // 0 means no limit
+	maxConnsPerIP int              // 0 means no limit
+	promptRate    int              // model calls per minute per client IP, 0 means no limit
+	sessionTTL    time.Duration    // idle sessions are closed after this long, 0 means never
+	transcriptDir string           // where expired sessions are saved; empty skips saving
+	now           func() time.Time // clock of the session reaper
+	logger        *slog.Logger     // connection, protocol, and server errors
+
+	mu          sync.Mutex
+	connections int64                     // live WebSocket connections
+	perIP       map[string]int            // live connections by client IP
+	limiters    map[string]*promptLimiter // prompt buckets by client IP
+	sessions    map[int64]*webSession     // live sessions by ID
+	nextID      int64
 }
 
 // NewAdaptor creates a WebSocket server. Each client gets its own agent session.
 func NewAdaptor(port string, cfg *app.Config) *Adaptor {
+	a := &Adaptor{
+		Config:       cfg,
+		pingInterval: DefaultPingInterval,
+		perIP:        make(map[string]int),
+		limiters:     make(map[string]*promptLimiter),
+		sessions:     make(map[int64]*webSession),
+		now:          time.Now,
+		logger:       logging.OrDiscard(cfg.Logger).With("component", "websocket"),
+	}
+	if cfg.Cfg != nil {
+		if cfg.Cfg.PingInterval > 0 {
+			a.pingInterval = cfg.Cfg.PingInterval
+		}
+		a.maxConns = cfg.Cfg.MaxConns
+		a.maxConnsPerIP = cfg.Cfg.MaxConnsPerIP
+		a.promptRate = cfg.Cfg.PromptRate
+		a.webRoot = cfg.Cfg.WebRoot
+		a.sessionTTL = cfg.Cfg.SessionTTL
+		a.transcriptDir = cfg.Cfg.TranscriptDirectory()
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/ws", handleWebSocket(cfg))
-	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/ws", a.handleWebSocket)
+	mux.HandleFunc("/api/health", a.serveHealth)
+	mux.HandleFunc("/healthz", serveHealthz)
+	mux.HandleFunc("/protocol.json", serveProtocol)
+	// Like the metrics, the session list describes every client
+	if cfg.Cfg != nil && cfg.Cfg.Metrics {
+		mux.Handle("/metrics", telemetry.Handler())
+		mux.HandleFunc("/api/sessions", a.serveSessions)
+	}
+	mux.Handle("/", staticHandler(a.webRoot))
 
-	return &Adaptor{
-		Config: cfg,
-		Server: &http.Server{
-			Addr:              port,
-			Handler:           mux,
-			ReadHeaderTimeout: 10 * time.Second,
-		},
+	a.Server = &http.Server{
+		Addr:              port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(a.logger.Handler(), slog.LevelWarn),
 	}
+	return a
+}
+
+// Connections returns the number of live WebSocket connections.
+func (a *Adaptor) Connections() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.connections
 }
 
-// Start begins listening in a goroutine.
+// acquire reserves a connection slot for ip. It returns the HTTP status to
+// reject the upgrade with, or 0 when the slot was reserved.
+func (a *Adaptor) acquire(ip string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.maxConns > 0 && a.connections >= int64(a.maxConns) {
+		return http.StatusServiceUnavailable
+	}
+	if a.maxConnsPerIP > 0 && a.perIP[ip] >= a.maxConnsPerIP {
+		return http.StatusTooManyRequests
+	}
+	a.connections++
+	a.perIP[ip]++
+	return 0
+}
+
+// release frees a slot reserved by acquire.
+func (a *Adaptor) release(ip string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.connections--
+	if a.perIP[ip]--; a.perIP[ip] <= 0 {
+		delete(a.perIP, ip)
+	}
+}
+
+// clientIP returns the IP of the remote end of r. Forwarding headers are
+// ignored, as any client could set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Start begins listening in a goroutine, along with the idle session reaper
+// when --session-ttl is set.
 func (a *Adaptor) Start() {
-	go a.Server.ListenAndServe() //nolint:errcheck // server runs in background
+	if a.sessionTTL > 0 {
+		go a.reapLoop()
+	}
+	go func() {
+		if err := a.Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("server stopped", "addr", a.Server.Addr, "err", err)
+		}
+	}()
 }
 
-// serveIndex serves the embedded chat UI.
-func serveIndex(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_, _ = w.Write(indexHTML) //nolint:errcheck // static HTML, write error not critical
+// serveHealth reports liveness and the number of live connections as JSON.
+func (a *Adaptor) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck // client may have gone away
+		"status":      "ok",
+		"connections": a.Connections(),
+	})
 }
 
 // handleWebSocket upgrades HTTP to WebSocket and runs a session.
-func handleWebSocket(cfg *app.Config) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			return
+func (a *Adaptor) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	cfg := a.Config
+	proto := r.URL.Query().Get("proto")
+	if proto != "" && proto != protoTLV && proto != protoJSON {
+		http.Error(w, fmt.Sprintf("unknown proto %q, want %s or %s", proto, protoTLV, protoJSON), http.StatusBadRequest)
+		return
+	}
+	ip := clientIP(r)
+	logger := a.logger.With("client", ip)
+	if status := a.acquire(ip); status != 0 {
+		logger.Info("connection refused", "status", status)
+		http.Error(w, "too many connections", status)
+		return
+	}
+	defer a.release(ip)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has answered with an HTTP error
+		logger.Debug("websocket upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+	telemetry.WebSocketConnects.Inc()
+	defer telemetry.WebSocketDisconnects.Inc()
+
+	input := stream.NewChanInput(100)
+	var session *agentpkg.Session
+	defer func() {
+		// The client is gone: let readFromInput exit, and stop the running
+		// task and drop queued ones so the session does not keep calling the
+		// model
+		input.Close()
+		if session != nil {
+			_ = session.Shutdown(context.Background()) //nolint:errcheck // waits without a deadline
 		}
-		defer conn.Close()
+	}()
+
+	var output stream.Output = newClientOutput(conn, logger)
+	decode := parseTLV
+	if proto == protoJSON {
+		output, decode = newJSONOutput(conn, logger), parseJSONMessage
+	}
+	// First, so a client knows the protocol before anything else arrives
+	_ = stream.WriteTLV(output, stream.TagHello, string(ServerHello())) //nolint:errcheck // a dead connection ends readMessages
 
-		input := stream.NewChanInput(100)
-		defer input.Close() // Signal session's readFromInput to exit
+	// Each connection gets its own agent session.
+	opts := cfg.SessionOptions()
+	opts.PromptLimit = a.promptLimit(ip)
+	opts.Logger = opts.Logger.With("client", ip)
+	session, _ = agentpkg.LoadOrNewSession(input, output, opts)
 
-		output := newClientOutput(conn)
+	// Tell the client which model, endpoint, and skills this session uses.
+	var protocolType, modelName, baseURL string
+	if model := session.ModelManager.GetActive(); model != nil {
+		protocolType, modelName, baseURL = model.ProtocolType, model.ModelName, model.BaseURL
+	}
+	_ = stream.WriteTLV(output, stream.TagSystemNotify, cfg.RuntimeSummary(protocolType, modelName, baseURL)) //nolint:errcheck // best-effort welcome message
+
+	ws := a.track(conn, output, session)
+	defer a.untrack(ws)
+	telemetry.ActiveSessions.Inc()
+	defer telemetry.ActiveSessions.Dec()
 
-		// Each connection gets its own agent session.
-		agentpkg.LoadOrNewSession(cfg.AgentTools, cfg.SystemPrompt, cfg.ExtraSystemPrompt, cfg.MaxSteps, input, output, cfg.Cfg.Session, cfg.Cfg.ModelConfig, cfg.Cfg.RuntimeConfig, cfg.Cfg.DebugAPI, cfg.Cfg.Proxy)
+	stop := make(chan struct{})
+	defer close(stop)
+	go keepAlive(conn, a.pingInterval, stop)
+
+	readMessages(conn, input, output, decode, a.pingInterval, func() { ws.touch(a.now()) }, logger)
+}
 
-		readMessages(conn, input)
+// keepAlive pings the client every interval until stop is closed. A failed
+// ping closes the connection, which ends readMessages.
+func keepAlive(conn *websocket.Conn, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// WriteControl is safe to call concurrently with clientOutput writes
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				conn.Close()
+				return
+			}
+		}
 	}
 }
 
-// readMessages reads TLV messages from conn and forwards to input.
-func readMessages(conn *websocket.Conn, input *stream.ChanInput) {
+// readMessages reads messages from conn, decodes each into a frame with
+// decode (parseTLV, or parseJSONMessage in JSON mode), and forwards it to
+// input. The read deadline is two ping intervals and is refreshed by every
+// pong and message, so a half-open connection is detected once pongs stop
+// arriving. A TagHello is answered here (see handshake.go) and never reaches
+// the session. Every message is reported to touch; dropped messages and read
+// errors are logged to logger.
+func readMessages(conn *websocket.Conn, input *stream.ChanInput, output stream.Output, decode func([]byte) (string, string, error), pingInterval time.Duration, touch func(), logger *slog.Logger) {
+	pongWait := 2 * pingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait)) //nolint:errcheck // deadline errors surface on read
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Info("connection lost", "err", err)
+			}
 			return
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait)) //nolint:errcheck // deadline errors surface on read
 		if len(message) == 0 {
 			continue
 		}
+		touch()
 
-		// Filter out :quit and :q commands from web client.
-		if tag, value, ok := parseTLV(message); ok && tag == stream.TagTextUser {
+		tag, value, err := decode(message)
+		if err != nil {
+			// Never forward a broken frame: the session would wait for the
+			// bytes its length promises, or misread the next message
+			logger.Warn("dropped malformed message", "bytes", len(message), "err", err)
+			_ = stream.WriteTLV(output, stream.TagSystemError, "dropped message: "+err.Error()) //nolint:errcheck // best-effort notice
+			continue
+		}
+		if tag == stream.TagHello {
+			version, err := Negotiate(value)
+			if err != nil {
+				logger.Warn("protocol negotiation failed", "err", err)
+				closeMsg := websocket.FormatCloseMessage(websocket.CloseProtocolError, err.Error())
+				_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait)) //nolint:errcheck // closing anyway
+				return
+			}
+			confirm := fmt.Sprintf(`{"protocol":%d}`, version)
+			_ = stream.WriteTLV(output, stream.TagHello, confirm) //nolint:errcheck // a dead connection ends the loop
+			continue
+		}
+		if tag == stream.TagTextUser {
+			// Filter out :quit and :q commands from web client.
 			if value == ":quit" || value == ":q" {
 				continue
 			}
 		}
 
-		_ = input.Emit(message) //nolint:errcheck // best-effort WebSocket message
+		if err := input.EmitTLV(tag, value); err != nil {
+			logger.Warn("dropped message", "tag", tag, "err", err)
+		}
 	}
 }
 
-// parseTLV extracts tag and value from a TLV-encoded message.
-// Returns ok=false if message is too short.
-func parseTLV(message []byte) (tag string, value string, ok bool) {
-	if len(message) < 6 {
-		return "", "", false
+// parseTLV extracts tag and value from the first TLV frame of a message.
+// Errors wrap stream.ErrMalformedFrame.
+func parseTLV(message []byte) (tag string, value string, err error) {
+	tag, length, err := stream.ParseHeader(message)
+	if err != nil {
+		return "", "", err
 	}
-	tag = string(message[0:2])
-	length := uint32(message[2])<<24 | uint32(message[3])<<16 | uint32(message[4])<<8 | uint32(message[5])
-	if len(message) < 6+int(length) {
-		return "", "", false
+	if len(message)-6 < length {
+		return "", "", fmt.Errorf("%w: %d value bytes, header says %d", stream.ErrMalformedFrame, len(message)-6, length)
 	}
-	return tag, string(message[6 : 6+length]), true
+	return tag, string(message[6 : 6+length]), nil
 }
 
-// clientOutput implements stream.Output for a WebSocket connection.
+// clientOutput implements stream.Output for a WebSocket connection. The
+// first failed write is logged; the connection is gone after it.
 type clientOutput struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+	conn   *websocket.Conn
+	logger *slog.Logger
+	mu     sync.Mutex
+	failed bool // guarded by mu
 }
 
-func newClientOutput(conn *websocket.Conn) *clientOutput {
-	return &clientOutput{conn: conn}
+func newClientOutput(conn *websocket.Conn, logger *slog.Logger) *clientOutput {
+	return &clientOutput{conn: conn, logger: logging.OrDiscard(logger)}
 }
 
+// Write sends each TLV frame of p as its own message. The frames of one
+// write, such as a TagTime frame and the frame it times, go out together.
 func (o *clientOutput) Write(p []byte) (n int, err error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
-	if err = o.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
-		return 0, err
+	for rest := p; len(rest) > 0; {
+		message := nextFrame(rest)
+		if err := o.send(websocket.BinaryMessage, message); err != nil {
+			return n, err
+		}
+		n += len(message)
+		rest = rest[len(message):]
 	}
-	return len(p), nil
+	return n, nil
+}
+
+// send writes one message to the connection. The caller holds o.mu.
+func (o *clientOutput) send(messageType int, message []byte) error {
+	_ = o.conn.SetWriteDeadline(time.Now().Add(writeWait)) //nolint:errcheck // deadline errors surface on write
+	err := o.conn.WriteMessage(messageType, message)
+	if err != nil && !o.failed {
+		o.failed = true
+		o.logger.Info("write to client failed", "err", err)
+	}
+	return err
+}
+
+// nextFrame returns the first TLV frame of p, or all of p when it does not
+// start with a whole frame.
+func nextFrame(p []byte) []byte {
+	if _, length, err := stream.ParseHeader(p); err == nil && 6+length < len(p) {
+		return p[:6+length]
+	}
+	return p
 }
 
 func (o *clientOutput) WriteString(s string) (int, error) {
@@ -140,6 +391,3 @@ func (o *clientOutput) WriteString(s string) (int, error) {
 }
 
 func (o *clientOutput) Flush() error { return nil }
-
-//go:embed chat.html
-var indexHTML []byte
@@ -0,0 +1,335 @@
+package websocket
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	agentpkg "github.com/alayacore/alayacore/internal/agent"
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/skills"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// newTestServer serves an Adaptor with the given limits over httptest.
+func newTestServer(t *testing.T, maxConns, maxConnsPerIP, promptRate int) (*Adaptor, string) {
+	t.Helper()
+	return newModelTestServer(t, agentpkg.DefaultModelConfig, maxConns, maxConnsPerIP, promptRate)
+}
+
+// newModelTestServer is newTestServer with the given model config.
+func newModelTestServer(t *testing.T, models string, maxConns, maxConnsPerIP, promptRate int) (*Adaptor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	modelConfig := filepath.Join(dir, "model.conf")
+	if err := os.WriteFile(modelConfig, []byte(models), 0600); err != nil {
+		t.Fatal(err)
+	}
+	skillsMgr, err := skills.NewManager(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &app.Config{
+		Cfg: &config.Settings{
+			ModelConfig:   modelConfig,
+			RuntimeConfig: filepath.Join(dir, "runtime.conf"),
+			MaxConns:      maxConns,
+			MaxConnsPerIP: maxConnsPerIP,
+			PromptRate:    promptRate,
+		},
+		SkillsMgr: skillsMgr,
+		MaxSteps:  10,
+	}
+	a := NewAdaptor(":0", cfg)
+	srv := httptest.NewServer(a.Server.Handler)
+	t.Cleanup(srv.Close)
+	return a, "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+}
+
+func dial(t *testing.T, url string) (*websocket.Conn, int) {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp == nil {
+			t.Fatal(err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, resp.StatusCode
+}
+
+// waitConnections waits for the adaptor to count n live connections.
+func waitConnections(t *testing.T, a *Adaptor, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Connections() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("connections = %d, want %d", a.Connections(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMaxConnections(t *testing.T) {
+	a, url := newTestServer(t, 2, 0, 0)
+	dial(t, url)
+	second, _ := dial(t, url)
+	if _, status := dial(t, url); status != http.StatusServiceUnavailable {
+		t.Errorf("third connection: status %d, want 503", status)
+	}
+
+	// A slot frees up when a client disconnects
+	second.Close()
+	waitConnections(t, a, 1)
+	if conn, _ := dial(t, url); conn == nil {
+		t.Error("connection after a disconnect was rejected")
+	}
+}
+
+func TestMaxConnectionsPerIP(t *testing.T) {
+	_, url := newTestServer(t, 0, 1, 0)
+	dial(t, url)
+	if _, status := dial(t, url); status != http.StatusTooManyRequests {
+		t.Errorf("second connection from one IP: status %d, want 429", status)
+	}
+}
+
+func TestPromptRateLimit(t *testing.T) {
+	_, url := newTestServer(t, 0, 0, 1)
+	conn, _ := dial(t, url)
+	// The first prompt uses the only token; the model is unreachable, so
+	// whatever it answers, the second prompt must be refused at once
+	for _, prompt := range []string{"hello", "again"} {
+		if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagTextUser, prompt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitRateLimited(t, conn)
+
+	// Reconnecting does not refill the bucket, and commands that call the
+	// model count too
+	conn.Close()
+	conn, _ = dial(t, url)
+	if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagTextUser, ":summarize")); err != nil {
+		t.Fatal(err)
+	}
+	waitRateLimited(t, conn)
+}
+
+// waitRateLimited reads from conn until the rate limit error arrives.
+func waitRateLimited(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no rate limit error: %v", err)
+		}
+		if tag, value, err := parseTLV(message); err == nil && tag == stream.TagSystemError && strings.HasPrefix(value, "rate limited, retry in ") {
+			return
+		}
+	}
+}
+
+func TestPromptLimiter(t *testing.T) {
+	start := time.Unix(0, 0)
+	l := newPromptLimiter(2, start)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow(start); !ok {
+			t.Fatalf("prompt %d of the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.allow(start)
+	if ok || wait != 30*time.Second {
+		t.Errorf("over the limit: allowed %v, wait %v; want refused, 30s", ok, wait)
+	}
+	if ok, _ := l.allow(start.Add(30 * time.Second)); !ok {
+		t.Error("prompt after the refill was refused")
+	}
+
+	if ok, _ := newPromptLimiter(0, start).allow(start); !ok {
+		t.Error("a zero rate limits nothing")
+	}
+}
+
+// lockedBuffer is a log destination safe to read while the server writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMalformedMessageIsDropped(t *testing.T) {
+	a, url := newTestServer(t, 0, 0, 0)
+	var log lockedBuffer
+	a.logger = logging.New(&log, logging.FormatText, slog.LevelWarn)
+	conn, _ := dial(t, url)
+	// Claims more bytes than the message holds
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte{'T', 'U', 0, 0, 1, 0, 'h', 'i'}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no error for the malformed message: %v", err)
+		}
+		if tag, value, err := parseTLV(message); err == nil && tag == stream.TagSystemError && strings.HasPrefix(value, "dropped message: ") {
+			if got := log.String(); !strings.Contains(got, `level=WARN msg="dropped malformed message"`) || !strings.Contains(got, "bytes=8") {
+				t.Errorf("log = %q", got)
+			}
+			return
+		}
+	}
+}
+
+func FuzzParseTLV(f *testing.F) {
+	f.Add(stream.EncodeTLV(stream.TagTextUser, "hello"))
+	f.Add([]byte{'T', 'U', 0xff, 0xff, 0xff, 0xff, 'x'})
+	f.Fuzz(func(t *testing.T, message []byte) {
+		_, value, err := parseTLV(message)
+		if err == nil && len(value) > len(message)-6 {
+			t.Fatalf("value of %d bytes from a %d-byte message", len(value), len(message))
+		}
+	})
+}
+
+func TestClientOutputSendsLargeValuesInChunks(t *testing.T) {
+	value := strings.Repeat("0123456789abcdef", 5<<20/16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		out := newClientOutput(conn, nil)
+		_ = stream.WriteTLVChunked(out, stream.TagTextAssistant, "[:1-1-t:]", value) //nolint:errcheck // the client checks what arrives
+		_ = stream.WriteTLV(out, stream.TagTurnEnd, "1")                             //nolint:errcheck // as above
+	}))
+	t.Cleanup(srv.Close)
+	conn, _ := dial(t, "ws"+strings.TrimPrefix(srv.URL, "http"))
+
+	var got strings.Builder
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(message) > 6+len("[:1-1-t:]")+stream.ChunkSize {
+			t.Fatalf("a message of %d bytes, want at most one chunk", len(message))
+		}
+		tag, frame, err := parseTLV(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag == stream.TagTurnEnd {
+			break
+		}
+		got.WriteString(strings.TrimPrefix(frame, "[:1-1-t:]"))
+	}
+	if got.String() != value {
+		t.Errorf("received %d bytes, want the %d sent", got.Len(), len(value))
+	}
+}
+
+// slowModel serves an OpenAI-compatible stream that sends one delta and then
+// hangs until the request is canceled, which it reports on canceled.
+func slowModel(t *testing.T) (models string, canceled <-chan struct{}) {
+	t.Helper()
+	done := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"partial answer\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		done <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	models = fmt.Sprintf("---\nname: \"slow\"\nprotocol_type: \"openai\"\nbase_url: %q\napi_key: \"test\"\nmodel_name: \"slow\"\n---\n", srv.URL)
+	return models, done
+}
+
+func TestCancelMidStream(t *testing.T) {
+	models, canceled := slowModel(t)
+	_, url := newModelTestServer(t, models, 0, 0, 0)
+	conn, _ := dial(t, url)
+
+	send := func(value string) {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.BinaryMessage, stream.EncodeTLV(stream.TagTextUser, value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// readUntil reads frames until one of tag containing want arrives and
+	// returns the notices read on the way.
+	readUntil := func(tag, want string) []string {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var notices []string
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("no %s frame containing %q: %v", tag, want, err)
+			}
+			got, value, err := parseTLV(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == stream.TagSystemNotify {
+				notices = append(notices, value)
+			}
+			if got == tag && strings.Contains(value, want) {
+				return notices
+			}
+		}
+	}
+
+	send("tell me a long story")
+	readUntil(stream.TagTextAssistant, "partial answer")
+	// The session is busy streaming; the cancel must still get through
+	send(":cancel")
+	notices := readUntil(stream.TagTurnEnd, "")
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the model request was not canceled")
+	}
+	if !slices.ContainsFunc(notices, func(n string) bool { return strings.Contains(n, "canceled") }) {
+		t.Errorf("no cancel notice before the turn end: %q", notices)
+	}
+
+	// The session takes prompts again after the cancel
+	send(":pwd")
+	readUntil(stream.TagSystemNotify, "/")
+}
@@ -0,0 +1,116 @@
+package agent
+
+// Image attachments: pending images are bound to the next submitted prompt.
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// MaxImageSize is the largest image accepted as an attachment (the Anthropic API limit).
+const MaxImageSize = 5 << 20
+
+// supportedImageTypes lists the media types accepted by both providers.
+var supportedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// newImagePart validates image data and detects its media type from the content.
+func newImagePart(name string, data []byte) (llm.ImagePart, error) {
+	if len(data) > MaxImageSize {
+		return llm.ImagePart{}, fmt.Errorf("%s is %s, larger than the %s limit", name, formatSize(len(data)), formatSize(MaxImageSize))
+	}
+	mediaType := http.DetectContentType(data)
+	if !supportedImageTypes[mediaType] {
+		return llm.ImagePart{}, fmt.Errorf("%s is %s, not a supported image (png, jpeg, gif, webp)", name, mediaType)
+	}
+	return llm.ImagePart{Type: "image", Name: name, MediaType: mediaType, Data: data}, nil
+}
+
+// isAttachCommand reports whether cmd is ":attach". It runs synchronously so the
+// image is bound to the prompt that follows it, even while a task is running.
+func isAttachCommand(cmd string) bool {
+	return cmd == "attach" || strings.HasPrefix(cmd, "attach ")
+}
+
+// handleAttach reads an image file, relative to the working directory, and
+// attaches it to the next prompt.
+func (s *Session) handleAttach(args []string) {
+	if len(args) == 0 {
+		s.writeError("usage: :attach <path>")
+		return
+	}
+	path, err := s.checkPath(strings.Join(args, " "))
+	if err != nil {
+		s.writeError(domainerrors.Wrap("attach", err).Error())
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		s.writeError(domainerrors.Wrap("attach", err).Error())
+		return
+	}
+	// Check the size before reading so huge files are never loaded
+	if info.Size() > MaxImageSize {
+		s.writeError(domainerrors.NewSessionErrorf("attach", "%s is %s, larger than the %s limit",
+			filepath.Base(path), formatSize(int(info.Size())), formatSize(MaxImageSize)).Error())
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.writeError(domainerrors.Wrap("attach", err).Error())
+		return
+	}
+	s.attachImage(filepath.Base(path), data)
+}
+
+// attachImage validates an image and queues it for the next prompt.
+func (s *Session) attachImage(name string, data []byte) {
+	image, err := newImagePart(name, data)
+	if err != nil {
+		s.writeError(domainerrors.Wrap("attach", err).Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.pendingImages = append(s.pendingImages, image)
+	s.mu.Unlock()
+
+	s.writeNotify(formatAttachment(image))
+	s.sendSystemInfo()
+}
+
+// takeAttachments returns and clears the pending images.
+func (s *Session) takeAttachments() []llm.ImagePart {
+	s.mu.Lock()
+	images := s.pendingImages
+	s.pendingImages = nil
+	s.mu.Unlock()
+	if len(images) > 0 {
+		s.sendSystemInfo()
+	}
+	return images
+}
+
+// formatAttachment returns the confirmation shown for an attached image.
+func formatAttachment(image llm.ImagePart) string {
+	return fmt.Sprintf("[attached %s, %s]", image.Name, formatSize(len(image.Data)))
+}
+
+// formatSize formats a byte count as KB (or MB above 1 MB).
+func formatSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%dKB", (n+1023)/1024)
+}
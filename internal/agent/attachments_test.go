@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// pngBytes is enough of a PNG for content sniffing.
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAttachImage(t *testing.T) {
+	session, output := newSettingsTestSession()
+	path := filepath.Join(t.TempDir(), "screenshot.png")
+	if err := os.WriteFile(path, pngBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	session.handleAttach([]string{path})
+
+	if !outputContains(output, "[attached screenshot.png, 1KB]") {
+		t.Errorf("expected attach confirmation, got %v", output.Messages)
+	}
+	images := session.takeAttachments()
+	if len(images) != 1 || images[0].MediaType != "image/png" || !bytes.Equal(images[0].Data, pngBytes) {
+		t.Fatalf("unexpected attachments: %+v", images)
+	}
+	if len(session.takeAttachments()) != 0 {
+		t.Error("attachments should be cleared once taken")
+	}
+}
+
+func TestAttachFollowsWorkdir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"screenshot.png", "secret.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), pngBytes, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session, output := newSettingsTestSession()
+	session.workdir = tools.NewWorkdir(dir)
+	session.pathPolicy = tools.NewPathPolicy(nil, []string{"secret.png"}, false)
+
+	session.handleAttach([]string{"screenshot.png"})
+	if len(session.takeAttachments()) != 1 {
+		t.Errorf("a relative path was not found in the working directory: %v", output.Messages)
+	}
+	session.handleAttach([]string{"secret.png"})
+	if len(session.takeAttachments()) != 0 || !outputContains(output, `denied by path rule "secret.png"`) {
+		t.Errorf("a denied image was attached: %v", output.Messages)
+	}
+}
+
+func TestAttachRejectsInvalidImages(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(text, []byte("plain text"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "large.png")
+	if err := os.WriteFile(large, append(pngBytes, make([]byte, MaxImageSize)...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{text, large, filepath.Join(dir, "missing.png")} {
+		session, output := newSettingsTestSession()
+		session.handleAttach([]string{path})
+		if len(session.takeAttachments()) != 0 {
+			t.Errorf("%s: should not be attached", path)
+		}
+		if len(output.Messages) == 0 {
+			t.Errorf("%s: expected an error message", path)
+		}
+	}
+}
+
+func TestAttachmentsRoundTripSessionFile(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	session.attachImage("a.png", pngBytes)
+
+	prompt := UserPrompt{Text: "describe", Images: session.takeAttachments()}
+	message := llm.NewUserMessage(prompt.Text)
+	for _, image := range prompt.Images {
+		message.Content = append(message.Content, image)
+	}
+
+	data := &SessionData{Messages: []llm.Message{message}}
+	raw, err := formatSessionMarkdown(data)
+	if err != nil {
+		t.Fatalf("Failed to format session: %v", err)
+	}
+	parsed, err := parseSessionMarkdown(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse session: %v", err)
+	}
+	if len(parsed.Messages) != 1 || len(parsed.Messages[0].Content) != 2 {
+		t.Fatalf("expected one user message with text and image, got %+v", parsed.Messages)
+	}
+	image, ok := parsed.Messages[0].Content[1].(llm.ImagePart)
+	if !ok || image.Name != "a.png" || !bytes.Equal(image.Data, pngBytes) {
+		t.Errorf("image did not round-trip: %+v", parsed.Messages[0].Content[1])
+	}
+	if last := parsed.TLVChunks[len(parsed.TLVChunks)-1]; last.Value != "[attached a.png, 1KB]" {
+		t.Errorf("display chunk = %q, want attachment placeholder", last.Value)
+	}
+}
+
+func TestSessionStateCountsAttachments(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.reviewEdits = true
+	session.attachImage("a.png", pngBytes)
+	session.attachImage("b.png", pngBytes)
+
+	if state := session.State(); state.Attachments != 2 || !state.ReviewEdits || state.DryRun {
+		t.Errorf("state = %+v, want 2 attachments in review mode", state)
+	}
+	if !outputContains(output, `"review_edits":true,"attachments":2`) {
+		t.Errorf("system info does not carry the state, got %q", output.Messages)
+	}
+	session.takeAttachments()
+	if !outputContains(output, `"review_edits":true,"context"`) {
+		t.Errorf("system info is not sent once the attachments are taken, got %q", output.Messages)
+	}
+}
@@ -0,0 +1,150 @@
+package agent
+
+// Continuing replies cut off at the output token limit.
+// With a small max_output_tokens or a verbose model, a reply can stop
+// mid-sentence. When the provider reports that it stopped at the limit
+// (Anthropic's max_tokens, OpenAI's "length"), the session asks the model to
+// go on, up to --auto-continue times per prompt. As with interrupted
+// responses (continuation.go), the follow-up reuses the stream IDs of the cut
+// reply, so it is shown in the same message, and its tokens count toward the
+// same task. Models often start over with the sentence they were cut off in:
+// overlapTrimmer drops the start of the follow-up that repeats the end of the
+// cut text, on screen and in the history.
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// lengthContinuePrompt asks the model to go on with a reply cut off at the
+// output token limit.
+const lengthContinuePrompt = "Your previous response was cut off because it reached the output token limit. " +
+	"Continue exactly from where you left off, without repeating anything you already wrote."
+
+// continueTruncated asks for the rest of the reply while the last request
+// at pos stopped at the output token limit. It returns the text and error of
+// the last request, as processPrompt does.
+func (s *Session) continueTruncated(ctx context.Context, pos *streamPosition) (string, error) {
+	s.mu.Lock()
+	limit := s.autoContinue
+	s.mu.Unlock()
+
+	for n := 1; pos.truncated && ctx.Err() == nil; n++ {
+		if n > limit {
+			s.writeNotify("The reply stopped at the output token limit; send \"continue\" for the rest")
+			break
+		}
+		s.writeVerbosef("reply stopped at the output token limit; continuing, %d of %d", n, limit)
+		start := len(s.Messages)
+		pos.trim = newOverlapTrimmer(lastAssistantText(s.Messages))
+		s.appendMessages(llm.NewUserMessage(lengthContinuePrompt))
+		_, partial, err := s.processPrompt(ctx, pos, s.Messages)
+		pos.trim = nil
+		if isEmptyResponse(err) {
+			// Nothing more to say: the reply stays as it was
+			s.setMessages(s.Messages[:start])
+			return "", nil
+		}
+		if err != nil {
+			return partial, err
+		}
+	}
+	return "", nil
+}
+
+// Bounds of the overlap between a cut reply and its continuation.
+const (
+	overlapWindow = 200 // bytes at the end of the cut reply that may be repeated
+	minOverlap    = 8   // shorter overlaps are taken for coincidence
+)
+
+// overlapTrimmer holds back the start of a continuation until it can tell
+// how much of it repeats the end of the text it continues, and drops that.
+type overlapTrimmer struct {
+	tail string // end of the text being continued
+	held strings.Builder
+	cut  string // start of the continuation that was dropped
+	done bool
+}
+
+func newOverlapTrimmer(text string) *overlapTrimmer {
+	tail := text
+	if len(tail) > overlapWindow {
+		tail = tail[len(tail)-overlapWindow:]
+		for tail != "" && !utf8.RuneStart(tail[0]) {
+			tail = tail[1:]
+		}
+	}
+	return &overlapTrimmer{tail: tail}
+}
+
+// feed takes a delta of the continuation and returns the text to show for
+// it, which is empty while the start is held back.
+func (t *overlapTrimmer) feed(delta string) string {
+	if t.done {
+		return delta
+	}
+	t.held.WriteString(delta)
+	if len(strings.TrimLeft(t.held.String(), " \t\n")) < len(t.tail) {
+		return ""
+	}
+	return t.flush()
+}
+
+// flush decides on the text held back so far and returns what is left of
+// it to show. Later deltas pass through feed unchanged.
+func (t *overlapTrimmer) flush() string {
+	if t.done {
+		return ""
+	}
+	t.done = true
+	held := t.held.String()
+	rest := strings.TrimLeft(held, " \t\n")
+	if n := overlap(t.tail, rest); n > 0 {
+		t.cut = held[:len(held)-len(rest)+n]
+	}
+	return held[len(t.cut):]
+}
+
+// apply drops the start cut from the display from the continuation's text
+// in messages as well. A continuation that only repeated is kept whole.
+func (t *overlapTrimmer) apply(messages []llm.Message) []llm.Message {
+	if t.cut == "" {
+		return messages
+	}
+	for i, msg := range messages {
+		if msg.Role != llm.RoleAssistant {
+			continue
+		}
+		for j, part := range msg.Content {
+			text, ok := part.(llm.TextPart)
+			if !ok {
+				continue
+			}
+			if !strings.HasPrefix(text.Text, t.cut) || strings.TrimSpace(text.Text[len(t.cut):]) == "" {
+				return messages
+			}
+			text.Text = text.Text[len(t.cut):]
+			content := append([]llm.ContentPart(nil), msg.Content...)
+			content[j] = text
+			trimmed := append([]llm.Message(nil), messages...)
+			trimmed[i].Content = content
+			return trimmed
+		}
+	}
+	return messages
+}
+
+// overlap returns the length of the longest end of a that b starts with, or
+// 0 when it is shorter than minOverlap.
+func overlap(a, b string) int {
+	for n := min(len(a), len(b)); n >= minOverlap; n-- {
+		if strings.HasSuffix(a, b[:n]) {
+			return n
+		}
+	}
+	return 0
+}
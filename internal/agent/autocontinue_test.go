@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// truncatingProvider answers with replies[i] for its i-th request, each
+// spending 10 input and 5 output tokens. All but the last stop at the output
+// token limit; the last one is repeated for later requests, and is cut off
+// too when endless is set.
+type truncatingProvider struct {
+	replies []string
+	endless bool
+	calls   atomic.Int32
+	prompts []string // last user message of each request
+}
+
+func (p *truncatingProvider) StreamMessages(_ context.Context, messages []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	n := int(p.calls.Add(1)) - 1
+	p.prompts = append(p.prompts, lastUserText(messages))
+	reply := p.replies[min(n, len(p.replies)-1)]
+	events := make(chan llm.StreamEvent, 2)
+	events <- llm.TextDeltaEvent{Delta: reply}
+	events <- llm.StepCompleteEvent{
+		Messages:  []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: reply}})},
+		Usage:     llm.Usage{InputTokens: 10, OutputTokens: 5},
+		Truncated: n < len(p.replies)-1 || p.endless,
+	}
+	close(events)
+	return events, nil
+}
+
+func lastUserText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == llm.RoleUser {
+			for _, part := range messages[i].Content {
+				if text, ok := part.(llm.TextPart); ok {
+					return text.Text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func TestTruncatedReplyContinues(t *testing.T) {
+	// The second reply starts over with the sentence the first was cut in
+	provider := &truncatingProvider{replies: []string{
+		"The quick brown fo",
+		"The quick brown fox jumps over",
+		" the lazy dog.",
+	}}
+	session, output := newSummarizeTestSession(t, provider)
+	session.autoContinue = 3
+
+	session.handleUserPrompt(context.Background(), "write a pangram", nil)
+
+	if got := provider.calls.Load(); got != 3 {
+		t.Fatalf("provider called %d times, want 3", got)
+	}
+	if provider.prompts[1] != lengthContinuePrompt || provider.prompts[2] != lengthContinuePrompt {
+		t.Errorf("continuations asked with %q", provider.prompts[1:])
+	}
+
+	// All three parts go to the same displayed message, without the repeat
+	var shown strings.Builder
+	for _, msg := range output.Messages {
+		tag, length, err := stream.ParseHeader([]byte(msg))
+		if err != nil || tag != stream.TagTextAssistant {
+			continue
+		}
+		id, text, _ := strings.Cut(msg[6:6+length], ":]")
+		if id != "[:0-1-t" {
+			t.Errorf("continuation shown under %s:], want [:0-1-t:]", id)
+		}
+		shown.WriteString(text)
+	}
+	if got := shown.String(); got != "The quick brown fox jumps over the lazy dog." {
+		t.Errorf("shown text = %q", got)
+	}
+	if outputContains(output, "output token limit") {
+		t.Errorf("a reply completed within the cap should not be reported, got %q", output.Messages)
+	}
+
+	var texts []string
+	for _, msg := range session.Messages {
+		if msg.Role == llm.RoleAssistant {
+			texts = append(texts, lastAssistantText([]llm.Message{msg}))
+		}
+	}
+	if got := strings.Join(texts, "|"); got != "The quick brown fo|x jumps over| the lazy dog." {
+		t.Errorf("assistant history = %q", got)
+	}
+	// The continuations count toward the prompt's usage
+	if got := session.totalTokens(); got != 45 {
+		t.Errorf("total tokens = %d, want 45", got)
+	}
+}
+
+func TestTruncatedReplyCap(t *testing.T) {
+	for _, limit := range []int{0, 2} {
+		provider := &truncatingProvider{replies: []string{"and so on, and so forth"}, endless: true}
+		session, output := newSummarizeTestSession(t, provider)
+		session.autoContinue = limit
+
+		session.handleUserPrompt(context.Background(), "talk", nil)
+
+		if got := provider.calls.Load(); got != int32(1+limit) {
+			t.Errorf("limit %d: provider called %d times, want %d", limit, got, 1+limit)
+		}
+		if !outputContains(output, `The reply stopped at the output token limit; send "continue" for the rest`) {
+			t.Errorf("limit %d: the cut reply was not reported, got %q", limit, output.Messages)
+		}
+	}
+}
+
+func TestOverlapTrimmer(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		deltas []string
+		want   string
+	}{
+		{"no overlap", "It was the best of", []string{" times, ", "it was the worst"}, " times, it was the worst"},
+		{"restarted sentence", "Done. The quick brown fo", []string{"The quick ", "brown fox jumps."}, "x jumps."},
+		{"repeated sentence", "First point. Second point is long.", []string{"\n\nSecond point is long. Third."}, " Third."},
+		{"short coincidence kept", "an apple", []string{"apple pie"}, "apple pie"},
+		{"ends before the tail is matched", "a long sentence that was cut", []string{"was cut"}, "was cut"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trim := newOverlapTrimmer(tt.before)
+			var got strings.Builder
+			for _, delta := range tt.deltas {
+				got.WriteString(trim.feed(delta))
+			}
+			got.WriteString(trim.flush())
+			if got.String() != tt.want {
+				t.Errorf("shown %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package agent
+
+// Batch runs.
+// alayacore --batch runs the prompts of a file one after another in one
+// session, each seeing the answers to those before it, as if they were typed
+// in turn, so summarizing and context recovery apply as usual. A prompt that
+// fails stops the batch unless continueOnError is set; the prompts after it
+// are skipped.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// BatchSeparator is the line between two prompts of a batch file.
+const BatchSeparator = "---"
+
+// Statuses of a PromptResult.
+const (
+	PromptDone     = "done"
+	PromptFailed   = "failed"
+	PromptCanceled = "canceled"
+	PromptSkipped  = "skipped" // not run, as an earlier prompt failed
+)
+
+// batchPromptLen bounds the prompt excerpt in the batch summary.
+const batchPromptLen = 40
+
+// PromptResult is the outcome of a prompt run with RunPrompt. It is
+// marshaled with the duration in seconds, as "seconds".
+type PromptResult struct {
+	Prompt   string        `json:"prompt"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+	Tokens   int64         `json:"tokens"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r PromptResult) MarshalJSON() ([]byte, error) {
+	type plain PromptResult
+	return json.Marshal(struct {
+		plain
+		Seconds float64 `json:"seconds"`
+	}{plain(r), r.Duration.Seconds()})
+}
+
+// SplitBatch splits the text of a batch file into its prompts, which are
+// separated by lines holding only BatchSeparator. Empty prompts are dropped.
+func SplitBatch(text string) []string {
+	var prompts, lines []string
+	flush := func() {
+		if prompt := strings.TrimSpace(strings.Join(lines, "\n")); prompt != "" {
+			prompts = append(prompts, prompt)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == BatchSeparator {
+			flush()
+			continue
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+	flush()
+	return prompts
+}
+
+// RunBatch runs prompts one after another with RunPrompt. After a prompt
+// that does not finish, the rest are skipped unless continueOnError is set.
+func (s *Session) RunBatch(prompts []string, continueOnError bool) []PromptResult {
+	results := make([]PromptResult, 0, len(prompts))
+	failed := false
+	for _, prompt := range prompts {
+		if failed && !continueOnError {
+			results = append(results, PromptResult{Prompt: prompt, Status: PromptSkipped})
+			continue
+		}
+		result := s.RunPrompt(prompt)
+		failed = failed || result.Status != PromptDone
+		results = append(results, result)
+	}
+	return results
+}
+
+// RunPrompt queues text like a typed prompt and waits until it has run. The
+// session's input must stay open meanwhile, or the prompt may never start.
+func (s *Session) RunPrompt(text string) PromptResult {
+	done := make(chan PromptResult, 1)
+	if !s.enqueue(UserPrompt{Text: text, Images: s.takeAttachments(), done: done}, false) {
+		return PromptResult{Prompt: text, Status: PromptFailed, Error: "the session did not accept the prompt"}
+	}
+	select {
+	case result := <-done:
+		return result
+	case <-s.stopped:
+		select {
+		case result := <-done:
+			return result
+		default:
+			return PromptResult{Prompt: text, Status: PromptCanceled, Error: "the session stopped"}
+		}
+	}
+}
+
+// promptResult reports the prompt that just ran, from the failure it
+// recorded.
+func (s *Session) promptResult(prompt string, elapsed time.Duration, tokens int64) PromptResult {
+	s.mu.Lock()
+	failure := s.taskFailure
+	s.mu.Unlock()
+
+	result := PromptResult{Prompt: prompt, Status: PromptDone, Duration: elapsed, Tokens: tokens}
+	if failure != nil {
+		result.Status = PromptFailed
+		var runErr *domainerrors.RunError
+		if errors.As(failure, &runErr) && runErr.Class == domainerrors.ClassCanceled {
+			result.Status = PromptCanceled
+		}
+		result.Error = failure.Error()
+	}
+	return result
+}
+
+// FormatBatchSummary renders results as a table of the status, duration, and
+// tokens of each prompt, with the start of its first line.
+func FormatBatchSummary(results []PromptResult) string {
+	table := tools.Table{Columns: []string{"#", "status", "duration", "tokens", "prompt"}}
+	for i, r := range results {
+		duration, tokens := "-", "-"
+		if r.Status != PromptSkipped {
+			duration = fmt.Sprintf("%.1fs", r.Duration.Seconds())
+			tokens = formatTokenCount(r.Tokens)
+		}
+		line, _, cut := strings.Cut(r.Prompt, "\n")
+		if runes := []rune(line); len(runes) > batchPromptLen {
+			line, cut = string(runes[:batchPromptLen]), true
+		}
+		if cut {
+			line += "…"
+		}
+		table.Rows = append(table.Rows, []string{fmt.Sprint(i + 1), r.Status, duration, tokens, line})
+	}
+	return tools.FormatTable(table)
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// failOnProvider answers like echoProvider, but rejects the request when
+// the latest user message contains fail.
+type failOnProvider struct {
+	echoProvider
+	fail string
+}
+
+func (p *failOnProvider) StreamMessages(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition, system, model string) (<-chan llm.StreamEvent, error) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llm.RoleUser {
+			continue
+		}
+		for _, part := range messages[i].Content {
+			if text, ok := part.(llm.TextPart); ok && strings.Contains(text.Text, p.fail) {
+				return nil, errors.New("500 internal server error")
+			}
+		}
+		break
+	}
+	return p.echoProvider.StreamMessages(ctx, messages, tools, system, model)
+}
+
+// startBatchTestSession runs the task runner of a session answered by
+// provider until the test ends.
+func startBatchTestSession(t *testing.T, provider llm.Provider) *Session {
+	t.Helper()
+	session, _ := newSummarizeTestSession(t, provider)
+	session.Output = &lockedOutput{}
+	session.taskAvailable = make(chan struct{}, 1)
+	session.done = make(chan struct{})
+	session.stopped = make(chan struct{})
+	go session.taskRunner()
+	t.Cleanup(func() {
+		close(session.done)
+		session.Wait()
+	})
+	return session
+}
+
+func TestSplitBatch(t *testing.T) {
+	text := "summarize main.go\n---\n\nlist the TODOs\nin internal/\n --- \r\n---\nfix the first one\r\n---\n"
+	want := []string{"summarize main.go", "list the TODOs\nin internal/", "fix the first one"}
+	if got := SplitBatch(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitBatch() = %q, want %q", got, want)
+	}
+}
+
+func TestRunBatchStopsAtFailure(t *testing.T) {
+	provider := &failOnProvider{fail: "second"}
+	session := startBatchTestSession(t, provider)
+
+	results := session.RunBatch([]string{"first", "second", "third"}, false)
+
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.Status)
+	}
+	if want := []string{PromptDone, PromptFailed, PromptSkipped}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %q, want %q", statuses, want)
+	}
+	if results[0].Tokens != 12 || !strings.Contains(results[1].Error, "500 internal server error") {
+		t.Errorf("results = %+v", results)
+	}
+	if got := provider.calls.Load(); got != 1 {
+		t.Errorf("provider answered %d prompts, want 1", got)
+	}
+	if got := domainerrors.ExitCode(session.Err()); got != domainerrors.ExitError {
+		t.Errorf("exit code = %d, want %d", got, domainerrors.ExitError)
+	}
+}
+
+func TestRunBatchContinuesOnError(t *testing.T) {
+	provider := &failOnProvider{fail: "second"}
+	session := startBatchTestSession(t, provider)
+
+	results := session.RunBatch([]string{"first", "second", "third"}, true)
+
+	if results[1].Status != PromptFailed || results[2].Status != PromptDone {
+		t.Fatalf("results = %+v, want the third prompt run after the failure", results)
+	}
+	// Each prompt sees the ones before it
+	if got := len(session.Messages); got < 5 {
+		t.Errorf("history has %d messages, want the three prompts and two answers", got)
+	}
+}
+
+func TestFormatBatchSummary(t *testing.T) {
+	results := []PromptResult{
+		{Prompt: "summarize main.go", Status: PromptDone, Duration: 2100 * time.Millisecond, Tokens: 1234},
+		{Prompt: "list every TODO comment in the internal packages\nand group them", Status: PromptFailed, Duration: 300 * time.Millisecond},
+		{Prompt: "fix it", Status: PromptSkipped},
+	}
+	want := "#  status   duration  tokens  prompt\n" +
+		"1  done     2.1s      1.2k    summarize main.go\n" +
+		"2  failed   0.3s      0       list every TODO comment in the internal …\n" +
+		"3  skipped  -         -       fix it"
+	if got := FormatBatchSummary(results); got != want {
+		t.Errorf("FormatBatchSummary() =\n%s\nwant\n%s", got, want)
+	}
+
+	data, err := json.Marshal(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"prompt":"summarize main.go","status":"done","tokens":1234,"seconds":2.1}`; string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+// Session file changes.
+// write_file, edit_file, and replace_lines record every file they change in
+// the session's change set, which keeps the content each file had before the
+// session first touched it. ":diff" shows the unified diff from those
+// originals to what is on disk now, for every file or for one path, and
+// ":revert <path>" puts a file back as it was. The status bar counts the
+// files left changed. Both commands run as queued tasks, so they never race
+// a tool call that is writing.
+
+import (
+	"fmt"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+)
+
+// handleDiff shows the changes the session made to path, or to every file
+// when path is "".
+func (s *Session) handleDiff(path string) {
+	if path != "" {
+		path = s.resolvePath(path)
+	}
+	text, err := s.changes.Diff(path)
+	if err != nil {
+		s.writeError(domainerrors.Wrap("diff", err).Error())
+		return
+	}
+	if text == "" {
+		s.writeNotify("No file changes in this session")
+		return
+	}
+
+	var sb strings.Builder
+	if path == "" {
+		files := s.changes.Files()
+		fmt.Fprintf(&sb, "%s changed:", countFiles(len(files)))
+		for _, f := range files {
+			status := "M"
+			switch {
+			case f.New:
+				status = "A"
+			case f.Gone:
+				status = "D"
+			}
+			fmt.Fprintf(&sb, "\n %s %s", status, f.Path)
+		}
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(strings.TrimRight(text, "\n"))
+	s.writeNotify(sb.String())
+}
+
+// handleRevert restores path as it was before the session changed it.
+func (s *Session) handleRevert(path string) {
+	if path == "" {
+		s.writeError("usage: :revert <path>")
+		return
+	}
+	path = s.resolvePath(path)
+	if err := s.changes.Revert(path); err != nil {
+		s.writeError(domainerrors.Wrap("revert", err).Error())
+		return
+	}
+	s.writeNotifyf("Reverted %s", path)
+}
+
+// countFiles renders a file count: "1 file" or "3 files".
+func countFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
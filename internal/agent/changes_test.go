@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+func TestDiffAndRevertCommands(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	path := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	session, output := newSettingsTestSession()
+	session.initWorkdir()
+	session.changes = tools.NewChangeSet(session.sendSystemInfo)
+
+	session.handleCommandSync(context.Background(), "diff")
+	if !outputContains(output, "No file changes in this session") {
+		t.Errorf(":diff before any change = %q", output.Messages)
+	}
+
+	ctx := tools.WithWorkdir(tools.WithChangeSet(context.Background(), session.changes), session.workdir)
+	input, _ := json.Marshal(tools.WriteFileInput{Path: "notes.txt", Content: "two\n"})
+	if _, err := tools.NewWriteFileTool().Execute(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	if !outputContains(output, `"changed_files":1`) {
+		t.Errorf("the status was not updated: %q", output.Messages)
+	}
+
+	output.Messages = nil
+	session.handleCommandSync(context.Background(), "diff notes.txt")
+	if !outputContains(output, "-one") || !outputContains(output, "+two") {
+		t.Errorf(":diff notes.txt = %q", output.Messages)
+	}
+	session.handleCommandSync(context.Background(), "diff")
+	if !outputContains(output, "1 file changed:\n M "+path) {
+		t.Errorf(":diff = %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "revert notes.txt")
+	if data, _ := os.ReadFile(path); string(data) != "one\n" || !outputContains(output, "Reverted "+path) {
+		t.Errorf("after :revert the file holds %q, output %q", data, output.Messages)
+	}
+	session.handleCommandSync(context.Background(), "revert notes.txt")
+	if !outputContains(output, "no changes recorded for "+path) {
+		t.Errorf("a second :revert = %q", output.Messages)
+	}
+}
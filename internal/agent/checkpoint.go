@@ -0,0 +1,153 @@
+package agent
+
+// Conversation checkpoints.
+// :checkpoint <name> snapshots the message history, :rewind <name> restores
+// it, and :checkpoints lists them. Checkpoints live in memory for the life of
+// the session. All three run as queued tasks, so they never see the history
+// while a prompt is changing it.
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// checkpoint is a saved copy of the conversation.
+type checkpoint struct {
+	name          string
+	messages      []llm.Message
+	contextTokens int64
+	createdAt     time.Time
+}
+
+// cloneMessages deep-copies messages, so appending to or editing either copy
+// never shows up in the other.
+func cloneMessages(messages []llm.Message) []llm.Message {
+	cloned := make([]llm.Message, len(messages))
+	for i, msg := range messages {
+		cloned[i] = msg
+		cloned[i].Content = make([]llm.ContentPart, len(msg.Content))
+		for j, part := range msg.Content {
+			switch p := part.(type) {
+			case llm.ImagePart:
+				p.Data = bytes.Clone(p.Data)
+				part = p
+			case llm.ToolCallPart:
+				p.Input = bytes.Clone(p.Input)
+				part = p
+			}
+			cloned[i].Content[j] = part
+		}
+	}
+	return cloned
+}
+
+// isRewindCommand reports whether cmd is :rewind, which is refused rather
+// than queued while a task is running.
+func isRewindCommand(cmd string) bool {
+	return cmd == "rewind" || strings.HasPrefix(cmd, "rewind ")
+}
+
+// handleCheckpoint saves the conversation under a name, replacing an older
+// checkpoint of the same name.
+func (s *Session) handleCheckpoint(args []string) {
+	if len(args) != 1 {
+		s.writeError("usage: :checkpoint <name>")
+		return
+	}
+	cp := checkpoint{
+		name:          args[0],
+		messages:      cloneMessages(s.Messages),
+		contextTokens: s.ContextTokens,
+		createdAt:     time.Now(),
+	}
+
+	s.mu.Lock()
+	s.checkpoints = slices.DeleteFunc(s.checkpoints, func(c checkpoint) bool { return c.name == cp.name })
+	s.checkpoints = append(s.checkpoints, cp)
+	s.checkpoint = cp.name
+	s.checkpointDiverged = false
+	s.mu.Unlock()
+
+	s.writeNotifyf("Checkpoint %q saved (%d messages)", cp.name, len(cp.messages))
+	s.sendSystemInfo()
+}
+
+// handleRewind restores the conversation saved under a name, dropping
+// everything after it. The checkpoint is kept, so it can be rewound to again.
+func (s *Session) handleRewind(args []string) {
+	if len(args) != 1 {
+		s.writeError("usage: :rewind <name>")
+		return
+	}
+	s.mu.Lock()
+	idx := slices.IndexFunc(s.checkpoints, func(c checkpoint) bool { return c.name == args[0] })
+	var cp checkpoint
+	if idx >= 0 {
+		cp = s.checkpoints[idx]
+		s.checkpoint = cp.name
+		s.checkpointDiverged = false
+	}
+	s.mu.Unlock()
+	if idx < 0 {
+		s.writeError(fmt.Sprintf("no checkpoint named %q (see :checkpoints)", args[0]))
+		return
+	}
+
+	dropped := len(s.Messages) - len(cp.messages)
+	s.mu.Lock()
+	s.Messages = cloneMessages(cp.messages)
+	s.ContextTokens = cp.contextTokens
+	s.mu.Unlock()
+
+	msg := fmt.Sprintf("Rewound to checkpoint %q (%d messages)", cp.name, len(cp.messages))
+	if dropped > 0 {
+		msg += fmt.Sprintf(", dropped %d", dropped)
+	}
+	s.writeNotify(msg)
+	s.sendSystemInfo()
+}
+
+// handleCheckpoints lists the checkpoints, oldest first.
+func (s *Session) handleCheckpoints() {
+	s.mu.Lock()
+	checkpoints := slices.Clone(s.checkpoints)
+	current := s.checkpoint
+	s.mu.Unlock()
+
+	if len(checkpoints) == 0 {
+		s.writeNotify("No checkpoints. Save one with :checkpoint <name>")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("Checkpoints:")
+	for _, cp := range checkpoints {
+		marker := " "
+		if cp.name == current {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "\n %s %s  %d messages, saved %s", marker, cp.name, len(cp.messages), cp.createdAt.Format("15:04:05"))
+	}
+	s.writeNotify(sb.String())
+}
+
+// updateCheckpointDivergence records whether the conversation has moved on
+// from the latest checkpoint. It runs after every task, on the task runner.
+func (s *Session) updateCheckpointDivergence() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkpoint == "" {
+		return
+	}
+	for _, cp := range s.checkpoints {
+		if cp.name == s.checkpoint {
+			// Stays diverged, even if :summarize happens to restore the length
+			s.checkpointDiverged = s.checkpointDiverged || len(s.Messages) != len(cp.messages)
+			return
+		}
+	}
+}
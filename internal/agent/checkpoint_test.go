@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestCheckpointIsDeepCopy(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	session.Messages = []llm.Message{
+		llm.NewUserMessage("first"),
+		{Role: llm.RoleAssistant, Content: []llm.ContentPart{
+			llm.ToolCallPart{Type: "tool_use", ToolCallID: "c1", ToolName: "read_file", Input: json.RawMessage(`{"path":"a"}`)},
+		}},
+	}
+	session.ContextTokens = 120
+
+	session.handleCheckpoint([]string{"start"})
+
+	// Mutate the live history in place and append to it
+	session.Messages[0].Content[0] = llm.TextPart{Type: "text", Text: "changed"}
+	session.Messages[1].Content[0].(llm.ToolCallPart).Input[2] = 'X'
+	session.Messages = append(session.Messages, llm.NewUserMessage("later"))
+	session.ContextTokens = 500
+
+	cp := session.checkpoints[0]
+	if len(cp.messages) != 2 {
+		t.Fatalf("checkpoint has %d messages, want 2", len(cp.messages))
+	}
+	if text := cp.messages[0].Content[0].(llm.TextPart).Text; text != "first" {
+		t.Errorf("checkpoint text = %q, want %q", text, "first")
+	}
+	if input := string(cp.messages[1].Content[0].(llm.ToolCallPart).Input); input != `{"path":"a"}` {
+		t.Errorf("checkpoint tool input = %s", input)
+	}
+
+	session.handleRewind([]string{"start"})
+	if len(session.Messages) != 2 || session.ContextTokens != 120 {
+		t.Fatalf("after rewind: %d messages, %d context tokens; want 2, 120", len(session.Messages), session.ContextTokens)
+	}
+
+	// Changing the rewound history must not change the checkpoint either
+	session.Messages[0].Content[0] = llm.TextPart{Type: "text", Text: "again"}
+	session.handleRewind([]string{"start"})
+	if text := session.Messages[0].Content[0].(llm.TextPart).Text; text != "first" {
+		t.Errorf("second rewind text = %q, want %q", text, "first")
+	}
+}
+
+func TestCheckpointDivergence(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	session.Messages = []llm.Message{llm.NewUserMessage("first")}
+	session.handleCheckpoint([]string{"a"})
+
+	session.updateCheckpointDivergence()
+	if session.checkpointDiverged {
+		t.Error("diverged without any change")
+	}
+
+	session.Messages = append(session.Messages, llm.NewUserMessage("second"))
+	session.updateCheckpointDivergence()
+	if !session.checkpointDiverged {
+		t.Error("not diverged after a new message")
+	}
+
+	session.handleRewind([]string{"a"})
+	if session.checkpointDiverged || session.checkpoint != "a" {
+		t.Errorf("after rewind: checkpoint %q, diverged %v", session.checkpoint, session.checkpointDiverged)
+	}
+}
+
+func TestRewindUnknownCheckpoint(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.handleRewind([]string{"missing"})
+	if !outputContains(output, `no checkpoint named "missing"`) {
+		t.Errorf("expected an error, got %v", output.Messages)
+	}
+}
+
+func TestRewindRejectedWhileBusy(t *testing.T) {
+	input := stream.NewChanInput(10)
+	output := &MockOutput{}
+	session := &Session{
+		Input:         input,
+		Output:        output,
+		taskQueue:     make([]QueueItem, 0),
+		taskAvailable: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		inProgress:    true,
+	}
+
+	_ = input.EmitTLV(stream.TagTextUser, ":rewind start")
+	_ = input.EmitTLV(stream.TagTextUser, ":checkpoint later")
+	input.Close()
+	session.readFromInput()
+
+	if !outputContains(output, "Cannot rewind while a task is running") {
+		t.Errorf("expected the rewind to be refused, got %v", output.Messages)
+	}
+	if len(session.taskQueue) != 1 {
+		t.Fatalf("queued %d tasks, want only the checkpoint", len(session.taskQueue))
+	}
+	if cmd, ok := session.taskQueue[0].Task.(CommandPrompt); !ok || cmd.Command != "checkpoint later" {
+		t.Errorf("queued task = %#v", session.taskQueue[0].Task)
+	}
+}
@@ -0,0 +1,25 @@
+package agent
+
+// Clearing the conversation.
+// :clear starts the conversation over without leaving the session: the
+// settings, the queue, the checkpoints, and the tokens spent so far are kept.
+// It runs as a queued task, like :summarize, so a running prompt finishes
+// first. A TagClear frame tells the adaptors to clear their display, so the
+// screen shows only what the model will see.
+
+import (
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// handleClear drops every message and the context size of the last request.
+func (s *Session) handleClear() {
+	s.mu.Lock()
+	dropped := len(s.Messages)
+	s.Messages = nil
+	s.ContextTokens = 0
+	s.mu.Unlock()
+
+	s.writeGapped(stream.TagClear, "")
+	s.writeNotifyf("Conversation cleared, dropped %d messages", dropped)
+	s.sendSystemInfo()
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestClearResetsHistoryAndKeepsTotals(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.Messages = conversation()
+	session.ContextTokens = 1200
+	session.TotalSpent = llm.Usage{InputTokens: 5000, OutputTokens: 800}
+
+	session.handleCommandSync(context.Background(), "clear")
+
+	if len(session.Messages) != 0 {
+		t.Errorf("%d messages left after :clear", len(session.Messages))
+	}
+	if session.ContextTokens != 0 {
+		t.Errorf("context tokens = %d, want 0", session.ContextTokens)
+	}
+	if session.TotalSpent.InputTokens != 5000 || session.TotalSpent.OutputTokens != 800 {
+		t.Errorf("total spent = %+v, want it kept", session.TotalSpent)
+	}
+
+	clear := slices.Index(output.Messages, string(stream.EncodeTLV(stream.TagClear, "")))
+	if clear < 0 {
+		t.Fatalf("no TagClear frame, got %q", output.Messages)
+	}
+	notice := slices.Index(output.Messages, string(stream.EncodeTLV(stream.TagSystemNotify, "Conversation cleared, dropped 8 messages")))
+	if notice < clear {
+		t.Errorf("the notice should follow TagClear, so the cleared display keeps it; got %q", output.Messages)
+	}
+}
+
+func TestClearIsQueuedWhileBusy(t *testing.T) {
+	input := stream.NewChanInput(1)
+	session, _ := newSettingsTestSession()
+	session.Input = input
+	session.Messages = conversation()
+	session.inProgress = true
+	session.taskAvailable = make(chan struct{}, 1)
+	session.done = make(chan struct{})
+
+	_ = input.EmitTLV(stream.TagTextUser, ":clear") //nolint:errcheck // buffered channel
+	_ = input.Close()                               //nolint:errcheck // ends readFromInput
+	session.readFromInput()
+
+	if len(session.Messages) == 0 {
+		t.Error(":clear ran while a task was in progress")
+	}
+	items := session.GetQueueItems()
+	if len(items) != 1 || describeTask(items[0].Task) != ":clear" {
+		t.Errorf("queue = %+v, want :clear waiting", items)
+	}
+}
@@ -0,0 +1,99 @@
+package agent
+
+// Command policy.
+// posix_shell consults the tools.CommandPolicy of --command-rule before
+// each command: allow runs it, deny refuses it with an error to the model,
+// and ask shows it for review like a file change (see review.go), running it
+// once the user accepts. ":policy" lists the rules and adds rules of the
+// session's own, which posix_shell finds in the context of the session's
+// requests. A command gets the stricter verdict of the two sets, so a client
+// can tighten the operator's rules but never remove or loosen them.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+const policyUsage = "usage: :policy [add <rule> | insert <n> <rule> | remove <n>], a rule being ACTION NAME [REGEX], e.g. \"deny sudo\""
+
+// handlePolicy lists the command rules or changes the session's. args is the
+// text after ":policy", as a rule's regex may hold spaces.
+func (s *Session) handlePolicy(args string) {
+	s.mu.Lock()
+	p, own := s.commandPolicy, s.commandRules
+	s.mu.Unlock()
+	if p == nil {
+		s.writeError(domainerrors.NewSessionErrorf("policy", "no command policy in this session").Error())
+		return
+	}
+
+	sub, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+	switch sub {
+	case "", "list":
+		s.listPolicy(p, own)
+	case "add":
+		rule, err := tools.ParseCommandRule(rest)
+		if err != nil {
+			s.writeError(err.Error())
+			return
+		}
+		own.Append(rule)
+		s.writeNotifyf("Added session rule %d: %s", len(own.Rules()), rule)
+	case "insert":
+		num, text, _ := strings.Cut(rest, " ")
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			s.writeError(policyUsage)
+			return
+		}
+		rule, err := tools.ParseCommandRule(text)
+		if err != nil {
+			s.writeError(err.Error())
+			return
+		}
+		if err := own.Insert(n, rule); err != nil {
+			s.writeError(domainerrors.Wrap("policy", err).Error())
+			return
+		}
+		s.writeNotifyf("Inserted session rule %d: %s", min(n, len(own.Rules())), rule)
+	case "remove":
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			s.writeError(policyUsage)
+			return
+		}
+		rule, err := own.Remove(n)
+		if err != nil {
+			s.writeError(domainerrors.Wrap("policy", err).Error())
+			return
+		}
+		s.writeNotifyf("Removed session rule %d: %s", n, rule)
+	default:
+		s.writeError(policyUsage)
+	}
+}
+
+func (s *Session) listPolicy(p, own *tools.CommandPolicy) {
+	rules, ownRules := p.Rules(), own.Rules()
+	if len(rules) == 0 && len(ownRules) == 0 {
+		s.writeNotify("No command rules: posix_shell runs every command (add one with :policy add)")
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Command rules (%d, from --command-rule), first match wins, unmatched commands run:", len(rules))
+	for i, rule := range rules {
+		fmt.Fprintf(&sb, "\n  %d. %s", i+1, rule)
+	}
+	if len(ownRules) > 0 {
+		fmt.Fprintf(&sb, "\nSession rules (%d), the stricter verdict wins:", len(ownRules))
+		for i, rule := range ownRules {
+			fmt.Fprintf(&sb, "\n  %d. %s", i+1, rule)
+		}
+	}
+	s.writeNotify(sb.String())
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+func TestPolicyCommand(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleCommandSync(context.Background(), "policy")
+	if !outputContains(output, "no command policy") {
+		t.Fatalf("expected an error without a policy, got %q", output.Messages)
+	}
+
+	policy, err := tools.NewCommandPolicy([]string{"deny sudo", "allow *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.commandPolicy, session.commandRules = policy, &tools.CommandPolicy{}
+
+	session.handleCommandSync(context.Background(), "policy add deny *")
+	session.handleCommandSync(context.Background(), "policy insert 1 allow git status|log")
+	session.handleCommandSync(context.Background(), "policy add ask make")
+	session.handleCommandSync(context.Background(), "policy")
+	if !outputContains(output, "Inserted session rule 1: allow git status|log") ||
+		!outputContains(output, "1. deny sudo\n  2. allow *\nSession rules (3), the stricter verdict wins:\n  1. allow git status|log\n  2. deny *\n  3. ask make") {
+		t.Fatalf("rules not listed, got %q", output.Messages)
+	}
+	if len(policy.Rules()) != 2 {
+		t.Errorf("the operator's rules changed: %v", policy.Rules())
+	}
+
+	session.handleCommandSync(context.Background(), "policy remove 2")
+	session.handleCommandSync(context.Background(), "policy remove 7")
+	session.handleCommandSync(context.Background(), "policy add block rm")
+	if !outputContains(output, "Removed session rule 2: deny *") || !outputContains(output, "no rule 7") || !outputContains(output, "the action must be allow, deny, or ask") {
+		t.Errorf("got %q", output.Messages)
+	}
+	if len(session.commandRules.Rules()) != 2 || len(policy.Rules()) != 2 {
+		t.Errorf("session rules = %v, operator rules = %v", session.commandRules.Rules(), policy.Rules())
+	}
+
+	// Another session sharing the operator's policy has no session rules
+	other, otherOutput := newSettingsTestSession()
+	other.commandPolicy, other.commandRules = policy, &tools.CommandPolicy{}
+	other.handleCommandSync(context.Background(), "policy")
+	if outputContains(otherOutput, "Session rules") {
+		t.Errorf("session rules leaked: %q", otherOutput.Messages)
+	}
+}
@@ -60,7 +60,16 @@ func init() {
 	// Session management commands
 	commandRegistry.Register(&Command{
 		Name:        "summarize",
-		Description: "Summarize the conversation to reduce context",
+		Description: "Summarize the conversation to reduce context, keeping the last n messages (default 4)",
+		Usage:       "[n]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "clear",
+		Description: "Start the conversation over, keeping the session and its token totals",
 		Usage:       "",
 		Handler: func(_ context.Context, _ []string) {
 			// Handler is resolved at runtime via Session method
@@ -94,6 +103,33 @@ func init() {
 		},
 	})
 
+	commandRegistry.Register(&Command{
+		Name:        "checkpoint",
+		Description: "Save the conversation under a name",
+		Usage:       "<name>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "rewind",
+		Description: "Restore the conversation saved by :checkpoint, dropping later messages",
+		Usage:       "<name>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "checkpoints",
+		Description: "List the saved checkpoints",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
 	// Model commands
 	commandRegistry.Register(&Command{
 		Name:        "model_set",
@@ -131,6 +167,229 @@ func init() {
 			// Handler is resolved at runtime via Session method
 		},
 	})
+
+	// Settings commands
+	commandRegistry.Register(&Command{
+		Name:        "set",
+		Description: "Change sampling settings for subsequent prompts",
+		Usage:       "<key>=<value> ...",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "settings",
+		Description: "Show the active model and sampling settings",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "reasoning",
+		Description: "Show or toggle display of model reasoning",
+		Usage:       "[on|off]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "time",
+		Description: "Show or hide the time of each prompt and tool call",
+		Usage:       "[on|off]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "attach",
+		Description: "Attach an image to the next prompt",
+		Usage:       "<path>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "clear_plan",
+		Description: "Empty the plan kept by the manage_todo tool",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "models",
+		Description: "List the active provider's models, or switch to one",
+		Usage:       "[name]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "retry",
+		Description: "Send the last prompt again",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "stats",
+		Description: "Show tool call counts, durations, and failure rates",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// Saved prompt commands
+	commandRegistry.Register(&Command{
+		Name:        "prompt",
+		Description: "Expand a saved prompt and submit it",
+		Usage:       "<name> [args...]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "prompts",
+		Description: "List the saved prompts",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// File change review commands
+	commandRegistry.Register(&Command{
+		Name:        "review_accept",
+		Description: "Accept a file change awaiting review (--review-edits)",
+		Usage:       "<id>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "review_reject",
+		Description: "Reject a file change awaiting review, telling the model why",
+		Usage:       "<id> [reason]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "review_mask",
+		Description: "Send a prompt held for possible secrets with the secrets masked (--secret-scan)",
+		Usage:       "<id>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "steer",
+		Description: "Guide the running prompt without canceling it, or send the text ahead of the queue",
+		Usage:       "<text>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "dryrun",
+		Description: "Describe commands and file changes instead of making them",
+		Usage:       "[on|off]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "system",
+		Description: "List the standing instructions added to the system prompt, or add or remove one",
+		Usage:       "[list|add <text>|remove <n>]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "policy",
+		Description: "List the command rules of posix_shell, or add, insert, or remove one of the session's",
+		Usage:       "[add <rule>|insert <n> <rule>|remove <n>]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// Working directory commands
+	commandRegistry.Register(&Command{
+		Name:        "cd",
+		Description: "Change the session's working directory for the file tools, posix_shell, and git",
+		Usage:       "[path]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "pwd",
+		Description: "Show the session's working directory",
+		Usage:       "",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// File change commands
+	commandRegistry.Register(&Command{
+		Name:        "diff",
+		Description: "Show the changes the session made to files, or to one file",
+		Usage:       "[path]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	commandRegistry.Register(&Command{
+		Name:        "revert",
+		Description: "Restore a file as it was before the session changed it",
+		Usage:       "<path>",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// Skill commands
+	commandRegistry.Register(&Command{
+		Name:        "skills",
+		Description: "Show the active skill, deactivate it, or reload the skills",
+		Usage:       "[deactivate|reload]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
+
+	// Memory commands
+	commandRegistry.Register(&Command{
+		Name:        "memory",
+		Description: "List the persistent memory of --memory, or forget an entry",
+		Usage:       "[list|forget <key>]",
+		Handler: func(_ context.Context, _ []string) {
+			// Handler is resolved at runtime via Session method
+		},
+	})
 }
 
 // GetCommandRegistry returns the global command registry
@@ -158,13 +417,21 @@ func (s *Session) dispatchCommand(ctx context.Context, cmd string) bool {
 	// Dispatch to the handler methods (defined in session.go)
 	switch commandName {
 	case "summarize":
-		s.summarize(ctx)
+		s.handleSummarize(ctx, args)
+	case "clear":
+		s.handleClear()
 	case "cancel":
 		s.cancelTask()
 	case "cancel_all":
-		s.cancelAllTasks()
+		s.CancelAll()
 	case "save":
 		s.saveSession(args)
+	case "checkpoint":
+		s.handleCheckpoint(args)
+	case "rewind":
+		s.handleRewind(args)
+	case "checkpoints":
+		s.handleCheckpoints()
 	case "model_set":
 		s.handleModelSet(args)
 	case "model_load":
@@ -173,6 +440,54 @@ func (s *Session) dispatchCommand(ctx context.Context, cmd string) bool {
 		s.handleTaskQueueGetAll()
 	case "taskqueue_del":
 		s.handleTaskQueueDel(args)
+	case "set":
+		s.handleSet(args)
+	case "settings":
+		s.handleSettings()
+	case "reasoning":
+		s.handleReasoning(args)
+	case "time":
+		s.handleTime(args)
+	case "attach":
+		s.handleAttach(args)
+	case "skills":
+		s.handleSkills(args)
+	case "memory":
+		s.handleMemory(args)
+	case "clear_plan":
+		s.handleClearPlan()
+	case "prompt":
+		s.handlePrompt(args)
+	case "prompts":
+		s.handlePrompts()
+	case "retry":
+		s.handleRetry()
+	case "stats":
+		s.handleStats()
+	case "models":
+		s.handleModels(ctx, args)
+	case "review_accept":
+		s.handleReviewDecision(args, ReviewAccepted)
+	case "review_reject":
+		s.handleReviewDecision(args, ReviewRejected)
+	case "review_mask":
+		s.handleReviewDecision(args, ReviewMasked)
+	case "steer":
+		s.handleSteer(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
+	case "cd":
+		s.handleCd(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
+	case "pwd":
+		s.handlePwd()
+	case "diff":
+		s.handleDiff(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
+	case "revert":
+		s.handleRevert(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
+	case "dryrun":
+		s.handleDryRun(args)
+	case "system":
+		s.handleSystem(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
+	case "policy":
+		s.handlePolicy(strings.TrimSpace(strings.TrimPrefix(cmd, commandName)))
 	}
 
 	return true
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestCheckContextEstimateWarnsNearWindow(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.ContextLimit = 1000
+	session.contextWarning = 0.8
+	session.Messages = []llm.Message{llm.NewUserMessage(strings.Repeat("x", 3500))}
+
+	session.checkContextEstimate()
+
+	if session.estimatedTokens < 1000 {
+		t.Errorf("estimatedTokens = %d, want at least 1000", session.estimatedTokens)
+	}
+	if !outputContains(output, "Warning: this request is an estimated") {
+		t.Errorf("expected a context warning, got %v", output.Messages)
+	}
+	if !outputContains(output, `"estimated":`) {
+		t.Errorf("expected the estimate in system info, got %v", output.Messages)
+	}
+}
+
+func TestCheckContextEstimateQuietBelowThreshold(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.ContextLimit = 100000
+	session.contextWarning = 0.8
+	session.Messages = []llm.Message{llm.NewUserMessage("hello")}
+
+	session.checkContextEstimate()
+
+	if outputContains(output, "Warning:") {
+		t.Errorf("unexpected warning: %v", output.Messages)
+	}
+}
+
+func TestCheckContextEstimateDisabled(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.ContextLimit = 10
+	session.Messages = []llm.Message{llm.NewUserMessage(strings.Repeat("x", 1000))}
+
+	session.checkContextEstimate()
+
+	if outputContains(output, "Warning:") {
+		t.Errorf("a zero --context-warning should disable the warning: %v", output.Messages)
+	}
+}
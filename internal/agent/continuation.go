@@ -0,0 +1,60 @@
+package agent
+
+// Continuing interrupted responses.
+// When the connection drops mid-response, the text already streamed is kept:
+// it goes into the history as an assistant message, followed by a request to
+// continue from where it stopped. The continuation reuses the stream IDs of
+// the interrupted step, so the adaptors append it to the message already on
+// screen instead of starting a new one. After maxContinuations failed
+// attempts the continuation messages are removed again and the error is
+// reported.
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+)
+
+// maxContinuations caps the continuation requests for one interrupted
+// response.
+const maxContinuations = 2
+
+// continuePrompt asks the model to pick up an interrupted response.
+const continuePrompt = "Your previous response was cut off by a network error. " +
+	"Continue exactly from where you left off, without repeating anything you already wrote " +
+	"and without commenting on the interruption."
+
+// streamPosition identifies the displayed message a prompt streams into:
+// assistant text is sent with the stream ID [:prompt-step-t:].
+type streamPosition struct {
+	prompt    uint64
+	step      int
+	started   bool
+	truncated bool            // the last reply stopped at the output token limit
+	trim      *overlapTrimmer // drops what a continuation repeats (see autocontinue.go)
+}
+
+// continueInterrupted retries a response that err cut off after partial was
+// streamed, continuing it at pos. It returns the text and error of the last
+// attempt; err is returned unchanged when it is not an interruption or
+// nothing was streamed.
+func (s *Session) continueInterrupted(ctx context.Context, pos *streamPosition, partial string, err error) (string, error) {
+	start := len(s.Messages)
+	for attempt := 1; attempt <= maxContinuations; attempt++ {
+		if err == nil || ctx.Err() != nil || !providers.IsInterruptedStreamError(err) || strings.TrimSpace(partial) == "" {
+			break
+		}
+		s.writeVerbosef("response interrupted after %d bytes (%v); continuing, attempt %d of %d", len(partial), err, attempt, maxContinuations)
+		s.appendMessages(
+			llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: partial}}),
+			llm.NewUserMessage(continuePrompt),
+		)
+		_, partial, err = s.processPrompt(ctx, pos, s.Messages)
+	}
+	if err != nil && ctx.Err() == nil && len(s.Messages) > start {
+		s.setMessages(s.Messages[:start])
+	}
+	return partial, err
+}
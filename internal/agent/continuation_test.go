@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// interruptingProvider streams "Hello wor" and drops the connection for its
+// first failures requests, then answers "ld!".
+type interruptingProvider struct {
+	failures int32
+	calls    atomic.Int32
+}
+
+func (p *interruptingProvider) StreamMessages(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	events := make(chan llm.StreamEvent, 2)
+	if p.calls.Add(1) <= p.failures {
+		events <- llm.TextDeltaEvent{Delta: "Hello wor"}
+		events <- llm.StreamErrorEvent{Error: io.ErrUnexpectedEOF}
+	} else {
+		events <- llm.TextDeltaEvent{Delta: "ld!"}
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "ld!"}})},
+		}
+	}
+	close(events)
+	return events, nil
+}
+
+func TestInterruptedResponseContinues(t *testing.T) {
+	provider := &interruptingProvider{failures: 1}
+	session, output := newSummarizeTestSession(t, provider)
+
+	session.handleUserPrompt(context.Background(), "greet", nil)
+
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+	// Both parts go to the same displayed message
+	if !outputContains(output, "[:0-1-t:]Hello wor") || !outputContains(output, "[:0-1-t:]ld!") {
+		t.Errorf("expected both parts under one stream ID, got %q", output.Messages)
+	}
+	if outputContains(output, "unexpected EOF") {
+		t.Errorf("a continued response should not report the error, got %q", output.Messages)
+	}
+
+	roles := make([]string, 0, len(session.Messages))
+	for _, msg := range session.Messages {
+		roles = append(roles, string(msg.Role))
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,user,assistant" {
+		t.Fatalf("history roles = %s", got)
+	}
+	if got := lastAssistantText(session.Messages[1:2]); got != "Hello wor" {
+		t.Errorf("partial text in history = %q", got)
+	}
+	if got := lastAssistantText(session.Messages); got != "ld!" {
+		t.Errorf("continuation in history = %q", got)
+	}
+}
+
+func TestInterruptedResponseGivesUp(t *testing.T) {
+	provider := &interruptingProvider{failures: 100}
+	session, output := newSummarizeTestSession(t, provider)
+
+	session.handleUserPrompt(context.Background(), "greet", nil)
+
+	if got := provider.calls.Load(); got != 1+maxContinuations {
+		t.Errorf("provider called %d times, want %d", got, 1+maxContinuations)
+	}
+	if !outputContains(output, "unexpected EOF") {
+		t.Errorf("expected the error once the continuations ran out, got %q", output.Messages)
+	}
+	// The failed turn is left as it was before any continuation
+	if len(session.Messages) != 1 || session.Messages[0].Role != llm.RoleUser {
+		t.Errorf("history = %+v, want only the prompt", session.Messages)
+	}
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newCostTestSession(model string) (*Session, *MockOutput) {
+	session, output := newSettingsTestSession()
+	session.ModelManager = NewModelManager("")
+	session.ModelManager.models = []ModelConfig{{ID: 1, Name: model, ModelName: model}}
+	session.ModelManager.activeID = 1
+	session.prices = providers.Prices{"priced": {Input: 2, Output: 10, CacheRead: 0.5}}
+	return session, output
+}
+
+// lastSystemInfo returns the last SystemInfo frame written to output.
+func lastSystemInfo(t *testing.T, output *MockOutput) SystemInfo {
+	t.Helper()
+	for i := len(output.Messages) - 1; i >= 0; i-- {
+		tag, value, err := stream.ReadTLV(strings.NewReader(output.Messages[i]))
+		if err != nil || tag != stream.TagSystemData {
+			continue
+		}
+		var info SystemInfo
+		if err := json.Unmarshal([]byte(value), &info); err == nil {
+			return info
+		}
+	}
+	t.Fatal("no system info written")
+	return SystemInfo{}
+}
+
+func TestCostAccumulatesAcrossTurns(t *testing.T) {
+	session, output := newCostTestSession("priced-model")
+
+	turns := []llm.Usage{
+		{InputTokens: 100_000, OutputTokens: 1_000},
+		{InputTokens: 20_000, OutputTokens: 30_000},
+		{InputTokens: 400_000, OutputTokens: 0, CacheReadTokens: 300_000},
+	}
+	// 0.2 + 0.01, 0.04 + 0.3, 0.2 + 0.15
+	want := 0.21 + 0.34 + 0.35
+	for _, usage := range turns {
+		session.trackUsage(usage)
+	}
+
+	cost, known := session.totalCostUSD()
+	if !known || math.Abs(cost-want) > 1e-9 {
+		t.Errorf("total cost = %v, %v; want %v", cost, known, want)
+	}
+	info := lastSystemInfo(t, output)
+	if info.Cost == nil || math.Abs(*info.Cost-want) > 1e-9 {
+		t.Errorf("SystemInfo cost = %v, want %v", info.Cost, want)
+	}
+}
+
+func TestCostUnknownForUnpricedModel(t *testing.T) {
+	session, output := newCostTestSession("local-model")
+	session.trackUsage(llm.Usage{InputTokens: 1000, OutputTokens: 200})
+
+	if _, known := session.totalCostUSD(); known {
+		t.Error("cost of an unpriced model should be unknown")
+	}
+	if info := lastSystemInfo(t, output); info.Cost != nil {
+		t.Errorf("SystemInfo cost = %v, want none", *info.Cost)
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	tests := []struct {
+		cost  float64
+		known bool
+		want  string
+	}{
+		{0.42, true, "$0.42"},
+		{12.345, true, "$12.35"},
+		{0.0031, true, "$0.0031"},
+		{0, true, "$0.00"},
+		{1.5, false, "cost: n/a"},
+	}
+	for _, tt := range tests {
+		if got := FormatCost(tt.cost, tt.known); got != tt.want {
+			t.Errorf("FormatCost(%v, %v) = %q, want %q", tt.cost, tt.known, got, tt.want)
+		}
+	}
+}
@@ -46,6 +46,6 @@
 //
 //	input := stream.NewChanInput(10)
 //	output := &bufferOutput{}
-//	session := agent.NewSession(model, input, output, modelMgr, runtimeMgr)
+//	session := agent.NewSession(input, output, agent.SessionOptions{Tools: tools, SystemPrompt: prompt})
 //	go session.Run(ctx)
 package agent
@@ -0,0 +1,50 @@
+package agent
+
+// Dry-run mode.
+// With --dry-run or ":dryrun on", the tools wrapped by tools.WithDryRun
+// describe shell commands, git changes, and file changes instead of making
+// them. The mode is read when a task starts, so :dryrun, which is queued
+// like other commands, applies from the next prompt. The status bar and the
+// echoed prompt show it.
+
+import (
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+)
+
+// SetDryRun turns dry-run mode on or off, as --dry-run does at startup.
+func (s *Session) SetDryRun(on bool) {
+	s.mu.Lock()
+	s.dryRun = on
+	s.mu.Unlock()
+	s.sendSystemInfo()
+}
+
+// DryRun reports whether dry-run mode is on.
+func (s *Session) DryRun() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dryRun
+}
+
+func (s *Session) handleDryRun(args []string) {
+	if len(args) > 1 {
+		s.writeError("usage: :dryrun [on|off]")
+		return
+	}
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			s.SetDryRun(true)
+		case "off":
+			s.SetDryRun(false)
+		default:
+			s.writeError(domainerrors.NewSessionErrorf("dryrun", "expected on or off, got %q", args[0]).Error())
+			return
+		}
+	}
+	if s.DryRun() {
+		s.writeNotify("Dry run: on (commands and file changes are only described)")
+	} else {
+		s.writeNotify("Dry run: off")
+	}
+}
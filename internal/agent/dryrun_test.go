@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDryRunCommand(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleCommandSync(context.Background(), "dryrun on")
+	if !session.DryRun() || !outputContains(output, "Dry run: on") || !outputContains(output, `"dry_run":true`) {
+		t.Fatalf("dry run not on, got %q", output.Messages)
+	}
+	session.signalPromptStart(3, "clean up")
+	if !outputContains(output, "#3 ▸ [dry-run] clean up") {
+		t.Errorf("the echoed prompt does not show the mode, got %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "dryrun maybe")
+	if !outputContains(output, `expected on or off, got "maybe"`) || !session.DryRun() {
+		t.Errorf("a bad argument should keep the mode, got %q", output.Messages)
+	}
+
+	output.Messages = nil
+	session.handleCommandSync(context.Background(), "dryrun off")
+	session.signalPromptStart(4, "clean up")
+	if session.DryRun() || !outputContains(output, "Dry run: off") || !outputContains(output, "#4 ▸ clean up") {
+		t.Errorf("dry run not off, got %q", output.Messages)
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+// Prompt failures.
+// A session remembers the first prompt that failed, so a scripted run
+// (alayacore-web --stdio) can end with an exit code that tells why: the
+// provider or the agent failed, no model could be set up, or the prompt was
+// canceled. See domainerrors.ExitCode.
+
+import (
+	"context"
+	"log/slog"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+)
+
+// recordFailure remembers err, of the given class, unless a prompt failed
+// before. It is also kept as the failure of the running task.
+func (s *Session) recordFailure(class string, err error) {
+	level := slog.LevelError
+	if class == domainerrors.ClassCanceled {
+		level = slog.LevelInfo
+	}
+	s.log().Log(context.Background(), level, "prompt failed", "class", class, "err", err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runErr := domainerrors.NewRunError(class, err)
+	if s.failure == nil {
+		s.failure = runErr
+	}
+	s.taskFailure = runErr
+}
+
+// Err returns the first prompt failure of the session as a
+// *domainerrors.RunError, or nil when every prompt so far was answered.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failure
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// failingProvider rejects every request.
+type failingProvider struct{}
+
+func (failingProvider) StreamMessages(context.Context, []llm.Message, []llm.ToolDefinition, string, string) (<-chan llm.StreamEvent, error) {
+	return nil, errors.New("401 unauthorized")
+}
+
+func TestPromptFailureClasses(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		provider llm.Provider
+		ctx      context.Context
+		want     int
+	}{
+		{"answered", &echoProvider{}, context.Background(), domainerrors.ExitOK},
+		{"provider error", failingProvider{}, context.Background(), domainerrors.ExitError},
+		{"canceled", &partialProvider{}, canceled, domainerrors.ExitCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, _ := newSummarizeTestSession(t, tt.provider)
+			session.handleUserPrompt(tt.ctx, "hello", nil)
+
+			err := session.Err()
+			if got := domainerrors.ExitCode(err); got != tt.want {
+				t.Errorf("exit code = %d (%v), want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptWithoutModelIsConfigFailure(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	// Commands need no model and do not fail the run
+	session.runTask(QueueItem{ID: 1, Task: CommandPrompt{Command: "stats"}})
+	if err := session.Err(); err != nil {
+		t.Fatalf("a command failed the run: %v", err)
+	}
+
+	session.runTask(QueueItem{ID: 2, Task: UserPrompt{Text: "hello"}})
+	err := session.Err()
+	if got := domainerrors.ExitCode(err); got != domainerrors.ExitConfig {
+		t.Errorf("exit code = %d (%v), want %d", got, err, domainerrors.ExitConfig)
+	}
+	if !outputContains(output, "Model manager not initialized") {
+		t.Errorf("the error is not shown, got %q", output.Messages)
+	}
+}
+
+func TestFirstFailureIsKept(t *testing.T) {
+	session, _ := newSummarizeTestSession(t, failingProvider{})
+	session.handleUserPrompt(context.Background(), "first", nil)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	session.handleUserPrompt(canceled, "second", nil)
+
+	if got := domainerrors.ExitCode(session.Err()); got != domainerrors.ExitError {
+		t.Errorf("exit code = %d, want the first failure's %d", got, domainerrors.ExitError)
+	}
+}
@@ -0,0 +1,175 @@
+package agent
+
+// File references in prompts.
+// A prompt token such as @./build.log or @src/main.go is replaced by the
+// file's content in a fenced block labeled with the path, so a large file
+// never has to travel through the input as one huge frame. Tokens without a
+// "/" or "." (e.g. @alice) are left alone, and \@ writes a literal @. Files
+// over maxFileRefSize keep their head and tail. Paths resolve against the
+// session's working directory and obey the same path rules as read_file.
+// The expanded prompt is what goes into the history.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFileRefSize is how much of one referenced file is included: its first
+// and last halves when the file is larger.
+const maxFileRefSize = 64 << 10
+
+// fileRef is one expanded reference, for the notice shown with the prompt.
+type fileRef struct {
+	path      string
+	size      int // bytes in the file
+	included  int // bytes included
+	truncated bool
+}
+
+// String describes the reference, e.g. "@build.log: 64KB of 200KB (head and
+// tail)".
+func (r fileRef) String() string {
+	if !r.truncated {
+		return fmt.Sprintf("@%s: %s", r.path, formatSize(r.size))
+	}
+	return fmt.Sprintf("@%s: %s of %s (head and tail)", r.path, formatSize(r.included), formatSize(r.size))
+}
+
+// checkPath resolves path as read_file would and checks it against the path
+// policy.
+func (s *Session) checkPath(path string) (string, error) {
+	path = s.resolvePath(path)
+	s.mu.Lock()
+	policy := s.pathPolicy
+	s.mu.Unlock()
+	return path, policy.Check(path)
+}
+
+// readPromptFile reads the file of an @path reference.
+func (s *Session) readPromptFile(path string) ([]byte, error) {
+	path, err := s.checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// expandFileRefs replaces the @path references of prompt with the files'
+// content. readFile is readPromptFile outside tests. A missing or unreadable
+// file is an error naming it, and nothing is expanded.
+func expandFileRefs(prompt string, readFile func(string) ([]byte, error)) (string, []fileRef, error) {
+	if !strings.Contains(prompt, "@") {
+		return prompt, nil, nil
+	}
+	var sb strings.Builder
+	var refs []fileRef
+	for pos := 0; pos < len(prompt); {
+		i := strings.IndexByte(prompt[pos:], '@')
+		if i < 0 {
+			sb.WriteString(prompt[pos:])
+			break
+		}
+		i += pos
+		// \@ is a literal @
+		if i > 0 && prompt[i-1] == '\\' {
+			sb.WriteString(prompt[pos : i-1])
+			sb.WriteByte('@')
+			pos = i + 1
+			continue
+		}
+		sb.WriteString(prompt[pos:i])
+		token := prompt[i+1:]
+		if end := strings.IndexFunc(token, unicode.IsSpace); end >= 0 {
+			token = token[:end]
+		}
+		// Only at the start of a word, and only path-like words
+		before, _ := utf8.DecodeLastRuneInString(prompt[:i])
+		if (i > 0 && !unicode.IsSpace(before)) || !strings.ContainsAny(token, "/.") {
+			sb.WriteByte('@')
+			pos = i + 1
+			continue
+		}
+
+		path, trailing, data, err := readFileRef(token, readFile)
+		if err != nil {
+			return "", nil, err
+		}
+		ref := fileRef{path: path, size: len(data)}
+		var body string
+		body, ref.truncated = headAndTail(data, maxFileRefSize)
+		ref.included = len(body)
+		refs = append(refs, ref)
+		sb.WriteString(fencedFile(path, body))
+		sb.WriteString(trailing)
+		pos = i + 1 + len(token)
+	}
+	return sb.String(), refs, nil
+}
+
+// readFileRef reads the file of token. Punctuation after a path, as in
+// "see @build.log.", is retried without when the whole token is not a file,
+// and returned as trailing.
+func readFileRef(token string, readFile func(string) ([]byte, error)) (path, trailing string, data []byte, err error) {
+	path = token
+	for {
+		data, err = readFile(path)
+		if err == nil {
+			break
+		}
+		trimmed := strings.TrimRight(path, ".,;:!?)'\"")
+		if !errors.Is(err, os.ErrNotExist) || trimmed == path || trimmed == "" {
+			return "", "", nil, fmt.Errorf("@%s: %w", token, unwrapPathError(err))
+		}
+		path = trimmed
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", "", nil, fmt.Errorf("@%s: not a text file", path)
+	}
+	return path, token[len(path):], data, nil
+}
+
+// unwrapPathError drops the path from an *os.PathError, which the caller
+// already names.
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// headAndTail returns data, or when it is larger than limit its first and last
+// limit/2 bytes, cut at line breaks, around a note of what was left out.
+func headAndTail(data []byte, limit int) (string, bool) {
+	if len(data) <= limit {
+		return string(data), false
+	}
+	head := data[:limit/2]
+	if nl := bytes.LastIndexByte(head, '\n'); nl >= 0 {
+		head = head[:nl+1]
+	}
+	tail := data[len(data)-limit/2:]
+	if nl := bytes.IndexByte(tail, '\n'); nl >= 0 {
+		tail = tail[nl+1:]
+	}
+	omitted := len(data) - len(head) - len(tail)
+	return fmt.Sprintf("%s[... %d bytes omitted ...]\n%s", head, omitted, tail), true
+}
+
+// fencedFile wraps content in a code fence labeled with path, longer than
+// any backtick run in the content.
+func fencedFile(path, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fence + path + "\n" + content + fence
+}
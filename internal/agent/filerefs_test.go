@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// fakeFiles returns a readFile serving files from a map.
+func fakeFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+		return []byte(content), nil
+	}
+}
+
+func TestExpandFileRefs(t *testing.T) {
+	read := fakeFiles(map[string]string{
+		"./build.log": "line 1\nline 2\n",
+		"notes.md":    "has ``` fences",
+		"src/main.go": "package main",
+		"binary.bin":  "a\x00b",
+	})
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{"no reference", "plain prompt", "plain prompt"},
+		{"reference", "Why does @./build.log fail?", "Why does ```./build.log\nline 1\nline 2\n``` fail?"},
+		{"at start", "@src/main.go review", "```src/main.go\npackage main\n``` review"},
+		{"longer fence", "@notes.md", "````notes.md\nhas ``` fences\n````"},
+		{"trailing punctuation", "see @src/main.go.", "see ```src/main.go\npackage main\n```."},
+		{"escaped", `mail \@./build.log`, "mail @./build.log"},
+		{"mention", "ask @alice", "ask @alice"},
+		{"inside a word", "user@example.com", "user@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := expandFileRefs(tt.prompt, read)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for prompt, want := range map[string]string{
+		"read @./missing.log": "@./missing.log: file does not exist",
+		"read @binary.bin":    "@binary.bin: not a text file",
+	} {
+		if _, _, err := expandFileRefs(prompt, read); err == nil || err.Error() != want {
+			t.Errorf("%q: err = %v, want %q", prompt, err, want)
+		}
+	}
+}
+
+func TestExpandFileRefsTruncates(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; sb.Len() < 200<<10; i++ {
+		sb.WriteString(strings.Repeat("x", 99) + "\n")
+	}
+	big := sb.String()
+	got, refs, err := expandFileRefs("@big.log", fakeFiles(map[string]string{"big.log": big}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || !refs[0].truncated || refs[0].size != len(big) {
+		t.Fatalf("refs = %+v", refs)
+	}
+	if len(got) > maxFileRefSize+100 {
+		t.Errorf("expanded to %d bytes, over the %d cap", len(got), maxFileRefSize)
+	}
+	if !strings.Contains(got, "bytes omitted ...]\n") {
+		t.Error("missing the omission note")
+	}
+	// Cut at line breaks: every kept line is whole
+	body := strings.TrimSuffix(strings.TrimPrefix(got, "```big.log\n"), "```")
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if line != strings.Repeat("x", 99) && !strings.HasPrefix(line, "[... ") {
+			t.Fatalf("partial line %q", line)
+		}
+	}
+	if s := refs[0].String(); s != "@big.log: 64KB of 200KB (head and tail)" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestReadPromptFileFollowsSession(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"notes.txt": "notes\n", ".env": "TOKEN=x\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session, _ := newSettingsTestSession()
+	session.workdir = tools.NewWorkdir(dir)
+	session.pathPolicy = tools.NewPathPolicy(nil, []string{".env"}, false)
+
+	got, _, err := expandFileRefs("@./notes.txt", session.readPromptFile)
+	if err != nil || got != "```./notes.txt\nnotes\n```" {
+		t.Errorf("a file of the working directory: %q, %v", got, err)
+	}
+	if _, _, err := expandFileRefs("@./.env", session.readPromptFile); err == nil || !strings.Contains(err.Error(), `denied by path rule ".env"`) {
+		t.Errorf("a denied file: %v", err)
+	}
+}
+
+func TestPromptWithMissingFileIsNotSent(t *testing.T) {
+	provider := &echoProvider{}
+	session, output := newSummarizeTestSession(t, provider)
+	before := len(session.Messages)
+
+	session.handleUserPrompt(context.Background(), "summarize @./no-such-file.log", nil)
+
+	if provider.calls.Load() != 0 || len(session.Messages) != before {
+		t.Errorf("the prompt was sent: %d calls, history %+v", provider.calls.Load(), session.Messages)
+	}
+	if !outputContains(output, "@./no-such-file.log: ") || !outputContains(output, "the prompt was not sent") {
+		t.Errorf("missing error: %v", output.Messages)
+	}
+}
+
+func TestPromptRecordsExpandedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.log")
+	if err := os.WriteFile(path, []byte("FAIL: TestThing\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	session, output := newSummarizeTestSession(t, &echoProvider{})
+
+	session.handleUserPrompt(context.Background(), "why @"+path+"?", nil)
+
+	var sent string
+	for _, msg := range session.Messages {
+		if msg.Role == llm.RoleUser {
+			sent = msg.Content[0].(llm.TextPart).Text
+		}
+	}
+	if want := "why ```" + path + "\nFAIL: TestThing\n```?"; sent != want {
+		t.Errorf("history has %q, want %q", sent, want)
+	}
+	if !outputContains(output, "[included @"+path+": 1KB]") {
+		t.Errorf("missing inclusion notice: %v", output.Messages)
+	}
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/hooks"
+)
+
+func TestUserPromptRunsLifecycleHooks(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	provider := &summaryProvider{reply: "hi"}
+	session, _ := newSummarizeTestSession(t, provider)
+	session.hooks = &hooks.Hooks{Shell: "/bin/sh", Hooks: []hooks.Hook{
+		{Event: hooks.PromptStart, Command: `echo "start:$ALAYACORE_PROMPT" >> ` + log},
+		{Event: hooks.TurnEnd, Command: `echo "end:$ALAYACORE_STATUS" >> ` + log},
+	}}
+
+	session.handleUserPrompt(context.Background(), "hello", nil)
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "start:hello\nend:done\n"; got != want {
+		t.Errorf("hook log = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+// Persistent memory.
+// With --memory the model saves and searches facts with the save_memory and
+// search_memory tools, in a file shared by all sessions. ":memory list"
+// shows what it holds and ":memory forget <key>" removes an entry.
+
+import (
+	"fmt"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/memory"
+)
+
+// memoryPreviewLen is the number of characters of each entry :memory list
+// shows.
+const memoryPreviewLen = 80
+
+// handleMemory lists the saved memories or forgets one.
+func (s *Session) handleMemory(args []string) {
+	s.mu.Lock()
+	m := s.memory
+	s.mu.Unlock()
+	if m == nil {
+		s.writeError(domainerrors.NewSessionErrorf("memory", "memory is not enabled; start with --memory").Error())
+		return
+	}
+
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "list"):
+		s.listMemories(m)
+	case len(args) == 2 && args[0] == "forget":
+		forgot, err := m.Forget(args[1])
+		if err != nil {
+			s.writeError(err.Error())
+			return
+		}
+		if !forgot {
+			s.writeError(domainerrors.NewSessionErrorf("memory", "no memory %q", args[1]).Error())
+			return
+		}
+		s.writeNotifyf("Forgot memory %s", args[1])
+	default:
+		s.writeError("usage: :memory [list|forget <key>]")
+	}
+}
+
+func (s *Session) listMemories(m *memory.Manager) {
+	entries, err := m.List()
+	if err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		s.writeNotify("No memories saved in " + m.Path())
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Memories (%d):", len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "\n  %s  %s", e.Key, memoryPreview(e.Content))
+		if len(e.Tags) > 0 {
+			fmt.Fprintf(&sb, "  [%s]", strings.Join(e.Tags, ", "))
+		}
+	}
+	s.writeNotify(sb.String())
+}
+
+// memoryPreview returns the first line of content, cut to memoryPreviewLen
+// characters.
+func memoryPreview(content string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(content), "\n")
+	if runes := []rune(line); len(runes) > memoryPreviewLen {
+		line, more = string(runes[:memoryPreviewLen]), true
+	}
+	if more {
+		line += "…"
+	}
+	return line
+}
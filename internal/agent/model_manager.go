@@ -7,6 +7,7 @@ package agent
 // query/update methods and receives safe JSON-ready views via ModelInfo.
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,18 +15,21 @@ import (
 	"sync"
 
 	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/llm/factory"
 )
 
 // ModelConfig represents a model configuration
 type ModelConfig struct {
-	ID           int    `json:"id"`                                   // Runtime ID (generated, not persisted)
-	Name         string `json:"name" config:"name"`                   // Display name
-	ProtocolType string `json:"protocol_type" config:"protocol_type"` // "openai" or "anthropic"
-	BaseURL      string `json:"base_url" config:"base_url"`           // API server URL
-	APIKey       string `json:"api_key,omitempty" config:"api_key"`   // API key (omitted in JSON responses for security)
-	ModelName    string `json:"model_name" config:"model_name"`       // Model identifier
-	ContextLimit int    `json:"context_limit" config:"context_limit"` // Maximum context length (0 means unlimited)
-	PromptCache  bool   `json:"prompt_cache" config:"prompt_cache"`   // Enable prompt caching (adds cache_control for Anthropic)
+	ID           int    `json:"id"`                                         // Runtime ID (generated, not persisted)
+	Name         string `json:"name" config:"name"`                         // Display name
+	ProtocolType string `json:"protocol_type" config:"protocol_type"`       // One of factory.ProviderTypes
+	BaseURL      string `json:"base_url" config:"base_url"`                 // API server URL
+	APIKey       string `json:"api_key,omitempty" config:"api_key"`         // API key (omitted in JSON responses for security)
+	ModelName    string `json:"model_name" config:"model_name"`             // Model identifier
+	ContextLimit int    `json:"context_limit" config:"context_limit"`       // Maximum context length (0 means unlimited)
+	PromptCache  bool   `json:"prompt_cache" config:"prompt_cache"`         // Enable prompt caching (adds cache_control for Anthropic)
+	Deployment   string `json:"deployment,omitempty" config:"deployment"`   // Azure deployment name; defaults to model_name
+	APIVersion   string `json:"api_version,omitempty" config:"api_version"` // Azure api-version query parameter
 }
 
 // ModelInfo is the safe version for JSON responses (no API key)
@@ -65,18 +69,9 @@ context_limit: 128000
 // NewModelManager creates a new model manager
 // If configPath is empty, uses the default path (~/.alayacore/model.conf)
 func NewModelManager(configPath string) *ModelManager {
-	var path string
-	var err error
-
-	if configPath != "" {
-		// Use user-specified path
-		path = configPath
-	} else {
-		// Use default path
-		path, err = defaultModelsConfigFile()
-		if err != nil {
-			path = ""
-		}
+	path, err := ResolveModelConfigPath(configPath)
+	if err != nil {
+		path = ""
 	}
 
 	mm := &ModelManager{
@@ -89,6 +84,26 @@ func NewModelManager(configPath string) *ModelManager {
 	return mm
 }
 
+// ResolveModelConfigPath returns configPath, or the default model config path
+// (~/.alayacore/model.conf) when it is empty.
+func ResolveModelConfigPath(configPath string) (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	return defaultModelsConfigFile()
+}
+
+// NeedsModelSetup reports whether the model config at path does not exist yet
+// or holds no usable model, i.e. whether a first-run setup should be offered
+// before LoadFromFile falls back to the default config.
+func NeedsModelSetup(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return len(parseModelConfig(string(data))) == 0
+}
+
 // defaultModelsConfigFile returns the default path to the models configuration file
 func defaultModelsConfigFile() (string, error) {
 	home, err := os.UserHomeDir()
@@ -169,7 +184,9 @@ func (mm *ModelManager) createDefaultConfig(path string) error {
 	return os.WriteFile(path, []byte(DefaultModelConfig), 0600)
 }
 
-// parseModelConfig parses the key-value model config format
+// parseModelConfig parses the key-value model config format. An api_key may
+// reference environment variables as ${NAME}; an unset one leaves the key as
+// written, so the provider reports it.
 func parseModelConfig(content string) []ModelConfig {
 	var models []ModelConfig
 
@@ -184,6 +201,9 @@ func parseModelConfig(content string) []ModelConfig {
 
 		var model ModelConfig
 		config.ParseKeyValue(block, &model)
+		if expanded, err := config.ExpandEnv(model.APIKey, os.LookupEnv); err == nil {
+			model.APIKey = expanded
+		}
 		if model.Name != "" || model.ModelName != "" {
 			models = append(models, model)
 		}
@@ -192,6 +212,30 @@ func parseModelConfig(content string) []ModelConfig {
 	return models
 }
 
+// FormatModelConfig renders a model as a block of the model config format,
+// the inverse of parseModelConfig. Blocks are joined with "---" lines.
+func FormatModelConfig(m ModelConfig) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "name: \"%s\"\n", m.Name)
+	fmt.Fprintf(&sb, "protocol_type: \"%s\"\n", m.ProtocolType)
+	fmt.Fprintf(&sb, "base_url: \"%s\"\n", m.BaseURL)
+	fmt.Fprintf(&sb, "api_key: \"%s\"\n", m.APIKey)
+	fmt.Fprintf(&sb, "model_name: \"%s\"\n", m.ModelName)
+	if m.ContextLimit > 0 {
+		fmt.Fprintf(&sb, "context_limit: %d\n", m.ContextLimit)
+	}
+	if m.PromptCache {
+		sb.WriteString("prompt_cache: true\n")
+	}
+	if m.Deployment != "" {
+		fmt.Fprintf(&sb, "deployment: \"%s\"\n", m.Deployment)
+	}
+	if m.APIVersion != "" {
+		fmt.Fprintf(&sb, "api_version: \"%s\"\n", m.APIVersion)
+	}
+	return sb.String()
+}
+
 // Reload reloads models from the config file
 func (mm *ModelManager) Reload() error {
 	if mm.filePath == "" {
@@ -200,6 +244,22 @@ func (mm *ModelManager) Reload() error {
 	return mm.LoadFromFile(mm.filePath)
 }
 
+// Validate reports the models whose protocol_type is not a known provider
+// type, joined, so a typo surfaces when the file is loaded rather than as an
+// error from the wrong API on the first prompt.
+func (mm *ModelManager) Validate() error {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	var errs []error
+	for _, m := range mm.models {
+		if _, err := factory.ResolveType(m.ProtocolType, m.BaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("model %q: %w", m.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // HasModels returns true if there are any models available
 func (mm *ModelManager) HasModels() bool {
 	mm.mu.RLock()
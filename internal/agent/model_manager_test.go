@@ -3,6 +3,7 @@ package agent
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -275,3 +276,93 @@ model_name: "model-b"
 		t.Errorf("expected second model ID to be 2 after reload, got %d", models[1].ID)
 	}
 }
+
+func TestFormatModelConfigRoundTrip(t *testing.T) {
+	model := ModelConfig{
+		Name:         "DeepSeek / deepseek-chat",
+		ProtocolType: "openai",
+		BaseURL:      "https://api.deepseek.com/v1",
+		APIKey:       "sk-test",
+		ModelName:    "deepseek-chat",
+		ContextLimit: 128000,
+	}
+	models := parseModelConfig("---\n" + FormatModelConfig(model) + "---\n")
+	if len(models) != 1 || models[0] != model {
+		t.Errorf("round trip = %+v, want %+v", models, model)
+	}
+}
+
+func TestNeedsModelSetup(t *testing.T) {
+	dir := t.TempDir()
+	if !NeedsModelSetup(filepath.Join(dir, "missing.conf")) {
+		t.Error("a missing config needs setup")
+	}
+
+	empty := filepath.Join(dir, "empty.conf")
+	if err := os.WriteFile(empty, []byte("# nothing here\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !NeedsModelSetup(empty) {
+		t.Error("a config without models needs setup")
+	}
+
+	configured := filepath.Join(dir, "model.conf")
+	if err := os.WriteFile(configured, []byte(DefaultModelConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if NeedsModelSetup(configured) {
+		t.Error("a config with a model does not need setup")
+	}
+}
+
+func TestParseModelConfigExpandsAPIKey(t *testing.T) {
+	t.Setenv("ALAYACORE_TEST_KEY", "sk-from-env")
+	models := parseModelConfig(`name: "a"
+model_name: "m"
+api_key: "${ALAYACORE_TEST_KEY}"
+---
+name: "b"
+model_name: "m"
+api_key: "${ALAYACORE_TEST_UNSET_KEY}"
+`)
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want 2", len(models))
+	}
+	if models[0].APIKey != "sk-from-env" {
+		t.Errorf("api_key = %q, want the environment value", models[0].APIKey)
+	}
+	if models[1].APIKey != "${ALAYACORE_TEST_UNSET_KEY}" {
+		t.Errorf("api_key with an unset variable = %q, want it unchanged", models[1].APIKey)
+	}
+}
+
+func TestModelManagerValidateProtocolType(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "model.conf")
+	content := `name: "Good"
+protocol_type: "anthropic"
+model_name: "claude"
+---
+name: "Inferred"
+base_url: "https://api.example.com/v1"
+model_name: "m"
+---
+name: "Typo"
+protocol_type: "antropic"
+model_name: "claude"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewModelManager(configPath).Validate()
+	if err == nil {
+		t.Fatal("expected an error for the misspelled protocol_type")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, `model "Typo"`) || !strings.Contains(msg, "anthropic, openai, ollama") {
+		t.Errorf("error = %q", msg)
+	}
+	if strings.Contains(msg, "Good") || strings.Contains(msg, "Inferred") {
+		t.Errorf("valid models reported: %q", msg)
+	}
+}
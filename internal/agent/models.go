@@ -0,0 +1,148 @@
+package agent
+
+// Provider model lists.
+// :models lists the models the active provider offers (OpenAI and Anthropic
+// /models, Ollama /api/tags), and :models <name> switches to one of them
+// without editing model.conf. For Ollama, whose unknown models fail with a
+// bare 404, the model is also checked at startup and whenever the provider
+// is created.
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm/factory"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+)
+
+// modelListTimeout bounds a model list request.
+const modelListTimeout = 10 * time.Second
+
+// checkModelAvailable reports an error listing the available models when
+// config's model is not offered by its provider.
+func checkModelAvailable(client *http.Client, config *ModelConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), modelListTimeout)
+	defer cancel()
+	return providers.CheckModel(ctx, client, config.ProtocolType, config.BaseURL, config.APIKey, config.ModelName)
+}
+
+// checkOllamaModel checks the active model at startup when it is served by
+// Ollama. It runs in the background so an unreachable server does not delay
+// the UI.
+func (s *Session) checkOllamaModel() {
+	if s.ModelManager == nil {
+		return
+	}
+	active := s.ModelManager.GetActive()
+	if active == nil || !strings.EqualFold(active.ProtocolType, "ollama") {
+		return
+	}
+	go func() {
+		client, err := newHTTPClient(s.debugAPI, s.proxyURL)
+		if err == nil {
+			err = checkModelAvailable(client, active)
+		}
+		if err != nil {
+			s.writeError("Ollama: " + err.Error())
+		}
+	}()
+}
+
+// handleModels lists the active provider's models, or switches to the one
+// named in args.
+func (s *Session) handleModels(ctx context.Context, args []string) {
+	if s.ModelManager == nil {
+		s.writeError("Model manager not initialized")
+		return
+	}
+	active := s.ModelManager.GetActive()
+	if active == nil {
+		s.writeError("No model configured. Please add a model to ~/.alayacore/model.conf")
+		return
+	}
+
+	client, err := newHTTPClient(s.debugAPI, s.proxyURL)
+	if err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	protocol, err := factory.ResolveType(active.ProtocolType, active.BaseURL)
+	if err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, modelListTimeout)
+	defer cancel()
+	ids, err := providers.ListModels(ctx, client, protocol, active.BaseURL, active.APIKey)
+	if err != nil {
+		s.writeError("Failed to list models: " + err.Error())
+		return
+	}
+
+	if len(args) == 0 {
+		s.writeNotify(formatModelList(active, ids))
+		return
+	}
+	name := args[0]
+	if protocol == "azure" {
+		// The list holds base models; requests go to deployments
+		s.writeError("Azure models are chosen by deployment; add a model.conf entry for the deployment of " + name)
+		return
+	}
+	if !slices.Contains(ids, name) {
+		s.writeError("Model " + name + " is not offered by " + active.Name + "; run :models to list them")
+		return
+	}
+	s.useProviderModel(active, name)
+}
+
+// useProviderModel switches to model name on the provider of base. A
+// configured model with the same endpoint and name is reused; otherwise a
+// copy of base is added for this run only, as model.conf is never written.
+func (s *Session) useProviderModel(base *ModelConfig, name string) {
+	id := 0
+	for _, info := range s.ModelManager.GetModels() {
+		if info.ModelName == name && info.BaseURL == base.BaseURL && strings.EqualFold(info.ProtocolType, base.ProtocolType) {
+			id = info.ID
+			break
+		}
+	}
+	if id == 0 {
+		model := *base
+		model.Name = name
+		model.ModelName = name
+		// The limit belongs to the old model
+		model.ContextLimit = 0
+		id = s.ModelManager.AddModel(model)
+	}
+	if err := s.ModelManager.SetActive(id); err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	model := s.ModelManager.GetModel(id)
+	if err := s.SwitchModel(model); err != nil {
+		s.writeError("Failed to switch model: " + err.Error())
+		return
+	}
+	s.writeNotify("Switched to " + model.Name)
+}
+
+// formatModelList renders the model IDs, marking the active model.
+func formatModelList(active *ModelConfig, ids []string) string {
+	if len(ids) == 0 {
+		return "No models offered by " + active.Name
+	}
+	var sb strings.Builder
+	sb.WriteString("Models offered by " + active.Name + " (switch with :models <name>):")
+	for _, id := range ids {
+		marker := "  "
+		if id == active.ModelName {
+			marker = "* "
+		}
+		sb.WriteString("\n" + marker + id)
+	}
+	return sb.String()
+}
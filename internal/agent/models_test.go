@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newOllamaStub serves /api/tags with two models.
+func newOllamaStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"models": [{"name": "qwen3:8b"}, {"name": "llama3.2:3b"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newModelsTestSession(baseURL string) (*Session, *MockOutput) {
+	session, output := newSettingsTestSession()
+	session.ModelManager = NewModelManager("")
+	session.ModelManager.models = []ModelConfig{{
+		ID: 1, Name: "local", ProtocolType: "ollama", BaseURL: baseURL + "/v1", ModelName: "qwen3:8b",
+	}}
+	session.ModelManager.activeID = 1
+	return session, output
+}
+
+func TestCheckModelAvailableListsModels(t *testing.T) {
+	server := newOllamaStub(t)
+
+	config := &ModelConfig{ProtocolType: "ollama", BaseURL: server.URL + "/v1", ModelName: "qwen3:8b"}
+	if err := checkModelAvailable(server.Client(), config); err != nil {
+		t.Errorf("pulled model: %v", err)
+	}
+
+	config.ModelName = "mistral"
+	err := checkModelAvailable(server.Client(), config)
+	if err == nil {
+		t.Fatal("missing model should fail")
+	}
+	for _, want := range []string{"mistral", "qwen3:8b", "llama3.2:3b"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestModelsListsProviderModels(t *testing.T) {
+	server := newOllamaStub(t)
+	session, output := newModelsTestSession(server.URL)
+
+	session.handleModels(context.Background(), nil)
+
+	if !outputContains(output, "* qwen3:8b") {
+		t.Errorf("active model not marked: %q", output.Messages)
+	}
+	if !outputContains(output, "  llama3.2:3b") {
+		t.Errorf("other model not listed: %q", output.Messages)
+	}
+}
+
+func TestModelsSwitchesModel(t *testing.T) {
+	server := newOllamaStub(t)
+	session, output := newModelsTestSession(server.URL)
+
+	session.handleModels(context.Background(), []string{"llama3.2:3b"})
+
+	active := session.ModelManager.GetActive()
+	if active == nil || active.ModelName != "llama3.2:3b" || active.BaseURL != server.URL+"/v1" {
+		t.Fatalf("active = %+v", active)
+	}
+	if !outputContains(output, "Switched to llama3.2:3b") {
+		t.Errorf("no switch notice: %q", output.Messages)
+	}
+
+	// Switching back reuses the configured model instead of adding another
+	session.handleModels(context.Background(), []string{"qwen3:8b"})
+	if id := session.ModelManager.GetActiveID(); id != 1 {
+		t.Errorf("active ID = %d, want 1", id)
+	}
+	if n := session.ModelManager.ModelCount(); n != 2 {
+		t.Errorf("model count = %d, want 2", n)
+	}
+
+	session.handleModels(context.Background(), []string{"mistral"})
+	if !outputContains(output, "mistral is not offered") {
+		t.Errorf("unknown model not reported: %q", output.Messages)
+	}
+}
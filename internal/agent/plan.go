@@ -0,0 +1,32 @@
+package agent
+
+// The model's plan.
+// manage_todo keeps its checklist in the session's TodoList, which reaches
+// the tool through the context of each task. Every change is sent to the
+// adaptors as a TagPlan frame holding the whole list, and :clear_plan
+// empties it.
+
+import (
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// sendPlan emits the list as a JSON array of {id, text, done}.
+func (s *Session) sendPlan(items []tools.TodoItem) {
+	if items == nil {
+		items = []tools.TodoItem{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return
+	}
+	s.writeGapped(stream.TagPlan, string(data))
+}
+
+// handleClearPlan empties the plan.
+func (s *Session) handleClearPlan() {
+	s.todo.Clear()
+	s.writeNotify("Plan cleared")
+}
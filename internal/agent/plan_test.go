@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+func TestPlanChangesEmitTLV(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.todo = tools.NewTodoList(session.sendPlan)
+
+	session.todo.Add("Read the code")
+	want := string(stream.EncodeTLV(stream.TagPlan, `[{"id":1,"text":"Read the code","done":false}]`))
+	if got := output.Messages[len(output.Messages)-1]; got != want {
+		t.Errorf("plan frame = %q, want %q", got, want)
+	}
+
+	session.handleCommandSync(context.Background(), "clear_plan")
+	if !outputContains(output, string(stream.EncodeTLV(stream.TagPlan, "[]"))) {
+		t.Error(":clear_plan should send an empty plan")
+	}
+	if !outputContains(output, "Plan cleared") {
+		t.Error(":clear_plan should confirm")
+	}
+	if len(session.todo.Items()) != 0 {
+		t.Error(":clear_plan left items in the plan")
+	}
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/tokens"
+)
+
+// Progress tracks the prompt that is currently streaming. The session updates
+// it from the stream callback; adaptors poll it through Session.Progress. It is
+// safe for concurrent use.
+type Progress struct {
+	mu           sync.Mutex
+	active       bool
+	start        time.Time
+	firstDelta   time.Time // zero until the first text or reasoning delta
+	lastData     time.Time // last event of any kind from the provider
+	outputTokens int       // estimated tokens of streamed text and reasoning
+	pendingTools int       // tool calls waiting for their result
+	stallWarned  bool
+	estimator    tokens.Estimator
+}
+
+// ProgressSnapshot is a point-in-time copy of Progress.
+type ProgressSnapshot struct {
+	Active          bool
+	Elapsed         time.Duration // since the prompt started
+	SinceLastData   time.Duration // since the provider last sent anything
+	OutputTokens    int           // estimated streamed output tokens
+	TokensPerSecond float64       // output rate since the first delta; 0 until measurable
+}
+
+// begin resets the tracker for a new prompt.
+func (p *Progress) begin(model string) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = true
+	p.start = now
+	p.firstDelta = time.Time{}
+	p.lastData = now
+	p.outputTokens = 0
+	p.pendingTools = 0
+	p.stallWarned = false
+	p.estimator = tokens.ForModel(model)
+}
+
+// end marks the prompt finished. The snapshot keeps its final figures.
+func (p *Progress) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = false
+	p.lastData = time.Now()
+}
+
+// delta records streamed text or reasoning.
+func (p *Progress) delta(text string) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.firstDelta.IsZero() {
+		p.firstDelta = now
+	}
+	p.lastData = now
+	p.stallWarned = false
+	if p.estimator != nil {
+		p.outputTokens += p.estimator.Count(text)
+	}
+}
+
+// toolCall records a tool call; the provider is idle until its result.
+func (p *Progress) toolCall() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pendingTools++
+	p.lastData = time.Now()
+}
+
+// toolResult records a finished tool call.
+func (p *Progress) toolResult() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pendingTools > 0 {
+		p.pendingTools--
+	}
+	p.lastData = time.Now()
+	p.stallWarned = false
+}
+
+// touch records any other provider event, such as a new step.
+func (p *Progress) touch() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastData = time.Now()
+}
+
+// stalled reports, once per stall, that the provider has sent nothing for at
+// least threshold. Time spent running tools does not count.
+func (p *Progress) stalled(threshold time.Duration) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.active || threshold <= 0 || p.pendingTools > 0 || p.stallWarned {
+		return 0, false
+	}
+	idle := time.Since(p.lastData)
+	if idle < threshold {
+		return 0, false
+	}
+	p.stallWarned = true
+	return idle, true
+}
+
+// Snapshot returns the current progress.
+func (p *Progress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		return ProgressSnapshot{}
+	}
+	now := time.Now()
+	if !p.active {
+		now = p.lastData
+	}
+	snap := ProgressSnapshot{
+		Active:        p.active,
+		Elapsed:       now.Sub(p.start),
+		SinceLastData: now.Sub(p.lastData),
+		OutputTokens:  p.outputTokens,
+	}
+	// Rates over less than a second swing wildly, so wait for one
+	if streaming := now.Sub(p.firstDelta); !p.firstDelta.IsZero() && streaming >= time.Second {
+		snap.TokensPerSecond = float64(p.outputTokens) / streaming.Seconds()
+	}
+	return snap
+}
+
+// Progress returns the streaming progress of the running prompt, or of the
+// last one once it has finished.
+func (s *Session) Progress() ProgressSnapshot {
+	return s.progress.Snapshot()
+}
+
+// watchStall warns once per stall when the provider sends nothing for
+// stallWarning, until done is closed.
+func (s *Session) watchStall(done <-chan struct{}) {
+	if s.stallWarning <= 0 {
+		return
+	}
+	interval := min(time.Second, s.stallWarning)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if idle, ok := s.progress.stalled(s.stallWarning); ok {
+				s.writeNotifyf("Warning: no data from provider for %s (Ctrl+G cancels)", idle.Round(time.Second))
+			}
+		}
+	}
+}
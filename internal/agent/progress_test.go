@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressTracksOutput(t *testing.T) {
+	var p Progress
+	if snap := p.Snapshot(); snap.Active || snap.Elapsed != 0 {
+		t.Errorf("zero progress should be idle, got %+v", snap)
+	}
+
+	p.begin("gpt-4o")
+	p.delta("hello world, this is streamed text")
+	snap := p.Snapshot()
+	if !snap.Active || snap.OutputTokens == 0 {
+		t.Errorf("expected active progress with output, got %+v", snap)
+	}
+	if snap.TokensPerSecond != 0 {
+		t.Errorf("rate should wait for a full second of streaming, got %v", snap.TokensPerSecond)
+	}
+
+	// Pretend streaming started two seconds ago
+	p.mu.Lock()
+	p.firstDelta = time.Now().Add(-2 * time.Second)
+	p.mu.Unlock()
+	if rate := p.Snapshot().TokensPerSecond; rate <= 0 {
+		t.Errorf("expected a positive rate, got %v", rate)
+	}
+
+	p.end()
+	if snap := p.Snapshot(); snap.Active || snap.TokensPerSecond <= 0 {
+		t.Errorf("finished progress should keep its rate, got %+v", snap)
+	}
+}
+
+func TestProgressStallWarnsOncePerStall(t *testing.T) {
+	var p Progress
+	p.begin("")
+	if _, ok := p.stalled(time.Minute); ok {
+		t.Error("fresh prompt should not be stalled")
+	}
+
+	idle := func() {
+		p.mu.Lock()
+		p.lastData = time.Now().Add(-2 * time.Minute)
+		p.mu.Unlock()
+	}
+	idle()
+	if _, ok := p.stalled(time.Minute); !ok {
+		t.Fatal("expected a stall")
+	}
+	if _, ok := p.stalled(time.Minute); ok {
+		t.Error("a stall should be reported only once")
+	}
+
+	p.delta("x")
+	idle()
+	if _, ok := p.stalled(time.Minute); !ok {
+		t.Error("a new stall after data should be reported again")
+	}
+
+	// Running tools is not a provider stall
+	p.toolCall()
+	idle()
+	p.mu.Lock()
+	p.stallWarned = false
+	p.mu.Unlock()
+	if _, ok := p.stalled(time.Minute); ok {
+		t.Error("time spent in tools should not count as a stall")
+	}
+	p.toolResult()
+	idle()
+	if _, ok := p.stalled(time.Minute); !ok {
+		t.Error("stall after the tool result should be reported")
+	}
+
+	p.end()
+	idle()
+	if _, ok := p.stalled(time.Minute); ok {
+		t.Error("finished prompts never stall")
+	}
+	if _, ok := p.stalled(0); ok {
+		t.Error("a zero threshold disables the warning")
+	}
+}
+
+func TestWatchStallWarns(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.stallWarning = 20 * time.Millisecond
+	session.progress.begin("")
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		session.watchStall(done)
+		close(exited)
+	}()
+	warned := func() bool {
+		session.progress.mu.Lock()
+		defer session.progress.mu.Unlock()
+		return session.progress.stallWarned
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !warned() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(done)
+	<-exited
+	if !outputContains(output, "no data from provider") {
+		t.Errorf("expected a stall warning, got %v", output.Messages)
+	}
+}
@@ -0,0 +1,85 @@
+package agent
+
+// Saved prompts.
+// :prompt <name> [args...] expands a template from the prompt library
+// (~/.alayacore/prompts) and submits the result as a user prompt; :prompts
+// lists the library. :prompt runs synchronously, like :attach, so the
+// expanded prompt takes its place in the queue and picks up pending
+// attachments.
+
+import (
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/prompts"
+)
+
+// defaultPromptLibrary returns the library in ~/.alayacore/prompts, or an
+// empty one when the home directory is unknown.
+func defaultPromptLibrary() *prompts.Library {
+	dir, err := prompts.DefaultDir()
+	if err != nil {
+		return prompts.NewLibrary("")
+	}
+	return prompts.NewLibrary(dir)
+}
+
+// isPromptCommand reports whether cmd is ":prompt".
+func isPromptCommand(cmd string) bool {
+	return cmd == "prompt" || strings.HasPrefix(cmd, "prompt ")
+}
+
+// PromptNames returns the names of the saved prompts, for completion.
+func (s *Session) PromptNames() []string {
+	return s.promptLib.Names()
+}
+
+// handlePrompt expands a saved prompt and submits it. Nothing is submitted
+// when the prompt is missing or fails to parse or expand.
+func (s *Session) handlePrompt(args []string) {
+	if len(args) == 0 {
+		s.writeError("usage: :prompt <name> [args...]")
+		return
+	}
+	p, err := s.promptLib.Load(args[0])
+	if err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	text, err := p.Expand(args[1:])
+	if err != nil {
+		s.writeError(err.Error())
+		return
+	}
+	if text == "" {
+		s.writeError("prompt " + p.Name + " expanded to nothing")
+		return
+	}
+	s.submitTask(UserPrompt{Text: text, Images: s.takeAttachments()})
+}
+
+// handlePrompts lists the saved prompts with their descriptions and
+// arguments.
+func (s *Session) handlePrompts() {
+	list, err := s.promptLib.List()
+	if err != nil {
+		s.writeError(err.Error())
+	}
+	if len(list) == 0 {
+		if err == nil {
+			s.writeNotify("No saved prompts in " + s.promptLib.Dir())
+		}
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("Saved prompts:")
+	for _, p := range list {
+		sb.WriteString("\n  " + p.Name)
+		for _, arg := range p.Args {
+			sb.WriteString(" <" + arg + ">")
+		}
+		if p.Description != "" {
+			sb.WriteString(" - " + p.Description)
+		}
+	}
+	s.writeNotify(sb.String())
+}
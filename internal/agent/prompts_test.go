@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/prompts"
+)
+
+func newPromptTestSession(t *testing.T) (*Session, *MockOutput) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"review.md": "---\ndescription: Review a file\nargs: [file]\n---\nReview {{index .Args 0}}.",
+		"broken.md": "Review {{index .Args 0}.",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session, output := newSettingsTestSession()
+	session.promptLib = prompts.NewLibrary(dir)
+	return session, output
+}
+
+func TestPromptCommandSubmitsExpansion(t *testing.T) {
+	session, output := newPromptTestSession(t)
+
+	session.handleCommandSync(context.Background(), "prompt review main.go")
+
+	if len(session.taskQueue) != 1 {
+		t.Fatalf("expected one queued prompt, got %d (output %v)", len(session.taskQueue), output.Messages)
+	}
+	if prompt, ok := session.taskQueue[0].Task.(UserPrompt); !ok || prompt.Text != "Review main.go." {
+		t.Errorf("queued task = %#v", session.taskQueue[0].Task)
+	}
+}
+
+func TestPromptCommandErrorsSubmitNothing(t *testing.T) {
+	for _, cmd := range []string{"prompt", "prompt review", "prompt broken x", "prompt missing"} {
+		session, output := newPromptTestSession(t)
+		session.handleCommandSync(context.Background(), cmd)
+		if len(session.taskQueue) != 0 {
+			t.Errorf(":%s queued %d tasks", cmd, len(session.taskQueue))
+		}
+		if len(output.Messages) == 0 {
+			t.Errorf(":%s reported nothing", cmd)
+		}
+	}
+}
+
+func TestPromptsCommandLists(t *testing.T) {
+	session, output := newPromptTestSession(t)
+
+	session.handleCommandSync(context.Background(), "prompts")
+
+	if !outputContains(output, "review <file> - Review a file") {
+		t.Errorf("expected the review prompt listed, got %v", output.Messages)
+	}
+	if !outputContains(output, "prompt broken") {
+		t.Errorf("expected the broken prompt reported, got %v", output.Messages)
+	}
+}
@@ -1,10 +1,14 @@
 package agent
 
 import (
+	"context"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alayacore/alayacore/internal/llm"
 	"github.com/alayacore/alayacore/internal/stream"
 )
 
@@ -224,8 +228,7 @@ func TestCancelAllTasks(t *testing.T) {
 				})
 			}
 
-			// Execute cancelAllTasks
-			session.cancelAllTasks()
+			session.CancelAll()
 
 			// Verify queue is cleared
 			if len(session.taskQueue) != 0 {
@@ -254,3 +257,369 @@ func TestCancelAllTasks(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskNumbering(t *testing.T) {
+	output := &MockOutput{}
+	session := &Session{
+		taskQueue:     make([]QueueItem, 0),
+		taskAvailable: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		Input:         &stream.ChanInput{},
+		Output:        output,
+	}
+
+	// The first task runs immediately: no queue notice
+	session.submitTask(UserPrompt{Text: "first"})
+	if outputContains(output, "[Queued") {
+		t.Fatalf("immediate task should not be reported as queued: %v", output.Messages)
+	}
+
+	// Once it is picked up, later submissions are queued behind it
+	item, ok := session.waitForNextTask()
+	if !ok || item.ID != 1 {
+		t.Fatalf("expected task #1, got %+v", item)
+	}
+	session.submitTask(CommandPrompt{Command: "summarize"})
+	session.submitTask(UserPrompt{Text: "third"})
+	if !outputContains(output, "[Queued #2]") || !outputContains(output, "[Queued #3]") {
+		t.Errorf("expected queue notices for #2 and #3, got %v", output.Messages)
+	}
+
+	items := session.GetQueueItems()
+	if len(items) != 2 || items[0].ID != 2 || items[1].ID != 3 {
+		t.Fatalf("unexpected queue: %+v", items)
+	}
+
+	session.signalPromptStart(items[1].ID, "third")
+	if !outputContains(output, "#3 ▸ third") {
+		t.Errorf("prompt start should carry the task number, got %v", output.Messages)
+	}
+	session.signalPromptDone(3, false, 2345, 8100*time.Millisecond, 0, 0.0234, true)
+	if !outputContains(output, "#3 done, 2.3k tokens, 8.1s, $0.02") {
+		t.Errorf("unexpected completion notice: %v", output.Messages)
+	}
+}
+
+func TestFormatTokenCount(t *testing.T) {
+	tests := map[int64]string{0: "0", 950: "950", 2345: "2.3k", 1_250_000: "1.2M"}
+	for n, want := range tests {
+		if got := formatTokenCount(n); got != want {
+			t.Errorf("formatTokenCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestPromptDoneShowsRate(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.signalPromptDone(4, false, 500, 2*time.Second, 33.6, 0, false)
+	if !outputContains(output, "#4 done, 500 tokens, 2.0s, 34 tok/s, cost: n/a") {
+		t.Errorf("unexpected completion notice: %v", output.Messages)
+	}
+}
+
+func TestLongTurnAlert(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.notifyAfter = 30 * time.Second
+
+	session.alertLongTurn(10*time.Second, "#1 done", "quick")
+	if len(output.Messages) != 0 {
+		t.Errorf("short prompt alerted: %v", output.Messages)
+	}
+
+	long := "Refactor the parser " + strings.Repeat("and more ", 10) + "\nsecond line"
+	session.alertLongTurn(45*time.Second, "#2 done, 45.0s", long)
+	want := string(stream.EncodeTLV(stream.TagTurnAlert, "#2 done, 45.0s: "+long[:alertSummaryLen]+"…"))
+	if !outputContains(output, want) {
+		t.Errorf("expected %q, got %v", want, output.Messages)
+	}
+
+	session.notifyAfter = 0
+	output.Messages = nil
+	session.alertLongTurn(time.Hour, "#3 done", "x")
+	if len(output.Messages) != 0 {
+		t.Errorf("alerts are off with notifyAfter 0: %v", output.Messages)
+	}
+}
+
+// blockingProvider streams nothing until the request is canceled.
+type blockingProvider struct {
+	calls   atomic.Int32
+	started chan struct{}
+}
+
+func (p *blockingProvider) StreamMessages(ctx context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.calls.Add(1)
+	p.started <- struct{}{}
+	events := make(chan llm.StreamEvent, 1)
+	go func() {
+		<-ctx.Done()
+		events <- llm.StreamErrorEvent{Error: ctx.Err()}
+		close(events)
+	}()
+	return events, nil
+}
+
+// lockedOutput is a MockOutput safe for use while the task runner writes.
+type lockedOutput struct {
+	mu  sync.Mutex
+	out MockOutput
+}
+
+func (o *lockedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.out.Write(p)
+}
+
+func (o *lockedOutput) WriteString(s string) (int, error) { return o.Write([]byte(s)) }
+func (o *lockedOutput) Flush() error                      { return nil }
+func (o *lockedOutput) Close() error                      { return nil }
+
+func (o *lockedOutput) contains(text string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return outputContains(&o.out, text)
+}
+
+func TestCancelAllStopsQueuedPrompts(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}, 3)}
+	session, _ := newSummarizeTestSession(t, provider)
+	output := &lockedOutput{}
+	session.Output = output
+	session.taskAvailable = make(chan struct{}, 1)
+	session.done = make(chan struct{})
+	session.stopped = make(chan struct{})
+	defer close(session.done)
+	go session.taskRunner()
+
+	session.submitTask(UserPrompt{Text: "first"})
+	session.submitTask(UserPrompt{Text: "second"})
+	session.submitTask(UserPrompt{Text: "third prompt"})
+
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first prompt never reached the provider")
+	}
+	session.CancelAll()
+
+	// Give the runner time to pick up anything left in the queue
+	deadline := time.Now().Add(2 * time.Second)
+	for !output.contains("#1 canceled") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+	if !output.contains("cleared 2 queued tasks\n  #2 second\n  #3 third prompt") {
+		output.mu.Lock()
+		t.Errorf("cancel notice should list the dropped prompts, got %v", output.out.Messages)
+		output.mu.Unlock()
+	}
+}
+
+// partialProvider streams some text, then blocks until the request is canceled.
+type partialProvider struct {
+	text string
+}
+
+func (p *partialProvider) StreamMessages(ctx context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	events := make(chan llm.StreamEvent, 2)
+	events <- llm.TextDeltaEvent{Delta: p.text}
+	go func() {
+		<-ctx.Done()
+		events <- llm.StreamErrorEvent{Error: ctx.Err()}
+		close(events)
+	}()
+	return events, nil
+}
+
+func TestCancelKeepsPartialResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		streamed string
+		want     string
+	}{
+		{"partial text", "The first half of", "The first half of\n\n" + interruptedMarker},
+		{"nothing streamed", "", "The user canceled."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, _ := newSummarizeTestSession(t, &partialProvider{text: tt.streamed})
+			output := &lockedOutput{}
+			session.Output = output
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				session.handleUserPrompt(ctx, "explain", nil)
+				close(done)
+			}()
+
+			deadline := time.Now().Add(2 * time.Second)
+			for tt.streamed != "" && !output.contains(tt.streamed) && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			cancel()
+			<-done
+			session.appendCancelMessage()
+
+			last := session.Messages[len(session.Messages)-1]
+			if last.Role != llm.RoleAssistant {
+				t.Fatalf("last message role = %s, want assistant", last.Role)
+			}
+			if got := last.Content[0].(llm.TextPart).Text; got != tt.want {
+				t.Errorf("assistant message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// echoProvider answers every request at once and counts the requests.
+type echoProvider struct {
+	calls atomic.Int32
+}
+
+func (p *echoProvider) StreamMessages(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.calls.Add(1)
+	events := make(chan llm.StreamEvent, 1)
+	events <- llm.StepCompleteEvent{
+		Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "ok"}})},
+		Usage:    llm.Usage{InputTokens: 10, OutputTokens: 2},
+	}
+	close(events)
+	return events, nil
+}
+
+// TestConcurrentSubmit submits prompts from several goroutines while others
+// read the session state. Run with -race.
+func TestConcurrentSubmit(t *testing.T) {
+	const submitters, prompts = 4, 2 // stays within the queue limit of 10
+	provider := &echoProvider{}
+	session, _ := newSummarizeTestSession(t, provider)
+	session.Output = &lockedOutput{}
+	session.taskAvailable = make(chan struct{}, 1)
+	session.done = make(chan struct{})
+	session.stopped = make(chan struct{})
+	defer close(session.done)
+	go session.taskRunner()
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for range 2 {
+		readers.Go(func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				session.UsageSnapshot()
+				session.HistoryLen()
+				session.IsInProgress()
+				session.GetQueueItems()
+			}
+		})
+	}
+
+	var submit sync.WaitGroup
+	for i := range submitters {
+		submit.Go(func() {
+			for j := range prompts {
+				session.submitTask(UserPrompt{Text: strings.Repeat("p", i*prompts+j+1)})
+			}
+		})
+	}
+	submit.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for session.isBusy() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	readers.Wait()
+
+	if calls := provider.calls.Load(); calls != submitters*prompts {
+		t.Errorf("provider called %d times, want %d", calls, submitters*prompts)
+	}
+	// A user message and a reply per prompt
+	if got := session.HistoryLen(); got != 2*submitters*prompts {
+		t.Errorf("HistoryLen = %d, want %d", got, 2*submitters*prompts)
+	}
+	if spent := session.UsageSnapshot().Spent; spent.InputTokens != 10*submitters*prompts {
+		t.Errorf("input tokens spent = %d, want %d", spent.InputTokens, 10*submitters*prompts)
+	}
+}
+
+func TestTurnEndFollowsTask(t *testing.T) {
+	tests := []struct {
+		name string
+		task Task
+	}{
+		{"prompt", UserPrompt{Text: "hello"}},
+		{"command", CommandPrompt{Command: "no_such_command"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, output := newSummarizeTestSession(t, &echoProvider{})
+
+			session.runTask(QueueItem{ID: 7, Task: tt.task})
+
+			// Written with its time
+			want := string(stream.EncodeTLV(stream.TagTurnEnd, "7"))
+			if n := len(output.Messages); n == 0 || !strings.HasPrefix(output.Messages[n-1], stream.TagTime) || !strings.HasSuffix(output.Messages[n-1], want) {
+				t.Errorf("last frame should be the turn end for task 7, got %q", output.Messages)
+			}
+		})
+	}
+}
+
+func TestShutdownStopsRunningAndQueuedTasks(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}, 3)}
+	session, _ := newSummarizeTestSession(t, provider)
+	session.Output = &lockedOutput{}
+	session.taskAvailable = make(chan struct{}, 1)
+	session.done = make(chan struct{})
+	session.stopped = make(chan struct{})
+	defer close(session.done)
+	go session.taskRunner()
+
+	session.submitTask(UserPrompt{Text: "first"})
+	session.submitTask(UserPrompt{Text: "second"})
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first prompt never reached the provider")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := session.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+
+	session.submitTask(UserPrompt{Text: "late"})
+	if items := session.GetQueueItems(); len(items) != 0 {
+		t.Errorf("queue after Shutdown = %+v, want it empty", items)
+	}
+}
+
+func TestShutdownGivesUpAtTheDeadline(t *testing.T) {
+	session := &Session{
+		taskAvailable: make(chan struct{}, 1),
+		stopped:       make(chan struct{}), // a task runner that never stops
+		Output:        &MockOutput{},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := session.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,39 @@
+package agent
+
+// Prompt rate limit.
+// The web server limits how often a client may have the model called
+// (--prompt-rate). The session asks the limit before queueing any task that
+// calls the model, whether typed, from :prompt, :retry, :steer, or
+// :summarize, and before :steer redirects the running prompt, so no command
+// gets around it.
+
+import (
+	"strings"
+)
+
+// callsModel reports whether task sends a request to the model.
+func callsModel(task Task) bool {
+	switch t := task.(type) {
+	case UserPrompt:
+		return true
+	case CommandPrompt:
+		return t.Command == "summarize" || strings.HasPrefix(t.Command, "summarize ")
+	}
+	return false
+}
+
+// allowPrompt reports whether the limit lets one more model call through,
+// showing its error when it does not.
+func (s *Session) allowPrompt() bool {
+	s.mu.Lock()
+	limit := s.promptLimit
+	s.mu.Unlock()
+	if limit == nil {
+		return true
+	}
+	if err := limit(); err != nil {
+		s.writeError(err.Error())
+		return false
+	}
+	return true
+}
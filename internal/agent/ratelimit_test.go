@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromptLimit(t *testing.T) {
+	session, output := newSettingsTestSession()
+	calls := 0
+	session.promptLimit = func() error {
+		calls++
+		return errors.New("rate limited, retry in 6s")
+	}
+
+	session.submitTask(CommandPrompt{Command: "status"})
+	if calls != 0 || len(session.taskQueue) != 1 {
+		t.Fatalf("a command that does not call the model was limited: %d calls, queue %d", calls, len(session.taskQueue))
+	}
+	session.submitTask(UserPrompt{Text: "hello"})
+	session.submitTask(CommandPrompt{Command: "summarize"})
+	if calls != 2 || len(session.taskQueue) != 1 || !outputContains(output, "rate limited, retry in 6s") {
+		t.Errorf("limited tasks: %d calls, queue %d, output %q", calls, len(session.taskQueue), output.Messages)
+	}
+
+	session.promptLimit = nil
+	session.submitTask(UserPrompt{Text: "hello"})
+	if len(session.taskQueue) != 2 {
+		t.Errorf("with no limit the queue holds %d tasks", len(session.taskQueue))
+	}
+}
@@ -0,0 +1,65 @@
+package agent
+
+// Retrying the last prompt.
+// When a provider ends a turn without an answer (llm.ErrEmptyResponse or
+// llm.ErrIncompleteResponse), handleUserPrompt drops the whole turn, so the
+// history never holds a prompt without a reply. :retry sends the last prompt
+// again. It runs synchronously, like :prompt, so the retry takes its place in
+// the queue; when the runner picks it up, a copy of the prompt still left
+// unanswered at the end of the history (e.g. after a network error) is
+// replaced rather than repeated.
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// isRetryCommand reports whether cmd is ":retry".
+func isRetryCommand(cmd string) bool {
+	return cmd == "retry"
+}
+
+// isEmptyResponse reports whether err is a provider ending a turn without an
+// answer.
+func isEmptyResponse(err error) bool {
+	return errors.Is(err, llm.ErrEmptyResponse) || errors.Is(err, llm.ErrIncompleteResponse)
+}
+
+// rememberPrompt records the prompt :retry sends again, and sent, the text
+// it added to the history once file references were expanded.
+func (s *Session) rememberPrompt(text, sent string, images []llm.ImagePart) {
+	s.mu.Lock()
+	s.lastPrompt = &UserPrompt{Text: text, Images: images, sent: sent}
+	s.mu.Unlock()
+}
+
+// handleRetry queues the last prompt again, with its attachments.
+func (s *Session) handleRetry() {
+	s.mu.Lock()
+	last := s.lastPrompt
+	s.mu.Unlock()
+	if last == nil {
+		s.writeError("Nothing to retry: no prompt has been sent in this session")
+		return
+	}
+	s.submitTask(UserPrompt{Text: last.Text, Images: last.Images, retry: true, sent: last.sent})
+}
+
+// dropUnansweredPrompt removes the last message when it is the user prompt
+// text, as sent, with no reply after it.
+func (s *Session) dropUnansweredPrompt(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.Messages)
+	if n == 0 || s.Messages[n-1].Role != llm.RoleUser {
+		return
+	}
+	if i := slices.IndexFunc(s.Messages[n-1].Content, func(part llm.ContentPart) bool {
+		t, ok := part.(llm.TextPart)
+		return ok && t.Text == text
+	}); i >= 0 {
+		s.Messages = s.Messages[:n-1]
+	}
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// emptyThenEchoProvider answers its first request with an empty step and
+// later ones with "ok".
+type emptyThenEchoProvider struct {
+	calls atomic.Int32
+}
+
+func (p *emptyThenEchoProvider) StreamMessages(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	text := "ok"
+	if p.calls.Add(1) == 1 {
+		text = ""
+	}
+	events := make(chan llm.StreamEvent, 1)
+	events <- llm.StepCompleteEvent{
+		Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: text}})},
+	}
+	close(events)
+	return events, nil
+}
+
+func TestEmptyResponseDropsTurn(t *testing.T) {
+	session, output := newSummarizeTestSession(t, &emptyThenEchoProvider{})
+	session.Messages = conversation()
+	before := len(session.Messages)
+
+	session.handleUserPrompt(context.Background(), "hello", nil)
+
+	if got := len(session.Messages); got != before {
+		t.Errorf("history has %d messages after an empty response, want %d", got, before)
+	}
+	if !outputContains(output, "provider returned an empty response") || !outputContains(output, ":retry") {
+		t.Errorf("expected an empty response error pointing at :retry, got %v", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "retry")
+	if len(session.taskQueue) != 1 {
+		t.Fatalf("expected :retry to queue the prompt, got %d tasks", len(session.taskQueue))
+	}
+	prompt, ok := session.taskQueue[0].Task.(UserPrompt)
+	if !ok || prompt.Text != "hello" || !prompt.retry {
+		t.Fatalf("queued task = %#v", session.taskQueue[0].Task)
+	}
+
+	session.runTask(session.taskQueue[0])
+	history := session.Messages
+	if len(history) != before+2 || history[before].Role != llm.RoleUser || history[before+1].Role != llm.RoleAssistant {
+		t.Errorf("history after the retry = %+v", history[before:])
+	}
+}
+
+func TestRetryReplacesUnansweredPrompt(t *testing.T) {
+	session, _ := newSummarizeTestSession(t, &echoProvider{})
+	session.Messages = []llm.Message{textMessage(llm.RoleUser, "hello")}
+	session.rememberPrompt("hello", "hello", nil)
+
+	session.handleCommandSync(context.Background(), "retry")
+	session.runTask(session.taskQueue[0])
+
+	if len(session.Messages) != 2 || session.Messages[0].Role != llm.RoleUser || session.Messages[1].Role != llm.RoleAssistant {
+		t.Errorf("history = %+v, want the prompt once and its reply", session.Messages)
+	}
+}
+
+func TestRetryWithoutPrompt(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleCommandSync(context.Background(), "retry")
+
+	if len(session.taskQueue) != 0 {
+		t.Errorf(":retry queued %d tasks with nothing to retry", len(session.taskQueue))
+	}
+	if !outputContains(output, "Nothing to retry") {
+		t.Errorf("expected an error, got %v", output.Messages)
+	}
+}
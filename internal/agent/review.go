@@ -0,0 +1,166 @@
+package agent
+
+// Review of file changes, commands, and prompts.
+// With --review-edits, write_file, edit_file, and replace_lines find the
+// session in their context as a tools.Reviewer, as does posix_shell for
+// commands an "ask" rule of the command policy matches. Each change is sent to the
+// adaptors as a TagReview frame with its unified diff, and the tool waits
+// until the user answers with :review_accept or :review_reject. These commands run at once,
+// like :cancel, since the task that asked is still running. A second
+// TagReview frame for the same ID reports the decision. Prompts holding
+// secrets are reviewed the same way (see secrets.go), and may also be
+// answered with :review_mask.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// Review decisions reported in TagReview frames.
+const (
+	ReviewAccepted = "accepted"
+	ReviewRejected = "rejected"
+	ReviewExpired  = "expired"  // no decision before the timeout
+	ReviewCanceled = "canceled" // the task was canceled while waiting
+	ReviewMasked   = "masked"   // a prompt sent with its secrets masked
+)
+
+// ReviewFrame is the value of a TagReview frame. Decision is empty while the
+// change is pending, and Diff is only sent then.
+type ReviewFrame struct {
+	ID       string   `json:"id"`
+	Tool     string   `json:"tool"`
+	Path     string   `json:"path"`
+	Command  string   `json:"command,omitempty"` // the command line, for a posix_shell command instead of a file change
+	Diff     string   `json:"diff,omitempty"`
+	Secrets  []string `json:"secrets,omitempty"` // the redacted secrets of a prompt, for tool "prompt"
+	Decision string   `json:"decision,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Subject is what the review is about: the file's path, the command line,
+// or the secrets of a prompt.
+func (f ReviewFrame) Subject() string {
+	switch {
+	case f.Path != "":
+		return f.Path
+	case f.Command != "":
+		return f.Command
+	case len(f.Secrets) == 1:
+		return "holds a possible secret"
+	case len(f.Secrets) > 1:
+		return fmt.Sprintf("holds %d possible secrets", len(f.Secrets))
+	}
+	return ""
+}
+
+// reviewAnswer is the user's decision on a review.
+type reviewAnswer struct {
+	outcome string // ReviewAccepted, ReviewRejected, or ReviewMasked
+	reason  string // why it was rejected, if given
+}
+
+// pendingReview is a change waiting for the user's decision.
+type pendingReview struct {
+	frame  ReviewFrame
+	answer chan reviewAnswer
+}
+
+func isReviewCommand(cmd string) bool {
+	name, _, _ := strings.Cut(cmd, " ")
+	return name == "review_accept" || name == "review_reject" || name == "review_mask"
+}
+
+// ReviewChange implements tools.Reviewer: it shows change to the user and
+// waits for their decision or the end of ctx.
+func (s *Session) ReviewChange(ctx context.Context, change tools.Change) (tools.Decision, error) {
+	answer, err := s.awaitReview(ctx, ReviewFrame{Tool: change.Tool, Path: change.Path, Command: change.Command, Diff: change.Diff})
+	if err != nil {
+		return tools.Decision{}, err
+	}
+	return tools.Decision{Accepted: answer.outcome == ReviewAccepted, Reason: answer.reason}, nil
+}
+
+// awaitReview sends frame under a new ID and waits for the user's answer or
+// the end of ctx.
+func (s *Session) awaitReview(ctx context.Context, frame ReviewFrame) (reviewAnswer, error) {
+	s.mu.Lock()
+	if s.reviews == nil {
+		s.reviews = make(map[string]*pendingReview)
+	}
+	s.nextReviewID++
+	frame.ID = fmt.Sprintf("R%d", s.nextReviewID)
+	review := &pendingReview{frame: frame, answer: make(chan reviewAnswer, 1)}
+	s.reviews[frame.ID] = review
+	s.mu.Unlock()
+
+	s.writeReview(frame)
+	select {
+	case answer := <-review.answer:
+		return answer, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		_, pending := s.reviews[frame.ID]
+		delete(s.reviews, frame.ID)
+		s.mu.Unlock()
+		if !pending {
+			// The decision arrived as the context ended
+			return <-review.answer, nil
+		}
+		done := frame
+		done.Diff, done.Decision = "", ReviewCanceled
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			done.Decision = ReviewExpired
+		}
+		s.writeReview(done)
+		return reviewAnswer{}, ctx.Err()
+	}
+}
+
+// handleReviewDecision answers a pending review: :review_accept <id>,
+// :review_reject <id> [reason], or, for a prompt, :review_mask <id>.
+func (s *Session) handleReviewDecision(args []string, outcome string) {
+	if len(args) == 0 {
+		s.writeError("Usage: :review_accept <id>, :review_reject <id> [reason], or :review_mask <id>")
+		return
+	}
+	id := args[0]
+	s.mu.Lock()
+	review, ok := s.reviews[id]
+	if ok && outcome == ReviewMasked && len(review.frame.Secrets) == 0 {
+		s.mu.Unlock()
+		s.writeError(fmt.Sprintf("%s is not a prompt; only prompts can be sent masked", id))
+		return
+	}
+	delete(s.reviews, id)
+	s.mu.Unlock()
+	if !ok {
+		s.writeError(fmt.Sprintf("No change %s is awaiting review", id))
+		return
+	}
+
+	answer := reviewAnswer{outcome: outcome}
+	frame := review.frame
+	frame.Diff = ""
+	frame.Decision = outcome
+	if outcome == ReviewRejected {
+		answer.reason = strings.Join(args[1:], " ")
+		frame.Reason = answer.reason
+	}
+	review.answer <- answer
+	s.writeReview(frame)
+}
+
+func (s *Session) writeReview(frame ReviewFrame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return
+	}
+	s.writeGapped(stream.TagReview, string(data))
+}
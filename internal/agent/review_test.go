@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// waitOutput waits until a message containing text was written.
+func waitOutput(t *testing.T, o *lockedOutput, text string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !o.contains(text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no output containing %q", text)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newReviewTestSession() (*Session, *lockedOutput) {
+	output := &lockedOutput{}
+	return &Session{Input: &stream.NopInput{}, Output: output}, output
+}
+
+type reviewResult struct {
+	decision tools.Decision
+	err      error
+}
+
+func startReview(ctx context.Context, s *Session) <-chan reviewResult {
+	done := make(chan reviewResult, 1)
+	go func() {
+		d, err := s.ReviewChange(ctx, tools.Change{Tool: "edit_file", Path: "main.go", Diff: "--- a/main.go\n+++ b/main.go\n"})
+		done <- reviewResult{d, err}
+	}()
+	return done
+}
+
+func TestReviewAccept(t *testing.T) {
+	s, output := newReviewTestSession()
+	done := startReview(context.Background(), s)
+	waitOutput(t, output, `{"id":"R1","tool":"edit_file","path":"main.go","diff":"--- a/main.go`)
+
+	s.handleCommandSync(context.Background(), "review_accept R1")
+	result := <-done
+	if result.err != nil || !result.decision.Accepted {
+		t.Fatalf("got %+v, want accepted", result)
+	}
+	waitOutput(t, output, `"decision":"accepted"`)
+}
+
+func TestReviewRejectWithReason(t *testing.T) {
+	s, output := newReviewTestSession()
+	done := startReview(context.Background(), s)
+	waitOutput(t, output, `"id":"R1"`)
+
+	s.handleCommandSync(context.Background(), "review_reject R1 keep the old name")
+	result := <-done
+	if result.err != nil || result.decision.Accepted || result.decision.Reason != "keep the old name" {
+		t.Fatalf("got %+v, want rejected with the reason", result)
+	}
+	waitOutput(t, output, `"decision":"rejected","reason":"keep the old name"`)
+
+	// The change was answered; a second answer has nothing to decide
+	s.handleCommandSync(context.Background(), "review_accept R1")
+	waitOutput(t, output, "No change R1 is awaiting review")
+}
+
+func TestReviewExpires(t *testing.T) {
+	s, output := newReviewTestSession()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result := <-startReview(ctx, s)
+	if !errors.Is(result.err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the deadline", result.err)
+	}
+	waitOutput(t, output, `"decision":"expired"`)
+	if len(s.reviews) != 0 {
+		t.Error("an expired change is still pending")
+	}
+	if !isReviewCommand("review_reject R1 too late") || isReviewCommand("reviews") {
+		t.Error("isReviewCommand should match exactly the review commands")
+	}
+}
@@ -0,0 +1,69 @@
+package agent
+
+// Secrets in prompts.
+// Before a prompt is sent, its text, with the @path files it includes, is
+// scanned for what looks like credentials (see internal/secrets). A session
+// that can ask the user holds the prompt as a review of tool "prompt",
+// listing the secrets redacted: :review_accept sends it as written,
+// :review_mask sends it with the secrets replaced by markers, and
+// :review_reject drops it. Other sessions, such as batch runs, follow
+// --secret-scan: block refuses the prompt and warn sends it with a warning.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/secrets"
+)
+
+// checkSecrets returns the prompt text to send: text itself, or text with
+// its secrets masked. It reports false, having told the user, when the
+// prompt must not be sent.
+func (s *Session) checkSecrets(ctx context.Context, text string) (string, bool) {
+	s.mu.Lock()
+	mode, ask := s.secretScan, s.askSecrets
+	s.mu.Unlock()
+	if mode == "" || mode == secrets.ModeOff {
+		return text, true
+	}
+	findings := secrets.Scan(text)
+	if len(findings) == 0 {
+		return text, true
+	}
+	described := make([]string, len(findings))
+	for i, f := range findings {
+		described[i] = f.String()
+	}
+
+	if !ask {
+		if mode == secrets.ModeWarn {
+			s.writeNotify("Warning: the prompt holds possible secrets and is sent anyway (--secret-scan warn): " + strings.Join(described, "; "))
+			return text, true
+		}
+		err := fmt.Errorf("the prompt holds possible secrets and was not sent (--secret-scan block): %s", strings.Join(described, "; "))
+		s.writeError(err.Error())
+		s.recordFailure(domainerrors.ClassError, err)
+		return "", false
+	}
+
+	frame := ReviewFrame{
+		Tool:    "prompt",
+		Secrets: described,
+		Diff:    "The prompt holds what looks like credentials:\n" + strings.Join(described, "\n") + "\n",
+	}
+	answer, err := s.awaitReview(ctx, frame)
+	switch {
+	case err != nil:
+		s.recordFailure(domainerrors.ClassCanceled, err)
+		return "", false
+	case answer.outcome == ReviewRejected:
+		s.writeNotify("The prompt was not sent")
+		return "", false
+	case answer.outcome == ReviewMasked:
+		s.writeNotify("Sending the prompt with its secrets masked")
+		return secrets.Mask(text, findings), true
+	}
+	return text, true
+}
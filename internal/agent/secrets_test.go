@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/secrets"
+)
+
+// githubToken is made up, and split so that scanners do not take it for one.
+const githubToken = "ghp_" + "R8kd7Qm2Lx9PzWv4Tn6Yb3Hc5Jf1Gs0Ae8Ui"
+
+type secretsResult struct {
+	text string
+	send bool
+}
+
+func startSecretsCheck(s *Session, text string) <-chan secretsResult {
+	done := make(chan secretsResult, 1)
+	go func() {
+		text, send := s.checkSecrets(context.Background(), text)
+		done <- secretsResult{text, send}
+	}()
+	return done
+}
+
+func TestSecretsHoldPrompt(t *testing.T) {
+	prompt := "push with " + githubToken
+	for _, tt := range []struct {
+		command string
+		want    secretsResult
+		outcome string
+	}{
+		{"review_accept R1", secretsResult{prompt, true}, `"decision":"accepted"`},
+		{"review_mask R1", secretsResult{"push with [REDACTED GitHub token]", true}, `"decision":"masked"`},
+		{"review_reject R1", secretsResult{"", false}, `"decision":"rejected"`},
+	} {
+		s, output := newReviewTestSession()
+		s.secretScan, s.askSecrets = secrets.ModeBlock, true
+		done := startSecretsCheck(s, prompt)
+		waitOutput(t, output, `{"id":"R1","tool":"prompt","path":"","diff":"The prompt holds what looks like credentials:\nGitHub token ghp_… (40 chars) on line 1\n","secrets":["GitHub token ghp_… (40 chars) on line 1"]}`)
+		if output.contains("R8kd7Qm2") {
+			t.Fatal("the review shows the secret")
+		}
+
+		s.handleCommandSync(context.Background(), tt.command)
+		if got := <-done; got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.command, got, tt.want)
+		}
+		waitOutput(t, output, tt.outcome)
+	}
+}
+
+func TestSecretsWithoutAsking(t *testing.T) {
+	prompt := "push with " + githubToken
+
+	s, output := newReviewTestSession()
+	s.secretScan, s.askSecrets = secrets.ModeBlock, false
+	if _, send := s.checkSecrets(context.Background(), prompt); send {
+		t.Error("block: the prompt was sent")
+	}
+	if !output.contains("not sent (--secret-scan block): GitHub token ghp_… (40 chars) on line 1") || s.Err() == nil {
+		t.Errorf("block: no error recorded, output %q", output.out.Messages)
+	}
+
+	s, output = newReviewTestSession()
+	s.secretScan, s.askSecrets = secrets.ModeWarn, false
+	if text, send := s.checkSecrets(context.Background(), prompt); !send || text != prompt {
+		t.Errorf("warn: got %q, %v", text, send)
+	}
+	if !output.contains("sent anyway (--secret-scan warn)") {
+		t.Errorf("warn: no warning, output %q", output.out.Messages)
+	}
+
+	s, output = newReviewTestSession()
+	s.secretScan, s.askSecrets = secrets.ModeOff, true
+	if text, send := s.checkSecrets(context.Background(), prompt); !send || text != prompt || len(output.out.Messages) != 0 {
+		t.Errorf("off: got %q, %v, output %q", text, send, output.out.Messages)
+	}
+}
+
+func TestReviewMaskOnlyForPrompts(t *testing.T) {
+	s, output := newReviewTestSession()
+	done := startReview(context.Background(), s)
+	waitOutput(t, output, `"id":"R1"`)
+
+	s.handleCommandSync(context.Background(), "review_mask R1")
+	waitOutput(t, output, "R1 is not a prompt")
+	s.handleCommandSync(context.Background(), "review_accept R1")
+	if result := <-done; !result.decision.Accepted {
+		t.Errorf("got %+v, want the change still pending and then accepted", result)
+	}
+}
@@ -18,11 +18,14 @@ package agent
 //   - Delegating model listing/switching to ModelManager + RuntimeManager
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os/user"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -32,9 +35,19 @@ import (
 
 	debugpkg "github.com/alayacore/alayacore/internal/debug"
 	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/hooks"
 	"github.com/alayacore/alayacore/internal/llm"
 	"github.com/alayacore/alayacore/internal/llm/factory"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/memory"
+	"github.com/alayacore/alayacore/internal/prompts"
+	"github.com/alayacore/alayacore/internal/skills"
 	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/internal/telemetry"
+	"github.com/alayacore/alayacore/internal/tokens"
+	"github.com/alayacore/alayacore/internal/tools"
+	"github.com/alayacore/alayacore/pkg/alayacore"
 )
 
 // ============================================================================
@@ -51,13 +64,18 @@ type Task interface {
 type QueueItem struct {
 	Task
 	QueueID   string
+	ID        uint64 // task number shown as #N in queue, start, and completion notices
 	CreatedAt time.Time
 }
 
 // UserPrompt is a user text input task
 type UserPrompt struct {
 	Text    string
+	Images  []llm.ImagePart // attachments bound when the prompt was submitted
+	retry   bool            // sent by :retry; replaces the same prompt left unanswered
+	sent    string          // for a retry, the text the first attempt added to the history
 	queueID string
+	done    chan PromptResult // receives the outcome, for RunPrompt
 }
 
 func (UserPrompt) isTask() {}
@@ -82,27 +100,48 @@ type QueueItemInfo struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// SessionState is a snapshot of the modes that change what the next prompt
+// does, shown by the adaptors next to the input.
+type SessionState struct {
+	DryRun      bool   `json:"dry_run,omitempty"`      // the tools describe changes instead of making them
+	ReviewEdits bool   `json:"review_edits,omitempty"` // file changes wait for the user's approval (--review-edits)
+	ActiveSkill string `json:"active_skill,omitempty"`
+	Attachments int    `json:"attachments,omitempty"` // images attached to the next prompt
+}
+
 // SystemInfo holds session state for clients.
 type SystemInfo struct {
-	ContextTokens     int64           `json:"context"`
-	ContextLimit      int64           `json:"context_limit"`
-	TotalTokens       int64           `json:"total"`
-	QueueItems        []QueueItemInfo `json:"queue_items,omitempty"`
-	InProgress        bool            `json:"in_progress"`
-	CurrentStep       int             `json:"current_step,omitempty"`
-	MaxSteps          int             `json:"max_steps,omitempty"`
-	Models            []ModelInfo     `json:"models,omitempty"`
-	ActiveModelID     int             `json:"active_model_id,omitempty"`
-	ActiveModelConfig *ModelConfig    `json:"active_model_config,omitempty"`
-	ActiveModelName   string          `json:"active_model_name,omitempty"`
-	HasModels         bool            `json:"has_models"`
-	ModelConfigPath   string          `json:"model_config_path,omitempty"`
+	SessionState
+	ContextTokens      int64           `json:"context"`
+	ContextLimit       int64           `json:"context_limit"`
+	TotalTokens        int64           `json:"total"`
+	CachedTokens       int64           `json:"cached,omitempty"`       // prompt-cache read tokens in the last request
+	TotalCachedTokens  int64           `json:"total_cached,omitempty"` // prompt-cache read tokens across the session
+	QueueItems         []QueueItemInfo `json:"queue_items,omitempty"`
+	InProgress         bool            `json:"in_progress"`
+	CurrentStep        int             `json:"current_step,omitempty"`
+	MaxSteps           int             `json:"max_steps,omitempty"`
+	Models             []ModelInfo     `json:"models,omitempty"`
+	ActiveModelID      int             `json:"active_model_id,omitempty"`
+	ActiveModelConfig  *ModelConfig    `json:"active_model_config,omitempty"`
+	ActiveModelName    string          `json:"active_model_name,omitempty"`
+	HasModels          bool            `json:"has_models"`
+	ModelConfigPath    string          `json:"model_config_path,omitempty"`
+	EstimatedTokens    int64           `json:"estimated,omitempty"`           // preflight estimate of the last request
+	ContextWindow      int64           `json:"context_window,omitempty"`      // context_limit, or the known window of the model
+	Cost               *float64        `json:"cost,omitempty"`                // USD spent in the session; nil when a model has no known price
+	Checkpoint         string          `json:"checkpoint,omitempty"`          // latest checkpoint saved or rewound to
+	CheckpointDiverged bool            `json:"checkpoint_diverged,omitempty"` // the history has changed since that checkpoint
+	Workdir            string          `json:"workdir,omitempty"`             // the session's working directory
+	Timestamps         bool            `json:"timestamps,omitempty"`          // clients show the time of prompts and tool calls (:time)
+	ChangedFiles       int             `json:"changed_files,omitempty"`       // files the session's changes left different (:diff)
 }
 
 // SessionMeta is the frontmatter metadata.
 type SessionMeta struct {
-	CreatedAt time.Time `config:"created_at"`
-	UpdatedAt time.Time `config:"updated_at"`
+	CreatedAt     time.Time `config:"created_at"`
+	UpdatedAt     time.Time `config:"updated_at"`
+	SystemAddenda []string  // the instructions of :system, one quoted "system" line each
 }
 
 // SessionData is the persisted form of a Session.
@@ -124,28 +163,81 @@ type TLVChunk struct {
 
 // Session manages conversation state and task execution.
 type Session struct {
-	Messages          []llm.Message
-	Agent             *llm.Agent
-	Provider          llm.Provider
-	SessionFile       string
-	CreatedAt         time.Time
-	TotalSpent        llm.Usage
-	ContextTokens     int64
-	ContextLimit      int64
-	Input             stream.Input
-	Output            stream.Output
-	ModelManager      *ModelManager
-	RuntimeManager    *RuntimeManager
-	baseTools         []llm.Tool
-	systemPrompt      string
-	extraSystemPrompt string
-	debugAPI          bool
-	maxSteps          int
-	proxyURL          string
+	Messages           []llm.Message // written only by the task runner, under mu; other goroutines use HistoryLen
+	Agent              *alayacore.Client
+	Provider           llm.Provider
+	SessionFile        string
+	CreatedAt          time.Time
+	TotalSpent         llm.Usage // guarded by mu, like the token counts below; read with UsageSnapshot
+	ContextTokens      int64
+	CachedTokens       int64 // prompt-cache read tokens in the last request
+	ContextLimit       int64
+	Input              stream.Input
+	Output             stream.Output
+	ModelManager       *ModelManager
+	RuntimeManager     *RuntimeManager
+	baseTools          []llm.Tool
+	systemPrompt       string // replaced by the task runner after a skill reload
+	extraSystemPrompt  string
+	debugAPI           bool
+	verbose            bool // emit agent lifecycle events as TagSystemLog frames
+	maxSteps           int
+	maxFrameSize       int // longest input frame readFromInput accepts; 0 for the default
+	proxyURL           string
+	skillPolicy        *skills.Policy            // the active skill, handed to the tool wrappers in the context; nil disables allowed-tools enforcement
+	sampling           llm.SamplingOptions       // applied when the provider is (re)created; guarded by mu
+	hideReasoning      bool                      // :reasoning off; reasoning deltas are not forwarded; guarded by mu
+	pendingImages      []llm.ImagePart           // :attach images for the next prompt; guarded by mu
+	contextWarning     float64                   // fraction of the context window that triggers a preflight warning; 0 disables
+	estimatedTokens    int64                     // preflight estimate of the last request; guarded by mu
+	stallWarning       time.Duration             // warn when the provider sends nothing for this long; 0 disables
+	stallTimeout       time.Duration             // cancel a request whose stream sends nothing for this long; 0 disables
+	requestExtras      providers.RequestExtras   // --header and --extra-body, added to every provider request; guarded by mu
+	azureDefaults      providers.AzureOptions    // --azure-endpoint, --azure-deployment, --azure-api-version; guarded by mu
+	notifyAfter        time.Duration             // send TagTurnAlert for prompts that run at least this long; 0 disables
+	contextRecovery    bool                      // summarize and retry once when the provider reports the context window exceeded
+	autoContinue       int                       // continuations of a reply cut off at the output token limit (see autocontinue.go)
+	progress           Progress                  // streaming progress of the running prompt
+	hooks              *hooks.Hooks              // on_prompt_start and on_turn_end hooks; nil runs nothing
+	prices             providers.Prices          // USD per million tokens by model; nil prices nothing
+	totalCost          float64                   // USD spent on priced usage; guarded by mu
+	unpricedUsage      bool                      // some usage came from a model without a price; guarded by mu
+	checkpoints        []checkpoint              // :checkpoint snapshots, oldest first; guarded by mu
+	checkpoint         string                    // latest checkpoint saved or rewound to; guarded by mu
+	checkpointDiverged bool                      // the history has changed since that checkpoint; guarded by mu
+	todo               *tools.TodoList           // the plan kept by manage_todo
+	promptLib          *prompts.Library          // saved prompts for :prompt
+	lastPrompt         *UserPrompt               // the prompt :retry sends again; guarded by mu
+	metrics            *tools.Metrics            // tool and turn statistics for :stats
+	reviews            map[string]*pendingReview // file changes and prompts awaiting :review_accept or :review_reject; guarded by mu
+	nextReviewID       uint64                    // guarded by mu
+	steerCurrent       context.CancelCauseFunc   // cancels the streaming request for :steer; nil when none streams; guarded by mu
+	steering           []string                  // :steer guidance for that request; guarded by mu
+	skillReloader      SkillReloader             // rediscovers skills for :skills reload; nil disables it; guarded by mu
+	watchSkills        bool                      // --watch-skills; set before the session starts
+	workdir            *tools.Workdir            // the directory of :cd, where the tools resolve paths and run commands
+	changes            *tools.ChangeSet          // the files the tools changed, for :diff and :revert
+	launchDir          string                    // the directory the process started in, named in systemPrompt
+	dryRun             bool                      // :dryrun on; the tools describe changes instead of making them; guarded by mu
+	timestamps         bool                      // :time on; clients show when prompts and tool calls happened; guarded by mu
+	reviewEdits        bool                      // --review-edits; only reported in SessionState; guarded by mu
+	memory             *memory.Manager           // the persistent memory of --memory for :memory; nil disables it; guarded by mu
+	commandPolicy      *tools.CommandPolicy      // the --command-rule rules posix_shell consults, listed by :policy; nil disables it; guarded by mu
+	commandRules       *tools.CommandPolicy      // the rules :policy added in this session; guarded by mu
+	pathPolicy         *tools.PathPolicy         // the --allow-path, --deny-path, and --safe-mode rules; nil allows every path; guarded by mu
+	promptLimit        func() error              // asked before each task that calls the model (SessionOptions.PromptLimit); guarded by mu
+	secretScan         string                    // --secret-scan; "" scans nothing; guarded by mu
+	systemAddenda      []string                  // standing instructions of :system, added to the system prompt; guarded by mu
+	askSecrets         bool                      // prompts holding secrets wait for the user's decision; guarded by mu
+	failure            error                     // the first prompt that failed, as a *domainerrors.RunError; guarded by mu
+	taskFailure        error                     // the failure of the running task, like failure; guarded by mu
+	logger             *slog.Logger              // where failures are logged; nil drops them; set before the session starts
 
 	taskQueue     []QueueItem
 	taskAvailable chan struct{}
-	done          chan struct{}
+	done          chan struct{} // closed when the input ends
+	stopped       chan struct{} // closed when the task runner has finished the queue
+	closing       bool          // Shutdown was called; no task starts any more
 	inProgress    bool
 	cancelCurrent func()
 	nextPromptID  uint64
@@ -158,73 +250,159 @@ type Session struct {
 // Session Lifecycle
 // ============================================================================
 
-// LoadOrNewSession loads a session from file or creates a new one.
-func LoadOrNewSession(baseTools []llm.Tool, systemPrompt string, extraSystemPrompt string, maxSteps int, input stream.Input, output stream.Output, sessionFile string, modelConfigPath, runtimeConfigPath string, debugAPI bool, proxyURL string) (*Session, string) {
-	sessionFile = expandPath(sessionFile)
-	if sessionFile != "" {
-		if data, err := LoadSession(sessionFile); err == nil {
-			return RestoreFromSession(baseTools, systemPrompt, extraSystemPrompt, maxSteps, input, output, data, sessionFile, modelConfigPath, runtimeConfigPath, debugAPI, proxyURL), sessionFile
+// SessionOptions configures a session.
+type SessionOptions struct {
+	Tools             []llm.Tool
+	SystemPrompt      string
+	ExtraSystemPrompt string // --system, added to SystemPrompt
+	MaxSteps          int
+	SessionFile       string // where the session is saved; "" for none
+	ModelConfig       string // path of the model config file
+	RuntimeConfig     string // path of the runtime config file
+	DebugAPI          bool
+	Verbose           bool
+	Proxy             string
+	Sampling          llm.SamplingOptions
+	ContextWarning    float64       // context fraction that warns; 0 disables it
+	StallWarning      time.Duration // silence before the stream is reported stalled; 0 disables it
+	NotifyAfter       time.Duration // tasks running longer end with a notification; 0 disables it
+	ContextRecovery   bool          // summarize and retry when the context overflows
+	Prices            providers.Prices
+	Hooks             *hooks.Hooks
+
+	// Settings in place before the session reads its first input
+	SkillReloader SkillReloader           // enables :skills reload; nil disables it
+	WatchSkills   bool                    // --watch-skills: reload when a SKILL.md changes
+	StallTimeout  time.Duration           // cancel a stream that sends nothing this long; 0 never does
+	AutoContinue  int                     // continuations of a reply cut off at the output token limit
+	RequestExtras providers.RequestExtras // --header and --extra-body
+	Azure         providers.AzureOptions  // --azure-* defaults for azure models
+	Memory        *memory.Manager         // enables :memory; nil disables it
+	CommandPolicy *tools.CommandPolicy    // the --command-rule rules :policy lists
+	PathPolicy    *tools.PathPolicy       // the path rules @path and :attach obey
+	PromptLimit   func() error            // asked before each task that calls the model; nil for no limit
+	SecretScan    string                  // --secret-scan
+	AskSecrets    bool                    // the user can be asked about a prompt holding secrets
+	Logger        *slog.Logger            // where failures are logged; nil drops them
+	DryRun        bool
+	Timestamps    bool
+	ReviewEdits   bool
+	MaxFrameSize  int // longest input frame accepted; 0 for stream.DefaultMaxFrameSize
+}
+
+// LoadOrNewSession loads the session in opts.SessionFile, or creates a new
+// one when there is none. It returns the session and its expanded file path.
+func LoadOrNewSession(input stream.Input, output stream.Output, opts SessionOptions) (*Session, string) {
+	opts.SessionFile = expandPath(opts.SessionFile)
+	if opts.SessionFile != "" {
+		if data, err := LoadSession(opts.SessionFile); err == nil {
+			return RestoreFromSession(input, output, data, opts), opts.SessionFile
 		}
 	}
-	return NewSession(baseTools, systemPrompt, extraSystemPrompt, maxSteps, input, output, sessionFile, modelConfigPath, runtimeConfigPath, debugAPI, proxyURL), sessionFile
+	return NewSession(input, output, opts), opts.SessionFile
 }
 
 // NewSession creates a fresh session.
-func NewSession(baseTools []llm.Tool, systemPrompt string, extraSystemPrompt string, maxSteps int, input stream.Input, output stream.Output, sessionFile string, modelConfigPath, runtimeConfigPath string, debugAPI bool, proxyURL string) *Session {
-	s := &Session{
-		SessionFile:       sessionFile,
-		CreatedAt:         time.Now(),
-		Input:             input,
-		Output:            output,
-		ModelManager:      NewModelManager(modelConfigPath),
-		RuntimeManager:    NewRuntimeManager(runtimeConfigPath, modelConfigPath),
-		baseTools:         baseTools,
-		systemPrompt:      systemPrompt,
-		extraSystemPrompt: extraSystemPrompt,
-		debugAPI:          debugAPI,
-		proxyURL:          proxyURL,
-		maxSteps:          maxSteps,
-		taskQueue:         make([]QueueItem, 0),
-		taskAvailable:     make(chan struct{}, 1),
-		done:              make(chan struct{}),
-	}
-	s.initModelManager()
-	s.sendSystemInfo()
-	go s.readFromInput()
-	go s.taskRunner()
+func NewSession(input stream.Input, output stream.Output, opts SessionOptions) *Session {
+	s := newSession(input, output, opts)
+	s.CreatedAt = time.Now()
+	s.start()
 	return s
 }
 
-// RestoreFromSession creates a session from saved data.
-func RestoreFromSession(baseTools []llm.Tool, systemPrompt string, extraSystemPrompt string, maxSteps int, input stream.Input, output stream.Output, data *SessionData, sessionFile string, modelConfigPath, runtimeConfigPath string, debugAPI bool, proxyURL string) *Session {
-	s := &Session{
-		Messages:          data.Messages,
-		SessionFile:       sessionFile,
-		CreatedAt:         data.CreatedAt,
+// newSession builds a session from opts, not yet started.
+func newSession(input stream.Input, output stream.Output, opts SessionOptions) *Session {
+	return &Session{
+		SessionFile:       opts.SessionFile,
 		Input:             input,
 		Output:            output,
-		ModelManager:      NewModelManager(modelConfigPath),
-		RuntimeManager:    NewRuntimeManager(runtimeConfigPath, modelConfigPath),
-		baseTools:         baseTools,
-		systemPrompt:      systemPrompt,
-		extraSystemPrompt: extraSystemPrompt,
-		debugAPI:          debugAPI,
-		proxyURL:          proxyURL,
-		maxSteps:          maxSteps,
+		ModelManager:      NewModelManager(opts.ModelConfig),
+		RuntimeManager:    NewRuntimeManager(opts.RuntimeConfig, opts.ModelConfig),
+		baseTools:         opts.Tools,
+		systemPrompt:      opts.SystemPrompt,
+		extraSystemPrompt: opts.ExtraSystemPrompt,
+		debugAPI:          opts.DebugAPI,
+		verbose:           opts.Verbose,
+		proxyURL:          opts.Proxy,
+		skillPolicy:       skills.NewPolicy(),
+		sampling:          opts.Sampling,
+		contextWarning:    opts.ContextWarning,
+		stallWarning:      opts.StallWarning,
+		notifyAfter:       opts.NotifyAfter,
+		contextRecovery:   opts.ContextRecovery,
+		prices:            opts.Prices,
+		hooks:             opts.Hooks,
+		maxSteps:          opts.MaxSteps,
+		maxFrameSize:      opts.MaxFrameSize,
+		skillReloader:     opts.SkillReloader,
+		watchSkills:       opts.WatchSkills && opts.SkillReloader != nil,
+		stallTimeout:      opts.StallTimeout,
+		autoContinue:      max(0, opts.AutoContinue),
+		requestExtras:     opts.RequestExtras,
+		azureDefaults:     opts.Azure,
+		memory:            opts.Memory,
+		commandPolicy:     opts.CommandPolicy,
+		commandRules:      &tools.CommandPolicy{},
+		pathPolicy:        opts.PathPolicy,
+		promptLimit:       opts.PromptLimit,
+		secretScan:        opts.SecretScan,
+		askSecrets:        opts.AskSecrets,
+		logger:            opts.Logger,
+		dryRun:            opts.DryRun,
+		timestamps:        opts.Timestamps,
+		reviewEdits:       opts.ReviewEdits,
 		taskQueue:         make([]QueueItem, 0),
 		taskAvailable:     make(chan struct{}, 1),
 		done:              make(chan struct{}),
+		stopped:           make(chan struct{}),
 	}
+}
+
+// start sets up the per-session state, checks the configuration, and starts
+// reading input and running tasks.
+func (s *Session) start() {
+	s.todo = tools.NewTodoList(s.sendPlan)
+	s.changes = tools.NewChangeSet(s.sendSystemInfo)
+	s.initWorkdir()
+	s.promptLib = defaultPromptLibrary()
+	s.metrics = tools.NewMetrics(nil)
 	s.initModelManager()
+	s.checkModelConfig()
+	s.checkSampling()
+	s.checkOllamaModel()
 	s.sendSystemInfo()
 	go s.readFromInput()
 	go s.taskRunner()
+	if s.watchSkills {
+		go s.watchSkillFiles(s.skillReloader)
+	}
+}
+
+// RestoreFromSession creates a session from saved data.
+func RestoreFromSession(input stream.Input, output stream.Output, data *SessionData, opts SessionOptions) *Session {
+	s := newSession(input, output, opts)
+	s.Messages = data.Messages
+	s.systemAddenda = data.SystemAddenda
+	s.CreatedAt = data.CreatedAt
+	s.start()
 
 	// Send TLV chunks directly to output (avoids reconstruction)
 	for _, chunk := range data.TLVChunks {
+		// Large tool results are split like live ones
+		var tr toolResultData
+		if chunk.Tag == stream.TagFunctionResult && json.Unmarshal([]byte(chunk.Value), &tr) == nil {
+			if tr.Kind != "" && json.Valid([]byte(tr.Output)) {
+				s.writeTypedResult(tr.ID, tr.Kind, json.RawMessage(tr.Output))
+			}
+			s.writeToolOutput(tr.ID, tr.Output, tr.Error)
+			continue
+		}
 		//nolint:errcheck // Best effort write, errors ignored
 		_ = stream.WriteTLV(s.Output, chunk.Tag, chunk.Value)
 	}
+	if len(data.SystemAddenda) > 0 {
+		s.listSystemAddenda()
+	}
 	if len(data.TLVChunks) > 0 {
 		s.Output.Flush()
 	}
@@ -262,6 +440,31 @@ func (s *Session) initModelManager() {
 	s.ModelManager.SetActiveToFirst()
 }
 
+// checkModelConfig reports models with an unknown protocol_type.
+func (s *Session) checkModelConfig() {
+	if s.ModelManager == nil {
+		return
+	}
+	if err := s.ModelManager.Validate(); err != nil {
+		s.writeError(err.Error())
+	}
+}
+
+// checkSampling reports sampling options the active model's provider cannot honor,
+// so a bad flag combination surfaces at startup instead of on the first prompt.
+func (s *Session) checkSampling() {
+	if s.ModelManager == nil {
+		return
+	}
+	active := s.ModelManager.GetActive()
+	if active == nil {
+		return
+	}
+	if err := factory.ValidateSampling(active.ProtocolType, s.sampling); err != nil {
+		s.writeError(domainerrors.Wrap("sampling", err).Error())
+	}
+}
+
 // GetRuntimeManager returns the runtime manager for the session
 func (s *Session) GetRuntimeManager() *RuntimeManager {
 	return s.RuntimeManager
@@ -284,18 +487,19 @@ func (s *Session) ensureAgentInitialized() string {
 		return "No model configured. Please add a model to ~/.alayacore/model.conf"
 	}
 
-	provider, err := createProviderFromConfig(activeModel, s.debugAPI, s.proxyURL)
+	s.mu.Lock()
+	sampling, extras, azure := s.sampling, s.requestExtras, s.azureDefaults
+	s.mu.Unlock()
+
+	provider, err := createProviderFromConfig(activeModel, s.debugAPI, s.proxyURL, sampling, extras, azure)
 	if err != nil {
 		return "Failed to create provider: " + err.Error()
 	}
 
-	agent := llm.NewAgent(llm.AgentConfig{
-		Provider:          provider,
-		Tools:             s.baseTools,
-		SystemPrompt:      s.systemPrompt,
-		ExtraSystemPrompt: s.extraSystemPrompt,
-		MaxSteps:          s.maxSteps,
-	})
+	agent, err := s.newClient(provider)
+	if err != nil {
+		return "Failed to create agent: " + err.Error()
+	}
 
 	s.mu.Lock()
 	s.Agent = agent
@@ -307,18 +511,19 @@ func (s *Session) ensureAgentInitialized() string {
 }
 
 func (s *Session) initAgentFromConfig(modelConfig *ModelConfig) error {
-	provider, err := createProviderFromConfig(modelConfig, s.debugAPI, s.proxyURL)
+	s.mu.Lock()
+	sampling, extras, azure := s.sampling, s.requestExtras, s.azureDefaults
+	s.mu.Unlock()
+
+	provider, err := createProviderFromConfig(modelConfig, s.debugAPI, s.proxyURL, sampling, extras, azure)
 	if err != nil {
 		return err
 	}
 
-	agent := llm.NewAgent(llm.AgentConfig{
-		Provider:          provider,
-		Tools:             s.baseTools,
-		SystemPrompt:      s.systemPrompt,
-		ExtraSystemPrompt: s.extraSystemPrompt,
-		MaxSteps:          s.maxSteps,
-	})
+	agent, err := s.newClient(provider)
+	if err != nil {
+		return err
+	}
 
 	s.mu.Lock()
 	s.Agent = agent
@@ -328,6 +533,17 @@ func (s *Session) initAgentFromConfig(modelConfig *ModelConfig) error {
 	return nil
 }
 
+// newClient wraps a provider in an agent client with the session's tools and prompts.
+func (s *Session) newClient(provider llm.Provider) (*alayacore.Client, error) {
+	return alayacore.New(alayacore.Config{
+		Provider:          provider,
+		Tools:             s.baseTools,
+		SystemPrompt:      s.sessionSystemPrompt(),
+		ExtraSystemPrompt: s.extraSystemPrompt,
+		MaxSteps:          s.maxSteps,
+	})
+}
+
 func (s *Session) applyModelContextLimit(model *ModelConfig) {
 	if model == nil || model.ContextLimit <= 0 {
 		return
@@ -337,7 +553,15 @@ func (s *Session) applyModelContextLimit(model *ModelConfig) {
 	s.mu.Unlock()
 }
 
-func createProviderFromConfig(config *ModelConfig, debugAPI bool, proxyURL string) (llm.Provider, error) {
+// log returns the logger of SessionOptions.Logger, or one that drops every
+// record.
+func (s *Session) log() *slog.Logger {
+	return logging.OrDiscard(s.logger)
+}
+
+// newHTTPClient returns the client for provider requests: proxied with
+// --proxy and logged with --debug-api. nil means http.DefaultClient.
+func newHTTPClient(debugAPI bool, proxyURL string) (*http.Client, error) {
 	var client *http.Client
 	var err error
 	if proxyURL != "" {
@@ -352,6 +576,26 @@ func createProviderFromConfig(config *ModelConfig, debugAPI bool, proxyURL strin
 	} else if debugAPI {
 		client = debugpkg.NewHTTPClient()
 	}
+	return client, nil
+}
+
+func createProviderFromConfig(config *ModelConfig, debugAPI bool, proxyURL string, sampling llm.SamplingOptions, extras providers.RequestExtras, azure providers.AzureOptions) (llm.Provider, error) {
+	client, err := newHTTPClient(debugAPI, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// A model Ollama has not pulled fails every request with a bare 404
+	if strings.EqualFold(config.ProtocolType, "ollama") {
+		if err := checkModelAvailable(client, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// --azure-deployment names the deployment only of models without a name
+	if config.ModelName != "" {
+		azure.Deployment = ""
+	}
 
 	return factory.NewProvider(factory.ProviderConfig{
 		Type:        config.ProtocolType,
@@ -360,9 +604,147 @@ func createProviderFromConfig(config *ModelConfig, debugAPI bool, proxyURL strin
 		Model:       config.ModelName,
 		HTTPClient:  client,
 		PromptCache: config.PromptCache,
+		Sampling:    sampling,
+		Extras:      extras,
+		Azure: providers.AzureOptions{
+			Deployment: config.Deployment,
+			APIVersion: config.APIVersion,
+		}.WithDefaults(azure),
 	})
 }
 
+// ============================================================================
+// State Snapshots
+// ============================================================================
+
+// Usage is a snapshot of the session's token accounting.
+type Usage struct {
+	ContextTokens int64     // input tokens of the last request
+	ContextLimit  int64     // context_limit of the active model; 0 when unset
+	CachedTokens  int64     // prompt-cache read tokens of the last request
+	Spent         llm.Usage // totals across the session
+}
+
+// UsageSnapshot returns the token accounting. It is safe to call from any
+// goroutine.
+func (s *Session) UsageSnapshot() Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Usage{
+		ContextTokens: s.ContextTokens,
+		ContextLimit:  s.ContextLimit,
+		CachedTokens:  s.CachedTokens,
+		Spent:         s.TotalSpent,
+	}
+}
+
+// HistoryLen returns the number of messages in the conversation. It is safe
+// to call from any goroutine.
+func (s *Session) HistoryLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.Messages)
+}
+
+// PromptHistory returns the text of the prompts in the conversation, newest
+// first and without repeats, for picking one to send again. It is safe to
+// call from any goroutine.
+func (s *Session) PromptHistory() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var prompts []string
+	seen := make(map[string]bool)
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		m := s.Messages[i]
+		if !isUserPrompt(m) {
+			continue
+		}
+		var sb strings.Builder
+		for _, part := range m.Content {
+			if text, ok := part.(llm.TextPart); ok {
+				sb.WriteString(text.Text)
+			}
+		}
+		text := sb.String()
+		if strings.TrimSpace(text) == "" || text == continuePrompt || seen[text] {
+			continue
+		}
+		seen[text] = true
+		prompts = append(prompts, text)
+	}
+	return prompts
+}
+
+// Wait blocks until the input has ended and every task queued before it has
+// finished. Tasks are not canceled: to stop early, send :cancel_all before
+// closing the input.
+func (s *Session) Wait() {
+	<-s.stopped
+}
+
+// Shutdown stops the session for good: queued tasks are dropped, the running
+// one is canceled, and nothing submitted later runs. It waits for the running
+// task to return, or for ctx to be done, then flushes the output. It returns
+// ctx.Err() when the task did not stop in time. Unlike Wait, it does not need
+// the input to end first.
+func (s *Session) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.taskQueue = make([]QueueItem, 0)
+	cancelCurrent := s.cancelCurrent
+	s.mu.Unlock()
+	if cancelCurrent != nil {
+		cancelCurrent()
+	}
+	s.signalTaskAvailable()
+
+	var err error
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	_ = s.Output.Flush() //nolint:errcheck // nothing left to report it to
+	return err
+}
+
+// CloseIfIdle stops the session from taking tasks when none is running or
+// queued, and reports whether it did. The session can then be saved without
+// racing a task; it still needs Shutdown to stop.
+func (s *Session) CloseIfIdle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inProgress || len(s.taskQueue) > 0 {
+		return false
+	}
+	s.closing = true
+	return true
+}
+
+// IsInProgress reports whether a task is running. It is safe to call from
+// any goroutine.
+func (s *Session) IsInProgress() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inProgress
+}
+
+// setMessages replaces the conversation. Only the task runner changes
+// s.Messages, so it reads it without locking; the lock keeps readers on
+// other goroutines, such as HistoryLen, from racing with the write.
+func (s *Session) setMessages(messages []llm.Message) {
+	s.mu.Lock()
+	s.Messages = messages
+	s.mu.Unlock()
+}
+
+// appendMessages adds messages to the conversation, like setMessages.
+func (s *Session) appendMessages(messages ...llm.Message) {
+	s.mu.Lock()
+	s.Messages = append(s.Messages, messages...)
+	s.mu.Unlock()
+}
+
 // ============================================================================
 // Input Processing
 // ============================================================================
@@ -378,24 +760,44 @@ func (s *Session) readFromInput() {
 		s.mu.Unlock()
 		s.signalTaskAvailable()
 	}()
+	frames := stream.NewFrameReader(s.Input, stream.WithMaxFrameSize(s.maxFrameSize))
 	for {
-		tag, value, err := stream.ReadTLV(s.Input)
+		tag, value, err := frames.Next()
+		if errors.Is(err, stream.ErrMalformedFrame) {
+			// Report and keep reading; the reader resynchronizes on the next frame
+			s.log().Warn("dropped malformed input frame", "err", err)
+			s.writeError(domainerrors.Wrap("input", err).Error())
+			continue
+		}
 		if err != nil {
+			// EOF or closed input: the client is gone
 			return
 		}
+		if tag == stream.TagUserImage {
+			if name, data, ok := stream.DecodeImage(value); ok {
+				s.attachImage(name, data)
+			} else {
+				s.writeError(domainerrors.NewSessionErrorf("input", "Invalid image attachment").Error())
+			}
+			continue
+		}
 		if tag != stream.TagTextUser {
 			s.writeError(domainerrors.NewSessionErrorf("input", "Invalid input tag: %s", tag).Error())
 			continue
 		}
 		if len(value) > 0 && value[0] == ':' {
 			cmd := value[1:]
-			if cmd == "cancel" || cmd == "cancel_all" || cmd == "model_load" || cmd == "taskqueue_get_all" || strings.HasPrefix(cmd, "taskqueue_del ") || strings.HasPrefix(cmd, "model_set ") {
+			if isRewindCommand(cmd) && s.isBusy() {
+				s.writeError("Cannot rewind while a task is running. Please wait or cancel the current task.")
+				continue
+			}
+			if cmd == "cancel" || cmd == "cancel_all" || cmd == "model_load" || cmd == "taskqueue_get_all" || strings.HasPrefix(cmd, "taskqueue_del ") || strings.HasPrefix(cmd, "model_set ") || isAttachCommand(cmd) || isPromptCommand(cmd) || isRetryCommand(cmd) || isReviewCommand(cmd) || isSteerCommand(cmd) {
 				s.handleCommandSync(context.Background(), cmd)
 			} else {
 				s.submitTask(CommandPrompt{Command: cmd})
 			}
 		} else {
-			s.submitTask(UserPrompt{Text: value})
+			s.submitTask(UserPrompt{Text: value, Images: s.takeAttachments()})
 		}
 	}
 }
@@ -405,15 +807,31 @@ func (s *Session) readFromInput() {
 // ============================================================================
 
 func (s *Session) submitTask(task Task) {
+	s.enqueue(task, false)
+}
+
+// enqueue adds task to the queue, ahead of the waiting tasks when first is
+// set. It reports whether the task was queued.
+func (s *Session) enqueue(task Task, first bool) bool {
+	if callsModel(task) && !s.allowPrompt() {
+		return false
+	}
 	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return false
+	}
 	if len(s.taskQueue) >= 10 {
 		s.mu.Unlock()
 		s.writeNotify("Busy. Cannot queue, try again shortly.")
-		return
+		return false
 	}
 
+	// A task is queued behind others when one is running or waiting
+	busy := s.inProgress || len(s.taskQueue) > 0
 	s.nextQueueID++
-	queueID := fmt.Sprintf("Q%d", s.nextQueueID)
+	id := s.nextQueueID
+	queueID := fmt.Sprintf("Q%d", id)
 
 	switch t := task.(type) {
 	case UserPrompt:
@@ -427,13 +845,26 @@ func (s *Session) submitTask(task Task) {
 	item := QueueItem{
 		Task:      task,
 		QueueID:   queueID,
+		ID:        id,
 		CreatedAt: time.Now(),
 	}
 
-	s.taskQueue = append(s.taskQueue, item)
+	waiting := len(s.taskQueue) > 0
+	if first {
+		s.taskQueue = append([]QueueItem{item}, s.taskQueue...)
+	} else {
+		s.taskQueue = append(s.taskQueue, item)
+	}
 	s.signalTaskAvailable()
 	s.mu.Unlock()
+	switch {
+	case busy && first && waiting:
+		s.writeNotifyf("[Queued #%d, next]", id)
+	case busy:
+		s.writeNotifyf("[Queued #%d]", id)
+	}
 	s.sendSystemInfo()
+	return true
 }
 
 func (s *Session) signalTaskAvailable() {
@@ -444,23 +875,39 @@ func (s *Session) signalTaskAvailable() {
 }
 
 func (s *Session) taskRunner() {
+	defer close(s.stopped)
+	// No task can change a file past here, so the copies of large originals
+	// go, before Wait or Shutdown returns
+	defer s.changes.Close() //nolint:errcheck // only temporary files are left behind
 	for {
 		task, ok := s.waitForNextTask()
 		if !ok {
 			return
 		}
 		s.setInProgress(true)
+		start, tokensBefore := time.Now(), s.totalTokens()
 		s.runTask(task)
 		s.setInProgress(s.hasQueuedTasks())
+		// After the session is idle, so the next prompt of a batch is not
+		// reported as queued
+		if p, ok := task.Task.(UserPrompt); ok && p.done != nil {
+			p.done <- s.promptResult(p.Text, time.Since(start), s.totalTokens()-tokensBefore)
+		}
 	}
 }
 
 func (s *Session) waitForNextTask() (QueueItem, bool) {
 	for {
 		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			return QueueItem{}, false
+		}
 		if len(s.taskQueue) > 0 {
 			item := s.taskQueue[0]
 			s.taskQueue = s.taskQueue[1:]
+			// Mark busy under the same lock so tasks submitted now are reported as queued
+			s.inProgress = true
 			s.mu.Unlock()
 			return item, true
 		}
@@ -473,6 +920,13 @@ func (s *Session) waitForNextTask() (QueueItem, bool) {
 	}
 }
 
+// isBusy reports whether a task is running or waiting in the queue.
+func (s *Session) isBusy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inProgress || len(s.taskQueue) > 0
+}
+
 func (s *Session) hasQueuedTasks() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -490,11 +944,20 @@ func (s *Session) setInProgress(v bool) {
 }
 
 func (s *Session) runTask(item QueueItem) {
+	defer s.signalTurnEnd(item.ID)
+	s.mu.Lock()
+	s.taskFailure = nil
+	s.mu.Unlock()
 	s.sendSystemInfo()
 
+	// Skills may have been reloaded by another session
+	s.refreshSystemPrompt()
 	errMsg := s.ensureAgentInitialized()
 	if errMsg != "" {
 		s.writeError(errMsg)
+		if _, ok := item.Task.(UserPrompt); ok {
+			s.recordFailure(domainerrors.ClassConfig, errors.New(errMsg))
+		}
 		s.sendSystemInfo()
 		return
 	}
@@ -503,9 +966,28 @@ func (s *Session) runTask(item QueueItem) {
 	s.currentStep = 0
 	s.mu.Unlock()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// manage_todo finds the session's plan, the metrics wrapper its
+	// collector, the file tools their reviewer, change set, and working
+	// directory, every tool the active skill, and posix_shell the session's
+	// command rules, in the context
+	ctx := tools.WithMetricsCollector(tools.WithTodoList(context.Background(), s.todo), s.metrics)
+	ctx = tools.WithReviewer(ctx, s)
+	ctx = tools.WithChangeSet(ctx, s.changes)
+	ctx = tools.WithActiveSkill(ctx, s.skillPolicy)
+	s.mu.Lock()
+	ctx = tools.WithCommandRules(ctx, s.commandRules)
+	s.mu.Unlock()
+	if s.workdir != nil {
+		ctx = tools.WithWorkdir(ctx, s.workdir)
+	}
+	ctx = tools.WithDryRunMode(ctx, s.DryRun())
+	ctx, cancel := context.WithCancel(ctx)
 	s.mu.Lock()
 	s.cancelCurrent = cancel
+	if s.closing {
+		// Shutdown came between taking the task and here
+		cancel()
+	}
 	s.mu.Unlock()
 	defer func() {
 		s.mu.Lock()
@@ -515,24 +997,60 @@ func (s *Session) runTask(item QueueItem) {
 
 	switch t := item.Task.(type) {
 	case UserPrompt:
-		s.signalPromptStart(t.Text)
-		s.handleUserPrompt(ctx, t.Text)
+		start := time.Now()
+		tokensBefore := s.totalTokens()
+		costBefore, _ := s.totalCostUSD()
+		s.signalPromptStart(item.ID, t.Text)
+		s.progress.begin(s.activeModelName())
+		stallDone := make(chan struct{})
+		go s.watchStall(stallDone)
+		if t.retry {
+			s.dropUnansweredPrompt(t.sent)
+		}
+		s.handleUserPrompt(ctx, t.Text, t.Images)
+		s.metrics.RecordTurn(time.Since(start))
+		close(stallDone)
+		s.progress.end()
+		costAfter, priced := s.totalCostUSD()
+		elapsed := time.Since(start)
+		telemetry.Prompts.Inc()
+		telemetry.PromptDuration.Observe(elapsed.Seconds())
+		summary := s.signalPromptDone(item.ID, ctx.Err() != nil, s.totalTokens()-tokensBefore, elapsed, s.progress.Snapshot().TokensPerSecond, costAfter-costBefore, priced)
+		// A canceled prompt needs no alert: the user is there
+		if ctx.Err() == nil {
+			s.alertLongTurn(elapsed, summary, t.Text)
+		}
 	case CommandPrompt:
-		s.signalCommandStart(t.Command)
+		s.signalCommandStart(item.ID, t.Command)
 		s.handleCommandSync(ctx, t.Command)
 	}
 
 	if ctx.Err() == context.Canceled {
 		s.appendCancelMessage()
 	}
+	s.updateCheckpointDivergence()
 }
 
+// totalTokens returns the input plus output tokens spent so far.
+func (s *Session) totalTokens() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.TotalSpent.InputTokens + s.TotalSpent.OutputTokens
+}
+
+// interruptedMarker ends the partial reply of a canceled prompt, so the model
+// knows the text stops early on purpose.
+const interruptedMarker = "[response interrupted by user]"
+
+// appendCancelMessage closes a canceled prompt with a placeholder reply when
+// nothing of the response was kept, so the history does not end with the
+// user's message.
 func (s *Session) appendCancelMessage() {
 	if len(s.Messages) == 0 {
 		return
 	}
 	if s.Messages[len(s.Messages)-1].Role == llm.RoleUser {
-		s.Messages = append(s.Messages, llm.Message{
+		s.appendMessages(llm.Message{
 			Role:    llm.RoleAssistant,
 			Content: []llm.ContentPart{llm.TextPart{Type: "text", Text: "The user canceled."}},
 		})
@@ -566,16 +1084,74 @@ func (s *Session) DeleteQueueItem(queueID string) bool {
 // Prompt Processing
 // ============================================================================
 
-func (s *Session) handleUserPrompt(ctx context.Context, prompt string) {
+func (s *Session) handleUserPrompt(ctx context.Context, prompt string, images []llm.ImagePart) {
+	text, refs, err := expandFileRefs(prompt, s.readPromptFile)
+	// :retry reads the files again
+	s.rememberPrompt(prompt, text, images)
+	if err != nil {
+		s.writeError(err.Error() + "; the prompt was not sent")
+		s.recordFailure(domainerrors.ClassError, err)
+		return
+	}
+	for _, ref := range refs {
+		s.writeNotify("[included " + ref.String() + "]")
+	}
+	text, send := s.checkSecrets(ctx, text)
+	if !send {
+		return
+	}
+
 	if s.shouldAutoSummarize() {
 		s.autoSummarize(ctx)
 	}
 
-	s.Messages = append(s.Messages, llm.NewUserMessage(prompt))
+	message := llm.NewUserMessage(text)
+	for _, image := range images {
+		message.Content = append(message.Content, image)
+	}
+	turnStart := len(s.Messages)
+	s.appendMessages(message)
+	s.checkContextEstimate()
 
-	_, err := s.processPrompt(ctx, prompt, s.Messages)
+	//nolint:errcheck // hook failures are logged by the hook runner and never block the prompt
+	_ = s.hooks.Run(ctx, hooks.PromptStart, hooks.Env{Prompt: prompt})
 
-	s.Messages = cleanIncompleteToolCalls(s.Messages)
+	pos := &streamPosition{}
+	partial, err := s.streamSteerable(ctx, pos)
+	if err != nil && ctx.Err() == nil && s.contextRecovery && providers.IsContextLengthError(err) {
+		partial, err = s.recoverContextLength(ctx, prompt, message, turnStart, err)
+	}
+	partial, err = s.continueInterrupted(ctx, pos, partial, err)
+	if err == nil {
+		partial, err = s.continueTruncated(ctx, pos)
+	}
+
+	if isEmptyResponse(err) {
+		// Drop the whole turn, so the next prompt does not follow a prompt
+		// without a reply
+		s.setMessages(s.Messages[:turnStart])
+		err = fmt.Errorf("%w; the prompt was not added to the history, use :retry to send it again", err)
+	}
+
+	s.setMessages(cleanIncompleteToolCalls(s.Messages))
+	if ctx.Err() != nil && strings.TrimSpace(partial) != "" {
+		// Keep what was streamed so "continue" has something to continue from
+		s.appendMessages(llm.NewAssistantMessage([]llm.ContentPart{
+			llm.TextPart{Type: "text", Text: partial + "\n\n" + interruptedMarker},
+		}))
+	}
+
+	status := "done"
+	switch {
+	case ctx.Err() != nil:
+		status = "canceled"
+		s.recordFailure(domainerrors.ClassCanceled, ctx.Err())
+	case err != nil:
+		status = "error"
+		s.recordFailure(domainerrors.ClassError, err)
+	}
+	//nolint:errcheck // hook failures are logged by the hook runner
+	_ = s.hooks.Run(context.WithoutCancel(ctx), hooks.TurnEnd, hooks.Env{Prompt: prompt, Status: status})
 
 	if err != nil {
 		s.writeError(err.Error())
@@ -583,20 +1159,107 @@ func (s *Session) handleUserPrompt(ctx context.Context, prompt string) {
 	}
 }
 
+// recoverContextLength retries a prompt the provider rejected as too long for
+// the context window, once: the turn is dropped, the history before it is
+// summarized, and the prompt is sent again. When that fails too, the original
+// error is returned.
+func (s *Session) recoverContextLength(ctx context.Context, prompt string, message llm.Message, turnStart int, origErr error) (string, error) {
+	s.writeNotify("The request exceeded the model's context window. Summarizing the earlier conversation and retrying...")
+	s.setMessages(s.Messages[:turnStart])
+	if !s.summarize(ctx, DefaultSummarizeKeep) {
+		s.appendMessages(message)
+		s.writeNotify("Could not shrink the conversation; run :summarize or start a new session")
+		return "", origErr
+	}
+
+	s.appendMessages(message)
+	s.checkContextEstimate()
+	_, partial, err := s.processPrompt(ctx, nil, s.Messages)
+	if err != nil && ctx.Err() == nil {
+		s.writeNotify("The retry failed as well")
+		return partial, origErr
+	}
+	return partial, err
+}
+
 func (s *Session) shouldAutoSummarize() bool {
-	return s.ContextLimit > 0 && s.ContextTokens > 0 &&
-		s.ContextTokens >= s.ContextLimit*80/100
+	u := s.UsageSnapshot()
+	return u.ContextLimit > 0 && u.ContextTokens > 0 &&
+		u.ContextTokens >= u.ContextLimit*80/100
+}
+
+// checkContextEstimate estimates the request about to be sent and warns when it
+// exceeds the configured fraction of the model's context window. Provider usage
+// only arrives after the request, so this is the last chance to catch an
+// oversized paste before paying for it.
+func (s *Session) checkContextEstimate() {
+	system := s.sessionSystemPrompt()
+	if s.extraSystemPrompt != "" {
+		system += "\n\n" + s.extraSystemPrompt
+	}
+	estimate := int64(tokens.EstimateRequest(tokens.ForModel(s.activeModelName()), system, s.baseTools, s.Messages))
+	window := s.contextWindow()
+
+	s.mu.Lock()
+	s.estimatedTokens = estimate
+	s.mu.Unlock()
+	s.sendSystemInfo()
+
+	if window > 0 && s.contextWarning > 0 && float64(estimate) >= float64(window)*s.contextWarning {
+		s.writeNotifyf("Warning: this request is an estimated %s tokens, %.0f%% of the %s-token context window",
+			formatTokenCount(estimate), float64(estimate)*100/float64(window), formatTokenCount(window))
+	}
+}
+
+// contextWindow returns the model's context_limit, or its window from the
+// known-models table when no limit is configured. It returns 0 when unknown.
+func (s *Session) contextWindow() int64 {
+	s.mu.Lock()
+	limit := s.ContextLimit
+	s.mu.Unlock()
+	if limit > 0 {
+		return limit
+	}
+	return int64(providers.ContextWindow(s.activeModelName()))
+}
+
+// activeModelName returns the provider-side name of the active model.
+func (s *Session) activeModelName() string {
+	if s.ModelManager == nil {
+		return ""
+	}
+	if model := s.ModelManager.GetActive(); model != nil {
+		return model.ModelName
+	}
+	return ""
 }
 
 func (s *Session) autoSummarize(ctx context.Context) {
-	usage := float64(s.ContextTokens) * 100 / float64(s.ContextLimit)
+	u := s.UsageSnapshot()
+	usage := float64(u.ContextTokens) * 100 / float64(u.ContextLimit)
 	s.writeNotifyf("Context usage at %d/%d tokens (%.0f%%). Auto-summarizing...",
-		s.ContextTokens, s.ContextLimit, usage)
-	s.summarize(ctx)
+		u.ContextTokens, u.ContextLimit, usage)
+	s.summarize(ctx, DefaultSummarizeKeep)
 }
 
-func (s *Session) processPrompt(ctx context.Context, _ string, history []llm.Message) (int64, error) {
-	promptID := atomic.AddUint64(&s.nextPromptID, 1) - 1
+// processPrompt streams a response to history and returns the output tokens
+// used. When it fails, for example because ctx was canceled, it also returns
+// the text streamed in the unfinished step, which is not in s.Messages yet.
+// pos records the stream IDs used; passing it back continues the same
+// displayed message (see continueInterrupted). nil starts a new one.
+func (s *Session) processPrompt(ctx context.Context, pos *streamPosition, history []llm.Message) (int64, string, error) {
+	if pos == nil {
+		pos = &streamPosition{}
+	}
+	// A continuation carries on with the stream IDs of the step it resumes
+	baseStep := 0
+	if pos.started {
+		baseStep = pos.step - 1
+	} else {
+		pos.prompt = atomic.AddUint64(&s.nextPromptID, 1) - 1
+		pos.started = true
+	}
+	promptID := pos.prompt
 
 	var stepCount int
 	var outputTokens int64
@@ -605,72 +1268,207 @@ func (s *Session) processPrompt(ctx context.Context, _ string, history []llm.Mes
 		return "[:" + strconv.FormatUint(promptID, 10) + "-" + strconv.FormatInt(int64(stepCount), 10) + "-" + id + ":]"
 	}
 
-	_, err := s.Agent.Stream(ctx, history, llm.StreamCallbacks{
-		OnTextDelta: func(delta string) error {
-			//nolint:errcheck // Best effort write, errors ignored
-			_ = stream.WriteTLV(s.Output, stream.TagTextAssistant, assembleID("t")+delta)
-			s.Output.Flush()
-			return nil
-		},
-		OnReasoningDelta: func(delta string) error {
+	toolNames := make(map[string]string)
+	var partial strings.Builder
+	writeText := func(text string) {
+		if text == "" {
+			return
+		}
+		partial.WriteString(text)
+		//nolint:errcheck // Best effort write, errors ignored
+		_ = stream.WriteTLVChunked(s.Output, stream.TagTextAssistant, assembleID("t"), text)
+		s.Output.Flush()
+	}
+	// The start of a continuation is held back until its overlap with the
+	// text it continues is known (see autocontinue.go)
+	releaseHeld := func() {
+		if pos.trim != nil {
+			writeText(pos.trim.flush())
+		}
+	}
+	s.writeVerbosef("agent started: model %s, %d messages, %d tools", s.activeModelName(), len(history), len(s.baseTools))
+
+	// :model_set may replace the agent from the input goroutine
+	s.mu.Lock()
+	agent := s.Agent
+	stallTimeout := s.stallTimeout
+	s.mu.Unlock()
+
+	streamCtx, watchdog := s.watchStream(ctx, stallTimeout)
+	result, err := agent.StreamHistory(streamCtx, history, func(ev alayacore.Event) error {
+		switch ev.(type) {
+		case alayacore.ToolCall:
+			watchdog.toolCall()
+		case alayacore.ToolResult:
+			watchdog.toolResult()
+		default:
+			watchdog.event()
+		}
+		switch e := ev.(type) {
+		case alayacore.TextDelta:
+			s.progress.delta(e.Text)
+			if pos.trim != nil {
+				writeText(pos.trim.feed(e.Text))
+			} else {
+				writeText(e.Text)
+			}
+		case alayacore.Reasoning:
+			s.progress.delta(e.Text)
+			s.mu.Lock()
+			hidden := s.hideReasoning
+			s.mu.Unlock()
+			if hidden {
+				// Still kept in the message history and counted in usage
+				return nil
+			}
 			//nolint:errcheck // Best effort write, errors ignored
-			_ = stream.WriteTLV(s.Output, stream.TagTextReasoning, assembleID("r")+delta)
+			_ = stream.WriteTLVChunked(s.Output, stream.TagTextReasoning, assembleID("r"), e.Text)
 			s.Output.Flush()
-			return nil
-		},
-		OnToolCall: func(toolCallID, toolName string, input json.RawMessage) error {
-			s.writeToolCall(toolName, string(input), toolCallID)
+		case alayacore.ToolCall:
+			s.progress.toolCall()
+			releaseHeld()
+			toolNames[e.ID] = e.Name
+			s.writeVerbosef("tool %s invoked: %s", e.Name, summarizeInput(e.Input))
+			s.writeToolCall(e.Name, string(e.Input), e.ID)
 			s.Output.Flush()
-			return nil
-		},
-		OnToolResult: func(toolCallID string, output llm.ToolResultOutput) error {
+		case alayacore.ToolResult:
+			s.progress.toolResult()
 			status := "success"
-			if textOutput, ok := output.(llm.ToolResultOutputText); ok {
-				s.writeToolOutput(toolCallID, textOutput.Text)
-			} else if errOutput, ok := output.(llm.ToolResultOutputError); ok {
+			if e.IsError() {
 				status = "error"
-				s.writeToolOutput(toolCallID, errOutput.Error)
 			}
-			s.writeToolResult(toolCallID, status)
-			return nil
-		},
-		OnStepStart: func(step int) error {
-			stepCount = step
+			if data, ok := e.Output.(llm.ToolResultOutputData); ok {
+				s.writeTypedResult(e.ID, data.Kind, data.Data)
+			}
+			s.writeToolOutput(e.ID, e.Text(), e.IsError())
+			s.writeToolResult(e.ID, status)
+			s.writeVerbosef("tool %s finished: %s, %d bytes", toolNames[e.ID], status, len(e.Text()))
+		case alayacore.StepStart:
+			s.progress.touch()
+			partial.Reset()
+			stepCount = baseStep + e.Step
+			pos.step = stepCount
 			s.mu.Lock()
-			s.currentStep = step
+			s.currentStep = stepCount
 			s.mu.Unlock()
 			s.sendSystemInfo()
-			return nil
-		},
-		OnStepFinish: func(messages []llm.Message, usage llm.Usage) error {
-			s.trackUsage(usage)
+			s.writeVerbosef("step %d started", e.Step)
+		case alayacore.Usage:
+			s.trackUsage(llm.Usage{
+				InputTokens:         e.InputTokens,
+				OutputTokens:        e.OutputTokens,
+				CacheReadTokens:     e.CacheReadTokens,
+				CacheCreationTokens: e.CacheCreationTokens,
+			})
+			outputTokens += e.OutputTokens
+			s.writeVerbosef("usage: %d input, %d output, %d cache read, %d cache write tokens",
+				e.InputTokens, e.OutputTokens, e.CacheReadTokens, e.CacheCreationTokens)
+		case alayacore.StepFinish:
+			messages := e.Messages
+			if pos.trim != nil {
+				// Only the first step of a continuation can repeat
+				releaseHeld()
+				messages = pos.trim.apply(messages)
+				pos.trim = nil
+			}
 			if len(messages) > 0 {
-				s.Messages = append(s.Messages, messages...)
+				s.appendMessages(messages...)
 			}
-			outputTokens += usage.OutputTokens
-			return nil
-		},
+			partial.Reset()
+			s.writeVerbosef("step %d finished: %d messages", e.Step, len(e.Messages))
+		}
+		return nil
 	})
+	watchdog.stop()
+	err = s.stallCause(streamCtx, err)
+	releaseHeld()
+	pos.truncated = err == nil && result.Truncated
 
 	s.Output.Flush()
 
 	if err != nil {
-		return 0, err
+		return 0, partial.String(), err
 	}
 
-	return outputTokens, nil
+	return outputTokens, "", nil
 }
 
 // ============================================================================
 // Output Helpers
 // ============================================================================
 
-func (s *Session) signalPromptStart(prompt string) {
-	s.writeGapped(stream.TagTextUser, prompt)
+// taskStartPrefix prefixes the echoed prompt so output can be matched to its task.
+func taskStartPrefix(id uint64) string {
+	return fmt.Sprintf("#%d ▸ ", id)
 }
 
-func (s *Session) signalCommandStart(cmd string) {
-	s.writeGapped(stream.TagTextUser, ":"+cmd)
+func (s *Session) signalPromptStart(id uint64, prompt string) {
+	if s.DryRun() {
+		// Transcripts show which answers were simulated
+		prompt = tools.DryRunPrefix + prompt
+	}
+	s.writeTimed(stream.TagTextUser, taskStartPrefix(id)+prompt)
+}
+
+func (s *Session) signalCommandStart(id uint64, cmd string) {
+	s.writeTimed(stream.TagTextUser, taskStartPrefix(id)+":"+cmd)
+}
+
+// signalTurnEnd tells clients that task id has finished, whether it
+// succeeded, failed, or was canceled.
+func (s *Session) signalTurnEnd(id uint64) {
+	s.writeTimed(stream.TagTurnEnd, strconv.FormatUint(id, 10))
+}
+
+// signalPromptDone reports a finished prompt, e.g. "#3 done, 2.3k tokens,
+// 8.1s, 34 tok/s, $0.02". The rate is left out when it could not be measured,
+// the cost when no tokens were spent. It returns the report.
+func (s *Session) signalPromptDone(id uint64, canceled bool, tokens int64, elapsed time.Duration, tokensPerSecond, cost float64, priced bool) string {
+	state := "done"
+	if canceled {
+		state = "canceled"
+	}
+	msg := fmt.Sprintf("#%d %s, %s tokens, %.1fs", id, state, formatTokenCount(tokens), elapsed.Seconds())
+	if tokensPerSecond > 0 {
+		msg += fmt.Sprintf(", %.0f tok/s", tokensPerSecond)
+	}
+	if tokens > 0 {
+		msg += ", " + FormatCost(cost, priced)
+	}
+	s.writeNotify(msg)
+	return msg
+}
+
+// alertSummaryLen bounds the prompt excerpt of a TagTurnAlert.
+const alertSummaryLen = 60
+
+// alertLongTurn sends a TagTurnAlert when a prompt ran for at least
+// notifyAfter, so a client can tell a user who has looked away. The value is
+// the done report and the start of the prompt, on one line.
+func (s *Session) alertLongTurn(elapsed time.Duration, summary, prompt string) {
+	if s.notifyAfter <= 0 || elapsed < s.notifyAfter {
+		return
+	}
+	line, _, cut := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if runes := []rune(line); len(runes) > alertSummaryLen {
+		line, cut = string(runes[:alertSummaryLen]), true
+	}
+	if cut {
+		line += "…"
+	}
+	s.writeGapped(stream.TagTurnAlert, summary+": "+line)
+}
+
+// formatTokenCount abbreviates token counts: 950, 2.3k, 1.2M.
+func formatTokenCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
 }
 
 func (s *Session) writeError(msg string) {
@@ -685,6 +1483,30 @@ func (s *Session) writeNotifyf(format string, args ...any) {
 	s.writeNotify(fmt.Sprintf(format, args...))
 }
 
+// writeVerbosef reports an agent lifecycle event when --verbose is set.
+func (s *Session) writeVerbosef(format string, args ...any) {
+	if !s.verbose {
+		return
+	}
+	s.writeGapped(stream.TagSystemLog, fmt.Sprintf(format, args...))
+}
+
+// maxInputSummary bounds the tool input shown in verbose tool events.
+const maxInputSummary = 120
+
+// summarizeInput compacts tool input JSON onto one line and truncates it.
+func summarizeInput(input json.RawMessage) string {
+	var buf bytes.Buffer
+	text := string(input)
+	if json.Compact(&buf, input) == nil {
+		text = buf.String()
+	}
+	if runes := []rune(text); len(runes) > maxInputSummary {
+		text = string(runes[:maxInputSummary]) + "…"
+	}
+	return text
+}
+
 func (s *Session) writeGapped(tag string, msg string) {
 	if s.Output == nil {
 		return
@@ -702,24 +1524,35 @@ func (s *Session) writeToolCall(toolName, input, id string) {
 		Input: input,
 	}
 	jsonData, _ := json.Marshal(tc) //nolint:errcheck // Best effort marshal, errors ignored
-	//nolint:errcheck // Best effort write, errors ignored
-	_ = stream.WriteTLV(s.Output, stream.TagFunctionCall, string(jsonData))
-	s.Output.Flush()
+	s.writeTimed(stream.TagFunctionCall, string(jsonData))
 	s.writeToolResult(id, "pending")
 }
 
-func (s *Session) writeToolOutput(toolCallID string, output string) {
-	// Send tool result as JSON via FR tag
-	tr := toolResultData{
-		ID:     toolCallID,
-		Output: output,
+// writeToolOutput sends a tool result as JSON via FR frames. A large output
+// is split over several frames; all but the first are marked as continued.
+func (s *Session) writeToolOutput(toolCallID string, output string, failed bool) {
+	for i, chunk := range stream.SplitChunks(output, stream.ChunkSize) {
+		tr := toolResultData{
+			ID:        toolCallID,
+			Output:    chunk,
+			Error:     failed,
+			Continued: i > 0,
+		}
+		jsonData, _ := json.Marshal(tr) //nolint:errcheck // Best effort marshal, errors ignored
+		//nolint:errcheck // Best effort write, errors ignored
+		_ = stream.WriteTLV(s.Output, stream.TagFunctionResult, string(jsonData))
 	}
-	jsonData, _ := json.Marshal(tr) //nolint:errcheck // Best effort marshal, errors ignored
-	//nolint:errcheck // Best effort write, errors ignored
-	_ = stream.WriteTLV(s.Output, stream.TagFunctionResult, string(jsonData))
 	s.Output.Flush()
 }
 
+// writeTypedResult sends a structured tool result via an FT frame, ahead of
+// the FR frames carrying it as text, so adaptors can render it by kind.
+func (s *Session) writeTypedResult(toolCallID, kind string, data json.RawMessage) {
+	jsonData, _ := json.Marshal(typedResultData{ID: toolCallID, Kind: kind, Data: data}) //nolint:errcheck // Best effort marshal, errors ignored
+	//nolint:errcheck // Best effort write, errors ignored
+	_ = stream.WriteTLV(s.Output, stream.TagToolResult, string(jsonData))
+}
+
 func (s *Session) writeToolResult(toolCallID string, status string) {
 	if s.Output == nil {
 		return
@@ -730,14 +1563,59 @@ func (s *Session) writeToolResult(toolCallID string, status string) {
 }
 
 func (s *Session) trackUsage(usage llm.Usage) {
+	model := s.activeModelName()
 	s.mu.Lock()
 	s.TotalSpent.InputTokens += usage.InputTokens
 	s.TotalSpent.OutputTokens += usage.OutputTokens
+	s.TotalSpent.CacheReadTokens += usage.CacheReadTokens
+	s.TotalSpent.CacheCreationTokens += usage.CacheCreationTokens
 	s.ContextTokens = usage.InputTokens
+	s.CachedTokens = usage.CacheReadTokens
+	// A counter panics when it goes down
+	telemetry.Tokens.WithLabelValues(model, "in").Add(float64(max(0, usage.InputTokens)))
+	telemetry.Tokens.WithLabelValues(model, "out").Add(float64(max(0, usage.OutputTokens)))
+	if price, ok := s.prices.Lookup(model); ok {
+		s.totalCost += price.Cost(usage)
+	} else if usage.InputTokens+usage.OutputTokens > 0 {
+		s.unpricedUsage = true
+	}
 	s.mu.Unlock()
 	s.sendSystemInfo()
 }
 
+// totalCostUSD returns the USD spent so far. It reports false once any usage
+// came from a model without a known price, as the sum would understate it.
+func (s *Session) totalCostUSD() (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totalCost, !s.unpricedUsage
+}
+
+// FormatCost formats a USD cost for display: "$0.42", or "$0.0031" for
+// amounts under a cent. Unknown costs read "cost: n/a" rather than "$0.00".
+func FormatCost(cost float64, known bool) string {
+	if !known {
+		return "cost: n/a"
+	}
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// State returns a snapshot of the session's modes.
+func (s *Session) State() SessionState {
+	s.mu.Lock()
+	state := SessionState{
+		DryRun:      s.dryRun,
+		ReviewEdits: s.reviewEdits,
+		Attachments: len(s.pendingImages),
+	}
+	s.mu.Unlock()
+	state.ActiveSkill, _ = s.skillPolicy.ActiveSkill()
+	return state
+}
+
 func (s *Session) sendSystemInfo() {
 	s.sendSystemInfoInternal(nil)
 }
@@ -788,23 +1666,46 @@ func (s *Session) sendSystemInfoInternal(activeModelConfig *ModelConfig) {
 	contextTokens := s.ContextTokens
 	contextLimit := s.ContextLimit
 	totalTokens := s.TotalSpent.InputTokens + s.TotalSpent.OutputTokens
+	cachedTokens := s.CachedTokens
+	totalCachedTokens := s.TotalSpent.CacheReadTokens
 	currentStep := s.currentStep
+	estimatedTokens := s.estimatedTokens
+	checkpoint := s.checkpoint
+	checkpointDiverged := s.checkpointDiverged
 	s.mu.Unlock()
 
+	var cost *float64
+	if total, known := s.totalCostUSD(); known {
+		cost = &total
+	}
+
 	info := SystemInfo{
-		ContextTokens:     contextTokens,
-		ContextLimit:      contextLimit,
-		TotalTokens:       totalTokens,
-		QueueItems:        queueItems,
-		InProgress:        inProgress,
-		CurrentStep:       currentStep,
-		MaxSteps:          s.maxSteps,
-		Models:            models,
-		ActiveModelID:     activeID,
-		ActiveModelConfig: activeModelConfig,
-		ActiveModelName:   activeModelName,
-		HasModels:         hasModels,
-		ModelConfigPath:   modelConfigPath,
+		SessionState:       s.State(),
+		ContextTokens:      contextTokens,
+		ContextLimit:       contextLimit,
+		TotalTokens:        totalTokens,
+		CachedTokens:       cachedTokens,
+		TotalCachedTokens:  totalCachedTokens,
+		QueueItems:         queueItems,
+		InProgress:         inProgress,
+		CurrentStep:        currentStep,
+		MaxSteps:           s.maxSteps,
+		Models:             models,
+		ActiveModelID:      activeID,
+		ActiveModelConfig:  activeModelConfig,
+		ActiveModelName:    activeModelName,
+		HasModels:          hasModels,
+		ModelConfigPath:    modelConfigPath,
+		EstimatedTokens:    estimatedTokens,
+		ContextWindow:      s.contextWindow(),
+		Cost:               cost,
+		Checkpoint:         checkpoint,
+		CheckpointDiverged: checkpointDiverged,
+		Timestamps:         s.Timestamps(),
+		ChangedFiles:       s.changes.Len(),
+	}
+	if s.workdir != nil {
+		info.Workdir = s.workdir.Get()
 	}
 	data, _ := json.Marshal(info) //nolint:errcheck // Best effort marshal, errors ignored
 	//nolint:errcheck // Best effort write, errors ignored
@@ -872,12 +1773,12 @@ func expandPath(path string) string {
 	if !strings.HasPrefix(path, "~") {
 		return path
 	}
-	usr, err := user.Current()
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return path
 	}
 	if path == "~" {
-		return usr.HomeDir
+		return home
 	}
-	return filepath.Join(usr.HomeDir, path[1:])
+	return filepath.Join(home, path[1:])
 }
@@ -4,11 +4,13 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
 	domainerrors "github.com/alayacore/alayacore/internal/errors"
 	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/factory"
 )
 
 // ============================================================================
@@ -41,62 +43,174 @@ func (s *Session) cancelTask() {
 	s.writeError(domainerrors.ErrNothingToCancel.Error())
 }
 
-func (s *Session) cancelAllTasks() {
-	// Clear the task queue first (while holding lock)
+// CancelAll cancels the running task and drops every queued one, so nothing
+// starts after the cancel. The queue is emptied before the running task is
+// canceled: the task runner picks its next task only after the current one
+// returns, and by then there is none. The dropped tasks are listed in a
+// notification.
+func (s *Session) CancelAll() {
 	s.mu.Lock()
-	queueLen := len(s.taskQueue)
+	dropped := s.taskQueue
 	s.taskQueue = make([]QueueItem, 0)
 	inProgress := s.inProgress
 	cancelCurrent := s.cancelCurrent
 	s.mu.Unlock()
 
-	// Then cancel current task (if running)
 	currentCanceled := false
 	if inProgress && cancelCurrent != nil {
 		cancelCurrent()
 		currentCanceled = true
 	}
 
-	// Send notification
-	if currentCanceled && queueLen > 0 {
-		s.writeNotifyf("Canceled current task and cleared %d queued tasks", queueLen)
-	} else if currentCanceled {
-		s.writeNotify("Canceled current task")
-	} else if queueLen > 0 {
-		s.writeNotifyf("Cleared %d queued tasks", queueLen)
-	} else {
+	var msg string
+	switch {
+	case currentCanceled && len(dropped) > 0:
+		msg = fmt.Sprintf("Canceled current task and cleared %d queued tasks", len(dropped))
+	case currentCanceled:
+		msg = "Canceled current task"
+	case len(dropped) > 0:
+		msg = fmt.Sprintf("Cleared %d queued tasks", len(dropped))
+	default:
 		s.writeError(domainerrors.ErrNothingToCancel.Error())
 		return
 	}
-
+	for _, item := range dropped {
+		msg += fmt.Sprintf("\n  #%d %s", item.ID, describeTask(item.Task))
+	}
+	s.writeNotify(msg)
 	s.sendSystemInfo()
 }
 
-func (s *Session) summarize(ctx context.Context) {
-	prompt := "Please summarize the conversation above in a concise manner. Return ONLY the summary, no introductions or explanations."
+// describeTask returns a one-line summary of a queued task.
+func describeTask(task Task) string {
+	const maxLen = 60
+	var text string
+	switch t := task.(type) {
+	case UserPrompt:
+		text = t.Text
+	case CommandPrompt:
+		text = ":" + t.Command
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxLen {
+		text = string(runes[:maxLen]) + "…"
+	}
+	return text
+}
+
+// DefaultSummarizeKeep is how many recent messages :summarize keeps verbatim.
+const DefaultSummarizeKeep = 4
+
+// summarizePrompt asks for a recap that preserves what later turns rely on.
+const summarizePrompt = `Summarize the conversation above so it can replace the full history. Include:
+- File paths read, created, or modified, and what changed
+- Commands run and their important results
+- Decisions made, constraints discovered, and open questions
+- The current task and its progress
+Return ONLY the summary, no introductions or explanations.`
+
+// summaryPrefix marks the recap message that replaces the summarized history.
+const summaryPrefix = "[Summary of the earlier conversation]\n"
+
+func (s *Session) handleSummarize(ctx context.Context, args []string) {
+	keep := DefaultSummarizeKeep
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 || len(args) > 1 {
+			s.writeError("usage: :summarize [n] (n = recent messages to keep, default 4)")
+			return
+		}
+		keep = n
+	}
+	s.summarize(ctx, keep)
+}
 
+// summarize replaces all but the last keep messages with a recap message. The
+// cut is moved back to a user prompt so the kept tail never starts with a
+// tool result separated from its tool call. It reports whether the history
+// was shortened.
+func (s *Session) summarize(ctx context.Context, keep int) bool {
+	cut := summarizeCut(s.Messages, keep)
+	if cut == 0 {
+		s.writeNotify("Nothing to summarize")
+		return false
+	}
+
+	head := s.Messages[:cut]
+	tail := append([]llm.Message(nil), s.Messages[cut:]...)
 	beforeCount := len(s.Messages)
 
-	outputTokens, err := s.processPrompt(ctx, prompt, s.Messages)
+	history := append(append([]llm.Message(nil), head...), llm.NewUserMessage(summarizePrompt))
+	outputTokens, _, err := s.processPrompt(ctx, nil, history)
 	if err != nil {
+		s.setMessages(s.Messages[:beforeCount])
 		s.writeError(err.Error())
-		return
+		return false
 	}
 
-	var lastAssistantMsg llm.Message
-	for i := beforeCount; i < len(s.Messages); i++ {
-		if s.Messages[i].Role == llm.RoleAssistant {
-			lastAssistantMsg = s.Messages[i]
-		}
+	summary := lastAssistantText(s.Messages[beforeCount:])
+	if summary == "" {
+		s.setMessages(s.Messages[:beforeCount])
+		s.writeError(domainerrors.NewSessionErrorf("summarize", "model returned an empty summary").Error())
+		return false
 	}
 
-	s.Messages = []llm.Message{lastAssistantMsg}
+	recap := llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: summaryPrefix + summary}})
+	s.setMessages(append([]llm.Message{recap}, tail...))
 	if outputTokens > 0 {
 		s.mu.Lock()
 		s.ContextTokens = outputTokens
 		s.mu.Unlock()
 	}
+	s.writeNotifyf("Summarized %d messages, kept the last %d", cut, len(tail))
 	s.sendSystemInfo()
+	return true
+}
+
+// summarizeCut returns the index splitting messages into the part to
+// summarize and the tail to keep: at least keep messages are kept, starting
+// at a user prompt. It returns 0 when there is nothing to summarize.
+func summarizeCut(messages []llm.Message, keep int) int {
+	cut := len(messages) - keep
+	if cut <= 0 {
+		return 0
+	}
+	for cut > 0 && cut < len(messages) && !isUserPrompt(messages[cut]) {
+		cut--
+	}
+	return cut
+}
+
+// isUserPrompt reports whether m starts a turn (a user message, not a tool result).
+func isUserPrompt(m llm.Message) bool {
+	if m.Role != llm.RoleUser {
+		return false
+	}
+	for _, part := range m.Content {
+		if _, ok := part.(llm.ToolResultPart); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// lastAssistantText returns the text of the last assistant message that has any.
+func lastAssistantText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llm.RoleAssistant {
+			continue
+		}
+		var sb strings.Builder
+		for _, part := range messages[i].Content {
+			if text, ok := part.(llm.TextPart); ok {
+				sb.WriteString(text.Text)
+			}
+		}
+		if sb.Len() > 0 {
+			return sb.String()
+		}
+	}
+	return ""
 }
 
 func (s *Session) saveSession(args []string) {
@@ -191,6 +305,7 @@ func (s *Session) handleModelLoad() {
 	s.initModelManager()
 	s.sendSystemInfo()
 	s.writeNotify("Models reloaded from configuration file")
+	s.checkModelConfig()
 }
 
 func (s *Session) handleTaskQueueGetAll() {
@@ -210,3 +325,134 @@ func (s *Session) handleTaskQueueDel(args []string) {
 		s.writeError(domainerrors.NewSessionErrorf("taskqueue_del", "queue item %s not found", queueID).Error())
 	}
 }
+
+// handleSkills reports the active skill, or lifts its allowed-tools
+// restriction with ":skills deactivate".
+func (s *Session) handleSkills(args []string) {
+	if len(args) == 0 {
+		name, allowed := s.skillPolicy.ActiveSkill()
+		switch {
+		case name == "":
+			s.writeNotify("No active skill")
+		case len(allowed) == 0:
+			s.writeNotifyf("Active skill: %s (all tools allowed)", name)
+		default:
+			s.writeNotifyf("Active skill: %s (allowed-tools: %s)", name, strings.Join(allowed, ", "))
+		}
+		return
+	}
+
+	if args[0] == "reload" && len(args) == 1 {
+		s.reloadSkills()
+		return
+	}
+	if args[0] != "deactivate" {
+		s.writeError("usage: :skills [deactivate|reload]")
+		return
+	}
+
+	name := s.skillPolicy.Deactivate()
+	if name == "" {
+		s.writeError(domainerrors.NewSessionErrorf("skills", "no active skill").Error())
+		return
+	}
+	s.writeNotifyf("Deactivated skill %s", name)
+	s.sendSystemInfo()
+}
+
+// handleSet updates sampling settings from "key=value" arguments (or a single
+// "key value" pair). Either all values are applied or none are. The provider
+// is rebuilt with the new settings on the next prompt.
+func (s *Session) handleSet(args []string) {
+	if len(args) == 0 {
+		s.writeError("usage: :set <key>=<value> ... (keys: temperature, top_p, max_output_tokens, reasoning_effort, thinking_budget_tokens)")
+		return
+	}
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		args = []string{args[0] + "=" + args[1]}
+	}
+
+	s.mu.Lock()
+	sampling := s.sampling
+	s.mu.Unlock()
+
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			s.writeError(domainerrors.NewSessionErrorf("set", "expected key=value, got %q", arg).Error())
+			return
+		}
+		if err := sampling.Set(key, value); err != nil {
+			s.writeError(domainerrors.Wrap("set", err).Error())
+			return
+		}
+	}
+	if s.ModelManager != nil {
+		if active := s.ModelManager.GetActive(); active != nil {
+			if err := factory.ValidateSampling(active.ProtocolType, sampling); err != nil {
+				s.writeError(domainerrors.Wrap("set", err).Error())
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.sampling = sampling
+	s.Agent = nil
+	s.Provider = nil
+	s.mu.Unlock()
+
+	s.writeNotifyf("Settings updated: %s", sampling)
+}
+
+// handleSettings shows the active model and sampling settings.
+func (s *Session) handleSettings() {
+	modelName := "(none)"
+	if s.ModelManager != nil {
+		if active := s.ModelManager.GetActive(); active != nil {
+			modelName = active.Name
+		}
+	}
+
+	s.mu.Lock()
+	sampling := s.sampling
+	reasoning := reasoningState(s.hideReasoning)
+	s.mu.Unlock()
+
+	s.writeNotifyf("Model: %s\nSampling: %s\nReasoning display: %s\nMax steps: %d", modelName, sampling, reasoning, s.maxSteps)
+}
+
+// handleReasoning shows or toggles whether reasoning deltas are displayed.
+func (s *Session) handleReasoning(args []string) {
+	if len(args) > 1 {
+		s.writeError("usage: :reasoning [on|off]")
+		return
+	}
+
+	s.mu.Lock()
+	hidden := s.hideReasoning
+	s.mu.Unlock()
+
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			hidden = false
+		case "off":
+			hidden = true
+		default:
+			s.writeError(domainerrors.NewSessionErrorf("reasoning", "expected on or off, got %q", args[0]).Error())
+			return
+		}
+		s.mu.Lock()
+		s.hideReasoning = hidden
+		s.mu.Unlock()
+	}
+	s.writeNotifyf("Reasoning display: %s", reasoningState(hidden))
+}
+
+func reasoningState(hidden bool) string {
+	if hidden {
+		return "off"
+	}
+	return "on"
+}
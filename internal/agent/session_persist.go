@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,14 +30,21 @@ func LoadSession(path string) (*SessionData, error) {
 	return parseSessionMarkdown(data)
 }
 
+// Save writes the conversation to path in the session file format, so that
+// --session can load it later. It is safe to call from any goroutine.
+func (s *Session) Save(path string) error {
+	return s.saveSessionToFile(path)
+}
+
 func (s *Session) saveSessionToFile(path string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	data := SessionData{
 		SessionMeta: SessionMeta{
-			CreatedAt: s.CreatedAt,
-			UpdatedAt: time.Now(),
+			CreatedAt:     s.CreatedAt,
+			UpdatedAt:     time.Now(),
+			SystemAddenda: append([]string(nil), s.systemAddenda...),
 		},
 		Messages: s.Messages,
 	}
@@ -69,6 +77,13 @@ func formatFrontmatter(meta *SessionMeta) string {
 	buf.WriteString(meta.UpdatedAt.Format(time.RFC3339))
 	buf.WriteString("\n")
 
+	// Quoted, as an instruction may hold newlines
+	for _, text := range meta.SystemAddenda {
+		buf.WriteString("system: ")
+		buf.WriteString(strconv.Quote(text))
+		buf.WriteString("\n")
+	}
+
 	buf.WriteString("---\n")
 	return buf.String()
 }
@@ -92,6 +107,9 @@ func formatSessionMarkdown(data *SessionData) ([]byte, error) {
 			case llm.ReasoningPart:
 				writeTLV(&binaryBuf, stream.TagTextReasoning, p.Text)
 
+			case llm.ImagePart:
+				writeTLV(&binaryBuf, stream.TagUserImage, stream.EncodeImage(p.Name, p.Data))
+
 			case llm.ToolCallPart:
 				tc := toolCallData{
 					ID:    p.ToolCallID,
@@ -105,9 +123,14 @@ func formatSessionMarkdown(data *SessionData) ([]byte, error) {
 				writeTLV(&binaryBuf, stream.TagFunctionCall, string(jsonData))
 
 			case llm.ToolResultPart:
+				_, failed := p.Output.(llm.ToolResultOutputError)
 				tr := toolResultData{
 					ID:     p.ToolCallID,
 					Output: formatToolResultOutput(p.Output),
+					Error:  failed,
+				}
+				if data, ok := p.Output.(llm.ToolResultOutputData); ok {
+					tr.Kind = data.Kind
 				}
 				jsonData, err := json.Marshal(tr)
 				if err != nil {
@@ -147,6 +170,18 @@ type toolCallData struct {
 type toolResultData struct {
 	ID     string `json:"id"`
 	Output string `json:"output"`
+	Error  bool   `json:"error,omitempty"` // the tool failed; Output is the error message
+	Kind   string `json:"kind,omitempty"`  // kind of a structured result; Output is its JSON
+	// Continued marks a frame that carries more of the output of the
+	// previous frame for the same ID. It is never persisted.
+	Continued bool `json:"continued,omitempty"`
+}
+
+// typedResultData is the value of an FT frame: a structured tool result.
+type typedResultData struct {
+	ID   string          `json:"id"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
 }
 
 // parseSessionMarkdown parses markdown format with TLV encoding.
@@ -168,9 +203,19 @@ func parseFrontmatter(content string) (frontmatter, body string, err error) {
 }
 
 // parseSessionMeta parses key-value pairs from frontmatter into SessionMeta using struct tags.
+// The "system" lines repeat, so they are read here.
 func parseSessionMeta(frontmatter string) SessionMeta {
 	var meta SessionMeta
 	config.ParseKeyValue(frontmatter, &meta)
+	for line := range strings.SplitSeq(frontmatter, "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		if strings.TrimSpace(key) != "system" {
+			continue
+		}
+		if text, err := strconv.Unquote(strings.TrimSpace(value)); err == nil {
+			meta.SystemAddenda = append(meta.SystemAddenda, text)
+		}
+	}
 	return meta
 }
 
@@ -260,6 +305,20 @@ func parseMessagesTLV(body string) ([]llm.Message, []TLVChunk, error) {
 			msgRole = llm.RoleUser
 			msgPart = llm.TextPart{Type: "text", Text: string(content)}
 
+		case stream.TagUserImage:
+			msgRole = llm.RoleUser
+			name, data, ok := stream.DecodeImage(string(content))
+			if !ok {
+				return nil, nil, fmt.Errorf("failed to parse image attachment")
+			}
+			image, err := newImagePart(name, data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse image attachment: %w", err)
+			}
+			msgPart = image
+			// Display a placeholder instead of the raw image bytes
+			chunks[len(chunks)-1] = TLVChunk{Tag: stream.TagSystemNotify, Value: formatAttachment(image)}
+
 		case stream.TagTextAssistant:
 			newMessage = true
 			msgRole = llm.RoleAssistant
@@ -288,10 +347,16 @@ func parseMessagesTLV(body string) ([]llm.Message, []TLVChunk, error) {
 			if err := json.Unmarshal(content, &tr); err != nil {
 				return nil, nil, fmt.Errorf("failed to parse tool result: %w", err)
 			}
+			var output llm.ToolResultOutput = llm.ToolResultOutputText{Type: "text", Text: tr.Output}
+			if tr.Error {
+				output = llm.ToolResultOutputError{Type: "error", Error: tr.Output}
+			} else if tr.Kind != "" && json.Valid([]byte(tr.Output)) {
+				output = llm.ToolResultOutputData{Type: "data", Kind: tr.Kind, Data: json.RawMessage(tr.Output)}
+			}
 			msgPart = llm.ToolResultPart{
 				Type:       "tool_result",
 				ToolCallID: tr.ID,
-				Output:     llm.ToolResultOutputText{Type: "text", Text: tr.Output},
+				Output:     output,
 			}
 
 		default:
@@ -326,5 +391,8 @@ func formatToolResultOutput(output llm.ToolResultOutput) string {
 	if e, ok := output.(llm.ToolResultOutputError); ok {
 		return e.Error
 	}
+	if data, ok := output.(llm.ToolResultOutputData); ok {
+		return string(data.Data)
+	}
 	return fmt.Sprintf("%v", output)
 }
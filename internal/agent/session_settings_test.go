@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func newSettingsTestSession() (*Session, *MockOutput) {
+	output := &MockOutput{}
+	return &Session{
+		Input:     &stream.NopInput{},
+		Output:    output,
+		taskQueue: make([]QueueItem, 0),
+	}, output
+}
+
+func outputContains(output *MockOutput, text string) bool {
+	for _, msg := range output.Messages {
+		if strings.Contains(msg, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSetUpdatesSampling(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleSet([]string{"temperature=0.2", "max_output_tokens=2048"})
+
+	if session.sampling.Temperature == nil || *session.sampling.Temperature != 0.2 {
+		t.Errorf("temperature = %v, want 0.2", session.sampling.Temperature)
+	}
+	if session.sampling.MaxOutputTokens != 2048 {
+		t.Errorf("max_output_tokens = %d, want 2048", session.sampling.MaxOutputTokens)
+	}
+	if !outputContains(output, "temperature=0.2") {
+		t.Errorf("expected confirmation notice, got %v", output.Messages)
+	}
+
+	// "key value" form and reset to default
+	session.handleSet([]string{"temperature", "default"})
+	if session.sampling.Temperature != nil {
+		t.Errorf("temperature should be reset, got %v", *session.sampling.Temperature)
+	}
+}
+
+func TestSetRejectsInvalidValuesAtomically(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleSet([]string{"temperature=0.5", "top_p=1.5"})
+
+	if session.sampling.Temperature != nil {
+		t.Error("no setting should be applied when one value is invalid")
+	}
+	if !outputContains(output, "top_p must be in (0, 1]") {
+		t.Errorf("expected top_p validation error, got %v", output.Messages)
+	}
+}
+
+func TestSettingsShowsSampling(t *testing.T) {
+	session, output := newSettingsTestSession()
+	temperature := 0.0
+	session.sampling = llm.SamplingOptions{Temperature: &temperature}
+	session.maxSteps = 42
+
+	session.handleSettings()
+
+	if !outputContains(output, "temperature=0 top_p=default max_output_tokens=default") {
+		t.Errorf("expected sampling summary, got %v", output.Messages)
+	}
+	if !outputContains(output, "Max steps: 42") {
+		t.Errorf("expected max steps, got %v", output.Messages)
+	}
+}
+
+func TestReasoningToggle(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleReasoning([]string{"off"})
+	if !session.hideReasoning {
+		t.Error("reasoning should be hidden after :reasoning off")
+	}
+	if !outputContains(output, "Reasoning display: off") {
+		t.Errorf("expected confirmation notice, got %v", output.Messages)
+	}
+
+	session.handleReasoning([]string{"maybe"})
+	if !session.hideReasoning || !outputContains(output, `expected on or off, got "maybe"`) {
+		t.Errorf("invalid argument should be rejected, got %v", output.Messages)
+	}
+
+	session.handleReasoning([]string{"on"})
+	if session.hideReasoning {
+		t.Error("reasoning should be shown after :reasoning on")
+	}
+}
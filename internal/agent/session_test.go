@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -77,7 +78,11 @@ func TestLoadOrNewSession(t *testing.T) {
 	extraSystemPrompt := ""
 
 	// Test creating a new session without specifying session file
-	session, sessionFile := LoadOrNewSession(baseTools, systemPrompt, extraSystemPrompt, 0, &stream.NopInput{}, &stream.NopOutput{}, "", "", "", false, "")
+	session, sessionFile := LoadOrNewSession(&stream.NopInput{}, &stream.NopOutput{}, SessionOptions{
+		Tools:             baseTools,
+		SystemPrompt:      systemPrompt,
+		ExtraSystemPrompt: extraSystemPrompt,
+	})
 	if session == nil {
 		t.Fatal("LoadOrNewSession returned nil session")
 	}
@@ -103,6 +108,26 @@ func TestLoadOrNewSession(t *testing.T) {
 	}
 }
 
+func TestNewSessionAppliesOptions(t *testing.T) {
+	session := NewSession(&stream.NopInput{}, &stream.NopOutput{}, SessionOptions{
+		DryRun:      true,
+		ReviewEdits: true,
+		Timestamps:  true,
+		SecretScan:  "block",
+	})
+
+	state := session.State()
+	if !state.DryRun || !state.ReviewEdits {
+		t.Errorf("State() = %+v, want dry run and review edits from the options", state)
+	}
+	session.mu.Lock()
+	timestamps, secretScan := session.timestamps, session.secretScan
+	session.mu.Unlock()
+	if !timestamps || secretScan != "block" {
+		t.Errorf("timestamps = %v, secretScan = %q; want the options", timestamps, secretScan)
+	}
+}
+
 // mockOutput is a simple mock for testing output
 type mockOutput struct {
 	writeCount int
@@ -714,3 +739,64 @@ func TestTLVFormatRecursionProtection(t *testing.T) {
 		t.Errorf("tool result should contain 'Fake user message', got: %q", output.Text)
 	}
 }
+
+func TestReadFromInputSurvivesMalformedFrames(t *testing.T) {
+	input := stream.NewChanInput(10)
+	output := &MockOutput{}
+	session := &Session{
+		Input:         input,
+		Output:        output,
+		taskQueue:     make([]QueueItem, 0),
+		taskAvailable: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	_ = input.Emit([]byte("\xff\xfe not a frame"))
+	_ = input.EmitTLV(stream.TagTextUser, "hello after garbage")
+	input.Close()
+
+	// Returns once the closed input reports EOF
+	session.readFromInput()
+
+	if !outputContains(output, "malformed TLV frame") {
+		t.Errorf("expected a framing error to be reported, got %v", output.Messages)
+	}
+	if len(session.taskQueue) != 1 {
+		t.Fatalf("expected the valid prompt to be queued, got %d tasks", len(session.taskQueue))
+	}
+	if prompt, ok := session.taskQueue[0].Task.(UserPrompt); !ok || prompt.Text != "hello after garbage" {
+		t.Errorf("queued task = %#v, want the prompt after the garbage", session.taskQueue[0].Task)
+	}
+}
+
+func TestPromptHistoryIsNewestFirstWithoutRepeats(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	session.Messages = append(conversation(),
+		textMessage(llm.RoleUser, "add a flag"),
+		textMessage(llm.RoleAssistant, "Already done."),
+		textMessage(llm.RoleUser, "partial"),
+		llm.NewUserMessage(continuePrompt),
+	)
+
+	got := session.PromptHistory()
+	want := []string{"partial", "add a flag", "thanks", "read main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("PromptHistory() = %q, want %q", got, want)
+	}
+}
+
+func TestCloseIfIdle(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	session.inProgress = true
+	if session.CloseIfIdle() {
+		t.Fatal("a busy session was closed")
+	}
+	session.inProgress = false
+	if !session.CloseIfIdle() {
+		t.Fatal("an idle session was not closed")
+	}
+	session.submitTask(UserPrompt{Text: "hello"})
+	if len(session.taskQueue) != 0 {
+		t.Error("a closed session queued a task")
+	}
+}
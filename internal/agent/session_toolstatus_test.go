@@ -1,8 +1,12 @@
 package agent
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/alayacore/alayacore/internal/llm"
 	"github.com/alayacore/alayacore/internal/stream"
@@ -138,9 +142,15 @@ func TestWriteToolCallWithPending(t *testing.T) {
 	// Call writeToolCall with posix_shell (a known tool)
 	session.writeToolCall("posix_shell", `{"command":"ls"}`, "tool123")
 
-	// Should have written two TLV messages:
-	// 1. TagFunctionCall with tool call JSON (creates window)
-	// 2. TagFunctionState with pending status (updates window)
+	// Should have written three TLV messages:
+	// 1. TagTime with the time of the call
+	// 2. TagFunctionCall with tool call JSON (creates window)
+	// 3. TagFunctionState with pending status (updates window)
+	tag0, value0 := parseTLVFromBytes(output.data)
+	if _, err := time.Parse(stream.TimeFormat, value0); tag0 != stream.TagTime || err != nil {
+		t.Fatalf("Expected a %s frame first, got %s %q", stream.TagTime, tag0, value0)
+	}
+	output.data = output.data[6+len(value0):]
 
 	// Parse first message (tool call display)
 	tag1, value1 := parseTLVFromBytes(output.data)
@@ -194,3 +204,161 @@ func parseTLVFromBytes(data []byte) (string, string) {
 	value := string(data[6 : 6+length])
 	return tag, value
 }
+
+func TestToolResultFollowsToolCall(t *testing.T) {
+	output := &mockOutput{}
+	session := &Session{Output: output}
+
+	session.writeToolCall("write_file", `{"path":"/etc/x"}`, "call1")
+	session.writeToolOutput("call1", "permission denied", true)
+	session.writeToolResult("call1", "error")
+
+	var tags []string
+	var result toolResultData
+	r := bytes.NewReader(output.data)
+	for r.Len() > 0 {
+		tag, value, err := stream.ReadTLV(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, tag)
+		if tag == stream.TagFunctionResult {
+			if err := json.Unmarshal([]byte(value), &result); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	want := []string{stream.TagTime, stream.TagFunctionCall, stream.TagFunctionState, stream.TagFunctionResult, stream.TagFunctionState}
+	if len(tags) != len(want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Fatalf("tags = %v, want %v", tags, want)
+		}
+	}
+	if result.ID != "call1" || result.Output != "permission denied" || !result.Error {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSessionKeepsToolErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.md")
+	session := &Session{
+		Messages: []llm.Message{
+			{Role: llm.RoleAssistant, Content: []llm.ContentPart{llm.ToolCallPart{
+				Type: "tool_use", ToolCallID: "call1", ToolName: "write_file", Input: json.RawMessage(`{}`),
+			}}},
+			{Role: llm.RoleTool, Content: []llm.ContentPart{llm.ToolResultPart{
+				Type: "tool_result", ToolCallID: "call1",
+				Output: llm.ToolResultOutputError{Type: "error", Error: "permission denied"},
+			}}},
+		},
+		Input:  &stream.NopInput{},
+		Output: &stream.NopOutput{},
+	}
+	if err := session.saveSessionToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(loaded.Messages))
+	}
+	part, ok := loaded.Messages[1].Content[0].(llm.ToolResultPart)
+	if !ok {
+		t.Fatalf("second message = %+v", loaded.Messages[1])
+	}
+	if out, ok := part.Output.(llm.ToolResultOutputError); !ok || out.Error != "permission denied" {
+		t.Errorf("restored output = %#v, want the error", part.Output)
+	}
+}
+
+func TestLargeToolOutputIsSplit(t *testing.T) {
+	output := &mockOutput{}
+	session := &Session{Output: output}
+	big := string(bytes.Repeat([]byte("x"), 2*stream.ChunkSize+1))
+
+	session.writeToolOutput("call1", big, false)
+
+	var joined string
+	var frames []toolResultData
+	for data := output.data; len(data) > 0; {
+		tag, value := parseTLVFromBytes(data)
+		if tag != stream.TagFunctionResult {
+			t.Fatalf("tag = %s, want FR", tag)
+		}
+		var tr toolResultData
+		if err := json.Unmarshal([]byte(value), &tr); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, tr)
+		joined += tr.Output
+		data = data[6+len(value):]
+	}
+	if len(frames) != 3 || joined != big {
+		t.Fatalf("got %d frames with %d bytes, want 3 with %d", len(frames), len(joined), len(big))
+	}
+	for i, tr := range frames {
+		if tr.ID != "call1" || tr.Continued != (i > 0) {
+			t.Errorf("frame %d = id %q continued %v", i, tr.ID, tr.Continued)
+		}
+	}
+}
+
+func TestTypedResultPrecedesItsText(t *testing.T) {
+	output := &mockOutput{}
+	session := &Session{Output: output}
+
+	session.writeTypedResult("call1", "file", json.RawMessage(`{"path":"a.go","bytes":12}`))
+	session.writeToolOutput("call1", `{"path":"a.go","bytes":12}`, false)
+
+	tag, value := parseTLVFromBytes(output.data)
+	if tag != stream.TagToolResult {
+		t.Fatalf("first tag = %s, want FT", tag)
+	}
+	var typed typedResultData
+	if err := json.Unmarshal([]byte(value), &typed); err != nil {
+		t.Fatal(err)
+	}
+	if typed.ID != "call1" || typed.Kind != "file" || string(typed.Data) != `{"path":"a.go","bytes":12}` {
+		t.Errorf("typed result = %+v", typed)
+	}
+	if tag, _ := parseTLVFromBytes(output.data[6+len(value):]); tag != stream.TagFunctionResult {
+		t.Errorf("second tag = %s, want FR", tag)
+	}
+}
+
+func TestSessionKeepsStructuredResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.md")
+	data := llm.ToolResultOutputData{Type: "data", Kind: "table", Data: json.RawMessage(`{"columns":["name"],"rows":[["a.go"]]}`)}
+	session := &Session{
+		Messages: []llm.Message{
+			{Role: llm.RoleAssistant, Content: []llm.ContentPart{llm.ToolCallPart{
+				Type: "tool_use", ToolCallID: "call1", ToolName: "list", Input: json.RawMessage(`{}`),
+			}}},
+			{Role: llm.RoleTool, Content: []llm.ContentPart{llm.ToolResultPart{
+				Type: "tool_result", ToolCallID: "call1", Output: data,
+			}}},
+		},
+		Input:  &stream.NopInput{},
+		Output: &stream.NopOutput{},
+	}
+	if err := session.saveSessionToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, ok := loaded.Messages[1].Content[0].(llm.ToolResultPart)
+	if !ok {
+		t.Fatalf("second message = %+v", loaded.Messages[1])
+	}
+	if out, ok := part.Output.(llm.ToolResultOutputData); !ok || out.Kind != "table" || string(out.Data) != string(data.Data) {
+		t.Errorf("restored output = %#v, want the table", part.Output)
+	}
+}
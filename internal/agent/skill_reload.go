@@ -0,0 +1,78 @@
+package agent
+
+// Skill reload.
+// ":skills reload" discovers the skills of all skill roots again and rebuilds
+// the system prompt with them, so the next prompt sees the new
+// <available_skills> list. Skills activated earlier stay in the history as
+// they were. Sessions that share the app config pick a reload up when their
+// next prompt starts. With --watch-skills, the app config watches the skill
+// roots, reloads once when a SKILL.md changes, and tells every session what
+// changed.
+
+import (
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/skills"
+)
+
+// SkillReloader rediscovers the skills for ":skills reload". app.Config
+// implements it.
+type SkillReloader interface {
+	// ReloadSkills discovers the skills again and rebuilds the system prompt.
+	ReloadSkills() (skills.Changes, error)
+	// CurrentSystemPrompt returns the system prompt with the skills found last.
+	CurrentSystemPrompt() string
+	// SubscribeSkills returns a channel that receives the changes of each
+	// reload made for a changed SKILL.md, and a function that ends the
+	// subscription.
+	SubscribeSkills() (<-chan skills.Changes, func())
+}
+
+// watchSkillFiles reports each reload r makes for a changed SKILL.md, until
+// the session ends. The next task takes up the new system prompt.
+func (s *Session) watchSkillFiles(r SkillReloader) {
+	changes, stop := r.SubscribeSkills()
+	defer stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case c := <-changes:
+			s.writeNotifyf("Skills reloaded: %s", c)
+		}
+	}
+}
+
+// reloadSkills handles ":skills reload".
+func (s *Session) reloadSkills() {
+	s.mu.Lock()
+	r := s.skillReloader
+	s.mu.Unlock()
+	if r == nil {
+		s.writeError(domainerrors.NewSessionErrorf("skills", "skill reload is not available").Error())
+		return
+	}
+
+	changes, err := r.ReloadSkills()
+	if err != nil {
+		s.writeError(domainerrors.Wrap("skills", err).Error())
+		return
+	}
+	s.refreshSystemPrompt()
+	s.writeNotifyf("Skills reloaded: %s", changes)
+}
+
+// refreshSystemPrompt takes up the system prompt of the last skill reload.
+// The agent is rebuilt with it on the next prompt. It runs on the task
+// runner, like every reader of systemPrompt.
+func (s *Session) refreshSystemPrompt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.skillReloader == nil {
+		return
+	}
+	if prompt := s.skillReloader.CurrentSystemPrompt(); prompt != s.systemPrompt {
+		s.systemPrompt = prompt
+		s.Agent = nil
+		s.Provider = nil
+	}
+}
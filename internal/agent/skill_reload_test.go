@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/skills"
+)
+
+type fakeReloader struct {
+	mu      sync.Mutex
+	prompt  string
+	changes chan skills.Changes
+}
+
+func (r *fakeReloader) ReloadSkills() (skills.Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prompt = "system with <available_skills>lint</available_skills>"
+	return skills.Changes{Updated: []string{"deploy", "lint"}, Added: []string{"build"}}, nil
+}
+
+func (r *fakeReloader) CurrentSystemPrompt() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.prompt
+}
+
+func (r *fakeReloader) SubscribeSkills() (<-chan skills.Changes, func()) {
+	return r.changes, func() {}
+}
+
+func TestSkillsReloadRebuildsSystemPrompt(t *testing.T) {
+	s, output := newSettingsTestSession()
+	s.systemPrompt = "system"
+
+	s.handleSkills([]string{"reload"})
+	if !outputContains(output, "not available") {
+		t.Errorf("reload without a reloader should fail, got %v", output.Messages)
+	}
+
+	s.skillReloader = &fakeReloader{prompt: "system"}
+	s.Agent = nil
+	s.handleSkills([]string{"reload"})
+	if !outputContains(output, "Skills reloaded: 2 updated, 1 added, 0 removed") {
+		t.Errorf("missing reload summary in %v", output.Messages)
+	}
+	if s.systemPrompt != "system with <available_skills>lint</available_skills>" {
+		t.Errorf("system prompt = %q", s.systemPrompt)
+	}
+}
+
+func TestWatchSkillsReportsReload(t *testing.T) {
+	s, output := newSettingsTestSession()
+	s.done = make(chan struct{})
+	r := &fakeReloader{changes: make(chan skills.Changes)}
+	stopped := make(chan struct{})
+	go func() {
+		s.watchSkillFiles(r)
+		close(stopped)
+	}()
+
+	r.changes <- skills.Changes{Added: []string{"build"}}
+	close(s.done)
+	<-stopped
+	if !outputContains(output, "Skills reloaded: 0 updated, 1 added, 0 removed") {
+		t.Errorf("a reload was not reported, got %v", output.Messages)
+	}
+}
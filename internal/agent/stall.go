@@ -0,0 +1,129 @@
+package agent
+
+// Stalled streams.
+// Some providers stop sending in the middle of a response without closing
+// the stream, leaving the prompt in progress forever. processPrompt runs a
+// stallWatchdog over each request: every delta, tool event, and step pushes
+// its deadline back, and when --stream-stall-timeout passes without any, it
+// cancels the request with a StallError. Time spent running tools does not
+// count. A StallError is an incomplete response, so text already streamed
+// is continued like after a dropped connection (see continuation.go), and a
+// prompt left without an answer can be sent again with :retry.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// StallError reports a response stream canceled because the provider sent
+// nothing for Idle.
+type StallError struct {
+	Idle time.Duration
+}
+
+func (e *StallError) Error() string {
+	return fmt.Sprintf("provider stream stalled: no data for %s", e.Idle.Round(time.Second))
+}
+
+// Unwrap makes a stall an incomplete response, which is retried.
+func (e *StallError) Unwrap() error {
+	return llm.ErrIncompleteResponse
+}
+
+// stallWatchdog cancels a request when its stream goes quiet. It is safe
+// for concurrent use.
+type stallWatchdog struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+	pending int  // tool calls waiting for their result
+	done    bool // fired or stopped
+}
+
+// watchStream starts a watchdog that cancels ctx's request with a
+// StallError after timeout without events. It returns ctx unchanged and a
+// nil watchdog when timeout is 0. The timer only cancels: the output belongs
+// to the task goroutine, which reports the stall once the stream has ended
+// (see stallCause).
+func (s *Session) watchStream(ctx context.Context, timeout time.Duration) (context.Context, *stallWatchdog) {
+	if timeout <= 0 {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &stallWatchdog{timeout: timeout, cancel: cancel}
+	w.timer = time.AfterFunc(timeout, func() {
+		w.mu.Lock()
+		if w.done {
+			w.mu.Unlock()
+			return
+		}
+		w.done = true
+		w.mu.Unlock()
+		cancel(&StallError{Idle: timeout})
+	})
+	return ctx, w
+}
+
+// event pushes the deadline back after anything from the provider.
+func (w *stallWatchdog) event() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.done && w.pending == 0 {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+// toolCall pauses the watchdog while the tool runs.
+func (w *stallWatchdog) toolCall() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending++
+	w.timer.Stop()
+}
+
+// toolResult restarts the watchdog once no tool is running.
+func (w *stallWatchdog) toolResult() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	if w.pending > 0 {
+		w.pending--
+	}
+	w.mu.Unlock()
+	w.event()
+}
+
+// stop ends the watchdog when the request is over, releasing its context.
+// A stall that canceled the request stays its cause.
+func (w *stallWatchdog) stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.done = true
+	w.timer.Stop()
+	w.mu.Unlock()
+	w.cancel(nil)
+}
+
+// stallCause returns the StallError that canceled ctx, reporting it, or err
+// when the request ended otherwise.
+func (s *Session) stallCause(ctx context.Context, err error) error {
+	if stall, ok := context.Cause(ctx).(*StallError); ok && err != nil {
+		s.writeError(stall.Error() + "; the request was canceled")
+		return stall
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// stallingProvider sends one delta and then nothing until the request is
+// canceled, for its first stalls requests; later requests answer.
+type stallingProvider struct {
+	stalls int32
+	calls  atomic.Int32
+}
+
+func (p *stallingProvider) StreamMessages(ctx context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	events := make(chan llm.StreamEvent, 2)
+	if p.calls.Add(1) > p.stalls {
+		events <- llm.TextDeltaEvent{Delta: "ld!"}
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "ld!"}})},
+		}
+		close(events)
+		return events, nil
+	}
+	go func() {
+		defer close(events)
+		events <- llm.TextDeltaEvent{Delta: "Hello wor"}
+		<-ctx.Done()
+		events <- llm.StreamErrorEvent{Error: ctx.Err()}
+	}()
+	return events, nil
+}
+
+func TestStalledStreamIsCanceledAndContinued(t *testing.T) {
+	provider := &stallingProvider{stalls: 1}
+	session, output := newSummarizeTestSession(t, provider)
+	session.stallTimeout = 50 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		session.handleUserPrompt(context.Background(), "greet", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled prompt never finished")
+	}
+
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+	if !outputContains(output, "provider stream stalled: no data for") {
+		t.Errorf("the stall was not reported, got %q", output.Messages)
+	}
+	if err := session.Err(); err != nil {
+		t.Errorf("the continued prompt failed: %v", err)
+	}
+	if text := lastAssistantText(session.Messages); text != "ld!" {
+		t.Errorf("last reply = %q, want the continuation", text)
+	}
+}
+
+func TestStallWithoutTextIsReported(t *testing.T) {
+	session, output := newSummarizeTestSession(t, &stallingProvider{stalls: 3})
+	session.stallTimeout = 50 * time.Millisecond
+
+	session.handleUserPrompt(context.Background(), "greet", nil)
+
+	var stall *StallError
+	if err := session.Err(); !errors.As(err, &stall) {
+		t.Fatalf("prompt error = %v, want a StallError", err)
+	}
+	if len(session.Messages) != 0 {
+		t.Errorf("the unanswered turn was kept: %d messages", len(session.Messages))
+	}
+	if !outputContains(output, "use :retry") {
+		t.Errorf("the error should offer :retry, got %q", output.Messages)
+	}
+}
+
+func TestStallWatchdogIgnoresToolsAndStops(t *testing.T) {
+	session, _ := newSettingsTestSession()
+	const timeout = 30 * time.Millisecond
+
+	ctx, watchdog := session.watchStream(context.Background(), timeout)
+	watchdog.toolCall()
+	time.Sleep(3 * timeout)
+	if ctx.Err() != nil {
+		t.Fatal("time spent running a tool counted as a stall")
+	}
+	watchdog.toolResult()
+	time.Sleep(3 * timeout)
+	if _, ok := context.Cause(ctx).(*StallError); !ok {
+		t.Fatalf("cause = %v, want a StallError once the tool finished", context.Cause(ctx))
+	}
+
+	ctx, watchdog = session.watchStream(context.Background(), timeout)
+	watchdog.stop()
+	time.Sleep(3 * timeout)
+	if _, ok := context.Cause(ctx).(*StallError); ok {
+		t.Error("a stopped watchdog reported a stall")
+	}
+}
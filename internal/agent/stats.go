@@ -0,0 +1,88 @@
+package agent
+
+// Session statistics.
+// Every tool call is timed by the tools.WithMetrics wrapper, which finds the
+// session's collector in the task context, and every prompt is counted as a
+// turn. :stats prints the table; StatsSummary is the one-line version the
+// terminal appends to the transcript.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// handleStats prints per-tool call counts, durations, failure rates, and
+// output sizes.
+func (s *Session) handleStats() {
+	s.writeNotify(formatStats(s.metrics.Snapshot()))
+}
+
+// StatsSummary returns the session totals on one line, e.g.
+// "3 turns, 12 tool calls, 1m30s running prompts, 12m0s wall clock".
+func (s *Session) StatsSummary() string {
+	return formatStatsSummary(s.metrics.Snapshot())
+}
+
+func formatStatsSummary(snap tools.MetricsSnapshot) string {
+	return fmt.Sprintf("%s, %s, %s running prompts, %s wall clock",
+		plural(snap.Turns, "turn"), plural(snap.Calls(), "tool call"),
+		roundDuration(snap.TurnTime), roundDuration(snap.Elapsed))
+}
+
+func formatStats(snap tools.MetricsSnapshot) string {
+	var sb strings.Builder
+	sb.WriteString(formatStatsSummary(snap))
+	if len(snap.Tools) == 0 {
+		return sb.String()
+	}
+
+	width := len("tool")
+	for _, st := range snap.Tools {
+		width = max(width, len(st.Name))
+	}
+	row := func(name, calls, errs, avg, total, output string) {
+		fmt.Fprintf(&sb, "\n%-*s  %5s  %11s  %8s  %8s  %8s", width, name, calls, errs, avg, total, output)
+	}
+	sb.WriteString("\n")
+	row("tool", "calls", "errors", "avg", "total", "output")
+	for _, st := range snap.Tools {
+		row(st.Name,
+			fmt.Sprint(st.Calls),
+			fmt.Sprintf("%d (%d%%)", st.Errors, st.Errors*100/max(st.Calls, 1)),
+			roundDuration(st.AverageDuration()).String(),
+			roundDuration(st.Duration).String(),
+			formatBytes(st.OutputBytes))
+	}
+	return sb.String()
+}
+
+// roundDuration drops the precision nobody reads: milliseconds under a
+// second, tenths of a second above.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
+
+// formatBytes formats a byte count, e.g. "512 B" or "1.4 KB".
+func formatBytes(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+func TestFormatStats(t *testing.T) {
+	got := formatStats(tools.MetricsSnapshot{
+		Turns:    2,
+		TurnTime: 90 * time.Second,
+		Elapsed:  12 * time.Minute,
+		Tools: []tools.ToolStats{
+			{Name: "posix_shell", Calls: 4, Errors: 1, Duration: 5 * time.Second, OutputBytes: 3072},
+			{Name: "read_file", Calls: 1, Duration: 12 * time.Millisecond, OutputBytes: 200},
+		},
+	})
+	want := strings.Join([]string{
+		"2 turns, 5 tool calls, 1m30s running prompts, 12m0s wall clock",
+		"",
+		"tool         calls       errors       avg     total    output",
+		"posix_shell      4      1 (25%)      1.3s        5s    3.0 KB",
+		"read_file        1       0 (0%)      12ms      12ms     200 B",
+	}, "\n")
+	if got != want {
+		t.Errorf("formatStats =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.metrics = tools.NewMetrics(nil)
+
+	session.handleCommandSync(context.Background(), "stats")
+	if !outputContains(output, "0 turns, 0 tool calls") {
+		t.Errorf("expected the empty totals, got %v", output.Messages)
+	}
+
+	session.metrics.RecordCall("posix_shell", time.Second, false, 10)
+	session.handleCommandSync(context.Background(), "stats")
+	if !outputContains(output, "posix_shell") {
+		t.Errorf("expected a posix_shell row, got %v", output.Messages)
+	}
+}
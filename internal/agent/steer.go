@@ -0,0 +1,116 @@
+package agent
+
+// Steering a running prompt.
+// ":steer <text>" nudges the model without giving up the turn. While a
+// prompt streams, the guidance cancels only the current request: the text
+// streamed so far is kept as an assistant message, the guidance follows as a
+// user message, and the model is called again within the same task, so the
+// adaptors show the answer as a continuation of the turn. :steer runs at
+// once, like :cancel. When no prompt is streaming, the guidance is queued
+// ahead of every waiting task instead.
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// errSteered is the cause of a request canceled by :steer.
+var errSteered = errors.New("steered by the user")
+
+func isSteerCommand(cmd string) bool {
+	name, _, _ := strings.Cut(cmd, " ")
+	return name == "steer"
+}
+
+// handleSteer delivers guidance to the running prompt, or queues it first.
+// text keeps its line breaks.
+func (s *Session) handleSteer(text string) {
+	if text == "" {
+		s.writeError("usage: :steer <text>")
+		return
+	}
+
+	s.mu.Lock()
+	running := s.steerCurrent != nil
+	s.mu.Unlock()
+	if !running {
+		// Queued first, after the rate limit allows it
+		s.enqueue(UserPrompt{Text: text, Images: s.takeAttachments()}, true)
+		return
+	}
+	// Redirecting the running prompt calls the model again
+	if !s.allowPrompt() {
+		return
+	}
+
+	s.mu.Lock()
+	cancel := s.steerCurrent
+	if cancel != nil {
+		s.steering = append(s.steering, text)
+	}
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel(errSteered)
+		return
+	}
+	s.enqueue(UserPrompt{Text: text, Images: s.takeAttachments()}, true)
+}
+
+// streamSteerable runs processPrompt on the history at pos until a request
+// ends without being steered. Each time the user steers, the partial answer
+// and the guidance are added to the history and the model is called again.
+// It returns what processPrompt returned for the last request.
+func (s *Session) streamSteerable(ctx context.Context, pos *streamPosition) (string, error) {
+	for {
+		requestCtx, cancel := context.WithCancelCause(ctx)
+		s.mu.Lock()
+		s.steerCurrent = cancel
+		s.mu.Unlock()
+
+		_, partial, err := s.processPrompt(requestCtx, pos, s.Messages)
+
+		s.mu.Lock()
+		s.steerCurrent = nil
+		guidance := s.steering
+		s.steering = nil
+		s.mu.Unlock()
+		cancel(nil)
+
+		// Guidance that came in as the answer finished still gets a reply
+		if len(guidance) == 0 || ctx.Err() != nil {
+			return partial, err
+		}
+		s.applySteering(partial, guidance)
+		// The answer to the guidance starts a new displayed message
+		pos.step++
+	}
+}
+
+// applySteering closes the interrupted answer and adds the guidance to the
+// history.
+func (s *Session) applySteering(partial string, guidance []string) {
+	s.setMessages(cleanIncompleteToolCalls(s.Messages))
+	last := llm.RoleUser
+	if len(s.Messages) > 0 {
+		last = s.Messages[len(s.Messages)-1].Role
+	}
+	switch {
+	case strings.TrimSpace(partial) != "":
+		s.appendMessages(llm.NewAssistantMessage([]llm.ContentPart{
+			llm.TextPart{Type: "text", Text: partial + "\n\n" + interruptedMarker},
+		}))
+	case last != llm.RoleAssistant:
+		// The guidance must not follow the prompt or a tool result directly
+		s.appendMessages(llm.NewAssistantMessage([]llm.ContentPart{
+			llm.TextPart{Type: "text", Text: interruptedMarker},
+		}))
+	}
+
+	text := strings.Join(guidance, "\n\n")
+	s.appendMessages(llm.NewUserMessage(text))
+	s.writeNotify("[steer] " + text)
+	s.writeVerbosef("steered with %d bytes of guidance", len(text))
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// steeringProvider streams "Going left" and waits to be canceled on its
+// first request, then answers "Going right." and records the history it got.
+type steeringProvider struct {
+	streaming chan struct{} // closed once the first request streams
+
+	mu      sync.Mutex
+	calls   int
+	history []llm.Message // of the last request
+}
+
+func (p *steeringProvider) StreamMessages(ctx context.Context, messages []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.mu.Lock()
+	p.calls++
+	first := p.calls == 1
+	p.history = append([]llm.Message(nil), messages...)
+	p.mu.Unlock()
+
+	events := make(chan llm.StreamEvent, 2)
+	if !first {
+		events <- llm.TextDeltaEvent{Delta: "Going right."}
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "Going right."}})},
+		}
+		close(events)
+		return events, nil
+	}
+	go func() {
+		defer close(events)
+		events <- llm.TextDeltaEvent{Delta: "Going left"}
+		close(p.streaming)
+		<-ctx.Done()
+		events <- llm.StreamErrorEvent{Error: ctx.Err()}
+	}()
+	return events, nil
+}
+
+func TestSteerContinuesTheTurn(t *testing.T) {
+	provider := &steeringProvider{streaming: make(chan struct{})}
+	session, output := newSummarizeTestSession(t, provider)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		session.handleUserPrompt(context.Background(), "which way?", nil)
+	}()
+	<-provider.streaming
+	session.handleCommandSync(context.Background(), "steer go right\ninstead")
+	<-done
+
+	var got []string
+	for _, msg := range session.Messages {
+		var text strings.Builder
+		for _, part := range msg.Content {
+			if p, ok := part.(llm.TextPart); ok {
+				text.WriteString(p.Text)
+			}
+		}
+		got = append(got, string(msg.Role)+": "+text.String())
+	}
+	want := []string{
+		"user: which way?",
+		"assistant: Going left\n\n" + interruptedMarker,
+		"user: go right\ninstead",
+		"assistant: Going right.",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("history =\n%q\nwant\n%q", got, want)
+	}
+	if provider.calls != 2 || len(provider.history) != 3 {
+		t.Errorf("provider called %d times, last with %d messages; want 2 and 3", provider.calls, len(provider.history))
+	}
+
+	// One turn: the answer to the guidance follows in the next step
+	if !outputContains(output, "[:0-1-t:]Going left") || !outputContains(output, "[:0-2-t:]Going right.") {
+		t.Errorf("expected both answers in the same prompt, got %q", output.Messages)
+	}
+	if !outputContains(output, "[steer] go right\ninstead") {
+		t.Errorf("the guidance is not shown, got %q", output.Messages)
+	}
+	if outputContains(output, "context canceled") {
+		t.Errorf("a steered request should not report the cancellation, got %q", output.Messages)
+	}
+}
+
+func TestSteerWithoutPromptJumpsTheQueue(t *testing.T) {
+	session, output := newSettingsTestSession()
+	session.inProgress = true // a command is running
+	session.submitTask(UserPrompt{Text: "queued first"})
+
+	session.handleCommandSync(context.Background(), "steer look at the tests")
+
+	if len(session.taskQueue) != 2 {
+		t.Fatalf("queue has %d tasks, want 2", len(session.taskQueue))
+	}
+	if got := session.taskQueue[0].Task.(UserPrompt).Text; got != "look at the tests" {
+		t.Errorf("first queued task = %q, want the guidance", got)
+	}
+	if !outputContains(output, "[Queued #2, next]") {
+		t.Errorf("missing queue notice, got %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "steer")
+	if !outputContains(output, "usage: :steer <text>") {
+		t.Errorf("empty guidance should show the usage, got %q", output.Messages)
+	}
+}
@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/pkg/alayacore"
+)
+
+// summaryProvider replies with a fixed summary and records the last request.
+type summaryProvider struct {
+	reply    string
+	received []llm.Message
+}
+
+func (p *summaryProvider) StreamMessages(_ context.Context, messages []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.received = messages
+	events := make(chan llm.StreamEvent, 2)
+	events <- llm.TextDeltaEvent{Delta: p.reply}
+	events <- llm.StepCompleteEvent{
+		Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: p.reply}})},
+		Usage:    llm.Usage{InputTokens: 100, OutputTokens: 20},
+	}
+	close(events)
+	return events, nil
+}
+
+func newSummarizeTestSession(t *testing.T, provider llm.Provider) (*Session, *MockOutput) {
+	t.Helper()
+	session, output := newSettingsTestSession()
+	client, err := alayacore.New(alayacore.Config{Provider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Agent = client
+	session.Provider = provider
+	return session, output
+}
+
+func textMessage(role llm.MessageRole, text string) llm.Message {
+	return llm.Message{Role: role, Content: []llm.ContentPart{llm.TextPart{Type: "text", Text: text}}}
+}
+
+// conversation builds: user, assistant+tool call, tool result, assistant, user, assistant, user, assistant.
+func conversation() []llm.Message {
+	return []llm.Message{
+		textMessage(llm.RoleUser, "read main.go"),
+		{Role: llm.RoleAssistant, Content: []llm.ContentPart{llm.ToolCallPart{Type: "tool_use", ToolCallID: "c1", ToolName: "read_file"}}},
+		{Role: llm.RoleTool, Content: []llm.ContentPart{llm.ToolResultPart{Type: "tool_result", ToolCallID: "c1", Output: llm.ToolResultOutputText{Type: "text", Text: "package main"}}}},
+		textMessage(llm.RoleAssistant, "It is a main package."),
+		textMessage(llm.RoleUser, "add a flag"),
+		textMessage(llm.RoleAssistant, "Added --verbose."),
+		textMessage(llm.RoleUser, "thanks"),
+		textMessage(llm.RoleAssistant, "You're welcome."),
+	}
+}
+
+func TestSummarizeKeepsRecentMessages(t *testing.T) {
+	provider := &summaryProvider{reply: "Read main.go."}
+	session, output := newSummarizeTestSession(t, provider)
+	session.Messages = conversation()
+
+	session.handleSummarize(context.Background(), nil)
+
+	if len(session.Messages) != 5 {
+		t.Fatalf("expected recap plus 4 kept messages, got %d: %+v", len(session.Messages), session.Messages)
+	}
+	recap := session.Messages[0]
+	if recap.Role != llm.RoleAssistant || !strings.Contains(lastAssistantText(session.Messages[:1]), "Read main.go.") {
+		t.Errorf("unexpected recap message: %+v", recap)
+	}
+	if got := lastAssistantText(session.Messages); got != "You're welcome." {
+		t.Errorf("tail not kept verbatim, last assistant text = %q", got)
+	}
+	if session.Messages[1].Role != llm.RoleUser {
+		t.Errorf("kept tail should start at a user prompt, got %s", session.Messages[1].Role)
+	}
+
+	// Only the head was summarized, followed by the summarize instruction
+	if len(provider.received) != 5 {
+		t.Fatalf("expected 4 summarized messages plus the prompt, got %d", len(provider.received))
+	}
+	last := provider.received[len(provider.received)-1]
+	if text := last.Content[0].(llm.TextPart).Text; !strings.Contains(text, "File paths") {
+		t.Errorf("summarize prompt should ask for file paths, got %q", text)
+	}
+	if !outputContains(output, "Summarized 4 messages, kept the last 4") {
+		t.Errorf("expected summary notice, got %v", output.Messages)
+	}
+}
+
+func TestSummarizeDoesNotSplitToolCalls(t *testing.T) {
+	session, _ := newSummarizeTestSession(t, &summaryProvider{reply: "recap"})
+	session.Messages = append([]llm.Message{textMessage(llm.RoleUser, "hi"), textMessage(llm.RoleAssistant, "hello")}, conversation()...)
+
+	// Keeping 7 would start the tail at the tool call; the cut moves back to its prompt
+	session.handleSummarize(context.Background(), []string{"7"})
+
+	if len(session.Messages) != 9 {
+		t.Fatalf("expected recap plus 8 kept messages, got %d", len(session.Messages))
+	}
+	if first := session.Messages[1]; first.Role != llm.RoleUser || first.Content[0].(llm.TextPart).Text != "read main.go" {
+		t.Errorf("tail should start at the prompt that made the tool call, got %+v", first)
+	}
+	if session.Messages[3].Role != llm.RoleTool || session.Messages[2].Role != llm.RoleAssistant {
+		t.Errorf("tool call and result should stay together: %+v", session.Messages[2:4])
+	}
+}
+
+func TestSummarizeAll(t *testing.T) {
+	session, _ := newSummarizeTestSession(t, &summaryProvider{reply: "everything"})
+	session.Messages = conversation()
+
+	session.handleSummarize(context.Background(), []string{"0"})
+
+	if len(session.Messages) != 1 || lastAssistantText(session.Messages) != summaryPrefix+"everything" {
+		t.Errorf("expected only the recap, got %+v", session.Messages)
+	}
+}
+
+func TestSummarizeNothingToDo(t *testing.T) {
+	provider := &summaryProvider{reply: "unused"}
+	session, output := newSummarizeTestSession(t, provider)
+	session.Messages = conversation()[:2]
+
+	session.handleSummarize(context.Background(), nil)
+
+	if len(session.Messages) != 2 || provider.received != nil {
+		t.Errorf("short history should be left alone, got %+v", session.Messages)
+	}
+	if !outputContains(output, "Nothing to summarize") {
+		t.Errorf("expected notice, got %v", output.Messages)
+	}
+}
+
+func TestSummarizeRejectsBadArgument(t *testing.T) {
+	session, output := newSummarizeTestSession(t, &summaryProvider{})
+	session.handleSummarize(context.Background(), []string{"-1"})
+	if !outputContains(output, "usage: :summarize") {
+		t.Errorf("expected usage error, got %v", output.Messages)
+	}
+}
+
+func TestSummarizeCut(t *testing.T) {
+	msgs := conversation()
+	tests := map[int]int{0: 8, 1: 6, 2: 6, 3: 4, 4: 4, 5: 0, 6: 0, 8: 0, 10: 0}
+	for keep, want := range tests {
+		if got := summarizeCut(msgs, keep); got != want {
+			t.Errorf("summarizeCut(keep=%d) = %d, want %d", keep, got, want)
+		}
+	}
+}
+
+// overflowProvider rejects the requests listed in fail (counting from 1)
+// with a context-length error and answers the others like summaryProvider.
+type overflowProvider struct {
+	summaryProvider
+	fail     map[int]bool
+	requests int
+}
+
+func (p *overflowProvider) StreamMessages(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition, system, extra string) (<-chan llm.StreamEvent, error) {
+	p.requests++
+	if p.fail[p.requests] {
+		return nil, errors.New(`API error (status 400): {"error":{"message":"This model's maximum context length is 128000 tokens.","code":"context_length_exceeded"}}`)
+	}
+	return p.summaryProvider.StreamMessages(ctx, messages, tools, system, extra)
+}
+
+func TestContextLengthErrorSummarizesAndRetries(t *testing.T) {
+	provider := &overflowProvider{summaryProvider: summaryProvider{reply: "recap"}, fail: map[int]bool{1: true}}
+	session, output := newSummarizeTestSession(t, provider)
+	session.contextRecovery = true
+	session.Messages = conversation()
+
+	session.handleUserPrompt(context.Background(), "next step", nil)
+
+	// The failed request, the summary, and the retry
+	if provider.requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", provider.requests)
+	}
+	// Recap, 4 kept messages, the prompt, and the reply
+	if len(session.Messages) != 7 {
+		t.Fatalf("expected 7 messages, got %d: %+v", len(session.Messages), session.Messages)
+	}
+	if !strings.HasPrefix(lastAssistantText(session.Messages[:1]), summaryPrefix) {
+		t.Errorf("history should start with the recap, got %+v", session.Messages[0])
+	}
+	if prompt := provider.received[len(provider.received)-1]; prompt.Content[0].(llm.TextPart).Text != "next step" {
+		t.Errorf("retry should resend the prompt, got %+v", prompt)
+	}
+	if !outputContains(output, "exceeded the model's context window") {
+		t.Errorf("expected a recovery notice, got %v", output.Messages)
+	}
+	if outputContains(output, "maximum context length") {
+		t.Errorf("a recovered error should not be reported, got %v", output.Messages)
+	}
+}
+
+func TestContextLengthErrorRetriesOnce(t *testing.T) {
+	// The summary works, but the retry overflows again
+	provider := &overflowProvider{summaryProvider: summaryProvider{reply: "recap"}, fail: map[int]bool{1: true, 3: true}}
+	session, output := newSummarizeTestSession(t, provider)
+	session.contextRecovery = true
+	session.Messages = conversation()
+
+	session.handleUserPrompt(context.Background(), "next step", nil)
+
+	if provider.requests != 3 {
+		t.Fatalf("expected exactly one retry, got %d requests", provider.requests)
+	}
+	if !outputContains(output, "maximum context length") {
+		t.Errorf("expected the original error, got %v", output.Messages)
+	}
+}
+
+func TestContextLengthErrorWithoutRecovery(t *testing.T) {
+	provider := &overflowProvider{summaryProvider: summaryProvider{reply: "recap"}, fail: map[int]bool{1: true}}
+	session, output := newSummarizeTestSession(t, provider)
+	session.Messages = conversation()
+
+	session.handleUserPrompt(context.Background(), "next step", nil)
+
+	if provider.requests != 1 {
+		t.Fatalf("expected no retry, got %d requests", provider.requests)
+	}
+	if len(session.Messages) != len(conversation())+1 {
+		t.Errorf("history should be kept, got %d messages", len(session.Messages))
+	}
+	if !outputContains(output, "maximum context length") {
+		t.Errorf("expected the error, got %v", output.Messages)
+	}
+}
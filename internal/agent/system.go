@@ -0,0 +1,119 @@
+package agent
+
+// Standing instructions.
+// ":system add <text>" adds an instruction, such as "always answer in
+// French", to the system prompt of every later request of the session;
+// ":system list" shows them numbered and ":system remove <n>" drops one.
+// They follow the base prompt and the working directory note (see
+// sessionSystemPrompt), and the agent client is rebuilt with them at once.
+// They are saved with the session, as "system" lines of its frontmatter,
+// and each change is written as a notice, which the transcript records.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+)
+
+const systemUsage = "usage: :system [list | add <text> | remove <n>]"
+
+// SystemAddenda returns the standing instructions added with :system, in
+// order.
+func (s *Session) SystemAddenda() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.systemAddenda...)
+}
+
+// handleSystem lists, adds, or removes standing instructions. args is the
+// text after ":system", as an instruction holds spaces.
+func (s *Session) handleSystem(args string) {
+	sub, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+	switch sub {
+	case "", "list":
+		s.listSystemAddenda()
+	case "add":
+		if rest == "" {
+			s.writeError(systemUsage)
+			return
+		}
+		s.mu.Lock()
+		s.systemAddenda = append(s.systemAddenda, rest)
+		n := len(s.systemAddenda)
+		s.mu.Unlock()
+		s.rebuildClient()
+		s.writeNotifyf("Added system instruction %d: %s", n, rest)
+	case "remove":
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			s.writeError(systemUsage)
+			return
+		}
+		s.mu.Lock()
+		count := len(s.systemAddenda)
+		if n < 1 || n > count {
+			s.mu.Unlock()
+			s.writeError(domainerrors.NewSessionErrorf("system", "no instruction %d (there are %d)", n, count).Error())
+			return
+		}
+		removed := s.systemAddenda[n-1]
+		s.systemAddenda = append(s.systemAddenda[:n-1:n-1], s.systemAddenda[n:]...)
+		s.mu.Unlock()
+		s.rebuildClient()
+		s.writeNotifyf("Removed system instruction %d: %s", n, removed)
+	default:
+		s.writeError(systemUsage)
+	}
+}
+
+func (s *Session) listSystemAddenda() {
+	addenda := s.SystemAddenda()
+	if len(addenda) == 0 {
+		s.writeNotify("No system instructions (add one with :system add <text>)")
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "System instructions (%d):", len(addenda))
+	for i, text := range addenda {
+		fmt.Fprintf(&sb, "\n  %d. %s", i+1, text)
+	}
+	s.writeNotify(sb.String())
+}
+
+// systemAddendaPrompt returns the part of the system prompt holding the
+// standing instructions, or "" when there are none.
+func (s *Session) systemAddendaPrompt() string {
+	addenda := s.SystemAddenda()
+	if len(addenda) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nStanding instructions from the user for this session:")
+	for _, text := range addenda {
+		sb.WriteString("\n- " + text)
+	}
+	return sb.String()
+}
+
+// rebuildClient gives the agent client the current system prompt, keeping
+// the provider. Without a provider, the client is built with it on the next
+// prompt.
+func (s *Session) rebuildClient() {
+	s.mu.Lock()
+	provider := s.Provider
+	s.mu.Unlock()
+	if provider == nil {
+		return
+	}
+	client, err := s.newClient(provider)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.Agent, s.Provider = nil, nil
+		return
+	}
+	s.Agent = client
+}
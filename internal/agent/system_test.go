@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// systemProvider answers every request at once and records the system
+// prompt of each.
+type systemProvider struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (p *systemProvider) StreamMessages(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition, system, _ string) (<-chan llm.StreamEvent, error) {
+	p.mu.Lock()
+	p.prompts = append(p.prompts, system)
+	p.mu.Unlock()
+	events := make(chan llm.StreamEvent, 1)
+	events <- llm.StepCompleteEvent{
+		Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "ok"}})},
+	}
+	close(events)
+	return events, nil
+}
+
+func (p *systemProvider) last() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.prompts[len(p.prompts)-1]
+}
+
+func TestSystemChangesThePromptOfLaterRequests(t *testing.T) {
+	provider := &systemProvider{}
+	session, output := newSummarizeTestSession(t, provider)
+	session.systemPrompt = "base"
+	session.rebuildClient()
+	ctx := context.Background()
+
+	session.handleUserPrompt(ctx, "one", nil)
+	if got := provider.last(); got != "base" {
+		t.Fatalf("first prompt = %q, want the base prompt", got)
+	}
+
+	session.handleCommandSync(ctx, "system add always answer in French")
+	session.handleCommandSync(ctx, "system add the project root is /srv/app")
+	if !outputContains(output, "Added system instruction 2: the project root is /srv/app") {
+		t.Errorf("output = %q", output.Messages)
+	}
+	session.handleUserPrompt(ctx, "two", nil)
+	want := "base\n\nStanding instructions from the user for this session:\n- always answer in French\n- the project root is /srv/app"
+	if got := provider.last(); got != want {
+		t.Errorf("second prompt = %q, want %q", got, want)
+	}
+
+	output.Messages = nil
+	session.handleCommandSync(ctx, "system")
+	if !outputContains(output, "1. always answer in French") || !outputContains(output, "2. the project root is /srv/app") {
+		t.Errorf(":system = %q", output.Messages)
+	}
+
+	session.handleCommandSync(ctx, "system remove 1")
+	session.handleUserPrompt(ctx, "three", nil)
+	if got := provider.last(); strings.Contains(got, "French") || !strings.HasSuffix(got, "\n- the project root is /srv/app") {
+		t.Errorf("third prompt = %q", got)
+	}
+
+	session.handleCommandSync(ctx, "system remove 1")
+	session.handleUserPrompt(ctx, "four", nil)
+	if got := provider.last(); got != "base" {
+		t.Errorf("prompt without instructions = %q", got)
+	}
+}
+
+func TestSystemRejectsBadArguments(t *testing.T) {
+	session, output := newSettingsTestSession()
+	for _, cmd := range []string{"system add", "system remove x", "system drop 1"} {
+		output.Messages = nil
+		session.handleCommandSync(context.Background(), cmd)
+		if !outputContains(output, "usage: :system") {
+			t.Errorf(":%s: output = %q", cmd, output.Messages)
+		}
+	}
+	session.handleCommandSync(context.Background(), "system remove 1")
+	if !outputContains(output, "no instruction 1 (there are 0)") {
+		t.Errorf("output = %q", output.Messages)
+	}
+}
+
+func TestSystemAddendaAreSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.md")
+	session, _ := newSettingsTestSession()
+	addenda := []string{"always answer in French", "use \"tabs\"\nnot spaces"}
+	for _, text := range addenda {
+		session.handleSystem("add " + text)
+	}
+	if err := session.saveSessionToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.SystemAddenda, addenda) {
+		t.Errorf("loaded addenda = %q, want %q", data.SystemAddenda, addenda)
+	}
+}
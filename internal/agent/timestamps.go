@@ -0,0 +1,68 @@
+package agent
+
+// Event timestamps.
+// The frames of the events an audit asks about, the start of a task (its
+// echoed prompt or command), each tool call, and the end of a task, follow
+// a TagTime frame with the time of the event. Transcripts always record it;
+// with --timestamps or ":time on", clients also show it next to prompts and
+// tool calls. The setting goes to clients with the system data.
+
+import (
+	"time"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// SetTimestamps turns the display of event times on or off, as
+// --timestamps does at startup.
+func (s *Session) SetTimestamps(on bool) {
+	s.mu.Lock()
+	s.timestamps = on
+	s.mu.Unlock()
+	s.sendSystemInfo()
+}
+
+// Timestamps reports whether clients show event times.
+func (s *Session) Timestamps() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timestamps
+}
+
+func (s *Session) handleTime(args []string) {
+	if len(args) > 1 {
+		s.writeError("usage: :time [on|off]")
+		return
+	}
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			s.SetTimestamps(true)
+		case "off":
+			s.SetTimestamps(false)
+		default:
+			s.writeError(domainerrors.NewSessionErrorf("time", "expected on or off, got %q", args[0]).Error())
+			return
+		}
+	}
+	if s.Timestamps() {
+		s.writeNotify("Timestamps: on")
+	} else {
+		s.writeNotify("Timestamps: off (transcripts still record them)")
+	}
+}
+
+// writeTimed writes a frame after a TagTime frame with the current time.
+// Both go in one write, so no frame from another goroutine comes between
+// them.
+func (s *Session) writeTimed(tag, msg string) {
+	if s.Output == nil {
+		return
+	}
+	frames := stream.EncodeTLV(stream.TagTime, time.Now().Format(stream.TimeFormat))
+	frames = append(frames, stream.EncodeTLV(tag, msg)...)
+	//nolint:errcheck // Best effort write, errors ignored
+	_, _ = s.Output.Write(frames)
+	s.Output.Flush()
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+func TestTimeCommand(t *testing.T) {
+	session, output := newSettingsTestSession()
+
+	session.handleCommandSync(context.Background(), "time on")
+	if !session.Timestamps() || !outputContains(output, "Timestamps: on") || !outputContains(output, `"timestamps":true`) {
+		t.Fatalf("timestamps not on, got %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "time sometimes")
+	if !outputContains(output, `expected on or off, got "sometimes"`) || !session.Timestamps() {
+		t.Errorf("a bad argument should keep the setting, got %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "time off")
+	if session.Timestamps() || !outputContains(output, "Timestamps: off (transcripts still record them)") {
+		t.Errorf("timestamps not off, got %q", output.Messages)
+	}
+}
+
+func TestPromptStartCarriesTime(t *testing.T) {
+	for _, on := range []bool{false, true} {
+		session, output := newSettingsTestSession()
+		session.SetTimestamps(on)
+		output.Messages = nil
+
+		before := time.Now().Truncate(time.Millisecond)
+		session.signalPromptStart(3, "list files")
+		after := time.Now()
+
+		// The time goes out whether or not clients show it
+		msg := output.Messages[len(output.Messages)-1]
+		tag, length, err := stream.ParseHeader([]byte(msg))
+		if err != nil || tag != stream.TagTime {
+			t.Fatalf("timestamps %v: message does not start with a time frame: %q", on, msg)
+		}
+		at, err := time.Parse(stream.TimeFormat, msg[6:6+length])
+		if err != nil || at.Before(before) || at.After(after) {
+			t.Errorf("timestamps %v: time = %v (%v), want between %v and %v", on, at, err, before, after)
+		}
+		if want := string(stream.EncodeTLV(stream.TagTextUser, "#3 ▸ list files")); msg[6+length:] != want {
+			t.Errorf("timestamps %v: frame after the time = %q, want %q", on, msg[6+length:], want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/stream"
+	"github.com/alayacore/alayacore/pkg/alayacore"
+)
+
+// toolThenTextProvider calls the echo tool in its first step and answers in
+// its second.
+type toolThenTextProvider struct {
+	calls int
+}
+
+func (p *toolThenTextProvider) StreamMessages(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.calls++
+	events := make(chan llm.StreamEvent, 3)
+	if p.calls == 1 {
+		call := llm.ToolCallPart{Type: "tool_call", ToolCallID: "call-1", ToolName: "echo", Input: json.RawMessage(`{ "text": "hi" }`)}
+		events <- llm.ToolCallEvent{ToolCallID: call.ToolCallID, ToolName: call.ToolName, Input: call.Input}
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{call})},
+			Usage:    llm.Usage{InputTokens: 50, OutputTokens: 10},
+		}
+	} else {
+		events <- llm.TextDeltaEvent{Delta: "done"}
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "done"}})},
+			Usage:    llm.Usage{InputTokens: 70, OutputTokens: 5, CacheReadTokens: 40},
+		}
+	}
+	close(events)
+	return events, nil
+}
+
+func newVerboseTestSession(t *testing.T, verbose bool) (*Session, *MockOutput) {
+	t.Helper()
+	echo := llm.NewTool("echo", "Echo text").
+		WithExecute(func(_ context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+			return llm.NewTextResponse(string(input)), nil
+		}).
+		Build()
+	provider := &toolThenTextProvider{}
+	client, err := alayacore.New(alayacore.Config{Provider: provider, Tools: []llm.Tool{echo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, output := newSettingsTestSession()
+	session.Agent = client
+	session.Provider = provider
+	session.baseTools = []llm.Tool{echo}
+	session.verbose = verbose
+	return session, output
+}
+
+// verboseEvents decodes the TagSystemLog frames written to output.
+func verboseEvents(output *MockOutput) []string {
+	var events []string
+	for _, msg := range output.Messages {
+		if len(msg) >= 6 && msg[:2] == stream.TagSystemLog {
+			events = append(events, msg[6:])
+		}
+	}
+	return events
+}
+
+func TestVerboseLifecycleEvents(t *testing.T) {
+	session, output := newVerboseTestSession(t, true)
+	session.handleUserPrompt(context.Background(), "say hi", nil)
+
+	want := []string{
+		"agent started: model , 1 messages, 1 tools",
+		"step 1 started",
+		`tool echo invoked: {"text":"hi"}`,
+		`tool echo finished: success, 16 bytes`,
+		"usage: 50 input, 10 output, 0 cache read, 0 cache write tokens",
+		"step 1 finished: 2 messages",
+		"step 2 started",
+		"usage: 70 input, 5 output, 40 cache read, 0 cache write tokens",
+		"step 2 finished: 1 messages",
+	}
+	if got := verboseEvents(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("verbose events:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestVerboseOffWritesNoLifecycleEvents(t *testing.T) {
+	session, output := newVerboseTestSession(t, false)
+	session.handleUserPrompt(context.Background(), "say hi", nil)
+	if got := verboseEvents(output); len(got) != 0 {
+		t.Errorf("expected no verbose events, got %q", got)
+	}
+}
+
+func TestSummarizeInputTruncates(t *testing.T) {
+	got := summarizeInput(json.RawMessage(`{"text": "` + strings.Repeat("x", 200) + `"}`))
+	if len([]rune(got)) != maxInputSummary+1 || !strings.HasSuffix(got, "…") {
+		t.Errorf("summary not truncated: %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+// Session working directory.
+// ":cd <path>" points the session at another directory: the file tools
+// resolve relative paths against it, posix_shell and git run in it, and the
+// system prompt names it. ":pwd" shows it.
+
+import (
+	"context"
+	"os"
+
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// initWorkdir starts the session in the directory of the process.
+func (s *Session) initWorkdir() {
+	dir, err := os.Getwd()
+	if err != nil {
+		return // the tools keep using the process's directory
+	}
+	s.launchDir = dir
+	s.workdir = tools.NewWorkdir(dir)
+}
+
+// resolvePath resolves a path the user gave against the session's working
+// directory, as the file tools would.
+func (s *Session) resolvePath(path string) string {
+	ctx := context.Background()
+	if s.workdir != nil {
+		ctx = tools.WithWorkdir(ctx, s.workdir)
+	}
+	return tools.ResolvePath(ctx, path)
+}
+
+// handleCd changes the working directory. Without a path it goes home, like
+// cd in a shell.
+func (s *Session) handleCd(path string) {
+	if s.workdir == nil {
+		s.writeError(domainerrors.NewSessionErrorf("cd", "the working directory is unknown").Error())
+		return
+	}
+	if path == "" {
+		path = "~"
+	}
+	dir, err := s.workdir.Set(path)
+	if err != nil {
+		s.writeError(domainerrors.Wrap("cd", err).Error())
+		return
+	}
+
+	// The next prompt builds an agent whose system prompt names dir
+	s.mu.Lock()
+	s.Agent = nil
+	s.Provider = nil
+	s.mu.Unlock()
+	s.writeNotifyf("Working directory: %s", dir)
+	s.sendSystemInfo()
+}
+
+func (s *Session) handlePwd() {
+	if s.workdir == nil {
+		s.writeError(domainerrors.NewSessionErrorf("pwd", "the working directory is unknown").Error())
+		return
+	}
+	s.writeNotify(s.workdir.Get())
+}
+
+// sessionSystemPrompt returns the system prompt, telling the model about a
+// working directory changed with :cd, followed by the instructions of
+// :system. The prompt names the directory the process started in.
+func (s *Session) sessionSystemPrompt() string {
+	prompt := s.systemPrompt
+	if s.workdir != nil {
+		if dir := s.workdir.Get(); dir != s.launchDir {
+			prompt += "\n\nThe working directory of this session is now " + dir +
+				". Relative paths in read_file, write_file, edit_file, and replace_lines resolve against it, and posix_shell and git run in it."
+		}
+	}
+	return prompt + s.systemAddendaPrompt()
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCdChangesTheWorkdir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "my project"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	session, output := newSettingsTestSession()
+	session.systemPrompt = "base"
+	t.Chdir(root)
+	session.initWorkdir()
+	if got := session.sessionSystemPrompt(); got != "base" {
+		t.Errorf("prompt before :cd = %q, want it unchanged", got)
+	}
+
+	// The path may contain spaces
+	session.handleCommandSync(context.Background(), "cd my project")
+	want := filepath.Join(root, "my project")
+	if session.workdir.Get() != want || !outputContains(output, "Working directory: "+want) {
+		t.Fatalf("workdir = %q, output %q", session.workdir.Get(), output.Messages)
+	}
+	if !outputContains(output, `"workdir":`) {
+		t.Error("the status was not updated")
+	}
+	if got := session.sessionSystemPrompt(); !strings.HasPrefix(got, "base\n\n") || !strings.Contains(got, "is now "+want) {
+		t.Errorf("prompt after :cd = %q", got)
+	}
+
+	output.Messages = nil
+	session.handleCommandSync(context.Background(), "pwd")
+	if !outputContains(output, want) {
+		t.Errorf(":pwd = %q", output.Messages)
+	}
+
+	session.handleCommandSync(context.Background(), "cd missing")
+	if !outputContains(output, "no such directory") || session.workdir.Get() != want {
+		t.Errorf("a bad :cd should keep %q, got %q and %q", want, session.workdir.Get(), output.Messages)
+	}
+}
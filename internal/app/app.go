@@ -2,10 +2,21 @@ package app
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/alayacore/alayacore/internal/agent"
 	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/debug"
+	"github.com/alayacore/alayacore/internal/hooks"
 	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/memory"
 	"github.com/alayacore/alayacore/internal/skills"
 	"github.com/alayacore/alayacore/internal/tools"
 )
@@ -13,69 +24,404 @@ import (
 // This package provides shared initialization for both terminal and web adaptors.
 // It builds the system prompt, initializes tools, and creates the app config.
 
-const DefaultSystemPrompt = `IDENTITY:
+// systemPromptTemplate is the base of the system prompt. Its verbs take the
+// wording that depends on whether commands can run (see baseSystemPrompt).
+const systemPromptTemplate = `IDENTITY:
 - Your name is AlayaCore
-- You are a helpful AI assistant with access to tools for reading/writing files, executing shell commands, and activating skills
+- You are a helpful AI assistant with access to tools for %s
 
 RULES:
 - Never assume - verify with tools
 
 SKILLS:
 - Check <available_skills> below; activate relevant ones using the activate_skill tool
-- Skill instructions may use relative paths - run them from the skill's directory (derived from <location>)
+- Skill instructions may use relative paths - %s the skill's directory (derived from <location>)
 
 FILE EDITING:
 - Always read a file before editing it to get exact text including whitespace
 - Use edit_file for surgical changes; use write_file only for new files or complete rewrites
+- Use replace_lines to rewrite a range by the line numbers read_file shows, passing its hash as expected_hash
 - Include 3-5 lines of context in old_string to make matches unique
 - Match whitespace exactly - tabs, spaces, and newlines must be identical`
 
+// memoryPrompt is added to the system prompt with --memory.
+const memoryPrompt = `MEMORY:
+- You have a persistent memory shared by all sessions
+- Use search_memory when the user refers to prior work, earlier sessions, or facts about their environment
+- Use save_memory for lasting facts worth knowing next time, such as conventions, layouts, and decisions`
+
 // Config holds the common app configuration
 type Config struct {
 	Cfg               *config.Settings
 	Provider          llm.Provider
 	SkillsMgr         *skills.Manager
 	AgentTools        []llm.Tool
-	SystemPrompt      string // Default system prompt (always present)
-	ExtraSystemPrompt string // User-provided extra system prompt via --system flag
-	MaxSteps          int    // Maximum agent loop steps
+	SystemPrompt      string                  // Default system prompt (always present); read it with CurrentSystemPrompt once sessions run
+	ExtraSystemPrompt string                  // User-provided extra system prompt via --system flag
+	MaxSteps          int                     // Maximum agent loop steps
+	Shell             string                  // Resolved shell path used by posix_shell
+	Hooks             *hooks.Hooks            // Lifecycle hooks from --hooks; nil when unset
+	Prices            providers.Prices        // Model prices, with --pricing-file entries over the defaults
+	RequestExtras     providers.RequestExtras // --header and --extra-body, added to every provider request
+	Azure             providers.AzureOptions  // --azure-endpoint, --azure-deployment, --azure-api-version defaults for azure models
+	Memory            *memory.Manager         // Persistent memory from --memory; nil when unset
+	CommandPolicy     *tools.CommandPolicy    // Rules from --command-rule that posix_shell consults; :policy changes them
+	PathPolicy        *tools.PathPolicy       // Rules from --allow-path, --deny-path, and --safe-mode; nil when unset
+	Logger            *slog.Logger            // Records at --log-level in --log-format, on stderr
+	LogOutput         *logging.Output         // Where Logger writes; the terminal holds it while the UI runs
+
+	promptMu  sync.Mutex // guards SystemPrompt against ReloadSkills
+	toolNames []string   // the tools listed in the system prompt
+	cwd       string     // the working directory named in the system prompt
+
+	skillWatch  sync.Once                        // starts the skill watcher for the first SubscribeSkills
+	skillSubsMu sync.Mutex                       // guards skillSubs
+	skillSubs   map[chan skills.Changes]struct{} // the subscribers told about the watcher's reloads
 }
 
+// fileTools are the tools that take a "path" argument subject to the path policy.
+var fileTools = map[string]bool{"read_file": true, "write_file": true, "edit_file": true, "replace_lines": true}
+
+// writeTools are the tools whose changes --review-edits shows for approval.
+var writeTools = map[string]bool{"write_file": true, "edit_file": true, "replace_lines": true}
+
 // Setup initializes the common app components
 func Setup(cfg *config.Settings) (*Config, error) {
-	// Build the default system prompt
-	systemPrompt := DefaultSystemPrompt
+	if cfg.DebugAPI {
+		if err := debug.Enable(cfg.DebugLogDir); err != nil {
+			return nil, fmt.Errorf("failed to enable API debug log: %w", err)
+		}
+	}
 
-	skillsManager, err := skills.NewManager(cfg.Skills)
+	logOutput := logging.NewOutput(os.Stderr)
+	logger, err := newLogger(cfg, logOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	skillsManager, err := skills.NewManagerWithLogger(cfg.SkillRoots(), logger.With("component", "skills"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize skills: %w", err)
 	}
 
-	// Generate skills fragment for system prompt
-	skillsFragment := skillsManager.GenerateSystemPromptFragment()
-	if skillsFragment != "" {
-		systemPrompt = systemPrompt + "\n\n" + skillsFragment
+	// Build the tools selected by --enable-tools and --disable-tools
+	toolNames, err := tools.DefaultRegistry.Select(cfg.EnableTools, cfg.DisableTools)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.NoExec {
+		if toolNames, err = withoutExecTools(toolNames, cfg.EnableTools); err != nil {
+			return nil, err
+		}
+	}
+	var memoryMgr *memory.Manager
+	if cfg.Memory {
+		if memoryMgr, err = memory.Open(config.DefaultMemoryFile()); err != nil {
+			return nil, err
+		}
+	} else if toolNames, err = withoutMemoryTools(toolNames, cfg.EnableTools); err != nil {
+		return nil, err
+	}
+	if err := tools.CheckShell(cfg.Shell); err != nil {
+		return nil, err
+	}
+	shell := tools.ResolveShell(cfg.Shell)
+	envFilter, err := tools.NewEnvFilter(cfg.EnvInherit, cfg.EnvAllow, cfg.EnvDeny)
+	if err != nil {
+		return nil, err
+	}
+	agentTools, err := tools.DefaultRegistry.Build(toolNames, tools.Deps{
+		Shell:  shell,
+		Env:    envFilter,
+		Skills: skillsManager,
+		Read:   tools.ReadLimit{Lines: cfg.ReadMaxLines, Bytes: cfg.ReadMaxBytes},
+		Memory: memoryMgr,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Add current working directory to system prompt (at the end for better API cache reuse)
-	cwd, err := os.Getwd()
-	if err == nil && cwd != "" {
-		systemPrompt = systemPrompt + "\n\nCurrent working directory: " + cwd
+	hookSet, err := hooks.Load(cfg.Hooks, shell)
+	if err != nil {
+		return nil, err
+	}
+	if hookSet != nil {
+		hookSet.Logger = logger.With("component", "hooks")
+	}
+
+	formatters, err := tools.ParseFormatters(cfg.Formatters)
+	if err != nil {
+		return nil, err
 	}
 
-	readFileTool := tools.NewReadFileTool()
-	writeFileTool := tools.NewWriteFileTool()
-	activateSkillTool := tools.NewActivateSkillTool(skillsManager)
-	posixShellTool := tools.NewPosixShellTool()
-	editFileTool := tools.NewEditFileTool()
+	commandPolicy, err := tools.NewCommandPolicy(cfg.CommandRules)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := providers.LoadPrices(cfg.PricingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	extras, err := providers.ParseRequestExtras(cfg.Headers, cfg.ExtraBody)
+	if err != nil {
+		return nil, err
+	}
+
+	azure := providers.AzureOptions{
+		Endpoint:   cfg.AzureEndpoint,
+		Deployment: cfg.AzureDeployment,
+		APIVersion: cfg.AzureAPIVersion,
+	}
+
+	toolLogger := logger.With("component", "tools")
+	pathPolicy := tools.NewPathPolicy(cfg.AllowPaths, cfg.DenyPaths, cfg.SafeMode)
+	for i, tool := range agentTools {
+		// Tools run one at a time unless --parallel-tools is set
+		tool.Parallel = tool.Parallel && cfg.ParallelTools
+		// Commands and file changes are only described in a dry run
+		tool = tools.WithDryRun(tool)
+		// Inside the policies, so only changes they allow are shown for review
+		if cfg.ReviewEdits && writeTools[tool.Definition.Name] {
+			tool = tools.WithReview(tool, cfg.ReviewTimeout)
+		}
+		// Formats what was written, after review let the change through
+		if writeTools[tool.Definition.Name] {
+			tool = tools.WithFormatters(tool, formatters, shell, envFilter)
+		}
+		// Hooks run only when the call gets past the policies below
+		tool = tools.WithHooks(tool, hookSet)
+		// The file tools honor --allow-path, --deny-path, and --safe-mode
+		if fileTools[tool.Definition.Name] {
+			tool = tools.WithPathPolicy(tool, pathPolicy)
+		}
+		// posix_shell honors --command-rule, with ask rules shown for review
+		if tool.Definition.Name == "posix_shell" {
+			tool = tools.WithCommandPolicy(tool, commandPolicy, cfg.ReviewTimeout)
+		}
+		// Every tool honors the allowed-tools list of the session's active skill
+		tool = tools.WithSkillPolicy(tool)
+		// Outermost, so calls refused by a policy count as failures in :stats
+		// and in the log
+		agentTools[i] = tools.WithMetrics(tools.WithLogging(tool, toolLogger))
+	}
+	if cfg.NoExec {
+		// Calls the model makes up anyway get an error, not "unknown tool"
+		for _, name := range tools.ExecTools {
+			agentTools = append(agentTools, tools.WithMetrics(tools.Unavailable(name)))
+		}
+	}
+
+	cwd, _ := os.Getwd()
 
 	return &Config{
 		Cfg:               cfg,
 		Provider:          nil, // Provider will be created when model is set
 		SkillsMgr:         skillsManager,
-		AgentTools:        []llm.Tool{readFileTool, editFileTool, writeFileTool, activateSkillTool, posixShellTool},
-		SystemPrompt:      systemPrompt,
+		AgentTools:        agentTools,
+		SystemPrompt:      buildSystemPrompt(skillsManager.GenerateSystemPromptFragment(), toolNames, cwd),
 		ExtraSystemPrompt: cfg.SystemPrompt, // User-provided extra system prompt (supplemental, not replacement)
 		MaxSteps:          cfg.MaxSteps,
+		Shell:             shell,
+		Hooks:             hookSet,
+		Prices:            prices,
+		RequestExtras:     extras,
+		Azure:             azure,
+		Memory:            memoryMgr,
+		CommandPolicy:     commandPolicy,
+		PathPolicy:        pathPolicy,
+		Logger:            logger,
+		LogOutput:         logOutput,
+		toolNames:         toolNames,
+		cwd:               cwd,
 	}, nil
 }
+
+// newLogger returns the logger of --log-level and --log-format writing to w.
+func newLogger(cfg *config.Settings, w io.Writer) (*slog.Logger, error) {
+	level := slog.LevelWarn
+	if cfg.LogLevel != "" {
+		var err error
+		if level, err = logging.ParseLevel(cfg.LogLevel); err != nil {
+			return nil, err
+		}
+	}
+	return logging.New(w, cfg.LogFormat, level), nil
+}
+
+// withoutExecTools drops the tools that run programs, for --no-exec. Asking
+// for one with --enable-tools too is an error rather than silently ignored.
+func withoutExecTools(toolNames, enable []string) ([]string, error) {
+	for _, name := range enable {
+		if tools.IsExecTool(name) {
+			return nil, fmt.Errorf("--enable-tools %s conflicts with --no-exec", name)
+		}
+	}
+	var kept []string
+	for _, name := range toolNames {
+		if !tools.IsExecTool(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept, nil
+}
+
+// withoutMemoryTools drops the memory tools, which are offered only with
+// --memory. Asking for one with --enable-tools alone is an error.
+func withoutMemoryTools(toolNames, enable []string) ([]string, error) {
+	for _, name := range enable {
+		if tools.IsMemoryTool(name) {
+			return nil, fmt.Errorf("--enable-tools %s needs --memory", name)
+		}
+	}
+	var kept []string
+	for _, name := range toolNames {
+		if !tools.IsMemoryTool(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept, nil
+}
+
+// baseSystemPrompt fills in systemPromptTemplate. Without canExec it offers
+// no shell commands.
+func baseSystemPrompt(canExec bool) string {
+	if !canExec {
+		return fmt.Sprintf(systemPromptTemplate, "reading/writing files and activating skills", "resolve them against")
+	}
+	return fmt.Sprintf(systemPromptTemplate, "reading/writing files, executing shell commands, and activating skills", "run them from")
+}
+
+// buildSystemPrompt appends the skills fragment, the tools, and the working
+// directory to the default system prompt. Without posix_shell, the prompt
+// does not mention shell commands.
+func buildSystemPrompt(skillsFragment string, toolNames []string, cwd string) string {
+	systemPrompt := baseSystemPrompt(slices.Contains(toolNames, "posix_shell"))
+	if slices.Contains(toolNames, "search_memory") {
+		systemPrompt += "\n\n" + memoryPrompt
+	}
+	if skillsFragment != "" {
+		systemPrompt = systemPrompt + "\n\n" + skillsFragment
+	}
+
+	if len(toolNames) == 0 {
+		systemPrompt += "\n\nAVAILABLE TOOLS: none"
+	} else {
+		systemPrompt += "\n\nAVAILABLE TOOLS: " + strings.Join(toolNames, ", ")
+	}
+
+	// Add current working directory to system prompt (at the end for better API cache reuse)
+	if cwd != "" {
+		systemPrompt = systemPrompt + "\n\nCurrent working directory: " + cwd
+	}
+	return systemPrompt
+}
+
+// ReloadSkills discovers the skills again and rebuilds the system prompt
+// with them. Sessions pick the new prompt up on their next prompt.
+func (c *Config) ReloadSkills() (skills.Changes, error) {
+	c.promptMu.Lock()
+	defer c.promptMu.Unlock()
+	changes, err := c.SkillsMgr.Reload()
+	if err != nil {
+		return skills.Changes{}, err
+	}
+	c.SystemPrompt = buildSystemPrompt(c.SkillsMgr.GenerateSystemPromptFragment(), c.toolNames, c.cwd)
+	return changes, nil
+}
+
+// SessionOptions returns the options of a session configured from the
+// settings, for the adaptors to adjust to what they can offer.
+func (c *Config) SessionOptions() agent.SessionOptions {
+	return agent.SessionOptions{
+		Tools:             c.AgentTools,
+		SystemPrompt:      c.CurrentSystemPrompt(),
+		ExtraSystemPrompt: c.ExtraSystemPrompt,
+		MaxSteps:          c.MaxSteps,
+		SessionFile:       c.Cfg.Session,
+		ModelConfig:       c.Cfg.ModelConfig,
+		RuntimeConfig:     c.Cfg.RuntimeConfig,
+		DebugAPI:          c.Cfg.DebugAPI,
+		Verbose:           c.Cfg.Verbose,
+		Proxy:             c.Cfg.Proxy,
+		Sampling:          c.Cfg.Sampling,
+		ContextWarning:    c.Cfg.ContextWarning,
+		StallWarning:      c.Cfg.StallWarning,
+		NotifyAfter:       c.Cfg.NotifyAfterDuration(),
+		ContextRecovery:   !c.Cfg.NoContextRecovery,
+		Prices:            c.Prices,
+		Hooks:             c.Hooks,
+		SkillReloader:     c,
+		WatchSkills:       c.Cfg.WatchSkills,
+		StallTimeout:      c.Cfg.StallTimeout,
+		AutoContinue:      c.Cfg.AutoContinue,
+		RequestExtras:     c.RequestExtras,
+		Azure:             c.Azure,
+		Memory:            c.Memory,
+		CommandPolicy:     c.CommandPolicy,
+		PathPolicy:        c.PathPolicy,
+		SecretScan:        c.Cfg.SecretScan,
+		AskSecrets:        true,
+		Logger:            logging.OrDiscard(c.Logger).With("component", "session"),
+		DryRun:            c.Cfg.DryRun,
+		Timestamps:        c.Cfg.Timestamps,
+		ReviewEdits:       c.Cfg.ReviewEdits,
+		MaxFrameSize:      c.Cfg.MaxFrameSize,
+	}
+}
+
+// CurrentSystemPrompt returns the system prompt with the skills found last.
+func (c *Config) CurrentSystemPrompt() string {
+	c.promptMu.Lock()
+	defer c.promptMu.Unlock()
+	return c.SystemPrompt
+}
+
+// SubscribeSkills returns a channel that receives what each reload made by
+// the skill watcher changed, and a function that ends the subscription. The
+// first call starts the watcher, which all sessions of --watch-skills share
+// and which runs until the process exits; when it cannot start, the channel
+// never receives.
+func (c *Config) SubscribeSkills() (<-chan skills.Changes, func()) {
+	c.skillWatch.Do(func() {
+		if _, err := c.SkillsMgr.Watch(c.reloadWatchedSkills); err != nil {
+			logging.OrDiscard(c.Logger).Warn("skills are not watched", "error", err)
+		}
+	})
+
+	ch := make(chan skills.Changes, 1)
+	c.skillSubsMu.Lock()
+	if c.skillSubs == nil {
+		c.skillSubs = make(map[chan skills.Changes]struct{})
+	}
+	c.skillSubs[ch] = struct{}{}
+	c.skillSubsMu.Unlock()
+	return ch, func() {
+		c.skillSubsMu.Lock()
+		delete(c.skillSubs, ch)
+		c.skillSubsMu.Unlock()
+	}
+}
+
+// reloadWatchedSkills reloads the skills once for a change the watcher saw
+// and passes what changed to every subscriber. A subscriber that has not
+// taken the last changes yet misses these.
+func (c *Config) reloadWatchedSkills() {
+	changes, err := c.ReloadSkills()
+	if err != nil {
+		logging.OrDiscard(c.Logger).Warn("failed to reload skills", "error", err)
+		return
+	}
+	if len(changes.Added)+len(changes.Updated)+len(changes.Removed) == 0 {
+		return
+	}
+	c.skillSubsMu.Lock()
+	defer c.skillSubsMu.Unlock()
+	for ch := range c.skillSubs {
+		select {
+		case ch <- changes:
+		default:
+		}
+	}
+}
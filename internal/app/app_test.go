@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/config"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestSetupDisableTools(t *testing.T) {
+	cfg, err := Setup(&config.Settings{DisableTools: []string{"posix_shell"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, tool := range cfg.AgentTools {
+		names = append(names, tool.Definition.Name)
+	}
+	if got := strings.Join(names, ","); got != "read_file,edit_file,replace_lines,write_file,activate_skill,git,manage_todo" {
+		t.Errorf("agent tools = %s", got)
+	}
+	if !strings.Contains(cfg.SystemPrompt, "AVAILABLE TOOLS: read_file, edit_file, replace_lines, write_file, activate_skill, git, manage_todo\n") {
+		t.Errorf("system prompt does not list the active tools:\n%s", cfg.SystemPrompt)
+	}
+	if summary := cfg.RuntimeSummary("", "", ""); strings.Contains(summary, "posix_shell") {
+		t.Errorf("summary lists a disabled tool:\n%s", summary)
+	}
+}
+
+func TestSetupUnknownTool(t *testing.T) {
+	if _, err := Setup(&config.Settings{EnableTools: []string{"bash"}}); err == nil {
+		t.Error("expected an error for an unknown tool name")
+	}
+}
+
+// toolCallingProvider asks for posix_shell on its first request, answers on
+// the second, and records the tool definitions and history it gets.
+type toolCallingProvider struct {
+	requests [][]llm.ToolDefinition
+	history  []llm.Message // of the last request
+}
+
+func (p *toolCallingProvider) StreamMessages(_ context.Context, messages []llm.Message, tools []llm.ToolDefinition, _, _ string) (<-chan llm.StreamEvent, error) {
+	p.requests = append(p.requests, tools)
+	p.history = messages
+	events := make(chan llm.StreamEvent, 2)
+	if len(p.requests) == 1 {
+		events <- llm.ToolCallEvent{ToolCallID: "c1", ToolName: "posix_shell", Input: json.RawMessage(`{"command":"id"}`)}
+		events <- llm.StepCompleteEvent{}
+	} else {
+		events <- llm.StepCompleteEvent{
+			Messages: []llm.Message{llm.NewAssistantMessage([]llm.ContentPart{llm.TextPart{Type: "text", Text: "Could not run it."}})},
+		}
+	}
+	close(events)
+	return events, nil
+}
+
+func TestSetupNoExec(t *testing.T) {
+	cfg, err := Setup(&config.Settings{NoExec: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cfg.SystemPrompt, "shell") || !strings.Contains(cfg.SystemPrompt, "AVAILABLE TOOLS: read_file, edit_file, replace_lines, write_file, activate_skill, manage_todo\n") {
+		t.Errorf("system prompt still offers commands:\n%s", cfg.SystemPrompt)
+	}
+	if summary := cfg.RuntimeSummary("", "", ""); strings.Contains(summary, "posix_shell") {
+		t.Errorf("summary lists a removed tool:\n%s", summary)
+	}
+
+	provider := &toolCallingProvider{}
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Tools: cfg.AgentTools})
+	if _, err := agent.Stream(context.Background(), []llm.Message{llm.NewUserMessage("who am I?")}, llm.StreamCallbacks{}); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := json.Marshal(provider.requests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"posix_shell", "git"} {
+		if strings.Contains(string(schema), `"`+name+`"`) {
+			t.Errorf("the tools sent to the provider include %s: %s", name, schema)
+		}
+	}
+	if !strings.Contains(string(schema), `"read_file"`) {
+		t.Errorf("the tools sent to the provider lack read_file: %s", schema)
+	}
+
+	result := provider.history[len(provider.history)-1].Content[0].(llm.ToolResultPart)
+	if out, ok := result.Output.(llm.ToolResultOutputError); !ok || out.Error != "tool not available in this deployment: posix_shell" {
+		t.Errorf("a call to a removed tool got %#v", result.Output)
+	}
+}
+
+func TestBaseSystemPrompt(t *testing.T) {
+	for canExec, want := range map[bool][]string{
+		true:  {"reading/writing files, executing shell commands, and activating skills\n", "- Skill instructions may use relative paths - run them from the skill's directory"},
+		false: {"reading/writing files and activating skills\n", "- Skill instructions may use relative paths - resolve them against the skill's directory"},
+	} {
+		prompt := baseSystemPrompt(canExec)
+		for _, text := range want {
+			if !strings.Contains(prompt, text) {
+				t.Errorf("canExec=%v: the prompt lacks %q:\n%s", canExec, text, prompt)
+			}
+		}
+		if strings.Contains(prompt, "%!") {
+			t.Errorf("canExec=%v: bad template:\n%s", canExec, prompt)
+		}
+	}
+}
+
+func TestSetupNoExecRefusesEnabledExecTool(t *testing.T) {
+	_, err := Setup(&config.Settings{NoExec: true, EnableTools: []string{"read_file", "git"}})
+	if err == nil || !strings.Contains(err.Error(), "conflicts with --no-exec") {
+		t.Errorf("Setup() error = %v, want a conflict", err)
+	}
+}
+
+func TestSetupMemory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg, err := Setup(&config.Settings{Memory: true, EnableTools: []string{"read_file", "save_memory", "search_memory"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Memory == nil {
+		t.Fatal("--memory did not open the memory")
+	}
+	if !strings.Contains(cfg.SystemPrompt, "MEMORY:") || !strings.Contains(cfg.SystemPrompt, "AVAILABLE TOOLS: read_file, save_memory, search_memory\n") {
+		t.Errorf("system prompt does not offer the memory:\n%s", cfg.SystemPrompt)
+	}
+
+	cfg, err = Setup(&config.Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Memory != nil || strings.Contains(cfg.SystemPrompt, "memory") {
+		t.Errorf("memory is offered without --memory:\n%s", cfg.SystemPrompt)
+	}
+	if _, err := Setup(&config.Settings{EnableTools: []string{"search_memory"}}); err == nil || !strings.Contains(err.Error(), "needs --memory") {
+		t.Errorf("Setup() error = %v, want --memory to be required", err)
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/tools"
+)
+
+// RuntimeSummary describes the active runtime configuration for welcome messages.
+// The model fields come from the session's active model; pass empty strings when
+// no model is configured.
+func (c *Config) RuntimeSummary(protocolType, modelName, baseURL string) string {
+	var sb strings.Builder
+
+	if modelName == "" {
+		sb.WriteString("Model: (none configured)\n")
+	} else {
+		fmt.Fprintf(&sb, "Model: %s (%s)", modelName, protocolType)
+		if host := extractHost(baseURL); host != "" {
+			fmt.Fprintf(&sb, " @ %s", host)
+		}
+		sb.WriteString("\n")
+	}
+
+	var names []string
+	if c != nil && c.SkillsMgr != nil {
+		for _, skill := range c.SkillsMgr.GetMetadata() {
+			names = append(names, skill.Name)
+		}
+	}
+	if len(names) == 0 {
+		sb.WriteString("Skills: (none)\n")
+	} else {
+		fmt.Fprintf(&sb, "Skills: %s\n", strings.Join(names, ", "))
+	}
+
+	var toolNames []string
+	if c != nil {
+		for _, tool := range c.AgentTools {
+			if !tool.Hidden {
+				toolNames = append(toolNames, tool.Definition.Name)
+			}
+		}
+	}
+	if len(toolNames) == 0 {
+		sb.WriteString("Tools: (none)\n")
+	} else {
+		fmt.Fprintf(&sb, "Tools: %s\n", strings.Join(toolNames, ", "))
+	}
+
+	shell := tools.DefaultShell
+	if c != nil && c.Shell != "" {
+		shell = c.Shell
+	}
+	fmt.Fprintf(&sb, "Shell: %s\n", shell)
+
+	debugAPI := "off"
+	if c != nil && c.Cfg != nil && c.Cfg.DebugAPI {
+		debugAPI = "on"
+	}
+	fmt.Fprintf(&sb, "Debug API: %s", debugAPI)
+
+	return sb.String()
+}
+
+// extractHost returns the host (with port) of a base URL, or the raw value if it
+// does not parse as an absolute URL.
+func extractHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return baseURL
+	}
+	return u.Host
+}
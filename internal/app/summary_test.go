@@ -0,0 +1,45 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/config"
+)
+
+func TestRuntimeSummary(t *testing.T) {
+	cfg := &Config{Cfg: &config.Settings{DebugAPI: true}}
+	got := cfg.RuntimeSummary("anthropic", "claude-test", "https://api.example.com:8443/v1")
+
+	for _, want := range []string{
+		"Model: claude-test (anthropic) @ api.example.com:8443",
+		"Skills: (none)",
+		"Debug API: on",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRuntimeSummaryNoModel(t *testing.T) {
+	var cfg *Config
+	got := cfg.RuntimeSummary("", "", "")
+	if !strings.Contains(got, "Model: (none configured)") || !strings.Contains(got, "Debug API: off") {
+		t.Errorf("unexpected summary:\n%s", got)
+	}
+}
+
+func TestExtractHost(t *testing.T) {
+	tests := map[string]string{
+		"http://127.0.0.1:11434":    "127.0.0.1:11434",
+		"https://api.openai.com/v1": "api.openai.com",
+		"not a url":                 "not a url",
+		"":                          "",
+	}
+	for in, want := range tests {
+		if got := extractHost(in); got != want {
+			t.Errorf("extractHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
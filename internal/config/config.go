@@ -1,86 +1,442 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/providers"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/secrets"
 )
 
-// stringSlice implements flag.Value for multiple string flags
+// stringSlice implements flag.Value for repeatable string flags. The first
+// use on the command line replaces the values from config files and the
+// environment; later uses append.
 type stringSlice struct {
-	slice []string
+	target *[]string
+	set    bool
 }
 
 func (s *stringSlice) String() string {
-	return strings.Join(s.slice, ",")
+	if s.target == nil {
+		return ""
+	}
+	return strings.Join(*s.target, ",")
 }
 
 func (s *stringSlice) Set(value string) error {
-	s.slice = append(s.slice, value)
+	if !s.set {
+		*s.target = nil
+		s.set = true
+	}
+	*s.target = append(*s.target, value)
 	return nil
 }
 
-func (s *stringSlice) Get() []string {
-	return s.slice
-}
-
 // Settings holds all CLI configuration
 type Settings struct {
-	ShowVersion   bool
-	ShowHelp      bool
-	DebugAPI      bool
-	SystemPrompt  string
-	Skills        []string
-	Addr          string
-	Session       string
-	Proxy         string
-	ModelConfig   string
-	RuntimeConfig string
-	MaxSteps      int
-	ThemesFolder  string
-}
-
-// Parse parses CLI flags and returns settings
-func Parse() *Settings {
-	showVersion := flag.Bool("version", false, "Show version information")
-	showHelp := flag.Bool("help", false, "Show help information")
-	debugAPI := flag.Bool("debug-api", false, "Write raw API requests and responses to log file")
-	systemPrompt := &stringSlice{}
-	flag.Var(systemPrompt, "system", "Extra system prompt (can be specified multiple times, will be appended to default)")
-	skill := &stringSlice{}
-	flag.Var(skill, "skill", "Skill path (can be specified multiple times)")
-	addr := flag.String("addr", ":8080", "Server address to listen on (for web server)")
-	session := flag.String("session", "", "Session file path to load/save conversations")
-	proxy := flag.String("proxy", "", "HTTP proxy URL (e.g., http://127.0.0.1:7890 or socks5://127.0.0.1:1080)")
-	modelConfig := flag.String("model-config", "", "Model config file path (default: ~/.alayacore/model.conf)")
-	runtimeConfig := flag.String("runtime-config", "", "Runtime config file path (default: <model-config-dir>/runtime.conf, or ~/.alayacore/runtime.conf)")
-	maxSteps := flag.Int("max-steps", 100, "Maximum agent loop steps")
-	themesFolder := flag.String("themes", "", "Themes folder path (default: ~/.alayacore/themes)")
-	flag.Parse()
-
-	// Collect skill paths
-	skillPaths := skill.Get()
-
-	// Merge system prompts with "\n\n" separator
-	var mergedSystemPrompt string
-	systemPrompts := systemPrompt.Get()
-	if len(systemPrompts) > 0 {
-		mergedSystemPrompt = strings.Join(systemPrompts, "\n\n")
-	}
-
-	s := &Settings{
-		ShowVersion:   *showVersion,
-		ShowHelp:      *showHelp,
-		DebugAPI:      *debugAPI,
-		SystemPrompt:  mergedSystemPrompt,
-		Skills:        skillPaths,
-		Addr:          *addr,
-		Session:       *session,
-		Proxy:         *proxy,
-		ModelConfig:   *modelConfig,
-		RuntimeConfig: *runtimeConfig,
-		MaxSteps:      *maxSteps,
-		ThemesFolder:  *themesFolder,
-	}
-
-	return s
+	ShowVersion       bool
+	ShowHelp          bool
+	ListProviders     bool
+	DebugAPI          bool
+	Verbose           bool
+	DebugLogDir       string
+	LogLevel          string // debug, info, warn, or error
+	LogFormat         string // logging.FormatText or logging.FormatJSON; "" picks the binary's default
+	SystemPrompt      string
+	Skills            []string
+	NoDefaultSkills   bool // skip DefaultSkillDirs
+	WatchSkills       bool // reload the skills when a SKILL.md changes
+	Addr              string
+	PingInterval      time.Duration
+	Session           string
+	Proxy             string
+	Headers           []string // "Key: Value" headers added to every provider request
+	ExtraBody         string   // JSON object whose fields are added to every provider request body
+	AzureEndpoint     string   // Azure OpenAI resource endpoint for azure models without a base_url
+	AzureDeployment   string   // Azure deployment for azure models without model_name or deployment
+	AzureAPIVersion   string   // Azure api-version for azure models without api_version
+	ModelConfig       string
+	RuntimeConfig     string
+	MaxSteps          int
+	ThemesFolder      string
+	NoHighlight       bool
+	Render            bool // render markdown in assistant replies (terminal)
+	ShowReasoning     bool // fold reasoning like tool output instead of a one-line summary (terminal)
+	Timestamps        bool // show the time of each prompt and tool call; :time switches it
+	NoMouse           bool
+	Color             string // ColorAuto, ColorAlways, or ColorNever
+	Shell             string
+	ReadMaxLines      int // lines per read_file call
+	ReadMaxBytes      int // bytes per read_file call
+	MaxFrameSize      int // bytes of the longest TLV frame accepted as input
+	ParallelTools     bool
+	EnableTools       []string
+	DisableTools      []string
+	AllowPaths        []string
+	DenyPaths         []string
+	SafeMode          bool
+	ReviewEdits       bool
+	ReviewTimeout     time.Duration
+	NoExec            bool // remove the tools that run programs (posix_shell, git)
+	DryRun            bool // describe commands and file changes instead of making them; :dryrun switches it
+	Memory            bool // offer save_memory and search_memory, backed by ~/.alayacore/memory.json
+	EnvInherit        string
+	EnvAllow          []string
+	EnvDeny           []string
+	Hooks             string
+	Formatters        []string // "EXT=COMMAND" formatters run on files the file tools write
+	CommandRules      []string // "ACTION NAME [REGEX]" rules posix_shell consults before each command
+	SecretScan        string   // secrets.ModeBlock, ModeWarn, or ModeOff: prompts holding secrets where no one can be asked
+	Sampling          llm.SamplingOptions
+	ContextWarning    float64
+	StallWarning      time.Duration
+	StallTimeout      time.Duration // cancel a request whose stream sends nothing for this long; 0 never
+	AutoContinue      int           // continuations of a reply cut off at the output token limit; 0 never
+	Notify            string        // NotifyOff, NotifyBell, or NotifyDesktop
+	NotifyAfter       time.Duration
+	NoContextRecovery bool
+	PricingFile       string
+	TranscriptDir     string
+	MaxConns          int
+	MaxConnsPerIP     int
+	SessionTTL        time.Duration // close idle web sessions after this long; 0 never
+	Metrics           bool          // serve Prometheus metrics at /metrics and /api/sessions (web server)
+	PromptRate        int
+	WebRoot           string
+	Stdio             bool   // speak TLV on stdin/stdout instead of serving WebSocket
+	Handshake         bool   // with Stdio, send the protocol hello first
+	Batch             string // run the prompts of this file without the terminal UI
+	ContinueOnError   bool   // with Batch, run the prompts after one that failed
+	JSON              bool   // with Batch, print the results as JSON
+}
+
+// defaults returns the settings used when nothing overrides them.
+func defaults() *Settings {
+	return &Settings{
+		Addr:           ":8080",
+		PingInterval:   30 * time.Second,
+		MaxSteps:       100,
+		ContextWarning: 0.8,
+		StallWarning:   30 * time.Second,
+		StallTimeout:   2 * time.Minute,
+		AutoContinue:   3,
+		Notify:         NotifyDesktop,
+		NotifyAfter:    30 * time.Second,
+		MaxConns:       32,
+		MaxConnsPerIP:  4,
+		PromptRate:     10,
+		ReviewTimeout:  10 * time.Minute,
+		SecretScan:     secrets.ModeBlock,
+		ReadMaxLines:   2000,
+		ReadMaxBytes:   256 * 1024,
+		MaxFrameSize:   16 << 20,
+		Color:          ColorAuto,
+		LogLevel:       "warn",
+	}
+}
+
+// Values of --color.
+const (
+	ColorAuto   = "auto"   // colors unless NO_COLOR is set or stdout is not a terminal
+	ColorAlways = "always" // colors even when piped
+	ColorNever  = "never"  // no colors or other styling
+)
+
+// setColor validates and sets --color.
+func setColor(s *Settings, v string) error {
+	switch v {
+	case ColorAuto, ColorAlways, ColorNever:
+		s.Color = v
+		return nil
+	}
+	return fmt.Errorf("invalid color mode %q: use auto, always, or never", v)
+}
+
+// setLogLevel validates and sets --log-level.
+func setLogLevel(s *Settings, v string) error {
+	if _, err := logging.ParseLevel(v); err != nil {
+		return err
+	}
+	s.LogLevel = v
+	return nil
+}
+
+// setLogFormat validates and sets --log-format.
+func setLogFormat(s *Settings, v string) error {
+	switch v {
+	case logging.FormatText, logging.FormatJSON:
+		s.LogFormat = v
+		return nil
+	}
+	return fmt.Errorf("invalid log format %q: use text or json", v)
+}
+
+// Values of --notify: how the terminal reports a long prompt finishing while
+// it is not focused. The web UI uses the browser's notifications for all but
+// off.
+const (
+	NotifyOff     = "off"     // no notification
+	NotifyBell    = "bell"    // BEL only
+	NotifyDesktop = "desktop" // BEL and an OSC 9 / OSC 777 desktop notification
+)
+
+// setNotify validates and sets --notify.
+func setNotify(s *Settings, v string) error {
+	switch v {
+	case NotifyOff, NotifyBell, NotifyDesktop:
+		s.Notify = v
+		return nil
+	}
+	return fmt.Errorf("invalid notify mode %q: use off, bell, or desktop", v)
+}
+
+// setSecretScan validates and sets --secret-scan.
+func setSecretScan(s *Settings, v string) error {
+	switch v {
+	case secrets.ModeBlock, secrets.ModeWarn, secrets.ModeOff:
+		s.SecretScan = v
+		return nil
+	}
+	return fmt.Errorf("invalid secret scan mode %q: use block, warn, or off", v)
+}
+
+// DefaultSkillDirs returns the skill roots scanned unless --no-default-skills
+// is given, from the least to the most specific: ~/.alayacore/skills, then
+// .alayacore/skills in the working directory.
+func DefaultSkillDirs() []string {
+	project := filepath.Join(".alayacore", "skills")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return []string{project}
+	}
+	user := filepath.Join(home, ".alayacore", "skills")
+	if abs, err := filepath.Abs(project); err == nil && abs == user {
+		// Running in the home directory
+		return []string{user}
+	}
+	return []string{user, project}
+}
+
+// SkillRoots returns the skill roots in order of precedence, lowest first:
+// the default ones, then the --skill paths.
+func (s *Settings) SkillRoots() []string {
+	if s.NoDefaultSkills {
+		return s.Skills
+	}
+	return append(DefaultSkillDirs(), s.Skills...)
+}
+
+// TranscriptOff disables transcripts when given as --transcript-dir.
+const TranscriptOff = "off"
+
+// DefaultTranscriptDir returns the default transcript directory,
+// ~/.alayacore/transcripts.
+func DefaultTranscriptDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".alayacore", "transcripts")
+	}
+	return filepath.Join(home, ".alayacore", "transcripts")
+}
+
+// DefaultMemoryFile returns the file of the persistent memory used with
+// --memory, ~/.alayacore/memory.json.
+func DefaultMemoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".alayacore", "memory.json")
+	}
+	return filepath.Join(home, ".alayacore", "memory.json")
+}
+
+// TranscriptDirectory returns the directory for transcripts, or "" with
+// --transcript-dir off.
+func (s *Settings) TranscriptDirectory() string {
+	switch s.TranscriptDir {
+	case TranscriptOff:
+		return ""
+	case "":
+		return DefaultTranscriptDir()
+	}
+	return s.TranscriptDir
+}
+
+// NotifyAfterDuration returns how long a prompt must run before its end is
+// notified, or 0 with --notify off.
+func (s *Settings) NotifyAfterDuration() time.Duration {
+	if s.Notify == NotifyOff {
+		return 0
+	}
+	return s.NotifyAfter
+}
+
+// Parse builds the settings from, in increasing precedence, the built-in
+// defaults, the user config file, the project config file, ALAYACORE_*
+// environment variables, and the command-line flags.
+func Parse() (*Settings, error) {
+	var files []string
+	if path, err := UserConfigFile(); err == nil {
+		files = append(files, path)
+	}
+	files = append(files, ProjectConfigFile)
+	return parse(os.Args[1:], os.LookupEnv, files)
+}
+
+// parse applies the config files in order, then the environment, then args.
+func parse(args []string, lookupEnv func(string) (string, bool), files []string) (*Settings, error) {
+	s := defaults()
+	var systemPrompts []string
+	for _, path := range files {
+		if err := applyFile(s, &systemPrompts, path, lookupEnv); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(s, &systemPrompts, lookupEnv); err != nil {
+		return nil, err
+	}
+	if err := applyFlags(s, &systemPrompts, args); err != nil {
+		return nil, err
+	}
+	// Multiple system prompts are appended to the default one together
+	s.SystemPrompt = strings.Join(systemPrompts, "\n\n")
+	return s, nil
+}
+
+// applyFlags parses the command-line flags over s. Each flag defaults to the
+// value s already has, so only flags given on the command line change it.
+func applyFlags(s *Settings, systemPrompts *[]string, args []string) error {
+	fs := flag.NewFlagSet("alayacore", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.BoolVar(&s.ShowVersion, "version", s.ShowVersion, "Show version information")
+	fs.BoolVar(&s.ShowHelp, "help", s.ShowHelp, "Show help information")
+	fs.BoolVar(&s.ListProviders, "list-providers", s.ListProviders, "List the protocol_type values model.conf accepts")
+	fs.BoolVar(&s.DebugAPI, "debug-api", s.DebugAPI, "Write raw API requests and responses to log file")
+	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "Show agent lifecycle events: steps, tool invocations, and usage")
+	fs.Func("log-level", "Log level: debug, info, warn, or error (default: warn)", func(v string) error {
+		return setLogLevel(s, v)
+	})
+	fs.Func("log-format", "Log format: text or json (default: text, json for alayacore-web)", func(v string) error {
+		return setLogFormat(s, v)
+	})
+	fs.StringVar(&s.DebugLogDir, "debug-log-dir", s.DebugLogDir, "Directory for --debug-api log files (default: $XDG_STATE_HOME/alayacore or ~/.alayacore/logs)")
+	fs.Var(&stringSlice{target: systemPrompts}, "system", "Extra system prompt (can be specified multiple times, will be appended to default)")
+	fs.Var(&stringSlice{target: &s.Skills}, "skill", "Skill path (can be specified multiple times)")
+	fs.BoolVar(&s.NoDefaultSkills, "no-default-skills", s.NoDefaultSkills, "Do not scan ~/.alayacore/skills and ./.alayacore/skills")
+	fs.BoolVar(&s.WatchSkills, "watch-skills", s.WatchSkills, "Reload the skills when a SKILL.md in a skill root is added, changed, or removed")
+	fs.StringVar(&s.Addr, "addr", s.Addr, "Server address to listen on (for web server)")
+	fs.DurationVar(&s.PingInterval, "ping-interval", s.PingInterval, "WebSocket keepalive ping interval; connections without a pong for two intervals are closed (for web server)")
+	fs.IntVar(&s.MaxConns, "max-connections", s.MaxConns, "Maximum concurrent WebSocket connections, 0 for no limit (for web server)")
+	fs.IntVar(&s.MaxConnsPerIP, "max-connections-per-ip", s.MaxConnsPerIP, "Maximum concurrent WebSocket connections from one IP, 0 for no limit (for web server)")
+	fs.IntVar(&s.PromptRate, "prompt-rate", s.PromptRate, "Maximum prompts per minute per client IP, 0 for no limit (for web server)")
+	fs.BoolVar(&s.Metrics, "metrics", s.Metrics, "Serve Prometheus metrics at /metrics and the session list at /api/sessions (for web server)")
+	fs.DurationVar(&s.SessionTTL, "session-ttl", s.SessionTTL, "Close WebSocket sessions idle for this long, saving them to --transcript-dir first; 0 never closes them (for web server)")
+	fs.StringVar(&s.WebRoot, "web-root", s.WebRoot, "Directory to serve at / instead of the built-in chat UI (for web server)")
+	fs.BoolVar(&s.Stdio, "stdio", s.Stdio, "Speak the TLV protocol on stdin and stdout instead of serving WebSocket (for web server)")
+	fs.BoolVar(&s.Handshake, "handshake", s.Handshake, "With --stdio, send the protocol hello frame first and negotiate client hellos")
+	fs.StringVar(&s.Batch, "batch", s.Batch, "Run the prompts of this file, separated by lines of ---, one after another without the terminal UI, then print a summary")
+	fs.BoolVar(&s.ContinueOnError, "continue-on-error", s.ContinueOnError, "With --batch, run the prompts after one that failed")
+	fs.BoolVar(&s.JSON, "json", s.JSON, "With --batch, print the results as a JSON array and the output of the prompts on stderr")
+	fs.StringVar(&s.Session, "session", s.Session, "Session file path to load/save conversations")
+	fs.StringVar(&s.Proxy, "proxy", s.Proxy, "HTTP proxy URL (e.g., http://127.0.0.1:7890 or socks5://127.0.0.1:1080)")
+	fs.Var(&stringSlice{target: &s.Headers}, "header", "Add \"Key: Value\" to every provider request (can be specified multiple times)")
+	fs.StringVar(&s.ExtraBody, "extra-body", s.ExtraBody, "JSON object whose fields are added to every provider request body, e.g. '{\"provider\":{\"order\":[\"openai\"]}}'")
+	fs.StringVar(&s.AzureEndpoint, "azure-endpoint", s.AzureEndpoint, "Azure OpenAI resource endpoint, e.g. https://my-resource.openai.azure.com, for azure models without a base_url")
+	fs.StringVar(&s.AzureDeployment, "azure-deployment", s.AzureDeployment, "Azure OpenAI deployment for azure models without model_name or deployment")
+	fs.StringVar(&s.AzureAPIVersion, "azure-api-version", s.AzureAPIVersion, "Azure OpenAI api-version for azure models without api_version (default: "+providers.AzureAPIVersion+")")
+	fs.StringVar(&s.ModelConfig, "model-config", s.ModelConfig, "Model config file path (default: ~/.alayacore/model.conf)")
+	fs.StringVar(&s.RuntimeConfig, "runtime-config", s.RuntimeConfig, "Runtime config file path (default: <model-config-dir>/runtime.conf, or ~/.alayacore/runtime.conf)")
+	fs.IntVar(&s.MaxSteps, "max-steps", s.MaxSteps, "Maximum agent loop steps")
+	fs.StringVar(&s.ThemesFolder, "themes", s.ThemesFolder, "Themes folder path (default: ~/.alayacore/themes)")
+	fs.BoolVar(&s.NoHighlight, "no-highlight", s.NoHighlight, "Disable syntax highlighting of code blocks in the terminal")
+	fs.BoolVar(&s.Render, "render", s.Render, "Render markdown in assistant replies in the terminal")
+	fs.BoolVar(&s.ShowReasoning, "show-reasoning", s.ShowReasoning, "Show reasoning inline in the terminal instead of a one-line summary")
+	fs.BoolVar(&s.Timestamps, "timestamps", s.Timestamps, "Show the time of each prompt and tool call (transcripts always record them)")
+	fs.BoolVar(&s.NoMouse, "no-mouse", s.NoMouse, "Disable mouse scrolling and click-to-focus, keeping the terminal's own text selection")
+	fs.Func("color", "Color output: auto, always, or never (default: auto, which honors NO_COLOR)", func(v string) error {
+		return setColor(s, v)
+	})
+	fs.IntVar(&s.ReadMaxLines, "read-max-lines", s.ReadMaxLines, "Maximum lines one read_file call returns")
+	fs.IntVar(&s.ReadMaxBytes, "read-max-bytes", s.ReadMaxBytes, "Maximum bytes one read_file call returns")
+	fs.IntVar(&s.MaxFrameSize, "max-frame-size", s.MaxFrameSize, "Maximum bytes of one input frame (prompt, command, or attachment); longer frames are dropped, at most 64 MiB")
+	fs.BoolVar(&s.ParallelTools, "parallel-tools", s.ParallelTools, "Run consecutive read_file calls of one step concurrently")
+	fs.StringVar(&s.Shell, "shell", s.Shell, "Shell for the posix_shell tool: sh, bash, or a path (default: /bin/sh, sh on Windows; falls back to it if not found)")
+	fs.Func("enable-tools", "Comma-separated tools to enable (default: all)", func(v string) error {
+		s.EnableTools = splitList(v)
+		return nil
+	})
+	fs.Func("disable-tools", "Comma-separated tools to disable", func(v string) error {
+		s.DisableTools = splitList(v)
+		return nil
+	})
+	fs.Var(&stringSlice{target: &s.AllowPaths}, "allow-path", "Restrict file tools to this path or glob (can be specified multiple times)")
+	fs.Var(&stringSlice{target: &s.DenyPaths}, "deny-path", "Deny file tools access to this path or glob; overrides --allow-path (can be specified multiple times)")
+	fs.Float64Var(&s.ContextWarning, "context-warning", s.ContextWarning, "Warn before sending a prompt whose estimated size exceeds this fraction of the context window (0 disables)")
+	fs.DurationVar(&s.StallWarning, "stall-warning", s.StallWarning, "Warn when the provider sends nothing for this long during a prompt (0 disables)")
+	fs.IntVar(&s.AutoContinue, "auto-continue", s.AutoContinue, "Ask the model to continue a reply cut off at the output token limit, at most this many times per prompt (0 disables)")
+	fs.DurationVar(&s.StallTimeout, "stream-stall-timeout", s.StallTimeout, "Cancel a request whose stream sends nothing for this long, and continue or report it (0 disables)")
+	fs.Func("notify", "Notify when a long prompt finishes while the terminal is not focused: off, bell, or desktop (default: desktop)", func(v string) error {
+		return setNotify(s, v)
+	})
+	fs.DurationVar(&s.NotifyAfter, "notify-after", s.NotifyAfter, "Only notify for prompts that run at least this long (0 disables)")
+	fs.BoolVar(&s.NoContextRecovery, "no-context-recovery", s.NoContextRecovery, "Report context-length errors instead of summarizing the conversation and retrying once")
+	fs.StringVar(&s.PricingFile, "pricing-file", s.PricingFile, "JSON file of model prices in USD per million tokens, added to the built-in table")
+	fs.StringVar(&s.TranscriptDir, "transcript-dir", s.TranscriptDir, "Directory for plain-text session transcripts, \"off\" to disable (default: ~/.alayacore/transcripts)")
+	fs.BoolVar(&s.SafeMode, "safe-mode", s.SafeMode, "Deny file tools access to SSH keys, cloud credentials, and shell history")
+	fs.BoolVar(&s.ReviewEdits, "review-edits", s.ReviewEdits, "Show the diff of every write_file, edit_file, and replace_lines change and wait for accept or reject before writing")
+	fs.DurationVar(&s.ReviewTimeout, "review-timeout", s.ReviewTimeout, "Reject a reviewed change nobody decided on within this long (default: 10m)")
+	fs.BoolVar(&s.NoExec, "no-exec", s.NoExec, "Remove the tools that run programs (posix_shell, git); calls to them are refused")
+	fs.BoolVar(&s.DryRun, "dry-run", s.DryRun, "Describe shell commands, git changes, and file changes instead of making them; read-only tools still run")
+	fs.BoolVar(&s.Memory, "memory", s.Memory, "Give the model a persistent memory shared by all sessions (save_memory and search_memory tools, :memory command)")
+	fs.StringVar(&s.EnvInherit, "env-inherit", s.EnvInherit, "Environment passed to posix_shell: filtered drops secret-looking variables, all passes everything (default: filtered)")
+	fs.Var(&stringSlice{target: &s.EnvAllow}, "env-allow", "Pass this environment variable or pattern to posix_shell even if it looks secret (can be specified multiple times)")
+	fs.Var(&stringSlice{target: &s.EnvDeny}, "env-deny", "Never pass this environment variable or pattern to posix_shell (can be specified multiple times)")
+	fs.StringVar(&s.Hooks, "hooks", s.Hooks, "Hook file mapping events (pre_tool:<tool>, post_tool:<tool>, on_prompt_start, on_turn_end) to shell commands")
+	fs.Var(&stringSlice{target: &s.Formatters}, "formatter", "Format files written by write_file, edit_file, and replace_lines with EXT=COMMAND, e.g. \".go=gofmt -w\"; the path is appended (can be specified multiple times)")
+	fs.Var(&stringSlice{target: &s.CommandRules}, "command-rule", "Rule for posix_shell commands, ACTION NAME [REGEX] with ACTION allow, deny, or ask, e.g. \"deny sudo\"; the first rule matching a command decides (can be specified multiple times)")
+	fs.Func("secret-scan", "Prompts that look like they hold credentials: the terminal and web UI ask unless off; batch mode blocks, warns, or sends them (block, warn, or off; default: block)", func(v string) error {
+		return setSecretScan(s, v)
+	})
+	fs.Func("temperature", "Sampling temperature, >= 0 (default: provider default)", func(v string) error {
+		return s.Sampling.Set(llm.SamplingTemperature, v)
+	})
+	fs.Func("top-p", "Nucleus sampling top_p, in (0, 1] (default: provider default)", func(v string) error {
+		return s.Sampling.Set(llm.SamplingTopP, v)
+	})
+	fs.Func("max-output-tokens", "Maximum tokens per response (default: provider default)", func(v string) error {
+		return s.Sampling.Set(llm.SamplingMaxOutputTokens, v)
+	})
+	fs.Func("reasoning-effort", "Reasoning effort: low, medium, or high (OpenAI-compatible models only)", func(v string) error {
+		return s.Sampling.Set(llm.SamplingReasoningEffort, v)
+	})
+	fs.Func("thinking-budget-tokens", "Extended thinking budget in tokens, >= 1024 (Anthropic models only)", func(v string) error {
+		return s.Sampling.Set(llm.SamplingThinkingBudget, v)
+	})
+
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		// -h is not defined as a flag, but should still show help
+		s.ShowHelp = true
+		return nil
+	}
+	return err
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	s, err := parse(nil, envLookup(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Addr != ":8080" || s.MaxSteps != 100 || s.PingInterval != 30*time.Second || s.Color != ColorAuto {
+		t.Errorf("unexpected defaults: %+v", s)
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	user := writeConfig(t, dir, "user.yaml", `
+max_steps: 10
+addr: ":9000"
+shell: bash
+proxy: http://user
+`)
+	project := writeConfig(t, dir, "project.yaml", `
+max_steps: 20
+addr: ":9001"
+shell: zsh
+`)
+	env := map[string]string{
+		"ALAYACORE_MAX_STEPS": "30",
+		"ALAYACORE_ADDR":      ":9002",
+	}
+	s, err := parse([]string{"--max-steps", "40"}, envLookup(env), []string{user, project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.MaxSteps != 40 {
+		t.Errorf("max_steps = %d, want the flag value 40", s.MaxSteps)
+	}
+	if s.Addr != ":9002" {
+		t.Errorf("addr = %q, want the environment value", s.Addr)
+	}
+	if s.Shell != "zsh" {
+		t.Errorf("shell = %q, want the project value", s.Shell)
+	}
+	if s.Proxy != "http://user" {
+		t.Errorf("proxy = %q, want the user value", s.Proxy)
+	}
+}
+
+func TestParseLists(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yaml", `
+skills: [/a, /b]
+disable_tools: posix_shell, write_file
+allow_paths:
+  - /src
+headers:
+  - "X-Title: My Tool, v2"
+extra_body: '{"provider": {"sort": "price"}}'
+`)
+	s, err := parse([]string{"--allow-path", "/x", "--allow-path", "/y"}, envLookup(nil), []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/a", "/b"}; !reflect.DeepEqual(s.Skills, want) {
+		t.Errorf("skills = %v, want %v", s.Skills, want)
+	}
+	if want := []string{"posix_shell", "write_file"}; !reflect.DeepEqual(s.DisableTools, want) {
+		t.Errorf("disable_tools = %v, want %v", s.DisableTools, want)
+	}
+	// Flags replace the config file list rather than adding to it
+	if want := []string{"/x", "/y"}; !reflect.DeepEqual(s.AllowPaths, want) {
+		t.Errorf("allow_paths = %v, want %v", s.AllowPaths, want)
+	}
+	if want := []string{"X-Title: My Tool, v2"}; !reflect.DeepEqual(s.Headers, want) {
+		t.Errorf("headers = %v, want %v", s.Headers, want)
+	}
+	if s.ExtraBody != `{"provider": {"sort": "price"}}` {
+		t.Errorf("extra_body = %q", s.ExtraBody)
+	}
+}
+
+func TestSkillRoots(t *testing.T) {
+	t.Setenv("HOME", "/home/u")
+	t.Setenv("USERPROFILE", "/home/u")
+	s, err := parse([]string{"--skill", "/x"}, envLookup(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join("/home/u", ".alayacore", "skills"), filepath.Join(".alayacore", "skills"), "/x"}
+	if got := s.SkillRoots(); !reflect.DeepEqual(got, want) {
+		t.Errorf("roots = %v, want %v", got, want)
+	}
+
+	s, err = parse([]string{"--skill", "/x", "--no-default-skills"}, envLookup(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.SkillRoots(); !reflect.DeepEqual(got, []string{"/x"}) {
+		t.Errorf("roots with --no-default-skills = %v, want [/x]", got)
+	}
+}
+
+func TestParseSystemPrompts(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "extra.md", "From a file.\n")
+	path := writeConfig(t, dir, "config.yaml", `
+system: Be brief.
+system_file: extra.md
+`)
+	s, err := parse(nil, envLookup(nil), []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SystemPrompt != "Be brief.\n\nFrom a file." {
+		t.Errorf("system prompt = %q", s.SystemPrompt)
+	}
+
+	s, err = parse([]string{"--system", "From a flag."}, envLookup(nil), []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SystemPrompt != "From a flag." {
+		t.Errorf("system prompt = %q, want the flag to replace the config file", s.SystemPrompt)
+	}
+}
+
+func TestParseExpandsEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yaml", "proxy: http://${PROXY_HOST}:7890\n")
+	s, err := parse(nil, envLookup(map[string]string{"PROXY_HOST": "127.0.0.1"}), []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Proxy != "http://127.0.0.1:7890" {
+		t.Errorf("proxy = %q", s.Proxy)
+	}
+
+	_, err = parse(nil, envLookup(nil), []string{path})
+	if err == nil || !strings.Contains(err.Error(), "PROXY_HOST is not set") {
+		t.Errorf("unset variable: err = %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unknown key", "max_step: 5\n", nil, `unknown key "max_step"`},
+		{"bad integer", "max_steps: many\n", nil, "max_steps: invalid integer"},
+		{"list for scalar", "addr: [a, b]\n", nil, "addr: expected a single value"},
+		{"bad env", "", map[string]string{"ALAYACORE_VERBOSE": "sometimes"}, "ALAYACORE_VERBOSE"},
+		{"bad color", "color: rainbow\n", nil, `color: invalid color mode "rainbow"`},
+		{"bad notify", "notify: loud\n", nil, `notify: invalid notify mode "loud"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, dir, "config.yaml", tt.content)
+			_, err := parse(nil, envLookup(tt.env), []string{path})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMissingFile(t *testing.T) {
+	if _, err := parse(nil, envLookup(nil), []string{filepath.Join(t.TempDir(), "missing.yaml")}); err != nil {
+		t.Errorf("a missing config file is not an error: %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// ProjectConfigFile is the project-local config file, read from the working
+// directory. It overrides the user config file.
+const ProjectConfigFile = "alayacore.yaml"
+
+// EnvPrefix prefixes the environment variable of each config file key, e.g.
+// ALAYACORE_MAX_STEPS for max_steps.
+const EnvPrefix = "ALAYACORE_"
+
+// UserConfigFile returns the path of the user config file,
+// ~/.alayacore/config.yaml.
+func UserConfigFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".alayacore", "config.yaml"), nil
+}
+
+// setting is a config file key and how it maps onto Settings. Scalar values
+// arrive as strings; lists arrive as YAML sequences or comma-separated
+// strings.
+type setting struct {
+	set     func(s *Settings, value string) error
+	setList func(s *Settings, values []string)
+	lines   bool // a list given as one string is split at newlines, as its items may hold commas
+}
+
+// settings maps config file keys to Settings fields. system collects into the
+// system prompt list and is handled by the callers.
+var settings = map[string]setting{
+	"debug_api":              {set: boolSetting(func(s *Settings) *bool { return &s.DebugAPI })},
+	"verbose":                {set: boolSetting(func(s *Settings) *bool { return &s.Verbose })},
+	"log_level":              {set: setLogLevel},
+	"log_format":             {set: setLogFormat},
+	"debug_log_dir":          {set: stringSetting(func(s *Settings) *string { return &s.DebugLogDir })},
+	"skills":                 {setList: func(s *Settings, v []string) { s.Skills = v }},
+	"no_default_skills":      {set: boolSetting(func(s *Settings) *bool { return &s.NoDefaultSkills })},
+	"watch_skills":           {set: boolSetting(func(s *Settings) *bool { return &s.WatchSkills })},
+	"addr":                   {set: stringSetting(func(s *Settings) *string { return &s.Addr })},
+	"ping_interval":          {set: durationSetting(func(s *Settings) *time.Duration { return &s.PingInterval })},
+	"max_connections":        {set: intSetting(func(s *Settings) *int { return &s.MaxConns })},
+	"max_connections_per_ip": {set: intSetting(func(s *Settings) *int { return &s.MaxConnsPerIP })},
+	"prompt_rate":            {set: intSetting(func(s *Settings) *int { return &s.PromptRate })},
+	"session_ttl":            {set: durationSetting(func(s *Settings) *time.Duration { return &s.SessionTTL })},
+	"metrics":                {set: boolSetting(func(s *Settings) *bool { return &s.Metrics })},
+	"web_root":               {set: stringSetting(func(s *Settings) *string { return &s.WebRoot })},
+	"session":                {set: stringSetting(func(s *Settings) *string { return &s.Session })},
+	"proxy":                  {set: stringSetting(func(s *Settings) *string { return &s.Proxy })},
+	"headers":                {setList: func(s *Settings, v []string) { s.Headers = v }, lines: true},
+	"extra_body":             {set: stringSetting(func(s *Settings) *string { return &s.ExtraBody })},
+	"azure_endpoint":         {set: stringSetting(func(s *Settings) *string { return &s.AzureEndpoint })},
+	"azure_deployment":       {set: stringSetting(func(s *Settings) *string { return &s.AzureDeployment })},
+	"azure_api_version":      {set: stringSetting(func(s *Settings) *string { return &s.AzureAPIVersion })},
+	"model_config":           {set: stringSetting(func(s *Settings) *string { return &s.ModelConfig })},
+	"runtime_config":         {set: stringSetting(func(s *Settings) *string { return &s.RuntimeConfig })},
+	"max_steps":              {set: intSetting(func(s *Settings) *int { return &s.MaxSteps })},
+	"themes":                 {set: stringSetting(func(s *Settings) *string { return &s.ThemesFolder })},
+	"no_highlight":           {set: boolSetting(func(s *Settings) *bool { return &s.NoHighlight })},
+	"render":                 {set: boolSetting(func(s *Settings) *bool { return &s.Render })},
+	"show_reasoning":         {set: boolSetting(func(s *Settings) *bool { return &s.ShowReasoning })},
+	"timestamps":             {set: boolSetting(func(s *Settings) *bool { return &s.Timestamps })},
+	"no_mouse":               {set: boolSetting(func(s *Settings) *bool { return &s.NoMouse })},
+	"color":                  {set: setColor},
+	"shell":                  {set: stringSetting(func(s *Settings) *string { return &s.Shell })},
+	"read_max_lines":         {set: intSetting(func(s *Settings) *int { return &s.ReadMaxLines })},
+	"read_max_bytes":         {set: intSetting(func(s *Settings) *int { return &s.ReadMaxBytes })},
+	"max_frame_size":         {set: intSetting(func(s *Settings) *int { return &s.MaxFrameSize })},
+	"parallel_tools":         {set: boolSetting(func(s *Settings) *bool { return &s.ParallelTools })},
+	"enable_tools":           {setList: func(s *Settings, v []string) { s.EnableTools = v }},
+	"disable_tools":          {setList: func(s *Settings, v []string) { s.DisableTools = v }},
+	"allow_paths":            {setList: func(s *Settings, v []string) { s.AllowPaths = v }},
+	"deny_paths":             {setList: func(s *Settings, v []string) { s.DenyPaths = v }},
+	"safe_mode":              {set: boolSetting(func(s *Settings) *bool { return &s.SafeMode })},
+	"review_edits":           {set: boolSetting(func(s *Settings) *bool { return &s.ReviewEdits })},
+	"review_timeout":         {set: durationSetting(func(s *Settings) *time.Duration { return &s.ReviewTimeout })},
+	"no_exec":                {set: boolSetting(func(s *Settings) *bool { return &s.NoExec })},
+	"dry_run":                {set: boolSetting(func(s *Settings) *bool { return &s.DryRun })},
+	"memory":                 {set: boolSetting(func(s *Settings) *bool { return &s.Memory })},
+	"env_inherit":            {set: stringSetting(func(s *Settings) *string { return &s.EnvInherit })},
+	"env_allow":              {setList: func(s *Settings, v []string) { s.EnvAllow = v }},
+	"env_deny":               {setList: func(s *Settings, v []string) { s.EnvDeny = v }},
+	"hooks":                  {set: stringSetting(func(s *Settings) *string { return &s.Hooks })},
+	"formatters":             {setList: func(s *Settings, v []string) { s.Formatters = v }, lines: true},
+	"command_rules":          {setList: func(s *Settings, v []string) { s.CommandRules = v }, lines: true},
+	"secret_scan":            {set: setSecretScan},
+	"context_warning": {set: func(s *Settings, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		s.ContextWarning = f
+		return nil
+	}},
+	"no_context_recovery":  {set: boolSetting(func(s *Settings) *bool { return &s.NoContextRecovery })},
+	"stall_warning":        {set: durationSetting(func(s *Settings) *time.Duration { return &s.StallWarning })},
+	"auto_continue":        {set: intSetting(func(s *Settings) *int { return &s.AutoContinue })},
+	"stream_stall_timeout": {set: durationSetting(func(s *Settings) *time.Duration { return &s.StallTimeout })},
+	"notify":               {set: setNotify},
+	"notify_after":         {set: durationSetting(func(s *Settings) *time.Duration { return &s.NotifyAfter })},
+	"pricing_file":         {set: stringSetting(func(s *Settings) *string { return &s.PricingFile })},
+	"transcript_dir":       {set: stringSetting(func(s *Settings) *string { return &s.TranscriptDir })},
+
+	llm.SamplingTemperature:     {set: samplingSetting(llm.SamplingTemperature)},
+	llm.SamplingTopP:            {set: samplingSetting(llm.SamplingTopP)},
+	llm.SamplingMaxOutputTokens: {set: samplingSetting(llm.SamplingMaxOutputTokens)},
+	llm.SamplingReasoningEffort: {set: samplingSetting(llm.SamplingReasoningEffort)},
+	llm.SamplingThinkingBudget:  {set: samplingSetting(llm.SamplingThinkingBudget)},
+}
+
+func stringSetting(field func(*Settings) *string) func(*Settings, string) error {
+	return func(s *Settings, v string) error {
+		*field(s) = v
+		return nil
+	}
+}
+
+func boolSetting(field func(*Settings) *bool) func(*Settings, string) error {
+	return func(s *Settings, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*field(s) = b
+		return nil
+	}
+}
+
+func intSetting(field func(*Settings) *int) func(*Settings, string) error {
+	return func(s *Settings, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*field(s) = n
+		return nil
+	}
+}
+
+func durationSetting(field func(*Settings) *time.Duration) func(*Settings, string) error {
+	return func(s *Settings, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*field(s) = d
+		return nil
+	}
+}
+
+func samplingSetting(name string) func(*Settings, string) error {
+	return func(s *Settings, v string) error {
+		return s.Sampling.Set(name, v)
+	}
+}
+
+// applyFile applies the YAML config file at path. A missing file is not an
+// error. String values may reference environment variables as ${NAME}.
+// system_file names a file, relative to the config file, whose content is
+// added to the system prompts.
+func applyFile(s *Settings, systemPrompts *[]string, path string, lookupEnv func(string) (string, bool)) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Sorted, so the first of several errors is reported consistently
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filePrompts []string
+	for _, key := range keys {
+		items, err := fileValues(values[key], lookupEnv)
+		if err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		switch key {
+		case "system":
+			filePrompts = append(filePrompts, items...)
+			continue
+		case "system_file":
+			for _, name := range items {
+				// Relative to the config file, like an include
+				if !filepath.IsAbs(name) {
+					name = filepath.Join(filepath.Dir(path), name)
+				}
+				content, err := os.ReadFile(name)
+				if err != nil {
+					return fmt.Errorf("config file %s: system_file: %w", path, err)
+				}
+				filePrompts = append(filePrompts, strings.TrimSpace(string(content)))
+			}
+			continue
+		}
+		if err := applySetting(s, key, items); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	if len(filePrompts) > 0 {
+		*systemPrompts = filePrompts
+	}
+	return nil
+}
+
+// fileValues converts a YAML value to strings: one for a scalar, one per
+// item for a sequence. Environment references are expanded.
+func fileValues(value any, lookupEnv func(string) (string, bool)) ([]string, error) {
+	var raw []any
+	switch v := value.(type) {
+	case []any:
+		raw = v
+	case map[string]any:
+		return nil, errors.New("expected a value or a list, got a mapping")
+	case nil:
+		return nil, nil
+	default:
+		raw = []any{v}
+	}
+
+	items := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if _, ok := item.(map[string]any); ok {
+			return nil, errors.New("expected a value or a list, got a mapping")
+		}
+		str, err := ExpandEnv(fmt.Sprint(item), lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, str)
+	}
+	return items, nil
+}
+
+// applyEnv applies ALAYACORE_* environment variables, one per config file
+// key. Lists are comma-separated. ALAYACORE_SYSTEM replaces the system
+// prompts of the config files.
+func applyEnv(s *Settings, systemPrompts *[]string, lookupEnv func(string) (string, bool)) error {
+	if v, ok := lookupEnv(EnvPrefix + "SYSTEM"); ok {
+		*systemPrompts = []string{v}
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := EnvPrefix + strings.ToUpper(key)
+		v, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := applySetting(s, key, []string{v}); err != nil {
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applySetting sets key from values. A single scalar given to a list key is
+// split at commas, or at newlines for keys whose items may hold commas.
+func applySetting(s *Settings, key string, values []string) error {
+	st, ok := settings[key]
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	if st.setList != nil {
+		if len(values) == 1 && st.lines {
+			values = splitLines(values[0])
+		} else if len(values) == 1 {
+			values = splitList(values[0])
+		}
+		st.setList(s, values)
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%s: expected a single value, got %d", key, len(values))
+	}
+	if err := st.set(s, strings.TrimSpace(values[0])); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// splitLines splits a list given one item per line, dropping empty lines.
+func splitLines(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, "\n") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ExpandEnv replaces ${NAME} references in value with the environment
+// variable NAME, so secrets can stay out of config files. An unset variable
+// is an error rather than an empty string. A bare $ is left alone.
+func ExpandEnv(value string, lookupEnv func(string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			sb.WriteString(value)
+			return sb.String(), nil
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+		name := value[start+2 : start+end]
+		v, ok := lookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		sb.WriteString(value[:start])
+		sb.WriteString(v)
+		value = value[start+end+1:]
+	}
+}
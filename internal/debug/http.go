@@ -1,9 +1,9 @@
 package debug
 
 // Package debug contains a small HTTP transport wrapper that logs API
-// requests and responses to a rotating local log file (or stderr as a
-// fallback). It is only used when the CLI enables --debug-api or when
-// providers are created with debug turned on.
+// requests and responses to size-rotated log files in --debug-log-dir.
+// It is only used when the CLI enables --debug-api or when providers are
+// created with debug turned on.
 
 import (
 	"bytes"
@@ -16,8 +16,6 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -27,59 +25,49 @@ import (
 
 var (
 	debugWriter io.Writer
-	initOnce    sync.Once
+	debugMu     sync.Mutex
 )
 
-func Enable() {
-	initOnce.Do(func() {
-		debugWriter = newDebugWriter()
-		// Keep the standard library logger consistent with our chosen writer.
-		log.SetOutput(debugWriter)
-	})
-}
+// Enable starts writing API debug logs to rotating files in dir
+// (DefaultLogDir() if empty). Calling it again after a successful
+// call is a no-op.
+func Enable(dir string) error {
+	debugMu.Lock()
+	defer debugMu.Unlock()
 
-// newDebugWriter picks a log destination:
-//   - prefer a new file named alayacore-debug-api-N.log next to the binary;
-//   - fall back to the current working directory;
-//   - finally fall back to stderr if nothing works.
-func newDebugWriter() io.Writer {
-	// Try to create log file in executable directory.
-	execPath, err := os.Executable()
-	if err != nil {
-		// Fallback to current directory.
-		execPath = "alayacore"
+	if debugWriter != nil {
+		return nil
 	}
-
-	execDir := filepath.Dir(execPath)
-	if execDir == "." {
-		if cwd, cwdErr := os.Getwd(); cwdErr == nil {
-			execDir = cwd
-		}
+	if dir == "" {
+		dir = DefaultLogDir()
 	}
 
-	const baseName = "alayacore-debug-api"
-
-	// Find next available log number.
-	for i := range 100 {
-		logName := fmt.Sprintf("%s-%d.log", baseName, i)
-		logPath := filepath.Join(execDir, logName)
-		if f, err := os.OpenFile(logPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err == nil {
-			// Write the start message directly to the file, not to stderr
-			fmt.Fprintf(f, "Debug log started: %s\n", filepath.Base(logPath))
-			return f
-		}
+	w, err := newRotatingWriter(dir, DefaultMaxLogSize, DefaultMaxLogFiles)
+	if err != nil {
+		return err
 	}
-
-	// Fallback to stderr if we can't create a log file.
-	return os.Stderr
+	debugWriter = w
+	// Keep the standard library logger consistent with our chosen writer.
+	log.SetOutput(debugWriter)
+	return nil
 }
 
+// writef writes to the debug log, if enabled. It takes debugMu, as Enable
+// may set the writer at any time.
 func writef(format string, args ...any) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
 	if debugWriter != nil {
 		fmt.Fprintf(debugWriter, format, args...)
 	}
 }
 
+// Logf writes a timestamped line to the debug log. It does nothing unless
+// debug logging was enabled with Enable, so callers can log freely.
+func Logf(format string, args ...any) {
+	writef("%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
 // Transport wraps an http.RoundTripper and logs requests and responses
 type Transport struct {
 	Transport http.RoundTripper
@@ -282,9 +270,9 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
-// NewHTTPClient creates a new HTTP client with debug logging enabled
+// NewHTTPClient creates a new HTTP client with debug logging enabled.
+// Logs are only written once Enable has succeeded.
 func NewHTTPClient() *http.Client {
-	Enable()
 	return &http.Client{
 		Timeout: 10 * time.Minute,
 		Transport: &Transport{
@@ -339,7 +327,6 @@ func NewHTTPClientWithProxyAndDebug(proxyURL string) (*http.Client, error) {
 		return nil, err
 	}
 
-	Enable()
 	// Wrap the transport with debug logging
 	client.Transport = &Transport{
 		Transport: client.Transport,
@@ -0,0 +1,37 @@
+package debug
+
+import (
+	"log"
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestEnableWhileLogging runs under -race: writers may log while Enable
+// sets the writer.
+func TestEnableWhileLogging(t *testing.T) {
+	t.Cleanup(func() {
+		debugMu.Lock()
+		if w, ok := debugWriter.(*rotatingWriter); ok {
+			w.Close()
+		}
+		debugWriter = nil
+		debugMu.Unlock()
+		log.SetOutput(os.Stderr)
+	})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				Logf("line")
+			}
+		}()
+	}
+	if err := Enable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+}
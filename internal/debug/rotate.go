@@ -0,0 +1,166 @@
+package debug
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	logBaseName = "alayacore-debug-api"
+
+	// DefaultMaxLogSize is the size after which a new log file is started.
+	DefaultMaxLogSize = 20 * 1024 * 1024
+
+	// DefaultMaxLogFiles is the number of log files kept in the log directory.
+	DefaultMaxLogFiles = 10
+)
+
+// DefaultLogDir returns the default debug log directory:
+// $XDG_STATE_HOME/alayacore if set, otherwise ~/.alayacore/logs.
+func DefaultLogDir() string {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "alayacore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".alayacore", "logs")
+	}
+	return filepath.Join(home, ".alayacore", "logs")
+}
+
+// rotatingWriter writes numbered log files (alayacore-debug-api-N.log) into a
+// directory, starting a new file once maxSize bytes have been written and
+// deleting the oldest files beyond maxFiles.
+type rotatingWriter struct {
+	mu       sync.Mutex
+	dir      string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	next     int // number for the next log file
+}
+
+// newRotatingWriter creates the directory if needed and opens the first log file.
+func newRotatingWriter(dir string, maxSize int64, maxFiles int) (*rotatingWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create debug log directory: %w", err)
+	}
+
+	w := &rotatingWriter{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	existing, err := w.logNumbers()
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		w.next = existing[len(existing)-1] + 1
+	}
+
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the current file, rotating first if it would exceed maxSize.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file.
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate closes the current file, opens the next one, and prunes old files.
+// Caller must hold w.mu (or be the constructor).
+func (w *rotatingWriter) rotate() error {
+	if w.file != nil {
+		_ = w.file.Close() //nolint:errcheck // best-effort close before rotating
+	}
+
+	name := fmt.Sprintf("%s-%d.log", logBaseName, w.next)
+	f, err := os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create debug log file: %w", err)
+	}
+	w.next++
+	w.file = f
+	w.size = 0
+
+	// The start header is not counted, so every file holds at least one write
+	_, _ = fmt.Fprintf(f, "Debug log started: %s\n", name) //nolint:errcheck // best-effort header
+
+	return w.prune()
+}
+
+// prune deletes the oldest log files beyond maxFiles.
+func (w *rotatingWriter) prune() error {
+	if w.maxFiles <= 0 {
+		return nil
+	}
+	numbers, err := w.logNumbers()
+	if err != nil {
+		return err
+	}
+	for len(numbers) > w.maxFiles {
+		name := fmt.Sprintf("%s-%d.log", logBaseName, numbers[0])
+		if err := os.Remove(filepath.Join(w.dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old debug log: %w", err)
+		}
+		numbers = numbers[1:]
+	}
+	return nil
+}
+
+// logNumbers returns the numbers of existing log files in ascending order.
+func (w *rotatingWriter) logNumbers() ([]int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug log directory: %w", err)
+	}
+	var numbers []int
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), logBaseName+"-")
+		if !ok {
+			continue
+		}
+		rest, ok = strings.CutSuffix(rest, ".log")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(rest); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	slices.Sort(numbers)
+	return numbers, nil
+}
@@ -0,0 +1,113 @@
+package debug
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriterCreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "logs")
+
+	w, err := newRotatingWriter(dir, 1024, 10)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "alayacore-debug-api-0.log")); err != nil {
+		t.Errorf("expected first log file to exist: %v", err)
+	}
+}
+
+func TestRotatingWriterRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newRotatingWriter(dir, 64, 10)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	line := strings.Repeat("x", 40) + "\n"
+	for range 3 {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	numbers, err := w.logNumbers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 {
+		t.Fatalf("expected 3 log files after rotation, got %v", numbers)
+	}
+
+	for _, n := range numbers {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("alayacore-debug-api-%d.log", n)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(data), line); got != 1 {
+			t.Errorf("log %d holds %d writes, want 1", n, got)
+		}
+	}
+}
+
+func TestRotatingWriterRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newRotatingWriter(dir, 32, 3)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	for range 10 {
+		if _, err := w.Write([]byte(strings.Repeat("y", 30))); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	numbers, err := w.logNumbers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 {
+		t.Fatalf("expected 3 retained log files, got %v", numbers)
+	}
+	if numbers[0] != 7 || numbers[2] != 9 {
+		t.Errorf("expected newest logs 7..9 to be kept, got %v", numbers)
+	}
+}
+
+func TestRotatingWriterContinuesNumbering(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "alayacore-debug-api-4.log"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newRotatingWriter(dir, 1024, 10)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "alayacore-debug-api-5.log")); err != nil {
+		t.Errorf("expected numbering to continue after existing logs: %v", err)
+	}
+}
+
+func TestRotatingWriterUnwritableDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newRotatingWriter(filepath.Join(file, "logs"), 1024, 10); err == nil {
+		t.Error("expected error when log directory cannot be created")
+	}
+}
@@ -0,0 +1,186 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HunkError reports a hunk that could not be applied.
+type HunkError struct {
+	Index  int // 1-based hunk number within the file
+	Hunk   *Hunk
+	Line   int    // 1-based line of the first mismatch at the hunk's expected position
+	Want   string // expected line from the hunk
+	Got    string // line found in the file
+	Reason string // set instead of Line/Want/Got for other failures
+}
+
+func (e *HunkError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("hunk %d (%s) failed: %s", e.Index, e.Hunk.Header(), e.Reason)
+	}
+	return fmt.Sprintf("hunk %d (%s) failed: context mismatch at line %d: expected %q, found %q",
+		e.Index, e.Hunk.Header(), e.Line, e.Want, e.Got)
+}
+
+// Apply applies the file's hunks to content and returns the result. Line
+// endings follow the original content: a CRLF file stays CRLF even when the
+// diff uses LF.
+func Apply(content string, f *File) (string, error) {
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
+	}
+	lines, finalNewline := splitLines(content)
+
+	var out []string
+	pos := 0    // next unconsumed line of the original
+	offset := 0 // drift between header line numbers and where hunks matched
+	for i, h := range f.Hunks {
+		old := h.old()
+		expected := h.OldStart - 1 + offset
+		if h.OldLines == 0 {
+			// Pure insertions name the line they follow
+			expected = h.OldStart + offset
+		}
+		expected = max(expected, pos)
+
+		at, trimFront, trimBack, ok := locate(lines, old, h, expected, pos)
+		if !ok {
+			return "", mismatch(i+1, h, lines, old, expected)
+		}
+
+		// Fuzzed context lines are kept from the file, not from the diff
+		newLines := h.new()
+		newLines = newLines[trimFront : len(newLines)-trimBack]
+		out = append(out, lines[pos:at]...)
+		out = append(out, lines[at:at+trimFront]...)
+		out = append(out, newLines...)
+		end := at + len(old)
+		out = append(out, lines[end-trimBack:end]...)
+		pos = end
+		offset = at - (h.OldStart - 1)
+		if h.OldLines == 0 {
+			offset = at - h.OldStart
+		}
+
+		if end == len(lines) {
+			if h.NoNewlineNew {
+				finalNewline = false
+			} else if h.NoNewlineOld || (len(lines) == 0 && len(newLines) > 0) {
+				finalNewline = true
+			}
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, eol)
+	if finalNewline {
+		result += eol
+	}
+	return result, nil
+}
+
+// splitLines splits content into lines without their endings and reports
+// whether the last line was terminated.
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return nil, false
+	}
+	finalNewline := strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines, finalNewline
+}
+
+// locate finds where old occurs in lines at or after from, nearest to
+// expected. With fuzz it may ignore up to MaxFuzz context lines at either
+// end of the hunk; trimFront and trimBack report how many were ignored.
+func locate(lines, old []string, h *Hunk, expected, from int) (at, trimFront, trimBack int, ok bool) {
+	leading, trailing := outerContext(h)
+	for fuzz := 0; fuzz <= MaxFuzz; fuzz++ {
+		front, back := min(fuzz, leading), min(fuzz, trailing)
+		if fuzz > 0 && front+back == 0 {
+			break
+		}
+		core := old[front : len(old)-back]
+		if len(core) == 0 && len(old) > 0 {
+			break
+		}
+		if at, ok := search(lines, core, expected+front, from+front); ok {
+			return at - front, front, back, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// outerContext counts the context lines before the first and after the last
+// change in a hunk.
+func outerContext(h *Hunk) (leading, trailing int) {
+	for _, l := range h.Lines {
+		if l.Op != ' ' {
+			break
+		}
+		leading++
+	}
+	for i := len(h.Lines) - 1; i >= 0 && h.Lines[i].Op == ' '; i-- {
+		trailing++
+	}
+	if leading == len(h.Lines) {
+		trailing = 0
+	}
+	return leading, trailing
+}
+
+// search returns the position of block in lines at or after from, preferring
+// the one closest to expected.
+func search(lines, block []string, expected, from int) (int, bool) {
+	last := len(lines) - len(block)
+	if last < from {
+		return 0, false
+	}
+	expected = max(from, expected)
+	for d := 0; expected-d >= from || expected+d <= last; d++ {
+		if at := expected - d; at >= from && at <= last && matches(lines[at:], block) {
+			return at, true
+		}
+		if at := expected + d; d > 0 && at <= last && matches(lines[at:], block) {
+			return at, true
+		}
+	}
+	return 0, false
+}
+
+func matches(lines, block []string) bool {
+	for i, l := range block {
+		if lines[i] != l {
+			return false
+		}
+	}
+	return true
+}
+
+// mismatch explains why a hunk did not apply at its expected position.
+func mismatch(index int, h *Hunk, lines, old []string, expected int) *HunkError {
+	if expected > len(lines) {
+		return &HunkError{Index: index, Hunk: h,
+			Reason: fmt.Sprintf("starts at line %d but the file has only %d lines", expected+1, len(lines))}
+	}
+	for i, want := range old {
+		if expected+i >= len(lines) {
+			return &HunkError{Index: index, Hunk: h,
+				Reason: fmt.Sprintf("expected %q at line %d, found end of file", want, expected+i+1)}
+		}
+		if got := lines[expected+i]; got != want {
+			return &HunkError{Index: index, Hunk: h, Line: expected + i + 1, Want: want, Got: got}
+		}
+	}
+	// The block matches where the header says but overlaps an earlier hunk
+	return &HunkError{Index: index, Hunk: h, Reason: "overlaps the previous hunk"}
+}
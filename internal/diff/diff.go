@@ -0,0 +1,240 @@
+// Package diff parses unified diffs and applies them to file content without
+// relying on an external patch binary.
+//
+// Hunks are located by their context and removed lines, starting at the line
+// number in the hunk header and searching outward, so diffs still apply after
+// the file has drifted. When the exact block cannot be found, up to MaxFuzz
+// leading and trailing context lines are ignored, like patch's fuzz factor.
+// A hunk that still does not match yields a *HunkError describing where and
+// why, carrying the hunk text so it can be regenerated.
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxFuzz is the number of outer context lines a hunk may drop to match.
+const MaxFuzz = 2
+
+// DevNull is the file name unified diffs use for a missing side.
+const DevNull = "/dev/null"
+
+// Line is one body line of a hunk.
+type Line struct {
+	Op   byte // ' ' context, '-' removed, '+' added
+	Text string
+}
+
+// Hunk is a single @@ section of a unified diff.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+
+	// NoNewlineOld and NoNewlineNew record "\ No newline at end of file"
+	// markers after the last old or new line of the hunk.
+	NoNewlineOld, NoNewlineNew bool
+}
+
+// Header returns the hunk's @@ line.
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// String returns the hunk as diff text.
+func (h *Hunk) String() string {
+	var sb strings.Builder
+	sb.WriteString(h.Header())
+	for _, l := range h.Lines {
+		sb.WriteByte('\n')
+		sb.WriteByte(l.Op)
+		sb.WriteString(l.Text)
+	}
+	return sb.String()
+}
+
+// old returns the lines the hunk expects in the original file.
+func (h *Hunk) old() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l.Op != '+' {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// new returns the lines the hunk produces.
+func (h *Hunk) new() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l.Op != '-' {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// File is the diff of a single file.
+type File struct {
+	OldName, NewName string // without a/ and b/ prefixes; DevNull when absent
+	Hunks            []*Hunk
+}
+
+// IsNew reports whether the diff creates the file.
+func (f *File) IsNew() bool {
+	return f.OldName == DevNull || (f.OldName == "" && len(f.Hunks) == 1 && f.Hunks[0].OldLines == 0 && f.Hunks[0].OldStart == 0)
+}
+
+// IsDelete reports whether the diff deletes the file.
+func (f *File) IsDelete() bool {
+	return f.NewName == DevNull
+}
+
+// Parse parses the unified diff text into its files. Text before the first
+// ---/+++ or @@ line (commit messages, "diff --git" lines) is ignored. A diff
+// made only of hunks yields a single File without names.
+func Parse(text string) ([]*File, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var files []*File
+	var cur *File
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			cur = &File{OldName: fileName(line[4:]), NewName: fileName(lines[i+1][4:])}
+			files = append(files, cur)
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				cur = &File{}
+				files = append(files, cur)
+			}
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.Hunks = append(cur.Hunks, h)
+			i = next - 1
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no hunks found: expected a unified diff with @@ -l,s +l,s @@ headers")
+	}
+	for _, f := range files {
+		if len(f.Hunks) == 0 {
+			return nil, fmt.Errorf("diff for %s has no hunks", f.NewName)
+		}
+	}
+	return files, nil
+}
+
+// fileName strips the timestamp and a/ or b/ prefix from a ---/+++ name.
+func fileName(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == DevNull {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// parseHunk parses the hunk whose header is lines[start] and returns it with
+// the index of the first line after it.
+func parseHunk(lines []string, start int) (*Hunk, int, error) {
+	h := &Hunk{}
+	if err := parseHeader(lines[start], h); err != nil {
+		return nil, 0, fmt.Errorf("line %d: %w", start+1, err)
+	}
+
+	// Counts are optional in headers produced by hand ("@@ -3 +3 @@") and are
+	// often wrong in model-written diffs, so the body runs until the next
+	// header or a line that cannot belong to a hunk.
+	oldSeen, newSeen := 0, 0
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			// Editors and models often strip the space from empty context lines
+			if i+1 < len(lines) && isHunkBody(lines[i+1]) || oldSeen < h.OldLines {
+				h.Lines = append(h.Lines, Line{Op: ' '})
+				oldSeen++
+				newSeen++
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, `\`) {
+			if len(h.Lines) > 0 && h.Lines[len(h.Lines)-1].Op == '+' {
+				h.NoNewlineNew = true
+			} else if len(h.Lines) > 0 && h.Lines[len(h.Lines)-1].Op == '-' {
+				h.NoNewlineOld = true
+			} else {
+				h.NoNewlineOld, h.NoNewlineNew = true, true
+			}
+			continue
+		}
+		if !isHunkBody(line) || (strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			break
+		}
+		h.Lines = append(h.Lines, Line{Op: line[0], Text: line[1:]})
+		if line[0] != '+' {
+			oldSeen++
+		}
+		if line[0] != '-' {
+			newSeen++
+		}
+	}
+
+	if len(h.Lines) == 0 {
+		return nil, 0, fmt.Errorf("line %d: hunk %s has no lines", start+1, h.Header())
+	}
+	h.OldLines, h.NewLines = oldSeen, newSeen
+	return h, i, nil
+}
+
+func isHunkBody(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '-' || line[0] == '+')
+}
+
+// parseHeader parses "@@ -l[,s] +l[,s] @@ optional section".
+func parseHeader(line string, h *Hunk) error {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return fmt.Errorf("malformed hunk header %q", line)
+	}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(fields[1][1:]); err != nil {
+		return fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(fields[2][1:]); err != nil {
+		return fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	return nil
+}
+
+func parseRange(s string) (start, count int, err error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startStr); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
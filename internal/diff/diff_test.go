@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		diff     string
+		want     string
+	}{
+		{
+			name:     "add lines",
+			original: "a\nb\nc\n",
+			diff:     "@@ -1,3 +1,5 @@\n a\n b\n+b1\n+b2\n c\n",
+			want:     "a\nb\nb1\nb2\nc\n",
+		},
+		{
+			name:     "delete lines",
+			original: "a\nb\nc\nd\n",
+			diff:     "@@ -1,4 +1,2 @@\n a\n-b\n-c\n d\n",
+			want:     "a\nd\n",
+		},
+		{
+			name:     "modify line",
+			original: "package main\n\nfunc old() {}\n",
+			diff:     "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n package main\n \n-func old() {}\n+func renamed() {}\n",
+			want:     "package main\n\nfunc renamed() {}\n",
+		},
+		{
+			name:     "multiple hunks",
+			original: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			diff:     "@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n@@ -8,3 +8,3 @@\n 8\n-9\n+nine\n 10\n",
+			want:     "1\ntwo\n3\n4\n5\n6\n7\n8\nnine\n10\n",
+		},
+		{
+			name:     "crlf file keeps crlf",
+			original: "a\r\nb\r\nc\r\n",
+			diff:     "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:     "a\r\nB\r\nc\r\n",
+		},
+		{
+			name:     "crlf diff on lf file",
+			original: "a\nb\nc\n",
+			diff:     "@@ -1,3 +1,3 @@\r\n a\r\n-b\r\n+B\r\n c\r\n",
+			want:     "a\nB\nc\n",
+		},
+		{
+			name:     "new file",
+			original: "",
+			diff:     "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n",
+			want:     "hello\nworld\n",
+		},
+		{
+			name:     "context drifted by inserted lines",
+			original: "x\ny\nz\na\nb\nc\n",
+			diff:     "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:     "x\ny\nz\na\nB\nc\n",
+		},
+		{
+			name:     "fuzz ignores changed outer context",
+			original: "header changed\nkeep\nold\nkeep2\nfooter changed\n",
+			diff:     "@@ -1,5 +1,5 @@\n header\n keep\n-old\n+new\n keep2\n footer\n",
+			want:     "header changed\nkeep\nnew\nkeep2\nfooter changed\n",
+		},
+		{
+			name:     "no newline at end of file",
+			original: "a\nb",
+			diff:     "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n",
+			want:     "a\nc\n",
+		},
+		{
+			name:     "remove final newline",
+			original: "a\nb\n",
+			diff:     "@@ -1,2 +1,2 @@\n a\n-b\n+c\n\\ No newline at end of file\n",
+			want:     "a\nc",
+		},
+		{
+			name:     "blank context line without leading space",
+			original: "a\n\nb\n",
+			diff:     "@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n",
+			want:     "a\n\nc\n",
+		},
+		{
+			name:     "header without counts",
+			original: "a\nb\n",
+			diff:     "@@ -2 +2 @@\n-b\n+c\n",
+			want:     "a\nc\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Parse(tt.diff)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("Parse returned %d files, want 1", len(files))
+			}
+			got, err := Apply(tt.original, files[0])
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyReportsFailingHunk(t *testing.T) {
+	files, err := Parse("@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -5,3 +5,3 @@\n e\n-f\n+F\n g\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Apply("a\nb\nc\nd\ne\nX\ng\n", files[0])
+
+	var hunkErr *HunkError
+	if !errors.As(err, &hunkErr) {
+		t.Fatalf("expected *HunkError, got %v", err)
+	}
+	if hunkErr.Index != 2 || hunkErr.Line != 6 || hunkErr.Want != "f" || hunkErr.Got != "X" {
+		t.Errorf("unexpected hunk error: %+v", hunkErr)
+	}
+	if !strings.Contains(err.Error(), "context mismatch at line 6") {
+		t.Errorf("error should name the mismatching line: %v", err)
+	}
+	if !strings.Contains(hunkErr.Hunk.String(), "-f\n+F") {
+		t.Errorf("hunk text not preserved: %q", hunkErr.Hunk.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	files, err := Parse(`diff --git a/one.go b/one.go
+index 123..456 100644
+--- a/one.go
++++ b/one.go
+@@ -1 +1 @@
+-x
++y
+diff --git a/two.go b/two.go
+--- a/two.go	2024-01-01 00:00:00
++++ /dev/null
+@@ -1,1 +0,0 @@
+-gone
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	if files[0].OldName != "one.go" || files[0].NewName != "one.go" || len(files[0].Hunks) != 1 {
+		t.Errorf("unexpected first file: %+v", files[0])
+	}
+	if files[1].OldName != "two.go" || !files[1].IsDelete() {
+		t.Errorf("second file should be a deletion of two.go: %+v", files[1])
+	}
+
+	for _, bad := range []string{"", "just some text\n", "@@ -x +1 @@\n+a\n", "--- a/f\n+++ b/f\n"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
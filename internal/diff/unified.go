@@ -0,0 +1,213 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines Unified shows around changes.
+const Context = 3
+
+// maxTrace bounds the memory of the line matching. Beyond it, changes are
+// shown as a removal of every old line and an addition of every new one.
+const maxTrace = 1 << 22
+
+// Unified returns the unified diff that turns oldText into newText, with
+// --- a/name and +++ b/name headers, or "" when the texts are equal. An empty
+// oldText or newText is shown as a file created or deleted: its name becomes
+// /dev/null. Line endings are compared without their \r.
+func Unified(name, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	oldLines, oldNewline := splitLines(oldText)
+	newLines, newNewline := splitLines(newText)
+	ops := splitNewlineChanges(editScript(oldLines, newLines), len(oldLines)-1, len(newLines)-1, oldNewline, newNewline)
+
+	oldName, newName := "a/"+name, "b/"+name
+	if oldText == "" {
+		oldName = DevNull
+	}
+	if newText == "" {
+		newName = DevNull
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, h, oldLines, newLines, oldNewline, newNewline)
+	}
+	return sb.String()
+}
+
+// edit is one step of an edit script: a line kept (' '), removed ('-'), or
+// added ('+'). old and new index the line in the old and new text.
+type edit struct {
+	op       byte
+	old, new int
+}
+
+// editScript returns the shortest edit script from a to b, using Myers'
+// O(ND) algorithm.
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		if (d+1)*(2*d+3) > maxTrace {
+			return replaceAll(n, m)
+		}
+		// Only diagonals -d-1..d+1 are read when backtracking from step d
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && trimCR(a[x]) == trimCR(b[y]) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	var ops []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		saved := trace[d]
+		at := func(k int) int { return saved[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, edit{' ', x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, edit{'+', x, prevY})
+			} else {
+				ops = append(ops, edit{'-', prevX, y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// splitNewlineChanges turns a kept line that ends the file without a newline
+// on one side only into a removal and an addition, which is how unified
+// diffs show the change. lastOld and lastNew index the final lines.
+func splitNewlineChanges(ops []edit, lastOld, lastNew int, oldNewline, newNewline bool) []edit {
+	out := ops[:0:0]
+	for _, e := range ops {
+		endsOld := e.old == lastOld && !oldNewline
+		endsNew := e.new == lastNew && !newNewline
+		if e.op == ' ' && endsOld != endsNew {
+			out = append(out, edit{'-', e.old, e.new}, edit{'+', e.old + 1, e.new})
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// replaceAll is the edit script that removes all n old lines and adds all m
+// new ones.
+func replaceAll(n, m int) []edit {
+	ops := make([]edit, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, edit{'-', i, 0})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, edit{'+', n, j})
+	}
+	return ops
+}
+
+func trimCR(s string) string {
+	return strings.TrimSuffix(s, "\r")
+}
+
+// hunks groups an edit script into runs of changes with up to Context kept
+// lines on each side. Changes closer than 2*Context lines share a hunk.
+func hunks(ops []edit) [][]edit {
+	var groups [][]edit
+	start, end := -1, -1 // bounds of the current group in ops
+	for i, e := range ops {
+		if e.op == ' ' {
+			continue
+		}
+		lo, hi := max(0, i-Context), min(len(ops), i+Context+1)
+		if start >= 0 && lo <= end {
+			end = max(end, hi)
+			continue
+		}
+		if start >= 0 {
+			groups = append(groups, ops[start:end])
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		groups = append(groups, ops[start:end])
+	}
+	return groups
+}
+
+// writeHunk writes one hunk, marking a last line without a newline.
+func writeHunk(sb *strings.Builder, h []edit, a, b []string, aNewline, bNewline bool) {
+	oldStart, newStart := h[0].old+1, h[0].new+1
+	oldCount, newCount := 0, 0
+	for _, e := range h {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	// Empty ranges name the line before them
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, e := range h {
+		var text string
+		lastOld := e.op != '+' && e.old == len(a)-1 && !aNewline
+		lastNew := e.op != '-' && e.new == len(b)-1 && !bNewline
+		switch e.op {
+		case '+':
+			text = b[e.new]
+		default:
+			text = a[e.old]
+		}
+		fmt.Fprintf(sb, "%c%s\n", e.op, trimCR(text))
+		if lastOld || lastNew {
+			sb.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	got := Unified("main.go", "package main\n\nfunc old() {}\n", "package main\n\nfunc renamed() {}\n")
+	want := "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n package main\n \n-func old() {}\n+func renamed() {}\n"
+	if got != want {
+		t.Errorf("Unified =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Unified("x", "same\n", "same\n"); got != "" {
+		t.Errorf("equal texts gave %q", got)
+	}
+
+	got = Unified("new.txt", "", "hello\n")
+	if want := "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,1 @@\n+hello\n"; got != want {
+		t.Errorf("created file =\n%s\nwant\n%s", got, want)
+	}
+
+	got = Unified("x", "a\nb", "a\nb\n")
+	if want := "--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"; got != want {
+		t.Errorf("added final newline =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedSplitsDistantChanges(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, string(rune('a'+i-1)))
+	}
+	oldText := strings.Join(lines, "\n") + "\n"
+	lines[1], lines[17] = "B", "R"
+	got := Unified("x", oldText, strings.Join(lines, "\n")+"\n")
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("got %d hunks, want 2:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n") {
+		t.Errorf("first hunk has the wrong context:\n%s", got)
+	}
+}
+
+// TestUnifiedRoundTrip checks that applying the diff of random edits
+// reproduces the new text.
+func TestUnifiedRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d", "", "func", "}"}
+	random := func() string {
+		var sb strings.Builder
+		for i := rng.Intn(30); i > 0; i-- {
+			sb.WriteString(words[rng.Intn(len(words))])
+			sb.WriteByte('\n')
+		}
+		s := sb.String()
+		if rng.Intn(4) == 0 {
+			s = strings.TrimSuffix(s, "\n")
+		}
+		return s
+	}
+	for i := 0; i < 500; i++ {
+		oldText, newText := random(), random()
+		text := Unified("f", oldText, newText)
+		if oldText == newText {
+			continue
+		}
+		files, err := Parse(text)
+		if err != nil {
+			t.Fatalf("case %d: %v\n%s", i, err, text)
+		}
+		got, err := Apply(oldText, files[0])
+		if err != nil {
+			t.Fatalf("case %d: %v\n%s", i, err, text)
+		}
+		if got != newText {
+			t.Fatalf("case %d: applied to %q gives %q, want %q\n%s", i, oldText, got, newText, text)
+		}
+	}
+}
+
+func TestUnifiedLargeRewriteFallsBack(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 5000; i++ {
+		a.WriteString("old line\n")
+		b.WriteString("new line\n")
+	}
+	got := Unified("x", a.String(), b.String())
+	if !strings.HasPrefix(got, "--- a/x\n+++ b/x\n@@ -1,5000 +1,5000 @@\n") {
+		t.Errorf("unexpected header: %.60q", got)
+	}
+}
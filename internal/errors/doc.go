@@ -32,6 +32,13 @@
 // Input errors:
 //   - ErrInvalidInputTag: Invalid TLV tag
 //
+// Exit codes:
+//
+//   - RunError: A failure with a class (ClassError, ClassConfig,
+//     ClassCanceled) that decides the exit code of a run
+//   - ExitCode(err): ExitOK, ExitError, ExitConfig, or ExitCanceled
+//   - Summary(err): The one-line "error[<class>]: <message>" for stderr
+//
 // Helper Functions:
 //
 //   - NewSessionError(op, err): Create new error with operation
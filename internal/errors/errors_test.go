@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -114,3 +115,30 @@ func TestDomainErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("read failed"), ExitError},
+		{NewRunError(ClassError, errors.New("401 unauthorized")), ExitError},
+		{ConfigError(errors.New("bad flag")), ExitConfig},
+		{fmt.Errorf("run: %w", NewRunError(ClassCanceled, errors.New("canceled"))), ExitCanceled},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got, want := Summary(ConfigError(errors.New("model.conf:\n  no models"))), "error[config]: model.conf: no models"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if got, want := Summary(errors.New("broken pipe")), "error[error]: broken pipe"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
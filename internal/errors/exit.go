@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Exit Codes
+// ============================================================================
+
+// Exit codes of the binaries. A scripted run (alayacore-web --stdio) ends
+// with the code of the first prompt that failed.
+const (
+	ExitOK       = 0 // every prompt was answered
+	ExitError    = 1 // the provider or the agent failed
+	ExitConfig   = 2 // bad flags, config files, or model setup
+	ExitCanceled = 3 // a prompt was canceled
+)
+
+// Failure classes, as printed in the error summary.
+const (
+	ClassError    = "error"
+	ClassConfig   = "config"
+	ClassCanceled = "canceled"
+)
+
+var exitCodes = map[string]int{
+	ClassError:    ExitError,
+	ClassConfig:   ExitConfig,
+	ClassCanceled: ExitCanceled,
+}
+
+// RunError is a failure that decides the exit code of a run.
+type RunError struct {
+	Class string // ClassError, ClassConfig, or ClassCanceled
+	Err   error  // The underlying error
+}
+
+// Error implements the error interface.
+func (e *RunError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error for use with errors.Is and errors.As.
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// NewRunError creates a RunError of the given class.
+func NewRunError(class string, err error) *RunError {
+	return &RunError{Class: class, Err: err}
+}
+
+// ConfigError marks err as a configuration error.
+func ConfigError(err error) *RunError {
+	return &RunError{Class: ClassConfig, Err: err}
+}
+
+// ExitCode returns the exit code for err: ExitOK for nil, the code of its
+// class for a RunError, and ExitError otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		if code, ok := exitCodes[runErr.Class]; ok {
+			return code
+		}
+	}
+	return ExitError
+}
+
+// Summary renders err as one line for stderr, "error[<class>]: <message>",
+// so scripts can grep for it.
+func Summary(err error) string {
+	class := ClassError
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		class = runErr.Class
+	}
+	message := strings.Join(strings.Fields(err.Error()), " ")
+	return fmt.Sprintf("error[%s]: %s", class, message)
+}
@@ -0,0 +1,229 @@
+// Package hooks runs user-configured shell commands at agent lifecycle
+// events: before and after each tool call, when a prompt starts, and when a
+// turn ends.
+//
+// Hooks are declared in a key: value file (a YAML subset) given by --hooks:
+//
+//	# Give each hook at most 10 seconds (default 30s)
+//	timeout: 10s
+//	on_prompt_start: git stash --include-untracked
+//	pre_tool:posix_shell: ! printf '%s' "$ALAYACORE_INPUT_JSON" | grep -q 'rm -rf'
+//	post_tool:write_file: gofmt -w .
+//	post_tool: echo "$ALAYACORE_TOOL" >> tool-calls.log
+//	on_turn_end: notify-send "AlayaCore" "$ALAYACORE_STATUS"
+//
+// pre_tool and post_tool without a tool name match every tool. An event may
+// be listed several times; its hooks run in file order. Each command runs with
+// the configured shell and receives ALAYACORE_EVENT, ALAYACORE_TOOL,
+// ALAYACORE_INPUT_JSON, ALAYACORE_PROMPT, and ALAYACORE_STATUS as applicable.
+// Hook output goes to the debug log only (--debug-api); failures are also
+// logged at warn level.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/debug"
+	"github.com/alayacore/alayacore/internal/logging"
+	"github.com/alayacore/alayacore/internal/proc"
+)
+
+// DefaultTimeout bounds each hook command unless the file sets timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Event names a lifecycle point hooks can attach to.
+type Event string
+
+const (
+	// PreTool runs before a tool executes; a non-zero exit aborts the call
+	// and its stderr becomes the tool's error result.
+	PreTool Event = "pre_tool"
+	// PostTool runs after a tool executes.
+	PostTool Event = "post_tool"
+	// PromptStart runs before a user prompt is sent to the model.
+	PromptStart Event = "on_prompt_start"
+	// TurnEnd runs after the model finishes responding to a prompt.
+	TurnEnd Event = "on_turn_end"
+)
+
+// Hook is a single configured command.
+type Hook struct {
+	Event   Event
+	Tool    string // tool filter for PreTool/PostTool; "" matches every tool
+	Command string
+}
+
+// Env carries the event details exposed to hook commands.
+type Env struct {
+	Tool   string          // ALAYACORE_TOOL
+	Input  json.RawMessage // ALAYACORE_INPUT_JSON
+	Prompt string          // ALAYACORE_PROMPT
+	Status string          // ALAYACORE_STATUS: done, canceled, or error
+}
+
+// Hooks holds the parsed hook file. A nil *Hooks runs nothing.
+type Hooks struct {
+	Hooks   []Hook
+	Timeout time.Duration
+	Shell   string       // shell used as "<shell> -c <command>"
+	Logger  *slog.Logger // where runs and failures are logged; nil drops them
+}
+
+// Load reads and parses a hook file. An empty path returns nil hooks.
+func Load(path, shell string) (*Hooks, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hooks file: %w", err)
+	}
+	h, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	h.Shell = shell
+	return h, nil
+}
+
+// Parse parses hook file content. Unknown events are errors so typos do not
+// silently disable a hook.
+func Parse(content string) (*Hooks, error) {
+	h := &Hooks{Timeout: DefaultTimeout, Shell: "/bin/sh"}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected \"event: command\"", i+1)
+		}
+
+		var tool string
+		event := Event(strings.TrimSpace(key))
+		if (event == PreTool || event == PostTool) && value != "" && value[0] != ' ' && value[0] != '\t' {
+			tool, value, _ = strings.Cut(value, ":")
+		}
+		value = unquote(strings.TrimSpace(value))
+
+		switch event {
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("line %d: invalid timeout %q", i+1, value)
+			}
+			h.Timeout = d
+			continue
+		case PreTool, PostTool, PromptStart, TurnEnd:
+		default:
+			return nil, fmt.Errorf("line %d: unknown hook event %q", i+1, event)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("line %d: %s has no command", i+1, event)
+		}
+		h.Hooks = append(h.Hooks, Hook{Event: event, Tool: strings.TrimSpace(tool), Command: value})
+	}
+	return h, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') ||
+			(value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// Has reports whether any hook is configured for the event and tool.
+func (h *Hooks) Has(event Event, tool string) bool {
+	return len(h.matching(event, tool)) > 0
+}
+
+func (h *Hooks) matching(event Event, tool string) []Hook {
+	if h == nil {
+		return nil
+	}
+	var matched []Hook
+	for _, hook := range h.Hooks {
+		if hook.Event == event && (hook.Tool == "" || hook.Tool == tool) {
+			matched = append(matched, hook)
+		}
+	}
+	return matched
+}
+
+// Run executes the hooks for an event in order. It stops at the first
+// failing hook and returns an error carrying its stderr; callers decide
+// whether that failure matters (it aborts the call only for PreTool).
+func (h *Hooks) Run(ctx context.Context, event Event, env Env) error {
+	for _, hook := range h.matching(event, env.Tool) {
+		if err := h.run(ctx, hook, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Hooks) run(ctx context.Context, hook Hook, env Env) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	//nolint:gosec // G204: hook commands come from the user's own hook file
+	cmd := exec.CommandContext(ctx, h.Shell, "-c", hook.Command)
+	cmd.Env = append(os.Environ(),
+		"ALAYACORE_EVENT="+string(hook.Event),
+		"ALAYACORE_TOOL="+env.Tool,
+		"ALAYACORE_INPUT_JSON="+string(env.Input),
+		"ALAYACORE_PROMPT="+env.Prompt,
+		"ALAYACORE_STATUS="+env.Status,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Kill the whole process group on timeout so background children do not
+	// keep the pipes open
+	proc.SetGroup(cmd)
+	cmd.Cancel = func() error {
+		return proc.Kill(cmd.Process)
+	}
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	debug.Logf("hook %s %q exited after %s (err: %v)\nstdout: %s\nstderr: %s",
+		hook.Event, hook.Command, elapsed, err, stdout.String(), stderr.String())
+	logger := logging.OrDiscard(h.Logger)
+	if err == nil {
+		logger.Debug("hook ran", "event", hook.Event, "command", hook.Command, "elapsed", elapsed)
+		return nil
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%s hook %q timed out after %s", hook.Event, hook.Command, timeout)
+	} else {
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		err = fmt.Errorf("%s hook %q failed: %s", hook.Event, hook.Command, detail)
+	}
+	logger.Warn("hook failed", "event", hook.Event, "tool", env.Tool, "elapsed", elapsed, "err", err)
+	return err
+}
@@ -0,0 +1,123 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	h, err := Parse(`
+# comment
+timeout: 5s
+pre_tool:write_file: git stash
+post_tool: echo "any tool: $ALAYACORE_TOOL"
+on_prompt_start: 'echo start'
+on_turn_end: echo end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %s, want 5s", h.Timeout)
+	}
+	want := []Hook{
+		{Event: PreTool, Tool: "write_file", Command: "git stash"},
+		{Event: PostTool, Command: `echo "any tool: $ALAYACORE_TOOL"`},
+		{Event: PromptStart, Command: "echo start"},
+		{Event: TurnEnd, Command: "echo end"},
+	}
+	if len(h.Hooks) != len(want) {
+		t.Fatalf("got %d hooks, want %d: %+v", len(h.Hooks), len(want), h.Hooks)
+	}
+	for i := range want {
+		if h.Hooks[i] != want[i] {
+			t.Errorf("hook %d = %+v, want %+v", i, h.Hooks[i], want[i])
+		}
+	}
+
+	if !h.Has(PreTool, "write_file") || h.Has(PreTool, "read_file") || !h.Has(PostTool, "read_file") {
+		t.Error("Has does not honor tool filters")
+	}
+}
+
+func TestParseRejectsInvalidLines(t *testing.T) {
+	for _, content := range []string{
+		"pre_tol:write_file: x",
+		"on_turn_end:",
+		"timeout: soon",
+		"just a command",
+	} {
+		if _, err := Parse(content); err == nil {
+			t.Errorf("Parse(%q) should fail", content)
+		}
+	}
+}
+
+func TestLoadEmptyPath(t *testing.T) {
+	h, err := Load("", "/bin/sh")
+	if err != nil || h != nil {
+		t.Fatalf("Load(\"\") = %v, %v; want nil, nil", h, err)
+	}
+	// nil hooks are safe to use
+	if err := h.Run(context.Background(), PreTool, Env{Tool: "write_file"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunExposesEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env.txt")
+	h := &Hooks{Shell: "/bin/sh", Hooks: []Hook{{
+		Event:   PreTool,
+		Command: `printf '%s|%s|%s' "$ALAYACORE_EVENT" "$ALAYACORE_TOOL" "$ALAYACORE_INPUT_JSON" > ` + out,
+	}}}
+
+	input := json.RawMessage(`{"path":"a.go"}`)
+	if err := h.Run(context.Background(), PreTool, Env{Tool: "write_file", Input: input}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `pre_tool|write_file|{"path":"a.go"}`; got != want {
+		t.Errorf("hook saw %q, want %q", got, want)
+	}
+}
+
+func TestRunStopsAtFailingHook(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "second-ran")
+	h := &Hooks{Shell: "/bin/sh", Hooks: []Hook{
+		{Event: PreTool, Command: "echo 'working tree is dirty' >&2; exit 3"},
+		{Event: PreTool, Command: "touch " + marker},
+	}}
+
+	err := h.Run(context.Background(), PreTool, Env{Tool: "write_file"})
+	if err == nil || !strings.Contains(err.Error(), "working tree is dirty") {
+		t.Fatalf("expected error carrying stderr, got %v", err)
+	}
+	if _, statErr := os.Stat(marker); statErr == nil {
+		t.Error("hooks after a failure should not run")
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	h := &Hooks{Shell: "/bin/sh", Timeout: 100 * time.Millisecond, Hooks: []Hook{
+		{Event: TurnEnd, Command: "sleep 10"},
+	}}
+
+	start := time.Now()
+	err := h.Run(context.Background(), TurnEnd, Env{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timed-out hook took %s to stop", elapsed)
+	}
+}
@@ -10,18 +10,42 @@ package llm
 // 2. INCOMPLETE TOOL CALLS ON CANCEL: When user cancels mid-tool-call, messages may have
 //    tool_use without matching tool_result. Clean up these orphaned tool uses before the
 //    next API request to prevent errors.
+//
+// 3. EMPTY RESPONSES: Misconfigured OpenAI-compatible servers can end a stream
+//    with no text and no tool calls, or cut it off before the step completes.
+//    Stream reports these as ErrEmptyResponse and ErrIncompleteResponse instead
+//    of finishing with nothing, so callers can drop the turn rather than leave
+//    a prompt without a reply in the history.
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 )
 
+var (
+	// ErrEmptyResponse is returned when a step ends with neither text nor
+	// tool calls.
+	ErrEmptyResponse = errors.New("provider returned an empty response")
+
+	// ErrIncompleteResponse is returned when the stream closes before the
+	// provider completes the step.
+	ErrIncompleteResponse = errors.New("provider stream ended before the response was complete")
+)
+
 // Tool represents an executable tool
 type Tool struct {
 	Definition ToolDefinition
 	Execute    func(ctx context.Context, input json.RawMessage) (ToolResultOutput, error)
+	// Parallel marks tools without side effects. Consecutive calls of such
+	// tools in one step run concurrently.
+	Parallel bool
+	// Hidden tools are not offered to the model, but a call it makes anyway
+	// still reaches Execute (see tools.Unavailable).
+	Hidden bool
 }
 
 // AgentConfig configures the agent
@@ -31,6 +55,7 @@ type AgentConfig struct {
 	SystemPrompt      string // Default system prompt (base)
 	ExtraSystemPrompt string // User-provided extra system prompt via --system flag
 	MaxSteps          int
+	MaxParallelTools  int // Concurrent calls of Parallel tools (default: 4)
 }
 
 // Agent orchestrates tool-calling loops
@@ -43,6 +68,9 @@ func NewAgent(config AgentConfig) *Agent {
 	if config.MaxSteps == 0 {
 		config.MaxSteps = 100
 	}
+	if config.MaxParallelTools == 0 {
+		config.MaxParallelTools = 4
+	}
 	return &Agent{config: config}
 }
 
@@ -58,8 +86,9 @@ type StreamCallbacks struct {
 
 // StreamResult is the final result of streaming
 type StreamResult struct {
-	Messages []Message
-	Usage    Usage
+	Messages  []Message
+	Usage     Usage
+	Truncated bool // the final response stopped at the output token limit
 }
 
 // Stream executes the agent with streaming callbacks
@@ -68,6 +97,7 @@ func (a *Agent) Stream(ctx context.Context, messages []Message, callbacks Stream
 		allMessages = make([]Message, len(messages))
 		totalUsage  Usage
 		step        int
+		truncated   bool
 		mu          sync.Mutex
 	)
 
@@ -88,9 +118,11 @@ func (a *Agent) Stream(ctx context.Context, messages []Message, callbacks Stream
 		}
 
 		// Convert tools to definitions
-		toolDefs := make([]ToolDefinition, len(a.config.Tools))
-		for i, tool := range a.config.Tools {
-			toolDefs[i] = tool.Definition
+		toolDefs := make([]ToolDefinition, 0, len(a.config.Tools))
+		for _, tool := range a.config.Tools {
+			if !tool.Hidden {
+				toolDefs = append(toolDefs, tool.Definition)
+			}
 		}
 
 		// Stream from provider
@@ -106,7 +138,7 @@ func (a *Agent) Stream(ctx context.Context, messages []Message, callbacks Stream
 		}
 
 		// Process events
-		stepMessages, stepUsage, toolCalls, err := a.processStreamEvents(eventChan, callbacks)
+		stepMessages, stepUsage, toolCalls, stepTruncated, err := a.processStreamEvents(eventChan, callbacks)
 		if err != nil {
 			return nil, err
 		}
@@ -118,12 +150,19 @@ func (a *Agent) Stream(ctx context.Context, messages []Message, callbacks Stream
 
 		// If no tool calls, we're done - add the step messages (assistant response)
 		if len(toolCalls) == 0 {
+			if stepMessages == nil {
+				return nil, ErrIncompleteResponse
+			}
+			if !hasText(stepMessages) {
+				return nil, ErrEmptyResponse
+			}
 			if callbacks.OnStepFinish != nil {
 				if err := callbacks.OnStepFinish(stepMessages, stepUsage); err != nil {
 					return nil, fmt.Errorf("OnStepFinish callback failed: %w", err)
 				}
 			}
 			allMessages = append(allMessages, stepMessages...)
+			truncated = stepTruncated
 			break
 		}
 
@@ -158,17 +197,33 @@ func (a *Agent) Stream(ctx context.Context, messages []Message, callbacks Stream
 	}
 
 	return &StreamResult{
-		Messages: allMessages,
-		Usage:    totalUsage,
+		Messages:  allMessages,
+		Usage:     totalUsage,
+		Truncated: truncated,
 	}, nil
 }
 
-// processStreamEvents handles streaming events from the provider
-func (a *Agent) processStreamEvents(eventChan <-chan StreamEvent, callbacks StreamCallbacks) ([]Message, Usage, []ToolCallPart, error) {
+// hasText reports whether messages hold any non-blank text. Reasoning alone
+// does not count: the user is left without an answer.
+func hasText(messages []Message) bool {
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			if text, ok := part.(TextPart); ok && strings.TrimSpace(text.Text) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// processStreamEvents handles streaming events from the provider. It also
+// reports whether the step stopped at the output token limit.
+func (a *Agent) processStreamEvents(eventChan <-chan StreamEvent, callbacks StreamCallbacks) ([]Message, Usage, []ToolCallPart, bool, error) {
 	var (
 		stepMessages []Message
 		stepUsage    Usage
 		toolCalls    []ToolCallPart
+		truncated    bool
 	)
 
 	for event := range eventChan {
@@ -176,14 +231,14 @@ func (a *Agent) processStreamEvents(eventChan <-chan StreamEvent, callbacks Stre
 		case TextDeltaEvent:
 			if callbacks.OnTextDelta != nil {
 				if err := callbacks.OnTextDelta(e.Delta); err != nil {
-					return nil, Usage{}, nil, fmt.Errorf("OnTextDelta callback failed: %w", err)
+					return nil, Usage{}, nil, false, fmt.Errorf("OnTextDelta callback failed: %w", err)
 				}
 			}
 
 		case ReasoningDeltaEvent:
 			if callbacks.OnReasoningDelta != nil {
 				if err := callbacks.OnReasoningDelta(e.Delta); err != nil {
-					return nil, Usage{}, nil, fmt.Errorf("OnReasoningDelta callback failed: %w", err)
+					return nil, Usage{}, nil, false, fmt.Errorf("OnReasoningDelta callback failed: %w", err)
 				}
 			}
 
@@ -197,56 +252,32 @@ func (a *Agent) processStreamEvents(eventChan <-chan StreamEvent, callbacks Stre
 
 			if callbacks.OnToolCall != nil {
 				if err := callbacks.OnToolCall(e.ToolCallID, e.ToolName, e.Input); err != nil {
-					return nil, Usage{}, nil, fmt.Errorf("OnToolCall callback failed: %w", err)
+					return nil, Usage{}, nil, false, fmt.Errorf("OnToolCall callback failed: %w", err)
 				}
 			}
 
 		case StepCompleteEvent:
 			stepMessages = e.Messages
 			stepUsage = e.Usage
+			truncated = e.Truncated
 
 		case StreamErrorEvent:
-			return nil, Usage{}, nil, e.Error
+			return nil, Usage{}, nil, false, e.Error
 		}
 	}
 
-	return stepMessages, stepUsage, toolCalls, nil
+	return stepMessages, stepUsage, toolCalls, truncated, nil
 }
 
-// executeTools executes all tool calls and returns the results
+// executeTools executes all tool calls and returns the results in call
+// order. Runs of consecutive calls to Parallel tools execute concurrently;
+// every other call runs alone, after the calls before it have finished.
 func (a *Agent) executeTools(ctx context.Context, toolCalls []ToolCallPart, callbacks StreamCallbacks) []ContentPart {
 	toolResults := make([]ContentPart, len(toolCalls))
-	for i, tc := range toolCalls {
-		// Find the tool
-		var tool *Tool
-		for _, t := range a.config.Tools {
-			if t.Definition.Name == tc.ToolName {
-				tool = &t
-				break
-			}
-		}
-
-		if tool == nil {
-			toolResults[i] = ToolResultPart{
-				Type:       "tool_result",
-				ToolCallID: tc.ToolCallID,
-				Output: ToolResultOutputError{
-					Type:  "error",
-					Error: fmt.Sprintf("unknown tool: %s", tc.ToolName),
-				},
-			}
-			continue
-		}
-
-		// Execute tool
-		output, err := tool.Execute(ctx, tc.Input)
-		if err != nil {
-			output = ToolResultOutputError{
-				Type:  "error",
-				Error: err.Error(),
-			}
-		}
-
+	var notify sync.Mutex // callbacks see one result at a time
+	run := func(i int) {
+		tc := toolCalls[i]
+		output := a.executeTool(ctx, tc)
 		toolResults[i] = ToolResultPart{
 			Type:       "tool_result",
 			ToolCallID: tc.ToolCallID,
@@ -255,13 +286,84 @@ func (a *Agent) executeTools(ctx context.Context, toolCalls []ToolCallPart, call
 
 		// Notify callback about tool result
 		if callbacks.OnToolResult != nil {
+			notify.Lock()
 			//nolint:errcheck // callback error shouldn't prevent tool result from being recorded
 			callbacks.OnToolResult(tc.ToolCallID, output)
+			notify.Unlock()
+		}
+	}
+
+	for i := 0; i < len(toolCalls); {
+		end := i + 1
+		if a.parallel(toolCalls[i]) {
+			for end < len(toolCalls) && a.parallel(toolCalls[end]) {
+				end++
+			}
+		}
+		if end-i == 1 {
+			run(i)
+			i = end
+			continue
+		}
+
+		sem := make(chan struct{}, a.config.MaxParallelTools)
+		var wg sync.WaitGroup
+		for j := i; j < end; j++ {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				run(j)
+			}()
 		}
+		wg.Wait()
+		i = end
 	}
 	return toolResults
 }
 
+// executeTool runs one tool call. Failures become error outputs.
+func (a *Agent) executeTool(ctx context.Context, tc ToolCallPart) ToolResultOutput {
+	tool := a.findTool(tc.ToolName)
+	if tool == nil {
+		return ToolResultOutputError{
+			Type:  "error",
+			Error: fmt.Sprintf("unknown tool: %s", tc.ToolName),
+		}
+	}
+	// Calls waiting for a slot in the pool do not start after a cancel
+	if err := ctx.Err(); err != nil {
+		return ToolResultOutputError{Type: "error", Error: err.Error()}
+	}
+
+	output, err := tool.Execute(ctx, tc.Input)
+	if err != nil {
+		output = ToolResultOutputError{
+			Type:  "error",
+			Error: err.Error(),
+		}
+	}
+	return output
+}
+
+// parallel reports whether tc may run concurrently with its neighbors.
+func (a *Agent) parallel(tc ToolCallPart) bool {
+	tool := a.findTool(tc.ToolName)
+	return tool != nil && tool.Parallel && a.config.MaxParallelTools > 1
+}
+
+func (a *Agent) findTool(name string) *Tool {
+	for i := range a.config.Tools {
+		if a.config.Tools[i].Definition.Name == name {
+			return &a.config.Tools[i]
+		}
+	}
+	return nil
+}
+
 // toolCallsToContent converts tool calls to content parts
 func toolCallsToContent(toolCalls []ToolCallPart) []ContentPart {
 	content := make([]ContentPart, len(toolCalls))
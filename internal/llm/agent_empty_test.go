@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// eventsProvider streams a fixed list of events.
+type eventsProvider struct {
+	events []StreamEvent
+}
+
+func (p *eventsProvider) StreamMessages(context.Context, []Message, []ToolDefinition, string, string) (<-chan StreamEvent, error) {
+	eventChan := make(chan StreamEvent, len(p.events))
+	for _, ev := range p.events {
+		eventChan <- ev
+	}
+	close(eventChan)
+	return eventChan, nil
+}
+
+func TestAgentRejectsEmptyResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []StreamEvent
+		want   error
+	}{
+		{"no events", nil, ErrIncompleteResponse},
+		{"deltas without completion", []StreamEvent{TextDeltaEvent{Delta: "Hel"}}, ErrIncompleteResponse},
+		{"empty message", []StreamEvent{StepCompleteEvent{Messages: []Message{{Role: RoleAssistant}}}}, ErrEmptyResponse},
+		{"blank text", []StreamEvent{StepCompleteEvent{Messages: []Message{
+			NewAssistantMessage([]ContentPart{TextPart{Type: "text", Text: " \n"}}),
+		}}}, ErrEmptyResponse},
+		{"reasoning only", []StreamEvent{StepCompleteEvent{Messages: []Message{
+			NewAssistantMessage([]ContentPart{ReasoningPart{Type: "reasoning", Text: "Thinking..."}}),
+		}}}, ErrEmptyResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finished := false
+			agent := NewAgent(AgentConfig{Provider: &eventsProvider{events: tt.events}})
+			_, err := agent.Stream(context.Background(), []Message{NewUserMessage("hi")}, StreamCallbacks{
+				OnStepFinish: func([]Message, Usage) error {
+					finished = true
+					return nil
+				},
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Stream() error = %v, want %v", err, tt.want)
+			}
+			if finished {
+				t.Error("an empty step should not be reported as finished")
+			}
+		})
+	}
+}
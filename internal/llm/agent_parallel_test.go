@@ -0,0 +1,214 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingTool returns a tool that tracks how many of its calls run at once.
+// Each call echoes its input after waiting for release (when set) or delay.
+type recordingTool struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	release  chan struct{}
+	delay    time.Duration
+}
+
+func (r *recordingTool) tool(name string, parallel bool) Tool {
+	return Tool{
+		Definition: ToolDefinition{Name: name, Schema: []byte(`{"type":"object"}`)},
+		Parallel:   parallel,
+		Execute: func(ctx context.Context, input json.RawMessage) (ToolResultOutput, error) {
+			n := r.inFlight.Add(1)
+			defer r.inFlight.Add(-1)
+			for {
+				peak := r.peak.Load()
+				if n <= peak || r.peak.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			wait := time.After(r.delay)
+			if r.release != nil {
+				wait = nil
+			}
+			select {
+			case <-r.release:
+			case <-wait:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return ToolResultOutputText{Type: "text", Text: string(input)}, nil
+		},
+	}
+}
+
+func calls(name string, n int) []ToolCallPart {
+	out := make([]ToolCallPart, n)
+	for i := range out {
+		out[i] = ToolCallPart{
+			Type:       "tool_use",
+			ToolCallID: fmt.Sprintf("call_%d", i),
+			ToolName:   name,
+			Input:      []byte(fmt.Sprintf(`"%d"`, i)),
+		}
+	}
+	return out
+}
+
+func resultTexts(t *testing.T, results []ContentPart) []string {
+	t.Helper()
+	texts := make([]string, len(results))
+	for i, part := range results {
+		result, ok := part.(ToolResultPart)
+		if !ok {
+			t.Fatalf("result %d is %T", i, part)
+		}
+		switch out := result.Output.(type) {
+		case ToolResultOutputText:
+			texts[i] = out.Text
+		case ToolResultOutputError:
+			texts[i] = "error: " + out.Error
+		}
+	}
+	return texts
+}
+
+func TestExecuteToolsParallelKeepsOrder(t *testing.T) {
+	rec := &recordingTool{delay: 10 * time.Millisecond}
+	agent := NewAgent(AgentConfig{Tools: []Tool{rec.tool("read", true)}, MaxParallelTools: 3})
+
+	var notified []string
+	results := agent.executeTools(context.Background(), calls("read", 7), StreamCallbacks{
+		OnToolResult: func(id string, _ ToolResultOutput) error {
+			notified = append(notified, id)
+			return nil
+		},
+	})
+
+	texts := resultTexts(t, results)
+	for i, text := range texts {
+		if want := fmt.Sprintf(`"%d"`, i); text != want {
+			t.Errorf("result %d = %s, want %s", i, text, want)
+		}
+		if id := results[i].(ToolResultPart).ToolCallID; id != fmt.Sprintf("call_%d", i) {
+			t.Errorf("result %d has id %s", i, id)
+		}
+	}
+	if len(notified) != 7 {
+		t.Errorf("OnToolResult called %d times, want 7", len(notified))
+	}
+	if peak := rec.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("peak concurrency = %d, want 2..3", peak)
+	}
+}
+
+func TestExecuteToolsParallelRunsConcurrently(t *testing.T) {
+	// Every call blocks until all of them have started: this only finishes
+	// when the calls run at the same time.
+	rec := &recordingTool{release: make(chan struct{})}
+	agent := NewAgent(AgentConfig{Tools: []Tool{rec.tool("read", true)}})
+	go func() {
+		for rec.inFlight.Load() < 4 {
+			time.Sleep(time.Millisecond)
+		}
+		close(rec.release)
+	}()
+
+	done := make(chan []ContentPart)
+	go func() { done <- agent.executeTools(context.Background(), calls("read", 4), StreamCallbacks{}) }()
+	select {
+	case results := <-done:
+		if texts := resultTexts(t, results); texts[3] != `"3"` {
+			t.Errorf("results = %v", texts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("parallel calls did not run concurrently")
+	}
+}
+
+func TestExecuteToolsSequentialBarrier(t *testing.T) {
+	reads := &recordingTool{}
+	writes := &recordingTool{}
+	var mu sync.Mutex
+	var order []string
+	track := func(tool Tool) Tool {
+		execute := tool.Execute
+		tool.Execute = func(ctx context.Context, input json.RawMessage) (ToolResultOutput, error) {
+			out, err := execute(ctx, input)
+			mu.Lock()
+			order = append(order, tool.Definition.Name+string(input))
+			mu.Unlock()
+			return out, err
+		}
+		return tool
+	}
+	agent := NewAgent(AgentConfig{Tools: []Tool{
+		track(reads.tool("read", true)),
+		track(writes.tool("write", false)),
+	}})
+
+	tcs := append(calls("read", 2), calls("write", 1)...)
+	tcs = append(tcs, calls("read", 2)...)
+	agent.executeTools(context.Background(), tcs, StreamCallbacks{})
+
+	// The write waits for the reads before it and runs before the reads after it
+	if len(order) != 5 || order[2] != `write"0"` {
+		t.Errorf("order = %v, want the write third", order)
+	}
+	if writes.peak.Load() != 1 {
+		t.Errorf("write peak = %d, want 1", writes.peak.Load())
+	}
+}
+
+func TestExecuteToolsDisabledRunsOneAtATime(t *testing.T) {
+	rec := &recordingTool{delay: time.Millisecond}
+	agent := NewAgent(AgentConfig{Tools: []Tool{rec.tool("read", false)}})
+	agent.executeTools(context.Background(), calls("read", 5), StreamCallbacks{})
+	if peak := rec.peak.Load(); peak != 1 {
+		t.Errorf("peak concurrency = %d, want 1 for tools not marked Parallel", peak)
+	}
+}
+
+func TestExecuteToolsParallelCancel(t *testing.T) {
+	// The tool never finishes on its own, so every call must see the cancel
+	rec := &recordingTool{release: make(chan struct{})}
+	agent := NewAgent(AgentConfig{Tools: []Tool{rec.tool("read", true)}, MaxParallelTools: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for rec.inFlight.Load() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	done := make(chan []ContentPart)
+	go func() { done <- agent.executeTools(ctx, calls("read", 5), StreamCallbacks{}) }()
+	select {
+	case results := <-done:
+		for i, text := range resultTexts(t, results) {
+			if text != "error: "+context.Canceled.Error() {
+				t.Errorf("result %d = %q, want a cancel error", i, text)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel did not reach in-flight calls")
+	}
+}
+
+func BenchmarkExecuteTools(b *testing.B) {
+	for _, parallel := range []bool{false, true} {
+		b.Run(fmt.Sprintf("parallel=%v", parallel), func(b *testing.B) {
+			rec := &recordingTool{delay: 5 * time.Millisecond}
+			agent := NewAgent(AgentConfig{Tools: []Tool{rec.tool("read", parallel)}})
+			tcs := calls("read", 4)
+			for b.Loop() {
+				agent.executeTools(context.Background(), tcs, StreamCallbacks{})
+			}
+		})
+	}
+}
@@ -21,8 +21,9 @@ func (m *mockProvider) StreamMessages(
 	m.lastSystemPrompt = systemPrompt
 	m.lastExtraSystemPrompt = extraSystemPrompt
 
-	// Return empty channel
-	eventChan := make(chan StreamEvent)
+	// Answer with a single text step
+	eventChan := make(chan StreamEvent, 1)
+	eventChan <- StepCompleteEvent{Messages: []Message{NewAssistantMessage([]ContentPart{TextPart{Type: "text", Text: "ok"}})}}
 	close(eventChan)
 	return eventChan, nil
 }
@@ -3,6 +3,7 @@ package factory
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/alayacore/alayacore/internal/llm"
@@ -11,49 +12,201 @@ import (
 
 // ProviderConfig configures a provider
 type ProviderConfig struct {
-	Type        string // "anthropic", "openai"
+	Type        string // One of ProviderTypes; empty picks one from BaseURL
 	APIKey      string
 	BaseURL     string
 	Model       string
 	HTTPClient  *http.Client
-	PromptCache bool // Enable prompt caching (Anthropic only)
+	PromptCache bool                    // Enable prompt caching (Anthropic only)
+	Sampling    llm.SamplingOptions     // Temperature, top_p, max output tokens, reasoning controls
+	Extras      providers.RequestExtras // Headers and body fields added to every request (--header, --extra-body)
+	Azure       providers.AzureOptions  // Deployment and api-version of the "azure" type; BaseURL overrides Azure.Endpoint
+}
+
+// ProviderType describes a supported provider type.
+type ProviderType struct {
+	Name        string
+	Description string
+}
+
+// ProviderTypes lists the supported provider types, the protocol_type values
+// of model.conf.
+var ProviderTypes = []ProviderType{
+	{"anthropic", "Anthropic Messages API (api.anthropic.com, or a compatible server)"},
+	{"openai", "OpenAI Chat Completions API, also spoken by most other servers"},
+	{"ollama", "A local Ollama server; base URL and API key are optional"},
+	{"openrouter", "OpenRouter (openrouter.ai), with its app headers set; base URL is optional"},
+	{"azure", "Azure OpenAI; base URL is the resource endpoint, model_name or deployment the deployment"},
+}
+
+// typeNames returns the provider type names, for error messages.
+func typeNames() string {
+	names := make([]string, len(ProviderTypes))
+	for i, t := range ProviderTypes {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// FormatTypes renders ProviderTypes one per line, for --list-providers.
+func FormatTypes() string {
+	var sb strings.Builder
+	for _, t := range ProviderTypes {
+		fmt.Fprintf(&sb, "%-10s %s\n", t.Name, t.Description)
+	}
+	return sb.String()
+}
+
+// ResolveType returns the canonical name of providerType. An empty type is
+// chosen from baseURL: anthropic for an anthropic.com host, openrouter for
+// openrouter.ai, azure for openai.azure.com, openai for any other, since most
+// servers speak the OpenAI protocol. An unknown type is an error listing the
+// valid ones, rather than a request to the wrong API.
+func ResolveType(providerType, baseURL string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(providerType))
+	if name == "" {
+		if u, err := url.Parse(baseURL); err == nil {
+			host := u.Hostname()
+			if host == "anthropic.com" || strings.HasSuffix(host, ".anthropic.com") {
+				return "anthropic", nil
+			}
+			if host == "openrouter.ai" || strings.HasSuffix(host, ".openrouter.ai") {
+				return "openrouter", nil
+			}
+			if strings.HasSuffix(host, ".openai.azure.com") {
+				return "azure", nil
+			}
+		}
+		return "openai", nil
+	}
+	for _, t := range ProviderTypes {
+		if t.Name == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown provider type %q: use one of %s", providerType, typeNames())
+}
+
+// ValidateSampling rejects sampling options the provider type cannot honor:
+// reasoning_effort is OpenAI-only (Ollama, OpenRouter, and Azure speak the
+// OpenAI protocol) and
+// thinking_budget_tokens is Anthropic-only.
+func ValidateSampling(providerType string, sampling llm.SamplingOptions) error {
+	switch strings.ToLower(providerType) {
+	case "anthropic":
+		if sampling.ReasoningEffort != "" {
+			return fmt.Errorf("%s is not supported by anthropic models; use %s instead",
+				llm.SamplingReasoningEffort, llm.SamplingThinkingBudget)
+		}
+		if sampling.ThinkingBudgetTokens > 0 && sampling.MaxOutputTokens > 0 &&
+			sampling.MaxOutputTokens <= sampling.ThinkingBudgetTokens {
+			return fmt.Errorf("%s (%d) must be greater than %s (%d)",
+				llm.SamplingMaxOutputTokens, sampling.MaxOutputTokens,
+				llm.SamplingThinkingBudget, sampling.ThinkingBudgetTokens)
+		}
+	case "openai", "ollama", "openrouter", "azure":
+		if sampling.ThinkingBudgetTokens > 0 {
+			return fmt.Errorf("%s is not supported by %s models; use %s instead",
+				llm.SamplingThinkingBudget, strings.ToLower(providerType), llm.SamplingReasoningEffort)
+		}
+	}
+	return nil
 }
 
 // NewProvider creates a provider based on configuration
 func NewProvider(config ProviderConfig) (llm.Provider, error) {
-	switch strings.ToLower(config.Type) {
+	providerType, err := ResolveType(config.Type, config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSampling(providerType, config.Sampling); err != nil {
+		return nil, err
+	}
+
+	client := config.Extras.Client(config.HTTPClient)
+
+	switch providerType {
 	case "anthropic":
 		opts := []providers.AnthropicOption{
 			providers.WithAPIKey(config.APIKey),
 			providers.WithPromptCache(config.PromptCache),
+			providers.WithSampling(config.Sampling),
 		}
 		if config.BaseURL != "" {
 			opts = append(opts, providers.WithBaseURL(config.BaseURL))
 		}
-		if config.HTTPClient != nil {
-			opts = append(opts, providers.WithHTTPClient(config.HTTPClient))
+		if client != nil {
+			opts = append(opts, providers.WithHTTPClient(client))
 		}
 		if config.Model != "" {
 			opts = append(opts, providers.WithAnthropicModel(config.Model))
 		}
 		return providers.NewAnthropic(opts...)
 
-	case "openai":
+	case "openai", "ollama", "openrouter", "azure":
+		apiKey, baseURL, model := config.APIKey, config.BaseURL, config.Model
+		switch providerType {
+		case "ollama":
+			// Ollama ignores the key, but the OpenAI provider requires one
+			if apiKey == "" {
+				apiKey = "ollama"
+			}
+			if baseURL == "" {
+				baseURL = providers.OllamaBaseURL
+			}
+		case "openrouter":
+			if baseURL == "" {
+				baseURL = providers.OpenRouterBaseURL
+			}
+			client = config.Extras.WithDefaultHeaders(providers.OpenRouterHeaders).Client(config.HTTPClient)
+		case "azure":
+			azure := config.Azure
+			if baseURL != "" {
+				azure.Endpoint = baseURL
+			}
+			deployment, err := azureDeployment(azure.Deployment, model)
+			if err != nil {
+				return nil, err
+			}
+			if azure.Endpoint == "" {
+				return nil, fmt.Errorf("azure needs the resource endpoint, e.g. https://my-resource.openai.azure.com, as base URL or --azure-endpoint")
+			}
+			baseURL, model = providers.AzureBaseURL(azure.Endpoint, deployment), deployment
+			client = providers.AzureClient(client, azure.APIVersion)
+		}
 		opts := []providers.OpenAIOption{
-			providers.WithOpenAIAPIKey(config.APIKey),
+			providers.WithOpenAIAPIKey(apiKey),
+			providers.WithOpenAISampling(config.Sampling),
 		}
-		if config.BaseURL != "" {
-			opts = append(opts, providers.WithOpenAIBaseURL(config.BaseURL))
+		if baseURL != "" {
+			opts = append(opts, providers.WithOpenAIBaseURL(baseURL))
 		}
-		if config.HTTPClient != nil {
-			opts = append(opts, providers.WithOpenAIHTTPClient(config.HTTPClient))
+		if client != nil {
+			opts = append(opts, providers.WithOpenAIHTTPClient(client))
 		}
-		if config.Model != "" {
-			opts = append(opts, providers.WithOpenAIModel(config.Model))
+		if model != "" {
+			opts = append(opts, providers.WithOpenAIModel(model))
 		}
 		return providers.NewOpenAI(opts...)
 
 	default:
-		return nil, fmt.Errorf("unknown provider type: %s", config.Type)
+		// ResolveType only returns listed types
+		return nil, fmt.Errorf("provider type %s has no constructor", providerType)
+	}
+}
+
+// azureDeployment returns the Azure deployment to call. Azure routes by
+// deployment, not model, so a model name stands for the deployment of that
+// name; one that names a different deployment is an error rather than a
+// request to whichever the URL picks.
+func azureDeployment(deployment, model string) (string, error) {
+	switch {
+	case deployment == "" && model == "":
+		return "", fmt.Errorf("azure needs a deployment: set model_name, deployment, or --azure-deployment")
+	case deployment == "":
+		return model, nil
+	case model != "" && model != deployment:
+		return "", fmt.Errorf("azure model %q does not match deployment %q: Azure selects the model by deployment, so set only one of them or make them equal", model, deployment)
 	}
+	return deployment, nil
 }
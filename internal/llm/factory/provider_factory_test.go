@@ -1,8 +1,15 @@
 package factory
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/alayacore/alayacore/internal/llm"
 	"github.com/alayacore/alayacore/internal/llm/providers"
 )
 
@@ -94,3 +101,284 @@ func TestFactoryAnthropicWithAllOptions(t *testing.T) {
 		t.Fatalf("Expected AnthropicProvider, got %T", provider)
 	}
 }
+
+func TestValidateSampling(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerType string
+		sampling     llm.SamplingOptions
+		wantErr      bool
+	}{
+		{"openai effort", "openai", llm.SamplingOptions{ReasoningEffort: "low"}, false},
+		{"openai budget", "openai", llm.SamplingOptions{ThinkingBudgetTokens: 2048}, true},
+		{"anthropic budget", "anthropic", llm.SamplingOptions{ThinkingBudgetTokens: 2048}, false},
+		{"anthropic effort", "anthropic", llm.SamplingOptions{ReasoningEffort: "high"}, true},
+		{"anthropic budget above max", "anthropic", llm.SamplingOptions{ThinkingBudgetTokens: 2048, MaxOutputTokens: 1024}, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSampling(tt.providerType, tt.sampling)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	_, err := NewProvider(ProviderConfig{Type: "openai", Sampling: llm.SamplingOptions{ThinkingBudgetTokens: 2048}})
+	if err == nil {
+		t.Error("NewProvider should reject unsupported sampling options")
+	}
+}
+
+func TestFactoryOllamaNeedsNoAPIKey(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Type: "ollama", BaseURL: server.URL + "/v1", Model: "qwen3:8b"})
+	if err != nil {
+		t.Fatalf("NewProvider without an API key: %v", err)
+	}
+	events, err := provider.StreamMessages(context.Background(), []llm.Message{llm.NewUserMessage("hello")}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range events {
+	}
+	if gotPath != "/v1/chat/completions" {
+		t.Errorf("request path = %q, want the OpenAI-compatible endpoint", gotPath)
+	}
+
+	if err := ValidateSampling("ollama", llm.SamplingOptions{ThinkingBudgetTokens: 1024}); err == nil {
+		t.Error("ollama should reject thinking_budget_tokens like openai")
+	}
+}
+
+func TestFactoryTypeSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		baseURL string
+		want    string // provider type, or "" for an error
+	}{
+		{"anthropic", "anthropic", "", "anthropic"},
+		{"case-insensitive", "OpenAI", "", "openai"},
+		{"ollama", "ollama", "", "ollama"},
+		{"openrouter", "openrouter", "", "openrouter"},
+		{"typo", "antropic", "https://api.anthropic.com", ""},
+		{"openaicompat is not a type", "openaicompat", "", ""},
+		{"unspecified, anthropic URL", "", "https://api.anthropic.com", "anthropic"},
+		{"unspecified, anthropic subdomain", "", "https://eu.api.anthropic.com/v1", "anthropic"},
+		{"unspecified, OpenAI URL", "", "https://api.openai.com/v1", "openai"},
+		{"unspecified, compatible server", "", "https://api.deepseek.com/v1", "openai"},
+		{"unspecified, OpenRouter URL", "", "https://openrouter.ai/api/v1", "openrouter"},
+		{"unspecified, look-alike host", "", "https://anthropic.com.example.org", "openai"},
+		{"unspecified, no URL", "", "", "openai"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveType(tt.typ, tt.baseURL)
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "anthropic, openai, ollama") {
+					t.Errorf("ResolveType(%q) = %q, %v; want an error listing the types", tt.typ, got, err)
+				}
+			} else if got != tt.want || err != nil {
+				t.Errorf("ResolveType(%q, %q) = %q, %v; want %q", tt.typ, tt.baseURL, got, err, tt.want)
+			}
+
+			provider, err := NewProvider(ProviderConfig{Type: tt.typ, BaseURL: tt.baseURL, APIKey: "test-key"})
+			switch tt.want {
+			case "":
+				if err == nil {
+					t.Errorf("NewProvider made a %T", provider)
+				}
+			case "anthropic":
+				if _, ok := provider.(*providers.AnthropicProvider); !ok {
+					t.Errorf("got %T, %v; want AnthropicProvider", provider, err)
+				}
+			default:
+				if _, ok := provider.(*providers.OpenAIProvider); !ok {
+					t.Errorf("got %T, %v; want OpenAIProvider", provider, err)
+				}
+			}
+		})
+	}
+}
+
+// captureServer records the last request and answers with an empty stream.
+func captureServer(t *testing.T) (*httptest.Server, *http.Header, *map[string]any) {
+	t.Helper()
+	header := &http.Header{}
+	body := &map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*header = r.Header.Clone()
+		*body = map[string]any{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, header, body
+}
+
+func streamOnce(t *testing.T, provider llm.Provider) {
+	t.Helper()
+	events, err := provider.StreamMessages(context.Background(), []llm.Message{llm.NewUserMessage("hello")}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range events {
+	}
+}
+
+func TestFactoryAddsRequestExtras(t *testing.T) {
+	extras, err := providers.ParseRequestExtras(
+		[]string{"X-Team: search", "X-Trace: abc"},
+		`{"provider": {"order": ["groq"]}, "stream": true}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, typ := range []string{"openai", "anthropic"} {
+		t.Run(typ, func(t *testing.T) {
+			server, header, body := captureServer(t)
+			provider, err := NewProvider(ProviderConfig{
+				Type: typ, APIKey: "test-key", BaseURL: server.URL, Model: "m",
+				HTTPClient: server.Client(), Extras: extras,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			streamOnce(t, provider)
+
+			if header.Get("X-Team") != "search" || header.Get("X-Trace") != "abc" {
+				t.Errorf("headers = %v, want the extra headers", *header)
+			}
+			if provider, ok := (*body)["provider"].(map[string]any); !ok || provider["order"] == nil {
+				t.Errorf("body = %v, want the extra provider field", *body)
+			}
+			if (*body)["model"] != "m" || (*body)["messages"] == nil {
+				t.Errorf("body = %v, want the request fields kept", *body)
+			}
+		})
+	}
+}
+
+func TestFactoryOpenRouterSetsAppHeaders(t *testing.T) {
+	server, header, _ := captureServer(t)
+	extras, err := providers.ParseRequestExtras([]string{"X-Title: My Tool"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewProvider(ProviderConfig{Type: "openrouter", APIKey: "test-key", BaseURL: server.URL, Model: "m", Extras: extras})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamOnce(t, provider)
+
+	if got := header.Get("HTTP-Referer"); got != "https://github.com/alayacore/alayacore" {
+		t.Errorf("HTTP-Referer = %q, want the default", got)
+	}
+	if got := header.Get("X-Title"); got != "My Tool" {
+		t.Errorf("X-Title = %q, want the one from --header", got)
+	}
+	if got := header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want the API key", got)
+	}
+}
+
+func TestFactoryAzureRequestShape(t *testing.T) {
+	var path, query string
+	var header http.Header
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, header = r.URL.Path, r.URL.RawQuery, r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"choices":[{"index":0,"delta":{"content":"hi"}}]}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{
+		Type: "azure", APIKey: "azure-key", BaseURL: server.URL + "/", Model: "gpt-4o-prod",
+		HTTPClient: server.Client(),
+		Azure:      providers.AzureOptions{APIVersion: "2025-01-01-preview"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := provider.StreamMessages(context.Background(), []llm.Message{llm.NewUserMessage("hello")}, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	for event := range events {
+		if delta, ok := event.(llm.TextDeltaEvent); ok {
+			text.WriteString(delta.Delta)
+		}
+	}
+
+	if path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("path = %q, want the deployment's chat completions", path)
+	}
+	if query != "api-version=2025-01-01-preview" {
+		t.Errorf("query = %q, want the api-version", query)
+	}
+	if got := header.Get("Api-Key"); got != "azure-key" {
+		t.Errorf("api-key = %q, want the API key", got)
+	}
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+	if body["stream"] != true || body["model"] != "gpt-4o-prod" {
+		t.Errorf("body = %v, want a streamed request for the deployment", body)
+	}
+	if text.String() != "hi" {
+		t.Errorf("streamed text = %q, want %q", text.String(), "hi")
+	}
+}
+
+func TestFactoryAzureDeployment(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      string
+		deployment string
+		endpoint   string
+		wantErr    string // "" for success
+	}{
+		{"model names the deployment", "prod", "", "https://r.openai.azure.com", ""},
+		{"deployment alone", "", "prod", "https://r.openai.azure.com", ""},
+		{"both equal", "prod", "prod", "https://r.openai.azure.com", ""},
+		{"both differ", "gpt-4o", "prod", "https://r.openai.azure.com", `model "gpt-4o" does not match deployment "prod"`},
+		{"neither", "", "", "https://r.openai.azure.com", "needs a deployment"},
+		{"no endpoint", "prod", "", "", "needs the resource endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProvider(ProviderConfig{
+				Type: "azure", APIKey: "k", Model: tt.model,
+				Azure: providers.AzureOptions{Endpoint: tt.endpoint, Deployment: tt.deployment},
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("NewProvider: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewProvider error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got, err := ResolveType("", "https://my-resource.openai.azure.com"); got != "azure" || err != nil {
+		t.Errorf("ResolveType of an Azure endpoint = %q, %v; want azure", got, err)
+	}
+}
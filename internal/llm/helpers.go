@@ -92,6 +92,13 @@ func (b *ToolBuilder) WithExecute(fn func(ctx context.Context, input json.RawMes
 	return b
 }
 
+// WithParallel marks the tool as free of side effects, so that several calls
+// of it in one step may run concurrently
+func (b *ToolBuilder) WithParallel() *ToolBuilder {
+	b.tool.Parallel = true
+	return b
+}
+
 // Build returns the tool
 func (b *ToolBuilder) Build() Tool {
 	return b.tool
@@ -21,6 +21,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -43,6 +44,7 @@ type AnthropicProvider struct {
 	client      *http.Client
 	model       string
 	promptCache bool
+	sampling    llm.SamplingOptions
 }
 
 // AnthropicOption configures the provider
@@ -99,17 +101,32 @@ func WithPromptCache(enabled bool) AnthropicOption {
 	}
 }
 
+// WithSampling sets temperature, top_p, max output tokens, and the thinking budget
+func WithSampling(sampling llm.SamplingOptions) AnthropicOption {
+	return func(p *AnthropicProvider) {
+		p.sampling = sampling
+	}
+}
+
 // anthropicRequest represents the Anthropic API request
 type anthropicRequest struct {
 	Model        string                   `json:"model"`
 	Messages     []anthropicMessage       `json:"messages"`
 	MaxTokens    int                      `json:"max_tokens"`
+	Temperature  *float64                 `json:"temperature,omitempty"`
+	TopP         *float64                 `json:"top_p,omitempty"`
+	Thinking     *anthropicThinking       `json:"thinking,omitempty"`
 	System       []anthropicSystemMessage `json:"system,omitempty"`
 	Tools        []anthropicTool          `json:"tools,omitempty"`
 	Stream       bool                     `json:"stream"`
 	CacheControl *anthropicCacheControl   `json:"cache_control,omitempty"`
 }
 
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
 type anthropicCacheControl struct {
 	Type string `json:"type"`
 }
@@ -139,13 +156,23 @@ type anthropicContentBlock struct {
 	Content   interface{} `json:"content,omitempty"`
 	IsError   bool        `json:"is_error,omitempty"`
 
+	// For images
+	Source *anthropicImageSource `json:"source,omitempty"`
+
 	// For thinking (extended thinking)
-	Thinking string `json:"thinking,omitempty"`
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
 
 	// Cache control
 	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
 }
 
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
 type anthropicTool struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
@@ -157,6 +184,7 @@ type streamState struct {
 	mu           sync.Mutex
 	contentParts []llm.ContentPart
 	usage        llm.Usage
+	rawUsage     map[string]int64 // latest value of each usage counter seen in the stream
 	stopReason   string
 
 	// Current block being accumulated
@@ -166,6 +194,7 @@ type streamState struct {
 	currentInput strings.Builder
 	currentID    string
 	currentName  string
+	currentSig   string
 }
 
 func (s *streamState) startBlock(index int, blockType, id, name string) {
@@ -175,6 +204,7 @@ func (s *streamState) startBlock(index int, blockType, id, name string) {
 	s.currentType = blockType
 	s.currentID = id
 	s.currentName = name
+	s.currentSig = ""
 	s.currentText.Reset()
 	s.currentInput.Reset()
 }
@@ -185,6 +215,12 @@ func (s *streamState) appendText(text string) {
 	s.currentText.WriteString(text)
 }
 
+func (s *streamState) setSignature(signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentSig += signature
+}
+
 func (s *streamState) appendInput(jsonStr string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -203,8 +239,9 @@ func (s *streamState) finishBlock() {
 		})
 	case "thinking":
 		s.contentParts = append(s.contentParts, llm.ReasoningPart{
-			Type: "reasoning",
-			Text: s.currentText.String(),
+			Type:      "reasoning",
+			Text:      s.currentText.String(),
+			Signature: s.currentSig,
 		})
 	case blockTypeToolUse:
 		s.contentParts = append(s.contentParts, llm.ToolCallPart{
@@ -217,12 +254,26 @@ func (s *streamState) finishBlock() {
 	s.currentType = ""
 }
 
-func (s *streamState) setUsage(inputTokens, outputTokens, cacheReadTokens, cacheCreationTokens int64) {
+// updateUsage merges usage counters from a stream event. Counters missing from
+// a later event (message_delta usually carries only output_tokens) keep their
+// earlier values.
+func (s *streamState) updateUsage(counters map[string]int64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.rawUsage == nil {
+		s.rawUsage = make(map[string]int64)
+	}
+	for k, v := range counters {
+		s.rawUsage[k] = v
+	}
+	cacheRead := s.rawUsage["cache_read_input_tokens"]
+	cacheCreation := s.rawUsage["cache_creation_input_tokens"]
 	s.usage = llm.Usage{
-		InputTokens:  inputTokens + cacheReadTokens + cacheCreationTokens,
-		OutputTokens: outputTokens,
+		// Cache tokens are part of input tokens
+		InputTokens:         s.rawUsage["input_tokens"] + cacheRead + cacheCreation,
+		OutputTokens:        s.rawUsage["output_tokens"],
+		CacheReadTokens:     cacheRead,
+		CacheCreationTokens: cacheCreation,
 	}
 }
 
@@ -247,6 +298,13 @@ func (s *streamState) setStopReason(reason string) {
 	s.stopReason = reason
 }
 
+// truncated reports whether the response stopped at max_tokens.
+func (s *streamState) truncated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopReason == "max_tokens"
+}
+
 // lastToolCall returns the last tool call if the current block is a tool_use
 func (s *streamState) lastToolCall() *llm.ToolCallPart {
 	s.mu.Lock()
@@ -293,11 +351,26 @@ func (p *AnthropicProvider) StreamMessages(
 					Type: "text",
 					Text: v.Text,
 				})
+			case llm.ImagePart:
+				apiMsg.Content = append(apiMsg.Content, anthropicContentBlock{
+					Type: "image",
+					Source: &anthropicImageSource{
+						Type:      "base64",
+						MediaType: v.MediaType,
+						Data:      base64.StdEncoding.EncodeToString(v.Data),
+					},
+				})
 			case llm.ReasoningPart:
+				// With extended thinking on, the API rejects thinking blocks without a
+				// signature (e.g. restored from a saved session); earlier turns may omit them
+				if v.Signature == "" && p.sampling.ThinkingBudgetTokens > 0 {
+					continue
+				}
 				// Anthropic uses "thinking" type for extended thinking
 				apiMsg.Content = append(apiMsg.Content, anthropicContentBlock{
-					Type:     "thinking",
-					Thinking: v.Text,
+					Type:      "thinking",
+					Thinking:  v.Text,
+					Signature: v.Signature,
 				})
 			case llm.ToolCallPart:
 				apiMsg.Content = append(apiMsg.Content, anthropicContentBlock{
@@ -311,6 +384,8 @@ func (p *AnthropicProvider) StreamMessages(
 				switch out := v.Output.(type) {
 				case llm.ToolResultOutputText:
 					content = out.Text
+				case llm.ToolResultOutputData:
+					content = string(out.Data)
 				case llm.ToolResultOutputError:
 					content = out.Error
 					apiMsg.Content = append(apiMsg.Content, anthropicContentBlock{
@@ -360,14 +435,26 @@ func (p *AnthropicProvider) StreamMessages(
 		})
 	}
 
-	// Build request
+	// Build request (max_tokens is required by the API and must exceed the thinking budget)
+	maxTokens := 4096
+	if p.sampling.MaxOutputTokens > 0 {
+		maxTokens = p.sampling.MaxOutputTokens
+	} else if p.sampling.ThinkingBudgetTokens > 0 {
+		maxTokens += p.sampling.ThinkingBudgetTokens
+	}
 	reqBody := anthropicRequest{
-		Model:     p.model,
-		Messages:  apiMessages,
-		MaxTokens: 4096,
-		System:    systemMessages,
-		Tools:     apiTools,
-		Stream:    true,
+		Model:       p.model,
+		Messages:    apiMessages,
+		MaxTokens:   maxTokens,
+		Temperature: p.sampling.Temperature,
+		TopP:        p.sampling.TopP,
+		System:      systemMessages,
+		Tools:       apiTools,
+		Stream:      true,
+	}
+
+	if p.sampling.ThinkingBudgetTokens > 0 {
+		reqBody.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: p.sampling.ThinkingBudgetTokens}
 	}
 
 	// Add top-level cache_control for automatic caching (Anthropic's automatic caching)
@@ -511,24 +598,13 @@ func (p *AnthropicProvider) handleMessageStart(payload map[string]interface{}, _
 
 // extractAndSetUsage extracts token counts from usage map and updates state
 func (p *AnthropicProvider) extractAndSetUsage(usage map[string]interface{}, state *streamState) {
-	inputTokens := 0.0
-	if v, ok := usage["input_tokens"].(float64); ok {
-		inputTokens = v
-	}
-	outputTokens := 0.0
-	if v, ok := usage["output_tokens"].(float64); ok {
-		outputTokens = v
-	}
-	// Cache tokens are part of input tokens
-	cacheReadTokens := 0.0
-	if v, ok := usage["cache_read_input_tokens"].(float64); ok {
-		cacheReadTokens = v
-	}
-	cacheCreationTokens := 0.0
-	if v, ok := usage["cache_creation_input_tokens"].(float64); ok {
-		cacheCreationTokens = v
+	counters := make(map[string]int64)
+	for _, key := range []string{"input_tokens", "output_tokens", "cache_read_input_tokens", "cache_creation_input_tokens"} {
+		if v, ok := usage[key].(float64); ok {
+			counters[key] = int64(v)
+		}
 	}
-	state.setUsage(int64(inputTokens), int64(outputTokens), int64(cacheReadTokens), int64(cacheCreationTokens))
+	state.updateUsage(counters)
 }
 
 // handleContentBlockStart handles content_block_start events
@@ -574,6 +650,11 @@ func (p *AnthropicProvider) handleContentDelta(payload map[string]interface{}, e
 			eventChan <- llm.ReasoningDeltaEvent{Delta: thinking}
 		}
 
+	case "signature_delta":
+		if signature, ok := delta["signature"].(string); ok {
+			state.setSignature(signature)
+		}
+
 	case "input_json_delta":
 		if partialJSON, ok := delta["partial_json"].(string); ok {
 			state.appendInput(partialJSON)
@@ -637,8 +718,9 @@ func (p *AnthropicProvider) handleMessageStop(payload map[string]interface{}, ev
 
 	// Send the accumulated message with usage
 	eventChan <- llm.StepCompleteEvent{
-		Messages: []llm.Message{state.getMessage()},
-		Usage:    state.getUsage(),
+		Messages:  []llm.Message{state.getMessage()},
+		Usage:     state.getUsage(),
+		Truncated: state.truncated(),
 	}
 	return nil
 }
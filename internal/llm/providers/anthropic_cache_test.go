@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
 )
 
 // TestAnthropicPromptCacheFullFlow tests that prompt_cache=true results in
@@ -159,3 +161,51 @@ func TestAnthropicPromptCacheDisabled(t *testing.T) {
 
 	t.Logf("Verified: no cache_control when prompt_cache=false")
 }
+
+// TestAnthropicCacheUsageReported tests that cache read/creation counters are
+// reported in the step usage and survive a message_delta that only carries
+// output_tokens
+func TestAnthropicCacheUsageReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":10,\"cache_read_input_tokens\":2000,\"cache_creation_input_tokens\":300}}}\n\n"))
+		w.Write([]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":5}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewAnthropic(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithAnthropicModel("claude-3-5-sonnet-20241022"),
+		WithPromptCache(true),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	eventChan, err := provider.StreamMessages(context.Background(), nil, nil, "system", "")
+	if err != nil {
+		t.Fatalf("Failed to stream messages: %v", err)
+	}
+
+	var usage llm.Usage
+	for event := range eventChan {
+		if e, ok := event.(llm.StepCompleteEvent); ok {
+			usage = e.Usage
+		}
+	}
+
+	if usage.CacheReadTokens != 2000 {
+		t.Errorf("CacheReadTokens = %d, want 2000", usage.CacheReadTokens)
+	}
+	if usage.CacheCreationTokens != 300 {
+		t.Errorf("CacheCreationTokens = %d, want 300", usage.CacheCreationTokens)
+	}
+	if usage.InputTokens != 2310 {
+		t.Errorf("InputTokens = %d, want 2310 (cache tokens included)", usage.InputTokens)
+	}
+	if usage.OutputTokens != 5 {
+		t.Errorf("OutputTokens = %d, want 5", usage.OutputTokens)
+	}
+}
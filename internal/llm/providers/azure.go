@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AzureAPIVersion is the api-version query parameter sent to Azure OpenAI
+// when none is configured.
+const AzureAPIVersion = "2024-10-21"
+
+// AzureOptions locate an Azure OpenAI deployment. Endpoint is the resource
+// endpoint, e.g. https://my-resource.openai.azure.com; the Chat Completions
+// URL is built from it and the deployment name.
+type AzureOptions struct {
+	Endpoint   string
+	Deployment string
+	APIVersion string
+}
+
+// WithDefaults returns o with defaults' values for the fields o leaves
+// empty.
+func (o AzureOptions) WithDefaults(defaults AzureOptions) AzureOptions {
+	if o.Endpoint == "" {
+		o.Endpoint = defaults.Endpoint
+	}
+	if o.Deployment == "" {
+		o.Deployment = defaults.Deployment
+	}
+	if o.APIVersion == "" {
+		o.APIVersion = defaults.APIVersion
+	}
+	return o
+}
+
+// AzureBaseURL returns the OpenAI-compatible base URL of a deployment:
+// {endpoint}/openai/deployments/{deployment}. An endpoint that already ends
+// in /openai is not doubled.
+func AzureBaseURL(endpoint, deployment string) string {
+	return azureResource(endpoint) + "/openai/deployments/" + url.PathEscape(deployment)
+}
+
+// azureResource returns endpoint without a trailing slash or /openai.
+func azureResource(endpoint string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/openai")
+}
+
+// AzureClient returns a copy of client whose requests authenticate the way
+// Azure OpenAI does: the bearer token of the OpenAI provider moves to the
+// api-key header, and apiVersion (AzureAPIVersion when empty) is added as
+// the api-version query parameter. A nil client stands for
+// http.DefaultClient.
+func AzureClient(client *http.Client, apiVersion string) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if apiVersion == "" {
+		apiVersion = AzureAPIVersion
+	}
+	wrapped := *client
+	wrapped.Transport = &azureTransport{base: client.Transport, apiVersion: apiVersion}
+	return &wrapped
+}
+
+// azureTransport rewrites OpenAI requests for Azure OpenAI.
+type azureTransport struct {
+	base       http.RoundTripper // nil means http.DefaultTransport
+	apiVersion string
+}
+
+func (t *azureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		req.Header.Del("Authorization")
+		if req.Header.Get("Api-Key") == "" {
+			req.Header.Set("Api-Key", strings.TrimPrefix(auth, "Bearer "))
+		}
+	}
+	query := req.URL.Query()
+	if query.Get("api-version") == "" {
+		query.Set("api-version", t.apiVersion)
+		req.URL.RawQuery = query.Encode()
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,29 @@
+package providers
+
+import "testing"
+
+func TestAzureBaseURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://r.openai.azure.com", "https://r.openai.azure.com/openai/deployments/my%20dep"},
+		{"https://r.openai.azure.com/", "https://r.openai.azure.com/openai/deployments/my%20dep"},
+		{"https://r.openai.azure.com/openai/", "https://r.openai.azure.com/openai/deployments/my%20dep"},
+	}
+	for _, tt := range tests {
+		if got := AzureBaseURL(tt.endpoint, "my dep"); got != tt.want {
+			t.Errorf("AzureBaseURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestAzureOptionsWithDefaults(t *testing.T) {
+	got := AzureOptions{Deployment: "prod"}.WithDefaults(AzureOptions{
+		Endpoint: "https://r.openai.azure.com", Deployment: "dev", APIVersion: "2025-01-01-preview",
+	})
+	want := AzureOptions{Endpoint: "https://r.openai.azure.com", Deployment: "prod", APIVersion: "2025-01-01-preview"}
+	if got != want {
+		t.Errorf("WithDefaults = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package providers
+
+import "strings"
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// The longest matching prefix wins, so specific variants can override their
+// family. Models not listed here rely on context_limit in the model config.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-5":         400000,
+	"gpt-oss":       131072,
+	"o1":            200000,
+	"o3":            200000,
+	"o4-mini":       200000,
+	"claude-":       200000,
+	"deepseek-":     128000,
+	"gemini-1.5":    1048576,
+	"gemini-2":      1048576,
+	"qwen2.5":       32768,
+	"qwen3":         40960,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+}
+
+// ContextWindow returns the known context window of a model, or 0 when the
+// model is not in the table. Provider prefixes such as "openai/" are ignored.
+func ContextWindow(model string) int {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	best, window := 0, 0
+	for prefix, size := range contextWindows {
+		if len(prefix) > best && strings.HasPrefix(name, prefix) {
+			best, window = len(prefix), size
+		}
+	}
+	return window
+}
+
+// contextLengthErrors are fragments of the errors providers return when a
+// request does not fit the context window:
+//   - OpenAI: code "context_length_exceeded", "This model's maximum context length is ..."
+//   - DeepSeek: "This model's maximum context length is ..." (OpenAI-style)
+//   - Anthropic: "prompt is too long: ... tokens > ... maximum",
+//     "input length and `max_tokens` exceed context limit"
+//   - Ollama and other local servers: "exceeds the context window"
+var contextLengthErrors = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"prompt is too long",
+	"exceed context limit",
+	"exceeds the context window",
+	"exceeds the context length",
+}
+
+// IsContextLengthError reports whether err is a provider rejecting a request
+// that does not fit the model's context window.
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range contextLengthErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
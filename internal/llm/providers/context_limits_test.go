@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"gpt-4-turbo-2024-04-09", 128000},
+		{"openai/gpt-4.1", 1047576},
+		{"claude-sonnet-4-5", 200000},
+		{"gpt-oss:20b", 131072},
+		{"my-local-model", 0},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New(`API error (status 400): {"error":{"message":"This model's maximum context length is 128000 tokens. However, your messages resulted in 130512 tokens.","type":"invalid_request_error","code":"context_length_exceeded"}}`), true},
+		{errors.New(`API error (status 400): {"error":{"message":"This model's maximum context length is 65536 tokens. However, you requested 70123 tokens","type":"invalid_request_error"}}`), true},
+		{errors.New(`API error (status 400): {"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`), true},
+		{errors.New("API error: input length and `max_tokens` exceed context limit: 195000 + 8192 > 200000"), true},
+		{errors.New(`API error (status 401): {"error":{"message":"Incorrect API key provided"}}`), false},
+		{errors.New("failed to send request: connection refused"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsContextLengthError(tt.err); got != tt.want {
+			t.Errorf("IsContextLengthError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
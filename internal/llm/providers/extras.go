@@ -0,0 +1,134 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenRouterBaseURL is the OpenAI-compatible endpoint of OpenRouter, the
+// default base URL of the "openrouter" provider type.
+const OpenRouterBaseURL = "https://openrouter.ai/api/v1"
+
+// OpenRouterHeaders identifies the app to OpenRouter, which ranks apps by
+// them. --header overrides either.
+var OpenRouterHeaders = http.Header{
+	"Http-Referer": {"https://github.com/alayacore/alayacore"},
+	"X-Title":      {"AlayaCore"},
+}
+
+// RequestExtras are added to every request sent to a provider: headers from
+// --header and top-level JSON body fields from --extra-body, such as
+// OpenRouter's "provider" routing hints.
+type RequestExtras struct {
+	Header http.Header
+	Body   map[string]json.RawMessage
+}
+
+// ParseRequestExtras parses "Key: Value" headers and a JSON object of body
+// fields.
+func ParseRequestExtras(headers []string, body string) (RequestExtras, error) {
+	var extras RequestExtras
+	for _, h := range headers {
+		key, value, ok := strings.Cut(h, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return RequestExtras{}, fmt.Errorf("invalid header %q: use \"Key: Value\"", h)
+		}
+		if extras.Header == nil {
+			extras.Header = http.Header{}
+		}
+		extras.Header.Add(key, strings.TrimSpace(value))
+	}
+	if strings.TrimSpace(body) != "" {
+		if err := json.Unmarshal([]byte(body), &extras.Body); err != nil || extras.Body == nil {
+			return RequestExtras{}, fmt.Errorf("invalid extra body: must be a JSON object")
+		}
+	}
+	return extras, nil
+}
+
+// IsZero reports whether there is nothing to add.
+func (e RequestExtras) IsZero() bool {
+	return len(e.Header) == 0 && len(e.Body) == 0
+}
+
+// WithDefaultHeaders returns e with header's values for the keys e does not
+// set.
+func (e RequestExtras) WithDefaultHeaders(header http.Header) RequestExtras {
+	merged := http.Header{}
+	for key, values := range header {
+		merged[key] = values
+	}
+	for key, values := range e.Header {
+		merged[http.CanonicalHeaderKey(key)] = values
+	}
+	return RequestExtras{Header: merged, Body: e.Body}
+}
+
+// Client returns a copy of client whose requests carry the extras, or
+// client itself when there are none. The extras are added before client's
+// own transport runs, so --debug-api logs the request as sent. A nil client
+// stands for http.DefaultClient.
+func (e RequestExtras) Client(client *http.Client) *http.Client {
+	if e.IsZero() {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = &extrasTransport{base: client.Transport, extras: e}
+	return &wrapped
+}
+
+// extrasTransport adds RequestExtras to each request.
+type extrasTransport struct {
+	base   http.RoundTripper // nil means http.DefaultTransport
+	extras RequestExtras
+}
+
+func (t *extrasTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.extras.Header {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	if len(t.extras.Body) > 0 && req.Body != nil && req.Method == http.MethodPost {
+		if err := t.mergeBody(req); err != nil {
+			return nil, err
+		}
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// mergeBody sets the extra fields in a JSON object body, replacing fields
+// of the same name. Other bodies are sent unchanged.
+func (t *extrasTransport) mergeBody(req *http.Request) error {
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil && fields != nil {
+		for key, value := range t.extras.Body {
+			fields[key] = value
+		}
+		if merged, err := json.Marshal(fields); err == nil {
+			data = merged
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package providers
+
+import "testing"
+
+func TestParseRequestExtras(t *testing.T) {
+	extras, err := ParseRequestExtras([]string{"x-title:  My Tool ", "X-Tag: a", "X-Tag: b"}, `{"provider": {"sort": "price"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extras.Header.Get("X-Title"); got != "My Tool" {
+		t.Errorf("X-Title = %q", got)
+	}
+	if got := extras.Header.Values("X-Tag"); len(got) != 2 {
+		t.Errorf("X-Tag = %q, want both values", got)
+	}
+	if string(extras.Body["provider"]) != `{"sort": "price"}` {
+		t.Errorf("body = %s", extras.Body)
+	}
+
+	for _, bad := range []string{"X-Title", ": value", "Bad Key: value"} {
+		if _, err := ParseRequestExtras([]string{bad}, ""); err == nil {
+			t.Errorf("header %q should be rejected", bad)
+		}
+	}
+	for _, bad := range []string{`[1, 2]`, `"text"`, `{"a":`, `null`} {
+		if _, err := ParseRequestExtras(nil, bad); err == nil {
+			t.Errorf("extra body %s should be rejected", bad)
+		}
+	}
+	if extras, err := ParseRequestExtras(nil, ""); err != nil || !extras.IsZero() {
+		t.Errorf("no extras = %+v, %v", extras, err)
+	}
+}
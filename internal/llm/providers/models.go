@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// OllamaBaseURL is the OpenAI-compatible endpoint of a local Ollama server,
+// the default base URL of the "ollama" provider type.
+const OllamaBaseURL = "http://localhost:11434/v1"
+
+// ListModels returns the model IDs the endpoint offers, sorted. It is the
+// cheapest authenticated request the OpenAI and Anthropic protocols have, so
+// it doubles as an API key check. Ollama lists its pulled models from
+// /api/tags, next to the /v1 endpoint, and Azure OpenAI lists the base
+// models of the resource, not its deployments. An empty baseURL uses the protocol's
+// default endpoint, and a nil client uses http.DefaultClient.
+func ListModels(ctx context.Context, client *http.Client, protocol, baseURL, apiKey string) ([]string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var url string
+	header := http.Header{}
+	switch strings.ToLower(protocol) {
+	case "anthropic":
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		url = strings.TrimSuffix(baseURL, "/") + "/v1/models"
+		header.Set("x-api-key", apiKey)
+		header.Set("anthropic-version", "2023-06-01")
+	case "openai", "openrouter":
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+			if strings.EqualFold(protocol, "openrouter") {
+				baseURL = OpenRouterBaseURL
+			}
+		}
+		url = strings.TrimSuffix(baseURL, "/") + "/models"
+		header.Set("Authorization", "Bearer "+apiKey)
+	case "ollama":
+		if baseURL == "" {
+			baseURL = OllamaBaseURL
+		}
+		url = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1") + "/api/tags"
+	case "azure":
+		if baseURL == "" {
+			return nil, fmt.Errorf("azure needs the resource endpoint as base URL")
+		}
+		url = azureResource(baseURL) + "/openai/models?api-version=" + AzureAPIVersion
+		header.Set("api-key", apiKey)
+	default:
+		return nil, fmt.Errorf("unknown provider type: %s", protocol)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model list: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("API key rejected (HTTP %d)", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("model list request failed (HTTP %d): %s", resp.StatusCode, truncateBody(body))
+	}
+
+	// OpenAI and Anthropic send {"data": [{"id"}]}, Ollama {"models": [{"name"}]}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse model list: %w", err)
+	}
+	ids := make([]string, 0, len(list.Data)+len(list.Models))
+	for _, m := range list.Data {
+		if m.ID != "" {
+			ids = append(ids, m.ID)
+		}
+	}
+	for _, m := range list.Models {
+		if m.Name != "" {
+			ids = append(ids, m.Name)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// CheckModel lists the endpoint's models and reports an error naming the
+// available ones when model is not among them.
+func CheckModel(ctx context.Context, client *http.Client, protocol, baseURL, apiKey, model string) error {
+	ids, err := ListModels(ctx, client, protocol, baseURL, apiKey)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(ids, model) {
+		return nil
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("model %q is not available: the server has no models", model)
+	}
+	return fmt.Errorf("model %q is not available; the server has: %s", model, strings.Join(ids, ", "))
+}
+
+// truncateBody shortens an error response for display.
+func truncateBody(body []byte) string {
+	const maxLen = 200
+	s := strings.TrimSpace(string(body))
+	if len(s) > maxLen {
+		s = s[:maxLen] + "..."
+	}
+	return s
+}
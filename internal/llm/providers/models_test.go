@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			if r.Header.Get("Authorization") != "Bearer good" && r.Header.Get("x-api-key") != "good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": [{"id": "model-b"}, {"id": "model-a"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// OpenAI base URLs include /v1; Anthropic ones do not
+	models, err := ListModels(context.Background(), server.Client(), "openai", server.URL+"/v1", "good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(models, ",") != "model-a,model-b" {
+		t.Errorf("models = %v", models)
+	}
+	if _, err := ListModels(context.Background(), server.Client(), "anthropic", server.URL, "good"); err != nil {
+		t.Errorf("anthropic: %v", err)
+	}
+
+	_, err = ListModels(context.Background(), server.Client(), "openai", server.URL+"/v1", "bad")
+	if err == nil || !strings.Contains(err.Error(), "API key rejected") {
+		t.Errorf("bad key error = %v", err)
+	}
+	_, err = ListModels(context.Background(), server.Client(), "openai", server.URL, "good")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("wrong base URL error = %v", err)
+	}
+	if _, err := ListModels(context.Background(), nil, "gopher", "", ""); err == nil {
+		t.Error("unknown protocol should fail")
+	}
+}
+
+func TestListModelsOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"models": [{"name": "qwen3:8b"}, {"name": "gpt-oss:20b"}]}`))
+	}))
+	defer server.Close()
+
+	// Both the /v1 endpoint and the server root find the tags
+	for _, base := range []string{server.URL + "/v1", server.URL + "/v1/", server.URL} {
+		models, err := ListModels(context.Background(), server.Client(), "ollama", base, "")
+		if err != nil {
+			t.Fatalf("%s: %v", base, err)
+		}
+		if strings.Join(models, ",") != "gpt-oss:20b,qwen3:8b" {
+			t.Errorf("%s: models = %v", base, models)
+		}
+	}
+
+	if err := CheckModel(context.Background(), server.Client(), "ollama", server.URL+"/v1", "", "qwen3:8b"); err != nil {
+		t.Errorf("CheckModel(qwen3:8b) = %v", err)
+	}
+	err := CheckModel(context.Background(), server.Client(), "ollama", server.URL+"/v1", "", "llama3")
+	if err == nil || !strings.Contains(err.Error(), `"llama3" is not available; the server has: gpt-oss:20b, qwen3:8b`) {
+		t.Errorf("CheckModel(llama3) = %v", err)
+	}
+}
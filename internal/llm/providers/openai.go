@@ -18,6 +18,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -37,6 +38,7 @@ type openAIStreamState struct {
 	toolCallArgs     map[int]*strings.Builder // tool call index -> arguments builder
 	toolCalls        []llm.ToolCallPart
 	usage            llm.Usage
+	truncated        bool // finish_reason was "length"
 }
 
 func (s *openAIStreamState) addTextDelta(delta string) {
@@ -152,12 +154,25 @@ func (s *openAIStreamState) getUsage() llm.Usage {
 	return s.usage
 }
 
+func (s *openAIStreamState) setTruncated() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.truncated = true
+}
+
+func (s *openAIStreamState) isTruncated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.truncated
+}
+
 // OpenAIProvider implements the OpenAI API
 type OpenAIProvider struct {
-	apiKey  string
-	baseURL string
-	client  *http.Client
-	model   string
+	apiKey   string
+	baseURL  string
+	client   *http.Client
+	model    string
+	sampling llm.SamplingOptions
 }
 
 // OpenAIOption configures the provider
@@ -207,15 +222,24 @@ func WithOpenAIModel(model string) OpenAIOption {
 	}
 }
 
+// WithOpenAISampling sets temperature, top_p, max output tokens, and reasoning effort
+func WithOpenAISampling(sampling llm.SamplingOptions) OpenAIOption {
+	return func(p *OpenAIProvider) {
+		p.sampling = sampling
+	}
+}
+
 // openAIRequest represents the OpenAI API request
 type openAIRequest struct {
-	Model         string               `json:"model"`
-	Messages      []openAIMessage      `json:"messages"`
-	Tools         []openAITool         `json:"tools,omitempty"`
-	Stream        bool                 `json:"stream"`
-	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
-	MaxTokens     int                  `json:"max_tokens,omitempty"`
-	Temperature   float64              `json:"temperature,omitempty"`
+	Model           string               `json:"model"`
+	Messages        []openAIMessage      `json:"messages"`
+	Tools           []openAITool         `json:"tools,omitempty"`
+	Stream          bool                 `json:"stream"`
+	StreamOptions   *openAIStreamOptions `json:"stream_options,omitempty"`
+	MaxTokens       int                  `json:"max_tokens,omitempty"`
+	Temperature     *float64             `json:"temperature,omitempty"`
+	TopP            *float64             `json:"top_p,omitempty"`
+	ReasoningEffort string               `json:"reasoning_effort,omitempty"`
 }
 
 type openAIStreamOptions struct {
@@ -307,6 +331,10 @@ func (p *OpenAIProvider) StreamMessages(
 		StreamOptions: &openAIStreamOptions{
 			IncludeUsage: true,
 		},
+		MaxTokens:       p.sampling.MaxOutputTokens,
+		Temperature:     p.sampling.Temperature,
+		TopP:            p.sampling.TopP,
+		ReasoningEffort: p.sampling.ReasoningEffort,
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -383,6 +411,8 @@ func (p *OpenAIProvider) convertToolResults(content []llm.ContentPart) []openAIM
 		switch out := tr.Output.(type) {
 		case llm.ToolResultOutputText:
 			apiMsg.Content = out.Text
+		case llm.ToolResultOutputData:
+			apiMsg.Content = string(out.Data)
 		case llm.ToolResultOutputError:
 			apiMsg.Content = out.Error
 		}
@@ -437,6 +467,13 @@ func (p *OpenAIProvider) convertRegularContent(apiMsg *openAIMessage, content []
 				"type": "text",
 				"text": v.Text,
 			})
+		case llm.ImagePart:
+			contentParts = append(contentParts, map[string]interface{}{
+				"type": "image_url",
+				"image_url": map[string]string{
+					"url": "data:" + v.MediaType + ";base64," + base64.StdEncoding.EncodeToString(v.Data),
+				},
+			})
 		case llm.ReasoningPart:
 			// Accumulate reasoning content
 			reasoningText += v.Text
@@ -448,11 +485,11 @@ func (p *OpenAIProvider) convertRegularContent(apiMsg *openAIMessage, content []
 		apiMsg.ReasoningContent = reasoningText
 	}
 
-	switch len(contentParts) {
-	case 1:
+	switch {
+	case len(contentParts) == 1 && contentParts[0]["type"] == "text":
 		// Single text part - use simple string
 		apiMsg.Content = contentParts[0]["text"]
-	case 0:
+	case len(contentParts) == 0:
 		// No content parts
 	default:
 		apiMsg.Content = contentParts
@@ -501,8 +538,9 @@ func (p *OpenAIProvider) parseStream(reader io.Reader, eventChan chan<- llm.Stre
 
 	// Send final StepCompleteEvent with accumulated message
 	eventChan <- llm.StepCompleteEvent{
-		Messages: []llm.Message{state.getMessage()},
-		Usage:    state.getUsage(),
+		Messages:  []llm.Message{state.getMessage()},
+		Usage:     state.getUsage(),
+		Truncated: state.isTruncated(),
 	}
 }
 
@@ -518,8 +556,11 @@ func (p *OpenAIProvider) handleEvent(data string, eventChan chan<- llm.StreamEve
 			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 		Usage struct {
-			PromptTokens     int `json:"prompt_tokens"`
-			CompletionTokens int `json:"completion_tokens"`
+			PromptTokens        int `json:"prompt_tokens"`
+			CompletionTokens    int `json:"completion_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
 		} `json:"usage"`
 	}
 
@@ -539,6 +580,9 @@ func (p *OpenAIProvider) handleEvent(data string, eventChan chan<- llm.StreamEve
 			choice.FinishReason != "length" && choice.FinishReason != "tool_calls" {
 			return fmt.Errorf("stream finished with unexpected reason: %s", choice.FinishReason)
 		}
+		if choice.FinishReason == "length" {
+			state.setTruncated()
+		}
 
 		// Handle reasoning content (DeepSeek, Qwen, etc.)
 		if choice.Delta.ReasoningContent != "" {
@@ -568,8 +612,9 @@ func (p *OpenAIProvider) handleEvent(data string, eventChan chan<- llm.StreamEve
 	// Track usage if available (may come in a chunk with empty choices)
 	if streamResp.Usage.PromptTokens > 0 || streamResp.Usage.CompletionTokens > 0 {
 		state.setUsage(llm.Usage{
-			InputTokens:  int64(streamResp.Usage.PromptTokens),
-			OutputTokens: int64(streamResp.Usage.CompletionTokens),
+			InputTokens:     int64(streamResp.Usage.PromptTokens),
+			OutputTokens:    int64(streamResp.Usage.CompletionTokens),
+			CacheReadTokens: int64(streamResp.Usage.PromptTokensDetails.CachedTokens),
 		})
 	}
 
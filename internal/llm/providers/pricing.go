@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// Price is the cost of a model in USD per million tokens. Cache prices left
+// at zero are charged at the input price.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+// Cost returns the USD cost of usage. Cache tokens are part of InputTokens,
+// so they are taken out of it before charging the input price.
+func (p Price) Cost(usage llm.Usage) float64 {
+	cacheRead, cacheWrite := p.CacheRead, p.CacheWrite
+	if cacheRead == 0 {
+		cacheRead = p.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+	uncached := max(usage.InputTokens-usage.CacheReadTokens-usage.CacheCreationTokens, 0)
+	return (float64(uncached)*p.Input +
+		float64(usage.CacheReadTokens)*cacheRead +
+		float64(usage.CacheCreationTokens)*cacheWrite +
+		float64(usage.OutputTokens)*p.Output) / 1_000_000
+}
+
+// Prices maps model name prefixes to their price. Like the context window
+// table, the longest matching prefix wins.
+type Prices map[string]Price
+
+// DefaultPrices lists the published list prices of common hosted models.
+// Providers change them from time to time; --pricing-file overrides them.
+var DefaultPrices = Prices{
+	"gpt-4o":            {Input: 2.50, Output: 10.00, CacheRead: 1.25},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+	"gpt-4.1":           {Input: 2.00, Output: 8.00, CacheRead: 0.50},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+	"gpt-4.1-nano":      {Input: 0.10, Output: 0.40, CacheRead: 0.025},
+	"gpt-5":             {Input: 1.25, Output: 10.00, CacheRead: 0.125},
+	"gpt-5-mini":        {Input: 0.25, Output: 2.00, CacheRead: 0.025},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.40, CacheRead: 0.005},
+	"o1":                {Input: 15.00, Output: 60.00, CacheRead: 7.50},
+	"o3":                {Input: 2.00, Output: 8.00, CacheRead: 0.50},
+	"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55},
+	"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+	"claude-opus-4":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
+	"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00},
+	"claude-haiku-4-5":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25},
+	"deepseek-chat":     {Input: 0.27, Output: 1.10, CacheRead: 0.07},
+	"deepseek-reasoner": {Input: 0.55, Output: 2.19, CacheRead: 0.14},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10.00, CacheRead: 0.31},
+	"gemini-2.5-flash":  {Input: 0.30, Output: 2.50, CacheRead: 0.075},
+}
+
+// Lookup returns the price of a model. Provider prefixes such as "openai/"
+// are ignored. It reports false for models not in the table, whose cost is
+// unknown rather than zero.
+func (p Prices) Lookup(model string) (Price, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	best, found := 0, false
+	var price Price
+	for prefix, pr := range p {
+		if len(prefix) > best && strings.HasPrefix(name, strings.ToLower(prefix)) {
+			best, price, found = len(prefix), pr, true
+		}
+	}
+	return price, found
+}
+
+// LoadPrices returns DefaultPrices with the entries of the JSON file at path
+// added or replaced, e.g. {"my-model": {"input": 0.5, "output": 1.5}}. An
+// empty path returns the defaults.
+func LoadPrices(path string) (Prices, error) {
+	prices := maps.Clone(DefaultPrices)
+	if path == "" {
+		return prices, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+	var custom Prices
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing file %s: %w", path, err)
+	}
+	for model, price := range custom {
+		if price.Input < 0 || price.Output < 0 || price.CacheRead < 0 || price.CacheWrite < 0 {
+			return nil, fmt.Errorf("pricing file %s: negative price for %q", path, model)
+		}
+		prices[model] = price
+	}
+	return prices, nil
+}
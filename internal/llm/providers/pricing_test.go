@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestPricesLookup(t *testing.T) {
+	tests := []struct {
+		model string
+		want  float64 // input price
+		found bool
+	}{
+		{"gpt-4o", 2.50, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"openai/gpt-4.1-mini", 0.40, true},
+		{"claude-sonnet-4-5", 3.00, true},
+		{"Claude-Opus-4-5-20251101", 5.00, true},
+		{"gpt-oss:20b", 0, false},
+	}
+	for _, tt := range tests {
+		price, found := DefaultPrices.Lookup(tt.model)
+		if found != tt.found || price.Input != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v; want input %v, %v", tt.model, price, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	price := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	usage := llm.Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 500_000, CacheCreationTokens: 100_000}
+	// 400k uncached input, 500k cache reads, 100k cache writes, 100k output
+	want := 1.2 + 0.15 + 0.375 + 1.5
+	if got := price.Cost(usage); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+
+	// Without cache prices, cached tokens cost as much as input
+	plain := Price{Input: 1, Output: 2}
+	if got := plain.Cost(llm.Usage{InputTokens: 1_000_000, CacheReadTokens: 400_000}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Cost without cache prices = %v, want 1", got)
+	}
+}
+
+func TestLoadPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	data := `{"my-model": {"input": 0.5, "output": 1.5}, "gpt-4o": {"input": 2, "output": 8}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := LoadPrices(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := prices.Lookup("my-model-v2"); !ok || p.Output != 1.5 {
+		t.Errorf("custom model = %v, %v", p, ok)
+	}
+	if p, _ := prices.Lookup("gpt-4o"); p.Input != 2 {
+		t.Errorf("file should override gpt-4o, got %v", p)
+	}
+	if p, _ := DefaultPrices.Lookup("gpt-4o"); p.Input != 2.50 {
+		t.Error("LoadPrices must not modify DefaultPrices")
+	}
+	if _, ok := prices.Lookup("claude-sonnet-4"); !ok {
+		t.Error("defaults should remain")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"bad": {"input": -1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrices(path); err == nil {
+		t.Error("negative prices should be rejected")
+	}
+	if _, err := LoadPrices(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("a missing file should be an error")
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/alayacore/alayacore/internal/llm"
@@ -651,12 +653,14 @@ func TestAnthropicValidStopReasons(t *testing.T) {
 			// Collect events - should NOT get an error
 			var gotError bool
 			var gotStepComplete bool
+			var truncated bool
 			for event := range eventChan {
 				if _, ok := event.(llm.StreamErrorEvent); ok {
 					gotError = true
 				}
-				if _, ok := event.(llm.StepCompleteEvent); ok {
+				if e, ok := event.(llm.StepCompleteEvent); ok {
 					gotStepComplete = true
+					truncated = e.Truncated
 				}
 			}
 
@@ -666,6 +670,9 @@ func TestAnthropicValidStopReasons(t *testing.T) {
 			if !gotStepComplete {
 				t.Errorf("Expected StepCompleteEvent for valid stop reason '%s'", reason)
 			}
+			if truncated != (reason == "max_tokens") {
+				t.Errorf("Truncated = %v for stop reason '%s'", truncated, reason)
+			}
 		})
 	}
 }
@@ -747,13 +754,14 @@ func TestOpenAILengthFinishReason(t *testing.T) {
 
 	// Collect events - should NOT get an error
 	var gotError bool
+	var step llm.StepCompleteEvent
 	var gotStepComplete bool
 	for event := range eventChan {
 		if _, ok := event.(llm.StreamErrorEvent); ok {
 			gotError = true
 		}
-		if _, ok := event.(llm.StepCompleteEvent); ok {
-			gotStepComplete = true
+		if e, ok := event.(llm.StepCompleteEvent); ok {
+			step, gotStepComplete = e, true
 		}
 	}
 
@@ -763,6 +771,9 @@ func TestOpenAILengthFinishReason(t *testing.T) {
 	if !gotStepComplete {
 		t.Error("Expected StepCompleteEvent for 'length' finish reason")
 	}
+	if !step.Truncated {
+		t.Error("Expected the step to be marked truncated for 'length' finish reason")
+	}
 }
 
 func TestOpenAIAPIError(t *testing.T) {
@@ -1507,3 +1518,225 @@ func TestAnthropicToolResultError(t *testing.T) {
 		}
 	}
 }
+
+func TestSamplingOptionsInRequest(t *testing.T) {
+	var lastRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	temperature, topP := 0.0, 0.9
+	provider, err := providers.NewOpenAI(
+		providers.WithOpenAIAPIKey("test-key"),
+		providers.WithOpenAIBaseURL(server.URL),
+		providers.WithOpenAISampling(llm.SamplingOptions{Temperature: &temperature, TopP: &topP, MaxOutputTokens: 256}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	eventChan, err := provider.StreamMessages(context.Background(), nil, nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	for range eventChan {
+	}
+
+	// temperature 0 must be sent explicitly, not dropped by omitempty
+	if v, ok := lastRequest["temperature"]; !ok || v.(float64) != 0 {
+		t.Errorf("temperature = %v (present=%v), want 0", v, ok)
+	}
+	if lastRequest["top_p"] != 0.9 {
+		t.Errorf("top_p = %v, want 0.9", lastRequest["top_p"])
+	}
+	if lastRequest["max_tokens"] != float64(256) {
+		t.Errorf("max_tokens = %v, want 256", lastRequest["max_tokens"])
+	}
+}
+
+func TestReasoningOptionsInRequest(t *testing.T) {
+	var lastRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	messages := []llm.Message{
+		{Role: llm.RoleUser, Content: []llm.ContentPart{llm.TextPart{Type: "text", Text: "hi"}}},
+		{Role: llm.RoleAssistant, Content: []llm.ContentPart{
+			llm.ReasoningPart{Type: "reasoning", Text: "restored, unsigned"},
+			llm.ReasoningPart{Type: "reasoning", Text: "signed", Signature: "sig"},
+			llm.TextPart{Type: "text", Text: "hello"},
+		}},
+		{Role: llm.RoleUser, Content: []llm.ContentPart{llm.TextPart{Type: "text", Text: "again"}}},
+	}
+
+	anthropic, err := providers.NewAnthropic(
+		providers.WithAPIKey("test-key"),
+		providers.WithBaseURL(server.URL),
+		providers.WithSampling(llm.SamplingOptions{ThinkingBudgetTokens: 2048}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	eventChan, err := anthropic.StreamMessages(context.Background(), messages, nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	for range eventChan {
+	}
+
+	thinking, _ := lastRequest["thinking"].(map[string]interface{})
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(2048) {
+		t.Errorf("thinking = %v, want enabled with budget 2048", lastRequest["thinking"])
+	}
+	if lastRequest["max_tokens"] != float64(4096+2048) {
+		t.Errorf("max_tokens = %v, want default plus thinking budget", lastRequest["max_tokens"])
+	}
+	assistant := lastRequest["messages"].([]interface{})[1].(map[string]interface{})
+	blocks := assistant["content"].([]interface{})
+	if len(blocks) != 2 {
+		t.Fatalf("expected unsigned thinking block to be dropped, got %v", blocks)
+	}
+	if first := blocks[0].(map[string]interface{}); first["signature"] != "sig" {
+		t.Errorf("thinking signature = %v, want sig", first["signature"])
+	}
+
+	openai, err := providers.NewOpenAI(
+		providers.WithOpenAIAPIKey("test-key"),
+		providers.WithOpenAIBaseURL(server.URL),
+		providers.WithOpenAISampling(llm.SamplingOptions{ReasoningEffort: "high"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	eventChan, err = openai.StreamMessages(context.Background(), nil, nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	for range eventChan {
+	}
+	if lastRequest["reasoning_effort"] != "high" {
+		t.Errorf("reasoning_effort = %v, want high", lastRequest["reasoning_effort"])
+	}
+}
+
+func TestImagePartsInRequest(t *testing.T) {
+	var lastRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	messages := []llm.Message{
+		{Role: llm.RoleUser, Content: []llm.ContentPart{
+			llm.TextPart{Type: "text", Text: "what is this?"},
+			llm.ImagePart{Type: "image", Name: "shot.png", MediaType: "image/png", Data: []byte("png")},
+		}},
+	}
+
+	anthropic, err := providers.NewAnthropic(
+		providers.WithAPIKey("test-key"),
+		providers.WithBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	eventChan, err := anthropic.StreamMessages(context.Background(), messages, nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	for range eventChan {
+	}
+
+	user := lastRequest["messages"].([]interface{})[0].(map[string]interface{})
+	blocks := user["content"].([]interface{})
+	if len(blocks) != 2 {
+		t.Fatalf("expected text and image blocks, got %v", blocks)
+	}
+	image := blocks[1].(map[string]interface{})
+	source, _ := image["source"].(map[string]interface{})
+	if image["type"] != "image" || source["media_type"] != "image/png" || source["data"] != "cG5n" {
+		t.Errorf("image block = %v, want base64 png source", image)
+	}
+
+	openai, err := providers.NewOpenAI(
+		providers.WithOpenAIAPIKey("test-key"),
+		providers.WithOpenAIBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	eventChan, err = openai.StreamMessages(context.Background(), messages, nil, "", "")
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	for range eventChan {
+	}
+
+	user = lastRequest["messages"].([]interface{})[0].(map[string]interface{})
+	parts := user["content"].([]interface{})
+	if len(parts) != 2 {
+		t.Fatalf("expected text and image parts, got %v", parts)
+	}
+	imageURL, _ := parts[1].(map[string]interface{})["image_url"].(map[string]interface{})
+	if imageURL["url"] != "data:image/png;base64,cG5n" {
+		t.Errorf("image_url = %v, want png data URL", imageURL)
+	}
+}
+
+func TestStructuredToolResultIsSentAsJSON(t *testing.T) {
+	const data = `{"path":"main.go","bytes":1234}`
+	messages := []llm.Message{
+		llm.NewUserMessage("write it"),
+		llm.NewAssistantMessage([]llm.ContentPart{llm.ToolCallPart{
+			Type: "tool_use", ToolCallID: "call1", ToolName: "write_file", Input: json.RawMessage(`{}`),
+		}}),
+		{Role: llm.RoleTool, Content: []llm.ContentPart{llm.ToolResultPart{
+			Type: "tool_result", ToolCallID: "call1",
+			Output: llm.ToolResultOutputData{Type: "data", Kind: "file", Data: json.RawMessage(data)},
+		}}},
+	}
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	for name, newProvider := range map[string]func() (llm.Provider, error){
+		"openai": func() (llm.Provider, error) {
+			return providers.NewOpenAI(providers.WithOpenAIAPIKey("k"), providers.WithOpenAIBaseURL(server.URL))
+		},
+		"anthropic": func() (llm.Provider, error) {
+			return providers.NewAnthropic(providers.WithAPIKey("k"), providers.WithBaseURL(server.URL))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider, err := newProvider()
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := provider.StreamMessages(context.Background(), messages, nil, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range events {
+			}
+			quoted, _ := json.Marshal(data)
+			if !strings.Contains(body, `"content":`+string(quoted)) {
+				t.Errorf("request does not carry the result as compact JSON text: %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// interruptedStreamErrors are fragments of errors from a connection dropped
+// mid-stream that do not keep their type on the way up:
+//   - HTTP/2: "stream error: stream ID 3; INTERNAL_ERROR"
+//   - Anthropic: an "overloaded_error" event in the middle of a stream
+var interruptedStreamErrors = []string{
+	"unexpected eof",
+	"connection reset",
+	"broken pipe",
+	"stream error",
+	"overloaded",
+}
+
+// IsInterruptedStreamError reports whether err is a response stream cut off
+// by the network or an overloaded server, rather than rejected: sending the
+// request again can succeed.
+func IsInterruptedStreamError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.Is(err, llm.ErrIncompleteResponse) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range interruptedStreamErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestIsInterruptedStreamError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{io.ErrUnexpectedEOF, true},
+		{fmt.Errorf("read body: %w", syscall.ECONNRESET), true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}, true},
+		{llm.ErrIncompleteResponse, true},
+		{errors.New("stream error: stream ID 3; INTERNAL_ERROR; received from peer"), true},
+		{errors.New("API error: Overloaded"), true},
+		{errors.New(`API error (status 401): {"error":{"message":"Incorrect API key provided"}}`), false},
+		{errors.New("content blocked by safety filter"), false},
+		{llm.ErrEmptyResponse, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsInterruptedStreamError(tt.err); got != tt.want {
+			t.Errorf("IsInterruptedStreamError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
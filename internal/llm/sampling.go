@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SamplingOptions holds optional generation parameters passed to providers.
+// Nil pointers and zero or empty values mean "use the provider default".
+type SamplingOptions struct {
+	Temperature     *float64
+	TopP            *float64
+	MaxOutputTokens int
+
+	// ReasoningEffort is sent as reasoning_effort (OpenAI-compatible providers only)
+	ReasoningEffort string
+	// ThinkingBudgetTokens enables extended thinking (Anthropic only)
+	ThinkingBudgetTokens int
+}
+
+// Sampling option keys accepted by Set
+const (
+	SamplingTemperature     = "temperature"
+	SamplingTopP            = "top_p"
+	SamplingMaxOutputTokens = "max_output_tokens"
+	SamplingReasoningEffort = "reasoning_effort"
+	SamplingThinkingBudget  = "thinking_budget_tokens"
+)
+
+// MinThinkingBudgetTokens is the smallest thinking budget Anthropic accepts
+const MinThinkingBudgetTokens = 1024
+
+// ValidateTemperature rejects negative and non-finite temperatures
+func ValidateTemperature(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("temperature must be a finite number, got %g", v)
+	}
+	if v < 0 {
+		return fmt.Errorf("temperature must be >= 0, got %g", v)
+	}
+	return nil
+}
+
+// ValidateTopP rejects top_p outside (0, 1], and NaN
+func ValidateTopP(v float64) error {
+	if math.IsNaN(v) || v <= 0 || v > 1 {
+		return fmt.Errorf("top_p must be in (0, 1], got %g", v)
+	}
+	return nil
+}
+
+// ValidateMaxOutputTokens rejects non-positive token limits
+func ValidateMaxOutputTokens(v int) error {
+	if v <= 0 {
+		return fmt.Errorf("max_output_tokens must be > 0, got %d", v)
+	}
+	return nil
+}
+
+// ValidateReasoningEffort accepts low, medium, or high
+func ValidateReasoningEffort(v string) error {
+	switch v {
+	case "low", "medium", "high":
+		return nil
+	}
+	return fmt.Errorf("reasoning_effort must be low, medium, or high, got %q", v)
+}
+
+// ValidateThinkingBudgetTokens rejects budgets below MinThinkingBudgetTokens
+func ValidateThinkingBudgetTokens(v int) error {
+	if v < MinThinkingBudgetTokens {
+		return fmt.Errorf("thinking_budget_tokens must be >= %d, got %d", MinThinkingBudgetTokens, v)
+	}
+	return nil
+}
+
+// Set parses and validates value for key. The value "default" clears the option.
+func (o *SamplingOptions) Set(key, value string) error {
+	value = strings.TrimSpace(value)
+	reset := value == "default"
+
+	switch key {
+	case SamplingTemperature:
+		if reset {
+			o.Temperature = nil
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid temperature %q: expected a number", value)
+		}
+		if err := ValidateTemperature(v); err != nil {
+			return err
+		}
+		o.Temperature = &v
+	case SamplingTopP:
+		if reset {
+			o.TopP = nil
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid top_p %q: expected a number", value)
+		}
+		if err := ValidateTopP(v); err != nil {
+			return err
+		}
+		o.TopP = &v
+	case SamplingMaxOutputTokens:
+		if reset {
+			o.MaxOutputTokens = 0
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid max_output_tokens %q: expected an integer", value)
+		}
+		if err := ValidateMaxOutputTokens(v); err != nil {
+			return err
+		}
+		o.MaxOutputTokens = v
+	case SamplingReasoningEffort:
+		if reset {
+			o.ReasoningEffort = ""
+			return nil
+		}
+		if err := ValidateReasoningEffort(value); err != nil {
+			return err
+		}
+		o.ReasoningEffort = value
+	case SamplingThinkingBudget:
+		if reset {
+			o.ThinkingBudgetTokens = 0
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid thinking_budget_tokens %q: expected an integer", value)
+		}
+		if err := ValidateThinkingBudgetTokens(v); err != nil {
+			return err
+		}
+		o.ThinkingBudgetTokens = v
+	default:
+		return fmt.Errorf("unknown setting %q (expected %s, %s, %s, %s, or %s)",
+			key, SamplingTemperature, SamplingTopP, SamplingMaxOutputTokens,
+			SamplingReasoningEffort, SamplingThinkingBudget)
+	}
+	return nil
+}
+
+// String formats the options for display, showing "default" for unset values
+func (o SamplingOptions) String() string {
+	format := func(v *float64) string {
+		if v == nil {
+			return "default"
+		}
+		return strconv.FormatFloat(*v, 'g', -1, 64)
+	}
+	formatInt := func(v int) string {
+		if v <= 0 {
+			return "default"
+		}
+		return strconv.Itoa(v)
+	}
+	effort := "default"
+	if o.ReasoningEffort != "" {
+		effort = o.ReasoningEffort
+	}
+	return fmt.Sprintf("%s=%s %s=%s %s=%s %s=%s %s=%s",
+		SamplingTemperature, format(o.Temperature),
+		SamplingTopP, format(o.TopP),
+		SamplingMaxOutputTokens, formatInt(o.MaxOutputTokens),
+		SamplingReasoningEffort, effort,
+		SamplingThinkingBudget, formatInt(o.ThinkingBudgetTokens))
+}
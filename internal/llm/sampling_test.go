@@ -0,0 +1,57 @@
+package llm
+
+import "testing"
+
+func TestSamplingOptionsSet(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{SamplingTemperature, "0", false},
+		{SamplingTemperature, "1.5", false},
+		{SamplingTemperature, "-0.1", true},
+		{SamplingTemperature, "hot", true},
+		{SamplingTemperature, "NaN", true},
+		{SamplingTemperature, "Inf", true},
+		{SamplingTemperature, "-Inf", true},
+		{SamplingTopP, "1", false},
+		{SamplingTopP, "0.9", false},
+		{SamplingTopP, "0", true},
+		{SamplingTopP, "1.01", true},
+		{SamplingTopP, "NaN", true},
+		{SamplingTopP, "+Inf", true},
+		{SamplingTopP, "-Inf", true},
+		{SamplingMaxOutputTokens, "1024", false},
+		{SamplingMaxOutputTokens, "0", true},
+		{SamplingMaxOutputTokens, "1.5", true},
+		{SamplingReasoningEffort, "high", false},
+		{SamplingReasoningEffort, "max", true},
+		{SamplingThinkingBudget, "2048", false},
+		{SamplingThinkingBudget, "512", true},
+		{"top_k", "40", true},
+	}
+
+	for _, tt := range tests {
+		var o SamplingOptions
+		err := o.Set(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSamplingOptionsZeroTemperatureIsSet(t *testing.T) {
+	var o SamplingOptions
+	if err := o.Set(SamplingTemperature, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if o.Temperature == nil || *o.Temperature != 0 {
+		t.Error("temperature 0 must be distinguishable from unset")
+	}
+	if err := o.Set(SamplingTemperature, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if o.Temperature != nil {
+		t.Error("default should clear temperature")
+	}
+}
@@ -31,12 +31,23 @@ func (TextPart) isContentPart() {}
 
 // ReasoningPart represents reasoning/thinking content
 type ReasoningPart struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"` // Anthropic thinking signature, echoed back on later turns
 }
 
 func (ReasoningPart) isContentPart() {}
 
+// ImagePart represents an image attached to a user message
+type ImagePart struct {
+	Type      string `json:"type"`
+	Name      string `json:"name,omitempty"` // original file name, for display only
+	MediaType string `json:"media_type"`     // e.g. "image/png"
+	Data      []byte `json:"data"`           // raw image bytes
+}
+
+func (ImagePart) isContentPart() {}
+
 // ToolCallPart represents a tool call
 type ToolCallPart struct {
 	Type       string          `json:"type"`
@@ -77,6 +88,17 @@ type ToolResultOutputError struct {
 
 func (ToolResultOutputError) isToolResultOutput() {}
 
+// ToolResultOutputData represents structured output of a kind such as
+// "json", "file", or "table". Data is compact JSON: the model gets it as
+// is, and adaptors render it by kind.
+type ToolResultOutputData struct {
+	Type string          `json:"type"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (ToolResultOutputData) isToolResultOutput() {}
+
 // Message represents a single message in the conversation
 type Message struct {
 	Role    MessageRole   `json:"role"`
@@ -90,10 +112,14 @@ type ToolDefinition struct {
 	Schema      json.RawMessage `json:"schema"`
 }
 
-// Usage tracks token usage
+// Usage tracks token usage.
+// Cache counters are included in InputTokens; they are zero when the
+// provider does not report them.
 type Usage struct {
-	InputTokens  int64 `json:"input_tokens"`
-	OutputTokens int64 `json:"output_tokens"`
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
 }
 
 // StreamEvent represents a streaming event
@@ -126,8 +152,9 @@ func (ToolCallEvent) isStreamEvent() {}
 
 // StepCompleteEvent represents completion of an agentic step
 type StepCompleteEvent struct {
-	Messages []Message
-	Usage    Usage
+	Messages  []Message
+	Usage     Usage
+	Truncated bool // the response stopped at the output token limit
 }
 
 func (StepCompleteEvent) isStreamEvent() {}
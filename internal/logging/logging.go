@@ -0,0 +1,182 @@
+// Package logging builds the structured logger shared by the adaptors, the
+// sessions, the tools, and the skills manager: text or JSON records at the
+// level of --log-level, with API keys and auth tokens redacted. The terminal
+// holds its records back while the UI owns the screen (see Output).
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Values of --log-format.
+const (
+	FormatText = "text" // key=value lines, for a terminal
+	FormatJSON = "json" // one JSON object per line, for log collectors
+)
+
+// Redacted replaces sensitive values in log records.
+const Redacted = "[REDACTED]"
+
+// ParseLevel parses a --log-level value: debug, info, warn, or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q: use debug, info, warn, or error", s)
+}
+
+// New returns a logger writing records of level and above to w, as JSON
+// with FormatJSON and as text otherwise. Sensitive values are redacted.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: Redact}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Discard returns a logger that drops every record, for callers that were
+// given none.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// OrDiscard returns l, or a logger that drops every record when l is nil.
+func OrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Discard()
+	}
+	return l
+}
+
+// sensitiveWords are the words of an attribute key whose value is never
+// logged, e.g. "token" in "auth_token". "input_tokens" is kept.
+var sensitiveWords = map[string]bool{
+	"apikey":        true,
+	"authorization": true,
+	"cookie":        true,
+	"credential":    true,
+	"credentials":   true,
+	"passwd":        true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
+// secretPatterns match secrets inside values such as error messages, with
+// the part to keep in the first group.
+var secretPatterns = []*regexp.Regexp{
+	// Authorization: Bearer abc, x-api-key: abc
+	regexp.MustCompile(`(?i)((?:authorization|x-api-key|api-key)["']?\s*[:=]\s*["']?(?:bearer\s+|basic\s+)?)[^\s"',]+`),
+	regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]+`),
+	// ?key=abc in the URLs of Gemini requests
+	regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|token|access_token)=)[^&\s"']+`),
+	// OpenAI and Anthropic keys
+	regexp.MustCompile(`()\bsk-[A-Za-z0-9_-]{8,}`),
+}
+
+// Redact is the slog ReplaceAttr hook of New: it replaces the value of a
+// sensitive key, such as api_key or authorization, and secrets found in
+// string and error values, such as a bearer token in an error message.
+func Redact(_ []string, a slog.Attr) slog.Attr {
+	if sensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); s != RedactString(s) {
+			return slog.String(a.Key, RedactString(s))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
+
+// RedactString replaces the secrets found in s.
+func RedactString(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+// sensitiveKey reports whether the value of an attribute key is a secret.
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "api_key") || strings.HasSuffix(key, "api-key") {
+		return true
+	}
+	for _, word := range strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if sensitiveWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// Output is a log writer that can hold records back and write them later,
+// for the terminal UI, which owns the screen while it runs. Its methods do
+// nothing on a nil Output.
+type Output struct {
+	mu   sync.Mutex
+	w    io.Writer
+	held *bytes.Buffer // records written since Hold; nil when not holding
+}
+
+// NewOutput returns an Output writing to w.
+func NewOutput(w io.Writer) *Output {
+	return &Output{w: w}
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.held != nil {
+		return o.held.Write(p)
+	}
+	return o.w.Write(p)
+}
+
+// Hold keeps the records written from now on until Release.
+func (o *Output) Hold() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.held == nil {
+		o.held = &bytes.Buffer{}
+	}
+}
+
+// Release writes the records held since Hold and writes through again.
+func (o *Output) Release() error {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	held := o.held
+	o.held = nil
+	if held == nil || held.Len() == 0 {
+		return nil
+	}
+	_, err := o.w.Write(held.Bytes())
+	return err
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactKeysAndValues(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON} {
+		var buf bytes.Buffer
+		logger := New(&buf, format, slog.LevelDebug)
+		logger.Info("request",
+			"api_key", "abc123",
+			"Authorization", "Bearer abc123",
+			"auth_token", "abc123",
+			slog.Group("provider", "x-api-key", "abc123"),
+			"input_tokens", 42,
+			"err", errors.New(`POST https://example.com/v1/models?key=abc123: 401, header "Authorization: Bearer abc123"`),
+			"note", "tried sk-ant-abc123abc123 and basic abc123",
+		)
+		got := buf.String()
+		if strings.Contains(got, "abc123") {
+			t.Errorf("%s: secret logged: %s", format, got)
+		}
+		if strings.Count(got, Redacted) != 8 {
+			t.Errorf("%s: want 8 redactions: %s", format, got)
+		}
+		if !strings.Contains(got, "42") || !strings.Contains(got, "example.com/v1/models?key=") {
+			t.Errorf("%s: redacted too much: %s", format, got)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud): expected an error")
+	}
+}
+
+func TestOutputHoldsUntilRelease(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutput(&buf)
+	logger := New(out, FormatText, slog.LevelWarn)
+
+	out.Hold()
+	logger.Warn("while the UI runs")
+	if buf.Len() != 0 {
+		t.Fatalf("held record written: %q", buf.String())
+	}
+	if err := out.Release(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("after")
+	if got := buf.String(); !strings.Contains(got, "while the UI runs") || !strings.Contains(got, "after") {
+		t.Errorf("log = %q", got)
+	}
+
+	var nilOutput *Output
+	nilOutput.Hold()
+	if err := nilOutput.Release(); err != nil {
+		t.Errorf("nil Output: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package memory keeps facts the model saves for later sessions: notes about
+// the user's environment, conventions, and prior work, stored under a key
+// with optional tags.
+//
+// The store is a single JSON file, ~/.alayacore/memory.json by default. Every
+// operation reads the file again, so sessions in other processes see each
+// other's changes, and every change replaces the file in one rename, so a
+// crash never leaves it half written. A change holds an OS lock on
+// memory.json.lock from the read to the rename, so two processes saving at
+// once both keep their entries; a reader needs no lock, since it sees either
+// the old file or the new one.
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is the number of matches Search returns for a limit of 0.
+const DefaultLimit = 5
+
+// Entry is one saved memory.
+type Entry struct {
+	Key     string    `json:"key"`
+	Content string    `json:"content"`
+	Tags    []string  `json:"tags,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// Match is an entry found by Search with its score.
+type Match struct {
+	Entry
+	Score int `json:"score"`
+}
+
+// Manager reads and writes the memory file.
+type Manager struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+// Open returns a Manager for the file at path. The file and its directory
+// are created by the first Save; a file that exists must be readable.
+func Open(path string) (*Manager, error) {
+	m := &Manager{path: path, now: time.Now}
+	if _, err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Path returns the memory file.
+func (m *Manager) Path() string {
+	return m.path
+}
+
+// Save stores content under key, replacing what the key held before.
+func (m *Manager) Save(key, content string, tags []string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return errors.New("memory key is empty")
+	}
+	if strings.TrimSpace(content) == "" {
+		return errors.New("memory content is empty")
+	}
+
+	return m.update(func(entries []Entry) ([]Entry, bool) {
+		entry := Entry{Key: key, Content: content, Tags: cleanTags(tags), Updated: m.now().UTC()}
+		if i := slices.IndexFunc(entries, func(e Entry) bool { return e.Key == key }); i >= 0 {
+			entries[i] = entry
+		} else {
+			entries = append(entries, entry)
+		}
+		return entries, true
+	})
+}
+
+// Forget removes key. It reports whether the key was saved.
+func (m *Manager) Forget(key string) (bool, error) {
+	var found bool
+	err := m.update(func(entries []Entry) ([]Entry, bool) {
+		i := slices.IndexFunc(entries, func(e Entry) bool { return e.Key == key })
+		if i < 0 {
+			return entries, false
+		}
+		found = true
+		return slices.Delete(entries, i, i+1), true
+	})
+	return found, err
+}
+
+// List returns every entry, the most recently updated first.
+func (m *Manager) List() ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(entries, func(a, b Entry) int { return b.Updated.Compare(a.Updated) })
+	return entries, nil
+}
+
+// Search returns up to limit entries matching the words of query, the best
+// first. A word in the key scores 3, a tag equal to it 2, and each time it
+// occurs in the content 1; matching ignores case. Entries that match no word
+// are left out, and ties go to the most recently updated.
+func (m *Manager) Search(query string, limit int) ([]Match, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, errors.New("memory query is empty")
+	}
+
+	m.mu.Lock()
+	entries, err := m.load()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, e := range entries {
+		if score := score(e, words); score > 0 {
+			matches = append(matches, Match{Entry: e, Score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		return b.Updated.Compare(a.Updated)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func score(e Entry, words []string) int {
+	key := strings.ToLower(e.Key)
+	content := strings.ToLower(e.Content)
+	total := 0
+	for _, w := range words {
+		if strings.Contains(key, w) {
+			total += 3
+		}
+		for _, tag := range e.Tags {
+			if tag == w {
+				total += 2
+			}
+		}
+		total += strings.Count(content, w)
+	}
+	return total
+}
+
+// cleanTags lowercases the tags and drops empty and repeated ones.
+func cleanTags(tags []string) []string {
+	var clean []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(clean, tag) {
+			clean = append(clean, tag)
+		}
+	}
+	return clean
+}
+
+// update applies change to the entries while holding the lock file, and
+// stores the result when change reports that it changed something.
+func (m *Manager) update(change func([]Entry) ([]Entry, bool)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o700); err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	lock, err := os.OpenFile(m.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to lock memory: %w", err)
+	}
+	defer lock.Close() //nolint:errcheck // closing also releases the lock
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock memory: %w", err)
+	}
+	defer unlockFile(lock) //nolint:errcheck // the close releases it anyway
+
+	entries, err := m.load()
+	if err != nil {
+		return err
+	}
+	entries, changed := change(entries)
+	if !changed {
+		return nil
+	}
+	return m.store(entries)
+}
+
+func (m *Manager) load() ([]Entry, error) {
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to read memory %s: %w", m.path, err)
+	}
+	return entries, nil
+}
+
+func (m *Manager) store(entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".memory-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after the rename
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close() //nolint:errcheck,gosec // the write error is reported
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemorySurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "memory.json")
+	m, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save("repo-layout", "Commands live in cmd/, packages in internal/", []string{"Repo", " layout ", "repo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save("test-command", "Run go test ./... from the module root", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Forget("test-command"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "repo-layout" || entries[0].Content != "Commands live in cmd/, packages in internal/" {
+		t.Fatalf("entries after restart = %+v", entries)
+	}
+	if tags := entries[0].Tags; len(tags) != 2 || tags[0] != "repo" || tags[1] != "layout" {
+		t.Errorf("tags = %q, want [repo layout]", tags)
+	}
+	if forgot, err := reopened.Forget("test-command"); err != nil || forgot {
+		t.Errorf("Forget of a forgotten key = %v, %v", forgot, err)
+	}
+
+	// Saving a key again replaces it
+	if err := reopened.Save("repo-layout", "Everything is in src/", nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = m.List()
+	if len(entries) != 1 || entries[0].Content != "Everything is in src/" {
+		t.Errorf("entries after replacing = %+v", entries)
+	}
+}
+
+func TestOpenRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("expected an error for a corrupt memory file")
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	m, err := Open(filepath.Join(t.TempDir(), "memory.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	save := func(key, content string, tags ...string) {
+		t.Helper()
+		if err := m.Save(key, content, tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("deploy", "Deploys go through the staging cluster first")               // key: 3
+	save("ci", "The CI pipeline runs deploy checks; deploy needs approval")      // content twice: 2
+	save("release", "Tag releases from main", "deploy")                          // tag: 2, newer than ci
+	save("style", "Use tabs, not spaces")                                        // no match
+	save("staging", "The staging cluster is staging.example.com", "environment") // other words only
+
+	matches, err := m.Search("Deploy", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, match := range matches {
+		keys = append(keys, match.Key)
+	}
+	want := []string{"deploy", "release", "ci"}
+	if len(keys) != len(want) {
+		t.Fatalf("matches = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("matches = %v, want %v", keys, want)
+		}
+	}
+	if matches[0].Score != 4 {
+		t.Errorf("deploy scored %d, want 4 (key and content)", matches[0].Score)
+	}
+
+	// Scores add up over the words of the query
+	matches, _ = m.Search("staging cluster", 1)
+	if len(matches) != 1 || matches[0].Key != "staging" {
+		t.Errorf("best match for two words = %+v, want staging", matches)
+	}
+
+	if _, err := m.Search("  ", 0); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
+func TestConcurrentManagersKeepEverySave(t *testing.T) {
+	// Two managers on one file stand in for two processes: neither sees the
+	// other's mutex, only the lock file
+	path := filepath.Join(t.TempDir(), "memory.json")
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const perManager = 20
+	var wg sync.WaitGroup
+	for _, m := range []*Manager{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perManager {
+				key := fmt.Sprintf("%p-%d", m, i)
+				if err := m.Save(key, "content", nil); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := a.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2*perManager {
+		t.Errorf("got %d entries, want %d", len(entries), 2*perManager)
+	}
+}
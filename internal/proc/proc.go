@@ -0,0 +1,4 @@
+// Package proc starts child processes in their own process group so they can
+// be interrupted or killed together with everything they spawned. The
+// platform-specific parts live in proc_unix.go and proc_windows.go.
+package proc
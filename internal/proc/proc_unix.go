@@ -0,0 +1,32 @@
+//go:build !windows
+
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// SetGroup makes cmd the leader of a new process group. Call before Start.
+func SetGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// Interrupt sends SIGINT to the process group led by p, falling back to p
+// alone when the group cannot be found.
+func Interrupt(p *os.Process) error {
+	if pgid, err := syscall.Getpgid(p.Pid); err == nil {
+		return syscall.Kill(-pgid, syscall.SIGINT)
+	}
+	return p.Signal(syscall.SIGINT)
+}
+
+// Kill sends SIGKILL to the process group led by p, falling back to p alone
+// when the group cannot be found.
+func Kill(p *os.Process) error {
+	if pgid, err := syscall.Getpgid(p.Pid); err == nil {
+		return syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+	return p.Kill()
+}
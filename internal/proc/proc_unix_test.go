@@ -0,0 +1,32 @@
+//go:build !windows
+
+package proc
+
+import (
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestKillStopsProcessGroup(t *testing.T) {
+	// The background sleep inherits stdout; Wait only returns once it is gone
+	cmd := exec.Command("/bin/sh", "-c", "sleep 30 & wait")
+	cmd.Stdout = io.Discard
+	SetGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	if err := Kill(cmd.Process); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process group still running after Kill")
+	}
+}
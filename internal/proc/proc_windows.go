@@ -0,0 +1,31 @@
+//go:build windows
+
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// SetGroup starts cmd in a new process group. Call before Start.
+func SetGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// Interrupt stops p and its children. Windows has no SIGINT for console-less
+// children, so this is the same as Kill.
+func Interrupt(p *os.Process) error {
+	return Kill(p)
+}
+
+// Kill terminates the process tree rooted at p, falling back to p alone when
+// taskkill is unavailable.
+func Kill(p *os.Process) error {
+	//nolint:gosec // G204: the only argument is a numeric PID
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).Run(); err == nil {
+		return nil
+	}
+	return p.Kill()
+}
@@ -0,0 +1,172 @@
+// Package prompts implements the saved prompt library used by :prompt and
+// :prompts.
+//
+// Each prompt is a Markdown file, <dir>/<name>.md, whose body is a Go
+// text/template. Optional YAML frontmatter gives a description and names the
+// arguments the template expects:
+//
+//	---
+//	description: Review a file for bugs
+//	args: [file, focus]
+//	---
+//	Review {{index .Args 0}}, paying attention to {{index .Args 1}}.
+//
+// The template sees .Args, the arguments after the prompt name, and .Input,
+// the same arguments joined by spaces.
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ext is the file extension of prompt files.
+const Ext = ".md"
+
+// DefaultDir returns the prompt library directory, ~/.alayacore/prompts.
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".alayacore", "prompts"), nil
+}
+
+// Prompt is a saved prompt template.
+type Prompt struct {
+	Name        string
+	Description string   `yaml:"description"`
+	Args        []string `yaml:"args"` // names of the required arguments, in order
+	Body        string   `yaml:"-"`
+}
+
+// Library reads prompts from a directory. Files are read on every call, so
+// edits take effect without a restart.
+type Library struct {
+	dir string
+}
+
+// NewLibrary returns the library in dir. An empty dir is an empty library.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// Dir returns the library directory.
+func (l *Library) Dir() string {
+	if l == nil {
+		return ""
+	}
+	return l.dir
+}
+
+// Names returns the prompt names, sorted.
+func (l *Library) Names() []string {
+	if l == nil || l.dir == "" {
+		return nil
+	}
+	matches, err := filepath.Glob(filepath.Join(l.dir, "*"+Ext))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(matches))
+	for _, path := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), Ext))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List loads every prompt, sorted by name. Prompts that fail to parse are
+// skipped and reported in the returned error, joined.
+func (l *Library) List() ([]Prompt, error) {
+	var list []Prompt
+	var errs []error
+	for _, name := range l.Names() {
+		p, err := l.Load(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		list = append(list, p)
+	}
+	return list, errors.Join(errs...)
+}
+
+// Load reads the named prompt.
+func (l *Library) Load(name string) (Prompt, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return Prompt{}, fmt.Errorf("invalid prompt name %q", name)
+	}
+	if l == nil || l.dir == "" {
+		return Prompt{}, fmt.Errorf("prompt %q not found", name)
+	}
+	data, err := os.ReadFile(filepath.Join(l.dir, name+Ext))
+	if errors.Is(err, os.ErrNotExist) {
+		return Prompt{}, fmt.Errorf("prompt %q not found in %s", name, l.dir)
+	}
+	if err != nil {
+		return Prompt{}, err
+	}
+	p, err := Parse(name, string(data))
+	if err != nil {
+		return Prompt{}, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// Parse parses a prompt file: optional frontmatter, then the template body.
+// The body is checked as a template here, so syntax errors surface before
+// any arguments are given.
+func Parse(name, content string) (Prompt, error) {
+	p := Prompt{Name: name, Body: content}
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		front, body, found := strings.Cut(rest, "\n---")
+		if !found {
+			return Prompt{}, errors.New("frontmatter is missing its closing ---")
+		}
+		if err := yaml.Unmarshal([]byte(front), &p); err != nil {
+			return Prompt{}, fmt.Errorf("invalid frontmatter: %w", err)
+		}
+		// Drop the rest of the closing delimiter line
+		_, body, _ = strings.Cut(body, "\n")
+		p.Name, p.Body = name, body
+	}
+	if _, err := p.template(); err != nil {
+		return Prompt{}, err
+	}
+	return p, nil
+}
+
+func (p Prompt) template() (*template.Template, error) {
+	return template.New(p.Name).Option("missingkey=error").Parse(p.Body)
+}
+
+// Expand fills in the template. Fewer arguments than the frontmatter names,
+// or an index past the last argument, is an error.
+func (p Prompt) Expand(args []string) (string, error) {
+	if len(args) < len(p.Args) {
+		return "", fmt.Errorf("prompt %s needs %d argument(s) (%s), got %d",
+			p.Name, len(p.Args), strings.Join(p.Args, ", "), len(args))
+	}
+	tmpl, err := p.template()
+	if err != nil {
+		return "", err
+	}
+	data := struct {
+		Args  []string
+		Input string
+	}{Args: args, Input: strings.Join(args, " ")}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", p.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
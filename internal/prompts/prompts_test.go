@@ -0,0 +1,118 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePrompt(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+Ext), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	p, err := Parse("review", "---\ndescription: Review a file\nargs: [file, focus]\n---\nReview {{index .Args 0}} for {{index .Args 1}}.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Description != "Review a file" || len(p.Args) != 2 {
+		t.Errorf("frontmatter = %q %v", p.Description, p.Args)
+	}
+
+	got, err := p.Expand([]string{"main.go", "races"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Review main.go for races."; got != want {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestExpandWithoutFrontmatter(t *testing.T) {
+	p, err := Parse("explain", "Explain {{.Input}} in plain words. ({{len .Args}} words)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Expand([]string{"context", "windows"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Explain context windows in plain words. (2 words)"; got != want {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestExpandMissingArgs(t *testing.T) {
+	declared, err := Parse("review", "---\nargs: [file, focus]\n---\nReview {{index .Args 0}} for {{index .Args 1}}.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = declared.Expand([]string{"main.go"})
+	if err == nil || !strings.Contains(err.Error(), "needs 2 argument(s) (file, focus), got 1") {
+		t.Errorf("Expand with a declared argument missing = %v", err)
+	}
+
+	// Without frontmatter the template itself catches the missing index
+	undeclared, err := Parse("review", "Review {{index .Args 0}}.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := undeclared.Expand(nil); err == nil || !strings.Contains(err.Error(), "index out of range") {
+		t.Errorf("Expand past the last argument = %v", err)
+	}
+
+	unknown, err := Parse("review", "Review {{.File}}.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unknown.Expand(nil); err == nil {
+		t.Error("Expand with an unknown field should fail")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"unclosed frontmatter": "---\ndescription: x\nbody",
+		"invalid frontmatter":  "---\nargs: [a\n---\nbody",
+		"invalid template":     "Review {{index .Args 0}.",
+	}
+	for name, content := range tests {
+		if _, err := Parse("p", content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "review", "---\ndescription: Review a file\n---\nReview it.")
+	writePrompt(t, dir, "broken", "{{")
+	writePrompt(t, dir, "explain", "Explain it.")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a prompt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary(dir)
+
+	if got := strings.Join(lib.Names(), ","); got != "broken,explain,review" {
+		t.Errorf("Names = %s", got)
+	}
+
+	list, err := lib.List()
+	if err == nil || !strings.Contains(err.Error(), "prompt broken") {
+		t.Errorf("List error = %v, want the broken prompt reported", err)
+	}
+	if len(list) != 2 || list[0].Name != "explain" || list[1].Description != "Review a file" {
+		t.Errorf("List = %+v", list)
+	}
+
+	if _, err := lib.Load("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Load(missing) = %v", err)
+	}
+	if _, err := lib.Load("../review"); err == nil || !strings.Contains(err.Error(), "invalid prompt name") {
+		t.Errorf("Load(../review) = %v", err)
+	}
+}
@@ -2,26 +2,53 @@ package skills
 
 import (
 	"fmt"
-	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-)
+	"sync"
 
-// warnWriter is where warnings are written. Can be set to io.Discard in tests.
-var warnWriter io.Writer = os.Stderr
+	"github.com/alayacore/alayacore/internal/logging"
+)
 
 // Manager handles skill discovery and loading
 type Manager struct {
-	skills    []Skill
+	mu        sync.RWMutex
+	skills    []Skill // guarded by mu
+	stamp     string  // fingerprint of the SKILL.md files last discovered; guarded by mu
 	skillDirs []string
+	logger    *slog.Logger // skills that fail to load are logged here
+}
+
+// Changes lists the skills a Reload added, updated, and removed, by name.
+type Changes struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+// String summarizes the changes, e.g. "2 updated, 1 added, 0 removed".
+func (c Changes) String() string {
+	return fmt.Sprintf("%d updated, %d added, %d removed", len(c.Updated), len(c.Added), len(c.Removed))
 }
 
-// NewManager creates a new skill manager
+// NewManager creates a new skill manager from skill roots: directories
+// holding one directory per skill. When roots have a skill of the same name,
+// the later root wins, so list them from the least to the most specific.
+// Missing roots are skipped. Skills that fail to load are skipped with a
+// warning on stderr.
 func NewManager(skillPaths []string) (*Manager, error) {
+	return NewManagerWithLogger(skillPaths, logging.New(os.Stderr, logging.FormatText, slog.LevelWarn))
+}
+
+// NewManagerWithLogger is NewManager logging the skills that fail to load
+// to logger.
+func NewManagerWithLogger(skillPaths []string, logger *slog.Logger) (*Manager, error) {
 	m := &Manager{
 		skills:    []Skill{},
 		skillDirs: skillPaths,
+		logger:    logging.OrDiscard(logger),
 	}
 
 	// If no skill paths provided, return empty manager
@@ -30,23 +57,96 @@ func NewManager(skillPaths []string) (*Manager, error) {
 	}
 
 	// Discover and load skill metadata from all paths
-	if err := m.discoverSkills(); err != nil {
+	found, err := m.discoverSkills()
+	if err != nil {
 		return nil, fmt.Errorf("failed to discover skills: %w", err)
 	}
+	m.skills = found
+	m.stamp = m.fingerprint()
 
 	return m, nil
 }
 
-// discoverSkills scans all skill directories for skills
-func (m *Manager) discoverSkills() error {
+// Reload discovers the skills of all roots again and replaces the current
+// set. Skills already activated keep the content they were activated with.
+func (m *Manager) Reload() (Changes, error) {
+	stamp := m.fingerprint()
+	found, err := m.discoverSkills()
+	if err != nil {
+		return Changes{}, fmt.Errorf("failed to discover skills: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var changes Changes
+	old := make(map[string]Skill, len(m.skills))
+	for _, skill := range m.skills {
+		old[skill.Name] = skill
+	}
+	for _, skill := range found {
+		prev, ok := old[skill.Name]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, skill.Name)
+		case prev.Location != skill.Location || prev.Content != skill.Content:
+			changes.Updated = append(changes.Updated, skill.Name)
+		}
+		delete(old, skill.Name)
+	}
+	for name := range old {
+		changes.Removed = append(changes.Removed, name)
+	}
+	sort.Strings(changes.Removed)
+
+	m.skills = found
+	m.stamp = stamp
+	return changes, nil
+}
+
+// Stale reports whether a SKILL.md was added, changed, or removed in the
+// skill roots since the skills were last discovered.
+func (m *Manager) Stale() bool {
+	stamp := m.fingerprint()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return stamp != m.stamp
+}
+
+// fingerprint describes the path, size, and modification time of every
+// SKILL.md in the skill roots.
+func (m *Manager) fingerprint() string {
+	var sb strings.Builder
+	for _, skillDir := range m.skillDirs {
+		entries, err := os.ReadDir(skillDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			skillFile := filepath.Join(skillDir, entry.Name(), "SKILL.md")
+			if info, err := os.Stat(skillFile); err == nil {
+				fmt.Fprintf(&sb, "%s %d %d\n", skillFile, info.Size(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	return sb.String()
+}
+
+// discoverSkills scans all skill directories for skills, returning them sorted
+// by name whatever the order of the directories
+func (m *Manager) discoverSkills() ([]Skill, error) {
+	found := []Skill{}
+	byName := make(map[string]int) // index in found
 	for _, skillDir := range m.skillDirs {
 		entries, err := os.ReadDir(skillDir)
 		if err != nil {
 			// If directory doesn't exist, that's OK - no skills
 			if os.IsNotExist(err) {
-				return nil
+				continue
 			}
-			return err
+			return nil, err
 		}
 
 		for _, entry := range entries {
@@ -65,22 +165,23 @@ func (m *Manager) discoverSkills() error {
 			skill, err := m.loadSkillMetadata(skillFile, entry.Name())
 			if err != nil {
 				// Skip invalid skills but log warning
-				fmt.Fprintf(warnWriter, "Warning: failed to load skill %s from %s: %v\n", entry.Name(), skillDir, err)
+				m.logger.Warn("failed to load skill", "skill", entry.Name(), "root", skillDir, "err", err)
 				continue
 			}
+			skill.Root = skillDir
 
-			// Check for duplicate skill names
-			for _, existing := range m.skills {
-				if existing.Name == skill.Name {
-					fmt.Fprintf(warnWriter, "Warning: duplicate skill name '%s' found in %s\n", skill.Name, skillDir)
-				}
+			// A later root overrides a skill of the same name
+			if i, ok := byName[skill.Name]; ok {
+				found[i] = skill
+				continue
 			}
-
-			m.skills = append(m.skills, skill)
+			byName[skill.Name] = len(found)
+			found = append(found, skill)
 		}
 	}
 
-	return nil
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found, nil
 }
 
 // loadSkillMetadata loads only the frontmatter from a SKILL.md file
@@ -114,23 +215,33 @@ func (m *Manager) loadSkillMetadata(skillFile, dirName string) (Skill, error) {
 	}, nil
 }
 
-// ActivateSkill loads the full content of a skill
-func (m *Manager) ActivateSkill(name string) (string, error) {
+// ActivateSkill loads the full content of a skill and makes it the active
+// skill of policy, the policy of the session activating it (nil only loads
+// it)
+func (m *Manager) ActivateSkill(name string, policy *Policy) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	for _, skill := range m.skills {
 		if skill.Name == name {
+			policy.Activate(skill)
 			return skill.Content, nil
 		}
 	}
 	return "", fmt.Errorf("skill not found: %s", name)
 }
 
-// GetMetadata returns all skill metadata for system prompt injection
+// GetMetadata returns all skill metadata for system prompt injection, sorted
+// by name. Root tells which skill root each one came from.
 func (m *Manager) GetMetadata() []Skill {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.skills
 }
 
 // GenerateSystemPromptFragment generates the XML fragment for system prompt
 func (m *Manager) GenerateSystemPromptFragment() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	if len(m.skills) == 0 {
 		return ""
 	}
@@ -0,0 +1,94 @@
+package skills
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AlwaysAllowedTool is exempt from allow-lists so the model can always switch skills.
+const AlwaysAllowedTool = "activate_skill"
+
+// Policy records the active skill and the tools its allowed-tools field permits.
+// Each session owns one and hands it to the tool wrappers in the context of
+// its requests. A nil or inactive Policy allows every tool.
+type Policy struct {
+	mu           sync.Mutex
+	skill        string
+	allowedTools []string
+}
+
+// NewPolicy creates an empty policy with no active skill
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// Activate makes skill the active skill, replacing any previous restriction
+func (p *Policy) Activate(skill Skill) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.skill = skill.Name
+	p.allowedTools = ParseAllowedTools(skill.Metadata.AllowedTools)
+}
+
+// Deactivate clears the active skill and returns its name ("" if none was active)
+func (p *Policy) Deactivate() string {
+	if p == nil {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name := p.skill
+	p.skill = ""
+	p.allowedTools = nil
+	return name
+}
+
+// ActiveSkill returns the active skill name and its allow-list (nil if unrestricted)
+func (p *Policy) ActiveSkill() (string, []string) {
+	if p == nil {
+		return "", nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.skill, slices.Clone(p.allowedTools)
+}
+
+// Check returns an error if the active skill does not allow toolName
+func (p *Policy) Check(toolName string) error {
+	if p == nil || toolName == AlwaysAllowedTool {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.skill == "" || len(p.allowedTools) == 0 {
+		return nil
+	}
+	if slices.Contains(p.allowedTools, toolName) {
+		return nil
+	}
+	return fmt.Errorf("tool %q is not allowed while skill %q is active (allowed-tools: %s)",
+		toolName, p.skill, strings.Join(p.allowedTools, ", "))
+}
+
+// ParseAllowedTools splits an allowed-tools value into tool names.
+// Entries may be separated by spaces or commas. A trailing argument pattern
+// such as "posix_shell(git:*)" is reduced to the tool name.
+func ParseAllowedTools(value string) []string {
+	var tools []string
+	for field := range strings.FieldsFuncSeq(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		if i := strings.IndexByte(field, '('); i >= 0 {
+			field = field[:i]
+		}
+		if field != "" && !slices.Contains(tools, field) {
+			tools = append(tools, field)
+		}
+	}
+	return tools
+}
@@ -1,10 +1,15 @@
 package skills
 
 import (
-	"io"
+	"bytes"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/logging"
 )
 
 func TestParseSkillMarkdown(t *testing.T) {
@@ -162,7 +167,7 @@ description: A test skill
 	}
 
 	// Test activation
-	content, err := m.ActivateSkill("test-skill")
+	content, err := m.ActivateSkill("test-skill", nil)
 	if err != nil {
 		t.Fatalf("ActivateSkill failed: %v", err)
 	}
@@ -172,7 +177,7 @@ description: A test skill
 	}
 
 	// Test non-existent skill
-	_, err = m.ActivateSkill("non-existent")
+	_, err = m.ActivateSkill("non-existent", nil)
 	if err == nil {
 		t.Error("Expected error for non-existent skill")
 	}
@@ -261,7 +266,7 @@ description: Second skill from directory 2
 	}
 
 	// Test activation of skills from different paths
-	content1, err := m.ActivateSkill("skill-one")
+	content1, err := m.ActivateSkill("skill-one", nil)
 	if err != nil {
 		t.Fatalf("ActivateSkill failed for skill-one: %v", err)
 	}
@@ -269,7 +274,7 @@ description: Second skill from directory 2
 		t.Error("Expected activated content to contain Skill One")
 	}
 
-	content2, err := m.ActivateSkill("skill-two")
+	content2, err := m.ActivateSkill("skill-two", nil)
 	if err != nil {
 		t.Fatalf("ActivateSkill failed for skill-two: %v", err)
 	}
@@ -288,11 +293,6 @@ description: Second skill from directory 2
 }
 
 func TestDuplicateSkillNames(t *testing.T) {
-	// Suppress warnings during this test
-	original := warnWriter
-	warnWriter = io.Discard
-	defer func() { warnWriter = original }()
-
 	// Create first temp skill directory
 	tmpDir1 := t.TempDir()
 
@@ -331,16 +331,142 @@ description: Second occurrence
 		t.Fatalf("Failed to write skill file: %v", err)
 	}
 
-	// Test manager - both skills should be loaded (with warning)
-	m, err := NewManager([]string{tmpDir1, tmpDir2})
+	// The later root overrides the skill of the earlier one
+	m, err := NewManagerWithLogger([]string{tmpDir1, tmpDir2}, logging.Discard())
 	if err != nil {
 		t.Fatalf("NewManager failed: %v", err)
 	}
 
 	metadata := m.GetMetadata()
-	// Both skills should be in the list
-	if len(metadata) != 2 {
-		t.Errorf("Expected 2 skills (with duplicate names), got %d", len(metadata))
+	if len(metadata) != 1 {
+		t.Fatalf("Expected 1 skill (the later root wins), got %d", len(metadata))
+	}
+	if metadata[0].Description != "Second occurrence" || metadata[0].Root != tmpDir2 || metadata[0].Location != skillFile2 {
+		t.Errorf("Expected the skill of the second root, got %+v", metadata[0])
+	}
+	content, err := m.ActivateSkill("duplicate-skill", nil)
+	if err != nil || !contains(content, "Second Duplicate") {
+		t.Errorf("Activated the overridden skill: %q, %v", content, err)
+	}
+
+	// Reversed, the first root wins
+	m, err = NewManager([]string{tmpDir2, tmpDir1})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	if got := m.GetMetadata(); len(got) != 1 || got[0].Root != tmpDir1 {
+		t.Errorf("Expected the skill of the last root, got %+v", got)
+	}
+}
+
+// writeSkill creates root/name/SKILL.md with the given description.
+func writeSkill(t *testing.T, root, name, description string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: " + name + "\ndescription: " + description + "\n---\n\n# " + name
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectSkillsOverrideUserSkills(t *testing.T) {
+	user, project := t.TempDir(), t.TempDir()
+	writeSkill(t, user, "lint", "User lint")
+	writeSkill(t, user, "deploy", "User deploy")
+	writeSkill(t, project, "lint", "Project lint")
+	writeSkill(t, project, "build", "Project build")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	// A missing root is skipped, not the end of the scan
+	m, err := NewManager([]string{missing, user, project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, skill := range m.GetMetadata() {
+		got = append(got, skill.Name+"@"+skill.Description)
+	}
+	want := "build@Project build deploy@User deploy lint@Project lint"
+	if strings.Join(got, " ") != want {
+		t.Errorf("skills = %v, want %s", got, want)
+	}
+	for _, skill := range m.GetMetadata() {
+		if root := map[string]string{"build": project, "deploy": user, "lint": project}[skill.Name]; skill.Root != root {
+			t.Errorf("%s root = %s, want %s", skill.Name, skill.Root, root)
+		}
+	}
+
+	// The fragment lists skills by name whatever the order of the roots
+	other, err := NewManager([]string{project, user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragment := m.GenerateSystemPromptFragment()
+	if strings.Index(fragment, "<name>build</name>") > strings.Index(fragment, "<name>deploy</name>") {
+		t.Errorf("fragment not sorted by name:\n%s", fragment)
+	}
+	if names := func(m *Manager) string {
+		var names []string
+		for _, skill := range m.GetMetadata() {
+			names = append(names, skill.Name)
+		}
+		return strings.Join(names, ",")
+	}; names(m) != names(other) {
+		t.Errorf("skill order depends on the order of the roots: %s vs %s", names(m), names(other))
+	}
+}
+
+func TestReload(t *testing.T) {
+	root := t.TempDir()
+	writeSkill(t, root, "lint", "Lint")
+	writeSkill(t, root, "deploy", "Deploy")
+	writeSkill(t, root, "old", "Old")
+	m, err := NewManager([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Stale() {
+		t.Error("skills are stale right after discovery")
+	}
+	writeSkill(t, root, "lint", "Lint harder")
+	writeSkill(t, root, "deploy", "Deploy to staging")
+	writeSkill(t, root, "build", "Build")
+	if err := os.RemoveAll(filepath.Join(root, "old")); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Stale() {
+		t.Error("changed SKILL.md files were not noticed")
+	}
+
+	changes, err := m.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changes.String(); got != "2 updated, 1 added, 1 removed" {
+		t.Errorf("changes = %s", got)
+	}
+	if strings.Join(changes.Updated, ",") != "deploy,lint" || strings.Join(changes.Added, ",") != "build" || strings.Join(changes.Removed, ",") != "old" {
+		t.Errorf("changes = %+v", changes)
+	}
+	if m.Stale() {
+		t.Error("skills are stale right after a reload")
+	}
+	fragment := m.GenerateSystemPromptFragment()
+	if !strings.Contains(fragment, "Lint harder") || strings.Contains(fragment, "<name>old</name>") {
+		t.Errorf("fragment not rebuilt:\n%s", fragment)
+	}
+
+	// Activating after the reload loads the new content
+	if content, err := m.ActivateSkill("lint", nil); err != nil || !strings.Contains(content, "Lint harder") {
+		t.Errorf("lint after reload = %q, %v", content, err)
+	}
+
+	changes, _ = m.Reload()
+	if changes.String() != "0 updated, 0 added, 0 removed" {
+		t.Errorf("a reload without changes reported %s", changes)
 	}
 }
 
@@ -356,3 +482,103 @@ func containsAt(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParseAllowedTools(t *testing.T) {
+	got := ParseAllowedTools("read_file, posix_shell(git:*) read_file")
+	want := []string{"read_file", "posix_shell"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ParseAllowedTools = %v, want %v", got, want)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := NewPolicy()
+	if err := p.Check("posix_shell"); err != nil {
+		t.Errorf("inactive policy should allow everything, got %v", err)
+	}
+
+	p.Activate(Skill{Name: "docs", Metadata: Metadata{AllowedTools: "read_file"}})
+	if err := p.Check("read_file"); err != nil {
+		t.Errorf("read_file should be allowed, got %v", err)
+	}
+	if err := p.Check("posix_shell"); err == nil {
+		t.Error("posix_shell should be refused")
+	}
+	if err := p.Check(AlwaysAllowedTool); err != nil {
+		t.Errorf("%s should always be allowed, got %v", AlwaysAllowedTool, err)
+	}
+
+	var nilPolicy *Policy
+	if err := nilPolicy.Check("posix_shell"); err != nil {
+		t.Errorf("nil policy should allow everything, got %v", err)
+	}
+}
+
+func TestInvalidSkillIsLogged(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "broken")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: Broken\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	m, err := NewManagerWithLogger([]string{root}, logging.New(&log, logging.FormatText, slog.LevelWarn))
+	if err != nil {
+		t.Fatalf("an invalid skill should be skipped, got %v", err)
+	}
+	if len(m.GetMetadata()) != 0 {
+		t.Errorf("invalid skill was loaded: %+v", m.GetMetadata())
+	}
+	if got := log.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, `msg="failed to load skill"`) || !strings.Contains(got, "skill=broken") {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestWatchReportsChangedSkills(t *testing.T) {
+	root := t.TempDir()
+	writeSkill(t, root, "lint", "Lint")
+	m, err := NewManager([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan struct{}, 1)
+	w, err := m.Watch(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	wait := func(what string) {
+		t.Helper()
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change reported after %s", what)
+		}
+		if _, err := m.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSkill(t, root, "lint", "Lint the tree")
+	wait("editing a skill")
+	writeSkill(t, root, "build", "Build")
+	wait("adding a skill")
+
+	// Files other than SKILL.md change nothing
+	if err := os.WriteFile(filepath.Join(root, "build", "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+		t.Error("a change reported for notes.txt")
+	case <-time.After(3 * watchSettle):
+	}
+}
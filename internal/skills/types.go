@@ -14,7 +14,8 @@ type Metadata struct {
 type Skill struct {
 	Name        string
 	Description string
-	Location    string
+	Location    string // path of its SKILL.md
+	Root        string // skill root the skill was found in
 	Content     string // Full SKILL.md content (loaded on activation)
 	Metadata    Metadata
 }
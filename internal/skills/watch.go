@@ -0,0 +1,87 @@
+package skills
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long Watch waits after the last event before it looks
+// at the SKILL.md files, so an editor's burst of writes counts as one change.
+var watchSettle = 100 * time.Millisecond
+
+// Watcher watches the skill roots of a Manager. Close stops it.
+type Watcher struct {
+	fs   *fsnotify.Watcher
+	done chan struct{}
+}
+
+// Watch calls onChange, on the watcher's goroutine, each time a SKILL.md in
+// the skill roots is added, changed, or removed while the skills are not
+// reloaded. It watches every root and every skill directory in them; roots
+// missing when Watch is called are not watched.
+func (m *Manager) Watch(onChange func()) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch skills: %w", err)
+	}
+	var roots []string
+	for _, root := range m.skillDirs {
+		root = filepath.Clean(root)
+		if err := fsw.Add(root); err != nil {
+			continue
+		}
+		roots = append(roots, root)
+		entries, _ := os.ReadDir(root) //nolint:errcheck // a root that cannot be read has no skills to watch
+		for _, entry := range entries {
+			if entry.IsDir() {
+				fsw.Add(filepath.Join(root, entry.Name())) //nolint:errcheck,gosec // a skill directory gone since ReadDir needs no watch
+			}
+		}
+	}
+
+	w := &Watcher{fs: fsw, done: make(chan struct{})}
+	go w.run(m, roots, onChange)
+	return w, nil
+}
+
+// Close stops the watcher. onChange is not called once Close returns.
+func (w *Watcher) Close() error {
+	err := w.fs.Close()
+	<-w.done
+	return err
+}
+
+func (w *Watcher) run(m *Manager, roots []string, onChange func()) {
+	defer close(w.done)
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			// A new skill directory in a root is watched for its SKILL.md
+			if event.Has(fsnotify.Create) && slices.Contains(roots, filepath.Dir(event.Name)) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.fs.Add(event.Name) //nolint:errcheck,gosec // a directory removed again needs no watch
+				}
+			}
+			settle.Reset(watchSettle)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			m.logger.Warn("skill watcher failed", "error", err)
+		case <-settle.C:
+			if m.Stale() {
+				onChange()
+			}
+		}
+	}
+}
@@ -13,12 +13,26 @@
 //	  - TagTextUser (TU): User text input
 //	  - TagTextAssistant (TA): Assistant text output
 //	  - TagTextReasoning (TR): Reasoning/thinking content
+//	  - TagUserImage (UI): User image attachment (file name, NUL, raw bytes)
 //	  - TagFunctionCall (FC): Function call (JSON: id, name, input)
-//	  - TagFunctionResult (FR): Function result (JSON: id, output)
+//	  - TagFunctionResult (FR): Function result (JSON: id, output, error)
 //	  - TagFunctionState (FS): Function state indicator (pending/success/error)
+//	  - TagToolResult (FT): Structured tool result (JSON: id, kind, data)
+//	    for display; the FR frames that follow carry it as text
 //	  - TagSystemError (SE): System error messages
 //	  - TagSystemNotify (SN): System notifications
 //	  - TagSystemData (SD): System data (JSON)
+//	  - TagSystemLog (SL): Agent lifecycle events (--verbose only)
+//	  - TagTurnEnd (TE): A task finished (task ID); its start is the
+//	    "#<id> ▸ " TagTextUser frame
+//	  - TagTurnAlert (TN): A prompt that ran past --notify-after finished
+//	    (one-line summary), for desktop notifications
+//	  - TagPlan (PL): The manage_todo list after each change (JSON array
+//	    of id, text, done)
+//	  - TagClear (CL): The conversation was cleared; clients clear their
+//	    display (no value)
+//	  - TagHello (HI): WebSocket protocol version handshake (JSON); never
+//	    reaches the session
 //
 // State Indicators:
 //
@@ -31,6 +45,8 @@
 // Key Types:
 //
 //   - ChanInput: Input implementation using a channel of TLV messages
+//   - FrameReader: Reads TLV frames, rejecting oversized frames and
+//     resynchronizing after malformed input
 //   - Input: Interface for reading bytes
 //   - Output: Interface for writing bytes with Flush
 //
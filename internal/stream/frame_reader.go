@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxFrameSize bounds the declared length of any frame: ParseHeader and
+// ReadTLV reject longer ones before any allocation, and no FrameReader
+// accepts more.
+const MaxFrameSize = 64 << 20 // 64 MiB
+
+// DefaultMaxFrameSize is the limit of a FrameReader made without
+// WithMaxFrameSize.
+const DefaultMaxFrameSize = 16 << 20 // 16 MiB
+
+// ErrMalformedFrame is returned (wrapped) for frames with an invalid header.
+// The reader stays usable: the next call skips ahead to the next plausible header.
+var ErrMalformedFrame = errors.New("malformed TLV frame")
+
+// FrameReader reads TLV frames from an Input and recovers from malformed data.
+//
+// A header is plausible when its tag is two uppercase ASCII letters and its
+// length does not exceed the reader's limit. On an implausible header, Next returns
+// an error wrapping ErrMalformedFrame; the following call discards bytes until
+// a plausible header is found and continues from there.
+type FrameReader struct {
+	r        *bufio.Reader
+	resync   bool
+	maxFrame int // longest value accepted, at most MaxFrameSize
+}
+
+// FrameReaderOption configures a FrameReader.
+type FrameReaderOption func(*FrameReader)
+
+// WithMaxFrameSize makes the reader reject frames longer than n bytes.
+// A limit of 0 or less keeps DefaultMaxFrameSize; one over MaxFrameSize is
+// lowered to it.
+func WithMaxFrameSize(n int) FrameReaderOption {
+	return func(fr *FrameReader) {
+		if n > 0 {
+			fr.maxFrame = min(n, MaxFrameSize)
+		}
+	}
+}
+
+// NewFrameReader creates a FrameReader over input.
+func NewFrameReader(input Input, opts ...FrameReaderOption) *FrameReader {
+	fr := &FrameReader{r: bufio.NewReader(input), maxFrame: DefaultMaxFrameSize}
+	for _, opt := range opts {
+		opt(fr)
+	}
+	return fr
+}
+
+// Next reads the next frame. Errors from the underlying input (including io.EOF)
+// are returned unchanged; framing problems wrap ErrMalformedFrame.
+func (fr *FrameReader) Next() (string, string, error) {
+	if fr.resync {
+		if err := fr.skipToHeader(); err != nil {
+			return "", "", err
+		}
+		fr.resync = false
+	}
+
+	header, err := fr.r.Peek(6)
+	if err != nil {
+		return "", "", err
+	}
+	if err := fr.checkHeader(header); err != nil {
+		fr.resync = true
+		return "", "", err
+	}
+	//nolint:errcheck // the 6 bytes were just peeked
+	_, _ = fr.r.Discard(6)
+
+	tag := string(header[0:2])
+	length := binary.BigEndian.Uint32(header[2:6])
+	if length == 0 {
+		return tag, "", nil
+	}
+
+	valueBuf := make([]byte, length)
+	if _, err := io.ReadFull(fr.r, valueBuf); err != nil {
+		return "", "", err
+	}
+	return tag, string(valueBuf), nil
+}
+
+// skipToHeader drops at least one byte, then keeps dropping until the buffered
+// data starts with a plausible header.
+func (fr *FrameReader) skipToHeader() error {
+	for {
+		if _, err := fr.r.Discard(1); err != nil {
+			return err
+		}
+		header, err := fr.r.Peek(6)
+		if err != nil {
+			return err
+		}
+		if fr.checkHeader(header) == nil {
+			return nil
+		}
+	}
+}
+
+// checkHeader validates a 6-byte TLV header against the reader's limit.
+func (fr *FrameReader) checkHeader(header []byte) error {
+	_, length, err := ParseHeader(header)
+	if err == nil && length > fr.maxFrame {
+		err = fmt.Errorf("%w: length %d exceeds limit of %d bytes", ErrMalformedFrame, length, fr.maxFrame)
+	}
+	return err
+}
+
+// ParseHeader validates a TLV header, the first 6 bytes of header, and
+// returns its tag and value length. Errors wrap ErrMalformedFrame. Decoders
+// of buffered output use it so a corrupt length can never be used to slice.
+func ParseHeader(header []byte) (string, int, error) {
+	if len(header) < 6 {
+		return "", 0, fmt.Errorf("%w: short header of %d bytes", ErrMalformedFrame, len(header))
+	}
+	if !isTagByte(header[0]) || !isTagByte(header[1]) {
+		return "", 0, fmt.Errorf("%w: invalid tag %q", ErrMalformedFrame, header[0:2])
+	}
+	length := binary.BigEndian.Uint32(header[2:6])
+	if length > MaxFrameSize {
+		return "", 0, fmt.Errorf("%w: length %d exceeds limit of %d bytes", ErrMalformedFrame, length, MaxFrameSize)
+	}
+	return string(header[0:2]), int(length), nil
+}
+
+// SkipToHeader drops at least one byte of buf, then keeps dropping until buf
+// starts with a plausible header or holds fewer than 6 bytes.
+func SkipToHeader(buf []byte) []byte {
+	if len(buf) > 0 {
+		buf = buf[1:]
+	}
+	for len(buf) >= 6 {
+		if _, _, err := ParseHeader(buf); err == nil {
+			break
+		}
+		buf = buf[1:]
+	}
+	return buf
+}
+
+func isTagByte(b byte) bool {
+	return b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFrameReaderResyncsAfterGarbage(t *testing.T) {
+	input := NewChanInput(10)
+	_ = input.Emit([]byte("\x00\x01garbage!"))
+	_ = input.EmitTLV(TagTextUser, "hello")
+	input.Close()
+
+	fr := NewFrameReader(input)
+
+	if _, _, err := fr.Next(); !errors.Is(err, ErrMalformedFrame) {
+		t.Fatalf("first Next() error = %v, want ErrMalformedFrame", err)
+	}
+	tag, value, err := fr.Next()
+	if err != nil {
+		t.Fatalf("Next() after resync error = %v", err)
+	}
+	if tag != TagTextUser || value != "hello" {
+		t.Errorf("Next() = (%q, %q), want (%q, %q)", tag, value, TagTextUser, "hello")
+	}
+	if _, _, err := fr.Next(); err != io.EOF {
+		t.Errorf("Next() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestFrameReaderRejectsOversizedFrame(t *testing.T) {
+	header := []byte{'T', 'U', 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[2:], 2<<30) // claims 2 GiB
+
+	input := NewChanInput(10)
+	_ = input.Emit(header)
+	_ = input.EmitTLV(TagTextUser, "still works")
+	input.Close()
+
+	fr := NewFrameReader(input)
+	if _, _, err := fr.Next(); !errors.Is(err, ErrMalformedFrame) {
+		t.Fatalf("Next() error = %v, want ErrMalformedFrame", err)
+	}
+	if _, value, err := fr.Next(); err != nil || value != "still works" {
+		t.Errorf("Next() = (%q, %v), want the following valid frame", value, err)
+	}
+}
+
+func TestFrameReaderMaxFrameSize(t *testing.T) {
+	oversized := func(length uint32) *ChanInput {
+		header := []byte{'T', 'U', 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(header[2:], length)
+		input := NewChanInput(10)
+		_ = input.Emit(header)
+		input.Close()
+		return input
+	}
+	if _, _, err := NewFrameReader(oversized(DefaultMaxFrameSize + 1)).Next(); !errors.Is(err, ErrMalformedFrame) {
+		t.Errorf("default limit: Next() error = %v, want ErrMalformedFrame", err)
+	}
+	if _, _, err := NewFrameReader(oversized(MaxFrameSize+1), WithMaxFrameSize(2*MaxFrameSize)).Next(); !errors.Is(err, ErrMalformedFrame) {
+		t.Errorf("limit over MaxFrameSize: Next() error = %v, want ErrMalformedFrame", err)
+	}
+
+	input := NewChanInput(10)
+	_ = input.EmitTLV(TagTextUser, "hello")
+	_ = input.EmitTLV(TagTextUser, "hi")
+	input.Close()
+	fr := NewFrameReader(input, WithMaxFrameSize(4))
+	if _, _, err := fr.Next(); !errors.Is(err, ErrMalformedFrame) {
+		t.Fatalf("Next() error = %v, want ErrMalformedFrame for 5 bytes over a limit of 4", err)
+	}
+	if _, value, err := fr.Next(); err != nil || value != "hi" {
+		t.Errorf("Next() = (%q, %v), want the frame within the limit", value, err)
+	}
+}
+
+func TestReadTLVRejectsOversizedFrame(t *testing.T) {
+	header := []byte{'T', 'U', 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[2:], MaxFrameSize+1)
+
+	if _, _, err := ReadTLV(&byteReader{data: header}); !errors.Is(err, ErrMalformedFrame) {
+		t.Errorf("ReadTLV() error = %v, want ErrMalformedFrame", err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	header := []byte{'T', 'U', 0x80, 0, 0, 0} // high bit set: negative as int32
+	if _, _, err := ParseHeader(header); !errors.Is(err, ErrMalformedFrame) {
+		t.Errorf("ParseHeader(high bit) error = %v, want ErrMalformedFrame", err)
+	}
+	if _, _, err := ParseHeader([]byte("TU")); !errors.Is(err, ErrMalformedFrame) {
+		t.Errorf("ParseHeader(short) error = %v, want ErrMalformedFrame", err)
+	}
+	tag, length, err := ParseHeader(EncodeTLV(TagTextUser, "hello"))
+	if err != nil || tag != TagTextUser || length != 5 {
+		t.Errorf("ParseHeader() = (%q, %d, %v), want (%q, 5, nil)", tag, length, err, TagTextUser)
+	}
+}
+
+func TestSkipToHeader(t *testing.T) {
+	buf := append([]byte("\x00\x01junk"), EncodeTLV(TagTextUser, "hi")...)
+	if got := SkipToHeader(buf); string(got) != string(EncodeTLV(TagTextUser, "hi")) {
+		t.Errorf("SkipToHeader() = %q", got)
+	}
+}
+
+func FuzzFrameReader(f *testing.F) {
+	f.Add(EncodeTLV(TagTextUser, "hello"))
+	f.Add([]byte{'T', 'U', 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte("\x00\x01garbage!"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fr := NewFrameReader(&byteReader{data: data})
+		// Every call consumes input, so this ends well within len(data)+1 calls
+		for i := 0; i <= len(data); i++ {
+			_, value, err := fr.Next()
+			if len(value) > len(data) {
+				t.Fatalf("value of %d bytes from %d bytes of input", len(value), len(data))
+			}
+			if err != nil && !errors.Is(err, ErrMalformedFrame) {
+				return
+			}
+		}
+	})
+}
@@ -7,7 +7,10 @@ package stream
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 // Message tags for TLV protocol (2-byte tags).
@@ -16,18 +19,32 @@ const (
 	TagTextUser      = "TU" // User text input
 	TagTextAssistant = "TA" // Assistant text output
 	TagTextReasoning = "TR" // Reasoning/thinking content
+	TagUserImage     = "UI" // User image attachment (see EncodeImage)
 
 	// Function/tool tags
 	TagFunctionCall   = "FC" // Function call (JSON: id, name, input) - for both display and persistence
-	TagFunctionResult = "FR" // Function result (JSON: id, output) - for both display and persistence
+	TagFunctionResult = "FR" // Function result (JSON: id, output, error) - for both display and persistence
 	TagFunctionState  = "FS" // Function state indicator (pending/success/error)
+	TagToolResult     = "FT" // Structured tool result (JSON: id, kind, data), sent before the FR frames of the same result
 
 	// System tags
 	TagSystemError  = "SE" // System error messages
 	TagSystemNotify = "SN" // System notification messages (simple string)
 	TagSystemData   = "SD" // System data messages (complex data, queue status, model info, etc.)
+	TagSystemLog    = "SL" // Agent lifecycle events, sent only with --verbose
+	TagTurnEnd      = "TE" // A task finished (value: task ID), after all of its output
+	TagTurnAlert    = "TN" // A long prompt finished (value: one-line summary); clients may alert the user
+	TagPlan         = "PL" // The manage_todo list after a change (JSON array: id, text, done)
+	TagClear        = "CL" // The conversation was cleared (:clear); clients clear their display. No value
+	TagReview       = "AR" // A file change awaiting the user's decision, or the decision (JSON: id, tool, path, diff, decision, reason)
+	TagHello        = "HI" // WebSocket protocol handshake (JSON: protocol, min_protocol, server, tags)
+	TagTime         = "TM" // When the event of the next frame happened (TimeFormat), sent before TU, FC, and TE
 )
 
+// TimeFormat is the layout of TagTime values: RFC 3339 with milliseconds,
+// e.g. 2026-10-16T09:30:12.345+02:00.
+const TimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 // ChanInput implements Input using a channel of raw TLV-encoded messages.
 type ChanInput struct {
 	ch  chan []byte
@@ -91,6 +108,20 @@ func EncodeTLV(tag string, value string) []byte {
 
 const maxMessageSize = 1<<31 - 1 // Max int32 to fit in uint32
 
+// EncodeImage builds a TagUserImage value: the file name, a NUL byte, then the raw image bytes.
+func EncodeImage(name string, data []byte) string {
+	return name + "\x00" + string(data)
+}
+
+// DecodeImage splits a TagUserImage value into file name and image bytes.
+func DecodeImage(value string) (name string, data []byte, ok bool) {
+	name, rest, found := strings.Cut(value, "\x00")
+	if !found {
+		return "", nil, false
+	}
+	return name, []byte(rest), true
+}
+
 // EmitTLV writes a TLV-encoded message to the input.
 func (i *ChanInput) EmitTLV(tag string, value string) error {
 	return i.Emit(EncodeTLV(tag, value))
@@ -102,6 +133,45 @@ func WriteTLV(output Output, tag string, value string) error {
 	return err
 }
 
+// ChunkSize is the largest value WriteTLVChunked puts in one frame. A tool
+// result of several megabytes then reaches the adaptors as many small frames,
+// which they decode and show as they come instead of all at once.
+const ChunkSize = 32 * 1024
+
+// SplitChunks splits value into pieces of at most size bytes, cutting only
+// between UTF-8 characters. A short value, even an empty one, is one piece.
+func SplitChunks(value string, size int) []string {
+	if len(value) <= size {
+		return []string{value}
+	}
+	chunks := make([]string, 0, len(value)/size+1)
+	for len(value) > size {
+		cut := size
+		// Back up to the start of the character the limit falls in
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = size
+		}
+		chunks = append(chunks, value[:cut])
+		value = value[cut:]
+	}
+	return append(chunks, value)
+}
+
+// WriteTLVChunked writes value as consecutive frames of tag, each holding
+// prefix and then at most ChunkSize bytes of value. Decoders join the frames
+// of one stream ID, so prefix is the stream ID, e.g. "[:1-2-t:]".
+func WriteTLVChunked(output Output, tag, prefix, value string) error {
+	for _, chunk := range SplitChunks(value, ChunkSize) {
+		if err := WriteTLV(output, tag, prefix+chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ReadTLV reads a single TLV-framed message from input.
 // It blocks until a full frame has been read or an error occurs.
 func ReadTLV(input Input) (string, string, error) {
@@ -111,6 +181,9 @@ func ReadTLV(input Input) (string, string, error) {
 	}
 	tag := string(header[0:2])
 	length := binary.BigEndian.Uint32(header[2:])
+	if length > MaxFrameSize {
+		return "", "", fmt.Errorf("%w: length %d exceeds limit of %d bytes", ErrMalformedFrame, length, MaxFrameSize)
+	}
 
 	if length == 0 {
 		return tag, "", nil
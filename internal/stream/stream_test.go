@@ -3,7 +3,9 @@ package stream
 import (
 	"bytes"
 	"io"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestEncodeTLV(t *testing.T) {
@@ -212,3 +214,47 @@ type bufferOutput struct {
 func (b *bufferOutput) Flush() error {
 	return nil
 }
+
+func TestSplitChunks(t *testing.T) {
+	if got := SplitChunks("", 4); len(got) != 1 || got[0] != "" {
+		t.Errorf("SplitChunks(\"\") = %q, want one empty chunk", got)
+	}
+
+	// "é" is two bytes: a chunk never ends inside it
+	value := strings.Repeat("aé", 1000)
+	chunks := SplitChunks(value, 5)
+	for i, chunk := range chunks {
+		if len(chunk) > 5 || !utf8.ValidString(chunk) {
+			t.Fatalf("chunk %d = %q: over 5 bytes or cut inside a character", i, chunk)
+		}
+	}
+	if strings.Join(chunks, "") != value {
+		t.Error("the chunks do not join back into the value")
+	}
+}
+
+func TestWriteTLVChunked(t *testing.T) {
+	out := bufferOutput{&bytes.Buffer{}}
+	value := strings.Repeat("x", 2*ChunkSize+10)
+	if err := WriteTLVChunked(&out, TagTextAssistant, "[:1-1-t:]", value); err != nil {
+		t.Fatal(err)
+	}
+
+	var joined strings.Builder
+	frames := 0
+	for data := out.Bytes(); len(data) > 0; frames++ {
+		tag, length, err := ParseHeader(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame := string(data[6 : 6+length])
+		if tag != TagTextAssistant || !strings.HasPrefix(frame, "[:1-1-t:]") {
+			t.Fatalf("frame %d = %s %.20q, want TA with the stream ID", frames, tag, frame)
+		}
+		joined.WriteString(strings.TrimPrefix(frame, "[:1-1-t:]"))
+		data = data[6+length:]
+	}
+	if frames != 3 || joined.String() != value {
+		t.Errorf("got %d frames joining to %d bytes, want 3 frames of the value", frames, joined.Len())
+	}
+}
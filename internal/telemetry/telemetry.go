@@ -0,0 +1,69 @@
+// Package telemetry keeps process-wide counters of sessions, prompts, tokens,
+// and tool calls for the /metrics endpoint of alayacore-web (--metrics).
+//
+// The metrics are prometheus client_golang collectors registered in Default,
+// a registry of their own rather than the client's global one, so /metrics
+// serves only them, and as package variables they are registered once
+// however many adaptors or sessions the process creates.
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of prompt duration
+// histograms: prompts take from a second to many minutes.
+var DefaultBuckets = []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// Default holds the metrics of the process.
+var Default = prometheus.NewRegistry()
+
+// The metrics recorded by the adaptors, the session, and the tool wrappers.
+var (
+	ActiveSessions = promauto.With(Default).NewGauge(prometheus.GaugeOpts{
+		Name: "alayacore_active_sessions",
+		Help: "WebSocket sessions open now.",
+	})
+	WebSocketConnects = promauto.With(Default).NewCounter(prometheus.CounterOpts{
+		Name: "alayacore_websocket_connects_total",
+		Help: "WebSocket connections accepted.",
+	})
+	WebSocketDisconnects = promauto.With(Default).NewCounter(prometheus.CounterOpts{
+		Name: "alayacore_websocket_disconnects_total",
+		Help: "WebSocket connections closed.",
+	})
+	Prompts = promauto.With(Default).NewCounter(prometheus.CounterOpts{
+		Name: "alayacore_prompts_total",
+		Help: "Prompts processed.",
+	})
+	PromptDuration = promauto.With(Default).NewHistogram(prometheus.HistogramOpts{
+		Name:    "alayacore_prompt_duration_seconds",
+		Help:    "Time from the start of a prompt to its answer.",
+		Buckets: DefaultBuckets,
+	})
+	Tokens = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "alayacore_tokens_total",
+		Help: "Tokens spent, by model and direction (in or out).",
+	}, []string{"model", "direction"})
+	ToolCalls = promauto.With(Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "alayacore_tool_calls_total",
+		Help: "Tool calls, by tool and result (ok or error).",
+	}, []string{"tool", "result"})
+)
+
+// Handler serves Default for Prometheus to scrape.
+func Handler() http.Handler {
+	return handlerFor(Default)
+}
+
+func handlerFor(g prometheus.Gatherer) http.Handler {
+	h := promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		h.ServeHTTP(w, r)
+	})
+}
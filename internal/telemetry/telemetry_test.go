@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// scrape returns what handlerFor serves for g.
+func scrape(t *testing.T, g prometheus.Gatherer) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handlerFor(g).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	return rec.Body.String()
+}
+
+func TestScrapeEscapesLabelValues(t *testing.T) {
+	r := prometheus.NewRegistry()
+	tokens := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: "test_tokens_total",
+		Help: "Tokens by model.",
+	}, []string{"model", "direction"})
+	tokens.WithLabelValues(`say "hi"`, "in").Add(5)
+	tokens.WithLabelValues(`C:\models`, "in").Add(2)
+	tokens.WithLabelValues("two\nlines", "out").Add(1)
+
+	body := scrape(t, r)
+	for _, line := range []string{
+		`test_tokens_total{direction="in",model="say \"hi\""} 5`,
+		`test_tokens_total{direction="in",model="C:\\models"} 2`,
+		`test_tokens_total{direction="out",model="two\nlines"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("scrape lacks %s:\n%s", line, body)
+		}
+	}
+}
+
+func TestScrapeHelpAndType(t *testing.T) {
+	r := prometheus.NewRegistry()
+	promauto.With(r).NewGauge(prometheus.GaugeOpts{Name: "test_sessions", Help: "Sessions open now."}).Inc()
+	promauto.With(r).NewCounter(prometheus.CounterOpts{Name: "test_paths_total", Help: "Paths like C:\\tmp,\nby drive."})
+	h := promauto.With(r).NewHistogram(prometheus.HistogramOpts{Name: "test_duration_seconds", Help: "Prompt time.", Buckets: []float64{1, 10}})
+	h.Observe(0.5)
+	h.Observe(3)
+	h.Observe(30)
+
+	body := scrape(t, r)
+	for _, block := range []string{
+		"# HELP test_sessions Sessions open now.\n# TYPE test_sessions gauge\ntest_sessions 1\n",
+		"# HELP test_paths_total Paths like C:\\\\tmp,\\nby drive.\n# TYPE test_paths_total counter\ntest_paths_total 0\n",
+		"# HELP test_duration_seconds Prompt time.\n# TYPE test_duration_seconds histogram\n" +
+			"test_duration_seconds_bucket{le=\"1\"} 1\n" +
+			"test_duration_seconds_bucket{le=\"10\"} 2\n" +
+			"test_duration_seconds_bucket{le=\"+Inf\"} 3\n" +
+			"test_duration_seconds_sum 33.5\n" +
+			"test_duration_seconds_count 3\n",
+	} {
+		if !strings.Contains(body, block) {
+			t.Errorf("scrape lacks\n%s\nin:\n%s", block, body)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+	for _, name := range []string{"alayacore_active_sessions", "alayacore_prompts_total", "alayacore_prompt_duration_seconds_count", "# TYPE alayacore_prompts_total counter"} {
+		if !strings.Contains(rec.Body.String(), name) {
+			t.Errorf("scrape lacks %s:\n%s", name, rec.Body.String())
+		}
+	}
+}
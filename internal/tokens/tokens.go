@@ -0,0 +1,218 @@
+// Package tokens estimates how many tokens a request will consume before it
+// is sent. The estimates are approximations meant for preflight warnings, not
+// for billing: OpenAI models are counted with a tiktoken-style pre-tokenizer,
+// every other model with a characters-per-token ratio.
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+const (
+	// MessageOverhead is the per-message framing cost (role markers and
+	// separators) added by chat templates.
+	MessageOverhead = 4
+
+	// ReplyOverhead primes the assistant reply at the end of every request.
+	ReplyOverhead = 3
+
+	// ImageTokens is the flat cost charged for each attached image. Providers
+	// bill images by resolution; this is roughly a 1024x1024 image.
+	ImageTokens = 1000
+
+	// DefaultCharsPerToken is the ratio used for models without a known
+	// tokenizer. It errs on the high side for English prose so warnings fire
+	// early rather than late.
+	DefaultCharsPerToken = 3.5
+)
+
+// Estimator counts the tokens in a piece of text.
+type Estimator interface {
+	Count(text string) int
+}
+
+// ForModel returns the estimator that best matches the model's tokenizer.
+// Provider prefixes such as "openai/" are ignored.
+func ForModel(model string) Estimator {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, prefix := range []string{"gpt-", "chatgpt-", "o1", "o3", "o4", "text-embedding-"} {
+		if strings.HasPrefix(name, prefix) {
+			return OpenAIEstimator{}
+		}
+	}
+	return RatioEstimator{CharsPerToken: DefaultCharsPerToken}
+}
+
+// RatioEstimator assumes a fixed number of characters per token.
+type RatioEstimator struct {
+	CharsPerToken float64
+}
+
+// Count implements Estimator.
+func (r RatioEstimator) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	ratio := r.CharsPerToken
+	if ratio <= 0 {
+		ratio = DefaultCharsPerToken
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / ratio))
+}
+
+// OpenAIEstimator approximates the cl100k/o200k encodings. It splits text the
+// way tiktoken's pre-tokenizer does (words with their leading space, digit
+// groups of up to three, punctuation runs, whitespace runs) and charges each
+// piece the number of BPE tokens such a piece typically merges into.
+type OpenAIEstimator struct{}
+
+// Count implements Estimator.
+func (OpenAIEstimator) Count(text string) int {
+	count := 0
+	rest := text
+	for rest != "" {
+		piece, kind := nextPiece(rest)
+		rest = rest[len(piece):]
+		count += pieceTokens(piece, kind)
+	}
+	return count
+}
+
+type pieceKind int
+
+const (
+	pieceWord pieceKind = iota
+	pieceNumber
+	piecePunct
+	pieceSpace
+)
+
+// nextPiece returns the next pre-tokenizer piece of s and its kind.
+func nextPiece(s string) (string, pieceKind) {
+	r, size := utf8.DecodeRuneInString(s)
+	switch {
+	case unicode.IsLetter(r):
+		return s[:size+scan(s[size:], unicode.IsLetter)], pieceWord
+	case unicode.IsDigit(r):
+		n := size
+		for i := 1; i < 3 && n < len(s); i++ {
+			d, dsize := utf8.DecodeRuneInString(s[n:])
+			if !unicode.IsDigit(d) {
+				break
+			}
+			n += dsize
+		}
+		return s[:n], pieceNumber
+	case unicode.IsSpace(r):
+		n := size + scan(s[size:], unicode.IsSpace)
+		// A single space attaches to the following word, as in " hello".
+		if n < len(s) && s[n-1] == ' ' {
+			next, _ := utf8.DecodeRuneInString(s[n:])
+			if unicode.IsLetter(next) {
+				if n == 1 {
+					return s[:n+scan(s[n:], unicode.IsLetter)], pieceWord
+				}
+				n--
+			}
+		}
+		return s[:n], pieceSpace
+	default:
+		isPunct := func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+		}
+		return s[:size+scan(s[size:], isPunct)], piecePunct
+	}
+}
+
+// scan returns the byte length of the prefix of s whose runes satisfy fn.
+func scan(s string, fn func(rune) bool) int {
+	for i, r := range s {
+		if !fn(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// pieceTokens estimates how many BPE tokens a pre-tokenizer piece becomes.
+func pieceTokens(piece string, kind pieceKind) int {
+	switch kind {
+	case pieceWord:
+		word := strings.TrimPrefix(piece, " ")
+		ascii, other := 0, 0
+		for _, r := range word {
+			if r < utf8.RuneSelf {
+				ascii++
+			} else {
+				other++
+			}
+		}
+		// Common English words are a single token; longer ones split into
+		// chunks of roughly six letters. Non-ASCII letters (CJK in
+		// particular) rarely merge and cost about one token each.
+		tokens := other
+		if ascii > 0 {
+			tokens += 1 + (ascii-1)/6
+		}
+		return tokens
+	case piecePunct:
+		return (utf8.RuneCountInString(piece) + 1) / 2
+	default:
+		return 1
+	}
+}
+
+// EstimateRequest estimates the input tokens of a request made of a system
+// prompt, tool definitions, and conversation history.
+func EstimateRequest(e Estimator, system string, tools []llm.Tool, messages []llm.Message) int {
+	total := ReplyOverhead
+	if system != "" {
+		total += MessageOverhead + e.Count(system)
+	}
+	for _, tool := range tools {
+		if tool.Hidden {
+			continue
+		}
+		total += e.Count(tool.Definition.Name) + e.Count(tool.Definition.Description) + e.Count(string(tool.Definition.Schema))
+	}
+	for _, msg := range messages {
+		total += EstimateMessage(e, msg)
+	}
+	return total
+}
+
+// EstimateMessage estimates the tokens of a single message, including its
+// framing overhead.
+func EstimateMessage(e Estimator, msg llm.Message) int {
+	total := MessageOverhead
+	for _, part := range msg.Content {
+		switch p := part.(type) {
+		case llm.TextPart:
+			total += e.Count(p.Text)
+		case llm.ReasoningPart:
+			total += e.Count(p.Text)
+		case llm.ImagePart:
+			total += ImageTokens
+		case llm.ToolCallPart:
+			total += e.Count(p.ToolName) + e.Count(string(p.Input))
+		case llm.ToolResultPart:
+			switch out := p.Output.(type) {
+			case llm.ToolResultOutputText:
+				total += e.Count(out.Text)
+			case llm.ToolResultOutputData:
+				total += e.Count(string(out.Data))
+			case llm.ToolResultOutputError:
+				total += e.Count(out.Error)
+			}
+		}
+	}
+	return total
+}
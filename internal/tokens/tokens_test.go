@@ -0,0 +1,91 @@
+package tokens
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestForModel(t *testing.T) {
+	if _, ok := ForModel("gpt-4o").(OpenAIEstimator); !ok {
+		t.Error("gpt-4o should use the OpenAI estimator")
+	}
+	if _, ok := ForModel("openrouter/o3-mini").(OpenAIEstimator); !ok {
+		t.Error("provider prefixes should be ignored")
+	}
+	if _, ok := ForModel("claude-sonnet-4-5").(RatioEstimator); !ok {
+		t.Error("claude models should use the ratio estimator")
+	}
+}
+
+func TestOpenAIEstimatorCount(t *testing.T) {
+	e := OpenAIEstimator{}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"Hello world", 2},
+		{"Hello, world!", 4},
+		{"12345", 2},
+		{"  indented", 3},
+		{"line one\nline two", 5},
+		{"你好", 2},
+	}
+	for _, tt := range tests {
+		if got := e.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAIEstimatorProseRatio(t *testing.T) {
+	// English prose averages about four characters per token in cl100k.
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog while the farmer watches. ", 50)
+	got := OpenAIEstimator{}.Count(text)
+	perToken := float64(len(text)) / float64(got)
+	if perToken < 3 || perToken > 5.5 {
+		t.Errorf("chars per token = %.2f, want between 3 and 5.5", perToken)
+	}
+}
+
+func TestRatioEstimatorCount(t *testing.T) {
+	e := RatioEstimator{CharsPerToken: 4}
+	if got := e.Count("abcdefghi"); got != 3 {
+		t.Errorf("Count = %d, want 3", got)
+	}
+	if got := (RatioEstimator{}).Count("abcdefg"); got != 2 {
+		t.Errorf("zero ratio should fall back to the default, got %d", got)
+	}
+}
+
+func TestEstimateRequest(t *testing.T) {
+	e := RatioEstimator{CharsPerToken: 1}
+	messages := []llm.Message{
+		{Role: llm.RoleUser, Content: []llm.ContentPart{
+			llm.TextPart{Text: "abcd"},
+			llm.ImagePart{MediaType: "image/png"},
+		}},
+		{Role: llm.RoleAssistant, Content: []llm.ContentPart{
+			llm.ToolCallPart{ToolName: "ls", Input: json.RawMessage(`{}`)},
+		}},
+		{Role: llm.RoleTool, Content: []llm.ContentPart{
+			llm.ToolResultPart{Output: llm.ToolResultOutputText{Text: "abc"}},
+		}},
+	}
+	tools := []llm.Tool{{Definition: llm.ToolDefinition{Name: "ls", Description: "list", Schema: json.RawMessage(`{}`)}}}
+
+	got := EstimateRequest(e, "sys", tools, messages)
+	want := ReplyOverhead +
+		MessageOverhead + 3 + // system
+		2 + 4 + 2 + // tool definition
+		MessageOverhead + 4 + ImageTokens +
+		MessageOverhead + 2 + 2 +
+		MessageOverhead + 3
+	if got != want {
+		t.Errorf("EstimateRequest = %d, want %d", got, want)
+	}
+}
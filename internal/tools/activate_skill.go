@@ -19,8 +19,9 @@ func NewActivateSkillTool(skillsManager *skills.Manager) llm.Tool {
 		"Activate a skill by name to load its full instructions. Use this instead of reading SKILL.md files.",
 	).
 		WithSchema(llm.GenerateSchema(ActivateSkillInput{})).
-		WithExecute(llm.TypedExecute(func(_ context.Context, args ActivateSkillInput) (llm.ToolResultOutput, error) {
-			content, err := skillsManager.ActivateSkill(args.Name)
+		WithExecute(llm.TypedExecute(func(ctx context.Context, args ActivateSkillInput) (llm.ToolResultOutput, error) {
+			// The skill becomes active in the calling session only
+			content, err := skillsManager.ActivateSkill(args.Name, skillPolicyFrom(ctx))
 			if err != nil {
 				return llm.NewTextErrorResponse(err.Error()), nil
 			}
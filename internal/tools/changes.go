@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/diff"
+)
+
+// changeSetMemory is how many bytes of original content a ChangeSet keeps in
+// memory. Originals that would go past it are copied to a temporary
+// directory instead.
+var changeSetMemory int64 = 4 << 20
+
+// ChangeSet records the files write_file, edit_file, and replace_lines change
+// in a session, with the content each had before its first change, so the
+// session can show everything it changed (:diff) and undo it (:revert). Like
+// the todo list, each session owns one and hands it to the tools through the
+// context of its requests (see WithChangeSet).
+type ChangeSet struct {
+	mu       sync.Mutex
+	files    map[string]*fileChange
+	order    []string // paths in the order of their first change
+	inMemory int64    // bytes of originals held in memory
+	spillDir string   // temporary directory of larger originals; "" until needed
+	onChange func()   // called after every change, without the lock held
+}
+
+// fileChange is what a ChangeSet knows about one file.
+type fileChange struct {
+	existed  bool        // the file existed before its first change
+	mode     fs.FileMode // its permissions then
+	original []byte      // its content then, unless it was spilled
+	spill    string      // the copy of its content in the spill directory, or ""
+	before   [sha256.Size]byte
+	after    [sha256.Size]byte // hash of the content the last change left
+	edits    int
+}
+
+// FileChange describes a changed file.
+type FileChange struct {
+	Path  string
+	New   bool // the session created the file
+	Gone  bool // the session deleted the file
+	Edits int  // changes made to it
+}
+
+// NewChangeSet creates an empty change set. onChange, when not nil, is
+// called after every recorded change and revert.
+func NewChangeSet(onChange func()) *ChangeSet {
+	return &ChangeSet{files: make(map[string]*fileChange), onChange: onChange}
+}
+
+type changeSetKey struct{}
+
+// WithChangeSet returns a context carrying the change set the file tools
+// record their changes in.
+func WithChangeSet(ctx context.Context, cs *ChangeSet) context.Context {
+	return context.WithValue(ctx, changeSetKey{}, cs)
+}
+
+// changeSetFrom returns the change set carried by ctx, or nil.
+func changeSetFrom(ctx context.Context) *ChangeSet {
+	cs, _ := ctx.Value(changeSetKey{}).(*ChangeSet)
+	return cs
+}
+
+// changeKey is the key of path in a ChangeSet.
+func changeKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// snapshot keeps the content of path before the first change the session
+// makes to it. The file tools call it just before they write; a nil change
+// set does nothing.
+func (cs *ChangeSet) snapshot(path string) error {
+	if cs == nil {
+		return nil
+	}
+	key := changeKey(path)
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if _, ok := cs.files[key]; ok {
+		return nil
+	}
+
+	fc := &fileChange{}
+	info, err := os.Stat(key)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fc.before = sha256.Sum256(nil)
+	case err != nil:
+		return err
+	default:
+		fc.existed = true
+		fc.mode = info.Mode().Perm()
+		if err := cs.keepOriginal(fc, key, info.Size()); err != nil {
+			return fmt.Errorf("failed to save the original of %s: %v", key, err)
+		}
+	}
+	fc.after = fc.before
+	cs.files[key] = fc
+	cs.order = append(cs.order, key)
+	return nil
+}
+
+// keepOriginal reads the content of path into fc, or copies it to the spill
+// directory when it does not fit in memory.
+func (cs *ChangeSet) keepOriginal(fc *fileChange, path string, size int64) error {
+	if cs.inMemory+size <= changeSetMemory {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fc.original = data
+		fc.before = sha256.Sum256(data)
+		cs.inMemory += int64(len(data))
+		return nil
+	}
+
+	if cs.spillDir == "" {
+		dir, err := os.MkdirTemp("", "alayacore-changes-*")
+		if err != nil {
+			return err
+		}
+		cs.spillDir = dir
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp(cs.spillDir, "original-*")
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(dst, h), src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	fc.spill = dst.Name()
+	copy(fc.before[:], h.Sum(nil))
+	return nil
+}
+
+// record notes a change the file tools made to path, after snapshot, once
+// the write succeeded. A nil change set does nothing.
+func (cs *ChangeSet) record(path string) {
+	if cs == nil {
+		return
+	}
+	key := changeKey(path)
+	cs.mu.Lock()
+	fc, ok := cs.files[key]
+	if ok {
+		fc.after = hashFile(key)
+		fc.edits++
+	}
+	cs.mu.Unlock()
+	if ok && cs.onChange != nil {
+		cs.onChange()
+	}
+}
+
+// hashFile returns the hash of the content of path, which is that of no
+// content when the file does not exist.
+func hashFile(path string) [sha256.Size]byte {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sha256.Sum256(nil)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sha256.Sum256(nil)
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Files returns the files whose content the session's changes left different
+// from the original, in the order they were first changed. A file changed
+// back to its original content is not listed.
+func (cs *ChangeSet) Files() []FileChange {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	var files []FileChange
+	for _, path := range cs.order {
+		fc := cs.files[path]
+		_, err := os.Lstat(path)
+		gone := fc.existed && errors.Is(err, fs.ErrNotExist)
+		if fc.edits == 0 || fc.after == fc.before && !gone {
+			continue
+		}
+		files = append(files, FileChange{Path: path, New: !fc.existed, Gone: gone, Edits: fc.edits})
+	}
+	return files
+}
+
+// Len returns the number of files Files lists; 0 for a nil change set.
+func (cs *ChangeSet) Len() int {
+	if cs == nil {
+		return 0
+	}
+	return len(cs.Files())
+}
+
+// Diff returns the unified diff from the original content of path to its
+// content on disk now, or of every changed file when path is "". It is ""
+// when nothing differs.
+func (cs *ChangeSet) Diff(path string) (string, error) {
+	paths, err := cs.paths(path)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, p := range paths {
+		d, err := cs.diffFile(p)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(d)
+	}
+	return sb.String(), nil
+}
+
+// paths returns the recorded path of path, or all recorded paths when it is
+// "".
+func (cs *ChangeSet) paths(path string) ([]string, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if path == "" {
+		return append([]string(nil), cs.order...), nil
+	}
+	key := changeKey(path)
+	if _, ok := cs.files[key]; !ok {
+		return nil, fmt.Errorf("no changes recorded for %s", key)
+	}
+	return []string{key}, nil
+}
+
+func (cs *ChangeSet) diffFile(path string) (string, error) {
+	original, err := cs.original(path)
+	if err != nil {
+		return "", err
+	}
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if bytes.Equal(original, current) {
+		return "", nil
+	}
+	if bytes.IndexByte(original, 0) >= 0 || bytes.IndexByte(current, 0) >= 0 {
+		return fmt.Sprintf("Binary file %s changed\n", path), nil
+	}
+	return diff.Unified(path, string(original), string(current)), nil
+}
+
+// original returns the content path had before the session changed it; nil
+// when it did not exist.
+func (cs *ChangeSet) original(path string) ([]byte, error) {
+	cs.mu.Lock()
+	fc := cs.files[path]
+	cs.mu.Unlock()
+	if fc == nil {
+		return nil, fmt.Errorf("no changes recorded for %s", path)
+	}
+	if fc.spill != "" {
+		return os.ReadFile(fc.spill)
+	}
+	return fc.original, nil
+}
+
+// Revert puts path back as it was before the session first changed it:
+// its original content, or no file when the session created it. The change
+// set forgets the file, so a later change starts from the restored content.
+func (cs *ChangeSet) Revert(path string) error {
+	paths, err := cs.paths(path)
+	if err != nil {
+		return err
+	}
+	key := paths[0]
+	cs.mu.Lock()
+	fc := cs.files[key]
+	cs.mu.Unlock()
+	if fc == nil {
+		return fmt.Errorf("no changes recorded for %s", key)
+	}
+
+	if !fc.existed {
+		if err := os.Remove(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	} else {
+		original, err := cs.original(key)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(key, original, fc.mode); err != nil {
+			return err
+		}
+	}
+
+	cs.mu.Lock()
+	delete(cs.files, key)
+	for i, p := range cs.order {
+		if p == key {
+			cs.order = append(cs.order[:i], cs.order[i+1:]...)
+			break
+		}
+	}
+	if fc.spill != "" {
+		os.Remove(fc.spill)
+	} else {
+		cs.inMemory -= int64(len(fc.original))
+	}
+	cs.mu.Unlock()
+	if cs.onChange != nil {
+		cs.onChange()
+	}
+	return nil
+}
+
+// Close removes the copies of large originals, when the session ends.
+func (cs *ChangeSet) Close() error {
+	if cs == nil {
+		return nil
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.spillDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(cs.spillDir)
+	cs.spillDir = ""
+	return err
+}
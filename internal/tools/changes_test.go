@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/diff"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// runTool calls tool with args in ctx and fails on an error answer.
+func runTool(t *testing.T, ctx context.Context, tool llm.Tool, args any) {
+	t.Helper()
+	input, _ := json.Marshal(args)
+	result, err := tool.Execute(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := result.(llm.ToolResultOutputError); ok {
+		t.Fatal(e.Error)
+	}
+}
+
+func TestChangeSetMultipleEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	original := "a\nb\nc\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	changes := 0
+	cs := NewChangeSet(func() { changes++ })
+	ctx := WithChangeSet(context.Background(), cs)
+
+	runTool(t, ctx, NewReplaceLinesTool(), ReplaceLinesInput{Path: path, StartLine: "2", EndLine: "2", NewContent: "B"})
+	runTool(t, ctx, NewEditFileTool(), EditFileInput{Path: path, OldString: "c", NewString: "C"})
+	runTool(t, ctx, NewEditFileTool(), EditFileInput{Path: path, Diff: "--- a/f.txt\n+++ b/f.txt\n@@ -1,3 +1,4 @@\n a\n B\n C\n+d\n"})
+	created := filepath.Join(dir, "new.txt")
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: created, Content: "hello\n"})
+
+	files := cs.Files()
+	if len(files) != 2 || changes != 4 {
+		t.Fatalf("files = %+v after %d changes", files, changes)
+	}
+	if files[0].Path != path || files[0].Edits != 3 || files[0].New {
+		t.Errorf("edited file = %+v", files[0])
+	}
+	if files[1].Path != created || !files[1].New {
+		t.Errorf("created file = %+v", files[1])
+	}
+
+	// The diff runs from the content before the first edit
+	got, err := cs.Diff(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := diff.Unified(path, original, "a\nB\nC\nd\n"); got != want {
+		t.Errorf("Diff(%s) =\n%s\nwant\n%s", path, got, want)
+	}
+	all, err := cs.Diff("")
+	if err != nil || !strings.HasPrefix(all, got) || !strings.Contains(all, "+hello") {
+		t.Errorf("Diff() = %q, %v", all, err)
+	}
+
+	if err := cs.Revert(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if string(data) != original || info.Mode().Perm() != 0640 {
+		t.Errorf("reverted to %q, mode %v", data, info.Mode().Perm())
+	}
+	if err := cs.Revert(created); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("a created file should be removed, stat: %v", err)
+	}
+	if cs.Len() != 0 || changes != 6 {
+		t.Errorf("%d files left after reverting, %d changes", cs.Len(), changes)
+	}
+	if err := cs.Revert(path); err == nil || !strings.Contains(err.Error(), "no changes recorded") {
+		t.Errorf("second revert: %v", err)
+	}
+
+	// After a revert, the next edit starts from the restored content
+	runTool(t, ctx, NewEditFileTool(), EditFileInput{Path: path, OldString: "a", NewString: "x"})
+	if got, _ := cs.Diff(path); got != diff.Unified(path, original, "x\nb\nc\n") {
+		t.Errorf("Diff after revert and edit = %q", got)
+	}
+}
+
+func TestChangeSetIgnoresChangesUndone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	if err := os.WriteFile(path, []byte("same\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cs := NewChangeSet(nil)
+	ctx := WithChangeSet(context.Background(), cs)
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: path, Content: "other\n"})
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: path, Content: "same\n"})
+	if cs.Len() != 0 {
+		t.Errorf("files = %+v, want none", cs.Files())
+	}
+	if got, err := cs.Diff(""); got != "" || err != nil {
+		t.Errorf("Diff() = %q, %v", got, err)
+	}
+}
+
+func TestChangeSetSpillsLargeFiles(t *testing.T) {
+	defer func(limit int64) { changeSetMemory = limit }(changeSetMemory)
+	changeSetMemory = 16
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	large := filepath.Join(dir, "large.txt")
+	largeContent := strings.Repeat("line\n", 100)
+	if err := os.WriteFile(small, []byte("small\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte(largeContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cs := NewChangeSet(nil)
+	ctx := WithChangeSet(context.Background(), cs)
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: small, Content: "x\n"})
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: large, Content: "y\n"})
+
+	if cs.files[small].spill != "" || cs.files[large].spill == "" || cs.files[large].original != nil {
+		t.Fatalf("small spilled to %q, large to %q", cs.files[small].spill, cs.files[large].spill)
+	}
+	if got, _ := cs.Diff(large); got != diff.Unified(large, largeContent, "y\n") {
+		t.Errorf("Diff of a spilled file = %q", got)
+	}
+	if err := cs.Revert(large); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(large); string(data) != largeContent {
+		t.Errorf("reverted to %q", data)
+	}
+
+	spillDir := cs.spillDir
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(spillDir); !os.IsNotExist(err) {
+		t.Errorf("the spill directory is left: %v", err)
+	}
+}
+
+func TestChangeSetSkipsRejectedChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	cs := NewChangeSet(nil)
+	ctx := WithDryRunMode(WithChangeSet(context.Background(), cs), true)
+	runTool(t, ctx, NewWriteFileTool(), WriteFileInput{Path: path, Content: "x\n"})
+	if len(cs.files) != 0 {
+		t.Errorf("a dry run recorded %+v", cs.files)
+	}
+}
@@ -0,0 +1,431 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// CommandAction is what a command rule does with the commands it matches.
+type CommandAction string
+
+const (
+	CommandAllow CommandAction = "allow" // run without asking
+	CommandDeny  CommandAction = "deny"  // refuse, with an error to the model
+	CommandAsk   CommandAction = "ask"   // run once the user approves it, as a review
+)
+
+// CommandRule is one rule of a CommandPolicy, written "ACTION NAME [REGEX]":
+// NAME is the command to match, such as rm, or * for any command, and REGEX,
+// the rest of the rule, must also match the full command line. Examples:
+//
+//	allow ls
+//	deny sudo
+//	deny rm -\w*[rR]\w*f|-\w*f\w*[rR]
+//	deny * curl[^|]*\|\s*(ba|z)?sh\b
+//	ask *
+type CommandRule struct {
+	Action  CommandAction
+	Name    string         // command name, or "*"
+	Pattern *regexp.Regexp // nil matches any command line
+}
+
+// ParseCommandRule parses a rule written "ACTION NAME [REGEX]".
+func ParseCommandRule(s string) (CommandRule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return CommandRule{}, fmt.Errorf("invalid command rule %q: expected ACTION NAME [REGEX], e.g. \"deny sudo\"", s)
+	}
+	rule := CommandRule{Action: CommandAction(strings.ToLower(fields[0])), Name: fields[1]}
+	switch rule.Action {
+	case CommandAllow, CommandDeny, CommandAsk:
+	default:
+		return CommandRule{}, fmt.Errorf("invalid command rule %q: the action must be allow, deny, or ask", s)
+	}
+	// The regex is the rest of the rule, spaces included
+	rest := strings.TrimSpace(s)
+	for range 2 {
+		rest = strings.TrimSpace(rest[len(strings.Fields(rest)[0]):])
+	}
+	if rest != "" {
+		re, err := regexp.Compile(rest)
+		if err != nil {
+			return CommandRule{}, fmt.Errorf("invalid command rule %q: %w", s, err)
+		}
+		rule.Pattern = re
+	}
+	return rule, nil
+}
+
+// String returns the rule as written.
+func (r CommandRule) String() string {
+	s := string(r.Action) + " " + r.Name
+	if r.Pattern != nil {
+		s += " " + r.Pattern.String()
+	}
+	return s
+}
+
+// match returns the name under which the rule applies to a command run
+// under names in the command line line: the wrapper for "deny sudo" on
+// "sudo rm", the command for "deny rm" or "deny *".
+func (r CommandRule) match(names []string, line string) (string, bool) {
+	if r.Pattern != nil && !r.Pattern.MatchString(line) {
+		return "", false
+	}
+	if r.Name == "*" {
+		return names[len(names)-1], true
+	}
+	want := path.Base(r.Name)
+	for _, name := range names {
+		if name == want {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// CommandVerdict is the policy's answer for a command line.
+type CommandVerdict struct {
+	Action  CommandAction
+	Rule    int         // 1-based number of the deciding rule; 0 when no rule matched
+	Matched CommandRule // the deciding rule
+	Command string      // the command the rule matched, e.g. "rm"
+	Reason  string      // why the verdict was reached without a rule, e.g. a parse error
+	Session bool        // the deciding rule is one of the session's (WithCommandRules)
+}
+
+// String explains a verdict, e.g. "rule 2 (deny sudo) matches sudo".
+func (v CommandVerdict) String() string {
+	if v.Rule == 0 {
+		return v.Reason
+	}
+	scope := "rule"
+	if v.Session {
+		scope = "session rule"
+	}
+	return fmt.Sprintf("%s %d (%s) matches %s", scope, v.Rule, v.Matched, v.Command)
+}
+
+// strictness orders the actions from allow to deny.
+var strictness = map[CommandAction]int{CommandAllow: 0, CommandAsk: 1, CommandDeny: 2}
+
+// stricter returns the verdict of a and b that does less, a when they agree.
+func stricter(a, b CommandVerdict) CommandVerdict {
+	if strictness[b.Action] > strictness[a.Action] {
+		return b
+	}
+	return a
+}
+
+// CommandPolicy decides whether posix_shell may run a command line. Each
+// command of the line, including those of pipelines, lists, subshells,
+// command substitutions, "sh -c" strings, and those run through wrappers
+// such as env, command, sudo, or xargs, gets the action of the first rule
+// that matches it, or allow when none does. The line is denied when any of
+// its commands is, asks when any asks, and runs otherwise. A line that does
+// not parse asks.
+//
+// The rules may change while commands are checked; it is safe for concurrent
+// use. A nil policy allows everything.
+type CommandPolicy struct {
+	mu    sync.RWMutex
+	rules []CommandRule
+}
+
+// NewCommandPolicy parses the rules of --command-rule.
+func NewCommandPolicy(rules []string) (*CommandPolicy, error) {
+	p := &CommandPolicy{}
+	for _, s := range rules {
+		rule, err := ParseCommandRule(s)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p, nil
+}
+
+// Rules returns the rules in order.
+func (p *CommandPolicy) Rules() []CommandRule {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]CommandRule(nil), p.rules...)
+}
+
+// Insert adds rule before rule number n, counted from 1; n past the last
+// rule appends it.
+func (p *CommandPolicy) Insert(n int, rule CommandRule) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n < 1 {
+		return fmt.Errorf("no rule %d: rules are numbered from 1", n)
+	}
+	n = min(n, len(p.rules)+1)
+	p.rules = append(p.rules[:n-1], append([]CommandRule{rule}, p.rules[n-1:]...)...)
+	return nil
+}
+
+// Append adds rule after the others.
+func (p *CommandPolicy) Append(rule CommandRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rule)
+}
+
+// Remove deletes rule number n, counted from 1, and returns it.
+func (p *CommandPolicy) Remove(n int) (CommandRule, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n < 1 || n > len(p.rules) {
+		return CommandRule{}, fmt.Errorf("no rule %d (there are %d)", n, len(p.rules))
+	}
+	rule := p.rules[n-1]
+	p.rules = append(p.rules[:n-1], p.rules[n:]...)
+	return rule, nil
+}
+
+// Evaluate decides what to do with line.
+func (p *CommandPolicy) Evaluate(line string) CommandVerdict {
+	rules := p.Rules()
+	if len(rules) == 0 {
+		return CommandVerdict{Action: CommandAllow}
+	}
+	commands, err := ParseCommands(line)
+	if err != nil {
+		return CommandVerdict{Action: CommandAsk, Reason: "the command could not be parsed: " + err.Error()}
+	}
+
+	verdict := CommandVerdict{Action: CommandAllow}
+	for _, names := range commands {
+		for i, rule := range rules {
+			name, ok := rule.match(names, line)
+			if !ok {
+				continue
+			}
+			if rule.Action == CommandDeny {
+				return CommandVerdict{Action: CommandDeny, Rule: i + 1, Matched: rule, Command: name}
+			}
+			if rule.Action == CommandAsk && verdict.Action == CommandAllow {
+				verdict = CommandVerdict{Action: CommandAsk, Rule: i + 1, Matched: rule, Command: name}
+			}
+			break
+		}
+	}
+	return verdict
+}
+
+// commandWrappers run the command given in their arguments.
+var commandWrappers = map[string]bool{
+	"builtin": true, "command": true, "doas": true, "env": true, "exec": true,
+	"nice": true, "nohup": true, "stdbuf": true, "sudo": true, "time": true,
+	"timeout": true, "xargs": true,
+}
+
+// shells run the string given with -c.
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "ksh": true, "zsh": true}
+
+// ParseCommands returns the commands line runs, each as the names it runs
+// under: "env rm -rf x" gives [env rm], "ls | sudo rm x" gives [ls] and
+// [sudo rm]. A command whose name is only known at run time, such as
+// "$EDITOR x", is named "?".
+func ParseCommands(line string) ([][]string, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil, err
+	}
+	var commands [][]string
+	var walkErr error
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 || walkErr != nil {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			args[i] = wordText(word)
+		}
+		names, inner := unwrapCommand(args)
+		commands = append(commands, names)
+		if inner != "" {
+			nested, err := ParseCommands(inner)
+			if err != nil {
+				walkErr = err
+				return false
+			}
+			commands = append(commands, nested...)
+		}
+		return true
+	})
+	return commands, walkErr
+}
+
+// unwrapCommand returns the names a command runs under, following
+// wrappers, and the script it runs with sh -c or eval, if any.
+func unwrapCommand(args []string) (names []string, script string) {
+	for len(args) > 0 {
+		name := args[0]
+		if name != "?" {
+			name = path.Base(name)
+		}
+		names = append(names, name)
+		args = args[1:]
+		switch {
+		case name == "eval":
+			return names, strings.Join(args, " ")
+		case shells[name]:
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "-") && strings.Contains(arg, "c") && !strings.HasPrefix(arg, "--") && i+1 < len(args) {
+					return names, args[i+1]
+				}
+			}
+			return names, ""
+		case !commandWrappers[name]:
+			return names, ""
+		}
+		args = wrappedArgs(name, args)
+	}
+	return names, ""
+}
+
+// wrappedArgs skips the options of a wrapper, and its own operands, up to
+// the command it runs. Options taking a value are not told apart, so
+// "nice -n 5 rm" finds "5"; a rule for rm still matches "nice -n5 rm".
+func wrappedArgs(wrapper string, args []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "--":
+			return args[1:]
+		case strings.HasPrefix(arg, "-"):
+			args = args[1:]
+		case wrapper == "env" && strings.Contains(arg, "="):
+			args = args[1:]
+		case wrapper == "timeout" && len(arg) > 0 && arg[0] >= '0' && arg[0] <= '9':
+			// The duration
+			args = args[1:]
+			wrapper = ""
+		default:
+			return args
+		}
+	}
+	return nil
+}
+
+// wordText returns the literal value of a word, with quotes removed, or "?"
+// when it depends on expansions.
+func wordText(word *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "?"
+				}
+				b.WriteString(lit.Value)
+			}
+		default:
+			return "?"
+		}
+	}
+	return b.String()
+}
+
+type commandRulesKey struct{}
+
+// WithCommandRules returns a context carrying the rules a session added with
+// :policy. posix_shell checks a command against them as well as against the
+// policy it was wrapped with, and takes the stricter verdict, so they can
+// only tighten that policy.
+func WithCommandRules(ctx context.Context, rules *CommandPolicy) context.Context {
+	return context.WithValue(ctx, commandRulesKey{}, rules)
+}
+
+// commandRulesFrom returns the session rules carried by ctx, or nil.
+func commandRulesFrom(ctx context.Context) *CommandPolicy {
+	rules, _ := ctx.Value(commandRulesKey{}).(*CommandPolicy)
+	return rules
+}
+
+// WithCommandPolicy wraps posix_shell so each command line is checked
+// against policy, and the session's rules in the request context (see
+// WithCommandRules), before it runs. Denied commands answer with an error
+// naming the rule. Commands to ask about are sent to the reviewer in the
+// request context (see WithReviewer) and run once accepted; without an
+// answer within timeout, or without a reviewer, they are refused. A dry run
+// does not ask, as nothing runs.
+func WithCommandPolicy(tool llm.Tool, policy *CommandPolicy, timeout time.Duration) llm.Tool {
+	if timeout <= 0 {
+		timeout = DefaultReviewTimeout
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		var args struct {
+			Command string `json:"command"`
+		}
+		if json.Unmarshal(input, &args) != nil || args.Command == "" {
+			return execute(ctx, input)
+		}
+		verdict := policy.Evaluate(args.Command)
+		if rules := commandRulesFrom(ctx); rules != nil {
+			own := rules.Evaluate(args.Command)
+			own.Session = true
+			verdict = stricter(verdict, own)
+		}
+		switch verdict.Action {
+		case CommandDeny:
+			return llm.NewTextErrorResponse(fmt.Sprintf("command denied by policy: %s. Do not retry it; use another approach or ask the user", verdict.String())), nil
+		case CommandAsk:
+			if dryRunFrom(ctx) {
+				break
+			}
+			if out := askCommand(ctx, tool.Definition.Name, args.Command, verdict.String(), timeout); out != nil {
+				return out, nil
+			}
+		}
+		return execute(ctx, input)
+	}
+	return tool
+}
+
+// askCommand shows a command to the reviewer. It returns nil when the user
+// accepted it, and the tool's answer to the model otherwise.
+func askCommand(ctx context.Context, tool, command, why string, timeout time.Duration) llm.ToolResultOutput {
+	reviewer, _ := ctx.Value(reviewerKey{}).(Reviewer)
+	if reviewer == nil {
+		return llm.NewTextErrorResponse(fmt.Sprintf("command needs the user's approval (%s), but no one can approve it here", why))
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	decision, err := reviewer.ReviewChange(ctx, Change{Tool: tool, Command: command, Diff: "$ " + command + "\n"})
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return llm.NewTextErrorResponse(fmt.Sprintf("command rejected: the user did not approve it within %s", timeout))
+	case err != nil:
+		return llm.NewTextErrorResponse(err.Error())
+	case !decision.Accepted && decision.Reason != "":
+		return llm.NewTextErrorResponse("command rejected by user: " + decision.Reason)
+	case !decision.Accepted:
+		return llm.NewTextErrorResponse("command rejected by user")
+	}
+	return nil
+}
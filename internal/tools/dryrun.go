@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/diff"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// DryRunPrefix starts every answer of a simulated tool call.
+const DryRunPrefix = "[dry-run] "
+
+type dryRunKey struct{}
+
+// WithDryRunMode returns a context in which the tools wrapped with WithDryRun
+// report what they would do instead of doing it.
+func WithDryRunMode(ctx context.Context, on bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, on)
+}
+
+// dryRunFrom reports whether ctx asks for a dry run.
+func dryRunFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(dryRunKey{}).(bool)
+	return on
+}
+
+// executeFunc is the Execute function of an llm.Tool.
+type executeFunc = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error)
+
+// dryRunSimulations hold, by tool name, how a tool that changes something is
+// simulated. Tools not listed, such as read_file, run as usual in a dry run.
+var dryRunSimulations = map[string]func(ctx context.Context, input json.RawMessage, execute executeFunc) (llm.ToolResultOutput, error){
+	"posix_shell":   simulateShell,
+	"git":           simulateGit,
+	"write_file":    simulateFileChange,
+	"edit_file":     simulateFileChange,
+	"replace_lines": simulateFileChange,
+	"save_memory":   simulateSaveMemory,
+}
+
+// WithDryRun wraps a tool so that, in dry-run mode (see WithDryRunMode), it
+// answers with what it would do and changes nothing: commands are not run
+// and files are not written. Tools that only read are returned unchanged.
+func WithDryRun(tool llm.Tool) llm.Tool {
+	simulate, ok := dryRunSimulations[tool.Definition.Name]
+	if !ok {
+		return tool
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		if !dryRunFrom(ctx) {
+			return execute(ctx, input)
+		}
+		return simulate(ctx, input, execute)
+	}
+	return tool
+}
+
+func simulateShell(_ context.Context, input json.RawMessage, _ executeFunc) (llm.ToolResultOutput, error) {
+	var args PosixShellInput
+	if err := json.Unmarshal(input, &args); err != nil {
+		return llm.NewTextErrorResponse("invalid input: " + err.Error()), nil
+	}
+	return llm.NewTextResponse(DryRunPrefix + "would execute: " + args.Command), nil
+}
+
+// gitReadOnly are the git operations that run in a dry run.
+var gitReadOnly = map[string]bool{"status": true, "diff": true, "log": true, "branch_list": true, "show": true}
+
+func simulateGit(ctx context.Context, input json.RawMessage, execute executeFunc) (llm.ToolResultOutput, error) {
+	var args GitInput
+	if err := json.Unmarshal(input, &args); err != nil {
+		return llm.NewTextErrorResponse("invalid input: " + err.Error()), nil
+	}
+	if gitReadOnly[args.Operation] {
+		return execute(ctx, input)
+	}
+	gitArgs, err := gitCommand(args)
+	if err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+	quoted := make([]string, len(gitArgs))
+	for i, arg := range gitArgs {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return llm.NewTextResponse(DryRunPrefix + "would run: git " + strings.Join(quoted, " ")), nil
+}
+
+// simulateFileChange runs the file tool, which stops where the change would
+// be written (see reviewChange) and answers with its diff instead.
+func simulateFileChange(ctx context.Context, input json.RawMessage, execute executeFunc) (llm.ToolResultOutput, error) {
+	return execute(ctx, input)
+}
+
+func simulateSaveMemory(_ context.Context, input json.RawMessage, _ executeFunc) (llm.ToolResultOutput, error) {
+	var args SaveMemoryInput
+	if err := json.Unmarshal(input, &args); err != nil {
+		return llm.NewTextErrorResponse("invalid input: " + err.Error()), nil
+	}
+	return llm.NewTextResponse(DryRunPrefix + "would save memory " + strings.TrimSpace(args.Key) + ": " + args.Content), nil
+}
+
+// dryRunChange answers a file change in a dry run, or returns nil outside
+// one.
+func dryRunChange(ctx context.Context, path string, current, next []byte) llm.ToolResultOutput {
+	if !dryRunFrom(ctx) {
+		return nil
+	}
+	patch := diff.Unified(path, string(current), string(next))
+	if patch == "" {
+		return llm.NewTextResponse(DryRunPrefix + "no change to " + path)
+	}
+	return llm.NewTextResponse(DryRunPrefix + "would change " + path + ":\n" + patch)
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/hooks"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestDryRunLeavesFilesUntouched(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithDryRunMode(context.Background(), true)
+
+	run := func(tool llm.Tool, input any) string {
+		t.Helper()
+		data, _ := json.Marshal(input)
+		out, err := WithDryRun(tool).Execute(ctx, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, ok := out.(llm.ToolResultOutputText)
+		if !ok {
+			t.Fatalf("%s failed: %#v", tool.Definition.Name, out)
+		}
+		return text.Text
+	}
+
+	out := run(NewWriteFileTool(), WriteFileInput{Path: path, Content: "package app\n"})
+	if !strings.HasPrefix(out, "[dry-run] would change "+path) || !strings.Contains(out, "-package main\n+package app") {
+		t.Errorf("write_file = %q", out)
+	}
+	newPath := filepath.Join(dir, "new.go")
+	if out := run(NewWriteFileTool(), WriteFileInput{Path: newPath, Content: "x\n"}); !strings.Contains(out, "+x") {
+		t.Errorf("write_file of a new file = %q", out)
+	}
+	out = run(NewEditFileTool(), EditFileInput{Path: path, OldString: "main", NewString: "app"})
+	if !strings.Contains(out, "+package app") {
+		t.Errorf("edit_file = %q", out)
+	}
+	marker := filepath.Join(dir, "ran")
+	if out := run(NewPosixShellToolWithShell(DefaultShell), PosixShellInput{Command: "touch " + marker}); out != "[dry-run] would execute: touch "+marker {
+		t.Errorf("posix_shell = %q", out)
+	}
+	// Reading still works
+	if out := run(NewReadFileTool(), ReadFileInput{Path: path, LineNumbers: new(false)}); out != "package main\n" {
+		t.Errorf("read_file = %q", out)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "package main\n" {
+		t.Errorf("the file was changed to %q", data)
+	}
+	for _, p := range []string{newPath, marker} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s was created", p)
+		}
+	}
+}
+
+func TestDryRunGit(t *testing.T) {
+	newGitRepo(t)
+	ctx := WithDryRunMode(context.Background(), true)
+	tool := WithDryRun(NewGitTool(EnvFilter{}))
+	execute := func(input GitInput) string {
+		data, _ := json.Marshal(input)
+		out, err := tool.Execute(ctx, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, _ := out.(llm.ToolResultOutputText)
+		return text.Text
+	}
+
+	if out := execute(GitInput{Operation: "commit", Message: "Fix it"}); out != `[dry-run] would run: git commit -m "Fix it"` {
+		t.Errorf("commit = %q", out)
+	}
+	if out := execute(GitInput{Operation: "status"}); !strings.Contains(out, "## No commits yet on main") {
+		t.Errorf("status should run, got %q", out)
+	}
+}
+
+func TestDryRunSkipsHooks(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "hook-ran")
+	h := &hooks.Hooks{Shell: "/bin/sh", Hooks: []hooks.Hook{
+		{Event: hooks.PreTool, Tool: "posix_shell", Command: "touch " + marker},
+	}}
+	tool := WithHooks(WithDryRun(NewPosixShellToolWithShell(DefaultShell)), h)
+
+	data, _ := json.Marshal(PosixShellInput{Command: "true"})
+	if _, err := tool.Execute(WithDryRunMode(context.Background(), true), data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("the pre_tool hook ran in a dry run")
+	}
+}
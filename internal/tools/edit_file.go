@@ -12,15 +12,16 @@ import (
 // EditFileInput represents the input for the edit_file tool
 type EditFileInput struct {
 	Path      string `json:"path" jsonschema:"required,description=The path of the file to edit"`
-	OldString string `json:"old_string" jsonschema:"required,description=The exact text to find and replace (must match exactly)"`
-	NewString string `json:"new_string" jsonschema:"required,description=The replacement text"`
+	OldString string `json:"old_string,omitempty" jsonschema:"description=The exact text to find and replace (must match exactly)"`
+	NewString string `json:"new_string,omitempty" jsonschema:"description=The replacement text"`
+	Diff      string `json:"diff,omitempty" jsonschema:"description=A unified diff to apply instead of old_string/new_string"`
 }
 
 // NewEditFileTool creates a tool for editing files using search/replace
 func NewEditFileTool() llm.Tool {
 	return llm.NewTool(
 		"edit_file",
-		`Apply a search/replace edit to a file.
+		`Apply a search/replace edit or a unified diff to a file.
 
 CRITICAL: Read the file first to get the exact text including whitespace.
 
@@ -28,6 +29,9 @@ Parameters:
 - path: The file path to edit
 - old_string: The exact text to find (must match exactly including all whitespace, indentation, newlines)
 - new_string: The replacement text
+- diff: Alternatively, a unified diff (@@ -l,s +l,s @@ hunks with context) for path.
+  Use it for several changes in one call or to create a file (--- /dev/null).
+  If a hunk fails, the error names it; fix that hunk and resend the diff.
 
 Requirements:
 - old_string must match EXACTLY (every space, tab, newline, character)
@@ -120,12 +124,16 @@ func (se *streamEditor) flushRemaining(tempFile *os.File) error {
 	return nil
 }
 
-func executeEditFile(_ context.Context, args EditFileInput) (llm.ToolResultOutput, error) {
+func executeEditFile(ctx context.Context, args EditFileInput) (llm.ToolResultOutput, error) {
 	if args.Path == "" {
 		return llm.NewTextErrorResponse("path is required"), nil
 	}
+	args.Path = ResolvePath(ctx, args.Path)
+	if args.Diff != "" {
+		return applyDiff(ctx, args.Path, args.Diff), nil
+	}
 	if args.OldString == "" {
-		return llm.NewTextErrorResponse("old_string is required"), nil
+		return llm.NewTextErrorResponse("old_string is required unless diff is given"), nil
 	}
 
 	file, err := os.Open(args.Path)
@@ -172,11 +180,19 @@ func executeEditFile(_ context.Context, args EditFileInput) (llm.ToolResultOutpu
 			fmt.Sprintf("old_string not found in file. Make sure to copy the exact text including all whitespace and indentation.\n\nSearched for:\n%q", args.OldString)), nil
 	}
 
+	if out := reviewChange(ctx, "edit_file", args.Path, func() ([]byte, error) { return os.ReadFile(tempPath) }); out != nil {
+		return out, nil
+	}
+
 	fileInfo, err := os.Stat(args.Path)
 	if err != nil {
 		return llm.NewTextErrorResponse(fmt.Sprintf("failed to get file info: %v", err)), nil
 	}
 
+	changes := changeSetFrom(ctx)
+	if err = changes.snapshot(args.Path); err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
 	if err = os.Rename(tempPath, args.Path); err != nil {
 		return llm.NewTextErrorResponse(fmt.Sprintf("failed to replace file: %v", err)), nil
 	}
@@ -184,6 +200,7 @@ func executeEditFile(_ context.Context, args EditFileInput) (llm.ToolResultOutpu
 	if err = os.Chmod(args.Path, fileInfo.Mode()); err != nil {
 		return llm.NewTextErrorResponse(fmt.Sprintf("failed to restore file permissions: %v", err)), nil
 	}
+	changes.record(args.Path)
 
 	return llm.NewTextResponse("File edited successfully"), nil
 }
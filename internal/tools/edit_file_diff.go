@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alayacore/alayacore/internal/diff"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// applyDiff applies a unified diff to path. A failing hunk is returned to the
+// model verbatim so it can regenerate just that part.
+func applyDiff(ctx context.Context, path, text string) llm.ToolResultOutput {
+	files, err := diff.Parse(text)
+	if err != nil {
+		return llm.NewTextErrorResponse(fmt.Sprintf("invalid diff: %v", err))
+	}
+	file, err := diffForPath(files, path)
+	if err != nil {
+		return llm.NewTextErrorResponse(err.Error())
+	}
+
+	var content []byte
+	mode := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if file.IsNew() {
+			return llm.NewTextErrorResponse(fmt.Sprintf("diff creates %s but the file already exists", path))
+		}
+		mode = info.Mode()
+		if content, err = os.ReadFile(path); err != nil {
+			return llm.NewTextErrorResponse(err.Error())
+		}
+	case os.IsNotExist(err):
+		if !file.IsNew() {
+			return llm.NewTextErrorResponse(fmt.Sprintf("file not found: %s", path))
+		}
+	default:
+		return llm.NewTextErrorResponse(err.Error())
+	}
+
+	result, err := diff.Apply(string(content), file)
+	if err != nil {
+		var hunkErr *diff.HunkError
+		if errors.As(err, &hunkErr) {
+			return llm.NewTextErrorResponse(fmt.Sprintf(
+				"%v. No changes were made. Re-read the file and regenerate this hunk:\n\n%s",
+				err, hunkErr.Hunk))
+		}
+		return llm.NewTextErrorResponse(err.Error())
+	}
+
+	if out := reviewChange(ctx, "edit_file", path, func() ([]byte, error) {
+		if file.IsDelete() {
+			return nil, nil
+		}
+		return []byte(result), nil
+	}); out != nil {
+		return out
+	}
+
+	changes := changeSetFrom(ctx)
+	if err := changes.snapshot(path); err != nil {
+		return llm.NewTextErrorResponse(err.Error())
+	}
+	if file.IsDelete() {
+		if err := os.Remove(path); err != nil {
+			return llm.NewTextErrorResponse(fmt.Sprintf("failed to delete file: %v", err))
+		}
+		changes.record(path)
+		return llm.NewTextResponse("File deleted successfully")
+	}
+	if file.IsNew() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return llm.NewTextErrorResponse(fmt.Sprintf("failed to create directory: %v", err))
+		}
+	}
+	if err := writeFileAtomic(path, []byte(result), mode); err != nil {
+		return llm.NewTextErrorResponse(err.Error())
+	}
+	changes.record(path)
+	return llm.NewTextResponse(fmt.Sprintf("Diff applied successfully (%d hunks)", len(file.Hunks)))
+}
+
+// diffForPath picks the file section of a diff that targets path. A diff of a
+// single file applies regardless of the names in its headers.
+func diffForPath(files []*diff.File, path string) (*diff.File, error) {
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	clean := filepath.ToSlash(filepath.Clean(path))
+	for _, f := range files {
+		for _, name := range []string{f.NewName, f.OldName} {
+			if name == "" || name == diff.DevNull {
+				continue
+			}
+			if name == clean || hasPathSuffix(clean, name) {
+				return f, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("diff covers %d files but none is %s; send one file per call", len(files), path)
+}
+
+func hasPathSuffix(path, suffix string) bool {
+	return len(path) > len(suffix) && path[len(path)-len(suffix)-1] == '/' && path[len(path)-len(suffix):] == suffix
+}
+
+// writeFileAtomic replaces path through a temporary file in the same
+// directory so a failed write never leaves a truncated file.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".edit_file_*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write to temp file: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %v", err)
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		return fmt.Errorf("failed to set file permissions: %v", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func runEditDiff(t *testing.T, path, diffText string) llm.ToolResultOutput {
+	t.Helper()
+	input, _ := json.Marshal(EditFileInput{Path: path, Diff: diffText})
+	out, err := NewEditFileTool().Execute(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return out
+}
+
+func TestEditFileAppliesDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc a() {}\n\nfunc b() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runEditDiff(t, path, "--- a/main.go\n+++ b/main.go\n@@ -3,3 +3,3 @@\n-func a() {}\n+func a() { println() }\n \n func b() {}\n")
+	if _, ok := out.(llm.ToolResultOutputText); !ok {
+		t.Fatalf("expected success, got %#v", out)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "package main\n\nfunc a() { println() }\n\nfunc b() {}\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("permissions not preserved: %v", info.Mode())
+	}
+}
+
+func TestEditFileDiffFailureReturnsHunk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	original := "one\ntwo\nthree\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, ok := runEditDiff(t, path, "@@ -1,3 +1,3 @@\n one\n-TWO\n+2\n three\n").(llm.ToolResultOutputError)
+	if !ok {
+		t.Fatal("expected an error result")
+	}
+	for _, want := range []string{"hunk 1", "context mismatch at line 2", "-TWO\n+2"} {
+		if !strings.Contains(out.Error, want) {
+			t.Errorf("error should contain %q:\n%s", want, out.Error)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("file changed after a failed diff: %q", data)
+	}
+}
+
+func TestEditFileDiffCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "new.txt")
+	out := runEditDiff(t, path, "--- /dev/null\n+++ b/sub/new.txt\n@@ -0,0 +1 @@\n+hello\n")
+	if _, ok := out.(llm.ToolResultOutputText); !ok {
+		t.Fatalf("expected success, got %#v", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "hello\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	if _, ok := runEditDiff(t, path, "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+again\n").(llm.ToolResultOutputError); !ok {
+		t.Error("creating an existing file should fail")
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Environment inheritance modes for --env-inherit.
+const (
+	EnvInheritFiltered = "filtered" // drop secret-looking variables (default)
+	EnvInheritAll      = "all"      // pass the whole environment through
+)
+
+// SecretEnvPatterns match the names of variables that usually hold
+// credentials, such as AWS_SECRET_ACCESS_KEY, GITHUB_TOKEN, or
+// OPENAI_API_KEY. Names are matched case-insensitively.
+var SecretEnvPatterns = []string{
+	"*KEY",
+	"*KEYS",
+	"*KEY_ID",
+	"*TOKEN",
+	"*SECRET",
+	"*SECRETS",
+	"*PASSWORD",
+	"*PASSWD",
+	"*CREDENTIALS",
+}
+
+// EnvFilter decides which environment variables posix_shell commands
+// inherit. The zero value drops the variables matching SecretEnvPatterns.
+type EnvFilter struct {
+	InheritAll bool     // skip SecretEnvPatterns
+	Allow      []string // patterns kept even when they look secret
+	Deny       []string // patterns always dropped; overrides Allow
+}
+
+// NewEnvFilter builds a filter from the --env-inherit mode and the
+// --env-allow and --env-deny patterns, which use path.Match syntax.
+func NewEnvFilter(inherit string, allow, deny []string) (EnvFilter, error) {
+	f := EnvFilter{Allow: allow, Deny: deny}
+	switch inherit {
+	case "", EnvInheritFiltered:
+	case EnvInheritAll:
+		f.InheritAll = true
+	default:
+		return EnvFilter{}, fmt.Errorf("--env-inherit must be %s or %s, got %q", EnvInheritFiltered, EnvInheritAll, inherit)
+	}
+	for _, pattern := range append(append([]string(nil), allow...), deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return EnvFilter{}, fmt.Errorf("invalid environment pattern %q: %w", pattern, err)
+		}
+	}
+	return f, nil
+}
+
+// Apply returns the entries of environ, in "NAME=value" form, that the
+// filter lets through.
+func (f EnvFilter) Apply(environ []string) []string {
+	kept := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if f.Keeps(name) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// Keeps reports whether the variable name is passed through.
+func (f EnvFilter) Keeps(name string) bool {
+	switch {
+	case matchesEnvPattern(f.Deny, name):
+		return false
+	case f.InheritAll, matchesEnvPattern(f.Allow, name):
+		return true
+	default:
+		return !matchesEnvPattern(SecretEnvPatterns, name)
+	}
+}
+
+func matchesEnvPattern(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func shellText(t *testing.T, result llm.ToolResultOutput) string {
+	t.Helper()
+	text, ok := result.(llm.ToolResultOutputText)
+	if !ok {
+		t.Fatalf("command failed: %#v", result)
+	}
+	return text.Text
+}
+
+func TestEnvFilterKeeps(t *testing.T) {
+	filtered, err := NewEnvFilter("", []string{"NPM_TOKEN"}, []string{"DATABASE_*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := NewEnvFilter(EnvInheritAll, nil, []string{"DATABASE_*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filtered bool
+		all      bool
+	}{
+		{"PATH", true, true},
+		{"HOME", true, true},
+		{"AWS_SECRET_ACCESS_KEY", false, true},
+		{"AWS_ACCESS_KEY_ID", false, true},
+		{"GITHUB_TOKEN", false, true},
+		{"openai_api_key", false, true},
+		{"DB_PASSWORD", false, true},
+		{"NPM_TOKEN", true, true},
+		{"DATABASE_URL", false, false},
+		{"MONKEY_PATCH", true, true},
+	}
+	for _, tt := range tests {
+		if got := filtered.Keeps(tt.name); got != tt.filtered {
+			t.Errorf("filtered: Keeps(%q) = %v, want %v", tt.name, got, tt.filtered)
+		}
+		if got := all.Keeps(tt.name); got != tt.all {
+			t.Errorf("all: Keeps(%q) = %v, want %v", tt.name, got, tt.all)
+		}
+	}
+}
+
+func TestNewEnvFilterRejectsBadInput(t *testing.T) {
+	if _, err := NewEnvFilter("some", nil, nil); err == nil {
+		t.Error("an unknown --env-inherit mode should fail")
+	}
+	if _, err := NewEnvFilter("", []string{"[A-"}, nil); err == nil {
+		t.Error("a malformed pattern should fail")
+	}
+}
+
+func TestShellDoesNotSeeSecrets(t *testing.T) {
+	t.Setenv("ALAYACORE_TEST_TOKEN", "secret-token-value")
+	t.Setenv("ALAYACORE_TEST_API_KEY", "secret-key-value")
+	t.Setenv("ALAYACORE_TEST_PLAIN", "plain-value")
+
+	out := shellText(t, execShell(t, NewPosixShellToolWithShell(DefaultShell), "env"))
+	if strings.Contains(out, "secret-token-value") || strings.Contains(out, "secret-key-value") {
+		t.Errorf("env output contains a secret:\n%s", out)
+	}
+	if !strings.Contains(out, "ALAYACORE_TEST_PLAIN=plain-value") {
+		t.Errorf("env output is missing a plain variable:\n%s", out)
+	}
+
+	all, err := NewEnvFilter(EnvInheritAll, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = shellText(t, execShell(t, NewPosixShellToolWithEnv(DefaultShell, all), "env"))
+	if !strings.Contains(out, "ALAYACORE_TEST_TOKEN=secret-token-value") {
+		t.Errorf("--env-inherit all should pass every variable:\n%s", out)
+	}
+}
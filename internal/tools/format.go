@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/diff"
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/proc"
+)
+
+// FormatTimeout bounds each formatter run.
+const FormatTimeout = 30 * time.Second
+
+// maxFormatOutput bounds the formatter output reported to the model.
+const maxFormatOutput = 2000
+
+// Formatters maps file extensions, such as ".go", to the command that
+// formats a file in place, such as "gofmt -w". The file's path is appended
+// to the command.
+type Formatters map[string]string
+
+// ParseFormatters parses --formatter values of the form EXT=COMMAND, e.g.
+// ".go=gofmt -w" or "py=black -q". Extensions are matched ignoring case;
+// a later value for an extension replaces an earlier one.
+func ParseFormatters(values []string) (Formatters, error) {
+	f := make(Formatters, len(values))
+	for _, value := range values {
+		ext, command, found := strings.Cut(value, "=")
+		ext, command = strings.ToLower(strings.TrimSpace(ext)), strings.TrimSpace(command)
+		if !found || strings.Trim(ext, ".") == "" || command == "" {
+			return nil, fmt.Errorf("invalid formatter %q: expected EXT=COMMAND, e.g. .go=gofmt -w", value)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		f[ext] = command
+	}
+	return f, nil
+}
+
+// WithFormatters wraps a file tool so that each file it writes is run
+// through the formatter of its extension, with shell and the environment
+// env lets through. The formatter's changes stay in the file and a note such
+// as "formatted with gofmt, 3 lines changed" is added to the result. A
+// formatter that fails does not fail the call: the file stays as written and
+// the note carries the formatter's output, so the model can fix the syntax
+// error it reports. Failed calls and dry runs are not formatted.
+func WithFormatters(tool llm.Tool, f Formatters, shell string, env EnvFilter) llm.Tool {
+	if len(f) == 0 {
+		return tool
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		output, err := execute(ctx, input)
+		if err != nil || dryRunFrom(ctx) {
+			return output, err
+		}
+		if _, failed := output.(llm.ToolResultOutputError); failed {
+			return output, nil
+		}
+		var args struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(input, &args) != nil || args.Path == "" {
+			return output, nil
+		}
+		path := ResolvePath(ctx, args.Path)
+		command, ok := f[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			return output, nil
+		}
+		return addNote(output, path, runFormatter(ctx, shell, env, command, path)), nil
+	}
+	return tool
+}
+
+// runFormatter formats path with command and returns the note for the
+// model, or "" when the formatter changed nothing.
+func runFormatter(ctx context.Context, shell string, env EnvFilter, command, path string) string {
+	name := filepath.Base(strings.Fields(command)[0])
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("not formatted with %s: %v", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), FormatTimeout)
+	defer cancel()
+	//nolint:gosec // G204: formatter commands come from the user's config
+	cmd := exec.CommandContext(ctx, shell, "-c", command+` "$1"`, name, path)
+	cmd.Dir = commandDir(ctx)
+	cmd.Env = append(env.Apply(os.Environ()), "NO_COLOR=1")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	proc.SetGroup(cmd)
+	cmd.Cancel = func() error {
+		return proc.Kill(cmd.Process)
+	}
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Sprintf("%s timed out after %s; the file is written unformatted", name, FormatTimeout)
+		}
+		detail := strings.TrimSpace(out.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		if len(detail) > maxFormatOutput {
+			detail = detail[:maxFormatOutput] + "\n[truncated]"
+		}
+		return fmt.Sprintf("%s failed; the file is written unformatted:\n%s", name, detail)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("formatted with %s, but reading the result failed: %v", name, err)
+	}
+	changed := changedLines(string(before), string(after))
+	if changed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("formatted with %s, %s changed", name, countLines(changed))
+}
+
+// changedLines counts the lines a change touches: the larger of the lines
+// removed and the lines added.
+func changedLines(before, after string) int {
+	files, err := diff.Parse(diff.Unified("file", before, after))
+	if err != nil || len(files) == 0 {
+		return 0
+	}
+	removed, added := 0, 0
+	for _, h := range files[0].Hunks {
+		for _, line := range h.Lines {
+			switch line.Op {
+			case '-':
+				removed++
+			case '+':
+				added++
+			}
+		}
+	}
+	return max(removed, added)
+}
+
+// addNote appends note to a file tool's result. A file reference becomes
+// text, with the size of the formatted file.
+func addNote(output llm.ToolResultOutput, path, note string) llm.ToolResultOutput {
+	if note == "" {
+		return output
+	}
+	switch out := output.(type) {
+	case llm.ToolResultOutputText:
+		out.Text += "\n" + note
+		return out
+	case llm.ToolResultOutputData:
+		if info, err := os.Stat(path); err == nil && out.Kind == ResultFile {
+			data, _ := json.Marshal(FileRef{Path: path, Bytes: info.Size()}) //nolint:errcheck // a FileRef always marshals
+			out.Data = data
+		}
+		return TextResult(FormatResult(out.Kind, out.Data) + "\n" + note)
+	}
+	return output
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// formatterScript writes a shell script to dir and returns its path.
+func formatterScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeWithFormatters(t *testing.T, f Formatters, path, content string) llm.ToolResultOutput {
+	t.Helper()
+	tool := WithFormatters(NewWriteFileTool(), f, "/bin/sh", EnvFilter{})
+	input, _ := json.Marshal(WriteFileInput{Path: path, Content: content})
+	out, err := tool.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestWithFormattersAppliesChanges(t *testing.T) {
+	dir := t.TempDir()
+	// Squeezes runs of spaces, like a formatter fixing indentation
+	squeeze := formatterScript(t, dir, "squeeze", `tr -s ' ' < "$1" > "$1.tmp" && mv "$1.tmp" "$1"`)
+	f, err := ParseFormatters([]string{"GO=" + squeeze})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "main.go")
+	out := writeWithFormatters(t, f, path, "package  main\n\nfunc  main() {}\nvar x = 1\n")
+	text, ok := out.(llm.ToolResultOutputText)
+	if !ok {
+		t.Fatalf("expected a text result, got %#v", out)
+	}
+	want := path + " (39 bytes)\nformatted with squeeze, 2 lines changed"
+	if text.Text != want {
+		t.Errorf("result = %q, want %q", text.Text, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n\nfunc main() {}\nvar x = 1\n" {
+		t.Errorf("file = %q", data)
+	}
+
+	// Files the formatter leaves alone, or of other extensions, get no note
+	out = writeWithFormatters(t, f, path, "package main\n")
+	if _, ok := out.(llm.ToolResultOutputData); !ok {
+		t.Errorf("unchanged file: expected the plain file result, got %#v", out)
+	}
+	out = writeWithFormatters(t, f, filepath.Join(dir, "notes.txt"), "a  b\n")
+	if _, ok := out.(llm.ToolResultOutputData); !ok {
+		t.Errorf("other extension: expected the plain file result, got %#v", out)
+	}
+}
+
+func TestWithFormattersReportsFailure(t *testing.T) {
+	dir := t.TempDir()
+	broken := formatterScript(t, dir, "broken", `echo "$1:3:1: expected declaration" >&2; exit 2`)
+	path := filepath.Join(dir, "main.go")
+
+	out := writeWithFormatters(t, Formatters{".go": broken}, path, "package main\n}\n")
+	text, ok := out.(llm.ToolResultOutputText)
+	if !ok {
+		t.Fatalf("a failing formatter should not fail the write, got %#v", out)
+	}
+	if !strings.Contains(text.Text, "broken failed; the file is written unformatted") || !strings.Contains(text.Text, "main.go:3:1: expected declaration") {
+		t.Errorf("result = %q", text.Text)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n}\n" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func TestParseFormattersRejectsMissingCommand(t *testing.T) {
+	for _, value := range []string{".go", ".go=", "=gofmt -w", ".=gofmt"} {
+		if _, err := ParseFormatters([]string{value}); err == nil {
+			t.Errorf("ParseFormatters(%q): expected an error", value)
+		}
+	}
+}
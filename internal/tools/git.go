@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/proc"
+)
+
+// gitMaxOutput caps the output of one git call, so a large diff cannot fill
+// the context window.
+const gitMaxOutput = 64 * 1024
+
+// gitDefaultLog and gitMaxLog bound the commits listed by the log operation.
+const (
+	gitDefaultLog = 10
+	gitMaxLog     = 100
+)
+
+// GitInput represents the input for the git tool
+type GitInput struct {
+	Operation string `json:"operation" jsonschema:"required,description=The git operation to run,enum=status|diff|log|add|commit|branch_list|branch_create|branch_switch|show"`
+	Path      string `json:"path,omitempty" jsonschema:"description=For diff and log: limit them to this file or directory. For add: what to stage (required)"`
+	Staged    bool   `json:"staged,omitempty" jsonschema:"type=boolean,description=For diff: show the staged changes instead of the unstaged ones"`
+	N         int    `json:"n,omitempty" jsonschema:"type=integer,description=For log: how many commits to list (default 10)"`
+	Message   string `json:"message,omitempty" jsonschema:"description=For commit: the commit message. It may span several lines"`
+	Branch    string `json:"branch,omitempty" jsonschema:"description=For branch_create and branch_switch: the branch name"`
+	Ref       string `json:"ref,omitempty" jsonschema:"description=For show: the commit to show (default HEAD)"`
+}
+
+// NewGitTool creates a tool for common git operations. Each operation runs
+// git with fixed arguments, so messages and paths need no shell quoting, and
+// nothing that discards work (reset --hard, push --force, clean) is offered.
+// Commands get the environment chosen by env, like posix_shell.
+func NewGitTool(env EnvFilter) llm.Tool {
+	return llm.NewTool(
+		"git",
+		`Run a git operation in the working directory's repository.
+
+Operations:
+- status: branch and changed files (short format)
+- diff: unstaged changes, or staged ones with staged; path limits it
+- log: the last n commits (default 10) as "hash date author: subject"; path limits it
+- add: stage path
+- commit: commit the staged changes with message
+- branch_list: local branches, the current one marked with *
+- branch_create: create branch without switching to it
+- branch_switch: switch to branch
+- show: a commit's message, changed files, and patch (default HEAD)
+
+Prefer this tool over running git with posix_shell.`,
+	).
+		WithSchema(llm.GenerateSchema(GitInput{})).
+		WithExecute(llm.TypedExecute(func(ctx context.Context, args GitInput) (llm.ToolResultOutput, error) {
+			gitArgs, err := gitCommand(args)
+			if err != nil {
+				return llm.NewTextErrorResponse(err.Error()), nil
+			}
+			return runGit(ctx, env, args.Operation, gitArgs), nil
+		})).
+		Build()
+}
+
+// gitCommand validates args and returns the git arguments of the operation.
+func gitCommand(args GitInput) ([]string, error) {
+	switch args.Operation {
+	case "status":
+		return []string{"status", "--short", "--branch"}, nil
+	case "diff":
+		cmd := []string{"diff", "--no-ext-diff"}
+		if args.Staged {
+			cmd = append(cmd, "--cached")
+		}
+		return withPath(cmd, args.Path), nil
+	case "log":
+		n := args.N
+		if n <= 0 {
+			n = gitDefaultLog
+		}
+		n = min(n, gitMaxLog)
+		cmd := []string{"log", "-n", strconv.Itoa(n), "--date=short", "--format=%h %ad %an: %s"}
+		return withPath(cmd, args.Path), nil
+	case "add":
+		if args.Path == "" {
+			return nil, errors.New("add needs a path")
+		}
+		return withPath([]string{"add"}, args.Path), nil
+	case "commit":
+		if strings.TrimSpace(args.Message) == "" {
+			return nil, errors.New("commit needs a message")
+		}
+		return []string{"commit", "-m", args.Message}, nil
+	case "branch_list":
+		return []string{"branch", "--list"}, nil
+	case "branch_create", "branch_switch":
+		if err := checkGitName("branch", args.Branch); err != nil {
+			return nil, err
+		}
+		if args.Operation == "branch_create" {
+			return []string{"branch", args.Branch}, nil
+		}
+		return []string{"switch", args.Branch}, nil
+	case "show":
+		ref := args.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if err := checkGitName("ref", ref); err != nil {
+			return nil, err
+		}
+		// The trailing -- keeps git from reading ref as a path
+		return []string{"show", "--stat", "--patch", "--no-ext-diff", ref, "--"}, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q: use status, diff, log, add, commit, branch_list, branch_create, branch_switch, or show", args.Operation)
+	}
+}
+
+// withPath appends path after "--", so it is never read as an option or a
+// revision.
+func withPath(cmd []string, path string) []string {
+	if path == "" {
+		return cmd
+	}
+	return append(cmd, "--", path)
+}
+
+// checkGitName rejects empty names and names git would read as options.
+func checkGitName(what, name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%s is required", what)
+	case strings.HasPrefix(name, "-"):
+		return fmt.Errorf("invalid %s %q: it must not start with -", what, name)
+	}
+	return nil
+}
+
+// runGit runs git with args in the working directory and returns its trimmed
+// output. Messages git writes to stderr on success, such as "Switched to
+// branch", are returned when there is no other output.
+func runGit(ctx context.Context, env EnvFilter, operation string, args []string) llm.ToolResultOutput {
+	// No pager, no colors, and no prompts for credentials or editors
+	args = append([]string{"--no-pager", "-c", "color.ui=never", "-c", "core.quotepath=off"}, args...)
+	//nolint:gosec // G204: the arguments come from gitCommand, and no shell reads them
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = commandDir(ctx)
+	cmd.Env = append(env.Apply(os.Environ()),
+		"TERM=dumb",
+		"NO_COLOR=1",
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_EDITOR=true",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Commit hooks run as children of git; stop them with it
+	proc.SetGroup(cmd)
+	cmd.Cancel = func() error {
+		return proc.Kill(cmd.Process)
+	}
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	out := strings.TrimSpace(SanitizeOutput(stdout.String()))
+	errOut := strings.TrimSpace(SanitizeOutput(stderr.String()))
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return llm.NewTextErrorResponse("git is not installed or not on PATH")
+	case ctx.Err() != nil:
+		return llm.NewTextErrorResponse("git " + operation + " canceled")
+	case err != nil:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return llm.NewTextErrorResponse(err.Error())
+		}
+		detail := strings.TrimSpace(strings.Join([]string{errOut, out}, "\n"))
+		return llm.NewTextErrorResponse(truncateGitOutput(fmt.Sprintf("git %s failed (exit %d):\n%s", operation, exitErr.ExitCode(), detail)))
+	}
+
+	if out == "" {
+		out = errOut
+	}
+	if out == "" {
+		out = "(no output)"
+	}
+	return llm.NewTextResponse(truncateGitOutput(out))
+}
+
+func truncateGitOutput(out string) string {
+	if len(out) <= gitMaxOutput {
+		return out
+	}
+	cut := strings.LastIndexByte(out[:gitMaxOutput], '\n')
+	if cut < 0 {
+		cut = gitMaxOutput
+	}
+	return out[:cut] + fmt.Sprintf("\n[truncated: the output is %d bytes; limit it with path]", len(out))
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// newGitRepo creates an empty repository in a temp directory and makes it
+// the working directory of the test.
+func newGitRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func runGitTool(t *testing.T, input GitInput) (string, bool) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	out, err := NewGitTool(EnvFilter{}).Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	switch out := out.(type) {
+	case llm.ToolResultOutputText:
+		return out.Text, true
+	case llm.ToolResultOutputError:
+		return out.Error, false
+	}
+	t.Fatalf("unexpected output %#v", out)
+	return "", false
+}
+
+func TestGitToolWorkflow(t *testing.T) {
+	newGitRepo(t)
+	if err := os.WriteFile("main.go", []byte("package main\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, ok := runGitTool(t, GitInput{Operation: "status"}); !ok || !strings.Contains(out, "?? main.go") {
+		t.Errorf("status = %q", out)
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "add", Path: "main.go"}); !ok || out != "(no output)" {
+		t.Errorf("add = %q", out)
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "diff", Staged: true}); !ok || !strings.Contains(out, "+package main") {
+		t.Errorf("staged diff = %q", out)
+	}
+
+	// The message reaches git as is, newlines and quotes included
+	message := "Add \"main\"\n\nWith a body; and $HOME untouched"
+	if _, ok := runGitTool(t, GitInput{Operation: "commit", Message: message}); !ok {
+		t.Fatal("commit failed")
+	}
+	body, err := exec.Command("git", "log", "-1", "--format=%B").Output()
+	if err != nil || strings.TrimSpace(string(body)) != message {
+		t.Errorf("commit message = %q (%v)", body, err)
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "log", N: 5}); !ok || !strings.HasSuffix(out, " Test: Add \"main\"") {
+		t.Errorf("log = %q", out)
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "show"}); !ok || !strings.Contains(out, "main.go | 1 +") || !strings.Contains(out, "+package main") {
+		t.Errorf("show = %q", out)
+	}
+
+	if _, ok := runGitTool(t, GitInput{Operation: "branch_create", Branch: "feature"}); !ok {
+		t.Fatal("branch_create failed")
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "branch_switch", Branch: "feature"}); !ok || !strings.Contains(out, "Switched to branch 'feature'") {
+		t.Errorf("branch_switch = %q", out)
+	}
+	if out, ok := runGitTool(t, GitInput{Operation: "branch_list"}); !ok || !strings.Contains(out, "* feature") || !strings.Contains(out, "  main") {
+		t.Errorf("branch_list = %q", out)
+	}
+}
+
+func TestGitToolRejectsBadInput(t *testing.T) {
+	newGitRepo(t)
+	tests := []struct {
+		input GitInput
+		want  string
+	}{
+		{GitInput{Operation: "reset"}, `unknown operation "reset"`},
+		{GitInput{Operation: "push"}, `unknown operation "push"`},
+		{GitInput{Operation: "commit", Message: " "}, "commit needs a message"},
+		{GitInput{Operation: "add"}, "add needs a path"},
+		{GitInput{Operation: "branch_switch", Branch: "--force"}, "must not start with -"},
+		{GitInput{Operation: "show", Ref: "--output=x"}, "must not start with -"},
+		{GitInput{Operation: "commit", Message: "nothing staged"}, "git commit failed (exit 1)"},
+	}
+	for _, tt := range tests {
+		if out, ok := runGitTool(t, tt.input); ok || !strings.Contains(out, tt.want) {
+			t.Errorf("%+v = %q (ok %v), want an error containing %q", tt.input, out, ok, tt.want)
+		}
+	}
+
+	// A path that looks like an option is still a path
+	if out, ok := runGitTool(t, GitInput{Operation: "add", Path: "--all"}); ok || !strings.Contains(out, "did not match any files") {
+		t.Errorf("add --all = %q (ok %v)", out, ok)
+	}
+}
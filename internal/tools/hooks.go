@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/hooks"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// WithHooks wraps a tool with its pre_tool and post_tool hooks. A failing
+// pre_tool hook aborts the call and its stderr is returned to the model as
+// the tool error; post_tool failures are only logged. Hooks run commands of
+// their own, so a dry run (see WithDryRunMode) skips them. Tools without
+// hooks are returned unchanged.
+func WithHooks(tool llm.Tool, h *hooks.Hooks) llm.Tool {
+	name := tool.Definition.Name
+	if !h.Has(hooks.PreTool, name) && !h.Has(hooks.PostTool, name) {
+		return tool
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		if dryRunFrom(ctx) {
+			return execute(ctx, input)
+		}
+		env := hooks.Env{Tool: name, Input: input}
+		if err := h.Run(ctx, hooks.PreTool, env); err != nil {
+			return llm.NewTextErrorResponse(err.Error()), nil
+		}
+		output, err := execute(ctx, input)
+		//nolint:errcheck // post_tool failures are logged by the hook runner
+		_ = h.Run(context.WithoutCancel(ctx), hooks.PostTool, env)
+		return output, err
+	}
+	return tool
+}
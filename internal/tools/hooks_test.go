@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/hooks"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func newCountingTool(name string, calls *int) llm.Tool {
+	return llm.Tool{
+		Definition: llm.ToolDefinition{Name: name},
+		Execute: func(context.Context, json.RawMessage) (llm.ToolResultOutput, error) {
+			*calls++
+			return llm.NewTextResponse("ok"), nil
+		},
+	}
+}
+
+func TestWithHooksPreToolFailureAbortsCall(t *testing.T) {
+	h := &hooks.Hooks{Shell: "/bin/sh", Hooks: []hooks.Hook{
+		{Event: hooks.PreTool, Tool: "write_file", Command: "echo 'refusing to write' >&2; exit 1"},
+	}}
+	var calls int
+	tool := WithHooks(newCountingTool("write_file", &calls), h)
+
+	out, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, ok := out.(llm.ToolResultOutputError)
+	if !ok || !strings.Contains(errOut.Error, "refusing to write") {
+		t.Fatalf("expected hook stderr as the tool error, got %#v", out)
+	}
+	if calls != 0 {
+		t.Errorf("tool ran %d times despite failing pre_tool hook", calls)
+	}
+}
+
+func TestWithHooksRunsPostToolAfterExecution(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "post")
+	h := &hooks.Hooks{Shell: "/bin/sh", Hooks: []hooks.Hook{
+		{Event: hooks.PreTool, Command: "true"},
+		{Event: hooks.PostTool, Tool: "write_file", Command: `printf '%s' "$ALAYACORE_TOOL" > ` + marker},
+	}}
+	var calls int
+	tool := WithHooks(newCountingTool("write_file", &calls), h)
+
+	if _, err := tool.Execute(context.Background(), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("tool calls = %d, want 1", calls)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil || string(data) != "write_file" {
+		t.Errorf("post_tool hook output = %q, %v", data, err)
+	}
+}
+
+func TestWithHooksLeavesUnhookedToolsAlone(t *testing.T) {
+	h := &hooks.Hooks{Shell: "/bin/sh", Hooks: []hooks.Hook{
+		{Event: hooks.PreTool, Tool: "write_file", Command: "exit 1"},
+	}}
+	var calls int
+	tool := WithHooks(newCountingTool("read_file", &calls), h)
+
+	if _, err := tool.Execute(context.Background(), json.RawMessage(`{}`)); err != nil || calls != 1 {
+		t.Errorf("read_file should run unhooked, calls = %d, err = %v", calls, err)
+	}
+}
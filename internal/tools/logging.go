@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// WithLogging wraps a tool so each call is logged: at debug level with its
+// duration, along with the error the model gets back when it fails. A call
+// that returns an error instead of an error result means the tool itself
+// broke and is logged at error level. Inputs and outputs are not logged, as
+// they may hold file contents and secrets.
+func WithLogging(tool llm.Tool, logger *slog.Logger) llm.Tool {
+	if logger == nil {
+		return tool
+	}
+	name := tool.Definition.Name
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		start := time.Now()
+		output, err := execute(ctx, input)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			logger.ErrorContext(ctx, "tool call error", "tool", name, "elapsed", elapsed, "err", err)
+			return output, err
+		}
+		if o, failed := output.(llm.ToolResultOutputError); failed {
+			logger.DebugContext(ctx, "tool call failed", "tool", name, "elapsed", elapsed, "result", o.Error)
+			return output, nil
+		}
+		logger.DebugContext(ctx, "tool call", "tool", name, "elapsed", elapsed, "input_bytes", len(input))
+		return output, nil
+	}
+	return tool
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/memory"
+)
+
+// MemoryTools are the tools of the persistent memory. They are offered only
+// with --memory.
+var MemoryTools = []string{"save_memory", "search_memory"}
+
+// IsMemoryTool reports whether name is one of MemoryTools.
+func IsMemoryTool(name string) bool {
+	for _, tool := range MemoryTools {
+		if name == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveMemoryInput represents the input for the save_memory tool
+type SaveMemoryInput struct {
+	Key     string   `json:"key" jsonschema:"required,description=A short unique name for the memory; saving an existing key replaces it"`
+	Content string   `json:"content" jsonschema:"required,description=The fact to remember"`
+	Tags    []string `json:"tags,omitempty" jsonschema:"description=Optional keywords to find the memory by"`
+}
+
+// SearchMemoryInput represents the input for the search_memory tool
+type SearchMemoryInput struct {
+	Query string `json:"query" jsonschema:"required,description=Keywords to look for in the keys, tags, and content of saved memories"`
+}
+
+// NewSaveMemoryTool creates a tool for saving facts to the persistent memory
+func NewSaveMemoryTool(m *memory.Manager) llm.Tool {
+	return llm.NewTool(
+		"save_memory",
+		"Save a fact to the persistent memory shared by all sessions, such as the user's environment, conventions, or decisions worth remembering.",
+	).
+		WithSchema(llm.GenerateSchema(SaveMemoryInput{})).
+		WithExecute(llm.TypedExecute(func(_ context.Context, args SaveMemoryInput) (llm.ToolResultOutput, error) {
+			if m == nil {
+				return llm.NewTextErrorResponse("memory is not enabled"), nil
+			}
+			if err := m.Save(args.Key, args.Content, args.Tags); err != nil {
+				return llm.NewTextErrorResponse(err.Error()), nil
+			}
+			return llm.NewTextResponse("Saved memory " + strings.TrimSpace(args.Key)), nil
+		})).
+		Build()
+}
+
+// NewSearchMemoryTool creates a tool for looking up saved facts
+func NewSearchMemoryTool(m *memory.Manager) llm.Tool {
+	return llm.NewTool(
+		"search_memory",
+		"Search the persistent memory by keywords and return the best matches. Use it when the user refers to prior work or facts from earlier sessions.",
+	).
+		WithSchema(llm.GenerateSchema(SearchMemoryInput{})).
+		WithExecute(llm.TypedExecute(func(_ context.Context, args SearchMemoryInput) (llm.ToolResultOutput, error) {
+			if m == nil {
+				return llm.NewTextErrorResponse("memory is not enabled"), nil
+			}
+			matches, err := m.Search(args.Query, memory.DefaultLimit)
+			if err != nil {
+				return llm.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(matches) == 0 {
+				return llm.NewTextResponse("No memories match " + args.Query), nil
+			}
+			var sb strings.Builder
+			for i, match := range matches {
+				if i > 0 {
+					sb.WriteString("\n\n")
+				}
+				fmt.Fprintf(&sb, "[%s]", match.Key)
+				if len(match.Tags) > 0 {
+					fmt.Fprintf(&sb, " (tags: %s)", strings.Join(match.Tags, ", "))
+				}
+				sb.WriteString("\n" + match.Content)
+			}
+			return llm.NewTextResponse(sb.String()), nil
+		})).
+		Build()
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/telemetry"
+)
+
+// ToolStats aggregates the calls of one tool.
+type ToolStats struct {
+	Name        string
+	Calls       int
+	Errors      int
+	Duration    time.Duration // total time spent executing
+	OutputBytes int64
+}
+
+// AverageDuration returns the mean duration of a call.
+func (st ToolStats) AverageDuration() time.Duration {
+	if st.Calls == 0 {
+		return 0
+	}
+	return st.Duration / time.Duration(st.Calls)
+}
+
+// MetricsSnapshot is a copy of the counters of a Metrics.
+type MetricsSnapshot struct {
+	Turns    int
+	TurnTime time.Duration // time spent running prompts
+	Elapsed  time.Duration // wall-clock time since the collector was created
+	Tools    []ToolStats   // sorted by name
+}
+
+// Calls returns the number of tool calls across all tools.
+func (m MetricsSnapshot) Calls() int {
+	n := 0
+	for _, st := range m.Tools {
+		n += st.Calls
+	}
+	return n
+}
+
+// Metrics collects tool and turn statistics for one session. Like TodoList,
+// it reaches the tool wrappers through the request context (see
+// WithMetricsCollector). It is safe for concurrent use; a nil Metrics records
+// nothing.
+type Metrics struct {
+	mu       sync.Mutex
+	start    time.Time
+	now      func() time.Time
+	tools    map[string]*ToolStats
+	turns    int
+	turnTime time.Duration
+}
+
+// NewMetrics creates an empty collector. now is the clock; nil uses
+// time.Now.
+func NewMetrics(now func() time.Time) *Metrics {
+	if now == nil {
+		now = time.Now
+	}
+	return &Metrics{start: now(), now: now, tools: make(map[string]*ToolStats)}
+}
+
+// RecordCall adds one call of tool name.
+func (m *Metrics) RecordCall(name string, d time.Duration, failed bool, outputBytes int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.tools[name]
+	if st == nil {
+		st = &ToolStats{Name: name}
+		m.tools[name] = st
+	}
+	st.Calls++
+	if failed {
+		st.Errors++
+	}
+	st.Duration += d
+	st.OutputBytes += int64(outputBytes)
+}
+
+// RecordTurn adds one prompt that took d.
+func (m *Metrics) RecordTurn(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns++
+	m.turnTime += d
+}
+
+// Snapshot returns a copy of the counters.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	if m == nil {
+		return MetricsSnapshot{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := MetricsSnapshot{
+		Turns:    m.turns,
+		TurnTime: m.turnTime,
+		Elapsed:  m.now().Sub(m.start),
+		Tools:    make([]ToolStats, 0, len(m.tools)),
+	}
+	for _, st := range m.tools {
+		snap.Tools = append(snap.Tools, *st)
+	}
+	sort.Slice(snap.Tools, func(i, j int) bool { return snap.Tools[i].Name < snap.Tools[j].Name })
+	return snap
+}
+
+type metricsKey struct{}
+
+// WithMetricsCollector returns a context carrying the collector WithMetrics
+// records into.
+func WithMetricsCollector(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+// metricsFrom returns the collector carried by ctx, or nil.
+func metricsFrom(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
+
+// WithMetrics wraps a tool so each call is recorded in the collector of the
+// request context: its duration, whether it failed, and the size of its
+// output. Calls without a collector are only counted in
+// telemetry.ToolCalls, like every call.
+func WithMetrics(tool llm.Tool) llm.Tool {
+	name := tool.Definition.Name
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		start := time.Now()
+		output, err := execute(ctx, input)
+		failed, size := err != nil, 0
+		switch o := output.(type) {
+		case llm.ToolResultOutputText:
+			size = len(o.Text)
+		case llm.ToolResultOutputData:
+			size = len(o.Data)
+		case llm.ToolResultOutputError:
+			failed, size = true, len(o.Error)
+		}
+		if err != nil {
+			size = len(err.Error())
+		}
+		metricsFrom(ctx).RecordCall(name, time.Since(start), failed, size)
+		result := "ok"
+		if failed {
+			result = "error"
+		}
+		telemetry.ToolCalls.WithLabelValues(name, result).Inc()
+		return output, err
+	}
+	return tool
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestMetricsAggregates(t *testing.T) {
+	clock := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	m := NewMetrics(func() time.Time { return clock })
+
+	m.RecordCall("posix_shell", 2*time.Second, false, 100)
+	m.RecordCall("posix_shell", 4*time.Second, true, 20)
+	m.RecordCall("read_file", 10*time.Millisecond, false, 5000)
+	m.RecordTurn(7 * time.Second)
+	clock = clock.Add(time.Minute)
+
+	snap := m.Snapshot()
+	if snap.Turns != 1 || snap.TurnTime != 7*time.Second || snap.Elapsed != time.Minute {
+		t.Errorf("totals = %d turns, %v, %v elapsed", snap.Turns, snap.TurnTime, snap.Elapsed)
+	}
+	if snap.Calls() != 3 || len(snap.Tools) != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	shell := snap.Tools[0]
+	want := ToolStats{Name: "posix_shell", Calls: 2, Errors: 1, Duration: 6 * time.Second, OutputBytes: 120}
+	if shell != want {
+		t.Errorf("posix_shell = %+v, want %+v", shell, want)
+	}
+	if avg := shell.AverageDuration(); avg != 3*time.Second {
+		t.Errorf("average = %v, want 3s", avg)
+	}
+	if snap.Tools[1].Name != "read_file" {
+		t.Errorf("tools are not sorted: %+v", snap.Tools)
+	}
+}
+
+func TestMetricsConcurrentUse(t *testing.T) {
+	m := NewMetrics(nil)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				m.RecordCall("posix_shell", time.Millisecond, false, 1)
+				m.RecordTurn(time.Millisecond)
+				_ = m.Snapshot()
+			}
+		})
+	}
+	wg.Wait()
+	if snap := m.Snapshot(); snap.Calls() != 800 || snap.Turns != 800 {
+		t.Errorf("got %d calls and %d turns, want 800 of each", snap.Calls(), snap.Turns)
+	}
+}
+
+func TestNilMetricsRecordsNothing(t *testing.T) {
+	var m *Metrics
+	m.RecordCall("posix_shell", time.Second, false, 1)
+	m.RecordTurn(time.Second)
+	if snap := m.Snapshot(); snap.Calls() != 0 || snap.Turns != 0 {
+		t.Errorf("nil metrics recorded %+v", snap)
+	}
+}
+
+func TestWithMetricsRecordsCalls(t *testing.T) {
+	results := []struct {
+		output llm.ToolResultOutput
+		err    error
+	}{
+		{llm.NewTextResponse("hello"), nil},
+		{llm.NewTextErrorResponse("denied"), nil},
+		{nil, errors.New("boom")},
+	}
+	call := 0
+	tool := WithMetrics(llm.Tool{
+		Definition: llm.ToolDefinition{Name: "probe"},
+		Execute: func(context.Context, json.RawMessage) (llm.ToolResultOutput, error) {
+			r := results[call]
+			call++
+			return r.output, r.err
+		},
+	})
+
+	m := NewMetrics(nil)
+	ctx := WithMetricsCollector(context.Background(), m)
+	for range results {
+		_, _ = tool.Execute(ctx, nil) //nolint:errcheck // the error is what is being counted
+	}
+
+	snap := m.Snapshot()
+	if len(snap.Tools) != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if st := snap.Tools[0]; st.Calls != 3 || st.Errors != 2 || st.OutputBytes != int64(len("hello")+len("denied")+len("boom")) {
+		t.Errorf("probe = %+v", st)
+	}
+
+	// Without a collector in the context the call still runs
+	call = 0
+	if _, err := tool.Execute(context.Background(), nil); err != nil {
+		t.Errorf("Execute without a collector = %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// ExecTools are the built-in tools that run programs: posix_shell runs shell
+// commands and git runs git. --no-exec removes them. The write tools can
+// still write files that other programs run, such as ~/.bashrc or git hooks;
+// the path policy has to keep them out.
+var ExecTools = []string{"posix_shell", "git"}
+
+// IsExecTool reports whether name is one of ExecTools.
+func IsExecTool(name string) bool {
+	for _, exec := range ExecTools {
+		if name == exec {
+			return true
+		}
+	}
+	return false
+}
+
+// Unavailable returns a stand-in for a tool removed from this deployment. It
+// is not offered to the model; a call the model makes anyway is answered with
+// an error instead of running anything.
+func Unavailable(name string) llm.Tool {
+	return llm.Tool{
+		Definition: llm.ToolDefinition{Name: name},
+		Execute: func(context.Context, json.RawMessage) (llm.ToolResultOutput, error) {
+			return llm.NewTextErrorResponse("tool not available in this deployment: " + name), nil
+		},
+		Hidden: true,
+	}
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// SafeModeDenyPaths is the built-in denylist enabled by --safe-mode: SSH and
+// GPG keys, cloud and registry credentials, and shell history.
+var SafeModeDenyPaths = []string{
+	"~/.ssh",
+	"~/.gnupg",
+	"~/.aws",
+	"~/.azure",
+	"~/.config/gcloud",
+	"~/.kube",
+	"~/.docker/config.json",
+	"~/.netrc",
+	"~/.git-credentials",
+	"~/.bash_history",
+	"~/.zsh_history",
+	"~/.python_history",
+	"~/.node_repl_history",
+	"id_rsa*",
+	"id_ecdsa*",
+	"id_ed25519*",
+	"*.pem",
+}
+
+// pathRule is a single allow or deny entry. raw is the rule as given, for
+// error messages; pattern is the normalized form used for matching.
+type pathRule struct {
+	raw     string
+	pattern string
+}
+
+// PathPolicy restricts the paths the file tools may access. Rules containing a
+// slash (and "." or "..") are absolute or relative paths (with ~ expansion) that match the path
+// itself and everything below it; glob characters are allowed. Rules without a
+// slash match any single path component, e.g. "*.pem" or ".env".
+//
+// Paths are resolved through symlinks before matching, with ".." taken after
+// the symlinks before it, as the system does when opening them. Deny rules
+// take precedence over allow rules, and when any allow rule is given, paths
+// outside all of them are refused.
+type PathPolicy struct {
+	allow []pathRule
+	deny  []pathRule
+}
+
+// NewPathPolicy builds a policy from --allow-path and --deny-path rules. With
+// safeMode the SafeModeDenyPaths are added to the deny rules. It returns nil
+// when there are no rules, leaving file access unrestricted.
+func NewPathPolicy(allow, deny []string, safeMode bool) *PathPolicy {
+	if safeMode {
+		deny = append(append([]string{}, deny...), SafeModeDenyPaths...)
+	}
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	p := &PathPolicy{}
+	for _, rule := range allow {
+		p.allow = append(p.allow, newPathRule(rule))
+	}
+	for _, rule := range deny {
+		p.deny = append(p.deny, newPathRule(rule))
+	}
+	return p
+}
+
+func newPathRule(rule string) pathRule {
+	pattern := expandHome(rule)
+	if pattern == "." || pattern == ".." || hasSeparator(pattern) {
+		if abs, err := filepath.Abs(pattern); err == nil {
+			pattern = abs
+		}
+		// Resolve symlinks in plain paths so they compare equal to resolved targets
+		if !hasGlobMeta(pattern) {
+			pattern = resolvePath(pattern)
+		}
+	}
+	return pathRule{raw: rule, pattern: pattern}
+}
+
+// Check returns an error naming the matched rule when path may not be accessed.
+func (p *PathPolicy) Check(path string) error {
+	if p == nil {
+		return nil
+	}
+	// Cleaning "link/.." as text would drop the symlink the system follows,
+	// so the path is resolved as given
+	given := expandHome(path)
+	if !filepath.IsAbs(given) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		given = cwd + string(filepath.Separator) + given
+	}
+	abs := filepath.Clean(given)
+	resolved := resolvePath(given)
+
+	// Deny on either form: a symlink inside a denied directory is denied even
+	// when it points elsewhere, and vice versa
+	for _, rule := range p.deny {
+		if rule.matches(abs) || rule.matches(resolved) {
+			return fmt.Errorf("access to %s is denied by path rule %q", path, rule.raw)
+		}
+	}
+
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.allow {
+		if rule.matches(resolved) {
+			return nil
+		}
+	}
+	raws := make([]string, len(p.allow))
+	for i, rule := range p.allow {
+		raws[i] = rule.raw
+	}
+	return fmt.Errorf("access to %s is outside the allowed paths (%s)", path, strings.Join(raws, ", "))
+}
+
+// matches reports whether path (absolute and cleaned) matches the rule.
+func (r pathRule) matches(path string) bool {
+	if !strings.ContainsRune(r.pattern, filepath.Separator) {
+		for _, name := range strings.Split(path, string(filepath.Separator)) {
+			if ok, _ := filepath.Match(r.pattern, name); ok && name != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Match the path or any of its ancestors, so a directory rule covers its contents
+	for {
+		if path == r.pattern {
+			return true
+		}
+		if ok, _ := filepath.Match(r.pattern, path); ok {
+			return true
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
+
+// maxSymlinks bounds the symlinks resolvePath follows, against loops.
+const maxSymlinks = 255
+
+// resolvePath evaluates the symlinks of an absolute path one element at a
+// time, as the system does: a ".." leaves the directory a symlink before it
+// points to. Elements that do not exist yet (write_file) are kept as they are.
+func resolvePath(path string) string {
+	vol := filepath.VolumeName(path)
+	cur := vol + string(filepath.Separator)
+	rest := pathElements(path[len(vol):])
+	links := 0
+	for len(rest) > 0 {
+		elem := rest[0]
+		rest = rest[1:]
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+		next := filepath.Join(cur, elem)
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 || links >= maxSymlinks {
+			cur = next
+			continue
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			cur = next
+			continue
+		}
+		links++
+		if filepath.IsAbs(target) {
+			vol = filepath.VolumeName(target)
+			cur = vol + string(filepath.Separator)
+			target = target[len(vol):]
+		}
+		rest = append(pathElements(target), rest...)
+	}
+	return cur
+}
+
+// pathElements splits path at its separators, dropping empty elements.
+func pathElements(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == filepath.Separator })
+}
+
+// hasSeparator reports whether path contains a separator. Forward slashes
+// count on every platform so rules like "~/.ssh" work on Windows too.
+func hasSeparator(path string) bool {
+	return strings.ContainsAny(path, "/"+string(filepath.Separator))
+}
+
+// expandHome replaces a leading "~" with the user's home directory. Both
+// "~/" and the platform separator ("~\" on Windows) are accepted.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// WithPathPolicy wraps a file tool so calls whose "path" argument violates the
+// policy return an error response naming the matched rule.
+func WithPathPolicy(tool llm.Tool, policy *PathPolicy) llm.Tool {
+	if policy == nil {
+		return tool
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(input, &args); err == nil && args.Path != "" {
+			if err := policy.Check(ResolvePath(ctx, args.Path)); err != nil {
+				return llm.NewTextErrorResponse(err.Error()), nil
+			}
+		}
+		return execute(ctx, input)
+	}
+	return tool
+}
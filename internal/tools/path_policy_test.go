@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// newPolicyTree creates <root>/repo/main.go and <root>/secret/key, returning root.
+func newPolicyTree(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"repo", "secret"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "repo", "main.go"), []byte("package main"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret", "key"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestPathPolicyNilAllowsEverything(t *testing.T) {
+	if p := NewPathPolicy(nil, nil, false); p != nil {
+		t.Fatalf("expected nil policy without rules, got %+v", p)
+	}
+	var p *PathPolicy
+	if err := p.Check("/etc/passwd"); err != nil {
+		t.Errorf("nil policy should allow everything: %v", err)
+	}
+}
+
+func TestPathPolicyAllowAndDeny(t *testing.T) {
+	root := newPolicyTree(t)
+	repo := filepath.Join(root, "repo")
+	p := NewPathPolicy([]string{repo}, []string{filepath.Join(repo, "*.key"), ".env"}, false)
+
+	tests := []struct {
+		path    string
+		allowed bool
+		rule    string
+	}{
+		{filepath.Join(repo, "main.go"), true, ""},
+		{filepath.Join(repo, "new", "file.go"), true, ""}, // not created yet
+		{filepath.Join(repo, "id.key"), false, "*.key"},
+		{filepath.Join(repo, "sub", ".env"), false, ".env"},
+		{filepath.Join(root, "secret", "key"), false, "outside the allowed paths"},
+		{repo + "/../../" + filepath.Base(root) + "/secret/key", false, "outside the allowed paths"},
+		{repo + "/../secret/key", false, "outside the allowed paths"},
+		{repo + "-other/file", false, "outside the allowed paths"},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.path)
+		if tt.allowed && err != nil {
+			t.Errorf("Check(%q) = %v, want allowed", tt.path, err)
+		}
+		if !tt.allowed && (err == nil || !strings.Contains(err.Error(), tt.rule)) {
+			t.Errorf("Check(%q) = %v, want error mentioning %q", tt.path, err, tt.rule)
+		}
+	}
+}
+
+func TestPathPolicyRelativePaths(t *testing.T) {
+	root := newPolicyTree(t)
+	t.Chdir(filepath.Join(root, "repo"))
+	p := NewPathPolicy([]string{"."}, nil, false)
+
+	if err := p.Check("main.go"); err != nil {
+		t.Errorf("relative path inside allowed dir: %v", err)
+	}
+	if err := p.Check("../secret/key"); err == nil {
+		t.Error("../secret/key should be outside the allowed paths")
+	}
+	if err := p.Check("../../../../../etc/passwd"); err == nil {
+		t.Error("../../etc/passwd should be outside the allowed paths")
+	}
+}
+
+func TestPathPolicySymlinkEscape(t *testing.T) {
+	root := newPolicyTree(t)
+	repo := filepath.Join(root, "repo")
+	secret := filepath.Join(root, "secret")
+	if err := os.Symlink(secret, filepath.Join(repo, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	// A symlink inside the allowed directory must not reach outside it
+	allow := NewPathPolicy([]string{repo}, nil, false)
+	if err := allow.Check(filepath.Join(repo, "link", "key")); err == nil {
+		t.Error("symlink escape should be outside the allowed paths")
+	}
+	if err := allow.Check(filepath.Join(repo, "link", "new-file")); err == nil {
+		t.Error("new file behind a symlink escape should be outside the allowed paths")
+	}
+
+	// A denied directory is denied whether reached directly or through a symlink
+	deny := NewPathPolicy(nil, []string{secret}, false)
+	if err := deny.Check(filepath.Join(repo, "link", "key")); err == nil || !strings.Contains(err.Error(), secret) {
+		t.Errorf("symlink into denied dir = %v, want denied by %q", err, secret)
+	}
+}
+
+func TestPathPolicySymlinkDotDot(t *testing.T) {
+	root := newPolicyTree(t)
+	repo := filepath.Join(root, "repo")
+	outside := filepath.Join(root, "outside")
+	if err := os.MkdirAll(filepath.Join(outside, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "passwd"), []byte("root:x:0:0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "sub"), filepath.Join(repo, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	// The system opens repo/link/../passwd as outside/passwd, not repo/passwd
+	path := repo + "/link/../passwd"
+	policy := NewPathPolicy([]string{repo}, nil, false)
+	if err := policy.Check(path); err == nil {
+		t.Error("link/.. should be outside the allowed paths")
+	}
+	if got := ResolvePath(WithWorkdir(context.Background(), NewWorkdir(repo)), "link/../passwd"); got != filepath.Join(outside, "passwd") {
+		t.Errorf("ResolvePath = %s, want the file the system opens", got)
+	}
+
+	input, _ := json.Marshal(ReadFileInput{Path: path})
+	result, err := WithPathPolicy(NewReadFileTool(), policy).Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.(llm.ToolResultOutputError); !ok {
+		t.Errorf("read_file read through link/..: %#v", result)
+	}
+}
+
+func TestPathPolicyDenyTakesPrecedence(t *testing.T) {
+	root := newPolicyTree(t)
+	p := NewPathPolicy([]string{root}, []string{filepath.Join(root, "secret")}, false)
+	if err := p.Check(filepath.Join(root, "secret", "key")); err == nil {
+		t.Error("deny rule should override allow rule")
+	}
+	if err := p.Check(filepath.Join(root, "repo", "main.go")); err != nil {
+		t.Errorf("allowed path: %v", err)
+	}
+}
+
+func TestPathPolicySafeMode(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	p := NewPathPolicy(nil, nil, true)
+
+	for _, path := range []string{
+		filepath.Join(home, ".ssh", "config"),
+		"~/.aws/credentials",
+		filepath.Join(home, ".bash_history"),
+		"/srv/project/deploy/id_ed25519",
+		"/srv/project/server.pem",
+	} {
+		if err := p.Check(path); err == nil {
+			t.Errorf("safe mode should deny %s", path)
+		}
+	}
+	if err := p.Check(filepath.Join(home, "project", "main.go")); err != nil {
+		t.Errorf("safe mode should allow ordinary files: %v", err)
+	}
+}
+
+func TestWithPathPolicy(t *testing.T) {
+	root := newPolicyTree(t)
+	secret := filepath.Join(root, "secret")
+	tool := WithPathPolicy(NewReadFileTool(), NewPathPolicy(nil, []string{secret}, false))
+
+	input, _ := json.Marshal(ReadFileInput{Path: filepath.Join(secret, "key")})
+	result, err := tool.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errResult, ok := result.(llm.ToolResultOutputError)
+	if !ok || !strings.Contains(errResult.Error, secret) {
+		t.Errorf("expected error naming the rule, got %#v", result)
+	}
+
+	input, _ = json.Marshal(ReadFileInput{Path: filepath.Join(root, "repo", "main.go"), LineNumbers: new(false)})
+	result, err = tool.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := result.(llm.ToolResultOutputText); !ok || text.Text != "package main" {
+		t.Errorf("expected file content, got %#v", result)
+	}
+}
+
+func TestExpandHomeAcceptsForwardSlash(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	if got, want := expandHome("~/.ssh"), filepath.Join(home, ".ssh"); got != want {
+		t.Errorf("expandHome(~/.ssh) = %q, want %q", got, want)
+	}
+	if got := expandHome("~other/.ssh"); got != "~other/.ssh" {
+		t.Errorf("expandHome should leave ~user paths alone, got %q", got)
+	}
+	if !hasSeparator("a/b") || hasSeparator("*.pem") {
+		t.Error("hasSeparator should treat forward slashes as separators")
+	}
+}
@@ -3,49 +3,99 @@ package tools
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"syscall"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/proc"
 )
 
 // PosixShellInput represents the input for the posix_shell tool
 type PosixShellInput struct {
-	Command string `json:"command" jsonschema:"required,description=The shell command to execute"`
+	Command  string `json:"command" jsonschema:"required,description=The shell command to execute"`
+	Combined bool   `json:"combined,omitempty" jsonschema:"description=Interleave stdout and stderr in one output section in the order they were written"`
+}
+
+// ResolveShell maps a shell name or path to an executable path.
+// Empty values and shells that cannot be found on PATH fall back to DefaultShell.
+func ResolveShell(name string) string {
+	if name == "" || name == "sh" {
+		return DefaultShell
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return DefaultShell
+	}
+	return path
+}
+
+// unsupportedShells cannot run POSIX "-c" commands.
+var unsupportedShells = map[string]bool{"cmd": true, "powershell": true, "pwsh": true}
+
+// CheckShell rejects shells that cannot run POSIX commands, such as cmd and
+// powershell on Windows.
+func CheckShell(name string) error {
+	base := strings.ToLower(filepath.Base(name))
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	if unsupportedShells[base] {
+		return fmt.Errorf("--shell %s is not supported: posix_shell needs a POSIX shell such as sh or bash%s", name, windowsShellHint)
+	}
+	return nil
 }
 
 // NewPosixShellTool creates a new posix_shell tool for executing shell commands
 func NewPosixShellTool() llm.Tool {
+	return NewPosixShellToolWithShell(DefaultShell)
+}
+
+// NewPosixShellToolWithShell creates a posix_shell tool that runs commands with
+// the given shell via "<shell> -c". The shell should come from ResolveShell.
+// Secret-looking environment variables are not passed to commands.
+func NewPosixShellToolWithShell(shell string) llm.Tool {
+	return NewPosixShellToolWithEnv(shell, EnvFilter{})
+}
+
+// NewPosixShellToolWithEnv is NewPosixShellToolWithShell with the environment
+// variables passed to commands chosen by env.
+func NewPosixShellToolWithEnv(shell string, env EnvFilter) llm.Tool {
+	syntaxRule := "- Use POSIX-compliant shell syntax only (no bash/zsh-specific features)"
+	if shell != DefaultShell {
+		syntaxRule = fmt.Sprintf("- Commands run with %s, so its syntax is available", filepath.Base(shell))
+	}
+
 	return llm.NewTool(
 		"posix_shell",
 		`Execute a shell command.
 
 Rules:
-- Use POSIX-compliant shell syntax only (no bash/zsh-specific features)
+`+syntaxRule+`
 - Prefer simple, standard commands over complex pipelines
 - Quote filenames with spaces or special characters
 - Check command output for errors before proceeding
-- Clean up temporary files when done`,
+- Clean up temporary files when done
+
+Output has a "stdout:" and a "stderr:" section (each only when non-empty) and always ends with "exit: N".
+Set combined to get one "output:" section with both streams in the order they were written.`,
 	).
 		WithSchema(llm.GenerateSchema(PosixShellInput{})).
-		WithExecute(llm.TypedExecute(executePosixShell)).
+		WithExecute(llm.TypedExecute(func(ctx context.Context, args PosixShellInput) (llm.ToolResultOutput, error) {
+			return executeShell(ctx, shell, env, args)
+		})).
 		Build()
 }
 
-func executePosixShell(ctx context.Context, args PosixShellInput) (llm.ToolResultOutput, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		cwd = "." // fallback to current directory
-	}
-
+func executeShell(ctx context.Context, shell string, env EnvFilter, args PosixShellInput) (llm.ToolResultOutput, error) {
 	//nolint:gosec // G204: Command from user input is intentional for shell tool
-	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", args.Command)
-	cmd.Dir = cwd
+	cmd := exec.CommandContext(ctx, shell, "-c", args.Command)
+	cmd.Dir = commandDir(ctx)
 	// Set environment variables to disable terminal features
-	cmd.Env = append(os.Environ(),
+	cmd.Env = append(env.Apply(os.Environ()),
 		"TERM=dumb",
 		"NO_COLOR=1",
 		"CI=true",
@@ -54,13 +104,18 @@ func executePosixShell(ctx context.Context, args PosixShellInput) (llm.ToolResul
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-
-	// Set process group ID so we can signal the entire process group (shell + children)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	if args.Combined {
+		// The same writer for both makes exec share one pipe, keeping order
+		cmd.Stderr = &stdout
 	}
 
+	// Start a new process group so we can signal the shell and its children together
+	proc.SetGroup(cmd)
+
 	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return llm.NewTextErrorResponse(fmt.Sprintf("failed to start command: shell %s not found%s", shell, windowsShellHint)), nil
+		}
 		return llm.NewTextErrorResponse("failed to start command: " + err.Error()), nil
 	}
 
@@ -72,37 +127,24 @@ func executePosixShell(ctx context.Context, args PosixShellInput) (llm.ToolResul
 
 	select {
 	case <-ctx.Done():
-		return handleShellCancellation(cmd, done, &stdout, &stderr)
+		return handleShellCancellation(cmd, done, &stdout, &stderr, args.Combined)
 	case execErr := <-done:
-		return handleShellCompletion(execErr, &stdout, &stderr)
+		return handleShellCompletion(execErr, &stdout, &stderr, args.Combined)
 	}
 }
 
-func handleShellCancellation(cmd *exec.Cmd, done chan error, stdout, stderr *bytes.Buffer) (llm.ToolResultOutput, error) {
+func handleShellCancellation(cmd *exec.Cmd, done chan error, stdout, stderr *bytes.Buffer, combined bool) (llm.ToolResultOutput, error) {
 	process := cmd.Process
 	if process != nil {
 		terminateProcessGroup(process, done)
 	}
-	output := combineShellOutput(stdout, stderr)
-	if output != "" {
-		return llm.NewTextErrorResponse("canceled: " + output), nil
-	}
-	return llm.NewTextErrorResponse("canceled"), nil
+	return llm.NewTextErrorResponse(formatShellOutput(stdout, stderr, combined, "canceled")), nil
 }
 
 func terminateProcessGroup(process *os.Process, done chan error) {
 	// Send SIGINT (Ctrl+C) to the process group so child processes also receive it
-	// Use negative PID to signal the entire process group
-	pgid, pgerr := syscall.Getpgid(process.Pid)
-	if pgerr == nil {
-		// Signal the process group
-		//nolint:errcheck // Best effort signal, errors ignored
-		_ = syscall.Kill(-pgid, syscall.SIGINT)
-	} else {
-		// Fallback: signal just the process
-		//nolint:errcheck // Best effort signal, errors ignored
-		_ = process.Signal(syscall.SIGINT)
-	}
+	//nolint:errcheck // Best effort signal, errors ignored
+	_ = proc.Interrupt(process)
 
 	// Give the process 2 seconds to clean up
 	select {
@@ -110,37 +152,53 @@ func terminateProcessGroup(process *os.Process, done chan error) {
 		// Process exited cleanly after SIGINT
 	case <-time.After(2 * time.Second):
 		// Force kill if still running
-		if pgerr == nil {
-			//nolint:errcheck // Best effort kill, errors ignored
-			_ = syscall.Kill(-pgid, syscall.SIGKILL)
-		} else {
-			//nolint:errcheck // Best effort kill, errors ignored
-			_ = process.Kill()
-		}
+		//nolint:errcheck // Best effort kill, errors ignored
+		_ = proc.Kill(process)
 		<-done
 	}
 }
 
-func handleShellCompletion(execErr error, stdout, stderr *bytes.Buffer) (llm.ToolResultOutput, error) {
-	output := combineShellOutput(stdout, stderr)
-
+func handleShellCompletion(execErr error, stdout, stderr *bytes.Buffer, combined bool) (llm.ToolResultOutput, error) {
 	if execErr != nil {
 		if exitErr, ok := execErr.(*exec.ExitError); ok {
-			return llm.NewTextErrorResponse(fmt.Sprintf("[%d] %s", exitErr.ExitCode(), output)), nil
+			return llm.NewTextErrorResponse(formatShellOutput(stdout, stderr, combined, strconv.Itoa(exitErr.ExitCode()))), nil
 		}
 		return llm.NewTextErrorResponse(execErr.Error()), nil
 	}
 
-	return llm.NewTextResponse(output), nil
+	return llm.NewTextResponse(formatShellOutput(stdout, stderr, combined, "0")), nil
 }
 
-func combineShellOutput(stdout, stderr *bytes.Buffer) string {
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		if output != "" {
-			output += "\n"
+// formatShellOutput lays out a command's result as labeled sections:
+//
+//	stdout:
+//	...
+//	stderr:
+//	...
+//	exit: 1
+//
+// Empty streams are left out; the exit line is always present so success
+// never has to be inferred from silence. Combined output uses one "output:"
+// section instead. Each stream goes through SanitizeOutput.
+func formatShellOutput(stdout, stderr *bytes.Buffer, combined bool, exit string) string {
+	var sb strings.Builder
+	section := func(label string, buf *bytes.Buffer) {
+		if buf.Len() == 0 {
+			return
 		}
-		output += stderr.String()
+		sb.WriteString(label + ":\n")
+		text := SanitizeOutput(buf.String())
+		sb.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	if combined {
+		section("output", stdout)
+	} else {
+		section("stdout", stdout)
+		section("stderr", stderr)
 	}
-	return output
+	sb.WriteString("exit: " + exit)
+	return sb.String()
 }
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func execShell(t *testing.T, tool llm.Tool, command string) llm.ToolResultOutput {
+	t.Helper()
+	input, _ := json.Marshal(PosixShellInput{Command: command})
+	result, err := tool.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestResolveShell(t *testing.T) {
+	if got := ResolveShell(""); got != DefaultShell {
+		t.Errorf("ResolveShell(\"\") = %q, want %q", got, DefaultShell)
+	}
+	if got := ResolveShell("no-such-shell-alayacore"); got != DefaultShell {
+		t.Errorf("missing shell should fall back to %q, got %q", DefaultShell, got)
+	}
+	if path, err := exec.LookPath("bash"); err == nil {
+		if got := ResolveShell("bash"); got != path {
+			t.Errorf("ResolveShell(\"bash\") = %q, want %q", got, path)
+		}
+	}
+}
+
+func TestCheckShellRejectsNonPOSIXShells(t *testing.T) {
+	for _, name := range []string{"cmd", "CMD.EXE", "powershell.exe", "/usr/bin/pwsh"} {
+		if err := CheckShell(name); err == nil {
+			t.Errorf("CheckShell(%q) should fail", name)
+		}
+	}
+	for _, name := range []string{"", "sh", "bash", "/bin/zsh"} {
+		if err := CheckShell(name); err != nil {
+			t.Errorf("CheckShell(%q) = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestMissingShellExplainsItself(t *testing.T) {
+	tool := NewPosixShellToolWithShell("no-such-shell-alayacore")
+	out, ok := execShell(t, tool, "true").(llm.ToolResultOutputError)
+	if !ok || !strings.Contains(out.Error, "shell no-such-shell-alayacore not found") {
+		t.Errorf("expected a shell-not-found error, got %#v", out)
+	}
+}
+
+func TestShellOutputMatchesAcrossShells(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"success", "echo out; echo err >&2"},
+		{"exit code", "echo partial; exit 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := execShell(t, NewPosixShellTool(), tt.command)
+			got := execShell(t, NewPosixShellToolWithShell(bash), tt.command)
+			if got != want {
+				t.Errorf("bash result %#v differs from default shell result %#v", got, want)
+			}
+		})
+	}
+
+	result := execShell(t, NewPosixShellToolWithShell(bash), "echo partial; exit 3")
+	errResp, ok := result.(llm.ToolResultOutputError)
+	if !ok || errResp.Error != "stdout:\npartial\nexit: 3" {
+		t.Errorf("expected exit code section, got %#v", result)
+	}
+}
+
+func TestBashSyntaxWithConfiguredShell(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	result := execShell(t, NewPosixShellToolWithShell(bash), "arr=(a b c); echo ${arr[1]}")
+	text, ok := result.(llm.ToolResultOutputText)
+	if !ok || text.Text != "stdout:\nb\nexit: 0" {
+		t.Errorf("expected bash array output, got %#v", result)
+	}
+}
+
+func TestShellSeparatesStdoutAndStderr(t *testing.T) {
+	tests := []struct {
+		name  string
+		input PosixShellInput
+		want  string
+	}{
+		{"both streams", PosixShellInput{Command: "echo out; echo warn >&2"}, "stdout:\nout\nstderr:\nwarn\nexit: 0"},
+		{"no output still reports exit", PosixShellInput{Command: "true"}, "exit: 0"},
+		{"missing trailing newline", PosixShellInput{Command: "printf out"}, "stdout:\nout\nexit: 0"},
+		{"combined keeps order", PosixShellInput{Command: "echo one; echo two >&2; echo three", Combined: true}, "output:\none\ntwo\nthree\nexit: 0"},
+		{"colors are stripped", PosixShellInput{Command: `printf '\033[1;31merror\033[0m: bad\r\n'`}, "stdout:\nerror: bad\nexit: 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(tt.input)
+			result, err := NewPosixShellTool().Execute(context.Background(), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, ok := result.(llm.ToolResultOutputText)
+			if !ok || text.Text != tt.want {
+				t.Errorf("got %#v, want text %q", result, tt.want)
+			}
+		})
+	}
+
+	result := execShell(t, NewPosixShellTool(), "echo out; echo bad >&2; exit 2")
+	if errResp, ok := result.(llm.ToolResultOutputError); !ok || errResp.Error != "stdout:\nout\nstderr:\nbad\nexit: 2" {
+		t.Errorf("unexpected failure result %#v", result)
+	}
+}
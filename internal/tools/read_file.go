@@ -8,93 +8,116 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/alayacore/alayacore/internal/llm"
 )
 
-const maxFullReadSize = 10 * 1024 * 1024 // 10MB limit for full file reads
+// sniffSize is the number of bytes checked for binary content.
+const sniffSize = 8 * 1024
 
-const sniffSize = 512 // Number of bytes to check for binary detection
+// Default read limits; see ReadLimit.
+const (
+	DefaultReadMaxLines = 2000
+	DefaultReadMaxBytes = 256 * 1024
+)
+
+// ReadLimit caps what one read_file call returns, so a huge file cannot fill
+// the context. Zero fields take the defaults.
+type ReadLimit struct {
+	Lines int // lines per call
+	Bytes int // bytes of text per call
+}
+
+func (l ReadLimit) withDefaults() ReadLimit {
+	if l.Lines <= 0 {
+		l.Lines = DefaultReadMaxLines
+	}
+	if l.Bytes <= 0 {
+		l.Bytes = DefaultReadMaxBytes
+	}
+	return l
+}
 
 // ReadFileInput represents the input for the read_file tool
 type ReadFileInput struct {
 	Path      string `json:"path" jsonschema:"required,description=The path of the file to read"`
 	StartLine string `json:"start_line" jsonschema:"description=Optional: The starting line number (1-indexed)"`
 	EndLine   string `json:"end_line" jsonschema:"description=Optional: The ending line number (1-indexed)"`
+	// LineNumbers is a pointer so that leaving it out keeps the gutter on
+	LineNumbers *bool `json:"line_numbers,omitempty" jsonschema:"type=boolean,description=Optional: Prefix each line with its number (default true); false returns the text exactly as stored"`
 }
 
-// NewReadFileTool creates a tool for reading files
+// NewReadFileTool creates a tool for reading files with the default limits.
 func NewReadFileTool() llm.Tool {
+	return NewReadFileToolWithLimit(ReadLimit{})
+}
+
+// NewReadFileToolWithLimit creates a tool for reading files that returns at
+// most limit per call.
+func NewReadFileToolWithLimit(limit ReadLimit) llm.Tool {
+	limit = limit.withDefaults()
 	return llm.NewTool(
 		"read_file",
-		"Read the contents of a file. Supports optional line range using start_line and end_line parameters (1-indexed).",
+		fmt.Sprintf("Read the contents of a text file. Without a range, the whole file is returned. "+
+			"With start_line and/or end_line (1-indexed, inclusive), only those lines are returned. Each line is "+
+			"prefixed with a gutter holding its number, as in \"  42 | text\"; the gutter is not part of the file. "+
+			"A [lines A-B hash H] note ends the output: pass H as expected_hash to replace_lines to edit exactly "+
+			"those lines. Set line_numbers to false for the raw text. At most %d lines or %s are "+
+			"returned per call; a [truncated ...] note says where to continue.", limit.Lines, byteLimit(limit.Bytes)),
 	).
 		WithSchema(llm.GenerateSchema(ReadFileInput{})).
-		WithExecute(llm.TypedExecute(executeReadFile)).
+		WithExecute(llm.TypedExecute(func(ctx context.Context, args ReadFileInput) (llm.ToolResultOutput, error) {
+			return executeReadFile(ctx, args, limit)
+		})).
+		WithParallel().
 		Build()
 }
 
-func executeReadFile(_ context.Context, args ReadFileInput) (llm.ToolResultOutput, error) {
+func executeReadFile(ctx context.Context, args ReadFileInput, limit ReadLimit) (llm.ToolResultOutput, error) {
+	args.Path = ResolvePath(ctx, args.Path)
 	info, err := os.Stat(args.Path)
 	if err != nil {
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
 
-	// Check if file is binary before attempting to read
 	file, err := os.Open(args.Path)
 	if err != nil {
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
+	defer file.Close()
 
+	// Check if file is binary before attempting to read
 	isBinary, err := isBinaryFile(file)
 	if err != nil {
-		file.Close()
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
 	if isBinary {
-		file.Close()
-		return llm.NewTextErrorResponse("file appears to be binary (non-text). This tool only works with text files."), nil
+		return llm.NewTextErrorResponse(fmt.Sprintf(
+			"file appears to be binary (%d bytes); read_file only reads text. "+
+				"Inspect it with posix_shell instead, e.g. file, hexdump -C | head, or strings.",
+			info.Size(),
+		)), nil
 	}
 
 	// Parse line range parameters
 	startLine, endLine, err := parseLineRange(args.StartLine, args.EndLine)
 	if err != nil {
-		file.Close()
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
 
-	// Full file read case
-	if startLine == 0 && endLine == 0 {
-		file.Close()
-		if info.Size() > maxFullReadSize {
-			return llm.NewTextErrorResponse(fmt.Sprintf(
-				"file is too large for full read (%d bytes, limit is %d). Use start_line and end_line to read a specific range.",
-				info.Size(), maxFullReadSize,
-			)), nil
-		}
-		var content []byte
-		content, err = os.ReadFile(args.Path)
-		if err != nil {
-			return llm.NewTextErrorResponse(err.Error()), nil
-		}
-		return llm.NewTextResponse(string(content)), nil
-	}
-
-	// Line range case: stream from file to avoid loading entire file into memory
-	// Reset file position to beginning for reading lines
-	_, err = file.Seek(0, 0)
-	if err != nil {
-		file.Close()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
-	defer file.Close()
-
-	lines, err := readLinesRange(file, startLine, endLine)
+	numbered := args.LineNumbers == nil || *args.LineNumbers
+	if startLine == 0 {
+		startLine = 1
+	}
+	text, err := readLines(file, startLine, endLine, numbered, limit, info.Size())
 	if err != nil {
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
-
-	return llm.NewTextResponse(strings.Join(lines, "\n")), nil
+	return llm.NewTextResponse(text), nil
 }
 
 func parseLineRange(startLineStr, endLineStr string) (startLine, endLine int, err error) {
@@ -129,35 +152,115 @@ func parseLineRange(startLineStr, endLineStr string) (startLine, endLine int, er
 	return startLine, endLine, nil
 }
 
-func readLinesRange(file *os.File, startLine, endLine int) ([]string, error) {
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size to handle long lines (default is 64KB)
-	// We use 1MB which should be reasonable for most cases while still
-	// preventing memory exhaustion from extremely long lines
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
-
-	var lines []string
-	currentLine := 1
-
-	for scanner.Scan() {
-		if startLine > 0 && currentLine < startLine {
-			currentLine++
+// readLines reads lines startLine to endLine (0 for the end of the file),
+// streaming, so only what is returned is held in memory. Unnumbered reads
+// keep the file's text exactly, final newline included; numbered reads put
+// a gutter before each line and end with the hash of the lines returned
+// (see lineHash). When limit stops the read early, a [truncated ...] note
+// saying where to continue is appended. Invalid UTF-8 is replaced, as
+// providers reject it.
+func readLines(file *os.File, startLine, endLine int, numbered bool, limit ReadLimit, size int64) (string, error) {
+	r := bufio.NewReader(file)
+	var sb, note strings.Builder
+	hash := newLineHash()
+	lineNum, count := 0, 0
+	for {
+		line, long, err := readLine(r, limit.Bytes)
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		lineNum++
+		if lineNum < startLine {
 			continue
 		}
-
-		if endLine > 0 && currentLine > endLine {
+		if endLine > 0 && lineNum > endLine {
+			break
+		}
+		if count == limit.Lines {
+			writeTruncated(&note, fmt.Sprintf("%d-line limit", limit.Lines), lineNum, size)
+			break
+		}
+		raw := line
+		if numbered {
+			line = gutter(lineNum) + line
+		}
+		if long || sb.Len()+len(line) > limit.Bytes {
+			if count == 0 {
+				// A single line over the limit: return its head rather than nothing
+				sb.WriteString(truncateUTF8(line, limit.Bytes))
+				writeTruncated(&note, fmt.Sprintf("%s limit in the middle of line %d", byteLimit(limit.Bytes), lineNum), lineNum+1, size)
+				break
+			}
+			writeTruncated(&note, byteLimit(limit.Bytes)+" limit", lineNum, size)
 			break
 		}
+		sb.WriteString(line)
+		hash.Write([]byte(raw))
+		count++
+		if err != nil {
+			break
+		}
+	}
+	if numbered && count > 0 {
+		if !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "[lines %d-%d hash %s]\n", startLine, startLine+count-1, hash.Sum())
+	}
+	if note.Len() > 0 {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(note.String())
+	}
+	text := sb.String()
+	if numbered {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	return strings.ToValidUTF8(text, "\uFFFD"), nil
+}
 
-		lines = append(lines, scanner.Text())
-		currentLine++
+// readLine reads one line, newline included. Past max bytes the rest of
+// the line is skipped and long is set, so a huge line never sits in memory.
+func readLine(r *bufio.Reader, max int) (line string, long bool, err error) {
+	var sb strings.Builder
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if room := max + 1 - sb.Len(); room > 0 {
+			sb.Write(chunk[:min(len(chunk), room)])
+		}
+		if err != bufio.ErrBufferFull {
+			return sb.String(), sb.Len() > max, err
+		}
 	}
+}
+
+// writeTruncated writes the note ending a read stopped by a limit.
+func writeTruncated(sb *strings.Builder, reason string, next int, size int64) {
+	fmt.Fprintf(sb, "[truncated at the %s; the file has %d bytes. Continue with start_line=%d]\n", reason, size, next)
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// byteLimit formats a byte limit for a truncation note: "256 KB" or
+// "100-byte".
+func byteLimit(n int) string {
+	if n >= 1024 && n%1024 == 0 {
+		return fmt.Sprintf("%d KB", n/1024)
 	}
+	return fmt.Sprintf("%d-byte", n)
+}
 
-	return lines, nil
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
 
 // isBinaryFile detects if a file is binary (non-text) by checking for null bytes
@@ -21,7 +21,7 @@ func TestReadFileFull(t *testing.T) {
 	}
 
 	tool := NewReadFileTool()
-	input := ReadFileInput{Path: tmpFile}
+	input := ReadFileInput{Path: tmpFile, LineNumbers: new(false)}
 	inputJSON, _ := json.Marshal(input)
 	result, err := tool.Execute(context.Background(), inputJSON)
 	if err != nil {
@@ -37,6 +37,22 @@ func TestReadFileFull(t *testing.T) {
 	}
 }
 
+func TestReadFileGutterAndHash(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(tmpFile, []byte("line1\nline2\nline3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inputJSON, _ := json.Marshal(ReadFileInput{Path: tmpFile})
+	result, err := NewReadFileTool().Execute(context.Background(), inputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "   1 | line1\n   2 | line2\n   3 | line3\n[lines 1-3 hash " + lineHashOf("line1\nline2\nline3") + "]"
+	if textResp, ok := result.(llm.ToolResultOutputText); !ok || textResp.Text != want {
+		t.Errorf("got %#v, want %q", result, want)
+	}
+}
+
 func TestReadFileWithLineRange(t *testing.T) {
 	// Create a temp file with many lines
 	tmpDir := t.TempDir()
@@ -61,27 +77,27 @@ func TestReadFileWithLineRange(t *testing.T) {
 		{
 			name:      "read lines 5-10",
 			input:     ReadFileInput{Path: tmpFile, StartLine: "5", EndLine: "10"},
-			wantLines: []string{"line5", "line6", "line7", "line8", "line9", "line10"},
+			wantLines: []string{"   5 | line5", "   6 | line6", "   7 | line7", "   8 | line8", "   9 | line9", "  10 | line10"},
 		},
 		{
 			name:      "read first line",
 			input:     ReadFileInput{Path: tmpFile, StartLine: "1", EndLine: "1"},
-			wantLines: []string{"line1"},
+			wantLines: []string{"   1 | line1"},
 		},
 		{
 			name:      "read last line",
 			input:     ReadFileInput{Path: tmpFile, StartLine: "100", EndLine: "100"},
-			wantLines: []string{"line100"},
+			wantLines: []string{" 100 | line100"},
 		},
 		{
 			name:      "read from line to end",
 			input:     ReadFileInput{Path: tmpFile, StartLine: "98"},
-			wantLines: []string{"line98", "line99", "line100"},
+			wantLines: []string{"  98 | line98", "  99 | line99", " 100 | line100"},
 		},
 		{
 			name:      "read from start to line",
 			input:     ReadFileInput{Path: tmpFile, EndLine: "3"},
-			wantLines: []string{"line1", "line2", "line3"},
+			wantLines: []string{"   1 | line1", "   2 | line2", "   3 | line3"},
 		},
 		{
 			name:      "invalid start_line",
@@ -135,19 +151,23 @@ func TestReadFileWithLineRange(t *testing.T) {
 				return
 			}
 
+			text, note, _ := cutLastLine(textResp.Text)
 			expected := strings.Join(tt.wantLines, "\n")
-			if textResp.Text != expected {
-				t.Errorf("expected %q, got %q", expected, textResp.Text)
+			if text != expected {
+				t.Errorf("expected %q, got %q", expected, text)
+			}
+			if !strings.HasPrefix(note, "[lines ") {
+				t.Errorf("expected a hash note, got %q", note)
 			}
 		})
 	}
 }
 
-func TestReadFileTooLarge(t *testing.T) {
-	// Create a temp file larger than maxFullReadSize
+func TestReadFileCapsLongLine(t *testing.T) {
+	// A single line far over the byte limit
 	tmpDir := t.TempDir()
 	tmpFile := filepath.Join(tmpDir, "large.txt")
-	largeContent := make([]byte, maxFullReadSize+1)
+	largeContent := make([]byte, 10*1024*1024)
 	for i := range largeContent {
 		largeContent[i] = 'x'
 	}
@@ -163,12 +183,91 @@ func TestReadFileTooLarge(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	errResp, ok := result.(llm.ToolResultOutputError)
+	textResp, ok := result.(llm.ToolResultOutputText)
 	if !ok {
-		t.Errorf("expected error response for large file, got %T", result)
+		t.Fatalf("expected a truncated text response, got %T", result)
+	}
+	head, note, _ := strings.Cut(textResp.Text, "\n")
+	if len(head) != DefaultReadMaxBytes {
+		t.Errorf("returned %d bytes of the line, want %d", len(head), DefaultReadMaxBytes)
+	}
+	if !strings.HasPrefix(note, "[truncated at the 256 KB limit in the middle of line 1; the file has 10485760 bytes.") {
+		t.Errorf("truncation note = %q", note)
+	}
+}
+
+func TestReadFileLineAndByteLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "lines.txt")
+	var content strings.Builder
+	for i := 1; i <= 50; i++ {
+		content.WriteString("line" + itoa(i) + "\n")
+	}
+	if err := os.WriteFile(tmpFile, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		limit ReadLimit
+		input ReadFileInput
+		want  string
+	}{
+		{
+			name:  "line limit on a full read",
+			limit: ReadLimit{Lines: 2},
+			input: ReadFileInput{Path: tmpFile, LineNumbers: new(false)},
+			want:  "line1\nline2\n[truncated at the 2-line limit; the file has 341 bytes. Continue with start_line=3]\n",
+		},
+		{
+			name:  "line limit on a range",
+			limit: ReadLimit{Lines: 2},
+			input: ReadFileInput{Path: tmpFile, StartLine: "10", EndLine: "20"},
+			want:  "  10 | line10\n  11 | line11\n[lines 10-11 hash " + lineHashOf("line10\nline11\n") + "]\n[truncated at the 2-line limit; the file has 341 bytes. Continue with start_line=12]",
+		},
+		{
+			name:  "byte limit stops at a whole line",
+			limit: ReadLimit{Bytes: 14},
+			input: ReadFileInput{Path: tmpFile, LineNumbers: new(false)},
+			want:  "line1\nline2\n[truncated at the 14-byte limit; the file has 341 bytes. Continue with start_line=3]\n",
+		},
+		{
+			name:  "range within the limits",
+			limit: ReadLimit{Lines: 2},
+			input: ReadFileInput{Path: tmpFile, StartLine: "49"},
+			want:  "  49 | line49\n  50 | line50\n[lines 49-50 hash " + lineHashOf("line49\nline50\n") + "]",
+		},
 	}
-	if !strings.Contains(errResp.Error, "too large") {
-		t.Errorf("expected 'too large' error, got %q", errResp.Error)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputJSON, _ := json.Marshal(tt.input)
+			result, err := NewReadFileToolWithLimit(tt.limit).Execute(context.Background(), inputJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			textResp, ok := result.(llm.ToolResultOutputText)
+			if !ok {
+				t.Fatalf("expected text response, got %#v", result)
+			}
+			if textResp.Text != tt.want {
+				t.Errorf("got %q, want %q", textResp.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileReplacesInvalidUTF8(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "latin1.txt")
+	if err := os.WriteFile(tmpFile, []byte("caf\xe9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inputJSON, _ := json.Marshal(ReadFileInput{Path: tmpFile, LineNumbers: new(false)})
+	result, err := NewReadFileTool().Execute(context.Background(), inputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if textResp, ok := result.(llm.ToolResultOutputText); !ok || textResp.Text != "caf\uFFFD\n" {
+		t.Errorf("got %#v", result)
 	}
 }
 
@@ -206,8 +305,8 @@ func TestReadFileLargeWithLineRange(t *testing.T) {
 		t.Errorf("expected text response, got error: %q", errResp.Error)
 		return
 	}
-	if textResp.Text != "first line" {
-		t.Errorf("expected 'first line', got %q", textResp.Text)
+	if text, _, _ := cutLastLine(textResp.Text); text != "   1 | first line" {
+		t.Errorf("expected '   1 | first line', got %q", textResp.Text)
 	}
 
 	// Also test reading the third line
@@ -222,8 +321,8 @@ func TestReadFileLargeWithLineRange(t *testing.T) {
 	if !ok {
 		t.Errorf("expected text response, got %T", result)
 	}
-	if textResp.Text != "third line" {
-		t.Errorf("expected 'third line', got %q", textResp.Text)
+	if text, _, _ := cutLastLine(textResp.Text); text != "   3 | third line" {
+		t.Errorf("expected '   3 | third line', got %q", textResp.Text)
 	}
 }
 
@@ -270,6 +369,11 @@ func TestReadFileBinary(t *testing.T) {
 			content:     []byte("Hello 世界\nПривет мир\n🎉\n"),
 			expectError: false,
 		},
+		{
+			name:        "null byte after the first 512 bytes",
+			content:     append([]byte(strings.Repeat("text ", 1000)), 0x00),
+			expectError: true,
+		},
 		{
 			name:        "empty file",
 			content:     []byte{},
@@ -323,6 +427,15 @@ func TestReadFileBinary(t *testing.T) {
 	}
 }
 
+// cutLastLine splits off the last line of a numbered read, its hash note.
+func cutLastLine(s string) (text, last string, found bool) {
+	i := strings.LastIndex(s, "\n")
+	if i < 0 {
+		return "", s, false
+	}
+	return s[:i], s[i+1:], true
+}
+
 // Helper function to convert int to string without strconv
 func itoa(i int) string {
 	if i == 0 {
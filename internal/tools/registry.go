@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/memory"
+	"github.com/alayacore/alayacore/internal/skills"
+)
+
+// Deps carries the runtime dependencies tool constructors may need.
+type Deps struct {
+	Shell  string          // Resolved shell path for posix_shell
+	Env    EnvFilter       // Environment variables passed to posix_shell and git commands
+	Skills *skills.Manager // Skills manager for activate_skill
+	Read   ReadLimit       // Per-call limits of read_file
+	Memory *memory.Manager // Persistent memory for save_memory and search_memory; nil without --memory
+}
+
+// Constructor builds a tool from its dependencies.
+type Constructor func(deps Deps) llm.Tool
+
+// Registry maps tool names to constructors, in registration order.
+type Registry struct {
+	names []string
+	ctors map[string]Constructor
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{ctors: make(map[string]Constructor)}
+}
+
+// DefaultRegistry holds the built-in tools. Register custom tools here before
+// calling app.Setup to make them available (and selectable with --enable-tools
+// and --disable-tools).
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("read_file", func(d Deps) llm.Tool { return NewReadFileToolWithLimit(d.Read) })
+	r.Register("edit_file", func(Deps) llm.Tool { return NewEditFileTool() })
+	r.Register("replace_lines", func(Deps) llm.Tool { return NewReplaceLinesTool() })
+	r.Register("write_file", func(Deps) llm.Tool { return NewWriteFileTool() })
+	r.Register("activate_skill", func(d Deps) llm.Tool { return NewActivateSkillTool(d.Skills) })
+	r.Register("posix_shell", func(d Deps) llm.Tool { return NewPosixShellToolWithEnv(d.Shell, d.Env) })
+	r.Register("git", func(d Deps) llm.Tool { return NewGitTool(d.Env) })
+	r.Register("manage_todo", func(Deps) llm.Tool { return NewManageTodoTool() })
+	r.Register("save_memory", func(d Deps) llm.Tool { return NewSaveMemoryTool(d.Memory) })
+	r.Register("search_memory", func(d Deps) llm.Tool { return NewSearchMemoryTool(d.Memory) })
+	return r
+}
+
+// Register adds a tool constructor. Registering an existing name replaces its
+// constructor and keeps its position.
+func (r *Registry) Register(name string, ctor Constructor) {
+	if _, ok := r.ctors[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.ctors[name] = ctor
+}
+
+// Names returns the registered tool names in registration order.
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Select resolves --enable-tools and --disable-tools into the tool names to
+// build. An empty enable list means every registered tool; disable wins over
+// enable. Unknown names are an error.
+func (r *Registry) Select(enable, disable []string) ([]string, error) {
+	if err := r.checkNames("--enable-tools", enable); err != nil {
+		return nil, err
+	}
+	if err := r.checkNames("--disable-tools", disable); err != nil {
+		return nil, err
+	}
+
+	enabled := toSet(enable)
+	disabled := toSet(disable)
+	var names []string
+	for _, name := range r.names {
+		if len(enabled) > 0 && !enabled[name] {
+			continue
+		}
+		if disabled[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Build constructs the named tools in registration order.
+func (r *Registry) Build(names []string, deps Deps) ([]llm.Tool, error) {
+	if err := r.checkNames("tool", names); err != nil {
+		return nil, err
+	}
+	selected := toSet(names)
+	var result []llm.Tool
+	for _, name := range r.names {
+		if selected[name] {
+			result = append(result, r.ctors[name](deps))
+		}
+	}
+	return result, nil
+}
+
+func (r *Registry) checkNames(what string, names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := r.ctors[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown %s: %s (available: %s)", what, strings.Join(unknown, ", "), strings.Join(r.names, ", "))
+	}
+	return nil
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestDefaultRegistryNames(t *testing.T) {
+	want := []string{"read_file", "edit_file", "replace_lines", "write_file", "activate_skill", "posix_shell", "git", "manage_todo", "save_memory", "search_memory"}
+	if got := DefaultRegistry.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistrySelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		enable  []string
+		disable []string
+		want    []string
+	}{
+		{"all by default", nil, nil, []string{"read_file", "edit_file", "replace_lines", "write_file", "activate_skill", "posix_shell", "git", "manage_todo", "save_memory", "search_memory"}},
+		{"disable shell", nil, []string{"posix_shell"}, []string{"read_file", "edit_file", "replace_lines", "write_file", "activate_skill", "git", "manage_todo", "save_memory", "search_memory"}},
+		{"enable subset keeps registry order", []string{"posix_shell", "read_file"}, nil, []string{"read_file", "posix_shell"}},
+		{"disable wins", []string{"read_file", "write_file"}, []string{"write_file"}, []string{"read_file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultRegistry.Select(tt.enable, tt.disable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryUnknownNames(t *testing.T) {
+	if _, err := DefaultRegistry.Select(nil, []string{"bash"}); err == nil || !strings.Contains(err.Error(), "bash") {
+		t.Errorf("expected error naming the unknown tool, got %v", err)
+	}
+	if _, err := DefaultRegistry.Select([]string{"nope"}, nil); err == nil {
+		t.Error("expected error for unknown enabled tool")
+	}
+}
+
+func TestRegistryCustomTool(t *testing.T) {
+	r := NewRegistry()
+	r.Register("echo", func(Deps) llm.Tool { return llm.NewTool("echo", "Echo input").Build() })
+	built, err := r.Build([]string{"echo"}, Deps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(built) != 1 || built[0].Definition.Name != "echo" {
+		t.Errorf("unexpected tools: %+v", built)
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// ReplaceLinesInput represents the input for the replace_lines tool
+type ReplaceLinesInput struct {
+	Path         string `json:"path" jsonschema:"required,description=The path of the file to edit"`
+	StartLine    string `json:"start_line" jsonschema:"required,description=The first line to replace (1-indexed)"`
+	EndLine      string `json:"end_line" jsonschema:"required,description=The last line to replace (inclusive); start_line - 1 inserts before start_line"`
+	NewContent   string `json:"new_content" jsonschema:"description=The text that takes the place of the lines; empty deletes them"`
+	ExpectedHash string `json:"expected_hash,omitempty" jsonschema:"description=Optional: The hash read_file reported for exactly these lines; the edit fails if they changed since"`
+}
+
+// NewReplaceLinesTool creates a tool for replacing a range of lines
+func NewReplaceLinesTool() llm.Tool {
+	return llm.NewTool(
+		"replace_lines",
+		`Replace lines start_line to end_line (1-indexed, inclusive) of a file with new_content.
+
+Use the line numbers from the read_file gutter ("  42 | text"); the gutter itself is not part of the file.
+Pass the hash from read_file's [lines A-B hash H] note as expected_hash when A-B is the range you replace:
+if those lines changed since you read them, nothing is written and the current lines are returned.
+
+- new_content replaces whole lines; a final newline is added if missing
+- Empty new_content deletes the lines
+- end_line = start_line - 1 inserts new_content before start_line (start_line may be one past the last line to append)
+- Line numbers after the range shift by the difference in line count; read again before further edits`,
+	).
+		WithSchema(llm.GenerateSchema(ReplaceLinesInput{})).
+		WithExecute(llm.TypedExecute(executeReplaceLines)).
+		Build()
+}
+
+func executeReplaceLines(ctx context.Context, args ReplaceLinesInput) (llm.ToolResultOutput, error) {
+	if args.Path == "" {
+		return llm.NewTextErrorResponse("path is required"), nil
+	}
+	start, err := strconv.Atoi(args.StartLine)
+	if err != nil {
+		return llm.NewTextErrorResponse("invalid start_line: must be a number"), nil
+	}
+	end, err := strconv.Atoi(args.EndLine)
+	if err != nil {
+		return llm.NewTextErrorResponse("invalid end_line: must be a number"), nil
+	}
+	args.Path = ResolvePath(ctx, args.Path)
+
+	info, err := os.Stat(args.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return llm.NewTextErrorResponse(fmt.Sprintf("file not found: %s", args.Path)), nil
+		}
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+	data, err := os.ReadFile(args.Path)
+	if err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+
+	lines := splitLines(string(data))
+	if err := checkLineRange(start, end, len(lines)); err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+	current := strings.Join(lines[start-1:end], "")
+	if args.ExpectedHash != "" {
+		if got := lineHashOf(current); got != args.ExpectedHash {
+			return llm.NewTextErrorResponse(staleRange(start, end, current, got, args.ExpectedHash)), nil
+		}
+	}
+
+	next := spliceLines(lines, start, end, args.NewContent)
+	if out := reviewChange(ctx, "replace_lines", args.Path, func() ([]byte, error) { return []byte(next), nil }); out != nil {
+		return out, nil
+	}
+	changes := changeSetFrom(ctx)
+	if err := changes.snapshot(args.Path); err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+	if err := os.WriteFile(args.Path, []byte(next), info.Mode().Perm()); err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
+	changes.record(args.Path)
+
+	added := len(splitLines(args.NewContent))
+	shift := added - (end - start + 1)
+	if end < start {
+		return llm.NewTextResponse(fmt.Sprintf("Inserted %s before line %d; later lines shift by %+d", countLines(added), start, shift)), nil
+	}
+	return llm.NewTextResponse(fmt.Sprintf("Replaced lines %d-%d with %s; later lines shift by %+d", start, end, countLines(added), shift)), nil
+}
+
+// countLines renders a line count: "1 line" or "3 lines".
+func countLines(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return strconv.Itoa(n) + " lines"
+}
+
+// checkLineRange validates start and end against a file of n lines. An
+// empty range, end = start - 1, marks an insertion before start.
+func checkLineRange(start, end, n int) error {
+	switch {
+	case start < 1:
+		return fmt.Errorf("start_line must be >= 1")
+	case end < start-1:
+		return fmt.Errorf("end_line must be >= start_line - 1")
+	case start > n+1:
+		return fmt.Errorf("start_line %d is past the end of the file (%d lines)", start, n)
+	case end > n:
+		return fmt.Errorf("end_line %d is past the end of the file (%d lines)", end, n)
+	}
+	return nil
+}
+
+// staleRange explains a hash mismatch and shows the lines as they are now.
+func staleRange(start, end int, current, got, want string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "lines %d-%d changed since they were read (hash %s, expected %s); nothing was written. They are now:\n", start, end, got, want)
+	for i, line := range splitLines(current) {
+		sb.WriteString(gutter(start + i))
+		sb.WriteString(line)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// spliceLines returns the file made of lines with lines start to end
+// replaced by content. content always ends its last line, unless it ends
+// the file and the file had no final newline.
+func spliceLines(lines []string, start, end int, content string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	before := strings.Join(lines[:start-1], "")
+	after := strings.Join(lines[end:], "")
+	n := len(lines)
+	if after == "" && n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		if content != "" && before != "" && !strings.HasSuffix(before, "\n") {
+			// Appending after an unterminated last line
+			before += "\n"
+		}
+		content = strings.TrimSuffix(content, "\n")
+		if content == "" {
+			before = strings.TrimSuffix(before, "\n")
+		}
+	}
+	return before + content + after
+}
+
+// splitLines splits s after each newline; the last line may lack one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// gutter is the prefix read_file puts before line n, e.g. "  42 | ".
+func gutter(n int) string {
+	return fmt.Sprintf("%4d | ", n)
+}
+
+// lineHash hashes the exact bytes of a range of lines, newlines included,
+// as read_file reports them for replace_lines' expected_hash.
+type lineHash struct{ h hash.Hash }
+
+func newLineHash() lineHash { return lineHash{sha256.New()} }
+
+func (l lineHash) Write(p []byte) { l.h.Write(p) }
+
+// Sum returns the first 8 hex digits of the hash, enough to tell a stale
+// range apart.
+func (l lineHash) Sum() string { return hex.EncodeToString(l.h.Sum(nil))[:8] }
+
+// lineHashOf hashes s like lineHash.
+func lineHashOf(s string) string {
+	h := newLineHash()
+	h.Write([]byte(s))
+	return h.Sum()
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func runReplaceLines(t *testing.T, args ReplaceLinesInput) llm.ToolResultOutput {
+	t.Helper()
+	input, _ := json.Marshal(args)
+	result, err := NewReplaceLinesTool().Execute(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestReplaceLinesBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		start, end string
+		newContent string
+		want       string
+	}{
+		{"first line", "a\nb\nc\n", "1", "1", "x", "x\nb\nc\n"},
+		{"last line", "a\nb\nc\n", "3", "3", "x\n", "a\nb\nx\n"},
+		{"middle range with more lines", "a\nb\nc\nd\n", "2", "3", "x\ny\nz", "a\nx\ny\nz\nd\n"},
+		{"delete", "a\nb\nc\n", "2", "2", "", "a\nc\n"},
+		{"insert before a line", "a\nb\n", "2", "1", "x", "a\nx\nb\n"},
+		{"append past the last line", "a\nb\n", "3", "2", "x", "a\nb\nx\n"},
+		{"keep a missing final newline", "a\nb", "2", "2", "x", "a\nx"},
+		{"append to an unterminated file", "a\nb", "3", "2", "x", "a\nb\nx"},
+		{"delete an unterminated last line", "a\nb", "2", "2", "", "a"},
+		{"insert into an empty file", "", "1", "0", "x", "x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "f.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			result := runReplaceLines(t, ReplaceLinesInput{Path: path, StartLine: tt.start, EndLine: tt.end, NewContent: tt.newContent})
+			if _, ok := result.(llm.ToolResultOutputText); !ok {
+				t.Fatalf("expected success, got %#v", result)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.want {
+				t.Errorf("file = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceLinesRejectsBadRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{"0", "1", "start_line must be >= 1"},
+		{"3", "1", "end_line must be >= start_line - 1"},
+		{"5", "4", "start_line 5 is past the end of the file (3 lines)"},
+		{"3", "4", "end_line 4 is past the end of the file (3 lines)"},
+		{"x", "1", "invalid start_line"},
+	}
+	for _, tt := range tests {
+		result := runReplaceLines(t, ReplaceLinesInput{Path: path, StartLine: tt.start, EndLine: tt.end, NewContent: "x"})
+		errResp, ok := result.(llm.ToolResultOutputError)
+		if !ok || !strings.Contains(errResp.Error, tt.want) {
+			t.Errorf("%s-%s: expected error %q, got %#v", tt.start, tt.end, tt.want, result)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\nc\n" {
+		t.Errorf("a rejected edit changed the file to %q", data)
+	}
+}
+
+func TestReplaceLinesRejectsStaleHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hash read_file reports for lines 2-3
+	input, _ := json.Marshal(ReadFileInput{Path: path, StartLine: "2", EndLine: "3"})
+	result, _ := NewReadFileTool().Execute(context.Background(), input)
+	_, note, _ := cutLastLine(result.(llm.ToolResultOutputText).Text)
+	hash := strings.TrimSuffix(strings.TrimPrefix(note, "[lines 2-3 hash "), "]")
+	if hash != lineHashOf("b\nc\n") {
+		t.Fatalf("read_file note = %q", note)
+	}
+
+	// Someone changes line 3 in the meantime
+	if err := os.WriteFile(path, []byte("a\nb\nC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result = runReplaceLines(t, ReplaceLinesInput{Path: path, StartLine: "2", EndLine: "3", NewContent: "x", ExpectedHash: hash})
+	errResp, ok := result.(llm.ToolResultOutputError)
+	if !ok || !strings.Contains(errResp.Error, "lines 2-3 changed since they were read") || !strings.HasSuffix(errResp.Error, "   2 | b\n   3 | C") {
+		t.Fatalf("expected a stale-range error with the current lines, got %#v", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\nC\n" {
+		t.Errorf("a stale edit changed the file to %q", data)
+	}
+
+	// The current hash is accepted
+	result = runReplaceLines(t, ReplaceLinesInput{Path: path, StartLine: "2", EndLine: "3", NewContent: "x", ExpectedHash: lineHashOf("b\nC\n")})
+	if text, ok := result.(llm.ToolResultOutputText); !ok || text.Text != "Replaced lines 2-3 with 1 line; later lines shift by -1" {
+		t.Errorf("got %#v", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nx\n" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func TestReplaceLinesDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	if err := os.WriteFile(path, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input, _ := json.Marshal(ReplaceLinesInput{Path: path, StartLine: "2", EndLine: "2", NewContent: "x"})
+	result, err := WithDryRun(NewReplaceLinesTool()).Execute(WithDryRunMode(context.Background(), true), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := result.(llm.ToolResultOutputText); !ok || !strings.Contains(text.Text, "-b\n+x") {
+		t.Errorf("expected the diff of the change, got %#v", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\n" {
+		t.Errorf("a dry run changed the file to %q", data)
+	}
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// Kinds of structured tool results (llm.ToolResultOutputData). Text results
+// stay llm.ToolResultOutputText.
+const (
+	ResultJSON  = "json"  // any JSON value
+	ResultFile  = "file"  // a FileRef
+	ResultTable = "table" // a Table
+)
+
+// FileRef refers to a file by path and size, with its content elided, for
+// results about a file the model already has, such as one it just wrote.
+type FileRef struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Table is a result of rows of named columns. The model gets it as compact
+// JSON; adaptors show it as aligned columns or an HTML table.
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// TextResult returns a plain text result.
+func TextResult(text string) llm.ToolResultOutput {
+	return llm.NewTextResponse(text)
+}
+
+// JSONResult returns v as a structured result of kind ResultJSON. A value
+// that cannot be marshaled is reported as the tool's error.
+func JSONResult(v any) llm.ToolResultOutput {
+	return dataResult(ResultJSON, v)
+}
+
+// FileResult returns a reference to the file at path holding size bytes.
+func FileResult(path string, size int64) llm.ToolResultOutput {
+	return dataResult(ResultFile, FileRef{Path: path, Bytes: size})
+}
+
+// TableResult returns rows of the named columns. Rows shorter than columns
+// are padded with empty cells.
+func TableResult(columns []string, rows [][]string) llm.ToolResultOutput {
+	table := Table{Columns: columns, Rows: make([][]string, len(rows))}
+	for i, row := range rows {
+		for len(row) < len(columns) {
+			row = append(row, "")
+		}
+		table.Rows[i] = row
+	}
+	return dataResult(ResultTable, table)
+}
+
+func dataResult(kind string, v any) llm.ToolResultOutput {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return llm.NewTextErrorResponse("failed to encode result: " + err.Error())
+	}
+	return llm.ToolResultOutputData{Type: "data", Kind: kind, Data: data}
+}
+
+// FormatResult renders a structured result as plain text for display: a
+// table as aligned columns, a file reference as its path and size, and any
+// other kind as indented JSON. Data that does not match its kind is shown
+// as it is.
+func FormatResult(kind string, data json.RawMessage) string {
+	switch kind {
+	case ResultFile:
+		var ref FileRef
+		if json.Unmarshal(data, &ref) == nil && ref.Path != "" {
+			return fmt.Sprintf("%s (%d bytes)", ref.Path, ref.Bytes)
+		}
+	case ResultTable:
+		var table Table
+		if json.Unmarshal(data, &table) == nil && len(table.Columns) > 0 {
+			return FormatTable(table)
+		}
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, data, "", "  ") == nil {
+		return indented.String()
+	}
+	return string(data)
+}
+
+// FormatTable aligns the columns of table under a header row.
+func FormatTable(table Table) string {
+	widths := make([]int, len(table.Columns))
+	rows := append([][]string{table.Columns}, table.Rows...)
+	for _, row := range rows {
+		for i := range widths {
+			if i < len(row) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+			}
+		}
+	}
+	var sb strings.Builder
+	for r, row := range rows {
+		if r > 0 {
+			sb.WriteByte('\n')
+		}
+		var line strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(cell)))
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestStructuredResultsAreCompactJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		output llm.ToolResultOutput
+		kind   string
+		data   string
+	}{
+		{"file", FileResult("/src/main.go", 1234), ResultFile, `{"path":"/src/main.go","bytes":1234}`},
+		{"table", TableResult([]string{"name", "size"}, [][]string{{"a.go", "10"}, {"b"}}), ResultTable,
+			`{"columns":["name","size"],"rows":[["a.go","10"],["b",""]]}`},
+		{"json", JSONResult(map[string]int{"matches": 3}), ResultJSON, `{"matches":3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, ok := tt.output.(llm.ToolResultOutputData)
+			if !ok {
+				t.Fatalf("got %#v, want structured output", tt.output)
+			}
+			if out.Kind != tt.kind || string(out.Data) != tt.data {
+				t.Errorf("got %s %s, want %s %s", out.Kind, out.Data, tt.kind, tt.data)
+			}
+		})
+	}
+
+	if _, ok := JSONResult(func() {}).(llm.ToolResultOutputError); !ok {
+		t.Error("JSONResult of an unencodable value should be an error")
+	}
+	if out, ok := TextResult("done").(llm.ToolResultOutputText); !ok || out.Text != "done" {
+		t.Errorf("TextResult = %#v", out)
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		data string
+		want string
+	}{
+		{"file", ResultFile, `{"path":"a.go","bytes":12}`, "a.go (12 bytes)"},
+		{"table", ResultTable, `{"columns":["name","size"],"rows":[["main.go","1024"],["ü","7"]]}`,
+			"name     size\nmain.go  1024\nü        7"},
+		{"json", ResultJSON, `{"a":[1,2]}`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"table that is not one", ResultTable, `[1]`, "[\n  1\n]"},
+		{"unknown kind", "chart", `"x"`, `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatResult(tt.kind, json.RawMessage(tt.data)); got != tt.want {
+				t.Errorf("FormatResult = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
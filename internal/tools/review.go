@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/diff"
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// DefaultReviewTimeout is how long a file change waits for the user's
+// decision before it is rejected.
+const DefaultReviewTimeout = 10 * time.Minute
+
+// Change is a file change, or a command, awaiting review.
+type Change struct {
+	Tool    string // write_file, edit_file, replace_lines, or posix_shell
+	Path    string
+	Diff    string // unified diff from the current content to the proposed one; "$ <command>" for a command
+	Command string // the command line of a posix_shell call an "ask" rule matched; "" for file changes
+}
+
+// Decision is the user's answer to a Change.
+type Decision struct {
+	Accepted bool
+	Reason   string // optional, for rejections
+}
+
+// Reviewer asks the user to accept or reject file changes. ReviewChange
+// blocks until the user decides or ctx ends.
+type Reviewer interface {
+	ReviewChange(ctx context.Context, change Change) (Decision, error)
+}
+
+type reviewerKey struct{}
+
+type reviewTimeoutKey struct{}
+
+// WithReviewer returns a context carrying the reviewer of file changes. The
+// file tools consult it only when wrapped with WithReview.
+func WithReviewer(ctx context.Context, r Reviewer) context.Context {
+	return context.WithValue(ctx, reviewerKey{}, r)
+}
+
+// WithReview wraps a file tool so that its changes wait for the reviewer in
+// the request context (see WithReviewer) before they are written. A change
+// without a decision within timeout is rejected; timeout <= 0 uses
+// DefaultReviewTimeout.
+func WithReview(tool llm.Tool, timeout time.Duration) llm.Tool {
+	if timeout <= 0 {
+		timeout = DefaultReviewTimeout
+	}
+	execute := tool.Execute
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		return execute(context.WithValue(ctx, reviewTimeoutKey{}, timeout), input)
+	}
+	return tool
+}
+
+// reviewChange shows the change from the current content of path to the
+// proposed one to the reviewer. It returns nil when the change may be
+// written: review is off, there is no reviewer, or the user accepted.
+// Otherwise it returns the tool's answer to the model; in a dry run, that is
+// the diff of the change. proposed is only called when the change is
+// reviewed or simulated; a deletion proposes nil.
+func reviewChange(ctx context.Context, tool, path string, proposed func() ([]byte, error)) llm.ToolResultOutput {
+	timeout, _ := ctx.Value(reviewTimeoutKey{}).(time.Duration)
+	reviewer, _ := ctx.Value(reviewerKey{}).(Reviewer)
+	if !dryRunFrom(ctx) && (timeout <= 0 || reviewer == nil) {
+		return nil
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return llm.NewTextErrorResponse(err.Error())
+	}
+	next, err := proposed()
+	if err != nil {
+		return llm.NewTextErrorResponse(err.Error())
+	}
+	if out := dryRunChange(ctx, path, current, next); out != nil {
+		return out
+	}
+	change := Change{Tool: tool, Path: path, Diff: diff.Unified(path, string(current), string(next))}
+	if change.Diff == "" {
+		// Nothing to review, e.g. write_file with the same content
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	decision, err := reviewer.ReviewChange(ctx, change)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return llm.NewTextErrorResponse(fmt.Sprintf("change rejected: the user did not review it within %s", timeout))
+	case err != nil:
+		return llm.NewTextErrorResponse(err.Error())
+	case !decision.Accepted && decision.Reason != "":
+		return llm.NewTextErrorResponse("change rejected by user: " + decision.Reason)
+	case !decision.Accepted:
+		return llm.NewTextErrorResponse("change rejected by user")
+	}
+	return nil
+}
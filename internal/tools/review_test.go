@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// fakeReviewer answers every change with decision, or waits for the end of
+// the context when wait is set.
+type fakeReviewer struct {
+	decision Decision
+	wait     bool
+	changes  []Change
+}
+
+func (r *fakeReviewer) ReviewChange(ctx context.Context, change Change) (Decision, error) {
+	r.changes = append(r.changes, change)
+	if r.wait {
+		<-ctx.Done()
+		return Decision{}, ctx.Err()
+	}
+	return r.decision, nil
+}
+
+func runReviewed(t *testing.T, tool llm.Tool, r Reviewer, input any) llm.ToolResultOutput {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	out, err := tool.Execute(WithReviewer(context.Background(), r), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return out
+}
+
+func TestReviewAcceptedWriteIsWritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	r := &fakeReviewer{decision: Decision{Accepted: true}}
+
+	out := runReviewed(t, WithReview(NewWriteFileTool(), time.Minute), r, WriteFileInput{Path: path, Content: "package main\n"})
+	if _, failed := out.(llm.ToolResultOutputError); failed {
+		t.Fatalf("expected success, got %#v", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n" {
+		t.Errorf("content = %q", data)
+	}
+	if len(r.changes) != 1 {
+		t.Fatalf("reviewed %d changes, want 1", len(r.changes))
+	}
+	if c := r.changes[0]; c.Tool != "write_file" || c.Path != path || !strings.Contains(c.Diff, "--- /dev/null\n") || !strings.Contains(c.Diff, "+package main\n") {
+		t.Errorf("change = %+v", c)
+	}
+}
+
+func TestReviewRejectedEditLeavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("x := 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeReviewer{decision: Decision{Reason: "use a constant"}}
+
+	out := runReviewed(t, WithReview(NewEditFileTool(), time.Minute), r, EditFileInput{Path: path, OldString: "x := 1", NewString: "x := 2"})
+	errOut, ok := out.(llm.ToolResultOutputError)
+	if !ok || errOut.Error != "change rejected by user: use a constant" {
+		t.Fatalf("expected the rejection, got %#v", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "x := 1\n" {
+		t.Errorf("rejected edit changed the file: %q", data)
+	}
+	if len(r.changes) != 1 || !strings.Contains(r.changes[0].Diff, "-x := 1\n+x := 2\n") {
+		t.Errorf("changes = %+v", r.changes)
+	}
+
+	// Temp files of the edit must not be left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only main.go", len(entries))
+	}
+}
+
+func TestReviewRejectedDiffEditLeavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("a\nb\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeReviewer{}
+
+	out := runReviewed(t, WithReview(NewEditFileTool(), time.Minute), r, EditFileInput{Path: path, Diff: "--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"})
+	if errOut, ok := out.(llm.ToolResultOutputError); !ok || errOut.Error != "change rejected by user" {
+		t.Fatalf("expected the rejection, got %#v", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\n" {
+		t.Errorf("rejected diff changed the file: %q", data)
+	}
+}
+
+func TestReviewTimeoutRejects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	r := &fakeReviewer{wait: true}
+
+	out := runReviewed(t, WithReview(NewWriteFileTool(), 20*time.Millisecond), r, WriteFileInput{Path: path, Content: "hello\n"})
+	errOut, ok := out.(llm.ToolResultOutputError)
+	if !ok || !strings.Contains(errOut.Error, "did not review it within 20ms") {
+		t.Fatalf("expected a timeout rejection, got %#v", out)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the file was written without a decision")
+	}
+}
+
+func TestReviewOnlyWhenWrapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	r := &fakeReviewer{}
+
+	runReviewed(t, NewWriteFileTool(), r, WriteFileInput{Path: path, Content: "hello\n"})
+	if len(r.changes) != 0 {
+		t.Error("a tool without WithReview asked for review")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "hello\n" {
+		t.Errorf("content = %q", data)
+	}
+
+	// Writing the same content again is no change to review
+	runReviewed(t, WithReview(NewWriteFileTool(), time.Minute), r, WriteFileInput{Path: path, Content: "hello\n"})
+	if len(r.changes) != 0 {
+		t.Error("an unchanged file was sent for review")
+	}
+}
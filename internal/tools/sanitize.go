@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizeOutput makes command output safe to show and to send to the model.
+// Commands run with TERM=dumb and NO_COLOR=1, but some color anyway, such as
+// ls --color=always or git -c color.ui=always. ANSI escape sequences (SGR
+// colors, cursor movement, OSC titles and hyperlinks) are removed, a carriage
+// return becomes a newline so progress bars read as lines, and any other
+// control character except \n and \t is escaped as \xNN.
+func SanitizeOutput(s string) string {
+	if !strings.ContainsFunc(s, isUnsafeControl) {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == 0x1b:
+			i = skipEscape(s, i)
+		case c == '\r':
+			sb.WriteByte('\n')
+			i++
+			if i < len(s) && s[i] == '\n' {
+				i++
+			}
+		case c < 0x20 && c != '\n' && c != '\t', c == 0x7f:
+			fmt.Fprintf(&sb, `\x%02x`, c)
+			i++
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// EscapeControls makes a string the model controls, such as a tool's input,
+// a file path, or a skill name, safe to echo to a terminal while keeping
+// what it holds visible, so a call cannot retitle the terminal, move the
+// cursor, or hide what it ran. ESC shows as ␛, so a sequence reads as ␛[2J
+// instead of clearing the screen; other C0 controls and DEL show as their
+// control pictures (␇, ␍, ␡); C1 controls, which some terminals obey too,
+// as \u009b; and bytes that are not UTF-8 as \xNN. Newlines and tabs are
+// kept. The web UI escapes the same way (escapeControls in chat.js).
+func EscapeControls(s string) string {
+	if !needsEscape(s) {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s) + 8)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case r == '\n' || r == '\t':
+			sb.WriteRune(r)
+		case r < 0x20:
+			sb.WriteRune(0x2400 + r)
+		case r == 0x7f:
+			sb.WriteRune('\u2421')
+		case r >= 0x80 && r < 0xa0:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// needsEscape reports whether EscapeControls would change s.
+func needsEscape(s string) bool {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || isUnsafeControl(r) || (r >= 0x80 && r < 0xa0) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+func isUnsafeControl(r rune) bool {
+	return (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f
+}
+
+// skipEscape returns the index just past the escape sequence starting at
+// s[i], which is ESC. An unterminated sequence runs to the end of s.
+func skipEscape(s string, i int) int {
+	i++
+	if i >= len(s) {
+		return i
+	}
+	switch s[i] {
+	case '[':
+		// CSI: parameters and intermediates, then a final byte in 0x40-0x7E
+		for i++; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return i
+	case ']', 'P', 'X', '^', '_':
+		// OSC, DCS, SOS, PM, APC: a string ended by BEL or ST (ESC \)
+		for i++; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return i
+	default:
+		// Two-byte sequences such as ESC 7 or ESC =
+		return i + 1
+	}
+}
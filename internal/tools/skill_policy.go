@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/skills"
+)
+
+type skillPolicyKey struct{}
+
+// WithActiveSkill returns a context carrying the skill policy of the session
+// making the request, which activate_skill changes and WithSkillPolicy
+// consults.
+func WithActiveSkill(ctx context.Context, policy *skills.Policy) context.Context {
+	return context.WithValue(ctx, skillPolicyKey{}, policy)
+}
+
+// skillPolicyFrom returns the skill policy carried by ctx, or nil.
+func skillPolicyFrom(ctx context.Context) *skills.Policy {
+	policy, _ := ctx.Value(skillPolicyKey{}).(*skills.Policy)
+	return policy
+}
+
+// WithSkillPolicy wraps a tool so calls are refused when the active skill's
+// allowed-tools list does not include it. The policy in the request context
+// (see WithActiveSkill) is consulted on every call, so activating another
+// skill or deactivating lifts the restriction.
+func WithSkillPolicy(tool llm.Tool) llm.Tool {
+	execute := tool.Execute
+	name := tool.Definition.Name
+	tool.Execute = func(ctx context.Context, input json.RawMessage) (llm.ToolResultOutput, error) {
+		if err := skillPolicyFrom(ctx).Check(name); err != nil {
+			return llm.NewTextErrorResponse(err.Error()), nil
+		}
+		return execute(ctx, input)
+	}
+	return tool
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/skills"
+)
+
+func newRestrictedSkillManager(t *testing.T) *skills.Manager {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for name, allowed := range map[string]string{"read-only": "read_file", "open": ""} {
+		skillDir := filepath.Join(tmpDir, name)
+		if err := os.Mkdir(skillDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "---\nname: " + name + "\ndescription: test skill\n"
+		if allowed != "" {
+			content += "allowed-tools: " + allowed + "\n"
+		}
+		content += "---\n\n# " + name + "\n"
+		if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := skills.NewManager([]string{tmpDir})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	return m
+}
+
+func runShell(t *testing.T, ctx context.Context, tool llm.Tool) llm.ToolResultOutput {
+	t.Helper()
+	input, _ := json.Marshal(PosixShellInput{Command: "echo hi"})
+	result, err := tool.Execute(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSkillPolicyRefusesDisallowedTools(t *testing.T) {
+	m := newRestrictedSkillManager(t)
+	ctx := WithActiveSkill(context.Background(), skills.NewPolicy())
+	shell := WithSkillPolicy(NewPosixShellTool())
+	activate := WithSkillPolicy(NewActivateSkillTool(m))
+
+	input, _ := json.Marshal(ActivateSkillInput{Name: "read-only"})
+	if _, err := activate.Execute(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+
+	result := runShell(t, ctx, shell)
+	errResp, ok := result.(llm.ToolResultOutputError)
+	if !ok {
+		t.Fatalf("expected error response, got %T", result)
+	}
+	if !strings.Contains(errResp.Error, "read-only") || !strings.Contains(errResp.Error, "read_file") {
+		t.Errorf("error should name the skill and its allow-list, got %q", errResp.Error)
+	}
+
+	// Allowed tools still run
+	tmpFile := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(tmpFile, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readFile := WithSkillPolicy(NewReadFileTool())
+	readInput, _ := json.Marshal(ReadFileInput{Path: tmpFile, LineNumbers: new(false)})
+	if result, _ := readFile.Execute(ctx, readInput); result != (llm.ToolResultOutputText{Type: "text", Text: "x"}) {
+		t.Errorf("read_file should be allowed, got %#v", result)
+	}
+
+	// Another session's requests are not restricted
+	other := WithActiveSkill(context.Background(), skills.NewPolicy())
+	if _, ok := runShell(t, other, shell).(llm.ToolResultOutputText); !ok {
+		t.Error("the skill of one session restricted another")
+	}
+}
+
+func TestSkillPolicyLiftsOnSwitchAndDeactivate(t *testing.T) {
+	m := newRestrictedSkillManager(t)
+	policy := skills.NewPolicy()
+	ctx := WithActiveSkill(context.Background(), policy)
+	shell := WithSkillPolicy(NewPosixShellTool())
+
+	if _, err := m.ActivateSkill("read-only", policy); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := runShell(t, ctx, shell).(llm.ToolResultOutputError); !ok {
+		t.Fatal("posix_shell should be refused under read-only skill")
+	}
+
+	// Activating a skill without allowed-tools lifts the restriction
+	if _, err := m.ActivateSkill("open", policy); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := runShell(t, ctx, shell).(llm.ToolResultOutputText); !ok {
+		t.Error("posix_shell should be allowed after switching skills")
+	}
+
+	if _, err := m.ActivateSkill("read-only", policy); err != nil {
+		t.Fatal(err)
+	}
+	if name := policy.Deactivate(); name != "read-only" {
+		t.Errorf("Deactivate returned %q, want read-only", name)
+	}
+	if _, ok := runShell(t, ctx, shell).(llm.ToolResultOutputText); !ok {
+		t.Error("posix_shell should be allowed after deactivation")
+	}
+}
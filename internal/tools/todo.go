@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// TodoItem is one step of the model's plan.
+type TodoItem struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// TodoList is the plan kept by manage_todo. Each session owns one and hands
+// it to the tool through the context of its requests (see WithTodoList), so
+// sessions sharing the tool never see each other's plans.
+type TodoList struct {
+	mu       sync.Mutex
+	items    []TodoItem
+	nextID   int
+	onChange func(items []TodoItem) // called after every change, without the lock held
+}
+
+// NewTodoList creates an empty list. onChange, when not nil, receives the
+// list after every change.
+func NewTodoList(onChange func(items []TodoItem)) *TodoList {
+	return &TodoList{nextID: 1, onChange: onChange}
+}
+
+// Add appends an item per text and returns the list.
+func (l *TodoList) Add(texts ...string) []TodoItem {
+	l.mu.Lock()
+	for _, text := range texts {
+		l.items = append(l.items, TodoItem{ID: l.nextID, Text: text})
+		l.nextID++
+	}
+	items := l.snapshot()
+	l.mu.Unlock()
+	l.changed(items)
+	return items
+}
+
+// Complete marks item id done and returns the list.
+func (l *TodoList) Complete(id int) ([]TodoItem, error) {
+	l.mu.Lock()
+	i := l.index(id)
+	if i < 0 {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("no todo item %d", id)
+	}
+	l.items[i].Done = true
+	items := l.snapshot()
+	l.mu.Unlock()
+	l.changed(items)
+	return items, nil
+}
+
+// Remove deletes item id and returns the list. The other items keep their
+// numbers.
+func (l *TodoList) Remove(id int) ([]TodoItem, error) {
+	l.mu.Lock()
+	i := l.index(id)
+	if i < 0 {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("no todo item %d", id)
+	}
+	l.items = append(l.items[:i], l.items[i+1:]...)
+	items := l.snapshot()
+	l.mu.Unlock()
+	l.changed(items)
+	return items, nil
+}
+
+// Clear empties the list and restarts numbering at 1.
+func (l *TodoList) Clear() {
+	l.mu.Lock()
+	l.items = nil
+	l.nextID = 1
+	l.mu.Unlock()
+	l.changed(nil)
+}
+
+// Items returns a copy of the list.
+func (l *TodoList) Items() []TodoItem {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.snapshot()
+}
+
+func (l *TodoList) index(id int) int {
+	for i, item := range l.items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *TodoList) snapshot() []TodoItem {
+	return append([]TodoItem(nil), l.items...)
+}
+
+func (l *TodoList) changed(items []TodoItem) {
+	if l.onChange != nil {
+		l.onChange(items)
+	}
+}
+
+// FormatTodoList renders items as a checkbox list, one item per line:
+// "[x] 1. Read the code".
+func FormatTodoList(items []TodoItem) string {
+	if len(items) == 0 {
+		return "(no items)"
+	}
+	var sb strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		box := "[ ]"
+		if item.Done {
+			box = "[x]"
+		}
+		fmt.Fprintf(&sb, "%s %d. %s", box, item.ID, item.Text)
+	}
+	return sb.String()
+}
+
+type todoListKey struct{}
+
+// WithTodoList returns a context carrying the list manage_todo works on.
+func WithTodoList(ctx context.Context, list *TodoList) context.Context {
+	return context.WithValue(ctx, todoListKey{}, list)
+}
+
+// todoListFrom returns the list carried by ctx, or nil.
+func todoListFrom(ctx context.Context) *TodoList {
+	list, _ := ctx.Value(todoListKey{}).(*TodoList)
+	return list
+}
+
+// ManageTodoInput represents the input for the manage_todo tool
+type ManageTodoInput struct {
+	Operation string   `json:"operation" jsonschema:"required,description=What to do with the list,enum=add|complete|remove|list"`
+	Items     []string `json:"items,omitempty" jsonschema:"description=For add: the steps to append, in order"`
+	ID        int      `json:"id,omitempty" jsonschema:"description=For complete and remove: the item number"`
+}
+
+// NewManageTodoTool creates a tool for keeping a plan of multi-step work.
+// The list comes from the request context; see WithTodoList.
+func NewManageTodoTool() llm.Tool {
+	return llm.NewTool(
+		"manage_todo",
+		"Keep a checklist of the steps of a multi-step task. Add the steps before starting, complete each as it is done, and list to check what is left. Every operation returns the whole list.",
+	).
+		WithSchema(llm.GenerateSchema(ManageTodoInput{})).
+		WithExecute(llm.TypedExecute(func(ctx context.Context, args ManageTodoInput) (llm.ToolResultOutput, error) {
+			list := todoListFrom(ctx)
+			if list == nil {
+				return llm.NewTextErrorResponse("no todo list is available in this session"), nil
+			}
+
+			var items []TodoItem
+			var err error
+			switch args.Operation {
+			case "add":
+				if len(args.Items) == 0 {
+					return llm.NewTextErrorResponse("add needs at least one item"), nil
+				}
+				items = list.Add(args.Items...)
+			case "complete":
+				items, err = list.Complete(args.ID)
+			case "remove":
+				items, err = list.Remove(args.ID)
+			case "list":
+				items = list.Items()
+			default:
+				return llm.NewTextErrorResponse(fmt.Sprintf("unknown operation %q: use add, complete, remove, or list", args.Operation)), nil
+			}
+			if err != nil {
+				return llm.NewTextErrorResponse(err.Error()), nil
+			}
+			return llm.NewTextResponse(FormatTodoList(items)), nil
+		})).
+		Build()
+}
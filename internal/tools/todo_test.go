@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func runTodo(t *testing.T, ctx context.Context, input ManageTodoInput) llm.ToolResultOutput {
+	t.Helper()
+	inputJSON, _ := json.Marshal(input)
+	result, err := NewManageTodoTool().Execute(ctx, inputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestManageTodoOperations(t *testing.T) {
+	var changes [][]TodoItem
+	list := NewTodoList(func(items []TodoItem) { changes = append(changes, items) })
+	ctx := WithTodoList(context.Background(), list)
+
+	tests := []struct {
+		input ManageTodoInput
+		want  string
+	}{
+		{ManageTodoInput{Operation: "add", Items: []string{"Read the code", "Fix the bug"}}, "[ ] 1. Read the code\n[ ] 2. Fix the bug"},
+		{ManageTodoInput{Operation: "complete", ID: 1}, "[x] 1. Read the code\n[ ] 2. Fix the bug"},
+		{ManageTodoInput{Operation: "add", Items: []string{"Run the tests"}}, "[x] 1. Read the code\n[ ] 2. Fix the bug\n[ ] 3. Run the tests"},
+		{ManageTodoInput{Operation: "remove", ID: 2}, "[x] 1. Read the code\n[ ] 3. Run the tests"},
+		{ManageTodoInput{Operation: "list"}, "[x] 1. Read the code\n[ ] 3. Run the tests"},
+	}
+	for _, tt := range tests {
+		result := runTodo(t, ctx, tt.input)
+		text, ok := result.(llm.ToolResultOutputText)
+		if !ok {
+			t.Fatalf("%s: expected text response, got %#v", tt.input.Operation, result)
+		}
+		if text.Text != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.input.Operation, text.Text, tt.want)
+		}
+	}
+
+	// list is the only operation that changes nothing
+	if len(changes) != 4 {
+		t.Errorf("onChange called %d times, want 4", len(changes))
+	}
+	if last := changes[len(changes)-1]; len(last) != 2 || last[1].ID != 3 {
+		t.Errorf("last change = %+v", last)
+	}
+
+	list.Clear()
+	if len(changes) != 5 || len(changes[4]) != 0 {
+		t.Errorf("Clear should report an empty list, got %+v", changes)
+	}
+	if items := list.Add("again"); items[0].ID != 1 {
+		t.Errorf("numbering should restart after Clear, got %+v", items)
+	}
+}
+
+func TestManageTodoErrors(t *testing.T) {
+	ctx := WithTodoList(context.Background(), NewTodoList(nil))
+	inputs := []ManageTodoInput{
+		{Operation: "complete", ID: 9},
+		{Operation: "remove", ID: 9},
+		{Operation: "add"},
+		{Operation: "reorder"},
+	}
+	for _, input := range inputs {
+		if _, ok := runTodo(t, ctx, input).(llm.ToolResultOutputError); !ok {
+			t.Errorf("%+v: expected an error response", input)
+		}
+	}
+
+	// Without a session list there is nothing to manage
+	if _, ok := runTodo(t, context.Background(), ManageTodoInput{Operation: "list"}).(llm.ToolResultOutputError); !ok {
+		t.Error("expected an error response without a list in the context")
+	}
+}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Workdir is the working directory of a session (see WithWorkdir). The file
+// tools resolve relative paths against it, and posix_shell and git run in it.
+type Workdir struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// NewWorkdir creates a working directory starting at dir, which should be
+// absolute.
+func NewWorkdir(dir string) *Workdir {
+	return &Workdir{dir: filepath.Clean(dir)}
+}
+
+// Get returns the directory.
+func (w *Workdir) Get() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.dir
+}
+
+// Set changes to path, which may start with "~" or be relative to the
+// current directory. It returns the new directory, or an error when path is
+// not an existing directory.
+func (w *Workdir) Set(path string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir := expandHome(path)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(w.dir, dir)
+	}
+	dir = filepath.Clean(dir)
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "", fmt.Errorf("no such directory: %s", dir)
+	case err != nil:
+		return "", err
+	case !info.IsDir():
+		return "", fmt.Errorf("not a directory: %s", dir)
+	}
+	w.dir = dir
+	return dir, nil
+}
+
+type workdirKey struct{}
+
+// WithWorkdir returns a context carrying the working directory of the tool
+// calls made with it.
+func WithWorkdir(ctx context.Context, w *Workdir) context.Context {
+	return context.WithValue(ctx, workdirKey{}, w)
+}
+
+// workdirFrom returns the working directory carried by ctx, or nil.
+func workdirFrom(ctx context.Context) *Workdir {
+	w, _ := ctx.Value(workdirKey{}).(*Workdir)
+	return w
+}
+
+// ResolvePath joins a relative path to the working directory carried by ctx,
+// after expanding a leading "~". Without a working directory in ctx, a
+// relative path stays relative to the process's directory. A path with ".."
+// is resolved through its symlinks, as PathPolicy.Check sees it, so the tools
+// open the file that was checked.
+func ResolvePath(ctx context.Context, path string) string {
+	if path == "" {
+		return path
+	}
+	w := workdirFrom(ctx)
+	if w != nil {
+		path = expandHome(path)
+		if !filepath.IsAbs(path) {
+			path = w.Get() + string(filepath.Separator) + path
+		}
+	}
+	if !slices.Contains(pathElements(path), "..") {
+		if w == nil {
+			return path
+		}
+		return filepath.Clean(path)
+	}
+	if !filepath.IsAbs(path) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return path
+		}
+		path = cwd + string(filepath.Separator) + path
+	}
+	return resolvePath(path)
+}
+
+// commandDir returns the directory commands run in: the working directory
+// carried by ctx, or else the process's.
+func commandDir(ctx context.Context) string {
+	if w := workdirFrom(ctx); w != nil {
+		return w.Get()
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "." // fallback to current directory
+	}
+	return cwd
+}
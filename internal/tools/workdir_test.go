@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+func TestWorkdirSet(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "file"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorkdir(root)
+
+	// Relative paths are relative to the current directory
+	if dir, err := w.Set("sub"); err != nil || dir != filepath.Join(root, "sub") {
+		t.Fatalf("Set(sub) = %q, %v", dir, err)
+	}
+	if dir, err := w.Set(".."); err != nil || dir != root {
+		t.Fatalf("Set(..) = %q, %v", dir, err)
+	}
+
+	for path, want := range map[string]string{"missing": "no such directory", "file": "not a directory"} {
+		if _, err := w.Set(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%s) error = %v, want %q", path, err, want)
+		}
+	}
+	if w.Get() != root {
+		t.Errorf("a failed Set moved the directory to %q", w.Get())
+	}
+}
+
+func TestToolsUseWorkdir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithWorkdir(context.Background(), NewWorkdir(root))
+
+	execute := func(tool llm.Tool, input any) string {
+		t.Helper()
+		data, _ := json.Marshal(input)
+		out, err := tool.Execute(ctx, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch out := out.(type) {
+		case llm.ToolResultOutputText:
+			return out.Text
+		case llm.ToolResultOutputData:
+			return string(out.Data)
+		}
+		t.Fatalf("%s failed: %#v", tool.Definition.Name, out)
+		return ""
+	}
+
+	if got := execute(NewReadFileTool(), ReadFileInput{Path: "notes.txt", LineNumbers: new(false)}); got != "hello" {
+		t.Errorf("read_file = %q", got)
+	}
+	execute(NewWriteFileTool(), WriteFileInput{Path: "new.txt", Content: "written"})
+	if data, err := os.ReadFile(filepath.Join(root, "new.txt")); err != nil || string(data) != "written" {
+		t.Errorf("write_file wrote %q (%v) in the working directory", data, err)
+	}
+	execute(NewEditFileTool(), EditFileInput{Path: "new.txt", OldString: "written", NewString: "edited"})
+	if data, _ := os.ReadFile(filepath.Join(root, "new.txt")); string(data) != "edited" {
+		t.Errorf("edit_file left %q", data)
+	}
+
+	if got := execute(NewPosixShellToolWithShell(DefaultShell), PosixShellInput{Command: "cat notes.txt"}); !strings.Contains(got, "hello") {
+		t.Errorf("posix_shell did not run in the working directory: %q", got)
+	}
+}
@@ -17,23 +17,32 @@ type WriteFileInput struct {
 func NewWriteFileTool() llm.Tool {
 	return llm.NewTool(
 		"write_file",
-		"Create a new file or replace the entire content of an existing file.",
+		"Create a new file or replace the entire content of an existing file. Returns the path and size written.",
 	).
 		WithSchema(llm.GenerateSchema(WriteFileInput{})).
 		WithExecute(llm.TypedExecute(executeWriteFile)).
 		Build()
 }
 
-func executeWriteFile(_ context.Context, args WriteFileInput) (llm.ToolResultOutput, error) {
+func executeWriteFile(ctx context.Context, args WriteFileInput) (llm.ToolResultOutput, error) {
 	if args.Path == "" {
 		return llm.NewTextErrorResponse("path is required"), nil
 	}
 	if args.Content == "" {
 		return llm.NewTextErrorResponse("content is required"), nil
 	}
+	args.Path = ResolvePath(ctx, args.Path)
 
+	if out := reviewChange(ctx, "write_file", args.Path, func() ([]byte, error) { return []byte(args.Content), nil }); out != nil {
+		return out, nil
+	}
+	changes := changeSetFrom(ctx)
+	if err := changes.snapshot(args.Path); err != nil {
+		return llm.NewTextErrorResponse(err.Error()), nil
+	}
 	if err := os.WriteFile(args.Path, []byte(args.Content), 0600); err != nil {
 		return llm.NewTextErrorResponse(err.Error()), nil
 	}
-	return llm.NewTextResponse("File written successfully"), nil
+	changes.record(args.Path)
+	return FileResult(args.Path, int64(len(args.Content))), nil
 }
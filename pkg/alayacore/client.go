@@ -0,0 +1,232 @@
+package alayacore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// SummarizePrompt is the instruction Summarize sends to the model.
+const SummarizePrompt = "Please summarize the conversation above in a concise manner. Return ONLY the summary, no introductions or explanations."
+
+// Config configures a Client.
+type Config struct {
+	// Provider is the model to talk to. When nil, one is created from
+	// ProviderConfig.
+	Provider       Provider
+	ProviderConfig ProviderConfig
+
+	Tools             []Tool
+	SystemPrompt      string
+	ExtraSystemPrompt string // Appended to SystemPrompt by the provider
+	MaxSteps          int    // Maximum agent loop steps per prompt (default: 100)
+}
+
+// Result is the outcome of a prompt.
+type Result struct {
+	Text      string    // Assistant text of the final step
+	Messages  []Message // Messages the prompt added to the history
+	Usage     Usage     // Tokens spent across all steps
+	Truncated bool      // The final reply stopped at the output token limit
+}
+
+// Client runs the agent loop against one model and keeps the conversation
+// history. Prompts on one Client run one at a time.
+type Client struct {
+	agent    *llm.Agent
+	provider Provider
+
+	run     sync.Mutex // serializes prompts
+	mu      sync.Mutex // guards history and usage
+	history []Message
+	usage   Usage
+}
+
+// New creates a Client.
+func New(cfg Config) (*Client, error) {
+	provider := cfg.Provider
+	if provider == nil {
+		var err error
+		if provider, err = NewProvider(cfg.ProviderConfig); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		provider: provider,
+		agent: llm.NewAgent(llm.AgentConfig{
+			Provider:          provider,
+			Tools:             cfg.Tools,
+			SystemPrompt:      cfg.SystemPrompt,
+			ExtraSystemPrompt: cfg.ExtraSystemPrompt,
+			MaxSteps:          cfg.MaxSteps,
+		}),
+	}, nil
+}
+
+// Provider returns the client's model provider.
+func (c *Client) Provider() Provider {
+	return c.provider
+}
+
+// Prompt sends a user prompt and waits for the final reply.
+func (c *Client) Prompt(ctx context.Context, prompt string) (Result, error) {
+	return c.prompt(ctx, prompt, nil)
+}
+
+// Stream sends a user prompt and delivers events to h as they arrive. On
+// error or cancellation, the steps that completed stay in the history.
+func (c *Client) Stream(ctx context.Context, prompt string, h Handler) error {
+	_, err := c.prompt(ctx, prompt, h)
+	return err
+}
+
+func (c *Client) prompt(ctx context.Context, prompt string, h Handler) (Result, error) {
+	c.run.Lock()
+	defer c.run.Unlock()
+
+	user := NewUserMessage(prompt)
+	c.mu.Lock()
+	c.history = append(c.history, user)
+	history := append([]Message(nil), c.history...)
+	c.mu.Unlock()
+
+	result, err := c.StreamHistory(ctx, history, func(ev Event) error {
+		if finish, ok := ev.(StepFinish); ok {
+			c.mu.Lock()
+			c.history = append(c.history, finish.Messages...)
+			c.mu.Unlock()
+		}
+		if h != nil {
+			return h(ev)
+		}
+		return nil
+	})
+	c.mu.Lock()
+	c.usage.add(result.Usage)
+	c.mu.Unlock()
+	return result, err
+}
+
+// StreamHistory runs the agent loop on an explicit history, leaving the
+// client's own history and usage untouched. The messages each step adds are
+// delivered as StepFinish events and collected in the Result. Use it to keep
+// the history yourself, as the alayacore CLI does.
+func (c *Client) StreamHistory(ctx context.Context, history []Message, h Handler) (Result, error) {
+	var result Result
+	step := 0
+	emit := func(ev Event) error {
+		if h == nil {
+			return nil
+		}
+		return h(ev)
+	}
+
+	final, err := c.agent.Stream(ctx, history, llm.StreamCallbacks{
+		OnTextDelta: func(delta string) error {
+			return emit(TextDelta{Text: delta})
+		},
+		OnReasoningDelta: func(delta string) error {
+			return emit(Reasoning{Text: delta})
+		},
+		OnToolCall: func(id, name string, input json.RawMessage) error {
+			return emit(ToolCall{ID: id, Name: name, Input: input})
+		},
+		OnToolResult: func(id string, output llm.ToolResultOutput) error {
+			return emit(ToolResult{ID: id, Output: output})
+		},
+		OnStepStart: func(n int) error {
+			step = n
+			return emit(StepStart{Step: n})
+		},
+		OnStepFinish: func(messages []llm.Message, usage llm.Usage) error {
+			stepUsage := usageFrom(usage)
+			result.Usage.add(stepUsage)
+			result.Messages = append(result.Messages, messages...)
+			if text := assistantText(messages); text != "" {
+				result.Text = text
+			}
+			if err := emit(stepUsage); err != nil {
+				return err
+			}
+			return emit(StepFinish{Step: step, Messages: messages})
+		},
+	})
+	if final != nil {
+		result.Truncated = final.Truncated
+	}
+	return result, err
+}
+
+// History returns a copy of the conversation history.
+func (c *Client) History() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.history...)
+}
+
+// SetHistory replaces the conversation history, e.g. to resume a saved one.
+func (c *Client) SetHistory(messages []Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append([]Message(nil), messages...)
+}
+
+// Reset clears the conversation history.
+func (c *Client) Reset() {
+	c.SetHistory(nil)
+}
+
+// TotalUsage returns the tokens spent by all prompts on this client.
+func (c *Client) TotalUsage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// Summarize asks the model to summarize the conversation and replaces the
+// history with the summary, freeing context for further prompts.
+func (c *Client) Summarize(ctx context.Context) (string, error) {
+	c.run.Lock()
+	defer c.run.Unlock()
+
+	c.mu.Lock()
+	if len(c.history) == 0 {
+		c.mu.Unlock()
+		return "", errors.New("nothing to summarize")
+	}
+	history := append(append([]Message(nil), c.history...), NewUserMessage(SummarizePrompt))
+	c.mu.Unlock()
+
+	result, err := c.StreamHistory(ctx, history, nil)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.add(result.Usage)
+	if err != nil {
+		return "", err
+	}
+	if result.Text == "" {
+		return "", errors.New("model returned an empty summary")
+	}
+	c.history = []Message{llm.NewAssistantMessage([]ContentPart{llm.TextPart{Type: "text", Text: result.Text}})}
+	return result.Text, nil
+}
+
+// assistantText joins the text parts of the assistant messages.
+func assistantText(messages []Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		if msg.Role != llm.RoleAssistant {
+			continue
+		}
+		for _, part := range msg.Content {
+			if text, ok := part.(llm.TextPart); ok {
+				sb.WriteString(text.Text)
+			}
+		}
+	}
+	return sb.String()
+}
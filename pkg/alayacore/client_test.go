@@ -0,0 +1,68 @@
+package alayacore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alayacore/alayacore/pkg/alayacore"
+)
+
+func TestStreamHistoryLeavesClientHistory(t *testing.T) {
+	client, err := alayacore.New(alayacore.Config{Provider: &fakeModel{replies: []string{"ok"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var finished []alayacore.Message
+	result, err := client.StreamHistory(context.Background(), []alayacore.Message{alayacore.NewUserMessage("hi")}, func(ev alayacore.Event) error {
+		if e, ok := ev.(alayacore.StepFinish); ok {
+			finished = append(finished, e.Messages...)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "ok" || len(result.Messages) != 1 || len(finished) != 1 {
+		t.Errorf("unexpected result %+v, step messages %+v", result, finished)
+	}
+	if len(client.History()) != 0 {
+		t.Errorf("StreamHistory must not touch the client history, got %+v", client.History())
+	}
+	if usage := client.TotalUsage(); usage.InputTokens != 0 {
+		t.Errorf("StreamHistory must not count toward client usage, got %+v", usage)
+	}
+}
+
+func TestStreamHandlerErrorAborts(t *testing.T) {
+	client, err := alayacore.New(alayacore.Config{Provider: &fakeModel{replies: []string{"ok"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("stop")
+	err = client.Stream(context.Background(), "hi", func(alayacore.Event) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	// The prompt stays in the history; the aborted step does not
+	if history := client.History(); len(history) != 1 || history[0].Role != alayacore.RoleUser {
+		t.Errorf("unexpected history after abort: %+v", history)
+	}
+}
+
+func TestSummarizeEmptyHistory(t *testing.T) {
+	client, err := alayacore.New(alayacore.Config{Provider: &fakeModel{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Summarize(context.Background()); err == nil {
+		t.Error("expected an error summarizing an empty history")
+	}
+}
+
+func TestNewRequiresKnownProvider(t *testing.T) {
+	if _, err := alayacore.New(alayacore.Config{ProviderConfig: alayacore.ProviderConfig{Type: "unknown"}}); err == nil {
+		t.Error("expected an error for an unknown provider type")
+	}
+}
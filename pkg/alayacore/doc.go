@@ -0,0 +1,20 @@
+// Package alayacore is the public API for embedding the AlayaCore agent loop
+// in other Go programs.
+//
+// A Client pairs a model provider with tools and a system prompt and keeps the
+// conversation history:
+//
+//	client, err := alayacore.New(alayacore.Config{
+//		ProviderConfig: alayacore.ProviderConfig{Type: "anthropic", APIKey: key, Model: "claude-sonnet-4-5"},
+//		Tools:          []alayacore.Tool{myTool},
+//		SystemPrompt:   "You are a helpful assistant.",
+//	})
+//	result, err := client.Prompt(ctx, "Hello!")
+//
+// Stream delivers typed events (TextDelta, Reasoning, ToolCall, ToolResult,
+// StepStart, Usage, StepFinish) to a Handler as they arrive. The alayacore CLI
+// and web server drive their sessions through the same Client.
+//
+// Message, Tool, and the other conversation types are aliases of the types the
+// providers use, so values can be passed between them without conversion.
+package alayacore
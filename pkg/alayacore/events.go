@@ -0,0 +1,105 @@
+package alayacore
+
+import (
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/llm"
+)
+
+// Event is a streaming event delivered to a Handler. It is one of TextDelta,
+// Reasoning, ToolCall, ToolResult, StepStart, Usage, or StepFinish.
+type Event interface {
+	isEvent()
+}
+
+// Handler receives streaming events. Returning an error aborts the stream.
+type Handler func(Event) error
+
+// TextDelta is a chunk of assistant text.
+type TextDelta struct {
+	Text string
+}
+
+// Reasoning is a chunk of model reasoning (thinking) text.
+type Reasoning struct {
+	Text string
+}
+
+// ToolCall is a tool invocation requested by the model, sent before it runs.
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input json.RawMessage
+}
+
+// ToolResult is the output of a finished tool call.
+type ToolResult struct {
+	ID     string
+	Output ToolResultOutput
+}
+
+// IsError reports whether the tool call failed.
+func (r ToolResult) IsError() bool {
+	_, ok := r.Output.(llm.ToolResultOutputError)
+	return ok
+}
+
+// Text returns the tool output or error message; structured output is its
+// JSON, as the model sees it.
+func (r ToolResult) Text() string {
+	switch o := r.Output.(type) {
+	case llm.ToolResultOutputText:
+		return o.Text
+	case llm.ToolResultOutputData:
+		return string(o.Data)
+	case llm.ToolResultOutputError:
+		return o.Error
+	}
+	return ""
+}
+
+// StepStart marks the start of an agent step (one model request); steps are
+// numbered from 1.
+type StepStart struct {
+	Step int
+}
+
+// Usage reports token usage. As an event it covers one step; in a Result it
+// is the total for the prompt.
+type Usage struct {
+	InputTokens         int64
+	OutputTokens        int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+}
+
+// StepFinish carries the messages a step added to the history: the assistant
+// reply and, for tool-using steps, the tool results.
+type StepFinish struct {
+	Step     int
+	Messages []Message
+}
+
+func (TextDelta) isEvent()  {}
+func (Reasoning) isEvent()  {}
+func (ToolCall) isEvent()   {}
+func (ToolResult) isEvent() {}
+func (StepStart) isEvent()  {}
+func (Usage) isEvent()      {}
+func (StepFinish) isEvent() {}
+
+func usageFrom(u llm.Usage) Usage {
+	return Usage{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheReadTokens:     u.CacheReadTokens,
+		CacheCreationTokens: u.CacheCreationTokens,
+	}
+}
+
+func (u *Usage) add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheReadTokens += o.CacheReadTokens
+	u.CacheCreationTokens += o.CacheCreationTokens
+}
@@ -0,0 +1,124 @@
+package alayacore_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alayacore/alayacore/pkg/alayacore"
+)
+
+// fakeModel is a scripted Provider: each request gets the next reply. A reply
+// starting with "call:" requests the named tool with an empty input.
+type fakeModel struct {
+	replies []string
+}
+
+func (m *fakeModel) StreamMessages(_ context.Context, _ []alayacore.Message, _ []alayacore.ToolDefinition, _, _ string) (<-chan alayacore.ProviderStreamEvent, error) {
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+
+	events := make(chan alayacore.ProviderStreamEvent, 3)
+	var part alayacore.ContentPart
+	if len(reply) > 5 && reply[:5] == "call:" {
+		call := alayacore.ProviderToolCallEvent{ToolCallID: "call_1", ToolName: reply[5:], Input: json.RawMessage(`{}`)}
+		events <- call
+		part = alayacore.ToolCallPart{Type: "tool_use", ToolCallID: call.ToolCallID, ToolName: call.ToolName, Input: call.Input}
+	} else {
+		events <- alayacore.ProviderTextDeltaEvent{Delta: reply}
+		part = alayacore.TextPart{Type: "text", Text: reply}
+	}
+	events <- alayacore.ProviderStepCompleteEvent{
+		Messages: []alayacore.Message{{Role: alayacore.RoleAssistant, Content: []alayacore.ContentPart{part}}},
+		Usage:    alayacore.ProviderUsage{InputTokens: 10, OutputTokens: 5},
+	}
+	close(events)
+	return events, nil
+}
+
+func ExampleClient_Prompt() {
+	client, err := alayacore.New(alayacore.Config{
+		Provider:     &fakeModel{replies: []string{"Hello! How can I help?"}},
+		SystemPrompt: "You are a helpful assistant.",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	result, err := client.Prompt(context.Background(), "Hi")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(result.Text)
+	fmt.Println("tokens:", result.Usage.InputTokens+result.Usage.OutputTokens)
+	fmt.Println("history:", len(client.History()))
+	// Output:
+	// Hello! How can I help?
+	// tokens: 15
+	// history: 2
+}
+
+func ExampleClient_Stream() {
+	type timeInput struct {
+		Zone string `json:"zone" jsonschema:"description=IANA time zone"`
+	}
+	clock := alayacore.NewTool("clock", "Tell the current time").
+		WithSchema(alayacore.GenerateSchema(timeInput{})).
+		WithExecute(alayacore.TypedExecute(func(_ context.Context, _ timeInput) (alayacore.ToolResultOutput, error) {
+			return alayacore.NewTextResponse("12:00"), nil
+		})).
+		Build()
+
+	client, err := alayacore.New(alayacore.Config{
+		Provider: &fakeModel{replies: []string{"call:clock", "It is 12:00."}},
+		Tools:    []alayacore.Tool{clock},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = client.Stream(context.Background(), "What time is it?", func(ev alayacore.Event) error {
+		switch e := ev.(type) {
+		case alayacore.TextDelta:
+			fmt.Println("text:", e.Text)
+		case alayacore.ToolCall:
+			fmt.Println("call:", e.Name)
+		case alayacore.ToolResult:
+			fmt.Println("result:", e.Text())
+		case alayacore.Usage:
+			fmt.Println("usage:", e.InputTokens, e.OutputTokens)
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// call: clock
+	// result: 12:00
+	// usage: 10 5
+	// text: It is 12:00.
+	// usage: 10 5
+}
+
+func ExampleClient_Summarize() {
+	client, err := alayacore.New(alayacore.Config{
+		Provider: &fakeModel{replies: []string{"Paris.", "The user asked for the capital of France: Paris."}},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := client.Prompt(context.Background(), "Capital of France?"); err != nil {
+		panic(err)
+	}
+	summary, err := client.Summarize(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(summary)
+	fmt.Println("history:", len(client.History()))
+	// Output:
+	// The user asked for the capital of France: Paris.
+	// history: 1
+}
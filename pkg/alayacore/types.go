@@ -0,0 +1,98 @@
+package alayacore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alayacore/alayacore/internal/llm"
+	"github.com/alayacore/alayacore/internal/llm/factory"
+)
+
+// Conversation types.
+type (
+	Message          = llm.Message
+	MessageRole      = llm.MessageRole
+	ContentPart      = llm.ContentPart
+	TextPart         = llm.TextPart
+	ReasoningPart    = llm.ReasoningPart
+	ImagePart        = llm.ImagePart
+	ToolCallPart     = llm.ToolCallPart
+	ToolResultPart   = llm.ToolResultPart
+	ToolResultOutput = llm.ToolResultOutput
+	ToolResultText   = llm.ToolResultOutputText
+	ToolResultError  = llm.ToolResultOutputError
+	ToolResultData   = llm.ToolResultOutputData
+)
+
+// Message roles.
+const (
+	RoleUser      = llm.RoleUser
+	RoleAssistant = llm.RoleAssistant
+	RoleTool      = llm.RoleTool
+)
+
+// Tool, provider, and provider stream types. Implement Provider to plug in
+// another model; it streams Provider*Event values and ends each step with a
+// ProviderStepCompleteEvent.
+type (
+	Tool                        = llm.Tool
+	ToolDefinition              = llm.ToolDefinition
+	ToolBuilder                 = llm.ToolBuilder
+	TypedExecuteFunc[T any]     = llm.TypedExecuteFunc[T]
+	SamplingOptions             = llm.SamplingOptions
+	ProviderConfig              = factory.ProviderConfig
+	Provider                    = llm.Provider
+	ProviderStreamEvent         = llm.StreamEvent
+	ProviderTextDeltaEvent      = llm.TextDeltaEvent
+	ProviderReasoningDeltaEvent = llm.ReasoningDeltaEvent
+	ProviderToolCallEvent       = llm.ToolCallEvent
+	ProviderStepCompleteEvent   = llm.StepCompleteEvent
+	ProviderUsage               = llm.Usage
+	ProviderErrorEvent          = llm.StreamErrorEvent
+)
+
+// Errors returned when a provider's stream carries no answer: a step with
+// neither text nor tool calls, or a stream that closes before the step
+// completes. The failed step is not added to the history.
+var (
+	ErrEmptyResponse      = llm.ErrEmptyResponse
+	ErrIncompleteResponse = llm.ErrIncompleteResponse
+)
+
+// NewUserMessage creates a user message with a single text part.
+func NewUserMessage(text string) Message {
+	return llm.NewUserMessage(text)
+}
+
+// NewTool starts building a tool with the given name and description.
+func NewTool(name, description string) *ToolBuilder {
+	return llm.NewTool(name, description)
+}
+
+// GenerateSchema derives a tool's JSON schema from a struct's json and
+// jsonschema tags.
+func GenerateSchema(v any) json.RawMessage {
+	return llm.GenerateSchema(v)
+}
+
+// TypedExecute adapts a function taking decoded arguments into a tool's
+// Execute function.
+func TypedExecute[T any](fn TypedExecuteFunc[T]) func(ctx context.Context, input json.RawMessage) (ToolResultOutput, error) {
+	return llm.TypedExecute(fn)
+}
+
+// NewTextResponse returns a successful tool result.
+func NewTextResponse(text string) ToolResultOutput {
+	return llm.NewTextResponse(text)
+}
+
+// NewTextErrorResponse returns a failed tool result; the model sees the message.
+func NewTextErrorResponse(msg string) ToolResultOutput {
+	return llm.NewTextErrorResponse(msg)
+}
+
+// NewProvider creates an "anthropic", "openai", "ollama", "openrouter", or
+// "azure" provider from its config. An unknown type is an error.
+func NewProvider(config ProviderConfig) (Provider, error) {
+	return factory.NewProvider(config)
+}
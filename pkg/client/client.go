@@ -0,0 +1,147 @@
+// Package client drives alayacore-web --stdio as a subprocess, for programs
+// and editor integrations that want an agent without the WebSocket server.
+//
+//	c, err := client.Start("alayacore-web", "--model-config", path)
+//	if err != nil { ... }
+//	defer c.Close()
+//	c.Prompt("Explain main.go")
+//	frames, err := c.ReadTurn()
+//
+// Frames use the TLV tags described at /protocol.json; the common ones are
+// re-exported here.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/alayacore/alayacore/internal/stream"
+)
+
+// Tags of the frames a client usually sends or handles.
+const (
+	TagTextUser       = stream.TagTextUser
+	TagTextAssistant  = stream.TagTextAssistant
+	TagTextReasoning  = stream.TagTextReasoning
+	TagFunctionCall   = stream.TagFunctionCall
+	TagFunctionResult = stream.TagFunctionResult
+	TagToolResult     = stream.TagToolResult
+	TagSystemError    = stream.TagSystemError
+	TagSystemNotify   = stream.TagSystemNotify
+	TagSystemData     = stream.TagSystemData
+	TagTurnEnd        = stream.TagTurnEnd
+	TagTime           = stream.TagTime
+	TagReview         = stream.TagReview
+	TagHello          = stream.TagHello
+)
+
+// Frame is one TLV frame from the agent.
+type Frame struct {
+	Tag   string
+	Value string
+}
+
+// Client is a running agent subprocess.
+type Client struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	frames *stream.FrameReader
+
+	mu sync.Mutex // serializes writes to stdin
+}
+
+// Start runs the alayacore-web binary at path in stdio mode, with args added
+// after --stdio.
+func Start(path string, args ...string) (*Client, error) {
+	return StartCommand(exec.Command(path, append([]string{"--stdio"}, args...)...))
+}
+
+// StartCommand runs cmd, which must speak the stdio protocol, and takes over
+// its stdin and stdout. Its stderr is left as configured.
+func StartCommand(cmd *exec.Cmd) (*Client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &Client{cmd: cmd, stdin: stdin, stdout: stdout, frames: stream.NewFrameReader(stdout)}, nil
+}
+
+// Send writes a frame to the agent.
+func (c *Client) Send(tag, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.stdin.Write(stream.EncodeTLV(tag, value))
+	return err
+}
+
+// Prompt sends a prompt, or a command when text starts with ':'.
+func (c *Client) Prompt(text string) error {
+	return c.Send(TagTextUser, text)
+}
+
+// Next reads the next frame. It returns io.EOF once the agent has exited.
+func (c *Client) Next() (Frame, error) {
+	tag, value, err := c.frames.Next()
+	if err != nil {
+		return Frame{}, err
+	}
+	return Frame{Tag: tag, Value: value}, nil
+}
+
+// ReadTurn reads frames up to and including the next TagTurnEnd, which ends
+// every task, whether it succeeded, failed, or was canceled.
+func (c *Client) ReadTurn() ([]Frame, error) {
+	var frames []Frame
+	for {
+		f, err := c.Next()
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, f)
+		if f.Tag == TagTurnEnd {
+			return frames, nil
+		}
+	}
+}
+
+// CloseInput closes the agent's stdin. The agent finishes the tasks already
+// queued, whose frames Next still returns, then exits; Next then returns
+// io.EOF.
+func (c *Client) CloseInput() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.stdin.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// Wait waits for the agent to exit. Call it after Next has returned io.EOF.
+func (c *Client) Wait() error {
+	if err := c.cmd.Wait(); err != nil {
+		return fmt.Errorf("agent exited: %w", err)
+	}
+	return nil
+}
+
+// Close closes the agent's stdin, discards the output of the tasks still
+// queued, and waits for the agent to exit.
+func (c *Client) Close() error {
+	if err := c.CloseInput(); err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, c.stdout) //nolint:errcheck // draining until exit
+	return c.Wait()
+}
@@ -0,0 +1,248 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alayacore/alayacore/internal/adaptors/stdio"
+	"github.com/alayacore/alayacore/internal/app"
+	"github.com/alayacore/alayacore/internal/config"
+	domainerrors "github.com/alayacore/alayacore/internal/errors"
+	"github.com/alayacore/alayacore/pkg/client"
+)
+
+// The test binary doubles as the agent: started with helperEnv set, it runs
+// what alayacore-web --stdio runs.
+const helperEnv = "STDIO_CLIENT_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		os.Exit(runAgent())
+	}
+	os.Exit(m.Run())
+}
+
+func runAgent() int {
+	cfg, err := config.Parse()
+	if err != nil {
+		return exit(domainerrors.ConfigError(err))
+	}
+	appCfg, err := app.Setup(cfg)
+	if err != nil {
+		return exit(domainerrors.ConfigError(err))
+	}
+	if err := stdio.NewAdaptor(appCfg, cfg.Handshake, os.Stdin, os.Stdout).Run(); err != nil {
+		return exit(err)
+	}
+	return domainerrors.ExitOK
+}
+
+// exit reports err as alayacore-web does and returns its exit code.
+func exit(err error) int {
+	fmt.Fprintln(os.Stderr, domainerrors.Summary(err))
+	return domainerrors.ExitCode(err)
+}
+
+// startAgent starts the agent against a fake OpenAI-compatible model that
+// answers every prompt with reply.
+func startAgent(t *testing.T, reply string, args ...string) *client.Client {
+	t.Helper()
+	return startAgentWith(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]string{"content": reply}}}})
+		_, _ = io.WriteString(w, "data: "+string(chunk)+"\n\ndata: [DONE]\n\n")
+	}), os.Stderr, args...)
+}
+
+// startAgentWith starts the agent against a fake model served by handler,
+// with its stderr going to stderr.
+func startAgentWith(t *testing.T, handler http.Handler, stderr io.Writer, args ...string) *client.Client {
+	t.Helper()
+	model := httptest.NewServer(handler)
+	t.Cleanup(model.Close)
+
+	dir := t.TempDir()
+	modelConfig := filepath.Join(dir, "model.conf")
+	conf := fmt.Sprintf("---\nname: fake\nprotocol_type: openai\nbase_url: %s\napi_key: test\nmodel_name: fake\n---\n", model.URL)
+	if err := os.WriteFile(modelConfig, []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], append([]string{"--stdio",
+		"--model-config", modelConfig,
+		"--runtime-config", filepath.Join(dir, "runtime.conf"),
+	}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), helperEnv+"=1", "HOME="+dir, "USERPROFILE="+dir)
+	cmd.Stderr = stderr
+	c, err := client.StartCommand(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPromptOverStdio(t *testing.T) {
+	c := startAgent(t, "hello from the fake model")
+	if err := c.Prompt("hi"); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := c.ReadTurn()
+	if err != nil {
+		t.Fatalf("ReadTurn: %v (frames %v)", err, frames)
+	}
+
+	var answer strings.Builder
+	for _, f := range frames {
+		if f.Tag == client.TagTextAssistant {
+			answer.WriteString(f.Value)
+		}
+	}
+	if !strings.Contains(answer.String(), "hello from the fake model") {
+		t.Errorf("no answer in %v", frames)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestQueuedPromptFinishesAfterStdinCloses(t *testing.T) {
+	c := startAgent(t, "done")
+	if err := c.Prompt("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CloseInput(); err != nil {
+		t.Fatal(err)
+	}
+
+	sawTurnEnd := false
+	for {
+		f, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sawTurnEnd = sawTurnEnd || f.Tag == client.TagTurnEnd
+	}
+	if !sawTurnEnd {
+		t.Error("the agent exited before finishing the prompt")
+	}
+	if err := c.Wait(); err != nil {
+		t.Errorf("exit: %v", err)
+	}
+}
+
+func TestHandshakeOverStdio(t *testing.T) {
+	c := startAgent(t, "unused", "--handshake")
+	defer c.Close()
+
+	f, err := c.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hello struct {
+		Protocol int    `json:"protocol"`
+		Server   string `json:"server"`
+	}
+	if f.Tag != client.TagHello || json.Unmarshal([]byte(f.Value), &hello) != nil || hello.Server == "" {
+		t.Fatalf("first frame = %+v, want the hello", f)
+	}
+
+	if err := c.Send(client.TagHello, `{"protocol":1}`); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		f, err := c.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Tag == client.TagHello {
+			if f.Value != `{"protocol":1}` {
+				t.Errorf("confirmation = %s", f.Value)
+			}
+			return
+		}
+	}
+}
+
+// exitCode returns the exit code in the error of Wait or Close.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+func TestExitCodes(t *testing.T) {
+	unauthorized := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+	})
+	// hanging answers nothing until the request is canceled
+	hanging := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		args    []string
+		prompt  string
+		cancel  bool // send :cancel once the prompt starts
+		want    int
+		summary string
+	}{
+		{"provider error", unauthorized, nil, "hi", false, domainerrors.ExitError, "error[error]: "},
+		{"config error", unauthorized, []string{"--max-steps", "zero"}, "", false, domainerrors.ExitConfig, "error[config]: "},
+		{"canceled", hanging, nil, "hi", true, domainerrors.ExitCanceled, "error[canceled]: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr strings.Builder
+			c := startAgentWith(t, tt.handler, &stderr, tt.args...)
+			if tt.prompt != "" {
+				if err := c.Prompt(tt.prompt); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.cancel {
+				for {
+					f, err := c.Next()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if f.Tag == client.TagTextUser {
+						break
+					}
+				}
+				if err := c.Prompt(":cancel"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := c.Close()
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exit code = %d (%v), want %d", got, err, tt.want)
+			}
+			lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+			if last := lines[len(lines)-1]; !strings.HasPrefix(last, tt.summary) {
+				t.Errorf("stderr ends with %q, want a %q summary", last, tt.summary)
+			}
+		})
+	}
+}